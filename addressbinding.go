@@ -0,0 +1,83 @@
+package bacnet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// AddressBinding is an entry of the Device_Address_Binding of a Device object: the address a
+// device uses to reach another device.
+type AddressBinding struct {
+	DeviceID uint32 `json:"deviceId"`
+	// Network is the BACnet network number; 0 is the local network.
+	Network uint16 `json:"network"`
+	MAC     []byte `json:"mac"`
+}
+
+// NewIPAddressBinding returns a binding to a BACnet/IP device on the local network.
+func NewIPAddressBinding(deviceID uint32, addr *net.UDPAddr) AddressBinding {
+	mac := make([]byte, 6)
+	copy(mac, addr.IP.To4())
+	binary.BigEndian.PutUint16(mac[4:], uint16(addr.Port))
+	return AddressBinding{DeviceID: deviceID, MAC: mac}
+}
+
+// UDPAddr returns the address of a BACnet/IP binding. It reports false for MAC addresses that are
+// not six bytes long, e.g. MS/TP stations behind a router.
+func (b AddressBinding) UDPAddr() (*net.UDPAddr, bool) {
+	if len(b.MAC) != 6 {
+		return nil, false
+	}
+	return &net.UDPAddr{IP: net.IP(b.MAC[:4]), Port: int(binary.BigEndian.Uint16(b.MAC[4:]))}, true
+}
+
+func (b AddressBinding) encode(buf *bytes.Buffer) {
+	encoding.EncodeApplicationObjectID(buf, uint32(OBJECT_DEVICE), b.DeviceID)
+	encoding.EncodeApplicationUnsigned(buf, uint32(b.Network))
+	encoding.EncodeApplicationOctetString(buf, b.MAC)
+}
+
+// ReadAddressBindings reads the Device_Address_Binding of a device.
+func (c *BACnetClient) ReadAddressBindings(device DeviceInfo, opts ...CallOption) ([]AddressBinding, error) {
+	deviceObject := BACnetObject{Type: OBJECT_DEVICE, Instance: device.DeviceID}
+	value, err := c.readProperty(callContext(opts...), device, deviceObject, PROP_DEVICE_ADDRESS_BINDING, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAddressBindings(value)
+}
+
+// WriteAddressBindings replaces the Device_Address_Binding of a device with static bindings. Most
+// devices treat the property as read-only; the error of those devices is returned as is.
+func (c *BACnetClient) WriteAddressBindings(device DeviceInfo, bindings []AddressBinding, opts ...CallOption) error {
+	deviceObject := BACnetObject{Type: OBJECT_DEVICE, Instance: device.DeviceID}
+	return c.WriteProperty(device, deviceObject, PROP_DEVICE_ADDRESS_BINDING, bindings, 0, opts...)
+}
+
+// decodeAddressBindings decodes a Device_Address_Binding as returned by readProperty: a flat list
+// of device identifier, network number and MAC address triples.
+func decodeAddressBindings(value interface{}) ([]AddressBinding, error) {
+	elements, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected address binding %v (%T)", value, value)
+	}
+	if len(elements)%3 != 0 {
+		return nil, fmt.Errorf("address binding list has %d elements, want a multiple of 3", len(elements))
+	}
+
+	bindings := make([]AddressBinding, 0, len(elements)/3)
+	for i := 0; i < len(elements); i += 3 {
+		device, ok1 := elements[i].(BACnetObject)
+		network, ok2 := elements[i+1].(uint32)
+		mac, ok3 := elements[i+2].([]byte)
+		if !ok1 || !ok2 || !ok3 || device.Type != OBJECT_DEVICE || network > 0xFFFF {
+			return nil, fmt.Errorf("invalid address binding %v", elements[i:i+3])
+		}
+		bindings = append(bindings, AddressBinding{DeviceID: device.Instance, Network: uint16(network), MAC: mac})
+	}
+	return bindings, nil
+}
@@ -0,0 +1,309 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventType is the type of event algorithm that generated an event notification.
+type EventType uint32
+
+const (
+	EventChangeOfBitstring       EventType = 0
+	EventChangeOfState           EventType = 1
+	EventChangeOfValue           EventType = 2
+	EventCommandFailure          EventType = 3
+	EventFloatingLimit           EventType = 4
+	EventOutOfRange              EventType = 5
+	EventChangeOfLifeSafety      EventType = 8
+	EventExtended                EventType = 9
+	EventBufferReady             EventType = 10
+	EventUnsignedRange           EventType = 11
+	EventAccessEvent             EventType = 13
+	EventDoubleOutOfRange        EventType = 14
+	EventSignedOutOfRange        EventType = 15
+	EventUnsignedOutOfRange      EventType = 16
+	EventChangeOfCharacterString EventType = 17
+	EventChangeOfStatusFlags     EventType = 18
+	EventChangeOfReliability     EventType = 19
+	EventNone                    EventType = 20
+	EventChangeOfDiscreteValue   EventType = 21
+	EventChangeOfTimer           EventType = 22
+)
+
+var eventTypeNames = map[EventType]string{
+	EventChangeOfBitstring:       "change-of-bitstring",
+	EventChangeOfState:           "change-of-state",
+	EventChangeOfValue:           "change-of-value",
+	EventCommandFailure:          "command-failure",
+	EventFloatingLimit:           "floating-limit",
+	EventOutOfRange:              "out-of-range",
+	EventChangeOfLifeSafety:      "change-of-life-safety",
+	EventExtended:                "extended",
+	EventBufferReady:             "buffer-ready",
+	EventUnsignedRange:           "unsigned-range",
+	EventAccessEvent:             "access-event",
+	EventDoubleOutOfRange:        "double-out-of-range",
+	EventSignedOutOfRange:        "signed-out-of-range",
+	EventUnsignedOutOfRange:      "unsigned-out-of-range",
+	EventChangeOfCharacterString: "change-of-characterstring",
+	EventChangeOfStatusFlags:     "change-of-status-flags",
+	EventChangeOfReliability:     "change-of-reliability",
+	EventNone:                    "none",
+	EventChangeOfDiscreteValue:   "change-of-discrete-value",
+	EventChangeOfTimer:           "change-of-timer",
+}
+
+func (t EventType) String() string { return enumName(eventTypeNames, t) }
+
+// EventState is the Event_State of an object.
+type EventState uint32
+
+const (
+	EventStateNormal          EventState = 0
+	EventStateFault           EventState = 1
+	EventStateOffnormal       EventState = 2
+	EventStateHighLimit       EventState = 3
+	EventStateLowLimit        EventState = 4
+	EventStateLifeSafetyAlarm EventState = 5
+)
+
+var eventStateNames = map[EventState]string{
+	EventStateNormal:          "normal",
+	EventStateFault:           "fault",
+	EventStateOffnormal:       "offnormal",
+	EventStateHighLimit:       "high-limit",
+	EventStateLowLimit:        "low-limit",
+	EventStateLifeSafetyAlarm: "life-safety-alarm",
+}
+
+func (s EventState) String() string { return enumName(eventStateNames, s) }
+
+// NotifyType tells alarms, events and acknowledgment notifications apart.
+type NotifyType uint32
+
+const (
+	NotifyAlarm           NotifyType = 0
+	NotifyEvent           NotifyType = 1
+	NotifyAckNotification NotifyType = 2
+)
+
+var notifyTypeNames = map[NotifyType]string{
+	NotifyAlarm:           "alarm",
+	NotifyEvent:           "event",
+	NotifyAckNotification: "ack-notification",
+}
+
+func (t NotifyType) String() string { return enumName(notifyTypeNames, t) }
+
+// EventNotification is an alarm or event reported by a device.
+type EventNotification struct {
+	ProcessID         uint32       `json:"processId"`
+	InitiatingDevice  BACnetObject `json:"initiatingDevice"`
+	EventObject       BACnetObject `json:"eventObject"`
+	Time              time.Time    `json:"time"`
+	NotificationClass uint32       `json:"notificationClass"`
+	// Priority is the BACnet event priority; lower values are more urgent.
+	Priority    uint8      `json:"priority"`
+	EventType   EventType  `json:"eventType"`
+	MessageText string     `json:"messageText,omitempty"`
+	NotifyType  NotifyType `json:"notifyType"`
+	AckRequired bool       `json:"ackRequired"`
+	FromState   EventState `json:"fromState"`
+	ToState     EventState `json:"toState"`
+	// Values are the event values, specific to the event type; nil for ack notifications.
+	Values EventValues `json:"values,omitempty"`
+}
+
+// AlarmHandler delivers an event notification somewhere, e.g. to a log, a message broker or a
+// mailbox.
+type AlarmHandler interface {
+	HandleAlarm(ctx context.Context, n EventNotification) error
+}
+
+// AlarmHandlerFunc adapts a function to an AlarmHandler.
+type AlarmHandlerFunc func(ctx context.Context, n EventNotification) error
+
+func (f AlarmHandlerFunc) HandleAlarm(ctx context.Context, n EventNotification) error {
+	return f(ctx, n)
+}
+
+// AlarmRule routes the event notifications it matches to its handlers. Empty match fields match
+// everything.
+type AlarmRule struct {
+	Name        string
+	Devices     []uint32
+	ObjectTypes []ObjectType
+	EventTypes  []EventType
+	ToStates    []EventState
+	NotifyTypes []NotifyType
+	// MinPriority and MaxPriority bound the event priority. A zero MaxPriority means 255.
+	MinPriority uint8
+	MaxPriority uint8
+	Handlers    []AlarmHandler
+	// RateLimit is the minimum time between two notifications of the same object routed by this
+	// rule; notifications arriving sooner are dropped. Zero disables rate limiting.
+	RateLimit time.Duration
+	// Final stops routing to the rules after this one when it matches.
+	Final bool
+}
+
+// Matches reports whether the rule applies to n.
+func (r AlarmRule) Matches(n EventNotification) bool {
+	maxPriority := r.MaxPriority
+	if maxPriority == 0 {
+		maxPriority = 255
+	}
+	return matchAny(r.Devices, n.InitiatingDevice.Instance) &&
+		matchAny(r.ObjectTypes, n.EventObject.Type) &&
+		matchAny(r.EventTypes, n.EventType) &&
+		matchAny(r.ToStates, n.ToState) &&
+		matchAny(r.NotifyTypes, n.NotifyType) &&
+		n.Priority >= r.MinPriority && n.Priority <= maxPriority
+}
+
+func matchAny[T comparable](values []T, v T) bool {
+	return len(values) == 0 || slices.Contains(values, v)
+}
+
+// AlarmRouter routes event notifications to handlers according to an ordered list of rules. It is
+// safe for concurrent use.
+type AlarmRouter struct {
+	mu       sync.Mutex
+	rules    []AlarmRule
+	lastSent map[alarmRateKey]time.Time
+}
+
+type alarmRateKey struct {
+	rule   int
+	device uint32
+	object BACnetObject
+}
+
+// NewAlarmRouter returns a router with the given rules.
+func NewAlarmRouter(rules ...AlarmRule) *AlarmRouter {
+	return &AlarmRouter{rules: rules, lastSent: make(map[alarmRateKey]time.Time)}
+}
+
+// AddRule appends a rule.
+func (r *AlarmRouter) AddRule(rule AlarmRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, rule)
+}
+
+// Route hands n to the handlers of every matching rule, in rule order, until a Final rule matched.
+// Handler errors are joined; a failing handler does not stop the others.
+func (r *AlarmRouter) Route(ctx context.Context, n EventNotification) error {
+	var handlers []AlarmHandler
+	now := time.Now()
+
+	r.mu.Lock()
+	for i, rule := range r.rules {
+		if !rule.Matches(n) {
+			continue
+		}
+		if rule.RateLimit > 0 {
+			key := alarmRateKey{rule: i, device: n.InitiatingDevice.Instance, object: n.EventObject}
+			if last, ok := r.lastSent[key]; ok && now.Sub(last) < rule.RateLimit {
+				if rule.Final {
+					break
+				}
+				continue
+			}
+			r.lastSent[key] = now
+		}
+		handlers = append(handlers, rule.Handlers...)
+		if rule.Final {
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h.HandleAlarm(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LogAlarmHandler writes notifications to logger, or to the standard logger if it is nil.
+func LogAlarmHandler(logger *log.Logger) AlarmHandler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return AlarmHandlerFunc(func(_ context.Context, n EventNotification) error {
+		logger.Printf("%s %s on device %d %s: %s -> %s (priority %d) %s",
+			n.NotifyType, n.EventType, n.InitiatingDevice.Instance, n.EventObject, n.FromState, n.ToState, n.Priority, n.MessageText)
+		return nil
+	})
+}
+
+// WebhookAlarmHandler posts notifications as JSON to url. A nil client uses http.DefaultClient.
+func WebhookAlarmHandler(url string, client *http.Client) AlarmHandler {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return AlarmHandlerFunc(func(ctx context.Context, n EventNotification) error {
+		body, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("webhook %s failed: %w", url, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s returned %s", url, resp.Status)
+		}
+		return nil
+	})
+}
+
+// AlarmPublisher publishes a message on a topic, e.g. an MQTT client.
+type AlarmPublisher interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+}
+
+// PublishAlarmHandler publishes notifications as JSON through publisher. topic returns the topic of
+// a notification.
+func PublishAlarmHandler(publisher AlarmPublisher, topic func(EventNotification) string) AlarmHandler {
+	return AlarmHandlerFunc(func(ctx context.Context, n EventNotification) error {
+		payload, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		return publisher.Publish(ctx, topic(n), payload)
+	})
+}
+
+// AlarmMailer sends an email, e.g. through an SMTP relay.
+type AlarmMailer interface {
+	SendMail(ctx context.Context, to []string, subject, body string) error
+}
+
+// EmailAlarmHandler mails notifications to the given recipients through mailer.
+func EmailAlarmHandler(mailer AlarmMailer, to ...string) AlarmHandler {
+	return AlarmHandlerFunc(func(ctx context.Context, n EventNotification) error {
+		subject := fmt.Sprintf("BACnet %s: %s on device %d %s", n.NotifyType, n.ToState, n.InitiatingDevice.Instance, n.EventObject)
+		body := fmt.Sprintf("Time: %s\nEvent type: %s\nTransition: %s -> %s\nPriority: %d\nNotification class: %d\n\n%s\n",
+			n.Time.Format(time.RFC3339), n.EventType, n.FromState, n.ToState, n.Priority, n.NotificationClass, n.MessageText)
+		return mailer.SendMail(ctx, to, subject, body)
+	})
+}
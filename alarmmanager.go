@@ -0,0 +1,437 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ActiveAlarm is an alarm an AlarmManager tracks: an object whose event state is not normal or
+// that has transitions waiting for acknowledgment.
+type ActiveAlarm struct {
+	DeviceID uint32       `json:"deviceId"`
+	Object   BACnetObject `json:"object"`
+	State    EventState   `json:"state"`
+	// Time is the time stamp of the transition to State.
+	Time time.Time `json:"time"`
+	// Priority is the event priority of the transition to State; lower values are more urgent.
+	Priority   uint8      `json:"priority"`
+	NotifyType NotifyType `json:"notifyType"`
+	// AckedTransitions and TimeStamps are indexed by transition: to-offnormal, to-fault and
+	// to-normal.
+	AckedTransitions [3]bool      `json:"ackedTransitions"`
+	TimeStamps       [3]time.Time `json:"timeStamps"`
+	// EventType, NotificationClass and MessageText are those of the last notification received;
+	// GetEventInformation does not report them.
+	EventType         EventType `json:"eventType"`
+	NotificationClass uint32    `json:"notificationClass"`
+	MessageText       string    `json:"messageText,omitempty"`
+
+	// stamps are the encoded time stamps reported by GetEventInformation, for acknowledgments.
+	stamps [3][]byte
+}
+
+// Acked reports whether the transition to the current state is acknowledged.
+func (a ActiveAlarm) Acked() bool {
+	return a.AckedTransitions[transitionOf(a.State)]
+}
+
+// active reports whether the alarm is still to be shown.
+func (a ActiveAlarm) active() bool {
+	return a.State != EventStateNormal || a.AckedTransitions != [3]bool{true, true, true}
+}
+
+// changed reports whether a differs from b in what an operator sees.
+func (a ActiveAlarm) changed(b ActiveAlarm) bool {
+	return a.State != b.State || a.Time != b.Time || a.Priority != b.Priority || a.AckedTransitions != b.AckedTransitions
+}
+
+// AlarmChange is an alarm that was raised, changed or acknowledged, or that cleared.
+type AlarmChange struct {
+	Alarm ActiveAlarm `json:"alarm"`
+	// Cleared is set when the alarm returned to normal and all its transitions are acknowledged,
+	// or the device was removed.
+	Cleared bool `json:"cleared,omitempty"`
+}
+
+// AlarmManagerOptions configures an AlarmManager.
+type AlarmManagerOptions struct {
+	// ProcessID identifies the manager in acknowledgments.
+	ProcessID uint32 `json:"processId"`
+	// Listener configures the event listener, e.g. the process identifiers the client is entered
+	// with in the Recipient_List of the devices' notification classes.
+	Listener EventListenerOptions `json:"listener"`
+	// ReconcileInterval is how often the alarms of every device are read with GetEventInformation
+	// to catch up with notifications that were lost; defaults to five minutes.
+	ReconcileInterval time.Duration `json:"reconcileInterval,omitempty"`
+}
+
+// AlarmManager maintains the active alarms of a set of devices, as an operator workstation shows
+// them: it reads them with GetEventInformation when a device is added, keeps them current with
+// the event notifications the devices send, and reconciles periodically and whenever a device or
+// the connection comes back after a failure. Notifications of devices that were not added are
+// ignored.
+type AlarmManager struct {
+	client  *BACnetClient
+	options AlarmManagerOptions
+	ctx     context.Context
+	cancel  context.CancelFunc
+	changes chan AlarmChange
+	errors  chan error
+	done    chan struct{}
+
+	mu      sync.Mutex
+	devices map[uint32]*alarmDevice
+}
+
+type alarmDevice struct {
+	info   DeviceInfo
+	alarms map[BACnetObject]*ActiveAlarm
+	// reachable is unset after a failed reconciliation, so the next notification of the device
+	// reconciles it.
+	reachable bool
+}
+
+// NewAlarmManager returns a manager listening for event notifications through client until ctx
+// is cancelled or Close is called.
+func NewAlarmManager(ctx context.Context, client *BACnetClient, options AlarmManagerOptions) *AlarmManager {
+	if options.ReconcileInterval <= 0 {
+		options.ReconcileInterval = 5 * time.Minute
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	m := &AlarmManager{
+		client:  client,
+		options: options,
+		ctx:     ctx,
+		cancel:  cancel,
+		changes: make(chan AlarmChange, 64),
+		errors:  make(chan error, 16),
+		done:    make(chan struct{}),
+		devices: make(map[uint32]*alarmDevice),
+	}
+	go m.run()
+	return m
+}
+
+// Changes returns the channel alarm changes are delivered on. Changes are dropped when the
+// channel is full; List always has the current alarms.
+func (m *AlarmManager) Changes() <-chan AlarmChange {
+	return m.changes
+}
+
+// Errors returns the channel reconciliation and connection errors are delivered on. Errors are
+// dropped when the channel is full.
+func (m *AlarmManager) Errors() <-chan error {
+	return m.errors
+}
+
+// AddDevice adds a device and reads its alarms. The device stays added if they cannot be read,
+// and is reconciled again later.
+func (m *AlarmManager) AddDevice(device DeviceInfo) error {
+	m.mu.Lock()
+	if _, ok := m.devices[device.DeviceID]; !ok {
+		m.devices[device.DeviceID] = &alarmDevice{info: device, alarms: make(map[BACnetObject]*ActiveAlarm)}
+	}
+	m.mu.Unlock()
+	return m.Reconcile(device.DeviceID)
+}
+
+// RemoveDevice removes a device and its alarms.
+func (m *AlarmManager) RemoveDevice(deviceID uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.devices[deviceID]
+	if !ok {
+		return
+	}
+	delete(m.devices, deviceID)
+	for _, alarm := range d.alarms {
+		m.publish(AlarmChange{Alarm: *alarm, Cleared: true})
+	}
+}
+
+// List returns the active alarms of all devices, most urgent first and the most recent first
+// within a priority.
+func (m *AlarmManager) List() []ActiveAlarm {
+	m.mu.Lock()
+	var alarms []ActiveAlarm
+	for _, d := range m.devices {
+		for _, alarm := range d.alarms {
+			alarms = append(alarms, *alarm)
+		}
+	}
+	m.mu.Unlock()
+	sortAlarms(alarms)
+	return alarms
+}
+
+// Alarms returns the active alarms of a device, ordered like List.
+func (m *AlarmManager) Alarms(deviceID uint32) []ActiveAlarm {
+	m.mu.Lock()
+	var alarms []ActiveAlarm
+	if d, ok := m.devices[deviceID]; ok {
+		for _, alarm := range d.alarms {
+			alarms = append(alarms, *alarm)
+		}
+	}
+	m.mu.Unlock()
+	sortAlarms(alarms)
+	return alarms
+}
+
+func sortAlarms(alarms []ActiveAlarm) {
+	sort.Slice(alarms, func(i, j int) bool {
+		if alarms[i].Priority != alarms[j].Priority {
+			return alarms[i].Priority < alarms[j].Priority
+		}
+		return alarms[i].Time.After(alarms[j].Time)
+	})
+}
+
+// Ack acknowledges the transition of an alarm to its current state on behalf of source, the
+// operator. Acknowledging an acknowledged alarm does nothing.
+func (m *AlarmManager) Ack(deviceID uint32, object BACnetObject, source string, opts ...CallOption) error {
+	alarm, device, err := m.alarm(deviceID, object)
+	if err != nil {
+		return err
+	}
+	if alarm.Acked() {
+		return nil
+	}
+	transition := transitionOf(alarm.State)
+	if alarm.stamps[transition] == nil && alarm.TimeStamps[transition].IsZero() {
+		// The notification had a time stamp other than a date-time; the device reports it.
+		if err := m.Reconcile(deviceID); err != nil {
+			return err
+		}
+		if alarm, device, err = m.alarm(deviceID, object); err != nil {
+			return err
+		}
+		transition = transitionOf(alarm.State)
+	}
+
+	ack := AlarmAcknowledgment{
+		ProcessID: m.options.ProcessID,
+		Object:    object,
+		State:     alarm.State,
+		TimeStamp: alarm.TimeStamps[transition],
+		Source:    source,
+		stamp:     alarm.stamps[transition],
+	}
+	if err := m.client.AcknowledgeAlarm(device, ack, opts...); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.devices[deviceID]; ok {
+		if current, ok := d.alarms[object]; ok && current.State == alarm.State {
+			acked := *current
+			acked.AckedTransitions[transition] = true
+			m.update(d, &acked)
+		}
+	}
+	return nil
+}
+
+// alarm returns a copy of an alarm and its device.
+func (m *AlarmManager) alarm(deviceID uint32, object BACnetObject) (ActiveAlarm, DeviceInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.devices[deviceID]
+	if !ok {
+		return ActiveAlarm{}, DeviceInfo{}, fmt.Errorf("device %d is not managed", deviceID)
+	}
+	alarm, ok := d.alarms[object]
+	if !ok {
+		return ActiveAlarm{}, DeviceInfo{}, fmt.Errorf("%s of device %d has no active alarm", object, deviceID)
+	}
+	return *alarm, d.info, nil
+}
+
+// Reconcile reads the alarms of a device with GetEventInformation and replaces those held.
+func (m *AlarmManager) Reconcile(deviceID uint32) error {
+	m.mu.Lock()
+	d, ok := m.devices[deviceID]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("device %d is not managed", deviceID)
+	}
+
+	summaries, err := m.client.GetEventInformation(d.info, CallContext(m.ctx))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.devices[deviceID] != d {
+		return nil // Removed meanwhile
+	}
+	if err != nil {
+		d.reachable = false
+		return fmt.Errorf("reconciling alarms of device %d: %w", deviceID, err)
+	}
+	d.reachable = true
+
+	seen := make(map[BACnetObject]bool, len(summaries))
+	for _, s := range summaries {
+		seen[s.Object] = true
+		transition := transitionOf(s.State)
+		alarm := ActiveAlarm{DeviceID: deviceID, Object: s.Object}
+		if old, ok := d.alarms[s.Object]; ok {
+			alarm = *old
+		}
+		alarm.State = s.State
+		alarm.Time = s.EventTimeStamps[transition]
+		alarm.Priority = uint8(min(s.EventPriorities[transition], 255))
+		alarm.NotifyType = s.NotifyType
+		alarm.AckedTransitions = s.AckedTransitions
+		alarm.TimeStamps = s.EventTimeStamps
+		alarm.stamps = s.stamps
+		m.update(d, &alarm)
+	}
+	for object, alarm := range d.alarms {
+		if !seen[object] {
+			delete(d.alarms, object)
+			m.publish(AlarmChange{Alarm: *alarm, Cleared: true})
+		}
+	}
+	return nil
+}
+
+// update stores alarm, or removes it once it is no longer active, and publishes the change. The
+// caller must hold m.mu.
+func (m *AlarmManager) update(d *alarmDevice, alarm *ActiveAlarm) {
+	old, existed := d.alarms[alarm.Object]
+	if !alarm.active() {
+		if existed {
+			delete(d.alarms, alarm.Object)
+			m.publish(AlarmChange{Alarm: *alarm, Cleared: true})
+		}
+		return
+	}
+	d.alarms[alarm.Object] = alarm
+	if !existed || alarm.changed(*old) {
+		m.publish(AlarmChange{Alarm: *alarm})
+	}
+}
+
+func (m *AlarmManager) publish(change AlarmChange) {
+	select {
+	case m.changes <- change:
+	default:
+	}
+}
+
+func (m *AlarmManager) reportError(err error) {
+	select {
+	case m.errors <- err:
+	default:
+	}
+}
+
+// apply updates the alarms with an event notification.
+func (m *AlarmManager) apply(n EventNotification) (reconcile bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.devices[n.InitiatingDevice.Instance]
+	if !ok {
+		return false
+	}
+
+	alarm := ActiveAlarm{DeviceID: n.InitiatingDevice.Instance, Object: n.EventObject, AckedTransitions: [3]bool{true, true, true}}
+	if old, ok := d.alarms[n.EventObject]; ok {
+		alarm = *old
+	}
+	transition := transitionOf(n.ToState)
+	if n.NotifyType == NotifyAckNotification {
+		alarm.AckedTransitions[transition] = true
+	} else {
+		alarm.State = n.ToState
+		alarm.Time = n.Time
+		alarm.Priority = n.Priority
+		alarm.NotifyType = n.NotifyType
+		alarm.AckedTransitions[transition] = !n.AckRequired
+		alarm.TimeStamps[transition] = n.Time
+		alarm.stamps[transition] = nil
+		alarm.EventType = n.EventType
+		alarm.NotificationClass = n.NotificationClass
+		alarm.MessageText = n.MessageText
+	}
+	m.update(d, &alarm)
+	return !d.reachable
+}
+
+// run applies notifications and reconciles until the manager is closed. When the listener fails,
+// it is restarted after the reconcile interval and every device is reconciled.
+func (m *AlarmManager) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.options.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		listener := m.client.ListenEvents(m.ctx, m.options.Listener)
+		failed := m.listen(listener, ticker.C)
+		if !failed {
+			return
+		}
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-time.After(m.options.ReconcileInterval):
+		}
+		m.reconcileAll()
+	}
+}
+
+// listen handles the notifications of listener until it ends, and reports whether it failed.
+func (m *AlarmManager) listen(listener *EventListener, tick <-chan time.Time) bool {
+	defer listener.Close()
+	for {
+		select {
+		case n, ok := <-listener.Notifications():
+			if !ok {
+				if err := listener.Err(); err != nil && m.ctx.Err() == nil {
+					m.reportError(fmt.Errorf("alarm manager: %w", err))
+					return true
+				}
+				return false
+			}
+			if m.apply(n) {
+				// The device is back: catch up with what was missed
+				deviceID := n.InitiatingDevice.Instance
+				go func() {
+					if err := m.Reconcile(deviceID); err != nil {
+						m.reportError(err)
+					}
+				}()
+			}
+		case <-tick:
+			go m.reconcileAll()
+		}
+	}
+}
+
+// reconcileAll reconciles every device.
+func (m *AlarmManager) reconcileAll() {
+	m.mu.Lock()
+	deviceIDs := make([]uint32, 0, len(m.devices))
+	for deviceID := range m.devices {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	m.mu.Unlock()
+	for _, deviceID := range deviceIDs {
+		if m.ctx.Err() != nil {
+			return
+		}
+		if err := m.Reconcile(deviceID); err != nil {
+			m.reportError(err)
+		}
+	}
+}
+
+// Close stops the manager.
+func (m *AlarmManager) Close() {
+	m.cancel()
+	<-m.done
+}
@@ -0,0 +1,51 @@
+package bacnet
+
+import (
+	"fmt"
+)
+
+// ReadArrayLength reads the number of elements of an array property by reading array index 0.
+func (c *BACnetClient) ReadArrayLength(device DeviceInfo, object BACnetObject, propertyID uint32, opts ...CallOption) (uint32, error) {
+	index := uint32(0)
+	value, err := c.readProperty(callContext(opts...), device, object, propertyID, &index)
+	if err != nil {
+		return 0, err
+	}
+	length, ok := value.(uint32)
+	if !ok {
+		return 0, fmt.Errorf("unexpected array length %v (%T) of %s %s", value, value, object, propertyLabel(propertyID))
+	}
+	return length, nil
+}
+
+// ReadArray reads all elements of an array property. The whole array is read with one request
+// unless ClientOptions.ChunkedArrayReads is set, in which case the length is read first and then
+// one element per request.
+func (c *BACnetClient) ReadArray(device DeviceInfo, object BACnetObject, propertyID uint32, opts ...CallOption) ([]interface{}, error) {
+	ctx := callContext(opts...)
+	if !c.options.ChunkedArrayReads {
+		value, err := c.readProperty(ctx, device, object, propertyID, nil)
+		if err != nil {
+			return nil, err
+		}
+		elements, ok := value.([]interface{})
+		if !ok {
+			elements = []interface{}{value} // A single element decodes to a bare value
+		}
+		return elements, nil
+	}
+
+	length, err := c.ReadArrayLength(device, object, propertyID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read array length: %w", err)
+	}
+	elements := make([]interface{}, 0, length)
+	for index := uint32(1); index <= length; index++ {
+		value, err := c.readProperty(ctx, device, object, propertyID, &index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read array index %d: %w", index, err)
+		}
+		elements = append(elements, value)
+	}
+	return elements, nil
+}
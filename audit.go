@@ -0,0 +1,131 @@
+package bacnet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// WriteAudit is the audit record of a write issued by a client.
+type WriteAudit struct {
+	Time time.Time `json:"time"`
+	// User is who issued the write: ClientOptions.AuditUser, or CallUser for the call.
+	User       string       `json:"user,omitempty"`
+	DeviceID   uint32       `json:"deviceId"`
+	Object     BACnetObject `json:"object"`
+	PropertyID uint32       `json:"propertyId"`
+	ArrayIndex *uint32      `json:"arrayIndex,omitempty"`
+	Priority   uint8        `json:"priority,omitempty"`
+	// OldValue is the value read back before the write if ClientOptions.AuditReadBack is set and
+	// the read succeeded.
+	OldValue interface{} `json:"oldValue,omitempty"`
+	NewValue interface{} `json:"newValue"`
+	// Error is the error the write failed with, empty if it succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditStore keeps the audit trail of a client's writes. Set it in ClientOptions.AuditStore. The
+// write has been issued when RecordWrite is called, so errors are logged but do not fail it.
+type AuditStore interface {
+	RecordWrite(audit WriteAudit) error
+}
+
+// AuditStoreFunc adapts a function to an AuditStore.
+type AuditStoreFunc func(audit WriteAudit) error
+
+func (f AuditStoreFunc) RecordWrite(audit WriteAudit) error { return f(audit) }
+
+// MemoryAuditStore keeps the most recent audit records in memory.
+type MemoryAuditStore struct {
+	mu      sync.Mutex
+	limit   int
+	records []WriteAudit
+}
+
+// NewMemoryAuditStore returns a store keeping up to limit records; zero keeps all.
+func NewMemoryAuditStore(limit int) *MemoryAuditStore {
+	return &MemoryAuditStore{limit: limit}
+}
+
+func (s *MemoryAuditStore) RecordWrite(audit WriteAudit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, audit)
+	if s.limit > 0 && len(s.records) > s.limit {
+		s.records = append(s.records[:0], s.records[len(s.records)-s.limit:]...)
+	}
+	return nil
+}
+
+// Records returns the stored records, oldest first.
+func (s *MemoryAuditStore) Records() []WriteAudit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]WriteAudit(nil), s.records...)
+}
+
+// JSONAuditStore writes every record as a line of JSON to w, e.g. an append-only file.
+func JSONAuditStore(w io.Writer) AuditStore {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return AuditStoreFunc(func(audit WriteAudit) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return enc.Encode(audit)
+	})
+}
+
+// CallUser sets the user recorded in the audit trail of a write, overriding ClientOptions.AuditUser.
+func CallUser(user string) CallOption {
+	return func(o *callOptions) { o.user = &user }
+}
+
+// newWriteAudit starts the audit record of a write, reading back the old value if configured.
+func (c *BACnetClient) newWriteAudit(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, arrayIndex *uint32, value interface{}, priority uint8) WriteAudit {
+	audit := WriteAudit{
+		Time:       time.Now(),
+		User:       c.callUser(ctx),
+		DeviceID:   device.DeviceID,
+		Object:     object,
+		PropertyID: propertyID,
+		ArrayIndex: arrayIndex,
+		Priority:   priority,
+		NewValue:   value,
+	}
+	if c.options.AuditReadBack {
+		if old, err := c.readProperty(ctx, device, object, propertyID, arrayIndex); err == nil {
+			audit.OldValue = old
+		}
+	}
+	return audit
+}
+
+// auditWrite records a write in the audit store of the client.
+func (c *BACnetClient) auditWrite(audit WriteAudit, err error) {
+	if err != nil {
+		audit.Error = err.Error()
+	}
+	if storeErr := c.options.AuditStore.RecordWrite(audit); storeErr != nil {
+		c.logf("bacnet: failed to record write of %s of %s on device %d: %v",
+			propertyLabel(audit.PropertyID), audit.Object, audit.DeviceID, storeErr)
+	}
+}
+
+// auditWrites records the writes of a WritePropertyMultiple request. If one of them failed, those
+// before it succeeded and those after it were not made, so they are not recorded.
+func (c *BACnetClient) auditWrites(audits []WriteAudit, err error) {
+	var failed *WritePropertyMultipleError
+	if !errors.As(err, &failed) || failed.Index < 0 {
+		for _, audit := range audits {
+			c.auditWrite(audit, err)
+		}
+		return
+	}
+	for _, audit := range audits[:failed.Index] {
+		c.auditWrite(audit, nil)
+	}
+	c.auditWrite(audits[failed.Index], failed.Err)
+}
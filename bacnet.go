@@ -1,28 +1,14 @@
 package bacnet
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net"
-	"sync"
 	"time"
-)
-
-// invokeIDManager provides thread-safe, unique Invoke IDs for BACnet requests.
-type invokeIDManager struct {
-	mu     sync.Mutex
-	lastID byte
-}
-
-// Next returns the next available Invoke ID. It handles wrapping from 255 back to 0.
-func (m *invokeIDManager) Next() byte {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.lastID++
-	return m.lastID
-}
 
-// Global instance of the invoke ID manager.
-var GInvokeIDManager = &invokeIDManager{}
+	"github.com/maxzerker/bacnet/encoding"
+)
 
 type ObjectType uint32
 
@@ -52,6 +38,22 @@ const (
 	OBJECT_LIFE_SAFETY_ZONE   ObjectType = 22
 	OBJECT_ACCUMULATOR        ObjectType = 23
 	OBJECT_PULSE_CONVERTER    ObjectType = 24
+	OBJECT_EVENT_LOG          ObjectType = 25
+	OBJECT_STRUCTURED_VIEW    ObjectType = 29
+	OBJECT_TIMER              ObjectType = 31
+
+	OBJECT_CHARACTERSTRING_VALUE  ObjectType = 40
+	OBJECT_DATE_VALUE             ObjectType = 42
+	OBJECT_DATETIME_VALUE         ObjectType = 44
+	OBJECT_INTEGER_VALUE          ObjectType = 45
+	OBJECT_LARGE_ANALOG_VALUE     ObjectType = 46
+	OBJECT_OCTETSTRING_VALUE      ObjectType = 47
+	OBJECT_POSITIVE_INTEGER_VALUE ObjectType = 48
+	OBJECT_TIME_VALUE             ObjectType = 50
+	OBJECT_ELEVATOR_GROUP         ObjectType = 57
+	OBJECT_ESCALATOR              ObjectType = 58
+	OBJECT_LIFT                   ObjectType = 59
+	OBJECT_STAGING                ObjectType = 60
 )
 
 var ObjectTypeNames = map[ObjectType]string{
@@ -80,73 +82,160 @@ var ObjectTypeNames = map[ObjectType]string{
 	OBJECT_LIFE_SAFETY_ZONE:   "LifeSafetyZone",
 	OBJECT_ACCUMULATOR:        "Accumulator",
 	OBJECT_PULSE_CONVERTER:    "PulseConverter",
+	OBJECT_EVENT_LOG:          "EventLog",
+	OBJECT_STRUCTURED_VIEW:    "StructuredView",
+	OBJECT_TIMER:              "Timer",
+
+	OBJECT_CHARACTERSTRING_VALUE:  "CharacterStringValue",
+	OBJECT_DATE_VALUE:             "DateValue",
+	OBJECT_DATETIME_VALUE:         "DateTimeValue",
+	OBJECT_INTEGER_VALUE:          "IntegerValue",
+	OBJECT_LARGE_ANALOG_VALUE:     "LargeAnalogValue",
+	OBJECT_OCTETSTRING_VALUE:      "OctetStringValue",
+	OBJECT_POSITIVE_INTEGER_VALUE: "PositiveIntegerValue",
+	OBJECT_TIME_VALUE:             "TimeValue",
+	OBJECT_ELEVATOR_GROUP:         "ElevatorGroup",
+	OBJECT_ESCALATOR:              "Escalator",
+	OBJECT_LIFT:                   "Lift",
+	OBJECT_STAGING:                "Staging",
 }
 
 var PropertyNames = map[uint32]string{
-	uint32(PROP_ACKED_TRANSITIONS):               "AckedTransitions",
-	uint32(PROP_ACK_REQUIRED):                    "AckRequired",
-	uint32(PROP_ACTION):                          "Action",
-	uint32(PROP_ACTION_TEXT):                     "ActionText",
-	uint32(PROP_ACTIVE_TEXT):                     "ActiveText",
-	uint32(PROP_ACTIVE_VT_SESSIONS):              "ActiveVtSessions",
-	uint32(PROP_ALARM_VALUE):                     "AlarmValue",
-	uint32(PROP_ALARM_VALUES):                    "AlarmValues",
-	uint32(PROP_ALL):                             "All",
-	uint32(PROP_ALL_WRITES_SUCCESSFUL):           "AllWritesSuccessful",
-	uint32(PROP_APDU_SEGMENT_TIMEOUT):            "ApduSegmentTimeout",
-	uint32(PROP_APDU_TIMEOUT):                    "ApduTimeout",
-	uint32(PROP_APPLICATION_SOFTWARE_VERSION):    "ApplicationSoftwareVersion",
-	uint32(PROP_ARCHIVE):                         "Archive",
-	uint32(PROP_BIAS):                            "Bias",
-	uint32(PROP_CHANGE_OF_STATE_COUNT):           "ChangeOfStateCount",
-	uint32(PROP_CHANGE_OF_STATE_TIME):            "ChangeOfStateTime",
-	uint32(PROP_NOTIFICATION_CLASS):              "NotificationClass",
-	uint32(PROP_COV_INCREMENT):                   "CovIncrement",
-	uint32(PROP_DATE_LIST):                       "DateList",
-	uint32(PROP_DAYLIGHT_SAVINGS_STATUS):         "DaylightSavingsStatus",
-	uint32(PROP_DEADBAND):                        "Deadband",
-	uint32(PROP_DESCRIPTION):                     "Description",
-	uint32(PROP_DEVICE_ADDRESS_BINDING):          "DeviceAddressBinding",
-	uint32(PROP_DEVICE_TYPE):                     "DeviceType",
-	uint32(PROP_EFFECTIVE_PERIOD):                "EffectivePeriod",
-	uint32(PROP_ELAPSED_ACTIVE_TIME):             "ElapsedActiveTime",
-	uint32(PROP_ERROR_LIMIT):                     "ErrorLimit",
-	uint32(PROP_EVENT_ENABLE):                    "EventEnable",
-	uint32(PROP_EVENT_STATE):                     "EventState",
-	uint32(PROP_EVENT_TYPE):                      "EventType",
-	uint32(PROP_EXCEPTION_SCHEDULE):              "ExceptionSchedule",
-	uint32(PROP_FILE_ACCESS_METHOD):              "FileAccessMethod",
-	uint32(PROP_FILE_SIZE):                       "FileSize",
-	uint32(PROP_FILE_TYPE):                       "FileType",
-	uint32(PROP_FIRMWARE_REVISION):               "FirmwareRevision",
-	uint32(PROP_HIGH_LIMIT):                      "HighLimit",
-	uint32(PROP_INSTANCE_OF):                     "InstanceOf",
-	uint32(PROP_LIMIT_ENABLE):                    "LimitEnable",
-	uint32(PROP_LIST_OF_GROUP_MEMBERS):           "ListOfGroupMembers",
+	uint32(PROP_ACKED_TRANSITIONS):                  "AckedTransitions",
+	uint32(PROP_ACK_REQUIRED):                       "AckRequired",
+	uint32(PROP_ACTION):                             "Action",
+	uint32(PROP_ACTION_TEXT):                        "ActionText",
+	uint32(PROP_ACTIVE_TEXT):                        "ActiveText",
+	uint32(PROP_ACTIVE_VT_SESSIONS):                 "ActiveVtSessions",
+	uint32(PROP_ALARM_VALUE):                        "AlarmValue",
+	uint32(PROP_ALARM_VALUES):                       "AlarmValues",
+	uint32(PROP_ALL):                                "All",
+	uint32(PROP_ALL_WRITES_SUCCESSFUL):              "AllWritesSuccessful",
+	uint32(PROP_APDU_SEGMENT_TIMEOUT):               "ApduSegmentTimeout",
+	uint32(PROP_APDU_TIMEOUT):                       "ApduTimeout",
+	uint32(PROP_APPLICATION_SOFTWARE_VERSION):       "ApplicationSoftwareVersion",
+	uint32(PROP_ARCHIVE):                            "Archive",
+	uint32(PROP_BIAS):                               "Bias",
+	uint32(PROP_CHANGE_OF_STATE_COUNT):              "ChangeOfStateCount",
+	uint32(PROP_CHANGE_OF_STATE_TIME):               "ChangeOfStateTime",
+	uint32(PROP_NOTIFICATION_CLASS):                 "NotificationClass",
+	uint32(PROP_COV_INCREMENT):                      "CovIncrement",
+	uint32(PROP_DATE_LIST):                          "DateList",
+	uint32(PROP_DAYLIGHT_SAVINGS_STATUS):            "DaylightSavingsStatus",
+	uint32(PROP_DEADBAND):                           "Deadband",
+	uint32(PROP_DESCRIPTION):                        "Description",
+	uint32(PROP_DEVICE_ADDRESS_BINDING):             "DeviceAddressBinding",
+	uint32(PROP_DEVICE_TYPE):                        "DeviceType",
+	uint32(PROP_EFFECTIVE_PERIOD):                   "EffectivePeriod",
+	uint32(PROP_ELAPSED_ACTIVE_TIME):                "ElapsedActiveTime",
+	uint32(PROP_ERROR_LIMIT):                        "ErrorLimit",
+	uint32(PROP_EVENT_ENABLE):                       "EventEnable",
+	uint32(PROP_EVENT_STATE):                        "EventState",
+	uint32(PROP_EVENT_TYPE):                         "EventType",
+	uint32(PROP_EXCEPTION_SCHEDULE):                 "ExceptionSchedule",
+	uint32(PROP_FILE_ACCESS_METHOD):                 "FileAccessMethod",
+	uint32(PROP_FILE_SIZE):                          "FileSize",
+	uint32(PROP_FILE_TYPE):                          "FileType",
+	uint32(PROP_FIRMWARE_REVISION):                  "FirmwareRevision",
+	uint32(PROP_HIGH_LIMIT):                         "HighLimit",
+	uint32(PROP_INACTIVE_TEXT):                      "InactiveText",
+	uint32(PROP_INSTANCE_OF):                        "InstanceOf",
+	uint32(PROP_LIMIT_ENABLE):                       "LimitEnable",
+	uint32(PROP_LIST_OF_GROUP_MEMBERS):              "ListOfGroupMembers",
 	uint32(PROP_LIST_OF_OBJECT_PROPERTY_REFERENCES): "ListOfObjectPropertyReferences",
-	uint32(PROP_OBJECT_IDENTIFIER):               "ObjectIdentifier",
-	uint32(PROP_OBJECT_LIST):                     "ObjectList",
-	uint32(PROP_OBJECT_NAME):                     "ObjectName",
-	uint32(PROP_OBJECT_PROPERTY_REFERENCE):       "ObjectPropertyReference",
-	uint32(PROP_OBJECT_TYPE):                     "ObjectType",
-	uint32(PROP_OPTIONAL):                        "Optional",
-	uint32(PROP_OUT_OF_SERVICE):                  "OutOfService",
-	uint32(PROP_PRESENT_VALUE):                   "PresentValue",
-	uint32(PROP_PRIORITY_ARRAY):                  "PriorityArray",
-	uint32(PROP_PROFILE_NAME):                    "ProfileName",
-	uint32(PROP_PROTOCOL_CONFORMANCE_CLASS):      "ProtocolConformanceClass",
-	uint32(PROP_PROTOCOL_OBJECT_TYPES_SUPPORTED): "ProtocolObjectTypesSupported",
-	uint32(PROP_PROTOCOL_SERVICES_SUPPORTED):     "ProtocolServicesSupported",
-	uint32(PROP_PROTOCOL_VERSION):                "ProtocolVersion",
-	uint32(PROP_RELIABILITY):                     "Reliability",
-	uint32(PROP_REQUIRED):                        "Required",
-	uint32(PROP_SEGMENTATION_SUPPORTED):          "SegmentationSupported",
-	uint32(PROP_STATUS_FLAGS):                    "StatusFlags",
-	uint32(PROP_SYSTEM_STATUS):                   "SystemStatus",
-	uint32(PROP_UNITS):                           "Units",
-	uint32(PROP_UPDATE_INTERVAL):                 "UpdateInterval",
-	uint32(PROP_VENDOR_IDENTIFIER):               "VendorIdentifier",
-	uint32(PROP_VENDOR_NAME):                     "VendorName",
+	PROP_LOW_LIMIT:                                  "LowLimit",
+	PROP_MAX_APDU_LENGTH_ACCEPTED:                   "MaxApduLengthAccepted",
+	PROP_MAX_INFO_FRAMES:                            "MaxInfoFrames",
+	PROP_MAX_MASTER:                                 "MaxMaster",
+	uint32(PROP_NUMBER_OF_STATES):                   "NumberOfStates",
+	uint32(PROP_OBJECT_IDENTIFIER):                  "ObjectIdentifier",
+	uint32(PROP_OBJECT_LIST):                        "ObjectList",
+	uint32(PROP_OBJECT_NAME):                        "ObjectName",
+	uint32(PROP_OBJECT_PROPERTY_REFERENCE):          "ObjectPropertyReference",
+	uint32(PROP_OBJECT_TYPE):                        "ObjectType",
+	uint32(PROP_OPTIONAL):                           "Optional",
+	uint32(PROP_OUT_OF_SERVICE):                     "OutOfService",
+	uint32(PROP_POLARITY):                           "Polarity",
+	uint32(PROP_PRESENT_VALUE):                      "PresentValue",
+	uint32(PROP_PRIORITY):                           "Priority",
+	uint32(PROP_PRIORITY_ARRAY):                     "PriorityArray",
+	uint32(PROP_PRIORITY_FOR_WRITING):               "PriorityForWriting",
+	uint32(PROP_PROFILE_NAME):                       "ProfileName",
+	uint32(PROP_PROTOCOL_CONFORMANCE_CLASS):         "ProtocolConformanceClass",
+	uint32(PROP_PROTOCOL_OBJECT_TYPES_SUPPORTED):    "ProtocolObjectTypesSupported",
+	uint32(PROP_PROTOCOL_SERVICES_SUPPORTED):        "ProtocolServicesSupported",
+	uint32(PROP_PROTOCOL_VERSION):                   "ProtocolVersion",
+	uint32(PROP_RECIPIENT_LIST):                     "RecipientList",
+	uint32(PROP_RELIABILITY):                        "Reliability",
+	uint32(PROP_RELINQUISH_DEFAULT):                 "RelinquishDefault",
+	uint32(PROP_REQUIRED):                           "Required",
+	uint32(PROP_SEGMENTATION_SUPPORTED):             "SegmentationSupported",
+	uint32(PROP_STATE_TEXT):                         "StateText",
+	uint32(PROP_STATUS_FLAGS):                       "StatusFlags",
+	uint32(PROP_SYSTEM_STATUS):                      "SystemStatus",
+	uint32(PROP_UNITS):                              "Units",
+	uint32(PROP_UPDATE_INTERVAL):                    "UpdateInterval",
+	uint32(PROP_VENDOR_IDENTIFIER):                  "VendorIdentifier",
+	uint32(PROP_VENDOR_NAME):                        "VendorName",
+	uint32(PROP_WEEKLY_SCHEDULE):                    "WeeklySchedule",
+	uint32(PROP_BUFFER_SIZE):                        "BufferSize",
+	PROP_LOG_BUFFER:                                 "LogBuffer",
+	uint32(PROP_LOG_DEVICE_OBJECT_PROPERTY):         "LogDeviceObjectProperty",
+	uint32(PROP_ENABLE):                             "Enable",
+	uint32(PROP_LOG_INTERVAL):                       "LogInterval",
+	uint32(PROP_RECORD_COUNT):                       "RecordCount",
+	uint32(PROP_STOP_WHEN_FULL):                     "StopWhenFull",
+	PROP_TOTAL_RECORD_COUNT:                         "TotalRecordCount",
+	PROP_ACTIVE_COV_SUBSCRIPTIONS:                   "ActiveCovSubscriptions",
+	uint32(PROP_SCHEDULE_DEFAULT):                   "ScheduleDefault",
+	PROP_LOGGING_TYPE:                               "LoggingType",
+	PROP_NODE_SUBTYPE:                               "NodeSubtype",
+	PROP_NODE_TYPE:                                  "NodeType",
+	PROP_SUBORDINATE_ANNOTATIONS:                    "SubordinateAnnotations",
+	PROP_SUBORDINATE_LIST:                           "SubordinateList",
+	PROP_DEFAULT_TIMEOUT:                            "DefaultTimeout",
+	PROP_INITIAL_TIMEOUT:                            "InitialTimeout",
+	PROP_LAST_STATE_CHANGE:                          "LastStateChange",
+	PROP_STATE_CHANGE_VALUES:                        "StateChangeValues",
+	PROP_TIMER_RUNNING:                              "TimerRunning",
+	PROP_TIMER_STATE:                                "TimerState",
+	PROP_PRESENT_STAGE:                              "PresentStage",
+	PROP_STAGES:                                     "Stages",
+	PROP_STAGE_NAMES:                                "StageNames",
+	PROP_TARGET_REFERENCES:                          "TargetReferences",
+	PROP_ASSIGNED_LANDING_CALLS:                     "AssignedLandingCalls",
+	PROP_CAR_ASSIGNED_DIRECTION:                     "CarAssignedDirection",
+	PROP_CAR_DOOR_COMMAND:                           "CarDoorCommand",
+	PROP_CAR_DOOR_STATUS:                            "CarDoorStatus",
+	PROP_CAR_DOOR_TEXT:                              "CarDoorText",
+	PROP_CAR_DOOR_ZONE:                              "CarDoorZone",
+	PROP_CAR_DRIVE_STATUS:                           "CarDriveStatus",
+	PROP_CAR_LOAD:                                   "CarLoad",
+	PROP_CAR_LOAD_UNITS:                             "CarLoadUnits",
+	PROP_CAR_MODE:                                   "CarMode",
+	PROP_CAR_MOVING_DIRECTION:                       "CarMovingDirection",
+	PROP_CAR_POSITION:                               "CarPosition",
+	PROP_ELEVATOR_GROUP:                             "ElevatorGroup",
+	PROP_ENERGY_METER:                               "EnergyMeter",
+	PROP_ENERGY_METER_REF:                           "EnergyMeterRef",
+	PROP_ESCALATOR_MODE:                             "EscalatorMode",
+	PROP_FAULT_SIGNALS:                              "FaultSignals",
+	PROP_FLOOR_TEXT:                                 "FloorText",
+	PROP_GROUP_ID:                                   "GroupId",
+	PROP_GROUP_MODE:                                 "GroupMode",
+	PROP_HIGHER_DECK:                                "HigherDeck",
+	PROP_INSTALLATION_ID:                            "InstallationId",
+	PROP_LANDING_CALLS:                              "LandingCalls",
+	PROP_LANDING_CALL_CONTROL:                       "LandingCallControl",
+	PROP_LANDING_DOOR_STATUS:                        "LandingDoorStatus",
+	PROP_LOWER_DECK:                                 "LowerDeck",
+	PROP_MACHINE_ROOM_ID:                            "MachineRoomId",
+	PROP_MAKING_CAR_CALL:                            "MakingCarCall",
+	PROP_NEXT_STOPPING_FLOOR:                        "NextStoppingFloor",
+	PROP_OPERATION_DIRECTION:                        "OperationDirection",
+	PROP_PASSENGER_ALARM:                            "PassengerAlarm",
+	PROP_POWER_MODE:                                 "PowerMode",
+	PROP_REGISTERED_CAR_CALL:                        "RegisteredCarCall",
 }
 
 type BACnetObject struct {
@@ -154,6 +243,15 @@ type BACnetObject struct {
 	Instance uint32
 }
 
+// String returns the object in the form "<ObjectType>:<instance>", e.g. "AnalogInput:3".
+func (o BACnetObject) String() string {
+	typeName, ok := ObjectTypeNames[o.Type]
+	if !ok {
+		typeName = fmt.Sprintf("%d", o.Type)
+	}
+	return fmt.Sprintf("%s:%d", typeName, o.Instance)
+}
+
 // StatusFlags represents the BACnet Status_Flags property.
 type StatusFlags struct {
 	InAlarm      bool
@@ -173,6 +271,9 @@ type COVNotification struct {
 	MonitoredObjectIdentifier   BACnetObject
 	TimeRemaining               uint32
 	ListOfValues                []BACnetPropertyValue
+	// PointName is the name of the monitored object in ClientOptions.Points, or its PointRef string
+	// if it has no name there. It is empty if the client has no registry.
+	PointName string
 }
 
 // BVLCHeader represents the BACnet/IP Virtual Link Control header.
@@ -201,41 +302,142 @@ type DeviceInfo struct {
 	Port       int
 	MacAddress []byte // BACnet MAC address (e.g., 0x08 for IP)
 	MaxAPDU    uint16 // Max APDU length supported by the device
+	VendorID   uint32 // Vendor identifier from the I-Am
 }
 
-// ClientOptions holds configuration for a BACnetClient.
+// ClientOptions holds configuration for a BACnetClient. Fields are only ever added, and their zero
+// value keeps the behaviour of clients that do not set them; see also Option.
 type ClientOptions struct {
 	// LocalAddr is the local address to bind to. If nil, a suitable address is chosen.
 	LocalAddr *net.UDPAddr
+	// Transport, if set, is the connection NewClient uses instead of binding a UDP socket to
+	// LocalAddr, e.g. a ReplayConn.
+	Transport PacketConn
+	// Logger, if set, receives retries, failed transactions and discarded datagrams.
+	Logger *log.Logger
 	// Timeout specifies the default timeout for BACnet requests.
 	Timeout time.Duration
+	// Retries is how many times a confirmed request is resent when no response arrives in time.
+	Retries int
+	// RetryBackoff is how much longer than Timeout the client waits before the first resend,
+	// doubled before every further one, so that retries do not pile onto a busy device. A response
+	// arriving meanwhile is still taken. Zero resends right after the timeout.
+	RetryBackoff time.Duration
+	// AdaptiveTimeout, if set, derives the timeout of requests to each device from the round trips
+	// measured to it, within bounds set by Timeout; see ClientStats.RoundTrips.
+	AdaptiveTimeout *AdaptiveTimeout
+	// ChunkedArrayReads makes ReadArray and the helpers built on it read arrays one element per
+	// request, for devices that abort when a whole array does not fit into one APDU.
+	ChunkedArrayReads bool
+	// COVBufferSize and COVBackpressure are the notification channel capacity and backpressure
+	// policy of subscriptions made with SubscribeCOV.
+	COVBufferSize   int
+	COVBackpressure BackpressurePolicy
+	// Tracer, if set, receives a span for every confirmed transaction.
+	Tracer Tracer
+	// ReadBufferSize is the size of the buffer datagrams are read into; larger datagrams are
+	// truncated. Zero takes datagrams of any size.
+	ReadBufferSize int
+	// OnUnexpectedBVLC, if set, is called for every received datagram whose BVLC function a client
+	// does not accept, such as a BBMD request; the datagram is discarded.
+	OnUnexpectedBVLC func(UnexpectedBVLC)
+	// OnFrame, if set, receives every datagram exchanged in confirmed transactions, raw and
+	// decoded; see Frame.
+	OnFrame func(Frame)
+	// OnTextMessage, if set, receives the text messages sent to the client; confirmed ones are
+	// acknowledged before it is called. The receive loop calls it, so no datagram is handled until
+	// it returns: it must not block, and a request made from it waits for a response the loop cannot
+	// deliver and fails with a timeout. Hand messages to another goroutine for anything slow.
+	OnTextMessage func(TextMessage)
+	// AuditStore, if set, records every write the client sends; see WriteAudit. AuditUser is the
+	// user recorded unless a call sets CallUser, and AuditReadBack reads the old value of the
+	// property before each write.
+	AuditStore    AuditStore
+	AuditUser     string
+	AuditReadBack bool
+	// Authorize, if set, is called before every request that changes a device and may refuse it
+	// by returning an error, e.g. to enforce the roles of the application's users; see Mutation.
+	Authorize func(ctx context.Context, m Mutation) error
+	// ReadOnly keeps the client from sending mutations that were authorized.
+	ReadOnly ReadOnlyMode
+	// Points, if set, names the points of COV notifications and Poller updates, so applications
+	// can key them by name; see COVNotification.PointName and PollUpdate.PointName.
+	Points *PointRegistry
+
+	// Charset is the character set of the strings the client writes; the default is UTF-8. Use
+	// e.g. encoding.CharsetISO8859_1 or CharsetUCS2 for devices that do not support UTF-8.
+	// Strings read are converted from any supported character set regardless.
+	Charset encoding.CharacterSet
+	// TextNormalization cleans up the strings the client reads.
+	TextNormalization TextNormalization
+	// NAT, if set, maps devices behind NAT to the public addresses that reach them.
+	NAT *NATTable
+	// DeviceFilter, if set, discards the I-Ams of the devices it does not allow, so discovery
+	// never reports them.
+	DeviceFilter *DeviceFilter
 }
 
 // BACnetClient manages network connections and configurations for BACnet interactions.
 type BACnetClient struct {
-	conn    *net.UDPConn
-	options ClientOptions
-	mu      sync.Mutex // Mutex to protect concurrent access to the connection
+	conn          PacketConn
+	options       ClientOptions
+	failover      *failoverState
+	stats         *clientStats
+	reads         *flightGroup
+	notifications *notificationDispatcher
+	receiver      *receiver
+
+	// closed is cancelled by Close and ends all subscriptions
+	closed    context.Context
+	closeFunc context.CancelFunc
 }
 
-// NewClient creates and initializes a new BACnetClient.
-func NewClient(options ClientOptions) (*BACnetClient, error) {
+// NewClient creates and initializes a new BACnetClient from options and opts applied on top of them.
+func NewClient(options ClientOptions, opts ...Option) (*BACnetClient, error) {
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.Transport != nil {
+		return NewClientWithConn(options.Transport, options), nil
+	}
 	conn, err := net.ListenUDP("udp4", options.LocalAddr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to listen on UDP: %w", err)
 	}
+	return NewClientWithConn(conn, options), nil
+}
 
+// NewClientWithConn returns a client that sends and receives through conn instead of its own UDP
+// socket, e.g. a ReplayConn. options.LocalAddr and options.Transport are ignored.
+func NewClientWithConn(conn PacketConn, options ClientOptions, opts ...Option) *BACnetClient {
+	for _, opt := range opts {
+		opt(&options)
+	}
+	closed, closeFunc := context.WithCancel(context.Background())
 	return &BACnetClient{
-		conn:    conn,
-		options: options,
-	}, nil
+		conn:          conn,
+		options:       options,
+		failover:      newFailoverState(),
+		stats:         newClientStats(),
+		reads:         newFlightGroup(),
+		notifications: newNotificationDispatcher(),
+		receiver:      newReceiver(),
+		closed:        closed,
+		closeFunc:     closeFunc,
+	}
 }
 
+// Close ends all subscriptions and closes the connection.
 func (c *BACnetClient) Close() error {
+	c.closeFunc()
 	return c.conn.Close()
 }
 
-// GetConn returns the underlying UDP connection of the client.
+// GetConn returns the underlying UDP connection of the client, or nil if the client was created
+// with NewClientWithConn on another kind of connection. Once the client made a request, subscribed
+// or listened, its receive loop reads the connection; reading it as well takes datagrams from the
+// client.
 func (c *BACnetClient) GetConn() *net.UDPConn {
-	return c.conn
+	conn, _ := c.conn.(*net.UDPConn)
+	return conn
 }
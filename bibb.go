@@ -0,0 +1,168 @@
+package bacnet
+
+import (
+	"fmt"
+	"slices"
+)
+
+// BIBB is a BACnet Interoperability Building Block, the unit in which a PICS states what a
+// product supports. Names ending in -A are client (user) roles, -B server (provider) roles.
+type BIBB struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (b BIBB) String() string { return fmt.Sprintf("%s %s", b.Name, b.Description) }
+
+// serviceChoice identifies a confirmed or unconfirmed service.
+type serviceChoice struct {
+	confirmed bool
+	choice    byte
+}
+
+func confirmed(choice byte) serviceChoice   { return serviceChoice{confirmed: true, choice: choice} }
+func unconfirmed(choice byte) serviceChoice { return serviceChoice{choice: choice} }
+
+// Services BACnetClient sends and processes.
+var (
+	clientInitiates = []serviceChoice{
+		confirmed(SERVICE_CONFIRMED_READ_PROPERTY),
+		confirmed(SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE),
+		confirmed(SERVICE_CONFIRMED_WRITE_PROPERTY),
+		confirmed(SERVICE_CONFIRMED_WRITE_PROPERTY_MULTIPLE),
+		confirmed(SERVICE_CONFIRMED_SUBSCRIBE_COV),
+		confirmed(SERVICE_CONFIRMED_CREATE_OBJECT),
+		confirmed(SERVICE_CONFIRMED_DELETE_OBJECT),
+		confirmed(SERVICE_CONFIRMED_READ_RANGE),
+		confirmed(SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL),
+		confirmed(SERVICE_CONFIRMED_REINITIALIZE_DEVICE),
+		confirmed(SERVICE_CONFIRMED_TEXT_MESSAGE),
+		confirmed(SERVICE_CONFIRMED_SUBSCRIBE_COV_PROPERTY_MULTIPLE),
+		confirmed(SERVICE_CONFIRMED_ACKNOWLEDGE_ALARM),
+		confirmed(SERVICE_CONFIRMED_GET_EVENT_INFORMATION),
+		confirmed(SERVICE_CONFIRMED_GET_ALARM_SUMMARY),
+		confirmed(SERVICE_CONFIRMED_LIFE_SAFETY_OPERATION),
+		unconfirmed(SERVICE_UNCONFIRMED_WHO_IS),
+		unconfirmed(SERVICE_UNCONFIRMED_TEXT_MESSAGE),
+	}
+	clientExecutes = []serviceChoice{
+		unconfirmed(SERVICE_UNCONFIRMED_I_AM),
+		unconfirmed(SERVICE_UNCONFIRMED_COV_NOTIFICATION),
+		unconfirmed(SERVICE_UNCONFIRMED_COV_NOTIFICATION_MULTIPLE),
+		confirmed(SERVICE_CONFIRMED_COV_NOTIFICATION),
+		confirmed(SERVICE_CONFIRMED_COV_NOTIFICATION_MULTIPLE),
+		unconfirmed(SERVICE_UNCONFIRMED_EVENT_NOTIFICATION),
+		confirmed(SERVICE_CONFIRMED_EVENT_NOTIFICATION),
+		unconfirmed(SERVICE_UNCONFIRMED_TEXT_MESSAGE),
+		confirmed(SERVICE_CONFIRMED_TEXT_MESSAGE),
+	}
+)
+
+// Services Server sends, and the unconfirmed services it processes; the confirmed services it
+// executes are those of confirmedHandlers.
+var (
+	serverInitiates = []serviceChoice{
+		unconfirmed(SERVICE_UNCONFIRMED_I_AM),
+		unconfirmed(SERVICE_UNCONFIRMED_I_HAVE),
+		unconfirmed(SERVICE_UNCONFIRMED_COV_NOTIFICATION),
+		unconfirmed(SERVICE_UNCONFIRMED_EVENT_NOTIFICATION),
+		confirmed(SERVICE_CONFIRMED_EVENT_NOTIFICATION),
+	}
+	serverExecutesUnconfirmed = []serviceChoice{
+		unconfirmed(SERVICE_UNCONFIRMED_WHO_IS),
+		unconfirmed(SERVICE_UNCONFIRMED_WHO_HAS),
+		unconfirmed(SERVICE_UNCONFIRMED_I_AM),
+		unconfirmed(SERVICE_UNCONFIRMED_TEXT_MESSAGE),
+	}
+)
+
+// bibbDefinition is a BIBB and the services it requires its role to initiate and execute.
+type bibbDefinition struct {
+	BIBB
+	server    bool
+	initiates []serviceChoice
+	executes  []serviceChoice
+}
+
+// bibbDefinitions are the BIBBs the package may support, in PICS order.
+var bibbDefinitions = []bibbDefinition{
+	{BIBB{"DS-RP-A", "Data Sharing-ReadProperty-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_READ_PROPERTY)}, nil},
+	{BIBB{"DS-RP-B", "Data Sharing-ReadProperty-B"}, true, nil, []serviceChoice{confirmed(SERVICE_CONFIRMED_READ_PROPERTY)}},
+	{BIBB{"DS-RPM-A", "Data Sharing-ReadPropertyMultiple-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE)}, nil},
+	{BIBB{"DS-RPM-B", "Data Sharing-ReadPropertyMultiple-B"}, true, nil, []serviceChoice{confirmed(SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE)}},
+	{BIBB{"DS-WP-A", "Data Sharing-WriteProperty-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_WRITE_PROPERTY)}, nil},
+	{BIBB{"DS-WP-B", "Data Sharing-WriteProperty-B"}, true, nil, []serviceChoice{confirmed(SERVICE_CONFIRMED_WRITE_PROPERTY)}},
+	{BIBB{"DS-WPM-A", "Data Sharing-WritePropertyMultiple-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_WRITE_PROPERTY_MULTIPLE)}, nil},
+	{BIBB{"DS-WPM-B", "Data Sharing-WritePropertyMultiple-B"}, true, nil, []serviceChoice{confirmed(SERVICE_CONFIRMED_WRITE_PROPERTY_MULTIPLE)}},
+	{BIBB{"DS-COV-A", "Data Sharing-COV-A"}, false,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_SUBSCRIBE_COV)},
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_COV_NOTIFICATION), unconfirmed(SERVICE_UNCONFIRMED_COV_NOTIFICATION)}},
+	{BIBB{"DS-COV-B", "Data Sharing-COV-B"}, true,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_COV_NOTIFICATION), unconfirmed(SERVICE_UNCONFIRMED_COV_NOTIFICATION)},
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_SUBSCRIBE_COV)}},
+	{BIBB{"DS-COVM-A", "Data Sharing-COV-Property-Multiple-A"}, false,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_SUBSCRIBE_COV_PROPERTY_MULTIPLE)},
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_COV_NOTIFICATION_MULTIPLE), unconfirmed(SERVICE_UNCONFIRMED_COV_NOTIFICATION_MULTIPLE)}},
+	{BIBB{"AE-N-A", "Alarm and Event-Notification-A"}, false, nil,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_EVENT_NOTIFICATION), unconfirmed(SERVICE_UNCONFIRMED_EVENT_NOTIFICATION)}},
+	{BIBB{"AE-N-I-B", "Alarm and Event-Notification Internal-B"}, true,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_EVENT_NOTIFICATION), unconfirmed(SERVICE_UNCONFIRMED_EVENT_NOTIFICATION)}, nil},
+	{BIBB{"AE-ACK-A", "Alarm and Event-ACK-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_ACKNOWLEDGE_ALARM)}, nil},
+	{BIBB{"AE-INFO-A", "Alarm and Event-Information-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_GET_EVENT_INFORMATION)}, nil},
+	{BIBB{"AE-ASUM-A", "Alarm and Event-Alarm Summary-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_GET_ALARM_SUMMARY)}, nil},
+	{BIBB{"AE-LS-A", "Alarm and Event-Life Safety-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_LIFE_SAFETY_OPERATION)}, nil},
+	{BIBB{"T-VMT-A", "Trending-Viewing and Modifying Trends-A"}, false, []serviceChoice{confirmed(SERVICE_CONFIRMED_READ_RANGE)}, nil},
+	{BIBB{"T-VMT-I-B", "Trending-Viewing and Modifying Trends Internal-B"}, true, nil, []serviceChoice{confirmed(SERVICE_CONFIRMED_READ_RANGE)}},
+	{BIBB{"DM-DDB-A", "Device Management-Dynamic Device Binding-A"}, false,
+		[]serviceChoice{unconfirmed(SERVICE_UNCONFIRMED_WHO_IS)}, []serviceChoice{unconfirmed(SERVICE_UNCONFIRMED_I_AM)}},
+	{BIBB{"DM-DDB-B", "Device Management-Dynamic Device Binding-B"}, true,
+		[]serviceChoice{unconfirmed(SERVICE_UNCONFIRMED_I_AM)}, []serviceChoice{unconfirmed(SERVICE_UNCONFIRMED_WHO_IS)}},
+	{BIBB{"DM-DOB-A", "Device Management-Dynamic Object Binding-A"}, false,
+		[]serviceChoice{unconfirmed(SERVICE_UNCONFIRMED_WHO_HAS)}, []serviceChoice{unconfirmed(SERVICE_UNCONFIRMED_I_HAVE)}},
+	{BIBB{"DM-DOB-B", "Device Management-Dynamic Object Binding-B"}, true,
+		[]serviceChoice{unconfirmed(SERVICE_UNCONFIRMED_I_HAVE)}, []serviceChoice{unconfirmed(SERVICE_UNCONFIRMED_WHO_HAS)}},
+	{BIBB{"DM-DCC-A", "Device Management-DeviceCommunicationControl-A"}, false,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL)}, nil},
+	{BIBB{"DM-DCC-B", "Device Management-DeviceCommunicationControl-B"}, true, nil,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL)}},
+	{BIBB{"DM-RD-A", "Device Management-ReinitializeDevice-A"}, false,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_REINITIALIZE_DEVICE)}, nil},
+	{BIBB{"DM-RD-B", "Device Management-ReinitializeDevice-B"}, true, nil,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_REINITIALIZE_DEVICE)}},
+	{BIBB{"DM-OCD-A", "Device Management-Object Creation and Deletion-A"}, false,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_CREATE_OBJECT), confirmed(SERVICE_CONFIRMED_DELETE_OBJECT)}, nil},
+	{BIBB{"DM-OCD-B", "Device Management-Object Creation and Deletion-B"}, true, nil,
+		[]serviceChoice{confirmed(SERVICE_CONFIRMED_CREATE_OBJECT), confirmed(SERVICE_CONFIRMED_DELETE_OBJECT)}},
+}
+
+// SupportedBIBBs returns the BIBBs the package implements, for the PICS of a product built on
+// it: the -A blocks of BACnetClient and the -B blocks of Server. The list is derived from the
+// services the client and server send and process, so it follows the package as services are
+// added.
+func SupportedBIBBs() []BIBB {
+	serverExecutes := slices.Clone(serverExecutesUnconfirmed)
+	for choice := range confirmedHandlers {
+		serverExecutes = append(serverExecutes, confirmed(choice))
+	}
+
+	var bibbs []BIBB
+	for _, def := range bibbDefinitions {
+		initiates, executes := clientInitiates, clientExecutes
+		if def.server {
+			initiates, executes = serverInitiates, serverExecutes
+		}
+		if containsServices(initiates, def.initiates) && containsServices(executes, def.executes) {
+			bibbs = append(bibbs, def.BIBB)
+		}
+	}
+	return bibbs
+}
+
+func containsServices(have, want []serviceChoice) bool {
+	for _, service := range want {
+		if !slices.Contains(have, service) {
+			return false
+		}
+	}
+	return true
+}
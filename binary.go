@@ -0,0 +1,109 @@
+package bacnet
+
+import "fmt"
+
+// BinaryPV is the Present_Value of a Binary Input, Output or Value.
+type BinaryPV uint32
+
+const (
+	BinaryInactive BinaryPV = 0
+	BinaryActive   BinaryPV = 1
+)
+
+func (v BinaryPV) String() string {
+	switch v {
+	case BinaryInactive:
+		return "inactive"
+	case BinaryActive:
+		return "active"
+	}
+	return fmt.Sprintf("%d", uint32(v))
+}
+
+// Text returns activeText or inactiveText depending on v, falling back to v.String() when the
+// text is empty.
+func (v BinaryPV) Text(activeText, inactiveText string) string {
+	switch {
+	case v == BinaryActive && activeText != "":
+		return activeText
+	case v == BinaryInactive && inactiveText != "":
+		return inactiveText
+	}
+	return v.String()
+}
+
+// Polarity is the Polarity of a Binary Input or Output.
+type Polarity uint32
+
+const (
+	PolarityNormal  Polarity = 0
+	PolarityReverse Polarity = 1
+)
+
+func (p Polarity) String() string {
+	switch p {
+	case PolarityNormal:
+		return "normal"
+	case PolarityReverse:
+		return "reverse"
+	}
+	return fmt.Sprintf("%d", uint32(p))
+}
+
+// BinaryState is the present value of a binary object together with its display text.
+type BinaryState struct {
+	Value BinaryPV
+	// Text is Active_Text or Inactive_Text, or the value's name if the object has no text.
+	Text     string
+	Polarity Polarity
+}
+
+// ReadBinaryState reads the Present_Value of a binary object and maps it through the object's
+// Active_Text and Inactive_Text. Text and polarity are optional properties; they are left at their
+// defaults if the device does not have them.
+func (c *BACnetClient) ReadBinaryState(device DeviceInfo, object BACnetObject, opts ...CallOption) (BinaryState, error) {
+	if !isBinaryObjectType(object.Type) {
+		return BinaryState{}, fmt.Errorf("%s is not a binary object", object)
+	}
+
+	var state BinaryState
+	var activeText, inactiveText string
+	var pvErr error
+	err := c.ReadPropertyMultipleStream(device, []BACnetObject{object},
+		[]uint32{PROP_PRESENT_VALUE, PROP_ACTIVE_TEXT, PROP_INACTIVE_TEXT, PROP_POLARITY},
+		func(result RPMResult) bool {
+			switch result.PropertyID {
+			case PROP_PRESENT_VALUE:
+				if result.Err != nil {
+					pvErr = result.Err
+					return false
+				}
+				v, ok := result.Value.(BinaryPV)
+				if !ok {
+					pvErr = fmt.Errorf("unexpected present value %v (%T)", result.Value, result.Value)
+					return false
+				}
+				state.Value = v
+			case PROP_ACTIVE_TEXT:
+				activeText, _ = result.Value.(string)
+			case PROP_INACTIVE_TEXT:
+				inactiveText, _ = result.Value.(string)
+			case PROP_POLARITY:
+				state.Polarity, _ = result.Value.(Polarity)
+			}
+			return true
+		}, opts...)
+	if err != nil {
+		return BinaryState{}, err
+	}
+	if pvErr != nil {
+		return BinaryState{}, fmt.Errorf("failed to read present value of %s: %w", object, pvErr)
+	}
+
+	state.Text = state.Value.Text(activeText, inactiveText)
+	return state, nil
+}
+
+func isBinaryObjectType(t ObjectType) bool {
+	return t == OBJECT_BINARY_INPUT || t == OBJECT_BINARY_OUTPUT || t == OBJECT_BINARY_VALUE
+}
@@ -0,0 +1,96 @@
+package bacnet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+var bvlcFunctionNames = map[byte]string{
+	BVLC_RESULT:                            "BVLC-Result",
+	BVLC_WRITE_BROADCAST_DIST_TABLE:        "Write-Broadcast-Distribution-Table",
+	BVLC_READ_BROADCAST_DIST_TABLE:         "Read-Broadcast-Distribution-Table",
+	BVLC_READ_BROADCAST_DIST_TABLE_ACK:     "Read-Broadcast-Distribution-Table-Ack",
+	BVLC_FORWARDED_NPDU:                    "Forwarded-NPDU",
+	BVLC_REGISTER_FOREIGN_DEVICE:           "Register-Foreign-Device",
+	BVLC_READ_FOREIGN_DEVICE_TABLE:         "Read-Foreign-Device-Table",
+	BVLC_READ_FOREIGN_DEVICE_TABLE_ACK:     "Read-Foreign-Device-Table-Ack",
+	BVLC_DELETE_FOREIGN_DEVICE_TABLE_ENTRY: "Delete-Foreign-Device-Table-Entry",
+	BVLC_DISTRIBUTE_BROADCAST_TO_NETWORK:   "Distribute-Broadcast-To-Network",
+	BVLC_ORIGINAL_UNICAST_NPDU:             "Original-Unicast-NPDU",
+	BVLC_ORIGINAL_BROADCAST_NPDU:           "Original-Broadcast-NPDU",
+}
+
+// bvlcNAKs are the BVLC-Result codes with which a node that is not a BBMD rejects BBMD requests.
+var bvlcNAKs = map[byte]uint16{
+	BVLC_WRITE_BROADCAST_DIST_TABLE:        0x0010,
+	BVLC_READ_BROADCAST_DIST_TABLE:         0x0020,
+	BVLC_REGISTER_FOREIGN_DEVICE:           0x0030,
+	BVLC_READ_FOREIGN_DEVICE_TABLE:         0x0040,
+	BVLC_DELETE_FOREIGN_DEVICE_TABLE_ENTRY: 0x0050,
+	BVLC_DISTRIBUTE_BROADCAST_TO_NETWORK:   0x0060,
+}
+
+// UnexpectedBVLC describes a received datagram whose BVLC function the receiver does not accept in
+// its role. Clients and servers of this package are plain BACnet/IP nodes, not BBMDs, so they only
+// accept NPDUs and BVLC-Results; BBMD requests such as Register-Foreign-Device, acks they never
+// asked for and unknown functions are unexpected.
+type UnexpectedBVLC struct {
+	Function byte
+	Source   *net.UDPAddr
+	Time     time.Time
+	// Rejected is set if the receiver answered with a BVLC-Result NAK.
+	Rejected bool
+}
+
+func (u UnexpectedBVLC) String() string {
+	name, ok := bvlcFunctionNames[u.Function]
+	if !ok {
+		name = fmt.Sprintf("BVLC function 0x%02x", u.Function)
+	}
+	return fmt.Sprintf("unexpected %s from %s", name, u.Source)
+}
+
+// expectedBVLC reports whether a node that is not a BBMD accepts a BVLC function.
+func expectedBVLC(function byte) bool {
+	switch function {
+	case BVLC_ORIGINAL_UNICAST_NPDU, BVLC_ORIGINAL_BROADCAST_NPDU, BVLC_FORWARDED_NPDU, BVLC_RESULT:
+		return true
+	}
+	return false
+}
+
+// bvlcResultPacket builds a BVLC-Result with the given result code.
+func bvlcResultPacket(code uint16) []byte {
+	packet := []byte{BVLC_TYPE_BACNET_IP, BVLC_RESULT, 0, 6}
+	return binary.BigEndian.AppendUint16(packet, code)
+}
+
+// screenBVLC reports whether a datagram from addr carries a BVLC function the client accepts. Other
+// datagrams are counted and reported to ClientOptions.OnUnexpectedBVLC; datagrams that are not
+// BACnet/IP at all are left to the parser.
+func (c *BACnetClient) screenBVLC(data []byte, addr *net.UDPAddr) bool {
+	if len(data) < 2 || data[0] != BVLC_TYPE_BACNET_IP || expectedBVLC(data[1]) {
+		return true
+	}
+	c.stats.unexpectedBVLC()
+	c.logf("bacnet: discarded unexpected BVLC function 0x%02x from %s", data[1], addr)
+	if c.options.OnUnexpectedBVLC != nil {
+		c.options.OnUnexpectedBVLC(UnexpectedBVLC{Function: data[1], Source: addr, Time: time.Now()})
+	}
+	return false
+}
+
+// rejectBVLC reports a datagram with an unexpected BVLC function to Server.OnUnexpectedBVLC and
+// returns the NAK to send if the function is a BBMD request.
+func (s *Server) rejectBVLC(function byte, addr *net.UDPAddr) []byte {
+	code, isRequest := bvlcNAKs[function]
+	if s.OnUnexpectedBVLC != nil {
+		s.OnUnexpectedBVLC(UnexpectedBVLC{Function: function, Source: addr, Time: time.Now(), Rejected: isRequest})
+	}
+	if !isRequest {
+		return nil
+	}
+	return bvlcResultPacket(code)
+}
@@ -0,0 +1,36 @@
+package bacnet
+
+import (
+	"context"
+	"time"
+)
+
+// CallInfo is the timing of one call, filled in when the call is made with the CallStats option.
+// Calls made of several requests, e.g. chunked array reads, add up the figures of all of them.
+type CallInfo struct {
+	// Transactions is the number of confirmed requests sent, not counting retries.
+	Transactions int `json:"transactions"`
+	// Retries is the number of times a request was resent after a timeout.
+	Retries int `json:"retries"`
+	// Segments is the number of segments of segmented responses received.
+	Segments int `json:"segments,omitempty"`
+	// RoundTrip is the time from sending the attempt that was answered to the response.
+	RoundTrip time.Duration `json:"roundTrip"`
+	// Elapsed is the time spent in the requests, including timed-out attempts and the transfer
+	// of further segments.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// CallStats fills in info with the timing of one call, e.g. to measure point latency per device.
+// CallStats resets info, which must not be shared by concurrent calls. A read coalesced with an
+// identical read already in flight sends no requests of its own and leaves info empty.
+func CallStats(info *CallInfo) CallOption {
+	*info = CallInfo{}
+	return func(o *callOptions) { o.info = info }
+}
+
+// callInfo returns the CallInfo requests made with ctx report to, or nil.
+func callInfo(ctx context.Context) *CallInfo {
+	call, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	return call.info
+}
@@ -0,0 +1,263 @@
+package bacnet
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Catalog holds display names that replace or extend the built-in English names of object types,
+// properties, engineering units and error classes and codes, e.g. translated names for a user
+// interface or the names of proprietary object types and properties. Names missing from a catalog
+// fall back to the built-in ones.
+//
+// The names are used by ObjectTypeName, PropertyName, UnitName, ErrorClassName and ErrorCodeName,
+// and so by FormatProperties, FormatValue and error messages. Identifiers that are parsed back,
+// such as BACnetObject.String and the columns of point lists, keep the built-in names.
+type Catalog struct {
+	ObjectTypes  map[ObjectType]string `json:"objectTypes,omitempty"`
+	Properties   map[uint32]string     `json:"properties,omitempty"`
+	Units        map[uint32]string     `json:"units,omitempty"`
+	ErrorClasses map[uint32]string     `json:"errorClasses,omitempty"`
+	ErrorCodes   map[uint32]string     `json:"errorCodes,omitempty"`
+}
+
+var activeCatalog atomic.Pointer[Catalog]
+
+// SetCatalog makes c the catalog of display names; nil restores the built-in names. It may be
+// called at any time, also while other goroutines format names, but c must not be modified
+// afterwards: set a new catalog instead.
+func SetCatalog(c *Catalog) {
+	activeCatalog.Store(c)
+}
+
+// ActiveCatalog returns the catalog set with SetCatalog, or nil.
+func ActiveCatalog() *Catalog {
+	return activeCatalog.Load()
+}
+
+// lookupName returns the name of key in the catalog map selected by field, or else in builtin.
+func lookupName[K comparable](field func(*Catalog) map[K]string, builtin map[K]string, key K) (string, bool) {
+	if c := activeCatalog.Load(); c != nil {
+		if name, ok := field(c)[key]; ok {
+			return name, true
+		}
+	}
+	name, ok := builtin[key]
+	return name, ok
+}
+
+// ObjectTypeName returns the display name of an object type. It reports false for types without
+// a name.
+func ObjectTypeName(objectType ObjectType) (string, bool) {
+	return lookupName(func(c *Catalog) map[ObjectType]string { return c.ObjectTypes }, ObjectTypeNames, objectType)
+}
+
+// PropertyName returns the display name of a property. It reports false for properties without a
+// name.
+func PropertyName(propertyID uint32) (string, bool) {
+	return lookupName(func(c *Catalog) map[uint32]string { return c.Properties }, PropertyNames, propertyID)
+}
+
+// UnitName returns the display name of an engineering unit, the value of the Units property. It
+// reports false for units without a name.
+func UnitName(units uint32) (string, bool) {
+	return lookupName(func(c *Catalog) map[uint32]string { return c.Units }, unitNames, units)
+}
+
+// ErrorClassName returns the display name of an error class. It reports false for classes
+// without a name.
+func ErrorClassName(class uint32) (string, bool) {
+	return lookupName(func(c *Catalog) map[uint32]string { return c.ErrorClasses }, errorClassNames, class)
+}
+
+// ErrorCodeName returns the display name of an error code. It reports false for codes without a
+// name.
+func ErrorCodeName(code uint32) (string, bool) {
+	return lookupName(func(c *Catalog) map[uint32]string { return c.ErrorCodes }, errorCodeNames, code)
+}
+
+// errorLabel returns the names of an error class and code for messages, or their numbers if they
+// have none.
+func errorLabel(class, code uint32) string {
+	className, ok := ErrorClassName(class)
+	if !ok {
+		className = fmt.Sprintf("class %d", class)
+	}
+	codeName, ok := ErrorCodeName(code)
+	if !ok {
+		codeName = fmt.Sprintf("code %d", code)
+	}
+	return className + ": " + codeName
+}
+
+var errorClassNames = map[uint32]string{
+	0: "device",
+	1: "object",
+	2: "property",
+	3: "resources",
+	4: "security",
+	5: "services",
+	6: "vt",
+	7: "communication",
+}
+
+var errorCodeNames = map[uint32]string{
+	0:  "other",
+	1:  "authentication-failed",
+	2:  "configuration-in-progress",
+	3:  "device-busy",
+	4:  "dynamic-creation-not-supported",
+	5:  "file-access-denied",
+	6:  "incompatible-security-levels",
+	7:  "inconsistent-parameters",
+	8:  "inconsistent-selection-criterion",
+	9:  "invalid-data-type",
+	10: "invalid-file-access-method",
+	11: "invalid-file-start-position",
+	12: "invalid-operator-name",
+	13: "invalid-parameter-data-type",
+	14: "invalid-time-stamp",
+	15: "key-generation-error",
+	16: "missing-required-parameter",
+	17: "no-objects-of-specified-type",
+	18: "no-space-for-object",
+	19: "no-space-to-add-list-element",
+	20: "no-space-to-write-property",
+	21: "no-vt-sessions-available",
+	22: "property-is-not-a-list",
+	23: "object-deletion-not-permitted",
+	24: "object-identifier-already-exists",
+	25: "operational-problem",
+	26: "password-failure",
+	27: "read-access-denied",
+	28: "security-not-supported",
+	29: "service-request-denied",
+	30: "timeout",
+	31: "unknown-object",
+	32: "unknown-property",
+	34: "unknown-vt-class",
+	35: "unknown-vt-session",
+	36: "unsupported-object-type",
+	37: "value-out-of-range",
+	38: "vt-session-already-closed",
+	39: "vt-session-termination-failure",
+	40: "write-access-denied",
+	41: "character-set-not-supported",
+	42: "invalid-array-index",
+	43: "cov-subscription-failed",
+	44: "not-cov-property",
+	45: "optional-functionality-not-supported",
+	46: "invalid-configuration-data",
+	47: "datatype-not-supported",
+	48: "duplicate-name",
+	49: "duplicate-object-id",
+	50: "property-is-not-an-array",
+}
+
+var unitNames = map[uint32]string{
+	0:   "square-meters",
+	1:   "square-feet",
+	2:   "milliamperes",
+	3:   "amperes",
+	4:   "ohms",
+	5:   "volts",
+	6:   "kilovolts",
+	7:   "megavolts",
+	8:   "volt-amperes",
+	9:   "kilovolt-amperes",
+	10:  "megavolt-amperes",
+	11:  "volt-amperes-reactive",
+	12:  "kilovolt-amperes-reactive",
+	13:  "megavolt-amperes-reactive",
+	14:  "degrees-phase",
+	15:  "power-factor",
+	16:  "joules",
+	17:  "kilojoules",
+	18:  "watt-hours",
+	19:  "kilowatt-hours",
+	20:  "btus",
+	21:  "therms",
+	22:  "ton-hours",
+	23:  "joules-per-kilogram-dry-air",
+	24:  "btus-per-pound-dry-air",
+	25:  "cycles-per-hour",
+	26:  "cycles-per-minute",
+	27:  "hertz",
+	28:  "grams-of-water-per-kilogram-dry-air",
+	29:  "percent-relative-humidity",
+	30:  "millimeters",
+	31:  "meters",
+	32:  "inches",
+	33:  "feet",
+	34:  "watts-per-square-foot",
+	35:  "watts-per-square-meter",
+	36:  "lumens",
+	37:  "luxes",
+	38:  "foot-candles",
+	39:  "kilograms",
+	40:  "pounds-mass",
+	41:  "tons",
+	42:  "kilograms-per-second",
+	43:  "kilograms-per-minute",
+	44:  "kilograms-per-hour",
+	45:  "pounds-mass-per-minute",
+	46:  "pounds-mass-per-hour",
+	47:  "watts",
+	48:  "kilowatts",
+	49:  "megawatts",
+	50:  "btus-per-hour",
+	51:  "horsepower",
+	52:  "tons-refrigeration",
+	53:  "pascals",
+	54:  "kilopascals",
+	55:  "bars",
+	56:  "pounds-force-per-square-inch",
+	57:  "centimeters-of-water",
+	58:  "inches-of-water",
+	59:  "millimeters-of-mercury",
+	60:  "centimeters-of-mercury",
+	61:  "inches-of-mercury",
+	62:  "degrees-celsius",
+	63:  "degrees-kelvin",
+	64:  "degrees-fahrenheit",
+	65:  "degree-days-celsius",
+	66:  "degree-days-fahrenheit",
+	67:  "years",
+	68:  "months",
+	69:  "weeks",
+	70:  "days",
+	71:  "hours",
+	72:  "minutes",
+	73:  "seconds",
+	74:  "meters-per-second",
+	75:  "kilometers-per-hour",
+	76:  "feet-per-second",
+	77:  "feet-per-minute",
+	78:  "miles-per-hour",
+	79:  "cubic-feet",
+	80:  "cubic-meters",
+	81:  "imperial-gallons",
+	82:  "liters",
+	83:  "us-gallons",
+	84:  "cubic-feet-per-minute",
+	85:  "cubic-meters-per-second",
+	86:  "imperial-gallons-per-minute",
+	87:  "liters-per-second",
+	88:  "liters-per-minute",
+	89:  "us-gallons-per-minute",
+	90:  "degrees-angular",
+	91:  "degrees-celsius-per-hour",
+	92:  "degrees-celsius-per-minute",
+	93:  "degrees-fahrenheit-per-hour",
+	94:  "degrees-fahrenheit-per-minute",
+	95:  "no-units",
+	96:  "parts-per-million",
+	97:  "parts-per-billion",
+	98:  "percent",
+	99:  "percent-per-second",
+	100: "per-minute",
+	101: "per-second",
+	102: "psi-per-degree-fahrenheit",
+	103: "radians",
+	104: "revolutions-per-minute",
+}
@@ -0,0 +1,190 @@
+// Command contextgen generates a ...Context variant of every exported BACnetClient method that takes
+// CallOptions, taking the context as its first parameter like the other methods of the package that
+// take one. It is run by go generate in the package directory.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var out = flag.String("out", "context_gen.go", "generated Go file")
+
+// method is a BACnetClient method taking CallOptions.
+type method struct {
+	name    string
+	params  []param // Without the CallOptions
+	results string
+}
+
+type param struct {
+	name, typ string
+}
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var methods []method
+	imports := map[string]bool{"context": true}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") || name == *out {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, parser.SkipObjectResolution)
+		if err != nil {
+			log.Fatal(err)
+		}
+		paths := make(map[string]string) // Package name to import path
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			pkg := filepath.Base(path)
+			if spec.Name != nil {
+				pkg = spec.Name.Name
+			}
+			paths[pkg] = path
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !isClientMethod(fn) {
+				continue
+			}
+			m, ok := clientMethod(fset, fn)
+			if !ok {
+				continue
+			}
+			ast.Inspect(fn.Type, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					if id, ok := sel.X.(*ast.Ident); ok && paths[id.Name] != "" {
+						imports[paths[id.Name]] = true
+					}
+				}
+				return true
+			})
+			methods = append(methods, m)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by contextgen; DO NOT EDIT.\n\npackage bacnet\n\nimport (\n")
+	var paths []string
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(&buf, "\t%q\n", path)
+	}
+	buf.WriteString(")\n")
+	for _, m := range methods {
+		var params, args []string
+		params = append(params, "ctx context.Context")
+		for _, p := range m.params {
+			params = append(params, p.name+" "+p.typ)
+			args = append(args, p.name)
+		}
+		params = append(params, "opts ...CallOption")
+		args = append(args, "withContext(ctx, opts)...")
+		fmt.Fprintf(&buf, "\n// %sContext is %s made with ctx; see CallContext.\n", m.name, m.name)
+		fmt.Fprintf(&buf, "func (c *BACnetClient) %sContext(%s) %s {\n", m.name, strings.Join(params, ", "), m.results)
+		call := fmt.Sprintf("c.%s(%s)", m.name, strings.Join(args, ", "))
+		if m.results == "" {
+			fmt.Fprintf(&buf, "\t%s\n}\n", call)
+		} else {
+			fmt.Fprintf(&buf, "\treturn %s\n}\n", call)
+		}
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("%v\n%s", err, buf.Bytes())
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// isClientMethod reports whether fn is an exported method of *BACnetClient.
+func isClientMethod(fn *ast.FuncDecl) bool {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 || !fn.Name.IsExported() {
+		return false
+	}
+	star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	id, ok := star.X.(*ast.Ident)
+	return ok && id.Name == "BACnetClient"
+}
+
+// clientMethod describes fn if its last parameter is opts ...CallOption.
+func clientMethod(fset *token.FileSet, fn *ast.FuncDecl) (method, bool) {
+	params := fn.Type.Params.List
+	if len(params) == 0 {
+		return method{}, false
+	}
+	last := params[len(params)-1]
+	ellipsis, ok := last.Type.(*ast.Ellipsis)
+	if !ok || len(last.Names) != 1 {
+		return method{}, false
+	}
+	if id, ok := ellipsis.Elt.(*ast.Ident); !ok || id.Name != "CallOption" {
+		return method{}, false
+	}
+
+	if strings.HasSuffix(fn.Name.Name, "Context") {
+		return method{}, false
+	}
+
+	m := method{name: fn.Name.Name}
+	for _, field := range params[:len(params)-1] {
+		if len(field.Names) == 0 {
+			return method{}, false
+		}
+		typ := source(fset, field.Type)
+		for _, name := range field.Names {
+			m.params = append(m.params, param{name.Name, typ})
+		}
+	}
+	if fn.Type.Results != nil {
+		var results []string
+		for _, field := range fn.Type.Results.List {
+			typ := source(fset, field.Type)
+			if len(field.Names) == 0 {
+				results = append(results, typ)
+			}
+			for _, name := range field.Names {
+				results = append(results, name.Name+" "+typ)
+			}
+		}
+		m.results = strings.Join(results, ", ")
+		if len(results) > 1 || len(fn.Type.Results.List[0].Names) > 0 {
+			m.results = "(" + m.results + ")"
+		}
+	}
+	return m, true
+}
+
+func source(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, node)
+	return buf.String()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/maxzerker/bacnet"
+)
+
+func main() {
+	if len(os.Args) != 6 {
+		log.Fatalf("Usage: %s <interface> <device-id> <schedule-instance> <weekday 1-7> \"07:00 = 21.5, 18:00 = null\"", os.Args[0])
+	}
+	deviceID, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid device-id: %v", err)
+	}
+	instance, err := strconv.Atoi(os.Args[3])
+	if err != nil {
+		log.Fatalf("Invalid schedule-instance: %v", err)
+	}
+	weekday, err := strconv.Atoi(os.Args[4])
+	if err != nil {
+		log.Fatalf("Invalid weekday: %v", err)
+	}
+	values, err := bacnet.ParseDailySchedule(os.Args[5])
+	if err != nil {
+		log.Fatalf("Invalid schedule: %v", err)
+	}
+
+	client, broadcastAddr, err := bacnet.QuickClient(os.Args[1])
+	if err != nil {
+		log.Fatalf("Failed to create BACnet client: %v", err)
+	}
+	defer client.Close()
+
+	target := uint32(deviceID)
+	devices, err := client.WhoIsWithOptions(broadcastAddr, bacnet.WhoIsOptions{DeviceID: &target})
+	if err != nil {
+		log.Fatalf("WhoIs failed: %v", err)
+	}
+	if len(devices) == 0 {
+		log.Fatalf("Device with ID %d not found", deviceID)
+	}
+
+	schedule := bacnet.BACnetObject{Type: bacnet.OBJECT_SCHEDULE, Instance: uint32(instance)}
+	if err := client.WriteDailySchedule(devices[0], schedule, uint8(weekday), values); err != nil {
+		log.Fatalf("Writing the schedule failed: %v", err)
+	}
+	fmt.Printf("Wrote day %d of %s:\n", weekday, schedule)
+	for _, tv := range values {
+		fmt.Printf("  %s\n", tv)
+	}
+}
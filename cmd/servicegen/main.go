@@ -0,0 +1,371 @@
+// Command servicegen generates the request and acknowledgment structs of confirmed services, their
+// encoders and decoders and the BACnetClient methods sending them, from the service description
+// table services.json. It is run by go generate in the package directory.
+//
+// A service lists the fields of its request and, unless it is answered with a Simple-ACK, of its
+// ack. Fields are context-tagged if they have a tag and application-tagged otherwise. Their type is
+// one of unsigned, enumerated, boolean, real, string, object, bits and timestamp, or a struct of
+// the table; list makes a field a SEQUENCE OF, enclosed in its tag or, without a tag, running to the
+// end of the message.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+)
+
+var (
+	in  = flag.String("in", "services.json", "service description table")
+	out = flag.String("out", "services_gen.go", "generated Go file")
+)
+
+// table is the service description table.
+type table struct {
+	Structs  []structDef  `json:"structs"`
+	Services []serviceDef `json:"services"`
+}
+
+type structDef struct {
+	Name   string     `json:"name"`
+	Doc    string     `json:"doc"`
+	Fields []fieldDef `json:"fields"`
+}
+
+type serviceDef struct {
+	// Name is the service name, e.g. "GetAlarmSummary".
+	Name string `json:"name"`
+	// Choice is the constant of the service choice.
+	Choice string `json:"choice"`
+	Doc    string `json:"doc"`
+	// Mutation makes the client check the request with its Authorize hook and read-only mode.
+	Mutation bool       `json:"mutation"`
+	Request  []fieldDef `json:"request"`
+	// Ack is nil for services answered with a Simple-ACK.
+	Ack []fieldDef `json:"ack"`
+}
+
+type fieldDef struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc"`
+	Tag  *uint8 `json:"tag"`
+	Type string `json:"type"`
+	// GoType is the Go type of an enumerated field, e.g. "EventState"; uint32 by default.
+	GoType   string `json:"goType"`
+	Optional bool   `json:"optional"`
+	List     bool   `json:"list"`
+}
+
+// kind is how a type is encoded.
+type kind struct {
+	goType string
+	// contextEncode and appEncode are format strings of the encoding statement, taking the tag
+	// number and the value.
+	contextEncode, appEncode string
+	// contextDecode and appDecode are format strings of an expression decoding the value and an
+	// error, taking the tag number.
+	contextDecode, appDecode string
+}
+
+var kinds = map[string]kind{
+	"unsigned": {"uint32",
+		"encoding.EncodeContextUnsigned(buf, %d, %s)", "encoding.EncodeApplicationUnsigned(buf, %[2]s)",
+		"encoding.DecodeContextUnsigned(r, %d)", "decodeApplicationAs[uint32](r, encoding.TagUnsignedInt)"},
+	"enumerated": {"uint32",
+		"encoding.EncodeContextEnumerated(buf, %d, uint32(%s))", "encoding.EncodeApplicationEnumerated(buf, uint32(%[2]s))",
+		"encoding.DecodeContextUnsigned(r, %d)", "decodeApplicationAs[uint32](r, encoding.TagEnumerated)"},
+	"boolean": {"bool",
+		"encoding.EncodeContextBoolean(buf, %d, %s)", "encoding.EncodeApplicationBoolean(buf, %[2]s)",
+		"encoding.DecodeContextBoolean(r, %d)", "decodeApplicationAs[bool](r, encoding.TagBoolean)"},
+	"real": {"float32",
+		"encoding.EncodeContextReal(buf, %d, %s)", "encoding.EncodeApplicationReal(buf, %[2]s)",
+		"encoding.DecodeContextReal(r, %d)", "decodeApplicationAs[float32](r, encoding.TagReal)"},
+	"string": {"string",
+		"encoding.EncodeContextCharacterString(buf, %d, %s)", "encoding.EncodeApplicationCharacterString(buf, %[2]s)",
+		"encoding.DecodeContextCharacterString(r, %d)", "decodeApplicationAs[string](r, encoding.TagCharacterString)"},
+	"object": {"BACnetObject",
+		"encoding.EncodeContextObjectID(buf, %d, uint32(%[2]s.Type), %[2]s.Instance)", "encoding.EncodeApplicationObjectID(buf, uint32(%[2]s.Type), %[2]s.Instance)",
+		"decodeContextObject(r, %d)", "decodeApplicationAs[BACnetObject](r, encoding.TagObjectID)"},
+	"bits": {"[]bool",
+		"encodeContextBits(buf, %d, %s)", "encoding.EncodeApplicationBitString(buf, %[2]s)",
+		"decodeContextBits(r, %d)", "decodeApplicationBits(r)"},
+	"timestamp": {"time.Time",
+		"encodeContextTimeStamp(buf, %d, %s)", "",
+		"decodeTimeStamp(r, %d)", ""},
+}
+
+func main() {
+	flag.Parse()
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var t table
+	if err := json.Unmarshal(data, &t); err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+	g := &generator{structs: make(map[string]bool)}
+	for _, s := range t.Structs {
+		g.structs[s.Name] = true
+	}
+	src, err := g.generate(t)
+	if err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type generator struct {
+	buf      bytes.Buffer
+	structs  map[string]bool
+	usesTime bool
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generate(t table) ([]byte, error) {
+	var body generator
+	body.structs = g.structs
+	for _, s := range t.Structs {
+		if err := body.message(s.Name, s.Doc, s.Fields); err != nil {
+			return nil, fmt.Errorf("struct %s: %w", s.Name, err)
+		}
+	}
+	for _, s := range t.Services {
+		if err := body.service(s); err != nil {
+			return nil, fmt.Errorf("service %s: %w", s.Name, err)
+		}
+	}
+
+	g.printf("// Code generated by servicegen from %s; DO NOT EDIT.\n\n", *in)
+	g.printf("package bacnet\n\nimport (\n\t\"bytes\"\n")
+	if body.usesTime {
+		g.printf("\t\"time\"\n")
+	}
+	g.printf("\n\t\"github.com/maxzerker/bacnet/encoding\"\n)\n")
+	g.buf.Write(body.buf.Bytes())
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, g.buf.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) service(s serviceDef) error {
+	if err := g.message(s.Name+"Request", fmt.Sprintf("%sRequest is the request of the %s service.", s.Name, s.Name), s.Request); err != nil {
+		return err
+	}
+	ack := s.Ack != nil
+	if ack {
+		if err := g.message(s.Name+"Ack", fmt.Sprintf("%sAck is the acknowledgment of the %s service.", s.Name, s.Name), s.Ack); err != nil {
+			return err
+		}
+	}
+
+	g.printf("\n")
+	comment(&g.buf, "", s.Doc)
+	if ack {
+		g.printf("func (c *BACnetClient) %s(device DeviceInfo, request %[1]sRequest, opts ...CallOption) (%[1]sAck, error) {\n", s.Name)
+		g.printf("\tvar ack %sAck\n", s.Name)
+		g.printf("\terr := c.confirmedService(callContext(opts...), device, %s, %t, &request, &ack)\n", s.Choice, s.Mutation)
+		g.printf("\treturn ack, err\n}\n")
+	} else {
+		g.printf("func (c *BACnetClient) %s(device DeviceInfo, request %[1]sRequest, opts ...CallOption) error {\n", s.Name)
+		g.printf("\treturn c.confirmedService(callContext(opts...), device, %s, %t, &request, nil)\n}\n", s.Choice, s.Mutation)
+	}
+	return nil
+}
+
+// message generates a struct with its encode and decode methods.
+func (g *generator) message(name, doc string, fields []fieldDef) error {
+	for i, f := range fields {
+		k, ok := kinds[f.Type]
+		switch {
+		case !ok && !g.structs[f.Type]:
+			return fmt.Errorf("field %s: unknown type %q", f.Name, f.Type)
+		case ok && (f.Tag == nil || f.List) && k.appEncode == "":
+			return fmt.Errorf("field %s: %s fields need a tag and cannot be lists", f.Name, f.Type)
+		case f.List && f.Tag == nil && i != len(fields)-1:
+			return fmt.Errorf("field %s: lists without a tag must come last", f.Name)
+		case f.Optional && f.Tag == nil:
+			return fmt.Errorf("field %s: optional fields need a tag", f.Name)
+		}
+		if f.Type == "timestamp" {
+			g.usesTime = true
+		}
+	}
+
+	g.printf("\n")
+	comment(&g.buf, "", doc)
+	g.printf("type %s struct {\n", name)
+	for _, f := range fields {
+		comment(&g.buf, "\t", f.Doc)
+		g.printf("\t%s %s `json:\"%s%s\"`\n", f.Name, g.goType(f), jsonName(f.Name), map[bool]string{true: ",omitempty"}[f.Optional || f.List])
+	}
+	g.printf("}\n")
+
+	g.printf("\nfunc (m *%s) encode(buf *bytes.Buffer) {\n", name)
+	for _, f := range fields {
+		g.encodeField(f)
+	}
+	g.printf("}\n")
+
+	g.printf("\nfunc (m *%s) decode(r *bytes.Reader) error {\n", name)
+	for _, f := range fields {
+		g.decodeField(f)
+	}
+	g.printf("\treturn nil\n}\n")
+	return nil
+}
+
+func (g *generator) goType(f fieldDef) string {
+	t := f.Type
+	if k, ok := kinds[f.Type]; ok {
+		t = k.goType
+		if f.GoType != "" {
+			t = f.GoType
+		}
+	}
+	switch {
+	case f.List:
+		return "[]" + t
+	case f.Optional:
+		return "*" + t
+	}
+	return t
+}
+
+// elementType is the Go type of a field without its list or pointer.
+func (g *generator) elementType(f fieldDef) string {
+	return g.goType(fieldDef{Type: f.Type, GoType: f.GoType})
+}
+
+func (g *generator) encodeField(f fieldDef) {
+	value := "m." + f.Name
+	switch {
+	case f.List:
+		if f.Tag != nil {
+			g.printf("\tencoding.EncodeOpeningTag(buf, %d)\n", *f.Tag)
+		}
+		g.printf("\tfor _, e := range %s {\n", value)
+		g.encodeValue(f, "e", "\t\t")
+		g.printf("\t}\n")
+		if f.Tag != nil {
+			g.printf("\tencoding.EncodeClosingTag(buf, %d)\n", *f.Tag)
+		}
+	case f.Optional:
+		g.printf("\tif %s != nil {\n", value)
+		g.encodeValue(f, "(*"+value+")", "\t\t")
+		g.printf("\t}\n")
+	default:
+		g.encodeValue(f, value, "\t")
+	}
+}
+
+func (g *generator) encodeValue(f fieldDef, value, indent string) {
+	k, ok := kinds[f.Type]
+	switch {
+	case !ok && f.Tag != nil && !f.List:
+		g.printf("%sencoding.EncodeOpeningTag(buf, %d)\n%s%s.encode(buf)\n%[1]sencoding.EncodeClosingTag(buf, %[2]d)\n", indent, *f.Tag, indent, value)
+	case !ok:
+		g.printf("%s%s.encode(buf)\n", indent, value)
+	case f.Tag != nil && !f.List:
+		g.printf("%s%s\n", indent, fmt.Sprintf(k.contextEncode, *f.Tag, value))
+	default:
+		g.printf("%s%s\n", indent, fmt.Sprintf(k.appEncode, 0, value))
+	}
+}
+
+func (g *generator) decodeField(f fieldDef) {
+	switch {
+	case f.List && f.Tag != nil:
+		g.printf("\tif err := encoding.ExpectOpeningTag(r, %d); err != nil {\n\t\treturn err\n\t}\n", *f.Tag)
+		g.printf("\tfor !atClosingTag(r, %d) {\n", *f.Tag)
+		g.decodeElement(f, "\t\t")
+		g.printf("\t}\n")
+		g.printf("\tif err := encoding.ExpectClosingTag(r, %d); err != nil {\n\t\treturn err\n\t}\n", *f.Tag)
+	case f.List:
+		g.printf("\tfor r.Len() > 0 {\n")
+		g.decodeElement(f, "\t\t")
+		g.printf("\t}\n")
+	case f.Optional:
+		g.printf("\tif hasContextTag(r, %d) {\n", *f.Tag)
+		g.decodeValue(f, "\t\t", "v")
+		g.printf("\t\tm.%s = &v\n\t}\n", f.Name)
+	default:
+		g.printf("\t{\n")
+		g.decodeValue(f, "\t\t", "v")
+		g.printf("\t\tm.%s = v\n\t}\n", f.Name)
+	}
+}
+
+func (g *generator) decodeElement(f fieldDef, indent string) {
+	g.decodeValue(fieldDef{Type: f.Type, GoType: f.GoType}, indent, "e")
+	g.printf("%sm.%s = append(m.%[2]s, e)\n", indent, f.Name)
+}
+
+// decodeValue decodes a value of the type of f into a new variable.
+func (g *generator) decodeValue(f fieldDef, indent, variable string) {
+	k, ok := kinds[f.Type]
+	if !ok {
+		g.printf("%svar %s %s\n", indent, variable, f.Type)
+		if f.Tag != nil {
+			g.printf("%sif err := encoding.ExpectOpeningTag(r, %d); err != nil {\n%[1]s\treturn err\n%[1]s}\n", indent, *f.Tag)
+		}
+		g.printf("%sif err := %s.decode(r); err != nil {\n%[1]s\treturn err\n%[1]s}\n", indent, variable)
+		if f.Tag != nil {
+			g.printf("%sif err := encoding.ExpectClosingTag(r, %d); err != nil {\n%[1]s\treturn err\n%[1]s}\n", indent, *f.Tag)
+		}
+		return
+	}
+	var decode string
+	if f.Tag != nil {
+		decode = fmt.Sprintf(k.contextDecode, *f.Tag)
+	} else {
+		decode = k.appDecode
+	}
+	raw := variable
+	if f.GoType != "" {
+		raw = "raw"
+	}
+	g.printf("%s%s, err := %s\n%[1]sif err != nil {\n%[1]s\treturn err\n%[1]s}\n", indent, raw, decode)
+	if f.GoType != "" {
+		g.printf("%s%s := %s(raw)\n", indent, variable, f.GoType)
+	}
+}
+
+// comment writes text as a doc comment wrapped at 100 columns.
+func comment(buf *bytes.Buffer, indent, text string) {
+	if text == "" {
+		return
+	}
+	line := indent + "//"
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > 100 && len(line) > len(indent)+2 {
+			buf.WriteString(line + "\n")
+			line = indent + "//"
+		}
+		line += " " + word
+	}
+	buf.WriteString(line + "\n")
+}
+
+// jsonName returns the JSON name of a field, its name with a lower-case first word, e.g.
+// "processId" for ProcessID.
+func jsonName(name string) string {
+	name = strings.ReplaceAll(name, "ID", "Id")
+	i := 1
+	for i < len(name) && name[i] >= 'A' && name[i] <= 'Z' && (i+1 == len(name) || name[i+1] >= 'A' && name[i+1] <= 'Z') {
+		i++
+	}
+	return strings.ToLower(name[:i]) + name[i:]
+}
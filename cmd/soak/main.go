@@ -0,0 +1,366 @@
+// Command soak runs discovery, polling, COV subscriptions and writes against simulated devices for
+// a long time and fails if goroutines leak, memory grows beyond a bound or a transaction gets stuck.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/maxzerker/bacnet"
+)
+
+var (
+	duration       = flag.Duration("duration", time.Hour, "how long to run")
+	devices        = flag.Int("devices", 3, "number of simulated devices")
+	objects        = flag.Int("objects", 10, "analog and binary values per device")
+	checkInterval  = flag.Duration("check", 30*time.Second, "interval between health checks")
+	maxHeapMB      = flag.Uint64("max-heap", 64, "maximum live heap in MiB")
+	goroutineSlack = flag.Int("goroutine-slack", 10, "goroutines tolerated above the baseline")
+	timeout        = flag.Duration("timeout", time.Second, "client request timeout")
+	stuckAfter     = flag.Duration("stuck", 30*time.Second, "time after which a running operation counts as stuck")
+)
+
+// maxLoggedErrors is how many errors of each kind of operation are logged.
+const maxLoggedErrors = 5
+
+// soak holds the state shared by the workers.
+type soak struct {
+	client     *bacnet.BACnetClient
+	simulators []*bacnet.Simulator
+	addrs      []*net.UDPAddr
+
+	mu      sync.Mutex
+	devices map[uint32]bacnet.DeviceInfo
+	running map[int64]operation
+	// errorsByName counts the errors of every kind of operation
+	errorsByName map[string]int
+	nextOp       int64
+	failures     []string
+
+	discoveries, polls, notifications, writes, errors atomic.Uint64
+}
+
+type operation struct {
+	name  string
+	start time.Time
+}
+
+func main() {
+	flag.Parse()
+	log.SetFlags(log.Ltime)
+
+	startGoroutines := runtime.NumGoroutine()
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	s, wait, err := start(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	baseline := -1
+	ticker := time.NewTicker(*checkInterval)
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+			goroutines, heap := measure()
+			if baseline < 0 {
+				baseline = goroutines // First check after warm-up
+			}
+			s.check(goroutines, baseline, heap)
+		}
+	}
+	ticker.Stop()
+	cancel()
+	wait()
+	s.client.Close()
+
+	// Everything started by the soak must have stopped
+	time.Sleep(2 * *timeout)
+	goroutines, _ := measure()
+	if goroutines > startGoroutines+*goroutineSlack {
+		s.fail("%d goroutines still running after shutdown, %d before start", goroutines, startGoroutines)
+	}
+
+	stats := s.client.Stats()
+	log.Printf("discoveries %d, poll updates %d, COV notifications %d, writes %d, errors %d",
+		s.discoveries.Load(), s.polls.Load(), s.notifications.Load(), s.writes.Load(), s.errors.Load())
+	log.Printf("transactions %d, retries %d, timeouts %d, dropped packets %d",
+		stats.Transactions, stats.Retries, stats.Timeouts, stats.DroppedPackets)
+	if len(s.failures) > 0 {
+		log.Printf("FAIL: %d problems", len(s.failures))
+		for _, f := range s.failures {
+			log.Printf("  %s", f)
+		}
+		os.Exit(1)
+	}
+	log.Print("PASS")
+}
+
+// start launches the simulators and workers; wait blocks until they all stopped after ctx ends.
+func start(ctx context.Context) (*soak, func(), error) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	client, err := bacnet.NewClient(bacnet.ClientOptions{LocalAddr: loopback, Timeout: *timeout, Retries: 1})
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &soak{client: client, devices: make(map[uint32]bacnet.DeviceInfo), running: make(map[int64]operation), errorsByName: make(map[string]int)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < *devices; i++ {
+		conn, err := net.ListenUDP("udp4", loopback)
+		if err != nil {
+			return nil, nil, err
+		}
+		sim := bacnet.NewSimulator(conn, bacnet.SimulatorOptions{
+			DeviceID:       uint32(1000 + i),
+			AnalogValues:   *objects,
+			BinaryValues:   *objects,
+			ChangeInterval: 200 * time.Millisecond,
+			Seed:           int64(i),
+		})
+		s.simulators = append(s.simulators, sim)
+		s.addrs = append(s.addrs, conn.LocalAddr().(*net.UDPAddr))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			sim.Run(ctx)
+		}()
+	}
+
+	s.discover()
+	for _, worker := range []func(context.Context){s.discoverLoop, s.pollLoop, s.covLoop, s.writeLoop, s.watchdog} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(ctx)
+		}()
+	}
+	return s, wg.Wait, nil
+}
+
+// guard runs fn as a tracked operation so the watchdog can report it if it never returns.
+func (s *soak) guard(name string, fn func() error) {
+	s.mu.Lock()
+	id := s.nextOp
+	s.nextOp++
+	s.running[id] = operation{name: name, start: time.Now()}
+	s.mu.Unlock()
+
+	err := fn()
+
+	s.mu.Lock()
+	delete(s.running, id)
+	s.mu.Unlock()
+	if err != nil {
+		s.error(name, err)
+	}
+}
+
+// error counts an error and logs the first ones of every kind of operation.
+func (s *soak) error(name string, err error) {
+	s.errors.Add(1)
+	s.mu.Lock()
+	s.errorsByName[name]++
+	n := s.errorsByName[name]
+	s.mu.Unlock()
+	if n <= maxLoggedErrors {
+		log.Printf("%s failed: %v", name, err)
+	}
+}
+
+func (s *soak) fail(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Print(msg)
+	s.mu.Lock()
+	s.failures = append(s.failures, msg)
+	s.mu.Unlock()
+}
+
+func (s *soak) device(i int) (bacnet.DeviceInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.devices[s.simulators[i].Device.DeviceID]
+	return d, ok
+}
+
+// discover sends a directed Who-Is to every simulator, since loopback has no broadcast.
+func (s *soak) discover() {
+	for i, addr := range s.addrs {
+		id := s.simulators[i].Device.DeviceID
+		s.guard("discovery", func() error {
+			found, err := s.client.WhoIsWithOptions(addr, bacnet.WhoIsOptions{DeviceID: &id})
+			if err != nil {
+				return err
+			}
+			if len(found) == 0 {
+				return fmt.Errorf("device %d not found", id)
+			}
+			s.discoveries.Add(1)
+			s.mu.Lock()
+			s.devices[id] = found[0]
+			s.mu.Unlock()
+			return nil
+		})
+	}
+}
+
+func (s *soak) discoverLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.discover()
+		}
+	}
+}
+
+func (s *soak) pollLoop(ctx context.Context) {
+	poller := bacnet.NewPoller(s.client, time.Second)
+	for i := range s.simulators {
+		device, ok := s.device(i)
+		if !ok {
+			continue
+		}
+		for n := 1; n <= *objects; n++ {
+			poller.Add(bacnet.PollPoint{Device: device, Object: bacnet.BACnetObject{Type: bacnet.OBJECT_ANALOG_VALUE, Instance: uint32(n)}, PropertyID: bacnet.PROP_PRESENT_VALUE})
+		}
+	}
+	poller.Run(ctx, func(u bacnet.PollUpdate) {
+		if u.Err != nil {
+			s.error("poll", u.Err)
+			return
+		}
+		s.polls.Add(1)
+	})
+}
+
+// covLoop keeps churning subscriptions: every cycle it subscribes to a few binary values and drops
+// the subscriptions of the previous cycle, so that leaked subscription goroutines add up.
+func (s *soak) covLoop(ctx context.Context) {
+	manager := bacnet.NewCOVManager(ctx, s.client)
+	defer manager.Close()
+
+	rnd := rand.New(rand.NewSource(1))
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	var previous []bacnet.COVSubscriptionConfig
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-manager.Notifications():
+			s.notifications.Add(1)
+		case err := <-manager.Errors():
+			s.error("COV", err)
+		case <-ticker.C:
+			for _, config := range previous {
+				manager.Unsubscribe(config.Device.DeviceID, config.Object)
+			}
+			previous = previous[:0]
+			for i := range s.simulators {
+				device, ok := s.device(i)
+				if !ok {
+					continue
+				}
+				config := bacnet.COVSubscriptionConfig{
+					Device:                      device,
+					Object:                      bacnet.BACnetObject{Type: bacnet.OBJECT_BINARY_VALUE, Instance: uint32(1 + rnd.Intn(*objects))},
+					SubscriberProcessIdentifier: uint32(1 + i),
+					Lifetime:                    60,
+				}
+				manager.Subscribe(config)
+				previous = append(previous, config)
+			}
+		}
+	}
+}
+
+// writeLoop writes random analog values and checks that the simulator received them.
+func (s *soak) writeLoop(ctx context.Context) {
+	rnd := rand.New(rand.NewSource(2))
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		i := rnd.Intn(len(s.simulators))
+		device, ok := s.device(i)
+		if !ok {
+			continue
+		}
+		object := bacnet.BACnetObject{Type: bacnet.OBJECT_ANALOG_VALUE, Instance: uint32(1 + rnd.Intn(*objects))}
+		value := float32(rnd.Intn(1000)) / 10
+		s.guard("write", func() error {
+			if err := s.client.WriteProperty(device, object, bacnet.PROP_PRESENT_VALUE, value, 0); err != nil {
+				return err
+			}
+			s.writes.Add(1)
+			return nil
+		})
+	}
+}
+
+// watchdog reports operations that have been running for longer than -stuck.
+func (s *soak) watchdog(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	reported := make(map[int64]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.mu.Lock()
+		var stuck []operation
+		for id, op := range s.running {
+			if !reported[id] && time.Since(op.start) > *stuckAfter {
+				reported[id] = true
+				stuck = append(stuck, op)
+			}
+		}
+		s.mu.Unlock()
+		for _, op := range stuck {
+			s.fail("%s stuck since %s", op.name, op.start.Format(time.TimeOnly))
+		}
+	}
+}
+
+// check compares the current goroutine count and heap with their bounds.
+func (s *soak) check(goroutines, baseline int, heap uint64) {
+	stats := s.client.Stats()
+	log.Printf("goroutines %d (baseline %d), heap %.1f MiB, open transactions %d, subscriptions %d, polls %d, notifications %d, writes %d, errors %d",
+		goroutines, baseline, float64(heap)/(1<<20), stats.OpenTransactions, stats.ActiveSubscriptions,
+		s.polls.Load(), s.notifications.Load(), s.writes.Load(), s.errors.Load())
+	if goroutines > baseline+*goroutineSlack {
+		s.fail("goroutines grew from %d to %d", baseline, goroutines)
+	}
+	if heap > *maxHeapMB<<20 {
+		s.fail("heap grew to %.1f MiB", float64(heap)/(1<<20))
+	}
+}
+
+// measure returns the number of goroutines and the live heap after a garbage collection.
+func measure() (int, uint64) {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return runtime.NumGoroutine(), m.HeapAlloc
+}
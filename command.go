@@ -0,0 +1,114 @@
+package bacnet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommandLevel is a priority array slot that currently holds a command.
+type CommandLevel struct {
+	Priority uint8
+	Value    interface{}
+	// IfReleased is the value the object would be commanded to if this level were relinquished
+	// and all other levels stayed as they are.
+	IfReleased interface{}
+}
+
+// CommandExplanation describes how the Present_Value of a commandable object comes about.
+type CommandExplanation struct {
+	Object            BACnetObject
+	PresentValue      interface{}
+	RelinquishDefault interface{}
+	// PriorityArray holds the 16 slots of the priority array, nil for NULL.
+	PriorityArray [16]interface{}
+	// ActivePriority is the priority in control, or 0 if all levels are relinquished and the
+	// object falls back to Relinquish_Default.
+	ActivePriority uint8
+	// Commanded is the value the priority array resolves to.
+	Commanded interface{}
+	// Levels lists the non-NULL slots, highest priority first.
+	Levels []CommandLevel
+}
+
+// Consistent reports whether Present_Value matches the value the priority array resolves to. It
+// does not when the object is out of service, overridden locally or the device has not yet
+// applied the command.
+func (e CommandExplanation) Consistent() bool {
+	return ValuesEqual(e.PresentValue, e.Commanded)
+}
+
+// String returns a multi-line report of the explanation.
+func (e CommandExplanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: Present_Value %v", e.Object, e.PresentValue)
+	if e.ActivePriority == 0 {
+		fmt.Fprintf(&b, ", relinquished to default %v\n", e.RelinquishDefault)
+	} else {
+		fmt.Fprintf(&b, ", commanded at priority %d to %v\n", e.ActivePriority, e.Commanded)
+	}
+	if !e.Consistent() {
+		fmt.Fprintf(&b, "  Present_Value differs from the commanded value %v\n", e.Commanded)
+	}
+	for _, level := range e.Levels {
+		fmt.Fprintf(&b, "  priority %2d: %v, if released %v\n", level.Priority, level.Value, level.IfReleased)
+	}
+	return b.String()
+}
+
+// ExplainCommand reads the Priority_Array, Relinquish_Default and Present_Value of a commandable
+// object and reports which priority commands it and what each commanded level's release would
+// change it to.
+func (c *BACnetClient) ExplainCommand(device DeviceInfo, object BACnetObject, opts ...CallOption) (CommandExplanation, error) {
+	e := CommandExplanation{Object: object}
+
+	slots, err := c.ReadArray(device, object, PROP_PRIORITY_ARRAY, opts...)
+	if err != nil {
+		return e, fmt.Errorf("failed to read priority array of %s: %w", object, err)
+	}
+	if len(slots) != len(e.PriorityArray) {
+		return e, fmt.Errorf("priority array of %s has %d elements, expected 16", object, len(slots))
+	}
+	for i, slot := range slots {
+		e.PriorityArray[i] = typedPropertyValue(object.Type, PROP_PRESENT_VALUE, slot)
+	}
+
+	relinquishDefault, err := c.readProperty(callContext(opts...), device, object, PROP_RELINQUISH_DEFAULT, nil)
+	if err != nil {
+		return e, fmt.Errorf("failed to read relinquish default of %s: %w", object, err)
+	}
+	e.RelinquishDefault = typedPropertyValue(object.Type, PROP_PRESENT_VALUE, relinquishDefault)
+
+	presentValue, err := c.readProperty(callContext(opts...), device, object, PROP_PRESENT_VALUE, nil)
+	if err != nil {
+		return e, fmt.Errorf("failed to read present value of %s: %w", object, err)
+	}
+	e.PresentValue = typedPropertyValue(object.Type, PROP_PRESENT_VALUE, presentValue)
+
+	e.explain()
+	return e, nil
+}
+
+// explain resolves the priority array and fills in ActivePriority, Commanded and Levels.
+func (e *CommandExplanation) explain() {
+	e.ActivePriority, e.Commanded = resolvePriorityArray(e.PriorityArray, e.RelinquishDefault, 0)
+	e.Levels = e.Levels[:0]
+	for i, value := range e.PriorityArray {
+		if value == nil {
+			continue
+		}
+		priority := uint8(i + 1)
+		_, ifReleased := resolvePriorityArray(e.PriorityArray, e.RelinquishDefault, priority)
+		e.Levels = append(e.Levels, CommandLevel{Priority: priority, Value: value, IfReleased: ifReleased})
+	}
+}
+
+// resolvePriorityArray returns the highest non-NULL priority and its value, skipping the priority
+// ignore, or 0 and relinquishDefault if every slot is NULL.
+func resolvePriorityArray(slots [16]interface{}, relinquishDefault interface{}, ignore uint8) (uint8, interface{}) {
+	for i, value := range slots {
+		if value != nil && uint8(i+1) != ignore {
+			return uint8(i + 1), value
+		}
+	}
+	return 0, relinquishDefault
+}
@@ -0,0 +1,106 @@
+package bacnet
+
+import (
+	"bytes"
+	"math"
+)
+
+// ValuesEqual compares two decoded property values. Numbers are compared by value regardless of
+// their Go type (so a uint32 read from a device equals the float64 it becomes after a JSON round
+// trip), strings and bit strings are compared exactly and lists element by element.
+func ValuesEqual(a, b interface{}) bool {
+	if af, ok := numericValue(a); ok {
+		bf, ok := numericValue(b)
+		return ok && (af == bf || (math.IsNaN(af) && math.IsNaN(bf)))
+	}
+
+	switch av := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case []byte:
+		bv, ok := b.([]byte)
+		return ok && bytes.Equal(av, bv)
+	case StatusFlags:
+		bv, ok := b.(StatusFlags)
+		return ok && av == bv
+	case BACnetObject:
+		bv, ok := b.(BACnetObject)
+		return ok && av == bv
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !ValuesEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return canonicalJSON(a) == canonicalJSON(b)
+	}
+}
+
+// ValueChanged reports whether next should be treated as a change from prev. Analog values
+// (Real and Double) only count as changed when they moved by more than deadband; a deadband of
+// zero reports every difference. Unsigned and enumerated values decode to the same Go type and
+// enumerations must never be deadbanded, so they are always compared exactly, as are strings,
+// bit strings and all other types.
+func ValueChanged(prev, next interface{}, deadband float64) bool {
+	if deadband > 0 {
+		pf, prevAnalog := analogValue(prev)
+		nf, nextAnalog := analogValue(next)
+		if prevAnalog && nextAnalog {
+			if math.IsNaN(pf) || math.IsNaN(nf) {
+				return math.IsNaN(pf) != math.IsNaN(nf)
+			}
+			return math.Abs(nf-pf) > deadband
+		}
+	}
+	return !ValuesEqual(prev, next)
+}
+
+// analogValue returns the value of a Real or Double.
+func analogValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// numericValue returns the value of any numeric type.
+func numericValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case Enumerated:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,317 @@
+package bacnet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ConfigOptions controls ApplyConfig.
+type ConfigOptions struct {
+	// Filter selects the properties of the snapshot to apply; nil applies those ConfigProperty
+	// reports.
+	Filter func(object BACnetObject, propertyID uint32) bool
+	// BatchSize is the number of properties written per WritePropertyMultiple request; zero
+	// means 16.
+	BatchSize int
+}
+
+// ConfigStatus is the outcome of applying one property.
+type ConfigStatus uint32
+
+const (
+	// ConfigUnchanged properties already had the value and were not written.
+	ConfigUnchanged ConfigStatus = iota
+	// ConfigVerified properties were written and read back with the value.
+	ConfigVerified
+	// ConfigWritten properties were written but could not be read back.
+	ConfigWritten
+	// ConfigMismatch properties were written but read back with a different value, e.g. because
+	// the device clamped or rounded it.
+	ConfigMismatch
+	// ConfigFailed properties could not be read or written.
+	ConfigFailed
+	// ConfigDryRun properties would have been written by a client in ReadOnlyDryRun mode.
+	ConfigDryRun
+)
+
+var configStatusNames = map[ConfigStatus]string{
+	ConfigUnchanged: "unchanged",
+	ConfigVerified:  "verified",
+	ConfigWritten:   "written",
+	ConfigMismatch:  "mismatch",
+	ConfigFailed:    "failed",
+	ConfigDryRun:    "dry-run",
+}
+
+func (s ConfigStatus) String() string { return enumName(configStatusNames, s) }
+
+// ConfigResult is the outcome of applying one property of a snapshot.
+type ConfigResult struct {
+	Object     BACnetObject
+	PropertyID uint32
+	// Value is the value of the snapshot, converted to the datatype the device reported.
+	Value interface{}
+	// Previous is the value before ApplyConfig and Actual the value read back after the write.
+	Previous interface{}
+	Actual   interface{}
+	Status   ConfigStatus
+	Err      error
+}
+
+func (r ConfigResult) String() string {
+	s := fmt.Sprintf("%s %s: %s", r.Object, propertyLabel(r.PropertyID), r.Status)
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+	return s
+}
+
+// ConfigReport lists the results of ApplyConfig in the order of the snapshot.
+type ConfigReport []ConfigResult
+
+// Failed returns the results of properties that do not have the value of the snapshot: those
+// that failed and those read back with a different value.
+func (r ConfigReport) Failed() ConfigReport {
+	var failed ConfigReport
+	for _, result := range r {
+		if result.Status == ConfigFailed || result.Status == ConfigMismatch {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// nonConfigProperties are identifiers, runtime state and properties the standard makes read-only.
+var nonConfigProperties = map[uint32]bool{
+	PROP_OBJECT_IDENTIFIER: true, PROP_OBJECT_TYPE: true, PROP_OBJECT_LIST: true,
+	PROP_PRESENT_VALUE: true, PROP_PRIORITY_ARRAY: true, PROP_STATUS_FLAGS: true, PROP_EVENT_STATE: true,
+	PROP_RELIABILITY: true, PROP_ACKED_TRANSITIONS: true, PROP_CHANGE_OF_STATE_COUNT: true,
+	PROP_CHANGE_OF_STATE_TIME: true, PROP_ELAPSED_ACTIVE_TIME: true, PROP_SYSTEM_STATUS: true,
+	PROP_VENDOR_NAME: true, PROP_VENDOR_IDENTIFIER: true, PROP_FIRMWARE_REVISION: true,
+	PROP_APPLICATION_SOFTWARE_VERSION: true, PROP_PROTOCOL_VERSION: true, PROP_PROTOCOL_CONFORMANCE_CLASS: true,
+	PROP_PROTOCOL_SERVICES_SUPPORTED: true, PROP_PROTOCOL_OBJECT_TYPES_SUPPORTED: true,
+	PROP_MAX_APDU_LENGTH_ACCEPTED: true, PROP_SEGMENTATION_SUPPORTED: true, PROP_DEVICE_ADDRESS_BINDING: true,
+	PROP_DAYLIGHT_SAVINGS_STATUS: true, PROP_ACTIVE_COV_SUBSCRIPTIONS: true, PROP_LOG_BUFFER: true,
+	PROP_RECORD_COUNT: true, PROP_TOTAL_RECORD_COUNT: true, PROP_TIMER_STATE: true, PROP_TIMER_RUNNING: true,
+	PROP_LAST_STATE_CHANGE: true, PROP_PRESENT_STAGE: true,
+}
+
+// ConfigProperty reports whether ApplyConfig applies a property by default: all properties but
+// identifiers, runtime state such as Present_Value and Status_Flags, and properties the standard
+// makes read-only.
+func ConfigProperty(propertyID uint32) bool {
+	return !nonConfigProperties[propertyID]
+}
+
+// ApplyConfig writes the properties of a snapshot, e.g. a golden file read with ReadSnapshot, to a
+// device. The Device object of the snapshot is applied to the Device object of device. Properties
+// are read first, so that only those that differ are written, with WritePropertyMultiple, and
+// then read back to verify them. Devices that fail a WritePropertyMultiple request as a whole,
+// e.g. because they do not support it, are written with WriteProperty instead.
+//
+// The error is only set if the device could not be reached; the results of the properties,
+// including the errors of individual reads and writes, are in the report.
+func (c *BACnetClient) ApplyConfig(device DeviceInfo, snapshot *DeviceSnapshot, options ConfigOptions, opts ...CallOption) (ConfigReport, error) {
+	ctx := callContext(opts...)
+	filter := options.Filter
+	if filter == nil {
+		filter = func(_ BACnetObject, propertyID uint32) bool { return ConfigProperty(propertyID) }
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 16
+	}
+
+	// Read the current values and convert the values of the snapshot to their datatypes.
+	var report ConfigReport
+	var pending []int // Indexes of the results to write
+	for _, obj := range snapshot.Objects {
+		if obj.Error != "" {
+			continue
+		}
+		object := obj.Object
+		if object.Type == OBJECT_DEVICE && object.Instance == snapshot.DeviceID {
+			object.Instance = device.DeviceID
+		}
+		var propertyIDs []uint32
+		var values []interface{}
+		for _, prop := range obj.Properties {
+			if filter(object, prop.PropertyID) {
+				propertyIDs = append(propertyIDs, prop.PropertyID)
+				values = append(values, prop.Value)
+			}
+		}
+		if len(propertyIDs) == 0 {
+			continue
+		}
+
+		current, err := c.ReadSpecificPropertiesFromObject(device, object, propertyIDs, opts...)
+		if errors.Is(err, errResponseTimeout) {
+			return report, fmt.Errorf("failed to read %s: %w", object, err)
+		}
+		for i, propertyID := range propertyIDs {
+			result := ConfigResult{Object: object, PropertyID: propertyID, Value: values[i]}
+			previous, readErr := current.Get(propertyID)
+			if err != nil {
+				readErr = err
+			}
+			result.Previous = previous
+			if readErr == nil {
+				result.Value, readErr = conformValue(values[i], previous, object.Type, propertyID)
+			}
+			switch {
+			case readErr != nil:
+				result.Status, result.Err = ConfigFailed, readErr
+			case ValuesEqual(previous, result.Value):
+				result.Status, result.Actual = ConfigUnchanged, previous
+			default:
+				pending = append(pending, len(report))
+			}
+			report = append(report, result)
+		}
+	}
+
+	// Write the values that differ.
+	written := c.applyWrites(ctx, device, report, pending, batchSize)
+	if c.options.ReadOnly == ReadOnlyDryRun {
+		for _, i := range written {
+			report[i].Status = ConfigDryRun
+		}
+		return report, nil
+	}
+
+	// Read back what was written, object by object.
+	for start := 0; start < len(written); {
+		object := report[written[start]].Object
+		end := start
+		var propertyIDs []uint32
+		for end < len(written) && report[written[end]].Object == object {
+			propertyIDs = append(propertyIDs, report[written[end]].PropertyID)
+			end++
+		}
+		actual, err := c.ReadSpecificPropertiesFromObject(device, object, propertyIDs, opts...)
+		for _, i := range written[start:end] {
+			value, readErr := actual.Get(report[i].PropertyID)
+			switch {
+			case err != nil:
+				report[i].Status, report[i].Err = ConfigWritten, fmt.Errorf("failed to read back: %w", err)
+			case readErr != nil:
+				report[i].Status, report[i].Err = ConfigWritten, fmt.Errorf("failed to read back: %w", readErr)
+			case ValuesEqual(value, report[i].Value):
+				report[i].Status, report[i].Actual = ConfigVerified, value
+			default:
+				report[i].Status, report[i].Actual = ConfigMismatch, value
+			}
+		}
+		start = end
+	}
+	return report, nil
+}
+
+// applyWrites writes the pending results of report in WritePropertyMultiple requests of up to
+// batchSize properties and returns the indexes of those written. Failed writes are marked in the
+// report.
+func (c *BACnetClient) applyWrites(ctx context.Context, device DeviceInfo, report ConfigReport, pending []int, batchSize int) (written []int) {
+	useWPM := true
+	for len(pending) > 0 {
+		batch := pending[:min(batchSize, len(pending))]
+		if !useWPM {
+			batch = pending[:1]
+			r := &report[batch[0]]
+			if err := c.writeProperty(ctx, device, r.Object, r.PropertyID, nil, r.Value, 0); err != nil {
+				r.Status, r.Err = ConfigFailed, err
+			} else {
+				written = append(written, batch[0])
+			}
+			pending = pending[1:]
+			continue
+		}
+
+		writes := make([]PropertyWrite, len(batch))
+		for i, index := range batch {
+			writes[i] = PropertyWrite{Object: report[index].Object, PropertyID: report[index].PropertyID, Value: report[index].Value}
+		}
+		err := c.writePropertyMultiple(ctx, device, writes)
+		var failed *WritePropertyMultipleError
+		switch {
+		case err == nil:
+			written = append(written, batch...)
+			pending = pending[len(batch):]
+		case errors.As(err, &failed) && failed.Index >= 0:
+			// Writes before the failed one were made; those after it are sent again.
+			written = append(written, batch[:failed.Index]...)
+			r := &report[batch[failed.Index]]
+			r.Status, r.Err = ConfigFailed, failed.Err
+			pending = pending[failed.Index+1:]
+		case errors.Is(err, ErrStandby), errors.Is(err, ErrReadOnly), ctx.Err() != nil:
+			for _, index := range pending {
+				report[index].Status, report[index].Err = ConfigFailed, err
+			}
+			return written
+		default:
+			c.logf("bacnet: WritePropertyMultiple to device %d failed, writing properties one by one: %v", device.DeviceID, err)
+			useWPM = false
+		}
+	}
+	return written
+}
+
+// conformValue converts value, e.g. a number decoded from JSON, to the datatype of like, the value
+// the device reported for the property. Unsigned values of enumerated properties become
+// Enumerated, so that they are written with the right tag.
+func conformValue(value, like interface{}, objectType ObjectType, propertyID uint32) (interface{}, error) {
+	converted, err := convertLike(value, like)
+	if err != nil {
+		return nil, fmt.Errorf("cannot write %v as %T: %w", value, like, err)
+	}
+	if n, ok := converted.(uint32); ok && enumeratedProperty(objectType, propertyID) {
+		return Enumerated(n), nil
+	}
+	return converted, nil
+}
+
+func convertLike(value, like interface{}) (interface{}, error) {
+	if value == nil || like == nil || reflect.TypeOf(value) == reflect.TypeOf(like) {
+		if list, ok := value.([]interface{}); ok {
+			if likeList, ok := like.([]interface{}); ok && len(likeList) > 0 {
+				converted := make([]interface{}, len(list))
+				for i, element := range list {
+					var err error
+					if converted[i], err = convertLike(element, likeList[min(i, len(likeList)-1)]); err != nil {
+						return nil, err
+					}
+				}
+				return converted, nil
+			}
+		}
+		return value, nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	target := reflect.New(reflect.TypeOf(like))
+	if err := json.Unmarshal(raw, target.Interface()); err != nil {
+		return nil, err
+	}
+	return target.Elem().Interface(), nil
+}
+
+// enumeratedProperty reports whether a property of objects of a type has an enumerated datatype.
+func enumeratedProperty(objectType ObjectType, propertyID uint32) bool {
+	switch propertyID {
+	case PROP_UNITS, PROP_POLARITY, PROP_RELIABILITY, PROP_EVENT_STATE, PROP_EVENT_TYPE, PROP_SYSTEM_STATUS,
+		PROP_SEGMENTATION_SUPPORTED, PROP_LOGGING_TYPE, PROP_FILE_ACCESS_METHOD:
+		return true
+	case PROP_PRESENT_VALUE, PROP_RELINQUISH_DEFAULT, PROP_ALARM_VALUE:
+		switch objectType {
+		case OBJECT_BINARY_INPUT, OBJECT_BINARY_OUTPUT, OBJECT_BINARY_VALUE:
+			return true
+		}
+	}
+	_, ok := propertyEnumerations[propertyID]
+	return ok
+}
@@ -21,10 +21,16 @@ const (
 
 	// NPDU (Network Protocol Data Unit) Control Field
 	NPDU_CONTROL_NORMAL_MESSAGE        byte = 0x00
-	NPDU_CONTROL_URGENT_MESSAGE        byte = 0x04
-	NPDU_CONTROL_EXPECTING_REPLY       byte = 0x08
+	NPDU_CONTROL_URGENT_MESSAGE        byte = 0x01
+	NPDU_CONTROL_EXPECTING_REPLY       byte = 0x04
+	NPDU_CONTROL_SOURCE_PRESENT        byte = 0x08
+	NPDU_CONTROL_DESTINATION_PRESENT   byte = 0x20
 	NPDU_CONTROL_NETWORK_LAYER_MESSAGE byte = 0x80
 
+	// Network Layer Message Types
+	NETWORK_MESSAGE_WHO_IS_ROUTER_TO_NETWORK byte = 0x00
+	NETWORK_MESSAGE_I_AM_ROUTER_TO_NETWORK   byte = 0x01
+
 	// APDU (Application Protocol Data Unit) Types
 	APDU_CONFIRMED_REQUEST   byte = 0x00
 	APDU_UNCONFIRMED_REQUEST byte = 0x10
@@ -36,81 +42,178 @@ const (
 	APDU_ABORT               byte = 0x70
 
 	// Unconfirmed Service Choice
-	SERVICE_UNCONFIRMED_I_AM             byte = 0x00
-	SERVICE_UNCONFIRMED_WHO_IS           byte = 0x08
-	SERVICE_UNCONFIRMED_COV_NOTIFICATION byte = 0x01
-	SERVICE_UNCONFIRMED_EVENT_NOTIFICATION byte = 0x02
+	SERVICE_UNCONFIRMED_I_AM                      byte = 0x00
+	SERVICE_UNCONFIRMED_WHO_IS                    byte = 0x08
+	SERVICE_UNCONFIRMED_I_HAVE                    byte = 0x01
+	SERVICE_UNCONFIRMED_WHO_HAS                   byte = 0x07
+	SERVICE_UNCONFIRMED_COV_NOTIFICATION          byte = 0x02
+	SERVICE_UNCONFIRMED_EVENT_NOTIFICATION        byte = 0x03
+	SERVICE_UNCONFIRMED_TEXT_MESSAGE              byte = 0x05
+	SERVICE_UNCONFIRMED_COV_NOTIFICATION_MULTIPLE byte = 0x0b
 
 	// Confirmed Service Choice
-	SERVICE_CONFIRMED_READ_PROPERTY          byte = 0x0c
-	SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE byte = 0x0e
-	SERVICE_CONFIRMED_SUBSCRIBE_COV          byte = 0x05
+	SERVICE_CONFIRMED_COV_NOTIFICATION                byte = 0x01
+	SERVICE_CONFIRMED_EVENT_NOTIFICATION              byte = 0x02
+	SERVICE_CONFIRMED_READ_PROPERTY                   byte = 0x0c
+	SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE          byte = 0x0e
+	SERVICE_CONFIRMED_SUBSCRIBE_COV                   byte = 0x05
+	SERVICE_CONFIRMED_CREATE_OBJECT                   byte = 0x0a
+	SERVICE_CONFIRMED_DELETE_OBJECT                   byte = 0x0b
+	SERVICE_CONFIRMED_WRITE_PROPERTY                  byte = 0x0f
+	SERVICE_CONFIRMED_WRITE_PROPERTY_MULTIPLE         byte = 0x10
+	SERVICE_CONFIRMED_READ_RANGE                      byte = 0x1a
+	SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL    byte = 0x11
+	SERVICE_CONFIRMED_REINITIALIZE_DEVICE             byte = 0x14
+	SERVICE_CONFIRMED_TEXT_MESSAGE                    byte = 0x13
+	SERVICE_CONFIRMED_SUBSCRIBE_COV_PROPERTY_MULTIPLE byte = 0x1e
+	SERVICE_CONFIRMED_COV_NOTIFICATION_MULTIPLE       byte = 0x1f
+	SERVICE_CONFIRMED_ACKNOWLEDGE_ALARM               byte = 0x00
+	SERVICE_CONFIRMED_GET_EVENT_INFORMATION           byte = 0x1d
+	SERVICE_CONFIRMED_GET_ALARM_SUMMARY               byte = 0x03
+	SERVICE_CONFIRMED_LIFE_SAFETY_OPERATION           byte = 0x1b
 
 	// Property IDs
-	PROP_ACKED_TRANSITIONS                  byte = 0
-	PROP_ACK_REQUIRED                       byte = 1
-	PROP_ACTION                             byte = 2
-	PROP_ACTION_TEXT                        byte = 3
-	PROP_ACTIVE_TEXT                        byte = 4
-	PROP_ACTIVE_VT_SESSIONS                 byte = 5
-	PROP_ALARM_VALUE                        byte = 6
-	PROP_ALARM_VALUES                       byte = 7
-	PROP_ALL                                byte = 8
-	PROP_ALL_WRITES_SUCCESSFUL              byte = 9
-	PROP_APDU_SEGMENT_TIMEOUT               byte = 10
-	PROP_APDU_TIMEOUT                       byte = 11
-	PROP_APPLICATION_SOFTWARE_VERSION       byte = 12
-	PROP_ARCHIVE                            byte = 13
-	PROP_BIAS                               byte = 14
-	PROP_CHANGE_OF_STATE_COUNT              byte = 15
-	PROP_CHANGE_OF_STATE_TIME               byte = 16
-	PROP_NOTIFICATION_CLASS                 byte = 17
-	PROP_COV_INCREMENT                      byte = 22
-	PROP_DATE_LIST                          byte = 23
-	PROP_DAYLIGHT_SAVINGS_STATUS            byte = 24
-	PROP_DEADBAND                           byte = 25
-	PROP_DESCRIPTION                        byte = 28
-	PROP_DEVICE_ADDRESS_BINDING             byte = 30
-	PROP_DEVICE_TYPE                        byte = 31
-	PROP_EFFECTIVE_PERIOD                   byte = 32
-	PROP_ELAPSED_ACTIVE_TIME                byte = 33
-	PROP_ERROR_LIMIT                        byte = 34
-	PROP_EVENT_ENABLE                       byte = 35
-	PROP_EVENT_STATE                        byte = 36
-	PROP_EVENT_TYPE                         byte = 37
-	PROP_EXCEPTION_SCHEDULE                 byte = 38
-	PROP_FILE_ACCESS_METHOD                 byte = 41
-	PROP_FILE_SIZE                          byte = 42
-	PROP_FILE_TYPE                          byte = 43
-	PROP_FIRMWARE_REVISION                  byte = 44
-	PROP_HIGH_LIMIT                         byte = 45
-	PROP_INSTANCE_OF                        byte = 48
-	PROP_LIMIT_ENABLE                       byte = 52
-	PROP_LIST_OF_GROUP_MEMBERS              byte = 53
-	PROP_LIST_OF_OBJECT_PROPERTY_REFERENCES byte = 54
-	PROP_OBJECT_IDENTIFIER                  byte = 75
-	PROP_OBJECT_LIST                        byte = 76
-	PROP_OBJECT_NAME                        byte = 77
-	PROP_OBJECT_PROPERTY_REFERENCE          byte = 78
-	PROP_OBJECT_TYPE                        byte = 79
-	PROP_OPTIONAL                           byte = 80
-	PROP_OUT_OF_SERVICE                     byte = 81
-	PROP_PRESENT_VALUE                      byte = 85
-	PROP_PRIORITY_ARRAY                     byte = 87
-	PROP_PROFILE_NAME                       byte = 90
-	PROP_PROTOCOL_CONFORMANCE_CLASS         byte = 92
-	PROP_PROTOCOL_OBJECT_TYPES_SUPPORTED    byte = 97
-	PROP_PROTOCOL_SERVICES_SUPPORTED        byte = 98
-	PROP_PROTOCOL_VERSION                   byte = 100
-	PROP_RELIABILITY                        byte = 103
-	PROP_REQUIRED                           byte = 104
-	PROP_SEGMENTATION_SUPPORTED             byte = 107
-	PROP_STATUS_FLAGS                       byte = 111
-	PROP_SYSTEM_STATUS                      byte = 112
-	PROP_UNITS                              byte = 117
-	PROP_UPDATE_INTERVAL                    byte = 118
-	PROP_VENDOR_IDENTIFIER                  byte = 120
-	PROP_VENDOR_NAME                        byte = 121
+	PROP_ACKED_TRANSITIONS                  uint32 = 0
+	PROP_ACK_REQUIRED                       uint32 = 1
+	PROP_ACTION                             uint32 = 2
+	PROP_ACTION_TEXT                        uint32 = 3
+	PROP_ACTIVE_TEXT                        uint32 = 4
+	PROP_ACTIVE_VT_SESSIONS                 uint32 = 5
+	PROP_ALARM_VALUE                        uint32 = 6
+	PROP_ALARM_VALUES                       uint32 = 7
+	PROP_ALL                                uint32 = 8
+	PROP_ALL_WRITES_SUCCESSFUL              uint32 = 9
+	PROP_APDU_SEGMENT_TIMEOUT               uint32 = 10
+	PROP_APDU_TIMEOUT                       uint32 = 11
+	PROP_APPLICATION_SOFTWARE_VERSION       uint32 = 12
+	PROP_ARCHIVE                            uint32 = 13
+	PROP_BIAS                               uint32 = 14
+	PROP_CHANGE_OF_STATE_COUNT              uint32 = 15
+	PROP_CHANGE_OF_STATE_TIME               uint32 = 16
+	PROP_NOTIFICATION_CLASS                 uint32 = 17
+	PROP_COV_INCREMENT                      uint32 = 22
+	PROP_DATE_LIST                          uint32 = 23
+	PROP_DAYLIGHT_SAVINGS_STATUS            uint32 = 24
+	PROP_DEADBAND                           uint32 = 25
+	PROP_DESCRIPTION                        uint32 = 28
+	PROP_DEVICE_ADDRESS_BINDING             uint32 = 30
+	PROP_DEVICE_TYPE                        uint32 = 31
+	PROP_EFFECTIVE_PERIOD                   uint32 = 32
+	PROP_ELAPSED_ACTIVE_TIME                uint32 = 33
+	PROP_ERROR_LIMIT                        uint32 = 34
+	PROP_EVENT_ENABLE                       uint32 = 35
+	PROP_EVENT_STATE                        uint32 = 36
+	PROP_EVENT_TYPE                         uint32 = 37
+	PROP_EXCEPTION_SCHEDULE                 uint32 = 38
+	PROP_FILE_ACCESS_METHOD                 uint32 = 41
+	PROP_FILE_SIZE                          uint32 = 42
+	PROP_FILE_TYPE                          uint32 = 43
+	PROP_FIRMWARE_REVISION                  uint32 = 44
+	PROP_HIGH_LIMIT                         uint32 = 45
+	PROP_INACTIVE_TEXT                      uint32 = 46
+	PROP_INSTANCE_OF                        uint32 = 48
+	PROP_LIMIT_ENABLE                       uint32 = 52
+	PROP_LIST_OF_GROUP_MEMBERS              uint32 = 53
+	PROP_LIST_OF_OBJECT_PROPERTY_REFERENCES uint32 = 54
+	PROP_LOW_LIMIT                          uint32 = 59
+	PROP_MAX_APDU_LENGTH_ACCEPTED           uint32 = 62
+	PROP_MAX_INFO_FRAMES                    uint32 = 63
+	PROP_MAX_MASTER                         uint32 = 64
+	PROP_NUMBER_OF_STATES                   uint32 = 74
+	PROP_OBJECT_IDENTIFIER                  uint32 = 75
+	PROP_OBJECT_LIST                        uint32 = 76
+	PROP_OBJECT_NAME                        uint32 = 77
+	PROP_OBJECT_PROPERTY_REFERENCE          uint32 = 78
+	PROP_OBJECT_TYPE                        uint32 = 79
+	PROP_OPTIONAL                           uint32 = 80
+	PROP_OUT_OF_SERVICE                     uint32 = 81
+	PROP_POLARITY                           uint32 = 84
+	PROP_PRESENT_VALUE                      uint32 = 85
+	PROP_PRIORITY                           uint32 = 86
+	PROP_PRIORITY_ARRAY                     uint32 = 87
+	PROP_PRIORITY_FOR_WRITING               uint32 = 88
+	PROP_PROFILE_NAME                       uint32 = 90
+	PROP_PROTOCOL_CONFORMANCE_CLASS         uint32 = 92
+	PROP_PROTOCOL_OBJECT_TYPES_SUPPORTED    uint32 = 97
+	PROP_PROTOCOL_SERVICES_SUPPORTED        uint32 = 98
+	PROP_PROTOCOL_VERSION                   uint32 = 100
+	PROP_RECIPIENT_LIST                     uint32 = 102
+	PROP_RELIABILITY                        uint32 = 103
+	PROP_RELINQUISH_DEFAULT                 uint32 = 104
+	PROP_REQUIRED                           uint32 = 105
+	PROP_SEGMENTATION_SUPPORTED             uint32 = 107
+	PROP_STATE_TEXT                         uint32 = 110
+	PROP_STATUS_FLAGS                       uint32 = 111
+	PROP_SYSTEM_STATUS                      uint32 = 112
+	PROP_UNITS                              uint32 = 117
+	PROP_UPDATE_INTERVAL                    uint32 = 118
+	PROP_VENDOR_IDENTIFIER                  uint32 = 120
+	PROP_VENDOR_NAME                        uint32 = 121
+	PROP_WEEKLY_SCHEDULE                    uint32 = 123
+	PROP_BUFFER_SIZE                        uint32 = 126
+	PROP_LOG_BUFFER                         uint32 = 131
+	PROP_LOG_DEVICE_OBJECT_PROPERTY         uint32 = 132
+	PROP_ENABLE                             uint32 = 133
+	PROP_LOG_INTERVAL                       uint32 = 134
+	PROP_RECORD_COUNT                       uint32 = 141
+	PROP_STOP_WHEN_FULL                     uint32 = 144
+	PROP_TOTAL_RECORD_COUNT                 uint32 = 145
+	PROP_ACTIVE_COV_SUBSCRIPTIONS           uint32 = 152
+	PROP_SCHEDULE_DEFAULT                   uint32 = 174
+	PROP_LOGGING_TYPE                       uint32 = 197
+
+	// Structured View properties
+	PROP_NODE_SUBTYPE            uint32 = 207
+	PROP_NODE_TYPE               uint32 = 208
+	PROP_SUBORDINATE_ANNOTATIONS uint32 = 210
+	PROP_SUBORDINATE_LIST        uint32 = 211
+
+	// Timer and Staging properties
+	PROP_DEFAULT_TIMEOUT     uint32 = 393
+	PROP_INITIAL_TIMEOUT     uint32 = 394
+	PROP_LAST_STATE_CHANGE   uint32 = 395
+	PROP_STATE_CHANGE_VALUES uint32 = 396
+	PROP_TIMER_RUNNING       uint32 = 397
+	PROP_TIMER_STATE         uint32 = 398
+	PROP_PRESENT_STAGE       uint32 = 493
+	PROP_STAGES              uint32 = 494
+	PROP_STAGE_NAMES         uint32 = 495
+	PROP_TARGET_REFERENCES   uint32 = 496
+
+	// Lift, Escalator and Elevator Group properties
+	PROP_ASSIGNED_LANDING_CALLS uint32 = 447
+	PROP_CAR_ASSIGNED_DIRECTION uint32 = 448
+	PROP_CAR_DOOR_COMMAND       uint32 = 449
+	PROP_CAR_DOOR_STATUS        uint32 = 450
+	PROP_CAR_DOOR_TEXT          uint32 = 451
+	PROP_CAR_DOOR_ZONE          uint32 = 452
+	PROP_CAR_DRIVE_STATUS       uint32 = 453
+	PROP_CAR_LOAD               uint32 = 454
+	PROP_CAR_LOAD_UNITS         uint32 = 455
+	PROP_CAR_MODE               uint32 = 456
+	PROP_CAR_MOVING_DIRECTION   uint32 = 457
+	PROP_CAR_POSITION           uint32 = 458
+	PROP_ELEVATOR_GROUP         uint32 = 459
+	PROP_ENERGY_METER           uint32 = 460
+	PROP_ENERGY_METER_REF       uint32 = 461
+	PROP_ESCALATOR_MODE         uint32 = 462
+	PROP_FAULT_SIGNALS          uint32 = 463
+	PROP_FLOOR_TEXT             uint32 = 464
+	PROP_GROUP_ID               uint32 = 465
+	PROP_GROUP_MODE             uint32 = 467
+	PROP_HIGHER_DECK            uint32 = 468
+	PROP_INSTALLATION_ID        uint32 = 469
+	PROP_LANDING_CALLS          uint32 = 470
+	PROP_LANDING_CALL_CONTROL   uint32 = 471
+	PROP_LANDING_DOOR_STATUS    uint32 = 472
+	PROP_LOWER_DECK             uint32 = 473
+	PROP_MACHINE_ROOM_ID        uint32 = 474
+	PROP_MAKING_CAR_CALL        uint32 = 475
+	PROP_NEXT_STOPPING_FLOOR    uint32 = 476
+	PROP_OPERATION_DIRECTION    uint32 = 477
+	PROP_PASSENGER_ALARM        uint32 = 478
+	PROP_POWER_MODE             uint32 = 479
+	PROP_REGISTERED_CAR_CALL    uint32 = 480
 
 	BACNET_DEFAULT_PORT = 47808
-)
\ No newline at end of file
+)
@@ -0,0 +1,261 @@
+// Code generated by contextgen; DO NOT EDIT.
+
+package bacnet
+
+import (
+	"context"
+	"iter"
+	"net"
+	"regexp"
+	"time"
+)
+
+// AcknowledgeAlarmContext is AcknowledgeAlarm made with ctx; see CallContext.
+func (c *BACnetClient) AcknowledgeAlarmContext(ctx context.Context, device DeviceInfo, ack AlarmAcknowledgment, opts ...CallOption) error {
+	return c.AcknowledgeAlarm(device, ack, withContext(ctx, opts)...)
+}
+
+// ApplyConfigContext is ApplyConfig made with ctx; see CallContext.
+func (c *BACnetClient) ApplyConfigContext(ctx context.Context, device DeviceInfo, snapshot *DeviceSnapshot, options ConfigOptions, opts ...CallOption) (ConfigReport, error) {
+	return c.ApplyConfig(device, snapshot, options, withContext(ctx, opts)...)
+}
+
+// CreateObjectContext is CreateObject made with ctx; see CallContext.
+func (c *BACnetClient) CreateObjectContext(ctx context.Context, device DeviceInfo, objectType ObjectType, instance *uint32, initialValues []BACnetPropertyValue, opts ...CallOption) (BACnetObject, error) {
+	return c.CreateObject(device, objectType, instance, initialValues, withContext(ctx, opts)...)
+}
+
+// DeleteObjectContext is DeleteObject made with ctx; see CallContext.
+func (c *BACnetClient) DeleteObjectContext(ctx context.Context, device DeviceInfo, object BACnetObject, opts ...CallOption) error {
+	return c.DeleteObject(device, object, withContext(ctx, opts)...)
+}
+
+// DeviceCommunicationControlContext is DeviceCommunicationControl made with ctx; see CallContext.
+func (c *BACnetClient) DeviceCommunicationControlContext(ctx context.Context, device DeviceInfo, control CommunicationControl, password string, opts ...CallOption) error {
+	return c.DeviceCommunicationControl(device, control, password, withContext(ctx, opts)...)
+}
+
+// ExplainCommandContext is ExplainCommand made with ctx; see CallContext.
+func (c *BACnetClient) ExplainCommandContext(ctx context.Context, device DeviceInfo, object BACnetObject, opts ...CallOption) (CommandExplanation, error) {
+	return c.ExplainCommand(device, object, withContext(ctx, opts)...)
+}
+
+// FindObjectsContext is FindObjects made with ctx; see CallContext.
+func (c *BACnetClient) FindObjectsContext(ctx context.Context, device DeviceInfo, namePattern string, opts ...CallOption) ([]NamedObject, error) {
+	return c.FindObjects(device, namePattern, withContext(ctx, opts)...)
+}
+
+// FindObjectsRegexpContext is FindObjectsRegexp made with ctx; see CallContext.
+func (c *BACnetClient) FindObjectsRegexpContext(ctx context.Context, device DeviceInfo, re *regexp.Regexp, opts ...CallOption) ([]NamedObject, error) {
+	return c.FindObjectsRegexp(device, re, withContext(ctx, opts)...)
+}
+
+// GetAlarmSummaryContext is GetAlarmSummary made with ctx; see CallContext.
+func (c *BACnetClient) GetAlarmSummaryContext(ctx context.Context, device DeviceInfo, request GetAlarmSummaryRequest, opts ...CallOption) (GetAlarmSummaryAck, error) {
+	return c.GetAlarmSummary(device, request, withContext(ctx, opts)...)
+}
+
+// GetEventInformationContext is GetEventInformation made with ctx; see CallContext.
+func (c *BACnetClient) GetEventInformationContext(ctx context.Context, device DeviceInfo, opts ...CallOption) ([]EventSummary, error) {
+	return c.GetEventInformation(device, withContext(ctx, opts)...)
+}
+
+// GetObjectAllPropertyListContext is GetObjectAllPropertyList made with ctx; see CallContext.
+func (c *BACnetClient) GetObjectAllPropertyListContext(ctx context.Context, device DeviceInfo, object BACnetObject, opts ...CallOption) ([]BACnetPropertyValue, error) {
+	return c.GetObjectAllPropertyList(device, object, withContext(ctx, opts)...)
+}
+
+// GetObjectListContext is GetObjectList made with ctx; see CallContext.
+func (c *BACnetClient) GetObjectListContext(ctx context.Context, device DeviceInfo, opts ...CallOption) ([]BACnetObject, error) {
+	return c.GetObjectList(device, withContext(ctx, opts)...)
+}
+
+// HierarchyContext is Hierarchy made with ctx; see CallContext.
+func (c *BACnetClient) HierarchyContext(ctx context.Context, device DeviceInfo, opts ...CallOption) ([]*HierarchyNode, error) {
+	return c.Hierarchy(device, withContext(ctx, opts)...)
+}
+
+// LifeSafetyOperationContext is LifeSafetyOperation made with ctx; see CallContext.
+func (c *BACnetClient) LifeSafetyOperationContext(ctx context.Context, device DeviceInfo, request LifeSafetyOperationRequest, opts ...CallOption) error {
+	return c.LifeSafetyOperation(device, request, withContext(ctx, opts)...)
+}
+
+// ObjectsContext is Objects made with ctx; see CallContext.
+func (c *BACnetClient) ObjectsContext(ctx context.Context, device DeviceInfo, opts ...CallOption) (iter.Seq[BACnetObject], func() error) {
+	return c.Objects(device, withContext(ctx, opts)...)
+}
+
+// ProvisionContext is Provision made with ctx; see CallContext.
+func (c *BACnetClient) ProvisionContext(ctx context.Context, device DeviceInfo, template ObjectTemplate, instance *uint32, opts ...CallOption) (BACnetObject, error) {
+	return c.Provision(device, template, instance, withContext(ctx, opts)...)
+}
+
+// ReadActiveCOVSubscriptionsContext is ReadActiveCOVSubscriptions made with ctx; see CallContext.
+func (c *BACnetClient) ReadActiveCOVSubscriptionsContext(ctx context.Context, device DeviceInfo, opts ...CallOption) ([]ActiveCOVSubscription, error) {
+	return c.ReadActiveCOVSubscriptions(device, withContext(ctx, opts)...)
+}
+
+// ReadAddressBindingsContext is ReadAddressBindings made with ctx; see CallContext.
+func (c *BACnetClient) ReadAddressBindingsContext(ctx context.Context, device DeviceInfo, opts ...CallOption) ([]AddressBinding, error) {
+	return c.ReadAddressBindings(device, withContext(ctx, opts)...)
+}
+
+// ReadAllObjectsContext is ReadAllObjects made with ctx; see CallContext.
+func (c *BACnetClient) ReadAllObjectsContext(ctx context.Context, device DeviceInfo, propertyIDs []uint32, options ObjectWalkOptions, fn func(RPMResult) bool, opts ...CallOption) error {
+	return c.ReadAllObjects(device, propertyIDs, options, fn, withContext(ctx, opts)...)
+}
+
+// ReadArrayContext is ReadArray made with ctx; see CallContext.
+func (c *BACnetClient) ReadArrayContext(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, opts ...CallOption) ([]interface{}, error) {
+	return c.ReadArray(device, object, propertyID, withContext(ctx, opts)...)
+}
+
+// ReadArrayLengthContext is ReadArrayLength made with ctx; see CallContext.
+func (c *BACnetClient) ReadArrayLengthContext(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, opts ...CallOption) (uint32, error) {
+	return c.ReadArrayLength(device, object, propertyID, withContext(ctx, opts)...)
+}
+
+// ReadBinaryStateContext is ReadBinaryState made with ctx; see CallContext.
+func (c *BACnetClient) ReadBinaryStateContext(ctx context.Context, device DeviceInfo, object BACnetObject, opts ...CallOption) (BinaryState, error) {
+	return c.ReadBinaryState(device, object, withContext(ctx, opts)...)
+}
+
+// ReadGroupContext is ReadGroup made with ctx; see CallContext.
+func (c *BACnetClient) ReadGroupContext(ctx context.Context, device DeviceInfo, group BACnetObject, opts ...CallOption) ([]RPMResult, error) {
+	return c.ReadGroup(device, group, withContext(ctx, opts)...)
+}
+
+// ReadGroupMembersContext is ReadGroupMembers made with ctx; see CallContext.
+func (c *BACnetClient) ReadGroupMembersContext(ctx context.Context, device DeviceInfo, group BACnetObject, opts ...CallOption) ([]ReadAccessSpecification, error) {
+	return c.ReadGroupMembers(device, group, withContext(ctx, opts)...)
+}
+
+// ReadLogBufferContext is ReadLogBuffer made with ctx; see CallContext.
+func (c *BACnetClient) ReadLogBufferContext(ctx context.Context, device DeviceInfo, object BACnetObject, opts ...CallOption) ([]LogRecord, error) {
+	return c.ReadLogBuffer(device, object, withContext(ctx, opts)...)
+}
+
+// ReadMSTPSettingsContext is ReadMSTPSettings made with ctx; see CallContext.
+func (c *BACnetClient) ReadMSTPSettingsContext(ctx context.Context, device DeviceInfo, opts ...CallOption) (MSTPSettings, error) {
+	return c.ReadMSTPSettings(device, withContext(ctx, opts)...)
+}
+
+// ReadObjectListPageContext is ReadObjectListPage made with ctx; see CallContext.
+func (c *BACnetClient) ReadObjectListPageContext(ctx context.Context, device DeviceInfo, offset uint32, limit int, opts ...CallOption) (ObjectListPage, error) {
+	return c.ReadObjectListPage(device, offset, limit, withContext(ctx, opts)...)
+}
+
+// ReadPresentValueContext is ReadPresentValue made with ctx; see CallContext.
+func (c *BACnetClient) ReadPresentValueContext(ctx context.Context, device DeviceInfo, object BACnetObject, opts ...CallOption) (interface{}, error) {
+	return c.ReadPresentValue(device, object, withContext(ctx, opts)...)
+}
+
+// ReadPropertiesFromMultipleObjectsContext is ReadPropertiesFromMultipleObjects made with ctx; see CallContext.
+func (c *BACnetClient) ReadPropertiesFromMultipleObjectsContext(ctx context.Context, device DeviceInfo, objects []BACnetObject, propertyID uint32, opts ...CallOption) (map[BACnetObject]interface{}, error) {
+	return c.ReadPropertiesFromMultipleObjects(device, objects, propertyID, withContext(ctx, opts)...)
+}
+
+// ReadPropertyContext is ReadProperty made with ctx; see CallContext.
+func (c *BACnetClient) ReadPropertyContext(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, arrayIndex *uint32, opts ...CallOption) (interface{}, error) {
+	return c.ReadProperty(device, object, propertyID, arrayIndex, withContext(ctx, opts)...)
+}
+
+// ReadPropertyMultipleStreamContext is ReadPropertyMultipleStream made with ctx; see CallContext.
+func (c *BACnetClient) ReadPropertyMultipleStreamContext(ctx context.Context, device DeviceInfo, objects []BACnetObject, propertyIDs []uint32, fn func(RPMResult) bool, opts ...CallOption) error {
+	return c.ReadPropertyMultipleStream(device, objects, propertyIDs, fn, withContext(ctx, opts)...)
+}
+
+// ReadRangeContext is ReadRange made with ctx; see CallContext.
+func (c *BACnetClient) ReadRangeContext(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, rng Range, opts ...CallOption) (RangeResult, error) {
+	return c.ReadRange(device, object, propertyID, rng, withContext(ctx, opts)...)
+}
+
+// ReadSpecificPropertiesFromObjectContext is ReadSpecificPropertiesFromObject made with ctx; see CallContext.
+func (c *BACnetClient) ReadSpecificPropertiesFromObjectContext(ctx context.Context, device DeviceInfo, object BACnetObject, propertyIDs []uint32, opts ...CallOption) (PropertyResults, error) {
+	return c.ReadSpecificPropertiesFromObject(device, object, propertyIDs, withContext(ctx, opts)...)
+}
+
+// ReadStagingContext is ReadStaging made with ctx; see CallContext.
+func (c *BACnetClient) ReadStagingContext(ctx context.Context, device DeviceInfo, object BACnetObject, opts ...CallOption) (StagingStatus, error) {
+	return c.ReadStaging(device, object, withContext(ctx, opts)...)
+}
+
+// ReadTimerContext is ReadTimer made with ctx; see CallContext.
+func (c *BACnetClient) ReadTimerContext(ctx context.Context, device DeviceInfo, object BACnetObject, opts ...CallOption) (TimerStatus, error) {
+	return c.ReadTimer(device, object, withContext(ctx, opts)...)
+}
+
+// ReinitializeDeviceContext is ReinitializeDevice made with ctx; see CallContext.
+func (c *BACnetClient) ReinitializeDeviceContext(ctx context.Context, device DeviceInfo, state ReinitializeState, password string, opts ...CallOption) error {
+	return c.ReinitializeDevice(device, state, password, withContext(ctx, opts)...)
+}
+
+// SendTextMessageContext is SendTextMessage made with ctx; see CallContext.
+func (c *BACnetClient) SendTextMessageContext(ctx context.Context, device DeviceInfo, message TextMessage, opts ...CallOption) error {
+	return c.SendTextMessage(device, message, withContext(ctx, opts)...)
+}
+
+// SnapshotContext is Snapshot made with ctx; see CallContext.
+func (c *BACnetClient) SnapshotContext(ctx context.Context, device DeviceInfo, opts ...CallOption) (*DeviceSnapshot, error) {
+	return c.Snapshot(device, withContext(ctx, opts)...)
+}
+
+// StartTimerContext is StartTimer made with ctx; see CallContext.
+func (c *BACnetClient) StartTimerContext(ctx context.Context, device DeviceInfo, object BACnetObject, d time.Duration, opts ...CallOption) error {
+	return c.StartTimer(device, object, d, withContext(ctx, opts)...)
+}
+
+// UnsubscribeCOVContext is UnsubscribeCOV made with ctx; see CallContext.
+func (c *BACnetClient) UnsubscribeCOVContext(ctx context.Context, device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, opts ...CallOption) error {
+	return c.UnsubscribeCOV(device, object, subscriberProcessIdentifier, withContext(ctx, opts)...)
+}
+
+// WalkObjectListContext is WalkObjectList made with ctx; see CallContext.
+func (c *BACnetClient) WalkObjectListContext(ctx context.Context, device DeviceInfo, options ObjectWalkOptions, fn func(BACnetObject) bool, opts ...CallOption) error {
+	return c.WalkObjectList(device, options, fn, withContext(ctx, opts)...)
+}
+
+// WhoIsContext is WhoIs made with ctx; see CallContext.
+func (c *BACnetClient) WhoIsContext(ctx context.Context, broadcastAddr *net.UDPAddr, opts ...CallOption) ([]DeviceInfo, error) {
+	return c.WhoIs(broadcastAddr, withContext(ctx, opts)...)
+}
+
+// WhoIsRangeContext is WhoIsRange made with ctx; see CallContext.
+func (c *BACnetClient) WhoIsRangeContext(ctx context.Context, broadcastAddr *net.UDPAddr, low uint32, high uint32, opts ...CallOption) ([]DeviceInfo, error) {
+	return c.WhoIsRange(broadcastAddr, low, high, withContext(ctx, opts)...)
+}
+
+// WhoIsWithOptionsContext is WhoIsWithOptions made with ctx; see CallContext.
+func (c *BACnetClient) WhoIsWithOptionsContext(ctx context.Context, broadcastAddr *net.UDPAddr, options WhoIsOptions, opts ...CallOption) ([]DeviceInfo, error) {
+	return c.WhoIsWithOptions(broadcastAddr, options, withContext(ctx, opts)...)
+}
+
+// WriteAddressBindingsContext is WriteAddressBindings made with ctx; see CallContext.
+func (c *BACnetClient) WriteAddressBindingsContext(ctx context.Context, device DeviceInfo, bindings []AddressBinding, opts ...CallOption) error {
+	return c.WriteAddressBindings(device, bindings, withContext(ctx, opts)...)
+}
+
+// WriteDailyScheduleContext is WriteDailySchedule made with ctx; see CallContext.
+func (c *BACnetClient) WriteDailyScheduleContext(ctx context.Context, device DeviceInfo, schedule BACnetObject, weekday uint8, values []TimeValue, opts ...CallOption) error {
+	return c.WriteDailySchedule(device, schedule, weekday, values, withContext(ctx, opts)...)
+}
+
+// WritePresentValueContext is WritePresentValue made with ctx; see CallContext.
+func (c *BACnetClient) WritePresentValueContext(ctx context.Context, device DeviceInfo, object BACnetObject, value interface{}, priority uint8, opts ...CallOption) error {
+	return c.WritePresentValue(device, object, value, priority, withContext(ctx, opts)...)
+}
+
+// WritePropertyContext is WriteProperty made with ctx; see CallContext.
+func (c *BACnetClient) WritePropertyContext(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, value interface{}, priority uint8, opts ...CallOption) error {
+	return c.WriteProperty(device, object, propertyID, value, priority, withContext(ctx, opts)...)
+}
+
+// WritePropertyMultipleContext is WritePropertyMultiple made with ctx; see CallContext.
+func (c *BACnetClient) WritePropertyMultipleContext(ctx context.Context, device DeviceInfo, writes []PropertyWrite, opts ...CallOption) error {
+	return c.WritePropertyMultiple(device, writes, withContext(ctx, opts)...)
+}
+
+// WriteWeeklyScheduleContext is WriteWeeklySchedule made with ctx; see CallContext.
+func (c *BACnetClient) WriteWeeklyScheduleContext(ctx context.Context, device DeviceInfo, schedule BACnetObject, weekly [7][]TimeValue, opts ...CallOption) error {
+	return c.WriteWeeklySchedule(device, schedule, weekly, withContext(ctx, opts)...)
+}
@@ -0,0 +1,58 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// DeviceCommunicationControl asks a device to enable or disable communication. A non-zero
+// control.Duration is sent rounded up to whole minutes; password is omitted if empty.
+func (c *BACnetClient) DeviceCommunicationControl(device DeviceInfo, control CommunicationControl, password string, opts ...CallOption) error {
+	ctx := callContext(opts...)
+	if send, err := c.authorize(ctx, Mutation{Service: SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL, Device: device, Value: control}); !send {
+		return err
+	}
+
+	var params bytes.Buffer
+	if control.Duration > 0 {
+		minutes := (control.Duration + time.Minute - 1) / time.Minute
+		encoding.EncodeContextUnsigned(&params, 0, uint32(min(minutes, 0xFFFF)))
+	}
+	encoding.EncodeContextEnumerated(&params, 1, uint32(control.State))
+	if password != "" {
+		encoding.EncodeContextCharacterString(&params, 2, password)
+	}
+	return c.controlRequest(ctx, device, SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL, params.Bytes())
+}
+
+// ReinitializeDevice asks a device to restart or to enter a backup or restore state; password is
+// omitted if empty.
+func (c *BACnetClient) ReinitializeDevice(device DeviceInfo, state ReinitializeState, password string, opts ...CallOption) error {
+	ctx := callContext(opts...)
+	if send, err := c.authorize(ctx, Mutation{Service: SERVICE_CONFIRMED_REINITIALIZE_DEVICE, Device: device, Value: state}); !send {
+		return err
+	}
+
+	var params bytes.Buffer
+	encoding.EncodeContextEnumerated(&params, 0, uint32(state))
+	if password != "" {
+		encoding.EncodeContextCharacterString(&params, 1, password)
+	}
+	return c.controlRequest(ctx, device, SERVICE_CONFIRMED_REINITIALIZE_DEVICE, params.Bytes())
+}
+
+// controlRequest sends a device management request answered by a Simple-ACK.
+func (c *BACnetClient) controlRequest(ctx context.Context, device DeviceInfo, service byte, params []byte) error {
+	_, data, err := c.transact(ctx, device, service, params)
+	if err != nil {
+		return err
+	}
+	if err := expectSimpleAck(data); err != nil {
+		return fmt.Errorf("%s of device %d failed: %w", TransactionInfo{Service: service}.ServiceName(), device.DeviceID, err)
+	}
+	return nil
+}
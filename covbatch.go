@@ -0,0 +1,102 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// COVBatchOptions configures SubscribeCOVBatch.
+type COVBatchOptions struct {
+	// COVOptions configures every subscription; BufferSize and Backpressure apply to the merged
+	// channel instead.
+	COVOptions
+	// Concurrency is how many subscription requests of the batch are outstanding at a time, so
+	// that subscribing hundreds of objects does not flood the device; zero means 4.
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// SubscriptionError is an error of one subscription of SubscribeCOVBatch, a COVManager or a
+// NotificationHub.
+type SubscriptionError struct {
+	DeviceID uint32       `json:"deviceId"`
+	Object   BACnetObject `json:"object"`
+	Err      error        `json:"-"`
+}
+
+func (e *SubscriptionError) Error() string {
+	return fmt.Sprintf("%s of device %d: %v", e.Object, e.DeviceID, e.Err)
+}
+
+func (e *SubscriptionError) Unwrap() error { return e.Err }
+
+// SubscribeCOVBatch subscribes to many objects of a device, each with its own SubscribeCOV
+// subscription, and merges their notifications into one channel; every notification names its
+// object in MonitoredObjectIdentifier. Errors are SubscriptionErrors naming the object, and are
+// dropped while the error channel is full. A failing subscription ends alone; both channels are
+// closed once all have ended, as with SubscribeCOV.
+func (c *BACnetClient) SubscribeCOVBatch(ctx context.Context, device DeviceInfo, objects []BACnetObject, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime uint32, options COVBatchOptions) (<-chan COVNotification, <-chan error) {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	slots := make(chan struct{}, concurrency)
+
+	merged := options.COVOptions
+	if merged.Backpressure == BackpressureCoalesce {
+		merged.BufferSize = 1
+	}
+	covChan := make(chan COVNotification, merged.BufferSize)
+	errChan := make(chan error, len(objects))
+	each := options.COVOptions
+	each.BufferSize = 0
+	each.Backpressure = BackpressureBlock
+
+	var wg sync.WaitGroup
+	for _, object := range objects {
+		notifications, errs := c.runCOVSubscription(ctx, covSubscription{
+			name:      "SubscribeCOV",
+			device:    device,
+			processID: subscriberProcessIdentifier,
+			objects:   []BACnetObject{object},
+			lifetime:  lifetime,
+			subscribe: func(ctx context.Context) error {
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				defer func() { <-slots }()
+				return c.sendSubscribeCOVRequest(ctx, device, object, subscriberProcessIdentifier, issueConfirmedNotifications, lifetime)
+			},
+			unsubscribe: func(ctx context.Context) error {
+				return c.unsubscribeCOV(ctx, device, object, subscriberProcessIdentifier)
+			},
+		}, each)
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for notification := range notifications {
+				if !c.deliver(ctx, covChan, merged.Backpressure, notification) {
+					// Cancelled while waiting for the consumer; let the subscription end
+					for range notifications {
+					}
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for err := range errs {
+				reportError(errChan, &SubscriptionError{DeviceID: device.DeviceID, Object: object, Err: err})
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(covChan)
+		close(errChan)
+	}()
+	return covChan, errChan
+}
@@ -0,0 +1,138 @@
+package bacnet
+
+import "time"
+
+// covFilter applies the filters of COVOptions to the notifications of a subscription before they
+// reach the notification channel.
+type covFilter struct {
+	options COVOptions
+	// last holds the latest value accepted of every property
+	last map[uint32]interface{}
+	// delivered is when the last notification was passed on; pending is held back until
+	// MinInterval has passed since then
+	delivered time.Time
+	pending   *COVNotification
+}
+
+func newCOVFilter(options COVOptions) *covFilter {
+	return &covFilter{options: options, last: make(map[uint32]interface{})}
+}
+
+// accept filters a notification received at now. It returns the notification to deliver, which
+// includes the values of notifications held back before it, and false if there is none yet;
+// filtered reports that n was discarded outright.
+func (f *covFilter) accept(n COVNotification, now time.Time) (_ COVNotification, deliver, filtered bool) {
+	if len(f.options.Properties) > 0 {
+		values := make([]BACnetPropertyValue, 0, len(n.ListOfValues))
+		for _, v := range n.ListOfValues {
+			for _, id := range f.options.Properties {
+				if v.PropertyID == id {
+					values = append(values, v)
+					break
+				}
+			}
+		}
+		if len(values) == 0 {
+			return n, false, true
+		}
+		n.ListOfValues = values
+	}
+
+	if f.options.IgnoreStatusFlagsOnly && f.statusFlagsOnly(n) {
+		return n, false, true
+	}
+	for _, v := range n.ListOfValues {
+		f.last[v.PropertyID] = v.Value
+	}
+
+	if f.pending != nil {
+		n = coalesceNotifications(*f.pending, n)
+		f.pending = nil
+	}
+	if f.options.MinInterval > 0 && now.Sub(f.delivered) < f.options.MinInterval {
+		f.pending = &n
+		return n, false, false
+	}
+	f.delivered = now
+	return n, true, false
+}
+
+// statusFlagsOnly reports whether a notification changes nothing but Status_Flags compared to the
+// values accepted before it. The first notification is never such an update.
+func (f *covFilter) statusFlagsOnly(n COVNotification) bool {
+	hasFlags := false
+	for _, v := range n.ListOfValues {
+		if v.PropertyID == PROP_STATUS_FLAGS {
+			hasFlags = true
+			continue
+		}
+		if last, ok := f.last[v.PropertyID]; !ok || !ValuesEqual(last, v.Value) {
+			return false
+		}
+	}
+	return hasFlags
+}
+
+// due returns when the notification held back is to be delivered, and false if none is.
+func (f *covFilter) due() (time.Time, bool) {
+	if f.pending == nil {
+		return time.Time{}, false
+	}
+	return f.delivered.Add(f.options.MinInterval), true
+}
+
+// flush returns the notification held back if it is due at now.
+func (f *covFilter) flush(now time.Time) (COVNotification, bool) {
+	due, ok := f.due()
+	if !ok || now.Before(due) {
+		return COVNotification{}, false
+	}
+	n := *f.pending
+	f.pending = nil
+	f.delivered = now
+	return n, true
+}
+
+// covFilters keeps a covFilter per monitored object, since a subscription may cover several
+// objects whose properties must not be mixed up.
+type covFilters struct {
+	options  COVOptions
+	byObject map[BACnetObject]*covFilter
+}
+
+func newCOVFilters(options COVOptions) *covFilters {
+	return &covFilters{options: options, byObject: make(map[BACnetObject]*covFilter)}
+}
+
+// accept filters n with the filter of its monitored object, see covFilter.accept.
+func (s *covFilters) accept(n COVNotification, now time.Time) (COVNotification, bool, bool) {
+	f, ok := s.byObject[n.MonitoredObjectIdentifier]
+	if !ok {
+		f = newCOVFilter(s.options)
+		s.byObject[n.MonitoredObjectIdentifier] = f
+	}
+	return f.accept(n, now)
+}
+
+// due returns when the earliest notification held back is to be delivered, and false if none is.
+func (s *covFilters) due() (time.Time, bool) {
+	var earliest time.Time
+	found := false
+	for _, f := range s.byObject {
+		if due, ok := f.due(); ok && (!found || due.Before(earliest)) {
+			earliest, found = due, true
+		}
+	}
+	return earliest, found
+}
+
+// flush returns the notifications held back that are due at now.
+func (s *covFilters) flush(now time.Time) []COVNotification {
+	var due []COVNotification
+	for _, f := range s.byObject {
+		if n, ok := f.flush(now); ok {
+			due = append(due, n)
+		}
+	}
+	return due
+}
@@ -0,0 +1,155 @@
+package bacnet
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// COVSubscriptionConfig describes a COV subscription held by a COVManager.
+type COVSubscriptionConfig struct {
+	Device                      DeviceInfo   `json:"device"`
+	Object                      BACnetObject `json:"object"`
+	SubscriberProcessIdentifier uint32       `json:"subscriberProcessIdentifier"`
+	IssueConfirmedNotifications bool         `json:"issueConfirmedNotifications"`
+	Lifetime                    uint32       `json:"lifetime"`
+	// Options configures the subscription's notification channel; nil uses the client defaults.
+	Options *COVOptions `json:"options,omitempty"`
+}
+
+type covKey struct {
+	deviceID uint32
+	object   BACnetObject
+}
+
+// COVManager holds any number of COV subscriptions, across any number of devices, and merges their
+// notifications and errors into a single pair of channels. Notifications name their device and
+// object; errors are SubscriptionErrors. All subscriptions of a client share its receive loop.
+type COVManager struct {
+	client        *BACnetClient
+	ctx           context.Context
+	cancel        context.CancelFunc
+	notifications chan COVNotification
+	errors        chan error
+
+	mu            sync.Mutex
+	subscriptions map[covKey]*managedSubscription
+	wg            sync.WaitGroup
+}
+
+type managedSubscription struct {
+	config COVSubscriptionConfig
+	cancel context.CancelFunc
+}
+
+// NewCOVManager returns a manager subscribing through client. All subscriptions end when ctx is
+// cancelled or Close is called.
+func NewCOVManager(ctx context.Context, client *BACnetClient) *COVManager {
+	ctx, cancel := context.WithCancel(ctx)
+	return &COVManager{
+		client:        client,
+		ctx:           ctx,
+		cancel:        cancel,
+		notifications: make(chan COVNotification),
+		errors:        make(chan error, 16),
+		subscriptions: make(map[covKey]*managedSubscription),
+	}
+}
+
+// Notifications returns the channel all COV notifications are delivered on.
+func (m *COVManager) Notifications() <-chan COVNotification {
+	return m.notifications
+}
+
+// Errors returns the channel subscription errors are delivered on. Errors are dropped when the
+// channel is full.
+func (m *COVManager) Errors() <-chan error {
+	return m.errors
+}
+
+// Subscribe starts a subscription. An existing subscription to the same object is replaced.
+func (m *COVManager) Subscribe(config COVSubscriptionConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := covKey{deviceID: config.Device.DeviceID, object: config.Object}
+	if old, ok := m.subscriptions[key]; ok {
+		old.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.subscriptions[key] = &managedSubscription{config: config, cancel: cancel}
+
+	options := COVOptions{BufferSize: m.client.options.COVBufferSize, Backpressure: m.client.options.COVBackpressure}
+	if config.Options != nil {
+		options = *config.Options
+	}
+	covChan, errChan := m.client.SubscribeCOVWithOptions(ctx, config.Device, config.Object, config.SubscriberProcessIdentifier, config.IssueConfirmedNotifications, config.Lifetime, options)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.forward(ctx, config, covChan, errChan)
+	}()
+}
+
+// forward copies the notifications and errors of one subscription to the manager's channels.
+func (m *COVManager) forward(ctx context.Context, config COVSubscriptionConfig, covChan <-chan COVNotification, errChan <-chan error) {
+	for covChan != nil || errChan != nil {
+		select {
+		case notification, ok := <-covChan:
+			if !ok {
+				covChan = nil
+				continue
+			}
+			select {
+			case m.notifications <- notification:
+			case <-ctx.Done():
+			}
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			select {
+			case m.errors <- &SubscriptionError{DeviceID: config.Device.DeviceID, Object: config.Object, Err: err}:
+			default:
+			}
+		}
+	}
+}
+
+// Unsubscribe stops the subscription to an object.
+func (m *COVManager) Unsubscribe(deviceID uint32, object BACnetObject) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := covKey{deviceID: deviceID, object: object}
+	if sub, ok := m.subscriptions[key]; ok {
+		sub.cancel()
+		delete(m.subscriptions, key)
+	}
+}
+
+// Subscriptions returns the configuration of all subscriptions, ordered by device and object.
+func (m *COVManager) Subscriptions() []COVSubscriptionConfig {
+	m.mu.Lock()
+	configs := make([]COVSubscriptionConfig, 0, len(m.subscriptions))
+	for _, sub := range m.subscriptions {
+		configs = append(configs, sub.config)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(configs, func(i, j int) bool {
+		return pointRefLess(
+			PointRef{DeviceID: configs[i].Device.DeviceID, Object: configs[i].Object},
+			PointRef{DeviceID: configs[j].Device.DeviceID, Object: configs[j].Object},
+		)
+	})
+	return configs
+}
+
+// Close ends all subscriptions and waits for them to stop.
+func (m *COVManager) Close() {
+	m.cancel()
+	m.wg.Wait()
+}
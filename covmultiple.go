@@ -0,0 +1,166 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// COVReference is a property monitored by a SubscribeCOVPropertyMultiple subscription.
+type COVReference struct {
+	PropertyID uint32 `json:"propertyId"`
+	// ArrayIndex, if set, monitors a single element of an array property.
+	ArrayIndex *uint32 `json:"arrayIndex,omitempty"`
+	// Increment, if set, is the change of a REAL property that triggers a notification, instead of
+	// the COV_Increment of the object.
+	Increment *float32 `json:"increment,omitempty"`
+}
+
+// COVSubscriptionSpec is an object and the properties of it a SubscribeCOVPropertyMultiple
+// subscription monitors.
+type COVSubscriptionSpec struct {
+	Object     BACnetObject   `json:"object"`
+	References []COVReference `json:"references"`
+}
+
+// SubscribeCOVPropertyMultiple subscribes to changes of many properties across many objects of a
+// device with a single subscription, which renews and ends like that of SubscribeCOV. Each
+// notification on the channel carries the values of one object; a COV-Notification-Multiple is
+// split into one notification per object. Cancelling ctx cancels the subscription on the device.
+// The device must support DS-COVM-B.
+func (c *BACnetClient) SubscribeCOVPropertyMultiple(ctx context.Context, device DeviceInfo, specs []COVSubscriptionSpec, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime uint32, options COVOptions) (<-chan COVNotification, <-chan error) {
+	objects := make([]BACnetObject, len(specs))
+	for i, spec := range specs {
+		objects[i] = spec.Object
+	}
+	params := encodeSubscribeCOVPropertyMultiple(specs, subscriberProcessIdentifier, issueConfirmedNotifications, lifetime, uint32(options.MaxNotificationDelay.Seconds()))
+	return c.runCOVSubscription(ctx, covSubscription{
+		name:      "SubscribeCOVPropertyMultiple",
+		device:    device,
+		processID: subscriberProcessIdentifier,
+		objects:   objects,
+		lifetime:  lifetime,
+		subscribe: func(ctx context.Context) error {
+			return c.sendSubscriptionRequest(ctx, device, SERVICE_CONFIRMED_SUBSCRIBE_COV_PROPERTY_MULTIPLE, params)
+		},
+		unsubscribe: func(ctx context.Context) error {
+			cancellation := encodeCancelCOVPropertyMultiple(specs, subscriberProcessIdentifier)
+			return c.sendSubscriptionRequest(ctx, device, SERVICE_CONFIRMED_SUBSCRIBE_COV_PROPERTY_MULTIPLE, cancellation)
+		},
+	}, options)
+}
+
+// encodeSubscribeCOVPropertyMultiple encodes the parameters of a SubscribeCOVPropertyMultiple
+// request. A maxNotificationDelay of zero is omitted.
+func encodeSubscribeCOVPropertyMultiple(specs []COVSubscriptionSpec, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime, maxNotificationDelay uint32) []byte {
+	var params bytes.Buffer
+	encoding.EncodeContextUnsigned(&params, 0, subscriberProcessIdentifier)
+	encoding.EncodeContextBoolean(&params, 1, issueConfirmedNotifications)
+	encoding.EncodeContextUnsigned(&params, 2, lifetime)
+	if maxNotificationDelay > 0 {
+		encoding.EncodeContextUnsigned(&params, 3, maxNotificationDelay)
+	}
+	encodeCOVSubscriptionSpecs(&params, specs)
+	return params.Bytes()
+}
+
+// encodeCancelCOVPropertyMultiple encodes a SubscribeCOVPropertyMultiple request cancelling the
+// subscriptions of specs, which omits the notification type and lifetime.
+func encodeCancelCOVPropertyMultiple(specs []COVSubscriptionSpec, subscriberProcessIdentifier uint32) []byte {
+	var params bytes.Buffer
+	encoding.EncodeContextUnsigned(&params, 0, subscriberProcessIdentifier)
+	encodeCOVSubscriptionSpecs(&params, specs)
+	return params.Bytes()
+}
+
+// encodeCOVSubscriptionSpecs encodes the list of COV subscription specifications, context tag 4.
+func encodeCOVSubscriptionSpecs(params *bytes.Buffer, specs []COVSubscriptionSpec) {
+	encoding.EncodeOpeningTag(params, 4)
+	for _, spec := range specs {
+		encoding.EncodeContextObjectID(params, 0, uint32(spec.Object.Type), spec.Object.Instance)
+		encoding.EncodeOpeningTag(params, 1)
+		for _, ref := range spec.References {
+			// Monitored property
+			encoding.EncodeOpeningTag(params, 0)
+			encoding.EncodeContextUnsigned(params, 0, ref.PropertyID)
+			if ref.ArrayIndex != nil {
+				encoding.EncodeContextUnsigned(params, 1, *ref.ArrayIndex)
+			}
+			encoding.EncodeClosingTag(params, 0)
+			if ref.Increment != nil {
+				encoding.EncodeContextReal(params, 1, *ref.Increment)
+			}
+			encoding.EncodeContextBoolean(params, 2, false) // Not timestamped
+		}
+		encoding.EncodeClosingTag(params, 1)
+	}
+	encoding.EncodeClosingTag(params, 4)
+}
+
+// parseCOVNotificationMultiple decodes a confirmed or unconfirmed COV-Notification-Multiple into
+// one notification per object.
+func parseCOVNotificationMultiple(data []byte) ([]COVNotification, error) {
+	r, err := notificationParams(data, SERVICE_UNCONFIRMED_COV_NOTIFICATION_MULTIPLE, SERVICE_CONFIRMED_COV_NOTIFICATION_MULTIPLE, "COV-Notification-Multiple")
+	if err != nil {
+		return nil, err
+	}
+
+	subscriberProcessIdentifier, err := encoding.DecodeContextUnsigned(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error reading subscriber process identifier: %w", err)
+	}
+	deviceType, deviceInstance, err := encoding.DecodeContextObjectID(r, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error reading initiating device identifier: %w", err)
+	}
+	timeRemaining, err := encoding.DecodeContextUnsigned(r, 2)
+	if err != nil {
+		return nil, fmt.Errorf("error reading time remaining: %w", err)
+	}
+	if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsOpening(3) {
+		encoding.DecodeTagHeader(r)
+		if err := encoding.SkipValue(r, tag); err != nil {
+			return nil, fmt.Errorf("error reading timestamp: %w", err)
+		}
+	}
+	if err := encoding.ExpectOpeningTag(r, 4); err != nil {
+		return nil, fmt.Errorf("expected list of notifications: %w", err)
+	}
+
+	var notifications []COVNotification
+	for {
+		tag, err := encoding.PeekTagHeader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tag inside list of notifications: %w", err)
+		}
+		if tag.IsClosing(4) {
+			return notifications, nil
+		}
+
+		objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+		if err != nil {
+			return nil, fmt.Errorf("error reading monitored object identifier: %w", err)
+		}
+		notification := COVNotification{
+			SubscriberProcessIdentifier: subscriberProcessIdentifier,
+			InitiatingDeviceIdentifier:  BACnetObject{Type: ObjectType(deviceType), Instance: deviceInstance},
+			MonitoredObjectIdentifier:   BACnetObject{Type: ObjectType(objectType), Instance: instance},
+			TimeRemaining:               timeRemaining,
+		}
+		if err := encoding.ExpectOpeningTag(r, 1); err != nil {
+			return nil, fmt.Errorf("expected values of %s: %w", notification.MonitoredObjectIdentifier, err)
+		}
+		// The optional time of change of a value is skipped like the priority of a property value
+		values, err := decodePropertyValueList(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			value.Value = typedPropertyValue(notification.MonitoredObjectIdentifier.Type, value.PropertyID, value.Value)
+			notification.ListOfValues = append(notification.ListOfValues, value)
+		}
+		notifications = append(notifications, notification)
+	}
+}
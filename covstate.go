@@ -0,0 +1,77 @@
+package bacnet
+
+import "time"
+
+// COVState is a step in the lifecycle of a COV subscription, reported to COVOptions.OnState.
+type COVState uint32
+
+const (
+	// COVSubscribed: the device acknowledged the subscription, initially or after a takeover.
+	COVSubscribed COVState = iota
+	// COVRenewed: the device acknowledged a renewal.
+	COVRenewed
+	// COVRenewalFailed: a renewal failed; Err is the ResubscribeError, which tells whether the
+	// renewal is retried.
+	COVRenewalFailed
+	// COVExpired: the lifetime of the subscription passed without a successful renewal, so the
+	// device no longer sends notifications. Renewals are still retried.
+	COVExpired
+	// COVStandby: the client was demoted to standby and stopped renewing; the subscription is
+	// established again after the next promotion. See StartFailover.
+	COVStandby
+	// COVCancelled: the subscription ended because its context was cancelled or the client was
+	// closed.
+	COVCancelled
+	// COVFailed: the subscription ended because of Err.
+	COVFailed
+)
+
+var covStateNames = map[COVState]string{
+	COVSubscribed:    "subscribed",
+	COVRenewed:       "renewed",
+	COVRenewalFailed: "renewal-failed",
+	COVExpired:       "expired",
+	COVStandby:       "standby",
+	COVCancelled:     "cancelled",
+	COVFailed:        "failed",
+}
+
+func (s COVState) String() string { return enumName(covStateNames, s) }
+
+// COVStateChange reports a step in the lifecycle of a COV subscription. Every subscription ends
+// with COVCancelled or COVFailed.
+type COVStateChange struct {
+	DeviceID  uint32 `json:"deviceId"`
+	ProcessID uint32 `json:"processId"`
+	// Objects are the monitored objects.
+	Objects []BACnetObject `json:"objects"`
+	State   COVState       `json:"state"`
+	// Expires is when the subscription lapses on the device unless it is renewed; zero for
+	// subscriptions without a lifetime and after the subscription ended.
+	Expires time.Time `json:"expires"`
+	Err     error     `json:"-"`
+}
+
+// reportState passes a state change of sub to options.OnState.
+func (sub covSubscription) reportState(options COVOptions, state COVState, expires time.Time, err error) {
+	if options.OnState == nil {
+		return
+	}
+	options.OnState(COVStateChange{
+		DeviceID:  sub.device.DeviceID,
+		ProcessID: sub.processID,
+		Objects:   sub.objects,
+		State:     state,
+		Expires:   expires,
+		Err:       err,
+	})
+}
+
+// expiry returns when a subscription made or renewed at t lapses, or the zero time if it has no
+// lifetime.
+func (sub covSubscription) expiry(t time.Time) time.Time {
+	if sub.lifetime == 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(sub.lifetime) * time.Second)
+}
@@ -0,0 +1,176 @@
+package bacnet
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// COVRecipient is the process notifications of a COV subscription are sent to. The recipient is
+// identified either by its device or by its network address.
+type COVRecipient struct {
+	Device *BACnetObject `json:"device,omitempty"`
+	// Network and MAC are set when the recipient is identified by address.
+	Network   uint16 `json:"network,omitempty"`
+	MAC       []byte `json:"mac,omitempty"`
+	ProcessID uint32 `json:"processId"`
+}
+
+// ActiveCOVSubscription is an entry of the Active_COV_Subscriptions of a Device object.
+type ActiveCOVSubscription struct {
+	Recipient  COVRecipient `json:"recipient"`
+	Object     BACnetObject `json:"object"`
+	PropertyID uint32       `json:"propertyId"`
+	ArrayIndex *uint32      `json:"arrayIndex,omitempty"`
+	Confirmed  bool         `json:"confirmed"`
+	// TimeRemaining is zero for subscriptions without a lifetime.
+	TimeRemaining time.Duration `json:"timeRemaining"`
+	// Increment is the COV increment of the subscription, if it has one.
+	Increment *float32 `json:"increment,omitempty"`
+}
+
+// ReadActiveCOVSubscriptions reads the Active_COV_Subscriptions of a device, i.e. all COV
+// subscriptions the device currently serves.
+func (c *BACnetClient) ReadActiveCOVSubscriptions(device DeviceInfo, opts ...CallOption) ([]ActiveCOVSubscription, error) {
+	deviceObject := BACnetObject{Type: OBJECT_DEVICE, Instance: device.DeviceID}
+	raw, err := c.readPropertyRaw(callContext(opts...), device, deviceObject, PROP_ACTIVE_COV_SUBSCRIPTIONS, nil)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := decodeActiveCOVSubscriptions(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode active COV subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// decodeActiveCOVSubscriptions decodes a list of BACnetCOVSubscription as returned by readPropertyRaw.
+func decodeActiveCOVSubscriptions(raw []byte) ([]ActiveCOVSubscription, error) {
+	r := bytes.NewReader(raw)
+	var subscriptions []ActiveCOVSubscription
+	for r.Len() > 0 {
+		var s ActiveCOVSubscription
+		var err error
+
+		// [0] Recipient
+		if err := encoding.ExpectOpeningTag(r, 0); err != nil {
+			return nil, err
+		}
+		if s.Recipient, err = decodeCOVRecipient(r); err != nil {
+			return nil, err
+		}
+		if err := encoding.ExpectClosingTag(r, 0); err != nil {
+			return nil, err
+		}
+
+		// [1] Monitored property reference
+		if err := encoding.ExpectOpeningTag(r, 1); err != nil {
+			return nil, err
+		}
+		objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		s.Object = BACnetObject{Type: ObjectType(objectType), Instance: instance}
+		if s.PropertyID, err = encoding.DecodeContextUnsigned(r, 1); err != nil {
+			return nil, err
+		}
+		if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsContext(2) {
+			index, err := encoding.DecodeContextUnsigned(r, 2)
+			if err != nil {
+				return nil, err
+			}
+			s.ArrayIndex = &index
+		}
+		if err := encoding.ExpectClosingTag(r, 1); err != nil {
+			return nil, err
+		}
+
+		// [2] Issue confirmed notifications, [3] time remaining, [4] optional COV increment
+		if s.Confirmed, err = encoding.DecodeContextBoolean(r, 2); err != nil {
+			return nil, err
+		}
+		seconds, err := encoding.DecodeContextUnsigned(r, 3)
+		if err != nil {
+			return nil, err
+		}
+		s.TimeRemaining = time.Duration(seconds) * time.Second
+		if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsContext(4) {
+			increment, err := encoding.DecodeContextReal(r, 4)
+			if err != nil {
+				return nil, err
+			}
+			s.Increment = &increment
+		}
+
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, nil
+}
+
+// decodeCOVRecipient decodes a BACnetRecipientProcess; the caller reads the enclosing tags.
+func decodeCOVRecipient(r *bytes.Reader) (COVRecipient, error) {
+	if err := encoding.ExpectOpeningTag(r, 0); err != nil {
+		return COVRecipient{}, err
+	}
+	recipient, err := decodeRecipient(r)
+	if err != nil {
+		return recipient, err
+	}
+	if err := encoding.ExpectClosingTag(r, 0); err != nil {
+		return recipient, err
+	}
+	recipient.ProcessID, err = encoding.DecodeContextUnsigned(r, 1)
+	return recipient, err
+}
+
+// decodeRecipient decodes a BACnetRecipient, the device or address of a recipient, leaving
+// ProcessID unset.
+func decodeRecipient(r *bytes.Reader) (COVRecipient, error) {
+	var recipient COVRecipient
+	tag, err := encoding.PeekTagHeader(r)
+	if err != nil {
+		return recipient, err
+	}
+	switch {
+	case tag.IsContext(0):
+		objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+		if err != nil {
+			return recipient, err
+		}
+		recipient.Device = &BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	case tag.IsOpening(1):
+		encoding.DecodeTagHeader(r)
+		address, err := decodeValueList(r, 1)
+		if err != nil {
+			return recipient, err
+		}
+		pair, ok := address.([]interface{})
+		if !ok || len(pair) != 2 {
+			return recipient, fmt.Errorf("invalid recipient address %v", address)
+		}
+		network, ok1 := pair[0].(uint32)
+		mac, ok2 := pair[1].([]byte)
+		if !ok1 || !ok2 || network > 0xFFFF {
+			return recipient, fmt.Errorf("invalid recipient address %v", address)
+		}
+		recipient.Network, recipient.MAC = uint16(network), mac
+	default:
+		return recipient, fmt.Errorf("expected recipient device or address, got tag %d", tag.Number)
+	}
+	return recipient, nil
+}
+
+// encodeRecipient writes the device or address of the recipient as a BACnetRecipient.
+func (c COVRecipient) encodeRecipient(buf *bytes.Buffer) {
+	if c.Device != nil {
+		encoding.EncodeContextObjectID(buf, 0, uint32(c.Device.Type), c.Device.Instance)
+		return
+	}
+	encoding.EncodeOpeningTag(buf, 1)
+	encoding.EncodeApplicationUnsigned(buf, uint32(c.Network))
+	encoding.EncodeApplicationOctetString(buf, c.MAC)
+	encoding.EncodeClosingTag(buf, 1)
+}
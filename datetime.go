@@ -0,0 +1,88 @@
+package bacnet
+
+import (
+	"fmt"
+	"time"
+)
+
+// Unspecified marks a date or time field as "any" (a wildcard), as used in schedules and calendars.
+const Unspecified uint8 = 0xFF
+
+// Date is a BACnet Date. Year is the number of years since 1900; any field may be Unspecified.
+// Weekday runs from 1 (Monday) to 7 (Sunday).
+type Date struct {
+	Year    uint8 `json:"year"`
+	Month   uint8 `json:"month"`
+	Day     uint8 `json:"day"`
+	Weekday uint8 `json:"weekday"`
+}
+
+// NewDate returns the date of t.
+func NewDate(t time.Time) Date {
+	weekday := uint8(t.Weekday())
+	if weekday == 0 {
+		weekday = 7 // Sunday
+	}
+	return Date{Year: uint8(t.Year() - 1900), Month: uint8(t.Month()), Day: uint8(t.Day()), Weekday: weekday}
+}
+
+// String returns the date as YYYY-MM-DD, with "*" for unspecified fields.
+func (d Date) String() string {
+	year := "*"
+	if d.Year != Unspecified {
+		year = fmt.Sprintf("%04d", 1900+int(d.Year))
+	}
+	return fmt.Sprintf("%s-%s-%s", year, dateField(d.Month), dateField(d.Day))
+}
+
+// Time is a BACnet Time; any field may be Unspecified.
+type Time struct {
+	Hour       uint8 `json:"hour"`
+	Minute     uint8 `json:"minute"`
+	Second     uint8 `json:"second"`
+	Hundredths uint8 `json:"hundredths"`
+}
+
+// NewTime returns the time of day of t.
+func NewTime(t time.Time) Time {
+	return Time{Hour: uint8(t.Hour()), Minute: uint8(t.Minute()), Second: uint8(t.Second()), Hundredths: uint8(t.Nanosecond() / 10000000)}
+}
+
+// String returns the time as HH:MM:SS.hh, with "*" for unspecified fields.
+func (t Time) String() string {
+	return fmt.Sprintf("%s:%s:%s.%s", dateField(t.Hour), dateField(t.Minute), dateField(t.Second), dateField(t.Hundredths))
+}
+
+// DateTime is a BACnet DateTime, encoded as a Date followed by a Time.
+type DateTime struct {
+	Date Date `json:"date"`
+	Time Time `json:"time"`
+}
+
+// NewDateTime returns the date and time of t.
+func NewDateTime(t time.Time) DateTime {
+	return DateTime{Date: NewDate(t), Time: NewTime(t)}
+}
+
+// String returns the date and time as "YYYY-MM-DD HH:MM:SS.hh".
+func (dt DateTime) String() string {
+	return dt.Date.String() + " " + dt.Time.String()
+}
+
+// In returns dt as a time.Time in loc. It fails if any field is unspecified.
+func (dt DateTime) In(loc *time.Location) (time.Time, error) {
+	d, t := dt.Date, dt.Time
+	for _, field := range []uint8{d.Year, d.Month, d.Day, t.Hour, t.Minute, t.Second, t.Hundredths} {
+		if field == Unspecified {
+			return time.Time{}, fmt.Errorf("date and time %s has unspecified fields", dt)
+		}
+	}
+	return time.Date(1900+int(d.Year), time.Month(d.Month), int(d.Day), int(t.Hour), int(t.Minute), int(t.Second), int(t.Hundredths)*10000000, loc), nil
+}
+
+func dateField(v uint8) string {
+	if v == Unspecified {
+		return "*"
+	}
+	return fmt.Sprintf("%02d", v)
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+
+	"github.com/maxzerker/bacnet/encoding"
 )
 
 func decodeStatusFlags(r *bytes.Reader) (StatusFlags, error) {
-	// Status_Flags is a BIT STRING with 4 bits:
+	// Status_Flags is a BIT STRING with 4 bits, bit 0 being the most significant bit of the octet:
 	// bit 0: In Alarm
 	// bit 1: Fault
 	// bit 2: Overridden
@@ -31,55 +33,47 @@ func decodeStatusFlags(r *bytes.Reader) (StatusFlags, error) {
 	}
 
 	return StatusFlags{
-		InAlarm:      (flagsByte>>3)&1 == 1,
-		Fault:        (flagsByte>>2)&1 == 1,
-		Overridden:   (flagsByte>>1)&1 == 1,
-		OutOfService: (flagsByte>>0)&1 == 1,
+		InAlarm:      flagsByte&0x80 != 0,
+		Fault:        flagsByte&0x40 != 0,
+		Overridden:   flagsByte&0x20 != 0,
+		OutOfService: flagsByte&0x10 != 0,
 	}, nil
 }
 
 func decodeApplicationValue(r *bytes.Reader) (interface{}, error) {
-	tag, err := r.ReadByte()
+	tag, err := encoding.DecodeTagHeader(r)
 	if err != nil {
 		return nil, err
 	}
+	lenVal := tag.Length
 
-	tagNumber := tag >> 4
-	lenVal := uint32(tag & 0x0F)
-
-	if lenVal == 5 {
-		lenByte, err := r.ReadByte()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read extended length: %w", err)
-		}
-		lenVal = uint32(lenByte)
-	}
-
-	// A complete implementation would handle all BACnet application tags and extended lengths > 253
-	switch tagNumber {
-	case 0: // Null
+	switch tag.Number {
+	case encoding.TagNull:
 		return nil, nil
-	case 1: // Boolean
+	case encoding.TagBoolean:
 		return lenVal == 1, nil // len is the value for booleans
-	case 2: // Unsigned Integer
-		buf := make([]byte, lenVal)
-		if _, err := io.ReadFull(r, buf); err != nil {
+	case encoding.TagUnsignedInt:
+		return encoding.DecodeUnsigned(r, lenVal)
+	case encoding.TagSignedInt:
+		return encoding.DecodeSigned(r, lenVal)
+	case encoding.TagReal:
+		var val float32
+		if err := binary.Read(r, binary.BigEndian, &val); err != nil {
 			return nil, err
 		}
-		var val uint32
-		for i := 0; i < int(lenVal); i++ {
-			val = (val << 8) | uint32(buf[i])
-		}
 		return val, nil
-	case 4: // Real
-		var val float32
+	case encoding.TagDouble:
+		var val float64
 		if err := binary.Read(r, binary.BigEndian, &val); err != nil {
 			return nil, err
 		}
 		return val, nil
-	case 7: // CharacterString
-		// First byte is the encoding
-		_, err := r.ReadByte()
+	case encoding.TagCharacterString:
+		if lenVal == 0 {
+			return "", nil
+		}
+		// First byte is the character set
+		charset, err := r.ReadByte()
 		if err != nil {
 			return nil, err
 		}
@@ -87,30 +81,46 @@ func decodeApplicationValue(r *bytes.Reader) (interface{}, error) {
 		if _, err := io.ReadFull(r, buf); err != nil {
 			return nil, err
 		}
-		return string(buf), nil
-	case 8: // BitString (Status_Flags)
-		flags, err := decodeStatusFlags(r)
-		if err != nil {
+		if text, ok := encoding.DecodeText(encoding.CharacterSet(charset), buf); ok {
+			return text, nil
+		}
+		return CharacterString{Charset: encoding.CharacterSet(charset), Data: buf}, nil
+	case encoding.TagBitString:
+		// Bit strings of four bits are taken for Status_Flags, all others decode to []bool.
+		if lenVal == 2 {
+			if unused, err := r.ReadByte(); err == nil {
+				r.UnreadByte()
+				if unused == 4 {
+					return decodeStatusFlags(r)
+				}
+			}
+		}
+		data := make([]byte, lenVal)
+		if _, err := io.ReadFull(r, data); err != nil {
 			return nil, err
 		}
-		return flags, nil
-	case 9: // Enumerated
-		buf := make([]byte, lenVal)
-		if _, err := io.ReadFull(r, buf); err != nil {
+		return decodeBits(data)
+	case encoding.TagEnumerated:
+		return encoding.DecodeUnsigned(r, lenVal)
+	case encoding.TagDate:
+		var buf [4]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
 			return nil, err
 		}
-		var val uint32
-		for i := 0; i < int(lenVal); i++ {
-			val = (val << 8) | uint32(buf[i])
+		return Date{Year: buf[0], Month: buf[1], Day: buf[2], Weekday: buf[3]}, nil
+	case encoding.TagTime:
+		var buf [4]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
 		}
-		return val, nil
-	case 12: // ObjectIdentifier
+		return Time{Hour: buf[0], Minute: buf[1], Second: buf[2], Hundredths: buf[3]}, nil
+	case encoding.TagObjectID:
 		var val uint32
 		if err := binary.Read(r, binary.BigEndian, &val); err != nil {
 			return nil, err
 		}
 		return BACnetObject{Type: ObjectType(val >> 22), Instance: val & 0x3FFFFF}, nil
-	default:
+	default: // Octet String
 		buf := make([]byte, lenVal)
 		if _, err := io.ReadFull(r, buf); err != nil {
 			return nil, err
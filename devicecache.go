@@ -0,0 +1,92 @@
+package bacnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CachedDevice is a device address binding together with the time it was last seen.
+type CachedDevice struct {
+	DeviceInfo
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// DeviceCache remembers the addresses of discovered devices, so they can be contacted without
+// another Who-Is. It is safe for concurrent use.
+type DeviceCache struct {
+	mu      sync.RWMutex
+	devices map[uint32]CachedDevice
+}
+
+// NewDeviceCache returns an empty cache.
+func NewDeviceCache() *DeviceCache {
+	return &DeviceCache{devices: make(map[uint32]CachedDevice)}
+}
+
+// Put records device as seen now.
+func (c *DeviceCache) Put(device DeviceInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.devices[device.DeviceID] = CachedDevice{DeviceInfo: device, LastSeen: time.Now()}
+}
+
+// Get returns the cached address binding of a device.
+func (c *DeviceCache) Get(deviceID uint32) (DeviceInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cached, ok := c.devices[deviceID]
+	return cached.DeviceInfo, ok
+}
+
+// Remove forgets a device.
+func (c *DeviceCache) Remove(deviceID uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.devices, deviceID)
+}
+
+// Devices returns all cached devices ordered by device instance.
+func (c *DeviceCache) Devices() []CachedDevice {
+	c.mu.RLock()
+	devices := make([]CachedDevice, 0, len(c.devices))
+	for _, cached := range c.devices {
+		devices = append(devices, cached)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].DeviceID < devices[j].DeviceID })
+	return devices
+}
+
+// Save writes the cache to w as JSON.
+func (c *DeviceCache) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.Devices())
+}
+
+// Load replaces the contents of the cache with the JSON read from r.
+func (c *DeviceCache) Load(r io.Reader) error {
+	var devices []CachedDevice
+	if err := json.NewDecoder(r).Decode(&devices); err != nil {
+		return fmt.Errorf("failed to decode device cache: %w", err)
+	}
+	c.restore(devices)
+	return nil
+}
+
+// restore replaces the contents of the cache, keeping the recorded LastSeen times.
+func (c *DeviceCache) restore(devices []CachedDevice) {
+	loaded := make(map[uint32]CachedDevice, len(devices))
+	for _, cached := range devices {
+		loaded[cached.DeviceID] = cached
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.devices = loaded
+}
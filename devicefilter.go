@@ -0,0 +1,50 @@
+package bacnet
+
+import (
+	"net/netip"
+	"slices"
+)
+
+// DeviceRange is the device instances Low through High.
+type DeviceRange struct {
+	Low  uint32 `json:"low"`
+	High uint32 `json:"high"`
+}
+
+// DeviceFilter limits the devices discovery reports, e.g. to the devices one tenant of a shared
+// network may see. Each list left empty allows everything; a device must pass all lists that are
+// set.
+type DeviceFilter struct {
+	// Vendors are the vendor identifiers allowed.
+	Vendors []uint32 `json:"vendors,omitempty"`
+	// Ranges are the device instances allowed.
+	Ranges []DeviceRange `json:"ranges,omitempty"`
+	// Networks are the IP networks allowed, matched against the address the I-Am came from.
+	Networks []netip.Prefix `json:"networks,omitempty"`
+}
+
+// Allows reports whether device passes the filter. A nil filter allows every device.
+func (f *DeviceFilter) Allows(device DeviceInfo) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Vendors) > 0 && !slices.Contains(f.Vendors, device.VendorID) {
+		return false
+	}
+	if len(f.Ranges) > 0 && !slices.ContainsFunc(f.Ranges, func(r DeviceRange) bool {
+		return device.DeviceID >= r.Low && device.DeviceID <= r.High
+	}) {
+		return false
+	}
+	if len(f.Networks) > 0 {
+		addr, ok := netip.AddrFromSlice(device.IPAddress)
+		if !ok {
+			return false
+		}
+		addr = addr.Unmap()
+		if !slices.ContainsFunc(f.Networks, func(p netip.Prefix) bool { return p.Contains(addr) }) {
+			return false
+		}
+	}
+	return true
+}
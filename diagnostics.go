@@ -0,0 +1,302 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+)
+
+// NetworkHealthOptions configures a network monitoring run.
+type NetworkHealthOptions struct {
+	// Window is how long traffic is observed. Defaults to 30 seconds.
+	Window time.Duration
+	// BroadcastAddr is where the Who-Is and Who-Is-Router-To-Network probes are sent at the start of
+	// the window. If nil, no probes are sent and only unsolicited traffic is measured.
+	BroadcastAddr *net.UDPAddr
+	// FloodThreshold is the I-Am rate (per second) from a single source above which the source is
+	// reported as flooding. Defaults to 1 per second.
+	FloodThreshold float64
+}
+
+// NetworkHealthReport summarizes the traffic observed during a monitoring window.
+type NetworkHealthReport struct {
+	Start  time.Time     `json:"start"`
+	End    time.Time     `json:"end"`
+	Window time.Duration `json:"window"`
+
+	TotalPackets     int     `json:"totalPackets"`
+	BroadcastPackets int     `json:"broadcastPackets"`
+	BroadcastRate    float64 `json:"broadcastRate"` // Broadcast packets per second
+	IAmCount         int     `json:"iAmCount"`
+	IAmRate          float64 `json:"iAmRate"` // I-Am messages per second
+	MalformedPackets int     `json:"malformedPackets"`
+
+	// Devices lists every device that announced itself, once per (device, address) pair.
+	Devices            []DeviceAnnouncement `json:"devices"`
+	DuplicateDeviceIDs []DuplicateDeviceID  `json:"duplicateDeviceIds,omitempty"`
+	AddressConflicts   []AddressConflict    `json:"addressConflicts,omitempty"`
+	FloodingSources    []FloodingSource     `json:"floodingSources,omitempty"`
+	Routers            []RouterInfo         `json:"routers,omitempty"`
+	// UnreachableNetworks are remote networks that devices were seen on but no router announced.
+	UnreachableNetworks []uint16 `json:"unreachableNetworks,omitempty"`
+}
+
+// Healthy reports whether no problems were found.
+func (r *NetworkHealthReport) Healthy() bool {
+	return len(r.DuplicateDeviceIDs) == 0 && len(r.AddressConflicts) == 0 &&
+		len(r.FloodingSources) == 0 && len(r.UnreachableNetworks) == 0 && r.MalformedPackets == 0
+}
+
+// DeviceAnnouncement is a device seen in an I-Am during monitoring.
+type DeviceAnnouncement struct {
+	DeviceID uint32 `json:"deviceId"`
+	Address  string `json:"address"`
+	Count    int    `json:"count"`
+}
+
+// DuplicateDeviceID is a device instance announced from more than one address.
+type DuplicateDeviceID struct {
+	DeviceID  uint32   `json:"deviceId"`
+	Addresses []string `json:"addresses"`
+}
+
+// AddressConflict is an address that announced more than one device instance.
+type AddressConflict struct {
+	Address   string   `json:"address"`
+	DeviceIDs []uint32 `json:"deviceIds"`
+}
+
+// FloodingSource is an address sending I-Am messages faster than the flood threshold.
+type FloodingSource struct {
+	Address  string  `json:"address"`
+	IAmCount int     `json:"iAmCount"`
+	Rate     float64 `json:"rate"`
+}
+
+// RouterInfo is a router that answered Who-Is-Router-To-Network.
+type RouterInfo struct {
+	Address  string   `json:"address"`
+	Networks []uint16 `json:"networks"`
+}
+
+// MonitorNetwork observes all BACnet traffic reaching the client for the configured window and
+// produces a network health report: broadcast and I-Am rates, duplicate device instances, address
+// conflicts, flooding sources and router reachability.
+//
+// Requests of the client go on during the window, and their traffic is counted as well.
+// Cancelling ctx ends the window early and returns what was seen so far.
+func (c *BACnetClient) MonitorNetwork(ctx context.Context, options NetworkHealthOptions) (*NetworkHealthReport, error) {
+	if options.Window <= 0 {
+		options.Window = 30 * time.Second
+	}
+	if options.FloodThreshold <= 0 {
+		options.FloodThreshold = 1
+	}
+
+	datagrams := make(chan receivedDatagram)
+	ended := make(chan struct{})
+	defer close(ended)
+	remove := c.watchDatagrams(func(data []byte, addr *net.UDPAddr) {
+		select {
+		case datagrams <- receivedDatagram{data: data, addr: addr}:
+		case <-ended:
+		}
+	})
+	defer remove()
+	failed := c.startReceiving()
+
+	if options.BroadcastAddr != nil {
+		if _, err := c.conn.WriteTo(whoIsPacket(), options.BroadcastAddr); err != nil {
+			return nil, fmt.Errorf("failed to send WhoIs packet: %w", err)
+		}
+		if _, err := c.conn.WriteTo(whoIsRouterToNetworkPacket(), options.BroadcastAddr); err != nil {
+			return nil, fmt.Errorf("failed to send Who-Is-Router-To-Network packet: %w", err)
+		}
+	}
+
+	report := &NetworkHealthReport{Start: time.Now()}
+	timer := time.NewTimer(options.Window)
+	defer timer.Stop()
+
+	announcements := make(map[DeviceAnnouncement]int)
+	iAmPerSource := make(map[string]int)
+	routers := make(map[string]map[uint16]bool)
+	remoteNetworks := make(map[uint16]bool)
+
+monitor:
+	for {
+		var datagram receivedDatagram
+		select {
+		case datagram = <-datagrams:
+		case <-timer.C:
+			break monitor // End of window
+		case <-ctx.Done():
+			break monitor
+		case <-failed:
+			return nil, c.receiveErr()
+		}
+		addr := datagram.addr
+		report.TotalPackets++
+
+		frame, err := decodeBVLC(datagram.data)
+		if err != nil {
+			report.MalformedPackets++
+			continue
+		}
+		if frame.function == BVLC_ORIGINAL_BROADCAST_NPDU || frame.function == BVLC_FORWARDED_NPDU {
+			report.BroadcastPackets++
+		}
+		if frame.function != BVLC_ORIGINAL_UNICAST_NPDU && frame.function != BVLC_ORIGINAL_BROADCAST_NPDU && frame.function != BVLC_FORWARDED_NPDU {
+			continue // BBMD management traffic carries no NPDU
+		}
+
+		npdu, payload, err := decodeNPDU(frame.npdu)
+		if err != nil {
+			report.MalformedPackets++
+			continue
+		}
+
+		source := addr.String()
+		if frame.forwardedFrom != nil {
+			source = frame.forwardedFrom.String()
+		}
+		if npdu.hasSource() {
+			remoteNetworks[npdu.snet] = true
+			source = fmt.Sprintf("%d:%x", npdu.snet, npdu.sadr)
+		}
+
+		if npdu.isNetworkMessage() {
+			if npdu.messageType == NETWORK_MESSAGE_I_AM_ROUTER_TO_NETWORK {
+				networks := routers[addr.String()]
+				if networks == nil {
+					networks = make(map[uint16]bool)
+					routers[addr.String()] = networks
+				}
+				for i := 0; i+1 < len(payload); i += 2 {
+					networks[binary.BigEndian.Uint16(payload[i:i+2])] = true
+				}
+			}
+			continue
+		}
+
+		deviceID, ok := iAmDeviceID(payload)
+		if !ok {
+			continue
+		}
+		report.IAmCount++
+		iAmPerSource[source]++
+		announcements[DeviceAnnouncement{DeviceID: deviceID, Address: source}]++
+	}
+
+	report.End = time.Now()
+	report.Window = report.End.Sub(report.Start)
+	seconds := report.Window.Seconds()
+	if seconds > 0 {
+		report.BroadcastRate = float64(report.BroadcastPackets) / seconds
+		report.IAmRate = float64(report.IAmCount) / seconds
+	}
+
+	addressesByDevice := make(map[uint32][]string)
+	devicesByAddress := make(map[string][]uint32)
+	for announcement, count := range announcements {
+		announcement.Count = count
+		report.Devices = append(report.Devices, announcement)
+		addressesByDevice[announcement.DeviceID] = append(addressesByDevice[announcement.DeviceID], announcement.Address)
+		devicesByAddress[announcement.Address] = append(devicesByAddress[announcement.Address], announcement.DeviceID)
+	}
+	sort.Slice(report.Devices, func(i, j int) bool {
+		if report.Devices[i].DeviceID != report.Devices[j].DeviceID {
+			return report.Devices[i].DeviceID < report.Devices[j].DeviceID
+		}
+		return report.Devices[i].Address < report.Devices[j].Address
+	})
+
+	for deviceID, addresses := range addressesByDevice {
+		if len(addresses) > 1 {
+			sort.Strings(addresses)
+			report.DuplicateDeviceIDs = append(report.DuplicateDeviceIDs, DuplicateDeviceID{DeviceID: deviceID, Addresses: addresses})
+		}
+	}
+	sort.Slice(report.DuplicateDeviceIDs, func(i, j int) bool {
+		return report.DuplicateDeviceIDs[i].DeviceID < report.DuplicateDeviceIDs[j].DeviceID
+	})
+
+	for address, deviceIDs := range devicesByAddress {
+		if len(deviceIDs) > 1 {
+			sort.Slice(deviceIDs, func(i, j int) bool { return deviceIDs[i] < deviceIDs[j] })
+			report.AddressConflicts = append(report.AddressConflicts, AddressConflict{Address: address, DeviceIDs: deviceIDs})
+		}
+	}
+	sort.Slice(report.AddressConflicts, func(i, j int) bool {
+		return report.AddressConflicts[i].Address < report.AddressConflicts[j].Address
+	})
+
+	for source, count := range iAmPerSource {
+		if seconds > 0 && float64(count)/seconds > options.FloodThreshold {
+			report.FloodingSources = append(report.FloodingSources, FloodingSource{Address: source, IAmCount: count, Rate: float64(count) / seconds})
+		}
+	}
+	sort.Slice(report.FloodingSources, func(i, j int) bool {
+		return report.FloodingSources[i].Rate > report.FloodingSources[j].Rate
+	})
+
+	reachable := make(map[uint16]bool)
+	for address, networks := range routers {
+		router := RouterInfo{Address: address}
+		for network := range networks {
+			router.Networks = append(router.Networks, network)
+			reachable[network] = true
+		}
+		sort.Slice(router.Networks, func(i, j int) bool { return router.Networks[i] < router.Networks[j] })
+		report.Routers = append(report.Routers, router)
+	}
+	sort.Slice(report.Routers, func(i, j int) bool { return report.Routers[i].Address < report.Routers[j].Address })
+
+	for network := range remoteNetworks {
+		if !reachable[network] {
+			report.UnreachableNetworks = append(report.UnreachableNetworks, network)
+		}
+	}
+	sort.Slice(report.UnreachableNetworks, func(i, j int) bool { return report.UnreachableNetworks[i] < report.UnreachableNetworks[j] })
+
+	return report, nil
+}
+
+// iAmDeviceID extracts the device instance from an I-Am APDU.
+func iAmDeviceID(apdu []byte) (uint32, bool) {
+	if len(apdu) < 7 || apdu[0]&0xF0 != APDU_UNCONFIRMED_REQUEST || apdu[1] != SERVICE_UNCONFIRMED_I_AM {
+		return 0, false
+	}
+	if apdu[2] != 0xC4 { // Application tag 12 (BACnetObjectIdentifier), length 4
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(apdu[3:7]) & 0x3FFFFF, true
+}
+
+// whoIsRouterToNetworkPacket builds a broadcast Who-Is-Router-To-Network network layer message
+// asking for all reachable networks.
+func whoIsRouterToNetworkPacket() []byte {
+	var buffer bytes.Buffer
+
+	// BVLC Header
+	bvlc := BVLCHeader{
+		Type:     BVLC_TYPE_BACNET_IP,
+		Function: BVLC_ORIGINAL_BROADCAST_NPDU,
+		Length:   7, // BVLC(4) + NPDU(2) + Message Type(1)
+	}
+	binary.Write(&buffer, binary.BigEndian, &bvlc)
+
+	// NPDU
+	npdu := NPDU{
+		Version: 1,
+		Control: NPDU_CONTROL_NETWORK_LAYER_MESSAGE,
+	}
+	binary.Write(&buffer, binary.BigEndian, &npdu)
+	buffer.WriteByte(NETWORK_MESSAGE_WHO_IS_ROUTER_TO_NETWORK)
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,342 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// WhoIsOptions controls a Who-Is search made with WhoIsWithOptions.
+type WhoIsOptions struct {
+	// Low and High limit the search to device instances Low through High. Both zero searches all
+	// devices.
+	Low  uint32
+	High uint32
+	// Timeout bounds the search. Zero uses the client timeout.
+	Timeout time.Duration
+	// MaxDevices ends the search as soon as this many devices answered. Zero waits out the timeout.
+	MaxDevices int
+	// DeviceID, if set, limits the search to this device and ends it as soon as the device answered.
+	DeviceID *uint32
+}
+
+// WhoIs broadcasts a Who-Is to all devices and returns the devices that answered within the client
+// timeout.
+func (c *BACnetClient) WhoIs(broadcastAddr *net.UDPAddr, opts ...CallOption) ([]DeviceInfo, error) {
+	return c.WhoIsWithOptions(broadcastAddr, WhoIsOptions{}, opts...)
+}
+
+// WhoIsRange broadcasts a Who-Is limited to the device instances low through high and returns the
+// devices that answered within the client timeout.
+func (c *BACnetClient) WhoIsRange(broadcastAddr *net.UDPAddr, low, high uint32, opts ...CallOption) ([]DeviceInfo, error) {
+	return c.WhoIsWithOptions(broadcastAddr, WhoIsOptions{Low: low, High: high}, opts...)
+}
+
+// WhoIsWithOptions broadcasts a Who-Is and returns the devices that answered, in the order they
+// answered, until the timeout or one of the early-exit conditions of options is reached. Cancelling
+// the context of CallContext ends the search early with the devices that answered so far.
+func (c *BACnetClient) WhoIsWithOptions(broadcastAddr *net.UDPAddr, options WhoIsOptions, opts ...CallOption) ([]DeviceInfo, error) {
+	low, high := options.Low, options.High
+	if options.DeviceID != nil {
+		low, high = *options.DeviceID, *options.DeviceID
+	}
+	packet := whoIsPacket()
+	if low != 0 || high != 0 {
+		packet = whoIsRangePacket(low, high)
+	} else {
+		high = 0x3FFFFF
+	}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = c.options.Timeout
+	}
+	ctx := callContext(opts...)
+
+	// I-Ams are taken from the receive loop from before the Who-Is is sent until the search ends.
+	answers := make(chan DeviceInfo)
+	ended := make(chan struct{})
+	defer close(ended)
+	remove := c.handleDatagrams(func(data []byte, addr *net.UDPAddr) bool {
+		device, err := parseIAm(data, *addr)
+		if err != nil || device.DeviceID < low || device.DeviceID > high {
+			return false
+		}
+		select {
+		case answers <- device:
+		case <-ended:
+		}
+		return true
+	})
+	defer remove()
+	failed := c.startReceiving()
+
+	if _, err := c.conn.WriteTo(packet, broadcastAddr); err != nil {
+		return nil, fmt.Errorf("failed to send WhoIs packet: %w", err)
+	}
+
+	var devices []DeviceInfo
+	seen := make(map[uint32]bool)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		var device DeviceInfo
+		select {
+		case device = <-answers:
+		case <-timer.C:
+			return devices, nil
+		case <-ctx.Done():
+			return devices, nil
+		case <-failed:
+			return nil, c.receiveErr()
+		}
+		if !c.options.DeviceFilter.Allows(device) {
+			c.stats.deviceFiltered()
+			continue
+		}
+		device = c.translateDevice(device)
+		if seen[device.DeviceID] {
+			continue // Answered again, e.g. through a second BBMD
+		}
+		seen[device.DeviceID] = true
+		devices = append(devices, device)
+
+		if options.DeviceID != nil || (options.MaxDevices > 0 && len(devices) >= options.MaxDevices) {
+			return devices, nil
+		}
+	}
+}
+
+// whoIsRangePacket builds a broadcast Who-Is request limited to a range of device instances.
+func whoIsRangePacket(low, high uint32) []byte {
+	var apduBuffer bytes.Buffer
+
+	// APDU (Unconfirmed-Request, Who-Is)
+	apduBuffer.WriteByte(APDU_UNCONFIRMED_REQUEST)
+	apduBuffer.WriteByte(SERVICE_UNCONFIRMED_WHO_IS)
+	encoding.EncodeContextUnsigned(&apduBuffer, 0, low)
+	encoding.EncodeContextUnsigned(&apduBuffer, 1, high)
+
+	var buffer bytes.Buffer
+	// BVLC Header
+	bvlc := BVLCHeader{
+		Type:     BVLC_TYPE_BACNET_IP,
+		Function: BVLC_ORIGINAL_BROADCAST_NPDU,
+		Length:   uint16(4 + 2 + apduBuffer.Len()),
+	}
+	binary.Write(&buffer, binary.BigEndian, &bvlc)
+
+	// NPDU
+	npdu := NPDU{
+		Version: 1,
+		Control: NPDU_CONTROL_NORMAL_MESSAGE,
+	}
+	binary.Write(&buffer, binary.BigEndian, &npdu)
+
+	buffer.Write(apduBuffer.Bytes())
+	return buffer.Bytes()
+}
+
+// DiscoveryOptions configures a DiscoveryRefresher.
+type DiscoveryOptions struct {
+	// BroadcastAddr is where Who-Is requests are sent.
+	BroadcastAddr *net.UDPAddr
+	// MinInterval is how often a device that stopped answering is probed. Defaults to 30 seconds.
+	MinInterval time.Duration
+	// MaxInterval is the longest time between probes of a stable device, and how often a full
+	// Who-Is is broadcast to find new devices. Defaults to 15 minutes.
+	MaxInterval time.Duration
+	// LostAfter is the number of unanswered probes after which a device is reported lost.
+	// Defaults to 3.
+	LostAfter int
+	// OnFound is called when a device is seen for the first time or answers again after being lost.
+	OnFound func(DeviceInfo)
+	// OnLost is called when a device stops answering.
+	OnLost func(DeviceInfo)
+}
+
+// deviceSchedule is the probing state of a single device.
+type deviceSchedule struct {
+	interval  time.Duration
+	nextProbe time.Time
+	misses    int
+	lost      bool
+}
+
+// DiscoveryRefresher keeps a DeviceCache up to date in long-running gateways. Every known device is
+// probed with a Who-Is targeted at its instance; the interval between probes doubles while the
+// device keeps answering, up to MaxInterval, and drops back to MinInterval as soon as it misses one.
+// Devices that have been lost are probed often at first and less frequently the longer they stay away.
+type DiscoveryRefresher struct {
+	client  *BACnetClient
+	cache   *DeviceCache
+	options DiscoveryOptions
+
+	mu        sync.Mutex
+	schedules map[uint32]*deviceSchedule
+}
+
+// NewDiscoveryRefresher returns a refresher that keeps cache up to date through client.
+// Devices already in the cache are probed first.
+func NewDiscoveryRefresher(client *BACnetClient, cache *DeviceCache, options DiscoveryOptions) *DiscoveryRefresher {
+	if options.MinInterval <= 0 {
+		options.MinInterval = 30 * time.Second
+	}
+	if options.MaxInterval <= 0 {
+		options.MaxInterval = 15 * time.Minute
+	}
+	if options.MaxInterval < options.MinInterval {
+		options.MaxInterval = options.MinInterval
+	}
+	if options.LostAfter <= 0 {
+		options.LostAfter = 3
+	}
+
+	r := &DiscoveryRefresher{
+		client:    client,
+		cache:     cache,
+		options:   options,
+		schedules: make(map[uint32]*deviceSchedule),
+	}
+	now := time.Now()
+	for _, cached := range cache.Devices() {
+		r.schedules[cached.DeviceID] = &deviceSchedule{interval: options.MinInterval, nextProbe: now}
+	}
+	return r
+}
+
+// Lost returns the devices that currently do not answer.
+func (r *DiscoveryRefresher) Lost() []DeviceInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lost []DeviceInfo
+	for deviceID, schedule := range r.schedules {
+		if !schedule.lost {
+			continue
+		}
+		if device, ok := r.cache.Get(deviceID); ok {
+			lost = append(lost, device)
+		}
+	}
+	return lost
+}
+
+// Run broadcasts a full Who-Is, then probes devices as they become due until ctx is cancelled.
+func (r *DiscoveryRefresher) Run(ctx context.Context) error {
+	tick := r.options.MinInterval / 4
+	if tick > time.Second {
+		tick = time.Second
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	var nextFullScan time.Time
+	for {
+		now := time.Now()
+		if !now.Before(nextFullScan) {
+			devices, err := r.client.WhoIsRange(r.options.BroadcastAddr, 0, 0x3FFFFF, CallContext(ctx))
+			if err != nil {
+				return err
+			}
+			for _, device := range devices {
+				r.seen(device)
+			}
+			nextFullScan = now.Add(r.options.MaxInterval)
+		}
+
+		for _, deviceID := range r.due(now) {
+			if ctx.Err() != nil {
+				break
+			}
+			devices, err := r.client.WhoIsWithOptions(r.options.BroadcastAddr, WhoIsOptions{DeviceID: &deviceID}, CallContext(ctx))
+			if err != nil {
+				return err
+			}
+			if len(devices) == 0 {
+				r.missed(deviceID)
+			}
+			for _, device := range devices {
+				r.seen(device)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// due returns the devices whose next probe is at or before now.
+func (r *DiscoveryRefresher) due(now time.Time) []uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []uint32
+	for deviceID, schedule := range r.schedules {
+		if !schedule.nextProbe.After(now) {
+			due = append(due, deviceID)
+		}
+	}
+	return due
+}
+
+// seen records an I-Am from device.
+func (r *DiscoveryRefresher) seen(device DeviceInfo) {
+	r.cache.Put(device)
+
+	r.mu.Lock()
+	schedule, known := r.schedules[device.DeviceID]
+	found := !known || schedule.lost
+	if !known {
+		schedule = &deviceSchedule{interval: r.options.MinInterval}
+		r.schedules[device.DeviceID] = schedule
+	} else if schedule.lost {
+		schedule.interval = r.options.MinInterval
+	} else {
+		schedule.interval = min(schedule.interval*2, r.options.MaxInterval)
+	}
+	schedule.misses = 0
+	schedule.lost = false
+	schedule.nextProbe = time.Now().Add(schedule.interval)
+	r.mu.Unlock()
+
+	if found && r.options.OnFound != nil {
+		r.options.OnFound(device)
+	}
+}
+
+// missed records an unanswered probe of a device.
+func (r *DiscoveryRefresher) missed(deviceID uint32) {
+	r.mu.Lock()
+	schedule, ok := r.schedules[deviceID]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	schedule.misses++
+	lostNow := !schedule.lost && schedule.misses >= r.options.LostAfter
+	if schedule.misses >= r.options.LostAfter {
+		// Back off the longer the device stays away.
+		schedule.lost = true
+		schedule.interval = min(schedule.interval*2, r.options.MaxInterval)
+	} else {
+		schedule.interval = r.options.MinInterval
+	}
+	schedule.nextProbe = time.Now().Add(schedule.interval)
+	r.mu.Unlock()
+
+	if lostNow && r.options.OnLost != nil {
+		if device, ok := r.cache.Get(deviceID); ok {
+			r.options.OnLost(device)
+		}
+	}
+}
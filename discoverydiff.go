@@ -0,0 +1,67 @@
+package bacnet
+
+import (
+	"bytes"
+	"sort"
+)
+
+// DeviceChange is a device whose address binding changed between two discovery passes, e.g.
+// after a controller got a new IP address.
+type DeviceChange struct {
+	Previous DeviceInfo `json:"previous"`
+	Current  DeviceInfo `json:"current"`
+}
+
+// DiscoveryDiff is the difference between two discovery passes. Every list is ordered by device
+// instance.
+type DiscoveryDiff struct {
+	Added   []DeviceInfo   `json:"added,omitempty"`
+	Removed []DeviceInfo   `json:"removed,omitempty"`
+	Changed []DeviceChange `json:"changed,omitempty"`
+}
+
+// Empty reports whether both passes found the same devices at the same addresses.
+func (d DiscoveryDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffDiscovery compares the devices found by two discovery passes, e.g. two calls of WhoIsRange,
+// matching them by device instance. A device is changed if its address, port, MAC address, maximum
+// APDU or vendor differ. If a pass lists a device twice, the last entry counts.
+func DiffDiscovery(previous, current []DeviceInfo) DiscoveryDiff {
+	before := make(map[uint32]DeviceInfo, len(previous))
+	for _, device := range previous {
+		before[device.DeviceID] = device
+	}
+	after := make(map[uint32]DeviceInfo, len(current))
+	for _, device := range current {
+		after[device.DeviceID] = device
+	}
+
+	var diff DiscoveryDiff
+	for deviceID, device := range after {
+		old, ok := before[deviceID]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, device)
+		case !sameBinding(old, device):
+			diff.Changed = append(diff.Changed, DeviceChange{Previous: old, Current: device})
+		}
+	}
+	for deviceID, device := range before {
+		if _, ok := after[deviceID]; !ok {
+			diff.Removed = append(diff.Removed, device)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].DeviceID < diff.Added[j].DeviceID })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].DeviceID < diff.Removed[j].DeviceID })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Current.DeviceID < diff.Changed[j].Current.DeviceID })
+	return diff
+}
+
+// sameBinding reports whether two entries of a device describe the same address binding.
+func sameBinding(a, b DeviceInfo) bool {
+	return a.IPAddress.Equal(b.IPAddress) && a.Port == b.Port && bytes.Equal(a.MacAddress, b.MacAddress) &&
+		a.MaxAPDU == b.MaxAPDU && a.VendorID == b.VendorID
+}
@@ -0,0 +1,304 @@
+package bacnet
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// covRoute identifies the notifications of one subscription about one object. The initiating device
+// is part of it since subscribers commonly use the same process identifier with every device.
+type covRoute struct {
+	device    uint32
+	processID uint32
+	object    BACnetObject
+}
+
+// covSubscriber is where the dispatcher hands the notifications of a subscription.
+type covSubscriber struct {
+	inbox chan COVNotification
+	// done is closed when the subscription no longer takes notifications.
+	done chan struct{}
+	// failed receives the error that ended the receive loop.
+	failed chan error
+}
+
+func newCOVSubscriber() *covSubscriber {
+	return &covSubscriber{
+		inbox:  make(chan COVNotification, covInboxSize),
+		done:   make(chan struct{}),
+		failed: make(chan error, 1),
+	}
+}
+
+// drain discards the notifications waiting in the inbox.
+func (s *covSubscriber) drain() {
+	for {
+		select {
+		case <-s.inbox:
+		default:
+			return
+		}
+	}
+}
+
+// notificationDispatcher hands the notifications the receive loop reads to the COV subscriptions
+// and event listeners they belong to, so any number of them can run over one socket. Notifications
+// wait in a queue of notificationQueueSize for the dispatcher, so that consumers that do not keep up
+// hold up other notifications but never responses; once the queue is full, notifications are
+// dropped and confirmed ones stay unanswered, for the device to send again.
+type notificationDispatcher struct {
+	mu          sync.Mutex
+	subscribers map[covRoute]*covSubscriber
+	listeners   map[*EventListener]bool
+	queue       chan receivedDatagram
+	// start starts dispatchNotifications with the first receive loop.
+	start sync.Once
+}
+
+// notificationQueueSize is the number of notifications received that wait for the dispatcher.
+const notificationQueueSize = 64
+
+func newNotificationDispatcher() *notificationDispatcher {
+	return &notificationDispatcher{
+		subscribers: make(map[covRoute]*covSubscriber),
+		listeners:   make(map[*EventListener]bool),
+		queue:       make(chan receivedDatagram, notificationQueueSize),
+	}
+}
+
+// registerCOV routes the notifications of routes to sub. It fails if another subscription holds
+// one of the routes.
+func (c *BACnetClient) registerCOV(routes []covRoute, sub *covSubscriber) error {
+	d := c.notifications
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, route := range routes {
+		if _, ok := d.subscribers[route]; ok {
+			return fmt.Errorf("%s of device %d is already subscribed with process identifier %d", route.object, route.device, route.processID)
+		}
+	}
+	for _, route := range routes {
+		d.subscribers[route] = sub
+	}
+	c.startReceiving()
+	return nil
+}
+
+// unregisterCOV removes routes.
+func (c *BACnetClient) unregisterCOV(routes []covRoute) {
+	d := c.notifications
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, route := range routes {
+		delete(d.subscribers, route)
+	}
+}
+
+// registerEvents hands the event notifications received to l.
+func (c *BACnetClient) registerEvents(l *EventListener) {
+	d := c.notifications
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listeners[l] = true
+	c.startReceiving()
+}
+
+// unregisterEvents removes l.
+func (c *BACnetClient) unregisterEvents(l *EventListener) {
+	d := c.notifications
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.listeners, l)
+}
+
+// queueNotification queues a notification datagram for dispatchNotifications, or drops it if the
+// queue is full.
+func (c *BACnetClient) queueNotification(data []byte, addr *net.UDPAddr) {
+	select {
+	case c.notifications.queue <- receivedDatagram{data: append([]byte(nil), data...), addr: addr}:
+	default:
+		c.stats.notificationDropped()
+	}
+}
+
+// dispatchNotifications dispatches the queued notifications until the client is closed.
+func (c *BACnetClient) dispatchNotifications() {
+	for {
+		select {
+		case n := <-c.notifications.queue:
+			if !c.dispatchNotification(n.data, n.addr) {
+				c.stats.dropped()
+			}
+		case <-c.closed.Done():
+			return
+		}
+	}
+}
+
+// isNotificationPDU reports whether apdu is a COV or event notification.
+func isNotificationPDU(apdu []byte) bool {
+	switch {
+	case apdu[0]&0xF0 == APDU_UNCONFIRMED_REQUEST:
+		switch apdu[1] {
+		case SERVICE_UNCONFIRMED_COV_NOTIFICATION, SERVICE_UNCONFIRMED_COV_NOTIFICATION_MULTIPLE, SERVICE_UNCONFIRMED_EVENT_NOTIFICATION:
+			return true
+		}
+	case apdu[0]&0xF0 == APDU_CONFIRMED_REQUEST && len(apdu) >= 4:
+		switch apdu[3] {
+		case SERVICE_CONFIRMED_COV_NOTIFICATION, SERVICE_CONFIRMED_COV_NOTIFICATION_MULTIPLE, SERVICE_CONFIRMED_EVENT_NOTIFICATION:
+			return true
+		}
+	}
+	return false
+}
+
+// failNotifications ends every subscription and listener after the connection failed.
+func (c *BACnetClient) failNotifications(err error) {
+	d := c.notifications
+	d.mu.Lock()
+	subscribers := make(map[*covSubscriber]bool, len(d.subscribers))
+	for _, sub := range d.subscribers {
+		subscribers[sub] = true
+	}
+	listeners := make([]*EventListener, 0, len(d.listeners))
+	for l := range d.listeners {
+		listeners = append(listeners, l)
+	}
+	d.mu.Unlock()
+	for sub := range subscribers {
+		select {
+		case sub.failed <- err:
+		default:
+		}
+	}
+	for _, l := range listeners {
+		select {
+		case l.failed <- err:
+		default:
+		}
+	}
+}
+
+// dispatchNotification hands the COV or event notifications of a datagram to the subscriptions or
+// listeners they belong to, waiting for those whose inbox is full, and acknowledges confirmed
+// notifications. It reports whether the datagram was a notification somebody takes.
+func (c *BACnetClient) dispatchNotification(data []byte, addr *net.UDPAddr) bool {
+	apdu, err := apduFromPacket(data)
+	if err != nil || len(apdu) < 2 {
+		return false
+	}
+	var service byte
+	switch {
+	case apdu[0]&0xF0 == APDU_UNCONFIRMED_REQUEST:
+		service = apdu[1]
+	case apdu[0]&0xF0 == APDU_CONFIRMED_REQUEST && len(apdu) >= 4:
+		service = apdu[3]
+	}
+	confirmed := apdu[0]&0xF0 == APDU_CONFIRMED_REQUEST
+
+	var notifications []COVNotification
+	switch {
+	case !confirmed && service == SERVICE_UNCONFIRMED_COV_NOTIFICATION,
+		confirmed && service == SERVICE_CONFIRMED_COV_NOTIFICATION:
+		var notification COVNotification
+		notification, err = parseCOVNotification(data)
+		notifications = []COVNotification{notification}
+	case !confirmed && service == SERVICE_UNCONFIRMED_COV_NOTIFICATION_MULTIPLE,
+		confirmed && service == SERVICE_CONFIRMED_COV_NOTIFICATION_MULTIPLE:
+		notifications, err = parseCOVNotificationMultiple(data)
+	case !confirmed && service == SERVICE_UNCONFIRMED_EVENT_NOTIFICATION,
+		confirmed && service == SERVICE_CONFIRMED_EVENT_NOTIFICATION:
+		return c.dispatchEvent(data, addr)
+	default:
+		return false
+	}
+	c.acknowledgeNotification(data, addr, err)
+	if err != nil {
+		c.stats.dropped()
+		c.logf("bacnet: discarded COV notification from %s: %v", addr, err)
+		return true
+	}
+
+	for _, notification := range notifications {
+		route := covRoute{
+			device:    notification.InitiatingDeviceIdentifier.Instance,
+			processID: notification.SubscriberProcessIdentifier,
+			object:    notification.MonitoredObjectIdentifier,
+		}
+		c.notifications.mu.Lock()
+		sub := c.notifications.subscribers[route]
+		c.notifications.mu.Unlock()
+		if sub == nil {
+			c.stats.dropped() // Not subscribed (any more)
+			continue
+		}
+		notification.PointName = c.pointName(PointRef{DeviceID: route.device, Object: route.object})
+		select {
+		case sub.inbox <- notification:
+		case <-sub.done:
+		}
+	}
+	return true
+}
+
+// dispatchEvent hands an event notification to every listener accepting it, like
+// dispatchNotification.
+func (c *BACnetClient) dispatchEvent(data []byte, addr *net.UDPAddr) bool {
+	c.notifications.mu.Lock()
+	listeners := make([]*EventListener, 0, len(c.notifications.listeners))
+	for l := range c.notifications.listeners {
+		listeners = append(listeners, l)
+	}
+	c.notifications.mu.Unlock()
+	if len(listeners) == 0 {
+		return false // Nobody listens; confirmed notifications stay unanswered
+	}
+
+	notification, err := parseEventNotification(data)
+	c.acknowledgeNotification(data, addr, err)
+	if err != nil {
+		c.stats.dropped()
+		c.logf("bacnet: discarded event notification from %s: %v", addr, err)
+		return true
+	}
+	for _, l := range listeners {
+		if !l.accepts(notification) {
+			continue
+		}
+		select {
+		case l.inbox <- notification:
+		case <-l.done:
+		}
+	}
+	return true
+}
+
+// acknowledgeNotification answers a confirmed COV or event notification with a Simple-ACK, or
+// with a Reject if it could not be decoded, so the device does not resend it. Other datagrams are
+// left alone.
+func (c *BACnetClient) acknowledgeNotification(data []byte, addr *net.UDPAddr, parseErr error) {
+	frame, err := decodeBVLC(data)
+	if err != nil {
+		return
+	}
+	header, apdu, err := decodeNPDU(frame.npdu)
+	if err != nil || header.isNetworkMessage() || len(apdu) < 4 || apdu[0]&0xF0 != APDU_CONFIRMED_REQUEST {
+		return
+	}
+	service := apdu[3]
+	switch service {
+	case SERVICE_CONFIRMED_COV_NOTIFICATION, SERVICE_CONFIRMED_COV_NOTIFICATION_MULTIPLE, SERVICE_CONFIRMED_EVENT_NOTIFICATION:
+	default:
+		return
+	}
+
+	reply := []byte{APDU_SIMPLE_ACK, apdu[2], service}
+	switch {
+	case apdu[0]&0x08 != 0:
+		reply = []byte{APDU_ABORT | 0x01, apdu[2], byte(AbortReasonSegmentationNotSupported)} // Sent by server
+	case parseErr != nil:
+		reply = []byte{APDU_REJECT, apdu[2], 0}
+	}
+	c.conn.WriteTo(serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, header, reply), addr)
+}
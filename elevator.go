@@ -0,0 +1,291 @@
+package bacnet
+
+// Enumerations used by the Lift, Escalator and Elevator Group objects (BACnet revision 18).
+
+// LiftCarDirection is the value of Car_Assigned_Direction and Car_Moving_Direction.
+type LiftCarDirection uint32
+
+const (
+	LiftCarDirectionUnknown   LiftCarDirection = 0
+	LiftCarDirectionNone      LiftCarDirection = 1
+	LiftCarDirectionStopped   LiftCarDirection = 2
+	LiftCarDirectionUp        LiftCarDirection = 3
+	LiftCarDirectionDown      LiftCarDirection = 4
+	LiftCarDirectionUpAndDown LiftCarDirection = 5
+)
+
+var liftCarDirectionNames = map[LiftCarDirection]string{
+	LiftCarDirectionUnknown:   "unknown",
+	LiftCarDirectionNone:      "none",
+	LiftCarDirectionStopped:   "stopped",
+	LiftCarDirectionUp:        "up",
+	LiftCarDirectionDown:      "down",
+	LiftCarDirectionUpAndDown: "up-and-down",
+}
+
+func (d LiftCarDirection) String() string { return enumName(liftCarDirectionNames, d) }
+
+// LiftCarDoorCommand is an element of Car_Door_Command.
+type LiftCarDoorCommand uint32
+
+const (
+	LiftCarDoorCommandNone  LiftCarDoorCommand = 0
+	LiftCarDoorCommandOpen  LiftCarDoorCommand = 1
+	LiftCarDoorCommandClose LiftCarDoorCommand = 2
+)
+
+var liftCarDoorCommandNames = map[LiftCarDoorCommand]string{
+	LiftCarDoorCommandNone:  "none",
+	LiftCarDoorCommandOpen:  "open",
+	LiftCarDoorCommandClose: "close",
+}
+
+func (c LiftCarDoorCommand) String() string { return enumName(liftCarDoorCommandNames, c) }
+
+// DoorStatus is an element of Car_Door_Status and Landing_Door_Status.
+type DoorStatus uint32
+
+const (
+	DoorStatusClosed        DoorStatus = 0
+	DoorStatusOpened        DoorStatus = 1
+	DoorStatusUnknown       DoorStatus = 2
+	DoorStatusDoorFault     DoorStatus = 3
+	DoorStatusUnused        DoorStatus = 4
+	DoorStatusNone          DoorStatus = 5
+	DoorStatusClosing       DoorStatus = 6
+	DoorStatusOpening       DoorStatus = 7
+	DoorStatusSafetyLocked  DoorStatus = 8
+	DoorStatusLimitedOpened DoorStatus = 9
+)
+
+var doorStatusNames = map[DoorStatus]string{
+	DoorStatusClosed:        "closed",
+	DoorStatusOpened:        "opened",
+	DoorStatusUnknown:       "unknown",
+	DoorStatusDoorFault:     "door-fault",
+	DoorStatusUnused:        "unused",
+	DoorStatusNone:          "none",
+	DoorStatusClosing:       "closing",
+	DoorStatusOpening:       "opening",
+	DoorStatusSafetyLocked:  "safety-locked",
+	DoorStatusLimitedOpened: "limited-opened",
+}
+
+func (s DoorStatus) String() string { return enumName(doorStatusNames, s) }
+
+// LiftCarDriveStatus is the value of Car_Drive_Status.
+type LiftCarDriveStatus uint32
+
+const (
+	LiftCarDriveStatusUnknown         LiftCarDriveStatus = 0
+	LiftCarDriveStatusStationary      LiftCarDriveStatus = 1
+	LiftCarDriveStatusBraking         LiftCarDriveStatus = 2
+	LiftCarDriveStatusAccelerate      LiftCarDriveStatus = 3
+	LiftCarDriveStatusDecelerate      LiftCarDriveStatus = 4
+	LiftCarDriveStatusRatedSpeed      LiftCarDriveStatus = 5
+	LiftCarDriveStatusSingleFloorJump LiftCarDriveStatus = 6
+	LiftCarDriveStatusTwoFloorJump    LiftCarDriveStatus = 7
+	LiftCarDriveStatusThreeFloorJump  LiftCarDriveStatus = 8
+	LiftCarDriveStatusMultiFloorJump  LiftCarDriveStatus = 9
+)
+
+var liftCarDriveStatusNames = map[LiftCarDriveStatus]string{
+	LiftCarDriveStatusUnknown:         "unknown",
+	LiftCarDriveStatusStationary:      "stationary",
+	LiftCarDriveStatusBraking:         "braking",
+	LiftCarDriveStatusAccelerate:      "accelerate",
+	LiftCarDriveStatusDecelerate:      "decelerate",
+	LiftCarDriveStatusRatedSpeed:      "rated-speed",
+	LiftCarDriveStatusSingleFloorJump: "single-floor-jump",
+	LiftCarDriveStatusTwoFloorJump:    "two-floor-jump",
+	LiftCarDriveStatusThreeFloorJump:  "three-floor-jump",
+	LiftCarDriveStatusMultiFloorJump:  "multi-floor-jump",
+}
+
+func (s LiftCarDriveStatus) String() string { return enumName(liftCarDriveStatusNames, s) }
+
+// LiftCarMode is the value of Car_Mode.
+type LiftCarMode uint32
+
+const (
+	LiftCarModeUnknown             LiftCarMode = 0
+	LiftCarModeNormal              LiftCarMode = 1
+	LiftCarModeVIP                 LiftCarMode = 2
+	LiftCarModeHoming              LiftCarMode = 3
+	LiftCarModeParking             LiftCarMode = 4
+	LiftCarModeAttendantControl    LiftCarMode = 5
+	LiftCarModeFirefighterControl  LiftCarMode = 6
+	LiftCarModeEmergencyPower      LiftCarMode = 7
+	LiftCarModeInspection          LiftCarMode = 8
+	LiftCarModeCabinetRecall       LiftCarMode = 9
+	LiftCarModeEarthquakeOperation LiftCarMode = 10
+	LiftCarModeFireOperation       LiftCarMode = 11
+	LiftCarModeOutOfService        LiftCarMode = 12
+	LiftCarModeOccupantEvacuation  LiftCarMode = 13
+)
+
+var liftCarModeNames = map[LiftCarMode]string{
+	LiftCarModeUnknown:             "unknown",
+	LiftCarModeNormal:              "normal",
+	LiftCarModeVIP:                 "vip",
+	LiftCarModeHoming:              "homing",
+	LiftCarModeParking:             "parking",
+	LiftCarModeAttendantControl:    "attendant-control",
+	LiftCarModeFirefighterControl:  "firefighter-control",
+	LiftCarModeEmergencyPower:      "emergency-power",
+	LiftCarModeInspection:          "inspection",
+	LiftCarModeCabinetRecall:       "cabinet-recall",
+	LiftCarModeEarthquakeOperation: "earthquake-operation",
+	LiftCarModeFireOperation:       "fire-operation",
+	LiftCarModeOutOfService:        "out-of-service",
+	LiftCarModeOccupantEvacuation:  "occupant-evacuation",
+}
+
+func (m LiftCarMode) String() string { return enumName(liftCarModeNames, m) }
+
+// LiftGroupMode is the value of Group_Mode of an Elevator Group.
+type LiftGroupMode uint32
+
+const (
+	LiftGroupModeUnknown        LiftGroupMode = 0
+	LiftGroupModeNormal         LiftGroupMode = 1
+	LiftGroupModeDownPeak       LiftGroupMode = 2
+	LiftGroupModeTwoWay         LiftGroupMode = 3
+	LiftGroupModeFourWay        LiftGroupMode = 4
+	LiftGroupModeEmergencyPower LiftGroupMode = 5
+	LiftGroupModeUpPeak         LiftGroupMode = 6
+)
+
+var liftGroupModeNames = map[LiftGroupMode]string{
+	LiftGroupModeUnknown:        "unknown",
+	LiftGroupModeNormal:         "normal",
+	LiftGroupModeDownPeak:       "down-peak",
+	LiftGroupModeTwoWay:         "two-way",
+	LiftGroupModeFourWay:        "four-way",
+	LiftGroupModeEmergencyPower: "emergency-power",
+	LiftGroupModeUpPeak:         "up-peak",
+}
+
+func (m LiftGroupMode) String() string { return enumName(liftGroupModeNames, m) }
+
+// EscalatorMode is the value of Escalator_Mode.
+type EscalatorMode uint32
+
+const (
+	EscalatorModeUnknown      EscalatorMode = 0
+	EscalatorModeStop         EscalatorMode = 1
+	EscalatorModeUp           EscalatorMode = 2
+	EscalatorModeDown         EscalatorMode = 3
+	EscalatorModeInspection   EscalatorMode = 4
+	EscalatorModeOutOfService EscalatorMode = 5
+)
+
+var escalatorModeNames = map[EscalatorMode]string{
+	EscalatorModeUnknown:      "unknown",
+	EscalatorModeStop:         "stop",
+	EscalatorModeUp:           "up",
+	EscalatorModeDown:         "down",
+	EscalatorModeInspection:   "inspection",
+	EscalatorModeOutOfService: "out-of-service",
+}
+
+func (m EscalatorMode) String() string { return enumName(escalatorModeNames, m) }
+
+// EscalatorOperationDirection is the value of Operation_Direction of an Escalator.
+type EscalatorOperationDirection uint32
+
+const (
+	EscalatorOperationDirectionUnknown          EscalatorOperationDirection = 0
+	EscalatorOperationDirectionStopped          EscalatorOperationDirection = 1
+	EscalatorOperationDirectionUpRatedSpeed     EscalatorOperationDirection = 2
+	EscalatorOperationDirectionUpReducedSpeed   EscalatorOperationDirection = 3
+	EscalatorOperationDirectionDownRatedSpeed   EscalatorOperationDirection = 4
+	EscalatorOperationDirectionDownReducedSpeed EscalatorOperationDirection = 5
+)
+
+var escalatorOperationDirectionNames = map[EscalatorOperationDirection]string{
+	EscalatorOperationDirectionUnknown:          "unknown",
+	EscalatorOperationDirectionStopped:          "stopped",
+	EscalatorOperationDirectionUpRatedSpeed:     "up-rated-speed",
+	EscalatorOperationDirectionUpReducedSpeed:   "up-reduced-speed",
+	EscalatorOperationDirectionDownRatedSpeed:   "down-rated-speed",
+	EscalatorOperationDirectionDownReducedSpeed: "down-reduced-speed",
+}
+
+func (d EscalatorOperationDirection) String() string {
+	return enumName(escalatorOperationDirectionNames, d)
+}
+
+// LiftFault is an element of Fault_Signals of a Lift.
+type LiftFault uint32
+
+const (
+	LiftFaultControllerFault              LiftFault = 0
+	LiftFaultDriveAndMotorFault           LiftFault = 1
+	LiftFaultGovernorAndSafetyGearFault   LiftFault = 2
+	LiftFaultLiftShaftDeviceFault         LiftFault = 3
+	LiftFaultPowerSupplyFault             LiftFault = 4
+	LiftFaultSafetyInterlockFault         LiftFault = 5
+	LiftFaultDoorClosingFault             LiftFault = 6
+	LiftFaultDoorOpeningFault             LiftFault = 7
+	LiftFaultCarStoppedOutsideLandingZone LiftFault = 8
+	LiftFaultCallButtonStuck              LiftFault = 9
+	LiftFaultStartFailure                 LiftFault = 10
+	LiftFaultControllerSupplyFault        LiftFault = 11
+	LiftFaultSelfTestFailure              LiftFault = 12
+	LiftFaultRuntimeLimitExceeded         LiftFault = 13
+	LiftFaultPositionLost                 LiftFault = 14
+	LiftFaultDriveTemperatureExceeded     LiftFault = 15
+	LiftFaultLoadMeasurementFault         LiftFault = 16
+)
+
+var liftFaultNames = map[LiftFault]string{
+	LiftFaultControllerFault:              "controller-fault",
+	LiftFaultDriveAndMotorFault:           "drive-and-motor-fault",
+	LiftFaultGovernorAndSafetyGearFault:   "governor-and-safety-gear-fault",
+	LiftFaultLiftShaftDeviceFault:         "lift-shaft-device-fault",
+	LiftFaultPowerSupplyFault:             "power-supply-fault",
+	LiftFaultSafetyInterlockFault:         "safety-interlock-fault",
+	LiftFaultDoorClosingFault:             "door-closing-fault",
+	LiftFaultDoorOpeningFault:             "door-opening-fault",
+	LiftFaultCarStoppedOutsideLandingZone: "car-stopped-outside-landing-zone",
+	LiftFaultCallButtonStuck:              "call-button-stuck",
+	LiftFaultStartFailure:                 "start-failure",
+	LiftFaultControllerSupplyFault:        "controller-supply-fault",
+	LiftFaultSelfTestFailure:              "self-test-failure",
+	LiftFaultRuntimeLimitExceeded:         "runtime-limit-exceeded",
+	LiftFaultPositionLost:                 "position-lost",
+	LiftFaultDriveTemperatureExceeded:     "drive-temperature-exceeded",
+	LiftFaultLoadMeasurementFault:         "load-measurement-fault",
+}
+
+func (f LiftFault) String() string { return enumName(liftFaultNames, f) }
+
+// EscalatorFault is an element of Fault_Signals of an Escalator.
+type EscalatorFault uint32
+
+const (
+	EscalatorFaultControllerFault          EscalatorFault = 0
+	EscalatorFaultDriveAndMotorFault       EscalatorFault = 1
+	EscalatorFaultMechanicalComponentFault EscalatorFault = 2
+	EscalatorFaultOverspeedFault           EscalatorFault = 3
+	EscalatorFaultPowerSupplyFault         EscalatorFault = 4
+	EscalatorFaultSafetyDeviceFault        EscalatorFault = 5
+	EscalatorFaultControllerSupplyFault    EscalatorFault = 6
+	EscalatorFaultDriveTemperatureExceeded EscalatorFault = 7
+	EscalatorFaultCombPlateFault           EscalatorFault = 8
+)
+
+var escalatorFaultNames = map[EscalatorFault]string{
+	EscalatorFaultControllerFault:          "controller-fault",
+	EscalatorFaultDriveAndMotorFault:       "drive-and-motor-fault",
+	EscalatorFaultMechanicalComponentFault: "mechanical-component-fault",
+	EscalatorFaultOverspeedFault:           "overspeed-fault",
+	EscalatorFaultPowerSupplyFault:         "power-supply-fault",
+	EscalatorFaultSafetyDeviceFault:        "safety-device-fault",
+	EscalatorFaultControllerSupplyFault:    "controller-supply-fault",
+	EscalatorFaultDriveTemperatureExceeded: "drive-temperature-exceeded",
+	EscalatorFaultCombPlateFault:           "comb-plate-fault",
+}
+
+func (f EscalatorFault) String() string { return enumName(escalatorFaultNames, f) }
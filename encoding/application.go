@@ -0,0 +1,105 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// EncodeApplicationNull writes an application-tagged Null.
+func EncodeApplicationNull(buf *bytes.Buffer) {
+	EncodeTag(buf, TagNull, false, 0)
+}
+
+// EncodeApplicationBoolean writes an application-tagged Boolean. The value is carried in the
+// length field of the tag, there is no content.
+func EncodeApplicationBoolean(buf *bytes.Buffer, value bool) {
+	if value {
+		EncodeTag(buf, TagBoolean, false, 1)
+	} else {
+		EncodeTag(buf, TagBoolean, false, 0)
+	}
+}
+
+// EncodeApplicationUnsigned writes an application-tagged Unsigned Integer.
+func EncodeApplicationUnsigned(buf *bytes.Buffer, value uint32) {
+	length := unsignedLength(value)
+	EncodeTag(buf, TagUnsignedInt, false, length)
+	writeUnsigned(buf, value, length)
+}
+
+// EncodeApplicationSigned writes an application-tagged Signed Integer.
+func EncodeApplicationSigned(buf *bytes.Buffer, value int32) {
+	length := signedLength(value)
+	EncodeTag(buf, TagSignedInt, false, length)
+	writeUnsigned(buf, uint32(value), length)
+}
+
+// EncodeApplicationReal writes an application-tagged single precision float.
+func EncodeApplicationReal(buf *bytes.Buffer, value float32) {
+	EncodeTag(buf, TagReal, false, 4)
+	binary.Write(buf, binary.BigEndian, math.Float32bits(value))
+}
+
+// EncodeApplicationDouble writes an application-tagged double precision float.
+func EncodeApplicationDouble(buf *bytes.Buffer, value float64) {
+	EncodeTag(buf, TagDouble, false, 8)
+	binary.Write(buf, binary.BigEndian, math.Float64bits(value))
+}
+
+// EncodeApplicationOctetString writes an application-tagged Octet String.
+func EncodeApplicationOctetString(buf *bytes.Buffer, value []byte) {
+	EncodeTag(buf, TagOctetString, false, uint32(len(value)))
+	buf.Write(value)
+}
+
+// EncodeApplicationCharacterString writes an application-tagged UTF-8 Character String.
+func EncodeApplicationCharacterString(buf *bytes.Buffer, value string) {
+	EncodeTag(buf, TagCharacterString, false, uint32(len(value)+1))
+	buf.WriteByte(0) // ANSI X3.4 / UTF-8
+	buf.WriteString(value)
+}
+
+// EncodeApplicationBitString writes an application-tagged Bit String. bits[0] is the first
+// (most significant) bit of the first octet.
+func EncodeApplicationBitString(buf *bytes.Buffer, bits []bool) {
+	octets := (len(bits) + 7) / 8
+	unused := octets*8 - len(bits)
+	EncodeTag(buf, TagBitString, false, uint32(octets+1))
+	buf.WriteByte(byte(unused))
+	for i := 0; i < octets; i++ {
+		var b byte
+		for j := 0; j < 8 && i*8+j < len(bits); j++ {
+			if bits[i*8+j] {
+				b |= 0x80 >> j
+			}
+		}
+		buf.WriteByte(b)
+	}
+}
+
+// EncodeApplicationEnumerated writes an application-tagged Enumerated value.
+func EncodeApplicationEnumerated(buf *bytes.Buffer, value uint32) {
+	length := unsignedLength(value)
+	EncodeTag(buf, TagEnumerated, false, length)
+	writeUnsigned(buf, value, length)
+}
+
+// EncodeApplicationDate writes an application-tagged Date. Use 0xFF for unspecified fields.
+// year is the number of years since 1900.
+func EncodeApplicationDate(buf *bytes.Buffer, year, month, day, weekday uint8) {
+	EncodeTag(buf, TagDate, false, 4)
+	buf.Write([]byte{year, month, day, weekday})
+}
+
+// EncodeApplicationTime writes an application-tagged Time. Use 0xFF for unspecified fields.
+func EncodeApplicationTime(buf *bytes.Buffer, hour, minute, second, hundredths uint8) {
+	EncodeTag(buf, TagTime, false, 4)
+	buf.Write([]byte{hour, minute, second, hundredths})
+}
+
+// EncodeApplicationObjectID writes an application-tagged BACnetObjectIdentifier.
+func EncodeApplicationObjectID(buf *bytes.Buffer, objectType, instance uint32) {
+	EncodeTag(buf, TagObjectID, false, 4)
+	binary.Write(buf, binary.BigEndian, ObjectID(objectType, instance))
+}
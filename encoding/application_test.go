@@ -0,0 +1,280 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// applicationContent decodes one application tag with the given number from data and returns its
+// content. It fails if the tag is different or if anything follows the value.
+func applicationContent(data []byte, tagNumber uint8) (TagHeader, []byte, bool) {
+	r := bytes.NewReader(data)
+	h, err := DecodeTagHeader(r)
+	if err != nil || h.Context || h.Number != tagNumber {
+		return h, nil, false
+	}
+	if tagNumber == TagBoolean {
+		return h, nil, r.Len() == 0
+	}
+	content := make([]byte, h.Length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return h, nil, false
+	}
+	return h, content, r.Len() == 0
+}
+
+func TestApplicationNullBooleanRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeApplicationNull(&buf)
+	if h, _, ok := applicationContent(buf.Bytes(), TagNull); !ok || h.Length != 0 {
+		t.Errorf("null encoded as %x", buf.Bytes())
+	}
+
+	f := func(value bool) bool {
+		var buf bytes.Buffer
+		EncodeApplicationBoolean(&buf, value)
+		h, _, ok := applicationContent(buf.Bytes(), TagBoolean)
+		return ok && (h.Length == 1) == value
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplicationUnsignedRoundTrip(t *testing.T) {
+	f := func(value uint32) bool {
+		var buf bytes.Buffer
+		EncodeApplicationUnsigned(&buf, value)
+		h, content, ok := applicationContent(buf.Bytes(), TagUnsignedInt)
+		if !ok || h.Length != unsignedLength(value) {
+			return false
+		}
+		got, err := DecodeUnsigned(bytes.NewReader(content), h.Length)
+		return err == nil && got == value
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplicationSignedRoundTrip(t *testing.T) {
+	f := func(value int32) bool {
+		var buf bytes.Buffer
+		EncodeApplicationSigned(&buf, value)
+		h, content, ok := applicationContent(buf.Bytes(), TagSignedInt)
+		if !ok || h.Length != signedLength(value) {
+			return false
+		}
+		got, err := DecodeSigned(bytes.NewReader(content), h.Length)
+		return err == nil && got == value
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+	for _, value := range []int32{0, -1, 127, 128, -128, -129, 32767, -32768, 8388607, -8388608, math.MaxInt32, math.MinInt32} {
+		if !f(value) {
+			t.Errorf("signed %d does not round trip", value)
+		}
+	}
+}
+
+func TestApplicationEnumeratedRoundTrip(t *testing.T) {
+	f := func(value uint32) bool {
+		var buf bytes.Buffer
+		EncodeApplicationEnumerated(&buf, value)
+		h, content, ok := applicationContent(buf.Bytes(), TagEnumerated)
+		if !ok {
+			return false
+		}
+		got, err := DecodeUnsigned(bytes.NewReader(content), h.Length)
+		return err == nil && got == value
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplicationRealDoubleRoundTrip(t *testing.T) {
+	// Compare bit patterns so that NaN payloads and negative zero count as well.
+	real := func(bits uint32) bool {
+		var buf bytes.Buffer
+		EncodeApplicationReal(&buf, math.Float32frombits(bits))
+		_, content, ok := applicationContent(buf.Bytes(), TagReal)
+		return ok && len(content) == 4 && binary.BigEndian.Uint32(content) == bits
+	}
+	if err := quick.Check(real, nil); err != nil {
+		t.Error(err)
+	}
+
+	double := func(bits uint64) bool {
+		var buf bytes.Buffer
+		EncodeApplicationDouble(&buf, math.Float64frombits(bits))
+		_, content, ok := applicationContent(buf.Bytes(), TagDouble)
+		return ok && len(content) == 8 && binary.BigEndian.Uint64(content) == bits
+	}
+	if err := quick.Check(double, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplicationOctetStringRoundTrip(t *testing.T) {
+	f := func(value []byte) bool {
+		var buf bytes.Buffer
+		EncodeApplicationOctetString(&buf, value)
+		_, content, ok := applicationContent(buf.Bytes(), TagOctetString)
+		return ok && bytes.Equal(content, value)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+	// Lengths around the one-, two- and four-octet extended length forms.
+	for _, n := range []int{4, 5, 253, 254, 255, 65535, 65536} {
+		value := make([]byte, n)
+		for i := range value {
+			value[i] = byte(i)
+		}
+		if !f(value) {
+			t.Errorf("octet string of %d octets does not round trip", n)
+		}
+	}
+}
+
+func TestApplicationCharacterStringRoundTrip(t *testing.T) {
+	utf8 := func(value string) bool {
+		var buf bytes.Buffer
+		EncodeApplicationCharacterString(&buf, value)
+		_, content, ok := applicationContent(buf.Bytes(), TagCharacterString)
+		if !ok || len(content) == 0 {
+			return false
+		}
+		text, ok := DecodeText(CharacterSet(content[0]), content[1:])
+		return ok && text == value
+	}
+	if err := quick.Check(utf8, nil); err != nil {
+		t.Error(err)
+	}
+
+	// Strings whose content is one octet short of and at the extended length boundaries.
+	for _, n := range []int{252, 253, 254, 65534, 65535} {
+		if !utf8(string(bytes.Repeat([]byte{'a'}, n))) {
+			t.Errorf("character string of %d characters does not round trip", n)
+		}
+	}
+
+	for _, charset := range []CharacterSet{CharsetUTF8, CharsetISO8859_1, CharsetUCS2, CharsetUCS4} {
+		f := func(value string) bool {
+			data, err := EncodeText(charset, value)
+			if err != nil {
+				return true // Not representable in this character set
+			}
+			var buf bytes.Buffer
+			EncodeApplicationCharacterStringData(&buf, charset, data)
+			_, content, ok := applicationContent(buf.Bytes(), TagCharacterString)
+			if !ok || CharacterSet(content[0]) != charset {
+				return false
+			}
+			text, ok := DecodeText(charset, content[1:])
+			return ok && text == value
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Errorf("character set %d: %v", charset, err)
+		}
+		if !f("Zone Temp 21°C") {
+			t.Errorf("character set %d: Latin-1 text does not round trip", charset)
+		}
+	}
+}
+
+func TestApplicationBitStringRoundTrip(t *testing.T) {
+	f := func(bits []bool) bool {
+		var buf bytes.Buffer
+		EncodeApplicationBitString(&buf, bits)
+		_, content, ok := applicationContent(buf.Bytes(), TagBitString)
+		if !ok || len(content) == 0 {
+			return false
+		}
+		unused := int(content[0])
+		if unused > 7 {
+			return false
+		}
+		got := make([]bool, 0, len(bits))
+		for i := 0; i < (len(content)-1)*8-unused; i++ {
+			got = append(got, content[1+i/8]&(0x80>>(i%8)) != 0)
+		}
+		return reflect.DeepEqual(got, append([]bool{}, bits...))
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplicationDateTimeRoundTrip(t *testing.T) {
+	date := func(year, month, day, weekday uint8) bool {
+		var buf bytes.Buffer
+		EncodeApplicationDate(&buf, year, month, day, weekday)
+		_, content, ok := applicationContent(buf.Bytes(), TagDate)
+		return ok && bytes.Equal(content, []byte{year, month, day, weekday})
+	}
+	if err := quick.Check(date, nil); err != nil {
+		t.Error(err)
+	}
+
+	tm := func(hour, minute, second, hundredths uint8) bool {
+		var buf bytes.Buffer
+		EncodeApplicationTime(&buf, hour, minute, second, hundredths)
+		_, content, ok := applicationContent(buf.Bytes(), TagTime)
+		return ok && bytes.Equal(content, []byte{hour, minute, second, hundredths})
+	}
+	if err := quick.Check(tm, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplicationObjectIDRoundTrip(t *testing.T) {
+	f := func(objectType, instance uint32) bool {
+		objectType &= 0x3FF
+		instance &= 0x3FFFFF
+		var buf bytes.Buffer
+		EncodeApplicationObjectID(&buf, objectType, instance)
+		_, content, ok := applicationContent(buf.Bytes(), TagObjectID)
+		if !ok || len(content) != 4 {
+			return false
+		}
+		gotType, gotInstance := SplitObjectID(binary.BigEndian.Uint32(content))
+		return gotType == objectType && gotInstance == instance
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestApplicationEncodingCanonical checks that equal values always encode to the same bytes and
+// that unsigned and signed values use the shortest encoding.
+func TestApplicationEncodingCanonical(t *testing.T) {
+	f := func(u uint32, s int32) bool {
+		var a, b bytes.Buffer
+		EncodeApplicationUnsigned(&a, u)
+		EncodeApplicationSigned(&a, s)
+		EncodeApplicationUnsigned(&b, u)
+		EncodeApplicationSigned(&b, s)
+		if !bytes.Equal(a.Bytes(), b.Bytes()) {
+			return false
+		}
+		r := bytes.NewReader(a.Bytes())
+		hu, _ := DecodeTagHeader(r)
+		if hu.Length > 1 && a.Bytes()[1] == 0 {
+			return false // Leading zero octet
+		}
+		r.Seek(int64(hu.Length), io.SeekCurrent)
+		hs, _ := DecodeTagHeader(r)
+		return hs.Length == signedLength(s)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
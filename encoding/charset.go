@@ -0,0 +1,109 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// CharacterSet is the character set octet that starts the content of a Character String.
+type CharacterSet uint8
+
+const (
+	// CharsetUTF8 is ANSI X3.4, i.e. UTF-8 since BACnet 2008.
+	CharsetUTF8 CharacterSet = 0
+	// CharsetDBCS is an IBM/Microsoft double byte character set; the two octets following the
+	// character set octet are the code page.
+	CharsetDBCS      CharacterSet = 1
+	CharsetJISX0208  CharacterSet = 2
+	CharsetUCS4      CharacterSet = 3
+	CharsetUCS2      CharacterSet = 4
+	CharsetISO8859_1 CharacterSet = 5
+)
+
+// DecodeText converts the encoded text of a Character String in the given character set to a Go
+// string. It reports false for DBCS and JIS X 0208, which need code tables. Text claiming to be
+// UTF-8 that is not valid UTF-8 is decoded as ISO 8859-1, which is what devices sending it
+// usually mean.
+func DecodeText(charset CharacterSet, data []byte) (string, bool) {
+	switch charset {
+	case CharsetUTF8:
+		if utf8.Valid(data) {
+			return string(data), true
+		}
+		return decodeLatin1(data), true
+	case CharsetISO8859_1:
+		return decodeLatin1(data), true
+	case CharsetUCS2:
+		if len(data)%2 != 0 {
+			return "", false
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(data[2*i:])
+		}
+		return string(utf16.Decode(units)), true
+	case CharsetUCS4:
+		if len(data)%4 != 0 {
+			return "", false
+		}
+		runes := make([]rune, len(data)/4)
+		for i := range runes {
+			runes[i] = rune(binary.BigEndian.Uint32(data[4*i:]))
+		}
+		return string(runes), true
+	}
+	return "", false
+}
+
+func decodeLatin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// EncodeText converts s to the encoded text of a Character String in the given character set. It
+// fails for characters the set cannot represent, and for DBCS and JIS X 0208.
+func EncodeText(charset CharacterSet, s string) ([]byte, error) {
+	switch charset {
+	case CharsetUTF8:
+		return []byte(s), nil
+	case CharsetISO8859_1:
+		data := make([]byte, 0, len(s))
+		for _, r := range s {
+			if r > 0xFF {
+				return nil, fmt.Errorf("character %q is not in ISO 8859-1", r)
+			}
+			data = append(data, byte(r))
+		}
+		return data, nil
+	case CharsetUCS2:
+		data := make([]byte, 0, 2*len(s))
+		for _, r := range s {
+			if r > 0xFFFF {
+				return nil, fmt.Errorf("character %q is not in UCS-2", r)
+			}
+			data = binary.BigEndian.AppendUint16(data, uint16(r))
+		}
+		return data, nil
+	case CharsetUCS4:
+		data := make([]byte, 0, 4*len(s))
+		for _, r := range s {
+			data = binary.BigEndian.AppendUint32(data, uint32(r))
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unsupported character set %d", charset)
+}
+
+// EncodeApplicationCharacterStringData writes an application-tagged Character String of text
+// already encoded in the given character set.
+func EncodeApplicationCharacterStringData(buf *bytes.Buffer, charset CharacterSet, data []byte) {
+	EncodeTag(buf, TagCharacterString, false, uint32(len(data)+1))
+	buf.WriteByte(byte(charset))
+	buf.Write(data)
+}
@@ -0,0 +1,169 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// EncodeContextUnsigned writes an unsigned integer with a context-specific tag.
+func EncodeContextUnsigned(buf *bytes.Buffer, tagNumber uint8, value uint32) {
+	length := unsignedLength(value)
+	EncodeTag(buf, tagNumber, true, length)
+	writeUnsigned(buf, value, length)
+}
+
+// EncodeContextEnumerated writes an enumerated value with a context-specific tag.
+func EncodeContextEnumerated(buf *bytes.Buffer, tagNumber uint8, value uint32) {
+	EncodeContextUnsigned(buf, tagNumber, value)
+}
+
+// EncodeContextSigned writes a signed integer with a context-specific tag.
+func EncodeContextSigned(buf *bytes.Buffer, tagNumber uint8, value int32) {
+	length := signedLength(value)
+	EncodeTag(buf, tagNumber, true, length)
+	writeUnsigned(buf, uint32(value), length)
+}
+
+// EncodeContextBoolean writes a boolean with a context-specific tag. Unlike application booleans,
+// context booleans carry their value in a one-octet content.
+func EncodeContextBoolean(buf *bytes.Buffer, tagNumber uint8, value bool) {
+	EncodeTag(buf, tagNumber, true, 1)
+	if value {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+}
+
+// EncodeContextReal writes a single precision float with a context-specific tag.
+func EncodeContextReal(buf *bytes.Buffer, tagNumber uint8, value float32) {
+	EncodeTag(buf, tagNumber, true, 4)
+	binary.Write(buf, binary.BigEndian, math.Float32bits(value))
+}
+
+// EncodeContextObjectID writes a BACnetObjectIdentifier with a context-specific tag.
+func EncodeContextObjectID(buf *bytes.Buffer, tagNumber uint8, objectType, instance uint32) {
+	EncodeTag(buf, tagNumber, true, 4)
+	binary.Write(buf, binary.BigEndian, ObjectID(objectType, instance))
+}
+
+// EncodeContextOctetString writes an octet string with a context-specific tag.
+func EncodeContextOctetString(buf *bytes.Buffer, tagNumber uint8, value []byte) {
+	EncodeTag(buf, tagNumber, true, uint32(len(value)))
+	buf.Write(value)
+}
+
+// EncodeContextCharacterString writes a UTF-8 character string with a context-specific tag.
+func EncodeContextCharacterString(buf *bytes.Buffer, tagNumber uint8, value string) {
+	EncodeTag(buf, tagNumber, true, uint32(len(value)+1))
+	buf.WriteByte(0) // ANSI X3.4 / UTF-8
+	buf.WriteString(value)
+}
+
+// DecodeContextUnsigned reads a primitive context tag with the given number and returns its
+// content as an unsigned integer.
+func DecodeContextUnsigned(r *bytes.Reader, tagNumber uint8) (uint32, error) {
+	h, err := DecodeTagHeader(r)
+	if err != nil {
+		return 0, err
+	}
+	if !h.IsContext(tagNumber) {
+		return 0, fmt.Errorf("expected context tag %d, got tag %d", tagNumber, h.Number)
+	}
+	return DecodeUnsigned(r, h.Length)
+}
+
+// DecodeContextBoolean reads a context-tagged Boolean with the given tag number.
+func DecodeContextBoolean(r *bytes.Reader, tagNumber uint8) (bool, error) {
+	v, err := DecodeContextUnsigned(r, tagNumber)
+	return v != 0, err
+}
+
+// DecodeContextReal reads a context-tagged REAL with the given tag number.
+func DecodeContextReal(r *bytes.Reader, tagNumber uint8) (float32, error) {
+	h, err := DecodeTagHeader(r)
+	if err != nil {
+		return 0, err
+	}
+	if !h.IsContext(tagNumber) || h.Length != 4 {
+		return 0, fmt.Errorf("expected real with context tag %d, got tag %d", tagNumber, h.Number)
+	}
+	var bits uint32
+	if err := binary.Read(r, binary.BigEndian, &bits); err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return math.Float32frombits(bits), nil
+}
+
+// DecodeContextObjectID reads a context-tagged BACnetObjectIdentifier with the given tag number.
+func DecodeContextObjectID(r *bytes.Reader, tagNumber uint8) (objectType, instance uint32, err error) {
+	h, err := DecodeTagHeader(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !h.IsContext(tagNumber) || h.Length != 4 {
+		return 0, 0, fmt.Errorf("expected object identifier with context tag %d, got tag %d", tagNumber, h.Number)
+	}
+	var id uint32
+	if err := binary.Read(r, binary.BigEndian, &id); err != nil {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	objectType, instance = SplitObjectID(id)
+	return objectType, instance, nil
+}
+
+// DecodeContextCharacterString reads a context-tagged Character String with the given tag number
+// and converts it to UTF-8; see DecodeText for the character sets supported.
+func DecodeContextCharacterString(r *bytes.Reader, tagNumber uint8) (string, error) {
+	h, err := DecodeTagHeader(r)
+	if err != nil {
+		return "", err
+	}
+	if !h.IsContext(tagNumber) || h.Length < 1 {
+		return "", fmt.Errorf("expected character string with context tag %d, got tag %d", tagNumber, h.Number)
+	}
+	value := make([]byte, h.Length)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return "", io.ErrUnexpectedEOF
+	}
+	text, ok := DecodeText(CharacterSet(value[0]), value[1:])
+	if !ok {
+		return "", fmt.Errorf("unsupported character set %d", value[0])
+	}
+	return text, nil
+}
+
+// ExpectOpeningTag reads a tag header and fails unless it is the opening tag with the given number.
+func ExpectOpeningTag(r *bytes.Reader, tagNumber uint8) error {
+	h, err := DecodeTagHeader(r)
+	if err != nil {
+		return err
+	}
+	if !h.IsOpening(tagNumber) {
+		return fmt.Errorf("expected opening tag %d, got tag %d", tagNumber, h.Number)
+	}
+	return nil
+}
+
+// ExpectClosingTag reads a tag header and fails unless it is the closing tag with the given number.
+func ExpectClosingTag(r *bytes.Reader, tagNumber uint8) error {
+	h, err := DecodeTagHeader(r)
+	if err != nil {
+		return err
+	}
+	if !h.IsClosing(tagNumber) {
+		return fmt.Errorf("expected closing tag %d, got tag %d", tagNumber, h.Number)
+	}
+	return nil
+}
+
+// PeekTagHeader decodes the next tag header without consuming it.
+func PeekTagHeader(r *bytes.Reader) (TagHeader, error) {
+	start := r.Size() - int64(r.Len())
+	h, err := DecodeTagHeader(r)
+	r.Seek(start, io.SeekStart)
+	return h, err
+}
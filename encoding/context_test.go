@@ -0,0 +1,116 @@
+package encoding
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+func TestContextUnsignedRoundTrip(t *testing.T) {
+	f := func(tagNumber uint8, value uint32) bool {
+		var buf bytes.Buffer
+		EncodeContextUnsigned(&buf, tagNumber, value)
+		EncodeContextEnumerated(&buf, tagNumber, value)
+		r := bytes.NewReader(buf.Bytes())
+		unsigned, err := DecodeContextUnsigned(r, tagNumber)
+		if err != nil || unsigned != value {
+			return false
+		}
+		enumerated, err := DecodeContextUnsigned(r, tagNumber)
+		return err == nil && enumerated == value && r.Len() == 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestContextSignedRoundTrip(t *testing.T) {
+	f := func(tagNumber uint8, value int32) bool {
+		var buf bytes.Buffer
+		EncodeContextSigned(&buf, tagNumber, value)
+		r := bytes.NewReader(buf.Bytes())
+		h, err := DecodeTagHeader(r)
+		if err != nil || !h.IsContext(tagNumber) {
+			return false
+		}
+		got, err := DecodeSigned(r, h.Length)
+		return err == nil && got == value && r.Len() == 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestContextBooleanRoundTrip(t *testing.T) {
+	f := func(tagNumber uint8, value bool) bool {
+		var buf bytes.Buffer
+		EncodeContextBoolean(&buf, tagNumber, value)
+		r := bytes.NewReader(buf.Bytes())
+		got, err := DecodeContextBoolean(r, tagNumber)
+		return err == nil && got == value && r.Len() == 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestContextRealRoundTrip(t *testing.T) {
+	f := func(tagNumber uint8, bits uint32) bool {
+		var buf bytes.Buffer
+		EncodeContextReal(&buf, tagNumber, math.Float32frombits(bits))
+		r := bytes.NewReader(buf.Bytes())
+		got, err := DecodeContextReal(r, tagNumber)
+		return err == nil && math.Float32bits(got) == bits && r.Len() == 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestContextObjectIDRoundTrip(t *testing.T) {
+	f := func(tagNumber uint8, objectType, instance uint32) bool {
+		objectType &= 0x3FF
+		instance &= 0x3FFFFF
+		var buf bytes.Buffer
+		EncodeContextObjectID(&buf, tagNumber, objectType, instance)
+		r := bytes.NewReader(buf.Bytes())
+		gotType, gotInstance, err := DecodeContextObjectID(r, tagNumber)
+		return err == nil && gotType == objectType && gotInstance == instance && r.Len() == 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestContextStringRoundTrip(t *testing.T) {
+	text := func(tagNumber uint8, value string) bool {
+		var buf bytes.Buffer
+		EncodeContextCharacterString(&buf, tagNumber, value)
+		r := bytes.NewReader(buf.Bytes())
+		got, err := DecodeContextCharacterString(r, tagNumber)
+		return err == nil && got == value && r.Len() == 0
+	}
+	if err := quick.Check(text, nil); err != nil {
+		t.Error(err)
+	}
+
+	octets := func(tagNumber uint8, value []byte) bool {
+		var buf bytes.Buffer
+		EncodeContextOctetString(&buf, tagNumber, value)
+		r := bytes.NewReader(buf.Bytes())
+		h, err := DecodeTagHeader(r)
+		if err != nil || !h.IsContext(tagNumber) || h.Length != uint32(len(value)) {
+			return false
+		}
+		return bytes.Equal(buf.Bytes()[buf.Len()-r.Len():], value)
+	}
+	if err := quick.Check(octets, nil); err != nil {
+		t.Error(err)
+	}
+	for _, n := range []int{5, 6, 7, 253, 254, 65535, 65536} {
+		if !octets(9, make([]byte, n)) || !octets(200, make([]byte, n)) {
+			t.Errorf("context octet string of %d octets does not round trip", n)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Package encoding provides the low-level BACnet tag encoding and decoding primitives used by the
+// bacnet package, for building services the bacnet package does not implement itself.
+//
+// BACnet encodes every value with a tag header. Application tags (Null, Boolean, Unsigned, ...)
+// carry their data type in the tag number; context-specific tags carry the position of a field in
+// a service production instead, so the type of a context-tagged value follows from the service
+// definition. Constructed values are wrapped in an opening and a closing context tag with the same
+// number.
+//
+// Encoders append to a *bytes.Buffer and always pick the shortest encoding, so the same value
+// encodes to the same bytes every time. Decoders read from a *bytes.Reader and return
+// io.ErrUnexpectedEOF when the data ends in the middle of a value, which lets callers wait for
+// more data when decoding incrementally.
+//
+// A ReadProperty request for the Present_Value of Analog Input 1, for example, is encoded as
+//
+//	var buf bytes.Buffer
+//	encoding.EncodeContextObjectID(&buf, 0, 0, 1)    // objectIdentifier [0]
+//	encoding.EncodeContextUnsigned(&buf, 1, 85)      // propertyIdentifier [1]
+package encoding
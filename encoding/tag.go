@@ -0,0 +1,264 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Application tag numbers.
+const (
+	TagNull            uint8 = 0
+	TagBoolean         uint8 = 1
+	TagUnsignedInt     uint8 = 2
+	TagSignedInt       uint8 = 3
+	TagReal            uint8 = 4
+	TagDouble          uint8 = 5
+	TagOctetString     uint8 = 6
+	TagCharacterString uint8 = 7
+	TagBitString       uint8 = 8
+	TagEnumerated      uint8 = 9
+	TagDate            uint8 = 10
+	TagTime            uint8 = 11
+	TagObjectID        uint8 = 12
+)
+
+// TagHeader is a decoded tag header.
+type TagHeader struct {
+	Number uint8
+	// Context is true for context-specific tags and false for application tags.
+	Context bool
+	// Opening and Closing mark the start and end of a constructed value.
+	Opening bool
+	Closing bool
+	// Length is the length of the content that follows the header.
+	// For application-tagged booleans it is the value itself (0 or 1).
+	Length uint32
+}
+
+// IsOpening reports whether h is the opening tag with the given number.
+func (h TagHeader) IsOpening(number uint8) bool {
+	return h.Opening && h.Number == number
+}
+
+// IsClosing reports whether h is the closing tag with the given number.
+func (h TagHeader) IsClosing(number uint8) bool {
+	return h.Closing && h.Number == number
+}
+
+// IsContext reports whether h is a primitive context tag with the given number.
+func (h TagHeader) IsContext(number uint8) bool {
+	return h.Context && !h.Opening && !h.Closing && h.Number == number
+}
+
+// DecodeTagHeader reads a tag header, including extended tag numbers and extended lengths.
+// It returns io.EOF if r is empty and io.ErrUnexpectedEOF if the header is incomplete.
+func DecodeTagHeader(r *bytes.Reader) (TagHeader, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return TagHeader{}, err
+	}
+
+	h := TagHeader{
+		Number:  b >> 4,
+		Context: b&0x08 != 0,
+	}
+	if h.Number == 0x0F {
+		ext, err := r.ReadByte()
+		if err != nil {
+			return TagHeader{}, io.ErrUnexpectedEOF
+		}
+		h.Number = ext
+	}
+
+	lvt := b & 0x07
+	switch {
+	case h.Context && lvt == 6:
+		h.Opening = true
+	case h.Context && lvt == 7:
+		h.Closing = true
+	case lvt == 5:
+		ext, err := r.ReadByte()
+		if err != nil {
+			return TagHeader{}, io.ErrUnexpectedEOF
+		}
+		switch ext {
+		case 254:
+			var l uint16
+			if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+				return TagHeader{}, io.ErrUnexpectedEOF
+			}
+			h.Length = uint32(l)
+		case 255:
+			if err := binary.Read(r, binary.BigEndian, &h.Length); err != nil {
+				return TagHeader{}, io.ErrUnexpectedEOF
+			}
+		default:
+			h.Length = uint32(ext)
+		}
+	default:
+		h.Length = uint32(lvt)
+	}
+	return h, nil
+}
+
+// EncodeTag writes a tag header for content of the given length.
+func EncodeTag(buf *bytes.Buffer, number uint8, context bool, length uint32) {
+	var first byte
+	if context {
+		first = 0x08
+	}
+
+	var extNumber []byte
+	if number <= 14 {
+		first |= number << 4
+	} else {
+		first |= 0xF0
+		extNumber = []byte{number}
+	}
+
+	switch {
+	case length <= 4:
+		buf.WriteByte(first | byte(length))
+		buf.Write(extNumber)
+	case length <= 253:
+		buf.WriteByte(first | 5)
+		buf.Write(extNumber)
+		buf.WriteByte(byte(length))
+	case length <= 0xFFFF:
+		buf.WriteByte(first | 5)
+		buf.Write(extNumber)
+		buf.WriteByte(254)
+		binary.Write(buf, binary.BigEndian, uint16(length))
+	default:
+		buf.WriteByte(first | 5)
+		buf.Write(extNumber)
+		buf.WriteByte(255)
+		binary.Write(buf, binary.BigEndian, length)
+	}
+}
+
+// EncodeOpeningTag writes the opening tag of a constructed value.
+func EncodeOpeningTag(buf *bytes.Buffer, number uint8) {
+	if number <= 14 {
+		buf.WriteByte(number<<4 | 0x0E)
+		return
+	}
+	buf.WriteByte(0xFE)
+	buf.WriteByte(number)
+}
+
+// EncodeClosingTag writes the closing tag of a constructed value.
+func EncodeClosingTag(buf *bytes.Buffer, number uint8) {
+	if number <= 14 {
+		buf.WriteByte(number<<4 | 0x0F)
+		return
+	}
+	buf.WriteByte(0xFF)
+	buf.WriteByte(number)
+}
+
+// SkipValue skips the content following h, including the whole constructed value if h is an
+// opening tag.
+func SkipValue(r *bytes.Reader, h TagHeader) error {
+	if h.Closing {
+		return nil
+	}
+	if !h.Opening {
+		if !h.Context && h.Number == TagBoolean {
+			return nil // Application booleans have no content
+		}
+		if uint32(r.Len()) < h.Length {
+			return io.ErrUnexpectedEOF
+		}
+		_, err := r.Seek(int64(h.Length), io.SeekCurrent)
+		return err
+	}
+	for {
+		inner, err := DecodeTagHeader(r)
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		if inner.IsClosing(h.Number) {
+			return nil
+		}
+		if err := SkipValue(r, inner); err != nil {
+			return err
+		}
+	}
+}
+
+// ObjectID packs an object type and instance number into a BACnetObjectIdentifier.
+func ObjectID(objectType, instance uint32) uint32 {
+	return objectType<<22 | instance&0x3FFFFF
+}
+
+// SplitObjectID unpacks a BACnetObjectIdentifier into object type and instance number.
+func SplitObjectID(id uint32) (objectType, instance uint32) {
+	return id >> 22, id & 0x3FFFFF
+}
+
+func unsignedLength(value uint32) uint32 {
+	switch {
+	case value < 0x100:
+		return 1
+	case value < 0x10000:
+		return 2
+	case value < 0x1000000:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func writeUnsigned(buf *bytes.Buffer, value uint32, length uint32) {
+	for i := int(length) - 1; i >= 0; i-- {
+		buf.WriteByte(byte(value >> (8 * i)))
+	}
+}
+
+func signedLength(value int32) uint32 {
+	switch {
+	case value >= -0x80 && value < 0x80:
+		return 1
+	case value >= -0x8000 && value < 0x8000:
+		return 2
+	case value >= -0x800000 && value < 0x800000:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// DecodeUnsigned reads a big-endian unsigned integer of the given length.
+func DecodeUnsigned(r *bytes.Reader, length uint32) (uint32, error) {
+	if length > 4 {
+		return 0, fmt.Errorf("unsigned value of %d octets does not fit in 32 bits", length)
+	}
+	var val uint32
+	for i := uint32(0); i < length; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		val = (val << 8) | uint32(b)
+	}
+	return val, nil
+}
+
+// DecodeSigned reads a big-endian two's complement integer of the given length.
+func DecodeSigned(r *bytes.Reader, length uint32) (int32, error) {
+	if length == 0 || length > 4 {
+		return 0, fmt.Errorf("invalid signed value length %d", length)
+	}
+	val, err := DecodeUnsigned(r, length)
+	if err != nil {
+		return 0, err
+	}
+	shift := 32 - 8*length
+	return int32(val<<shift) >> shift, nil
+}
@@ -0,0 +1,130 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/quick"
+)
+
+func TestTagHeaderRoundTrip(t *testing.T) {
+	f := func(number uint8, context bool, length uint32) bool {
+		var buf bytes.Buffer
+		EncodeTag(&buf, number, context, length)
+		r := bytes.NewReader(buf.Bytes())
+		h, err := DecodeTagHeader(r)
+		if err != nil || r.Len() != 0 {
+			return false
+		}
+		// Context tags with lengths 6 and 7 would be opening and closing tags, which EncodeTag
+		// avoids by switching to the extended length form.
+		return h.Number == number && h.Context == context && !h.Opening && !h.Closing && h.Length == length
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTagHeaderExtendedLengthBoundaries(t *testing.T) {
+	tests := []struct {
+		length     uint32
+		headerSize int
+	}{
+		{4, 1},
+		{5, 2},
+		{253, 2},
+		{254, 4},
+		{255, 4},
+		{65535, 4},
+		{65536, 6},
+		{1<<32 - 1, 6},
+	}
+	for _, tt := range tests {
+		for _, number := range []uint8{TagOctetString, 14, 15, 254} {
+			for _, context := range []bool{false, true} {
+				var buf bytes.Buffer
+				EncodeTag(&buf, number, context, tt.length)
+				want := tt.headerSize
+				if number > 14 {
+					want++ // Extended tag number octet
+				}
+				if buf.Len() != want {
+					t.Errorf("EncodeTag(%d, %v, %d) wrote %d octets, want %d", number, context, tt.length, buf.Len(), want)
+				}
+				h, err := DecodeTagHeader(bytes.NewReader(buf.Bytes()))
+				if err != nil {
+					t.Fatalf("DecodeTagHeader(%x): %v", buf.Bytes(), err)
+				}
+				if h.Number != number || h.Context != context || h.Length != tt.length {
+					t.Errorf("DecodeTagHeader(%x) = %+v, want number %d, context %v, length %d", buf.Bytes(), h, number, context, tt.length)
+				}
+			}
+		}
+	}
+}
+
+func TestTagHeaderTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeTag(&buf, 20, true, 65535)
+	data := buf.Bytes()
+	for i := 1; i < len(data); i++ {
+		if _, err := DecodeTagHeader(bytes.NewReader(data[:i])); err != io.ErrUnexpectedEOF {
+			t.Errorf("DecodeTagHeader(%x) error = %v, want io.ErrUnexpectedEOF", data[:i], err)
+		}
+	}
+	if _, err := DecodeTagHeader(bytes.NewReader(nil)); err != io.EOF {
+		t.Errorf("DecodeTagHeader(empty) error = %v, want io.EOF", err)
+	}
+}
+
+func TestOpeningClosingTagRoundTrip(t *testing.T) {
+	f := func(number uint8) bool {
+		var buf bytes.Buffer
+		EncodeOpeningTag(&buf, number)
+		EncodeClosingTag(&buf, number)
+		r := bytes.NewReader(buf.Bytes())
+		if err := ExpectOpeningTag(r, number); err != nil {
+			return false
+		}
+		return ExpectClosingTag(r, number) == nil && r.Len() == 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSkipValueConstructed(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeOpeningTag(&buf, 3)
+	EncodeApplicationUnsigned(&buf, 1476)
+	EncodeOpeningTag(&buf, 0)
+	EncodeApplicationBoolean(&buf, true)
+	EncodeApplicationOctetString(&buf, make([]byte, 300))
+	EncodeClosingTag(&buf, 0)
+	EncodeClosingTag(&buf, 3)
+	EncodeContextUnsigned(&buf, 4, 7)
+
+	r := bytes.NewReader(buf.Bytes())
+	h, err := DecodeTagHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SkipValue(r, h); err != nil {
+		t.Fatalf("SkipValue: %v", err)
+	}
+	if v, err := DecodeContextUnsigned(r, 4); err != nil || v != 7 {
+		t.Fatalf("value after skipped constructed value = %d, %v; want 7", v, err)
+	}
+}
+
+func TestObjectIDRoundTrip(t *testing.T) {
+	f := func(objectType, instance uint32) bool {
+		objectType &= 0x3FF
+		instance &= 0x3FFFFF
+		gotType, gotInstance := SplitObjectID(ObjectID(objectType, instance))
+		return gotType == objectType && gotInstance == instance
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,45 @@
+package bacnet
+
+import "fmt"
+
+// propertyEnumerations maps properties with an enumerated datatype to the name of a value.
+var propertyEnumerations = map[uint32]func(uint32) string{
+	PROP_CAR_ASSIGNED_DIRECTION: func(v uint32) string { return LiftCarDirection(v).String() },
+	PROP_CAR_MOVING_DIRECTION:   func(v uint32) string { return LiftCarDirection(v).String() },
+	PROP_CAR_DOOR_COMMAND:       func(v uint32) string { return LiftCarDoorCommand(v).String() },
+	PROP_CAR_DOOR_STATUS:        func(v uint32) string { return DoorStatus(v).String() },
+	PROP_LANDING_DOOR_STATUS:    func(v uint32) string { return DoorStatus(v).String() },
+	PROP_CAR_DRIVE_STATUS:       func(v uint32) string { return LiftCarDriveStatus(v).String() },
+	PROP_CAR_MODE:               func(v uint32) string { return LiftCarMode(v).String() },
+	PROP_GROUP_MODE:             func(v uint32) string { return LiftGroupMode(v).String() },
+	PROP_ESCALATOR_MODE:         func(v uint32) string { return EscalatorMode(v).String() },
+	PROP_OPERATION_DIRECTION:    func(v uint32) string { return EscalatorOperationDirection(v).String() },
+	PROP_TIMER_STATE:            func(v uint32) string { return TimerState(v).String() },
+	PROP_LAST_STATE_CHANGE:      func(v uint32) string { return TimerTransition(v).String() },
+	PROP_NODE_TYPE:              func(v uint32) string { return NodeType(v).String() },
+	PROP_UNITS: func(v uint32) string {
+		if name, ok := UnitName(v); ok {
+			return name
+		}
+		return fmt.Sprintf("%d", v)
+	},
+}
+
+// EnumerationName returns the name of an enumerated value of a property, e.g. "up" for a
+// Car_Moving_Direction of 3. It reports false for properties whose enumeration is not known.
+func EnumerationName(propertyID uint32, value uint32) (string, bool) {
+	name, ok := propertyEnumerations[propertyID]
+	if !ok {
+		return "", false
+	}
+	return name(value), true
+}
+
+// enumName returns the name of an enumeration value, or its number for values without a name
+// (e.g. proprietary extensions).
+func enumName[T ~uint32](names map[T]string, v T) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", uint32(v))
+}
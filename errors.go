@@ -0,0 +1,159 @@
+package bacnet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// BACnetError is the Error PDU a device answered a confirmed request with. Client methods return
+// it, often wrapped, so use errors.As to find it; ErrorClassName and ErrorCodeName name Class and
+// Code.
+type BACnetError struct {
+	Class    uint32 `json:"class"`
+	Code     uint32 `json:"code"`
+	InvokeID byte   `json:"invokeId"`
+	Service  byte   `json:"service"`
+}
+
+func (e *BACnetError) Error() string {
+	return "BACnet error " + errorLabel(e.Class, e.Code)
+}
+
+// decodeErrorPDU decodes an Error PDU into a *BACnetError. The error class and code start the
+// service data, or are enclosed in context tag 0 for services whose errors carry more, such as
+// WritePropertyMultiple and CreateObject.
+func decodeErrorPDU(apdu []byte) error {
+	if len(apdu) < 3 {
+		return fmt.Errorf("truncated Error PDU")
+	}
+	e := &BACnetError{InvokeID: apdu[1], Service: apdu[2]}
+	r := bytes.NewReader(apdu[3:])
+	if hasContextTag(r, 0) {
+		if err := encoding.ExpectOpeningTag(r, 0); err != nil {
+			return fmt.Errorf("malformed Error PDU: %w", err)
+		}
+	}
+	for _, field := range []*uint32{&e.Class, &e.Code} {
+		value, err := decodeApplicationAs[uint32](r, encoding.TagEnumerated)
+		if err != nil {
+			return fmt.Errorf("malformed Error PDU: %w", err)
+		}
+		*field = value
+	}
+	return e
+}
+
+// RejectReason is the reason of a Reject PDU.
+type RejectReason uint32
+
+const (
+	RejectReasonOther                    RejectReason = 0
+	RejectReasonBufferOverflow           RejectReason = 1
+	RejectReasonInconsistentParameters   RejectReason = 2
+	RejectReasonInvalidParameterDataType RejectReason = 3
+	RejectReasonInvalidTag               RejectReason = 4
+	RejectReasonMissingRequiredParameter RejectReason = 5
+	RejectReasonParameterOutOfRange      RejectReason = 6
+	RejectReasonTooManyArguments         RejectReason = 7
+	RejectReasonUndefinedEnumeration     RejectReason = 8
+	RejectReasonUnrecognizedService      RejectReason = 9
+	RejectReasonInvalidDataEncoding      RejectReason = 10
+)
+
+var rejectReasonNames = map[RejectReason]string{
+	RejectReasonOther:                    "other",
+	RejectReasonBufferOverflow:           "buffer-overflow",
+	RejectReasonInconsistentParameters:   "inconsistent-parameters",
+	RejectReasonInvalidParameterDataType: "invalid-parameter-data-type",
+	RejectReasonInvalidTag:               "invalid-tag",
+	RejectReasonMissingRequiredParameter: "missing-required-parameter",
+	RejectReasonParameterOutOfRange:      "parameter-out-of-range",
+	RejectReasonTooManyArguments:         "too-many-arguments",
+	RejectReasonUndefinedEnumeration:     "undefined-enumeration",
+	RejectReasonUnrecognizedService:      "unrecognized-service",
+	RejectReasonInvalidDataEncoding:      "invalid-data-encoding",
+}
+
+func (r RejectReason) String() string { return enumName(rejectReasonNames, r) }
+
+// AbortReason is the reason of an Abort PDU.
+type AbortReason uint32
+
+const (
+	AbortReasonOther                         AbortReason = 0
+	AbortReasonBufferOverflow                AbortReason = 1
+	AbortReasonInvalidAPDUInThisState        AbortReason = 2
+	AbortReasonPreemptedByHigherPriorityTask AbortReason = 3
+	AbortReasonSegmentationNotSupported      AbortReason = 4
+	AbortReasonSecurityError                 AbortReason = 5
+	AbortReasonInsufficientSecurity          AbortReason = 6
+	AbortReasonWindowSizeOutOfRange          AbortReason = 7
+	AbortReasonApplicationExceededReplyTime  AbortReason = 8
+	AbortReasonOutOfResources                AbortReason = 9
+	AbortReasonTSMTimeout                    AbortReason = 10
+	AbortReasonAPDUTooLong                   AbortReason = 11
+)
+
+var abortReasonNames = map[AbortReason]string{
+	AbortReasonOther:                         "other",
+	AbortReasonBufferOverflow:                "buffer-overflow",
+	AbortReasonInvalidAPDUInThisState:        "invalid-apdu-in-this-state",
+	AbortReasonPreemptedByHigherPriorityTask: "preempted-by-higher-priority-task",
+	AbortReasonSegmentationNotSupported:      "segmentation-not-supported",
+	AbortReasonSecurityError:                 "security-error",
+	AbortReasonInsufficientSecurity:          "insufficient-security",
+	AbortReasonWindowSizeOutOfRange:          "window-size-out-of-range",
+	AbortReasonApplicationExceededReplyTime:  "application-exceeded-reply-time",
+	AbortReasonOutOfResources:                "out-of-resources",
+	AbortReasonTSMTimeout:                    "tsm-timeout",
+	AbortReasonAPDUTooLong:                   "apdu-too-long",
+}
+
+func (r AbortReason) String() string { return enumName(abortReasonNames, r) }
+
+// RejectError is the Reject PDU a device answered a confirmed request with, because it could not
+// parse or does not implement the request. Like BACnetError, it is found with errors.As.
+type RejectError struct {
+	Reason   RejectReason `json:"reason"`
+	InvokeID byte         `json:"invokeId"`
+}
+
+func (e *RejectError) Error() string {
+	return "BACnet reject: " + e.Reason.String()
+}
+
+// AbortError is the Abort PDU that ended a confirmed transaction. Like BACnetError, it is found
+// with errors.As.
+type AbortError struct {
+	Reason   AbortReason `json:"reason"`
+	InvokeID byte        `json:"invokeId"`
+	// Server is set if the device answering the request aborted the transaction, as opposed to the
+	// client's side of it.
+	Server bool `json:"server"`
+}
+
+func (e *AbortError) Error() string {
+	return "BACnet abort: " + e.Reason.String()
+}
+
+// pduError returns the error an Error, Reject or Abort PDU answers a request with, or nil for other
+// APDUs, which must not be empty.
+func pduError(apdu []byte) error {
+	switch apdu[0] & 0xF0 {
+	case APDU_ERROR:
+		return decodeErrorPDU(apdu)
+	case APDU_REJECT:
+		if len(apdu) < 3 {
+			return fmt.Errorf("truncated Reject PDU")
+		}
+		return &RejectError{Reason: RejectReason(apdu[2]), InvokeID: apdu[1]}
+	case APDU_ABORT:
+		if len(apdu) < 3 {
+			return fmt.Errorf("truncated Abort PDU")
+		}
+		return &AbortError{Reason: AbortReason(apdu[2]), InvokeID: apdu[1], Server: apdu[0]&0x01 != 0}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// EventSummary is the event state of an object as reported by GetEventInformation: an object
+// that is not in the normal state or has transitions that are not acknowledged.
+type EventSummary struct {
+	Object BACnetObject `json:"object"`
+	State  EventState   `json:"state"`
+	// AckedTransitions, EventTimeStamps, EventEnable and EventPriorities are indexed by
+	// transition: to-offnormal, to-fault and to-normal.
+	AckedTransitions [3]bool      `json:"ackedTransitions"`
+	EventTimeStamps  [3]time.Time `json:"eventTimeStamps"`
+	NotifyType       NotifyType   `json:"notifyType"`
+	EventEnable      [3]bool      `json:"eventEnable"`
+	EventPriorities  [3]uint32    `json:"eventPriorities"`
+
+	// stamps are the encoded time stamps, for acknowledgments.
+	stamps [3][]byte
+}
+
+// Acknowledgment returns the acknowledgment of the transition to the current event state.
+func (s EventSummary) Acknowledgment(processID uint32, source string) AlarmAcknowledgment {
+	transition := transitionOf(s.State)
+	return AlarmAcknowledgment{
+		ProcessID: processID,
+		Object:    s.Object,
+		State:     s.State,
+		TimeStamp: s.EventTimeStamps[transition],
+		Source:    source,
+		stamp:     s.stamps[transition],
+	}
+}
+
+// GetEventInformation returns the objects of a device that are in an event state other than
+// normal or have unacknowledged transitions, requesting further pages while the device reports
+// more events.
+func (c *BACnetClient) GetEventInformation(device DeviceInfo, opts ...CallOption) ([]EventSummary, error) {
+	ctx := callContext(opts...)
+	var summaries []EventSummary
+	for {
+		var params bytes.Buffer
+		if len(summaries) > 0 {
+			last := summaries[len(summaries)-1].Object
+			encoding.EncodeContextObjectID(&params, 0, uint32(last.Type), last.Instance)
+		}
+		page, more, err := c.getEventInformation(ctx, device, params.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, page...)
+		if !more || len(page) == 0 {
+			return summaries, nil
+		}
+	}
+}
+
+func (c *BACnetClient) getEventInformation(ctx context.Context, device DeviceInfo, params []byte) ([]EventSummary, bool, error) {
+	invokeID, data, err := c.transact(ctx, device, SERVICE_CONFIRMED_GET_EVENT_INFORMATION, params)
+	if err != nil {
+		return nil, false, err
+	}
+	apdu, err := apduFromPacket(data)
+	if err != nil {
+		return nil, false, err
+	}
+	header, serviceData, err := decodeComplexAck(apdu)
+	if err != nil {
+		return nil, false, fmt.Errorf("GetEventInformation of device %d failed: %w", device.DeviceID, err)
+	}
+	if header.invokeID != invokeID || header.service != SERVICE_CONFIRMED_GET_EVENT_INFORMATION {
+		return nil, false, fmt.Errorf("unexpected response to GetEventInformation: invoke ID %d, service 0x%x", header.invokeID, header.service)
+	}
+	if header.segmented {
+		return nil, false, fmt.Errorf("segmented GetEventInformation responses are not supported")
+	}
+	summaries, more, err := decodeEventInformationAck(serviceData)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode GetEventInformation-ACK: %w", err)
+	}
+	return summaries, more, nil
+}
+
+// decodeEventInformationAck decodes the service data of a GetEventInformation-ACK.
+func decodeEventInformationAck(data []byte) ([]EventSummary, bool, error) {
+	r := bytes.NewReader(data)
+	if err := encoding.ExpectOpeningTag(r, 0); err != nil {
+		return nil, false, err
+	}
+	var summaries []EventSummary
+	for {
+		h, err := encoding.PeekTagHeader(r)
+		if err != nil {
+			return nil, false, err
+		}
+		if h.IsClosing(0) {
+			break
+		}
+		s, err := decodeEventSummary(r)
+		if err != nil {
+			return nil, false, fmt.Errorf("event summary %d: %w", len(summaries), err)
+		}
+		summaries = append(summaries, s)
+	}
+	if err := encoding.ExpectClosingTag(r, 0); err != nil {
+		return nil, false, err
+	}
+	more, err := encoding.DecodeContextBoolean(r, 1)
+	if err != nil {
+		return nil, false, err
+	}
+	return summaries, more, nil
+}
+
+func decodeEventSummary(r *bytes.Reader) (EventSummary, error) {
+	var s EventSummary
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+	if err != nil {
+		return s, err
+	}
+	s.Object = BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	state, err := encoding.DecodeContextUnsigned(r, 1)
+	if err != nil {
+		return s, err
+	}
+	s.State = EventState(state)
+	acked, err := decodeContextBits(r, 2)
+	if err != nil {
+		return s, err
+	}
+	copy(s.AckedTransitions[:], acked)
+	if err := encoding.ExpectOpeningTag(r, 3); err != nil {
+		return s, err
+	}
+	for i := range s.EventTimeStamps {
+		if s.EventTimeStamps[i], s.stamps[i], err = decodeTimeStampChoice(r); err != nil {
+			return s, err
+		}
+	}
+	if err := encoding.ExpectClosingTag(r, 3); err != nil {
+		return s, err
+	}
+	notifyType, err := encoding.DecodeContextUnsigned(r, 4)
+	if err != nil {
+		return s, err
+	}
+	s.NotifyType = NotifyType(notifyType)
+	enable, err := decodeContextBits(r, 5)
+	if err != nil {
+		return s, err
+	}
+	copy(s.EventEnable[:], enable)
+	if err := encoding.ExpectOpeningTag(r, 6); err != nil {
+		return s, err
+	}
+	for i := range s.EventPriorities {
+		priority, err := decodeApplicationValue(r)
+		if err != nil {
+			return s, err
+		}
+		p, ok := priority.(uint32)
+		if !ok {
+			return s, fmt.Errorf("expected event priority, got %T", priority)
+		}
+		s.EventPriorities[i] = p
+	}
+	return s, encoding.ExpectClosingTag(r, 6)
+}
+
+// AlarmAcknowledgment acknowledges the transition of an object to an event state.
+type AlarmAcknowledgment struct {
+	// ProcessID identifies the acknowledging process.
+	ProcessID uint32       `json:"processId"`
+	Object    BACnetObject `json:"object"`
+	// State is the event state the acknowledged transition led to.
+	State EventState `json:"state"`
+	// TimeStamp is the time stamp of the transition, as reported by the device.
+	TimeStamp time.Time `json:"timeStamp"`
+	// Source identifies the operator acknowledging.
+	Source string `json:"source"`
+
+	// stamp is the time stamp as received, sent instead of TimeStamp so that time stamps other than
+	// date-times are acknowledged as well.
+	stamp []byte
+}
+
+// AcknowledgeAlarm acknowledges a transition of an event state, stamped with the current time.
+func (c *BACnetClient) AcknowledgeAlarm(device DeviceInfo, ack AlarmAcknowledgment, opts ...CallOption) error {
+	ctx := callContext(opts...)
+	if send, err := c.authorize(ctx, Mutation{Service: SERVICE_CONFIRMED_ACKNOWLEDGE_ALARM, Device: device, Object: &ack.Object, Value: ack.State}); !send {
+		return err
+	}
+
+	var params bytes.Buffer
+	encoding.EncodeContextUnsigned(&params, 0, ack.ProcessID)
+	encoding.EncodeContextObjectID(&params, 1, uint32(ack.Object.Type), ack.Object.Instance)
+	encoding.EncodeContextEnumerated(&params, 2, uint32(ack.State))
+	encoding.EncodeOpeningTag(&params, 3)
+	if ack.stamp != nil {
+		params.Write(ack.stamp)
+	} else {
+		encodeDateTimeStamp(&params, ack.TimeStamp)
+	}
+	encoding.EncodeClosingTag(&params, 3)
+	encoding.EncodeContextCharacterString(&params, 4, ack.Source)
+	encoding.EncodeOpeningTag(&params, 5)
+	encodeDateTimeStamp(&params, time.Now())
+	encoding.EncodeClosingTag(&params, 5)
+	return c.controlRequest(ctx, device, SERVICE_CONFIRMED_ACKNOWLEDGE_ALARM, params.Bytes())
+}
+
+// encodeDateTimeStamp encodes t as the date-time choice of a BACnetTimeStamp.
+func encodeDateTimeStamp(buf *bytes.Buffer, t time.Time) {
+	encoding.EncodeOpeningTag(buf, 2)
+	encodeApplicationValue(buf, NewDateTime(t))
+	encoding.EncodeClosingTag(buf, 2)
+}
@@ -0,0 +1,216 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// EventListenerOptions configures an EventListener.
+type EventListenerOptions struct {
+	// BufferSize is the capacity of the notification channel.
+	BufferSize int `json:"bufferSize,omitempty"`
+	// ProcessIDs, if set, are the recipient process identifiers listened to, e.g. the one the
+	// client is entered with in the Recipient_List of the notification classes.
+	ProcessIDs []uint32 `json:"processIds,omitempty"`
+}
+
+// EventListener receives the event notifications, alarms, events and acknowledgments, that devices
+// send to the client, typically because it is a recipient of their Notification Class objects.
+// Confirmed notifications are acknowledged with a Simple-ACK as soon as they are decoded.
+type EventListener struct {
+	options EventListenerOptions
+	// inbox is where the dispatcher hands notifications; the listener forwards them to out.
+	inbox  chan EventNotification
+	out    chan EventNotification
+	done   chan struct{}
+	failed chan error
+	cancel context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// ListenEvents starts listening for event notifications until ctx is cancelled, Close is called
+// or the client is closed. Notifications are read by the receive loop of the client, shared with
+// requests and COV subscriptions. While the channel is full, further notifications are held back,
+// as with BackpressureBlock.
+func (c *BACnetClient) ListenEvents(ctx context.Context, options EventListenerOptions) *EventListener {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.closed, cancel)
+	l := &EventListener{
+		options: options,
+		inbox:   make(chan EventNotification, covInboxSize),
+		out:     make(chan EventNotification, options.BufferSize),
+		done:    make(chan struct{}),
+		failed:  make(chan error, 1),
+		cancel:  cancel,
+	}
+	c.registerEvents(l)
+
+	go func() {
+		defer close(l.out)
+		defer stop()
+		defer cancel()
+		defer c.unregisterEvents(l)
+		defer close(l.done)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-l.failed:
+				l.mu.Lock()
+				l.err = err
+				l.mu.Unlock()
+				return
+			case n := <-l.inbox:
+				select {
+				case l.out <- n:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return l
+}
+
+// Notifications returns the channel of received notifications, which is closed when the listener
+// ends.
+func (l *EventListener) Notifications() <-chan EventNotification {
+	return l.out
+}
+
+// Err returns the error that ended the listener once the notification channel is closed, or nil
+// if it was closed or its context cancelled.
+func (l *EventListener) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Close stops listening.
+func (l *EventListener) Close() {
+	l.cancel()
+}
+
+// accepts reports whether n is for the listener.
+func (l *EventListener) accepts(n EventNotification) bool {
+	return len(l.options.ProcessIDs) == 0 || slices.Contains(l.options.ProcessIDs, n.ProcessID)
+}
+
+// UnknownEventValues are the event values of an event type whose values are not decoded: the
+// encoded content of the event type's tag.
+type UnknownEventValues struct {
+	EventType EventType `json:"eventType"`
+	Data      []byte    `json:"data"`
+}
+
+func (v UnknownEventValues) encodeEventValues(buf *bytes.Buffer) {
+	encoding.EncodeOpeningTag(buf, uint8(v.EventType))
+	buf.Write(v.Data)
+	encoding.EncodeClosingTag(buf, uint8(v.EventType))
+}
+
+// decodeEventValues decodes the BACnetNotificationParameters following the opening tag 12 of an
+// event notification, up to and including the closing tag 12.
+func decodeEventValues(r *bytes.Reader) (EventValues, error) {
+	tag, err := encoding.DecodeTagHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if !tag.Opening {
+		return nil, fmt.Errorf("expected event values, got tag %d", tag.Number)
+	}
+	start := r.Size() - int64(r.Len())
+	if err := encoding.SkipValue(r, tag); err != nil {
+		return nil, err
+	}
+	// The content, without the closing tag SkipValue consumed
+	closingLength := int64(1)
+	if tag.Number > 14 {
+		closingLength = 2 // Extended tag number
+	}
+	content := make([]byte, r.Size()-int64(r.Len())-start-closingLength)
+	r.ReadAt(content, start)
+	if err := encoding.ExpectClosingTag(r, 12); err != nil {
+		return nil, err
+	}
+
+	eventType := EventType(tag.Number)
+	var values EventValues
+	switch eventType {
+	case EventChangeOfState:
+		values, err = decodeChangeOfStateValues(bytes.NewReader(content))
+	case EventOutOfRange:
+		values, err = decodeOutOfRangeValues(bytes.NewReader(content))
+	default:
+		return UnknownEventValues{EventType: eventType, Data: content}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s event values: %w", eventType, err)
+	}
+	return values, nil
+}
+
+func decodeChangeOfStateValues(r *bytes.Reader) (ChangeOfStateValues, error) {
+	var v ChangeOfStateValues
+	if err := encoding.ExpectOpeningTag(r, 0); err != nil {
+		return v, err
+	}
+	tag, err := encoding.DecodeTagHeader(r)
+	if err != nil {
+		return v, err
+	}
+	if !tag.Context || tag.Opening || tag.Closing {
+		return v, fmt.Errorf("expected new state, got tag %d", tag.Number)
+	}
+	state, err := encoding.DecodeUnsigned(r, tag.Length)
+	if err != nil {
+		return v, err
+	}
+	switch tag.Number {
+	case 0:
+		v.NewState = state != 0
+	case 1:
+		v.NewState = BinaryPV(state)
+	default:
+		v.NewState = state
+	}
+	if err := encoding.ExpectClosingTag(r, 0); err != nil {
+		return v, err
+	}
+	v.StatusFlags, err = decodeContextStatusFlags(r, 1)
+	return v, err
+}
+
+func decodeOutOfRangeValues(r *bytes.Reader) (OutOfRangeValues, error) {
+	var v OutOfRangeValues
+	var err error
+	if v.ExceedingValue, err = encoding.DecodeContextReal(r, 0); err != nil {
+		return v, err
+	}
+	if v.StatusFlags, err = decodeContextStatusFlags(r, 1); err != nil {
+		return v, err
+	}
+	if v.Deadband, err = encoding.DecodeContextReal(r, 2); err != nil {
+		return v, err
+	}
+	v.ExceededLimit, err = encoding.DecodeContextReal(r, 3)
+	return v, err
+}
+
+// decodeContextStatusFlags decodes Status_Flags written as a context-tagged bit string.
+func decodeContextStatusFlags(r *bytes.Reader, tagNumber uint8) (StatusFlags, error) {
+	bits, err := decodeContextBits(r, tagNumber)
+	if err != nil {
+		return StatusFlags{}, err
+	}
+	bits = append(bits, false, false, false, false)
+	return StatusFlags{InAlarm: bits[0], Fault: bits[1], Overridden: bits[2], OutOfService: bits[3]}, nil
+}
@@ -0,0 +1,215 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Role is the part a BACnetClient plays in a redundant client pair.
+type Role int
+
+const (
+	// RoleActive clients issue writes and own COV subscriptions. A client that never
+	// joined a redundant pair is always active.
+	RoleActive Role = iota
+	// RoleStandby clients keep reading but hold back writes and subscriptions until promoted.
+	RoleStandby
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleActive:
+		return "active"
+	case RoleStandby:
+		return "standby"
+	default:
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+}
+
+// LeaseCoordinator is the shared medium two redundant clients use to agree on which of them is active.
+// Implementations usually sit on a database row, a key in a distributed store or a file on shared
+// storage; the only requirement is that at most one node holds the lease at any time.
+type LeaseCoordinator interface {
+	// Acquire obtains or renews the lease for nodeID for the duration of ttl.
+	// It reports whether nodeID holds the lease after the call.
+	Acquire(ctx context.Context, nodeID string, ttl time.Duration) (bool, error)
+	// Release gives up the lease if nodeID currently holds it.
+	Release(ctx context.Context, nodeID string) error
+}
+
+// FailoverOptions configures the active/standby behaviour of a client.
+type FailoverOptions struct {
+	// NodeID identifies this client towards the LeaseCoordinator. It must differ between the pair.
+	NodeID string
+	// LeaseTTL is how long a lease stays valid without renewal. The lease is renewed every
+	// LeaseTTL/3, so a standby takes over at most LeaseTTL after the active node disappears.
+	// Defaults to 10 seconds.
+	LeaseTTL time.Duration
+	// OnRoleChange, if set, is called after every promotion or demotion.
+	OnRoleChange func(Role)
+}
+
+// failoverState tracks the current role of a client and wakes up waiters whenever it changes.
+type failoverState struct {
+	mu      sync.Mutex
+	role    Role
+	running bool
+	changed chan struct{} // closed and replaced on every role change
+}
+
+func newFailoverState() *failoverState {
+	return &failoverState{role: RoleActive, changed: make(chan struct{})}
+}
+
+func (f *failoverState) current() Role {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.role
+}
+
+// set updates the role and reports whether it actually changed.
+func (f *failoverState) set(role Role) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.role == role {
+		return false
+	}
+	f.role = role
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return true
+}
+
+// whileActive blocks until the client is active and returns a context that is cancelled as soon as
+// the client is demoted again (or ctx ends).
+func (f *failoverState) whileActive(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	for {
+		f.mu.Lock()
+		role, changed := f.role, f.changed
+		f.mu.Unlock()
+
+		if role == RoleActive {
+			activeCtx, cancel := context.WithCancel(ctx)
+			go func() {
+				select {
+				case <-changed:
+					cancel()
+				case <-activeCtx.Done():
+				}
+			}()
+			return activeCtx, cancel, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// Role returns the current failover role of the client.
+func (c *BACnetClient) Role() Role {
+	return c.failover.current()
+}
+
+// StartFailover makes the client one half of an active/standby pair coordinated through coordinator.
+// The client starts out as standby and is promoted once it holds the lease. While standby, COV
+// subscriptions are held back and established automatically on promotion; on demotion they are
+// cancelled on the devices, so that only the active node holds and renews them.
+//
+// Failover runs until ctx is cancelled, after which the lease is released so the peer can take
+// over immediately and the client stays in standby.
+func (c *BACnetClient) StartFailover(ctx context.Context, coordinator LeaseCoordinator, options FailoverOptions) error {
+	if options.NodeID == "" {
+		return fmt.Errorf("failover requires a NodeID")
+	}
+	if options.LeaseTTL <= 0 {
+		options.LeaseTTL = 10 * time.Second
+	}
+
+	c.failover.mu.Lock()
+	if c.failover.running {
+		c.failover.mu.Unlock()
+		return fmt.Errorf("failover already started")
+	}
+	c.failover.running = true
+	c.failover.mu.Unlock()
+
+	c.setRole(RoleStandby, options)
+
+	go func() {
+		renewInterval := options.LeaseTTL / 3
+		ticker := time.NewTicker(renewInterval)
+		defer ticker.Stop()
+
+		var lastRenewal time.Time
+		for {
+			held, err := coordinator.Acquire(ctx, options.NodeID, options.LeaseTTL)
+			switch {
+			case err == nil && held:
+				lastRenewal = time.Now()
+				c.setRole(RoleActive, options)
+			case err == nil:
+				c.setRole(RoleStandby, options)
+			case time.Since(lastRenewal) >= options.LeaseTTL:
+				// The coordinator is unreachable and our lease has run out; the peer may already be active.
+				c.setRole(RoleStandby, options)
+			}
+
+			select {
+			case <-ctx.Done():
+				c.setRole(RoleStandby, options)
+				releaseCtx, cancel := context.WithTimeout(context.Background(), c.options.Timeout)
+				coordinator.Release(releaseCtx, options.NodeID)
+				cancel()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (c *BACnetClient) setRole(role Role, options FailoverOptions) {
+	if c.failover.set(role) && options.OnRoleChange != nil {
+		options.OnRoleChange(role)
+	}
+}
+
+// MemoryLease is an in-process LeaseCoordinator. It is useful for running both halves of a pair in
+// one process (tests, demos) and as a reference for implementations backed by shared storage.
+type MemoryLease struct {
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+}
+
+// Acquire implements LeaseCoordinator.
+func (l *MemoryLease) Acquire(ctx context.Context, nodeID string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.holder == "" || l.holder == nodeID || now.After(l.expires) {
+		l.holder = nodeID
+		l.expires = now.Add(ttl)
+		return true, nil
+	}
+	return false, nil
+}
+
+// Release implements LeaseCoordinator.
+func (l *MemoryLease) Release(ctx context.Context, nodeID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.holder == nodeID {
+		l.holder = ""
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// switchLease is a LeaseCoordinator the test grants and takes away.
+type switchLease struct {
+	held atomic.Bool
+}
+
+func (l *switchLease) Acquire(context.Context, string, time.Duration) (bool, error) {
+	return l.held.Load(), nil
+}
+
+func (l *switchLease) Release(context.Context, string) error { return nil }
+
+// covNotificationPacket returns an unconfirmed COV notification of the present value of object.
+func covNotificationPacket(deviceID, processID uint32, object BACnetObject, value float32) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{APDU_UNCONFIRMED_REQUEST, SERVICE_UNCONFIRMED_COV_NOTIFICATION})
+	encoding.EncodeContextUnsigned(&buf, 0, processID)
+	encoding.EncodeContextObjectID(&buf, 1, uint32(OBJECT_DEVICE), deviceID)
+	encoding.EncodeContextObjectID(&buf, 2, uint32(object.Type), object.Instance)
+	encoding.EncodeContextUnsigned(&buf, 3, 60)
+	encoding.EncodeOpeningTag(&buf, 4)
+	encoding.EncodeContextUnsigned(&buf, 0, PROP_PRESENT_VALUE)
+	encoding.EncodeOpeningTag(&buf, 2)
+	encodeApplicationValue(&buf, value)
+	encoding.EncodeClosingTag(&buf, 2)
+	encoding.EncodeClosingTag(&buf, 4)
+	return serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, npduHeader{}, buf.Bytes())
+}
+
+func TestDemotionCancelsSubscriptions(t *testing.T) {
+	sim, device := startSimulator(t, SimulatorOptions{DeviceID: 3007, AnalogValues: 1})
+	client := newLoopbackClient(t, ClientOptions{})
+
+	lease := &switchLease{}
+	lease.held.Store(true)
+	roles := make(chan Role, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := client.StartFailover(ctx, lease, FailoverOptions{NodeID: "a", LeaseTTL: 30 * time.Millisecond, OnRoleChange: func(r Role) { roles <- r }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitRole := func(want Role) {
+		t.Helper()
+		for {
+			select {
+			case r := <-roles:
+				if r == want {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("client did not become %s", want)
+			}
+		}
+	}
+	waitSubscriptions := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for sim.Server.SubscriptionCount() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("device holds %d subscriptions, want %d", sim.Server.SubscriptionCount(), want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitRole(RoleActive)
+
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+	covChan, errChan := client.SubscribeCOV(ctx, device, av1, 1, false, 60)
+	waitSubscriptions(1)
+	select {
+	case <-covChan: // Initial notification
+	case <-time.After(2 * time.Second):
+		t.Fatal("no initial notification")
+	}
+
+	lease.held.Store(false)
+	waitRole(RoleStandby)
+	waitSubscriptions(0)
+
+	// A device that did not take the cancellation goes on notifying the standby, more often than
+	// the inbox of the subscription and the notification queue hold.
+	device2, err := net.ListenUDP("udp4", loopback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device2.Close()
+	stale := covNotificationPacket(3007, 1, av1, 999)
+	for range covInboxSize + notificationQueueSize {
+		device2.WriteTo(stale, client.conn.LocalAddr())
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	lease.held.Store(true)
+	waitRole(RoleActive)
+	waitSubscriptions(1)
+
+	// The first notification after the promotion is the device's answer to the new subscription,
+	// not one received in standby.
+	select {
+	case n := <-covChan:
+		for _, v := range n.ListOfValues {
+			if v.PropertyID == PROP_PRESENT_VALUE && v.Value == float32(999) {
+				t.Fatal("notification received in standby delivered after the promotion")
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification after the promotion: dispatch is stalled")
+	}
+
+	select {
+	case err := <-errChan:
+		t.Fatalf("subscription failed: %v", err)
+	default:
+	}
+	cancel()
+	for range covChan {
+	}
+	waitSubscriptions(0)
+}
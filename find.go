@@ -0,0 +1,81 @@
+package bacnet
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// findObjectsBatchSize is the number of objects whose names are read per ReadPropertyMultiple request.
+// It keeps responses well below the 1476 byte APDU limit of typical BACnet/IP devices.
+const findObjectsBatchSize = 16
+
+// NamedObject is an object together with its Object_Name.
+type NamedObject struct {
+	Object BACnetObject
+	Name   string
+}
+
+// FindObjects returns the objects on a device whose Object_Name matches a glob pattern.
+// In the pattern, '*' matches any sequence of characters (including '/') and '?' matches a
+// single character; matching is case-sensitive. Results are in object-list order.
+func (c *BACnetClient) FindObjects(device DeviceInfo, namePattern string, opts ...CallOption) ([]NamedObject, error) {
+	re, err := globToRegexp(namePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name pattern %q: %w", namePattern, err)
+	}
+	return c.FindObjectsRegexp(device, re, opts...)
+}
+
+// FindObjectsRegexp returns the objects on a device whose Object_Name matches re.
+// Object names are read in bulk with ReadPropertyMultiple. Results are in object-list order.
+func (c *BACnetClient) FindObjectsRegexp(device DeviceInfo, re *regexp.Regexp, opts ...CallOption) ([]NamedObject, error) {
+	objects, err := c.GetObjectList(device, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object list: %w", err)
+	}
+
+	var matches []NamedObject
+	for start := 0; start < len(objects); start += findObjectsBatchSize {
+		end := min(start+findObjectsBatchSize, len(objects))
+		batch := objects[start:end]
+
+		results, err := c.ReadPropertiesFromMultipleObjects(device, batch, uint32(PROP_OBJECT_NAME), opts...)
+		var partial *PartialError
+		if err != nil && !errors.As(err, &partial) { // Objects whose name failed are skipped
+			return nil, fmt.Errorf("failed to read object names: %w", err)
+		}
+
+		for _, object := range batch {
+			props, ok := results[object].(map[uint32]interface{})
+			if !ok {
+				continue
+			}
+			name, ok := props[uint32(PROP_OBJECT_NAME)].(string)
+			if ok && re.MatchString(name) {
+				matches = append(matches, NamedObject{Object: object, Name: name})
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// globToRegexp converts a glob pattern using '*' and '?' into an anchored regular expression.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
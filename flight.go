@@ -0,0 +1,73 @@
+package bacnet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// readKey identifies a single property read. Reads are only coalesced if their CallOptions give them
+// the same timeout, retries and retry backoff.
+type readKey struct {
+	deviceID   uint32
+	object     BACnetObject
+	propertyID uint32
+	arrayIndex uint32
+	hasIndex   bool
+	timeout    time.Duration
+	retries    int
+	backoff    time.Duration
+}
+
+// flightGroup coalesces concurrent reads of the same property into one request.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[readKey]*flightCall
+}
+
+type flightCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+	// abandoned is set if the call failed because the context of the caller making it ended.
+	abandoned bool
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: make(map[readKey]*flightCall)}
+}
+
+// do calls fn with ctx unless a call for key is already in flight, in which case it waits for that
+// call, or until ctx is done, and returns its result. If the call in flight fails because the
+// context of its caller ended, a waiter whose own context goes on makes the call again. The
+// returned bytes are shared between callers and must not be modified.
+func (g *flightGroup) do(ctx context.Context, key readKey, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	for {
+		g.mu.Lock()
+		call, ok := g.calls[key]
+		if !ok {
+			break // Still holding g.mu
+		}
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+			if !call.abandoned {
+				return call.value, call.err
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.value, call.err = fn(ctx)
+	call.abandoned = call.err != nil && ctx.Err() != nil
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+	return call.value, call.err
+}
@@ -0,0 +1,85 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// TestCoalescedReadOutlivesFirstCaller checks that a read waiting for an identical read in flight
+// is not failed by the first caller cancelling, but makes the request itself.
+func TestCoalescedReadOutlivesFirstCaller(t *testing.T) {
+	dev, device, requests := fakeDevice(t, 3008)
+	client := newLoopbackClient(t, ClientOptions{Timeout: 2 * time.Second})
+
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := client.ReadProperty(device, av1, PROP_PRESENT_VALUE, nil, CallContext(first))
+		firstErr <- err
+	}()
+	receiveAPDU(t, requests) // Not answered
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	second := make(chan result, 1)
+	go func() {
+		value, err := client.ReadProperty(device, av1, PROP_PRESENT_VALUE, nil)
+		second <- result{value, err}
+	}()
+	time.Sleep(50 * time.Millisecond) // Let the second read join the first
+	select {
+	case r := <-requests:
+		t.Fatalf("second read sent its own request %x", r)
+	default:
+	}
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("first read: %v, want context.Canceled", err)
+	}
+
+	// The second read asks again, and gets the answer.
+	apdu := receiveAPDU(t, requests)
+	var ack bytes.Buffer
+	ack.Write([]byte{APDU_COMPLEX_ACK, apdu[2], SERVICE_CONFIRMED_READ_PROPERTY})
+	encoding.EncodeContextObjectID(&ack, 0, uint32(av1.Type), av1.Instance)
+	encoding.EncodeContextUnsigned(&ack, 1, PROP_PRESENT_VALUE)
+	encoding.EncodeOpeningTag(&ack, 3)
+	encodeApplicationValue(&ack, float32(7))
+	encoding.EncodeClosingTag(&ack, 3)
+	dev.WriteTo(serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, npduHeader{}, ack.Bytes()), client.conn.LocalAddr())
+
+	select {
+	case res := <-second:
+		if res.err != nil || res.value != float32(7) {
+			t.Errorf("second read: %v, %v", res.value, res.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("second read did not finish")
+	}
+}
+
+func TestReadsWithOtherTimeoutsNotCoalesced(t *testing.T) {
+	client := NewClientWithConn(nil, ClientOptions{Timeout: time.Second})
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+	key := func(opts ...CallOption) readKey {
+		return client.readKey(callContext(opts...), DeviceInfo{DeviceID: 1}, av1, PROP_PRESENT_VALUE, nil)
+	}
+	if key() != key(CallTimeout(time.Second)) {
+		t.Error("explicit default timeout changes the key")
+	}
+	if key() == key(CallTimeout(time.Minute)) || key() == key(CallRetries(3)) {
+		t.Error("reads with other timeouts or retries share a key")
+	}
+	if key() != key(CallStats(&CallInfo{})) {
+		t.Error("CallStats changes the key")
+	}
+}
@@ -0,0 +1,44 @@
+package bacnet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatDevice returns a one-line description of a device, e.g. "Device 1234 at 10.0.0.5:47808".
+func FormatDevice(device DeviceInfo) string {
+	return fmt.Sprintf("Device %d at %s:%d", device.DeviceID, device.IPAddress, device.Port)
+}
+
+// FormatProperties returns one line per property in the form "ObjectName (77): value", each line
+// prefixed with indent. Enumerated values of known properties are shown with their name.
+func FormatProperties(properties []BACnetPropertyValue, indent string) string {
+	var b strings.Builder
+	for _, prop := range properties {
+		name, ok := PropertyName(prop.PropertyID)
+		if !ok {
+			name = "Unknown"
+		}
+		fmt.Fprintf(&b, "%s%s (%d): %s\n", indent, name, prop.PropertyID, FormatValue(prop.PropertyID, prop.Value))
+	}
+	return b.String()
+}
+
+// FormatValue formats a decoded property value for display.
+func FormatValue(propertyID uint32, value interface{}) string {
+	switch v := value.(type) {
+	case uint32:
+		if name, ok := EnumerationName(propertyID, v); ok {
+			return name
+		}
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []interface{}:
+		elements := make([]string, len(v))
+		for i, element := range v {
+			elements[i] = FormatValue(propertyID, element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,154 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// FrameDirection tells whether a Frame was sent or received by the client.
+type FrameDirection int
+
+const (
+	FrameSent FrameDirection = iota
+	FrameReceived
+)
+
+func (d FrameDirection) String() string {
+	if d == FrameReceived {
+		return "received"
+	}
+	return "sent"
+}
+
+// Frame is a datagram a client exchanged in a confirmed transaction: requests, retransmissions,
+// Segment-ACKs and the responses accepted for the transaction. Frames are passed to
+// ClientOptions.OnFrame and to the callback of CallFrames, e.g. for audit logs of exactly what was
+// sent to a device. HandleUnmatched passes the datagrams received outside transactions as Frames
+// too.
+type Frame struct {
+	Time      time.Time
+	Direction FrameDirection
+	// Peer is the device the frame was sent to or received from.
+	Peer *net.UDPAddr
+	// Data is a copy of the datagram, BVLC header included.
+	Data []byte
+	// Packet is the decoded datagram; it is zero if Data could not be decoded.
+	Packet Packet
+}
+
+// Packet holds the decoded BVLC, NPDU and APDU headers of a BACnet/IP datagram.
+type Packet struct {
+	BVLCFunction byte
+	// ForwardedFrom is the original source of a Forwarded-NPDU.
+	ForwardedFrom *net.UDPAddr
+
+	NPDUControl byte
+	// DNET/DADR and SNET/SADR are the destination and source network addresses, if present.
+	DNET     uint16
+	DADR     []byte
+	SNET     uint16
+	SADR     []byte
+	HopCount byte
+	// NetworkMessage is set for network layer messages, which have MessageType and no APDU fields.
+	NetworkMessage bool
+	MessageType    byte
+
+	// PDUType is the APDU type, e.g. APDU_CONFIRMED_REQUEST.
+	PDUType byte
+	// Segmented is set for segments of a segmented request or Complex-ACK.
+	Segmented bool
+	// InvokeID is the invoke ID of all APDU types but unconfirmed requests.
+	InvokeID byte
+	// Service is the service choice of requests, Simple-ACKs, Complex-ACKs and Errors.
+	Service byte
+	// Reason is the reason of a Reject or Abort, a RejectReason or AbortReason.
+	Reason byte
+	// APDU is the whole APDU, including its header.
+	APDU []byte
+}
+
+// DecodePacket decodes the headers of a BACnet/IP datagram. The returned Packet refers to data.
+func DecodePacket(data []byte) (Packet, error) {
+	frame, err := decodeBVLC(data)
+	if err != nil {
+		return Packet{}, err
+	}
+	p := Packet{BVLCFunction: frame.function, ForwardedFrom: frame.forwardedFrom}
+	if frame.function != BVLC_ORIGINAL_UNICAST_NPDU && frame.function != BVLC_ORIGINAL_BROADCAST_NPDU && frame.function != BVLC_FORWARDED_NPDU {
+		return p, nil // BVLC-Result and BBMD management carry no NPDU
+	}
+
+	header, apdu, err := decodeNPDU(frame.npdu)
+	if err != nil {
+		return Packet{}, err
+	}
+	p.NPDUControl = header.control
+	p.DNET, p.DADR = header.dnet, header.dadr
+	p.SNET, p.SADR = header.snet, header.sadr
+	p.HopCount = header.hopCount
+	if header.isNetworkMessage() {
+		p.NetworkMessage, p.MessageType = true, header.messageType
+		return p, nil
+	}
+
+	if len(apdu) < 2 {
+		return Packet{}, fmt.Errorf("APDU too short: %d bytes", len(apdu))
+	}
+	p.APDU = apdu
+	p.PDUType = apdu[0] & 0xF0
+	segmented := apdu[0]&0x08 != 0
+	// at returns the byte at i, or 0 if the APDU is truncated.
+	at := func(i int) byte {
+		if i < len(apdu) {
+			return apdu[i]
+		}
+		return 0
+	}
+	switch p.PDUType {
+	case APDU_CONFIRMED_REQUEST:
+		p.InvokeID, p.Segmented = at(2), segmented
+		p.Service = at(3)
+		if segmented {
+			p.Service = at(5)
+		}
+	case APDU_UNCONFIRMED_REQUEST:
+		p.Service = apdu[1]
+	case APDU_COMPLEX_ACK:
+		p.InvokeID, p.Segmented = apdu[1], segmented
+		p.Service = at(2)
+		if segmented {
+			p.Service = at(4)
+		}
+	case APDU_SIMPLE_ACK, APDU_ERROR:
+		p.InvokeID, p.Service = apdu[1], at(2)
+	case APDU_SEGMENT_ACK:
+		p.InvokeID = apdu[1]
+	case APDU_REJECT, APDU_ABORT:
+		p.InvokeID, p.Reason = apdu[1], at(2)
+	}
+	return p, nil
+}
+
+// CallFrames passes the frames of one request to fn, in addition to ClientOptions.OnFrame. A read
+// coalesced with an identical read already in flight sends no frames of its own.
+func CallFrames(fn func(Frame)) CallOption {
+	return func(o *callOptions) { o.frames = fn }
+}
+
+// tapFrame hands a datagram to the frame callbacks of the client and of ctx, if any.
+func (c *BACnetClient) tapFrame(ctx context.Context, direction FrameDirection, peer *net.UDPAddr, data []byte) {
+	call, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	if c.options.OnFrame == nil && call.frames == nil {
+		return
+	}
+	frame := Frame{Time: time.Now(), Direction: direction, Peer: peer, Data: append([]byte(nil), data...)}
+	frame.Packet, _ = DecodePacket(frame.Data)
+	if c.options.OnFrame != nil {
+		c.options.OnFrame(frame)
+	}
+	if call.frames != nil {
+		call.frames(frame)
+	}
+}
@@ -0,0 +1,108 @@
+package bacnet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// PropertyReference is a property of an object, optionally a single array element.
+type PropertyReference struct {
+	PropertyID uint32  `json:"propertyId"`
+	ArrayIndex *uint32 `json:"arrayIndex,omitempty"`
+}
+
+// ReadAccessSpecification lists properties of one object, as used in ReadPropertyMultiple requests
+// and the List_Of_Group_Members of a Group object.
+type ReadAccessSpecification struct {
+	Object     BACnetObject        `json:"object"`
+	Properties []PropertyReference `json:"properties"`
+}
+
+// ReadGroupMembers reads the List_Of_Group_Members of a Group object.
+func (c *BACnetClient) ReadGroupMembers(device DeviceInfo, group BACnetObject, opts ...CallOption) ([]ReadAccessSpecification, error) {
+	if group.Type != OBJECT_GROUP {
+		return nil, fmt.Errorf("%s is not a group", group)
+	}
+	raw, err := c.readPropertyRaw(callContext(opts...), device, group, PROP_LIST_OF_GROUP_MEMBERS, nil)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := decodeReadAccessSpecifications(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode group members of %s: %w", group, err)
+	}
+	return specs, nil
+}
+
+// ReadGroup reads the Present_Value of a Group object, which holds the current values of all group
+// members, with a single ReadProperty request. The result has one entry per member property; members
+// the device could not read have Err set.
+func (c *BACnetClient) ReadGroup(device DeviceInfo, group BACnetObject, opts ...CallOption) ([]RPMResult, error) {
+	if group.Type != OBJECT_GROUP {
+		return nil, fmt.Errorf("%s is not a group", group)
+	}
+	raw, err := c.readPropertyRaw(callContext(opts...), device, group, PROP_PRESENT_VALUE, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The present value is a list of ReadAccessResults, encoded like a ReadPropertyMultiple-ACK.
+	var results []RPMResult
+	decoder := NewRPMStreamDecoder(func(result RPMResult) bool {
+		results = append(results, result)
+		return true
+	})
+	if _, err := decoder.Write(raw); err != nil {
+		return nil, fmt.Errorf("failed to decode present value of %s: %w", group, err)
+	}
+	if err := decoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to decode present value of %s: %w", group, err)
+	}
+	return results, nil
+}
+
+// decodeReadAccessSpecifications decodes a list of ReadAccessSpecifications as returned by
+// readPropertyRaw.
+func decodeReadAccessSpecifications(raw []byte) ([]ReadAccessSpecification, error) {
+	r := bytes.NewReader(raw)
+	var specs []ReadAccessSpecification
+	for r.Len() > 0 {
+		objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		spec := ReadAccessSpecification{Object: BACnetObject{Type: ObjectType(objectType), Instance: instance}}
+		if err := encoding.ExpectOpeningTag(r, 1); err != nil {
+			return nil, err
+		}
+
+		for {
+			tag, err := encoding.DecodeTagHeader(r)
+			if err != nil {
+				return nil, err
+			}
+			if tag.IsClosing(1) {
+				break
+			}
+			if !tag.IsContext(0) {
+				return nil, fmt.Errorf("expected property identifier context tag 0, got tag %d", tag.Number)
+			}
+			var ref PropertyReference
+			if ref.PropertyID, err = encoding.DecodeUnsigned(r, tag.Length); err != nil {
+				return nil, err
+			}
+			if next, err := encoding.PeekTagHeader(r); err == nil && next.IsContext(1) {
+				index, err := encoding.DecodeContextUnsigned(r, 1)
+				if err != nil {
+					return nil, err
+				}
+				ref.ArrayIndex = &index
+			}
+			spec.Properties = append(spec.Properties, ref)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
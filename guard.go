@@ -0,0 +1,117 @@
+package bacnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ReadOnlyMode keeps a client from changing devices, e.g. to test integration logic against a live
+// network. Set it in ClientOptions.ReadOnly.
+type ReadOnlyMode uint32
+
+const (
+	// ReadWrite sends mutations as requested.
+	ReadWrite ReadOnlyMode = iota
+	// ReadOnlyReject fails every mutation with ErrReadOnly.
+	ReadOnlyReject
+	// ReadOnlyDryRun logs mutations to ClientOptions.Logger instead of sending them and reports
+	// them successful.
+	// CreateObject returns the object requested, with instance 4194303 if the device was to pick it.
+	ReadOnlyDryRun
+)
+
+var readOnlyModeNames = map[ReadOnlyMode]string{
+	ReadWrite:      "read-write",
+	ReadOnlyReject: "read-only",
+	ReadOnlyDryRun: "dry-run",
+}
+
+func (m ReadOnlyMode) String() string { return enumName(readOnlyModeNames, m) }
+
+// ErrReadOnly is returned by operations that change devices when ClientOptions.ReadOnly is
+// ReadOnlyReject.
+var ErrReadOnly = errors.New("client is read-only")
+
+// Mutation describes a request of a client that changes a device: WriteProperty, CreateObject,
+// DeleteObject, DeviceCommunicationControl, ReinitializeDevice, AcknowledgeAlarm or a generated
+// service marked as a mutation, such as LifeSafetyOperation. Every mutation is passed to
+// ClientOptions.Authorize before it is sent.
+type Mutation struct {
+	Service byte
+	Device  DeviceInfo
+	// User is ClientOptions.AuditUser, or CallUser for the call.
+	User string
+	// Object is the object written, deleted or acknowledged; for CreateObject it has the type
+	// created and the instance requested, which is 4194303 if the device picks it.
+	Object *BACnetObject
+	// PropertyID, ArrayIndex, Value and Priority are those of a WriteProperty request. Value is the
+	// CommunicationControl of a DeviceCommunicationControl, the ReinitializeState of a
+	// ReinitializeDevice, the acknowledged EventState of an AcknowledgeAlarm request and the request
+	// struct of a generated service, e.g. *LifeSafetyOperationRequest.
+	PropertyID *uint32
+	ArrayIndex *uint32
+	Value      interface{}
+	Priority   uint8
+}
+
+// ServiceName returns the name of the service, e.g. "WriteProperty".
+func (m Mutation) ServiceName() string {
+	return TransactionInfo{Service: m.Service}.ServiceName()
+}
+
+// callUser returns the user requests made with ctx are made on behalf of.
+func (c *BACnetClient) callUser(ctx context.Context) string {
+	if call, ok := ctx.Value(callOptionsKey{}).(callOptions); ok && call.user != nil {
+		return *call.user
+	}
+	return c.options.AuditUser
+}
+
+// authorize checks a mutation before it is sent: a standby client sends none, and
+// ClientOptions.Authorize may refuse it. It reports whether to send the mutation, which is false
+// without an error for a dry run.
+func (c *BACnetClient) authorize(ctx context.Context, m Mutation) (send bool, err error) {
+	if c.failover.current() != RoleActive {
+		return false, ErrStandby
+	}
+	if c.options.Authorize != nil {
+		m.User = c.callUser(ctx)
+		if err := c.options.Authorize(ctx, m); err != nil {
+			return false, fmt.Errorf("%s not authorized: %w", m.ServiceName(), err)
+		}
+	}
+	switch c.options.ReadOnly {
+	case ReadOnlyReject:
+		return false, fmt.Errorf("%s of device %d: %w", m.ServiceName(), m.Device.DeviceID, ErrReadOnly)
+	case ReadOnlyDryRun:
+		c.logf("bacnet: dry run, not sending %s", m)
+		return false, nil
+	}
+	return true, nil
+}
+
+// String describes the mutation for logs, e.g. "WriteProperty PresentValue of AnalogValue:1 = 21.5
+// @8 on device 100".
+func (m Mutation) String() string {
+	s := m.ServiceName()
+	if m.PropertyID != nil {
+		s += " " + propertyLabel(*m.PropertyID)
+		if m.ArrayIndex != nil {
+			s += fmt.Sprintf("[%d]", *m.ArrayIndex)
+		}
+	}
+	if m.Object != nil {
+		if m.PropertyID != nil {
+			s += " of"
+		}
+		s += " " + m.Object.String()
+	}
+	if m.Value != nil {
+		s += fmt.Sprintf(" = %v", m.Value)
+		if m.Priority != 0 {
+			s += fmt.Sprintf(" @%d", m.Priority)
+		}
+	}
+	return s + fmt.Sprintf(" on device %d", m.Device.DeviceID)
+}
@@ -0,0 +1,216 @@
+package bacnet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// NodeType is the Node_Type of a Structured View.
+type NodeType uint32
+
+const (
+	NodeTypeUnknown        NodeType = 0
+	NodeTypeSystem         NodeType = 1
+	NodeTypeNetwork        NodeType = 2
+	NodeTypeDevice         NodeType = 3
+	NodeTypeOrganizational NodeType = 4
+	NodeTypeArea           NodeType = 5
+	NodeTypeEquipment      NodeType = 6
+	NodeTypePoint          NodeType = 7
+	NodeTypeCollection     NodeType = 8
+	NodeTypeProperty       NodeType = 9
+	NodeTypeFunctional     NodeType = 10
+	NodeTypeOther          NodeType = 11
+	NodeTypeSubsystem      NodeType = 12
+	NodeTypeBuilding       NodeType = 13
+	NodeTypeFloor          NodeType = 14
+	NodeTypeSection        NodeType = 15
+	NodeTypeModule         NodeType = 16
+	NodeTypeTree           NodeType = 17
+	NodeTypeMember         NodeType = 18
+	NodeTypeProtocol       NodeType = 19
+	NodeTypeRoom           NodeType = 20
+	NodeTypeZone           NodeType = 21
+)
+
+var nodeTypeNames = map[NodeType]string{
+	NodeTypeUnknown:        "unknown",
+	NodeTypeSystem:         "system",
+	NodeTypeNetwork:        "network",
+	NodeTypeDevice:         "device",
+	NodeTypeOrganizational: "organizational",
+	NodeTypeArea:           "area",
+	NodeTypeEquipment:      "equipment",
+	NodeTypePoint:          "point",
+	NodeTypeCollection:     "collection",
+	NodeTypeProperty:       "property",
+	NodeTypeFunctional:     "functional",
+	NodeTypeOther:          "other",
+	NodeTypeSubsystem:      "subsystem",
+	NodeTypeBuilding:       "building",
+	NodeTypeFloor:          "floor",
+	NodeTypeSection:        "section",
+	NodeTypeModule:         "module",
+	NodeTypeTree:           "tree",
+	NodeTypeMember:         "member",
+	NodeTypeProtocol:       "protocol",
+	NodeTypeRoom:           "room",
+	NodeTypeZone:           "zone",
+}
+
+func (t NodeType) String() string { return enumName(nodeTypeNames, t) }
+
+// DeviceObjectReference refers to an object, optionally on another device.
+type DeviceObjectReference struct {
+	// Device is the device holding the object. Nil means the device the reference was read from.
+	Device *BACnetObject `json:"device,omitempty"`
+	Object BACnetObject  `json:"object"`
+}
+
+// HierarchyNode is an object in a device's point hierarchy. Structured Views have children;
+// all other objects are leaves.
+type HierarchyNode struct {
+	Reference DeviceObjectReference `json:"reference"`
+	Name      string                `json:"name,omitempty"`
+	// NodeType is only set for Structured Views.
+	NodeType NodeType         `json:"nodeType,omitempty"`
+	Children []*HierarchyNode `json:"children,omitempty"`
+}
+
+// Hierarchy walks the Subordinate_List of every Structured View on a device and returns the
+// resulting trees. Roots are the views that are not a subordinate of another view. Objects on other
+// devices are included as leaves without a name; they are not followed. Objects that are not part
+// of any view are not included.
+func (c *BACnetClient) Hierarchy(device DeviceInfo, opts ...CallOption) ([]*HierarchyNode, error) {
+	objects, err := c.GetObjectList(device, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object list: %w", err)
+	}
+
+	subordinates := make(map[BACnetObject][]DeviceObjectReference)
+	var views []BACnetObject
+	for _, object := range objects {
+		if object.Type != OBJECT_STRUCTURED_VIEW {
+			continue
+		}
+		views = append(views, object)
+		raw, err := c.readPropertyRaw(callContext(opts...), device, object, PROP_SUBORDINATE_LIST, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subordinate list of %s: %w", object, err)
+		}
+		refs, err := decodeDeviceObjectReferences(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode subordinate list of %s: %w", object, err)
+		}
+		subordinates[object] = refs
+	}
+
+	// Read the names of all local objects in the hierarchy, and the node types of the views.
+	isChild := make(map[BACnetObject]bool)
+	local := append([]BACnetObject{}, views...)
+	seen := make(map[BACnetObject]bool)
+	for _, view := range views {
+		seen[view] = true
+	}
+	for _, view := range views {
+		for _, ref := range subordinates[view] {
+			if ref.Device != nil && ref.Device.Instance != device.DeviceID {
+				continue
+			}
+			isChild[ref.Object] = true
+			if !seen[ref.Object] {
+				seen[ref.Object] = true
+				local = append(local, ref.Object)
+			}
+		}
+	}
+
+	names := make(map[BACnetObject]string)
+	nodeTypes := make(map[BACnetObject]NodeType)
+	for start := 0; start < len(local); start += findObjectsBatchSize {
+		batch := local[start:min(start+findObjectsBatchSize, len(local))]
+		err := c.ReadPropertyMultipleStream(device, batch, []uint32{PROP_OBJECT_NAME, PROP_NODE_TYPE}, func(result RPMResult) bool {
+			switch result.PropertyID {
+			case PROP_OBJECT_NAME:
+				names[result.Object], _ = result.Value.(string)
+			case PROP_NODE_TYPE:
+				if v, ok := result.Value.(uint32); ok {
+					nodeTypes[result.Object] = NodeType(v)
+				}
+			}
+			return true
+		}, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read object names: %w", err)
+		}
+	}
+
+	var build func(ref DeviceObjectReference, path map[BACnetObject]bool) *HierarchyNode
+	build = func(ref DeviceObjectReference, path map[BACnetObject]bool) *HierarchyNode {
+		node := &HierarchyNode{Reference: ref}
+		if ref.Device != nil && ref.Device.Instance != device.DeviceID {
+			return node // Not followed
+		}
+		node.Name = names[ref.Object]
+		if ref.Object.Type != OBJECT_STRUCTURED_VIEW || path[ref.Object] {
+			return node // Leaf, or a view that contains itself
+		}
+		node.NodeType = nodeTypes[ref.Object]
+		path[ref.Object] = true
+		for _, child := range subordinates[ref.Object] {
+			node.Children = append(node.Children, build(child, path))
+		}
+		delete(path, ref.Object)
+		return node
+	}
+
+	var roots []*HierarchyNode
+	for _, view := range views {
+		if !isChild[view] {
+			roots = append(roots, build(DeviceObjectReference{Object: view}, make(map[BACnetObject]bool)))
+		}
+	}
+	return roots, nil
+}
+
+// Walk calls fn for n and all of its descendants, depth first. depth is 0 for n.
+func (n *HierarchyNode) Walk(fn func(node *HierarchyNode, depth int)) {
+	var walk func(node *HierarchyNode, depth int)
+	walk = func(node *HierarchyNode, depth int) {
+		fn(node, depth)
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(n, 0)
+}
+
+// decodeDeviceObjectReferences decodes a list of BACnetDeviceObjectReference as returned by
+// readPropertyRaw.
+func decodeDeviceObjectReferences(raw []byte) ([]DeviceObjectReference, error) {
+	r := bytes.NewReader(raw)
+	var refs []DeviceObjectReference
+	for r.Len() > 0 {
+		var ref DeviceObjectReference
+		tag, err := encoding.PeekTagHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if tag.IsContext(0) {
+			deviceType, deviceInstance, err := encoding.DecodeContextObjectID(r, 0)
+			if err != nil {
+				return nil, err
+			}
+			ref.Device = &BACnetObject{Type: ObjectType(deviceType), Instance: deviceInstance}
+		}
+		objectType, instance, err := encoding.DecodeContextObjectID(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		ref.Object = BACnetObject{Type: ObjectType(objectType), Instance: instance}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
@@ -0,0 +1,33 @@
+package bacnet
+
+// LifeSafetyOperation is an operation requested of a life safety object with the
+// LifeSafetyOperation service, e.g. silencing or resetting it.
+type LifeSafetyOperation uint32
+
+const (
+	LifeSafetyOpNone             LifeSafetyOperation = 0
+	LifeSafetyOpSilence          LifeSafetyOperation = 1
+	LifeSafetyOpSilenceAudible   LifeSafetyOperation = 2
+	LifeSafetyOpSilenceVisual    LifeSafetyOperation = 3
+	LifeSafetyOpReset            LifeSafetyOperation = 4
+	LifeSafetyOpResetAlarm       LifeSafetyOperation = 5
+	LifeSafetyOpResetFault       LifeSafetyOperation = 6
+	LifeSafetyOpUnsilence        LifeSafetyOperation = 7
+	LifeSafetyOpUnsilenceAudible LifeSafetyOperation = 8
+	LifeSafetyOpUnsilenceVisual  LifeSafetyOperation = 9
+)
+
+var lifeSafetyOperationNames = map[LifeSafetyOperation]string{
+	LifeSafetyOpNone:             "none",
+	LifeSafetyOpSilence:          "silence",
+	LifeSafetyOpSilenceAudible:   "silence-audible",
+	LifeSafetyOpSilenceVisual:    "silence-visual",
+	LifeSafetyOpReset:            "reset",
+	LifeSafetyOpResetAlarm:       "reset-alarm",
+	LifeSafetyOpResetFault:       "reset-fault",
+	LifeSafetyOpUnsilence:        "unsilence",
+	LifeSafetyOpUnsilenceAudible: "unsilence-audible",
+	LifeSafetyOpUnsilenceVisual:  "unsilence-visual",
+}
+
+func (o LifeSafetyOperation) String() string { return enumName(lifeSafetyOperationNames, o) }
@@ -0,0 +1,151 @@
+package bacnet
+
+import (
+	"fmt"
+	"math"
+)
+
+// RegisterReader reads count consecutive Modbus holding or input registers starting at address.
+// It is provided by the application, which owns the Modbus connection.
+type RegisterReader func(address uint16, count uint16) ([]uint16, error)
+
+// RegisterFormat is how a value is stored in Modbus registers.
+type RegisterFormat int
+
+const (
+	RegisterUint16 RegisterFormat = iota
+	RegisterInt16
+	// Two-register formats, high word first.
+	RegisterUint32
+	RegisterInt32
+	RegisterFloat32
+	// Two-register formats, low word first.
+	RegisterUint32Swapped
+	RegisterInt32Swapped
+	RegisterFloat32Swapped
+)
+
+// registerCount returns the number of registers a value of the format occupies.
+func (f RegisterFormat) registerCount() uint16 {
+	if f == RegisterUint16 || f == RegisterInt16 {
+		return 1
+	}
+	return 2
+}
+
+// decode converts raw registers to a number.
+func (f RegisterFormat) decode(regs []uint16) float64 {
+	var hi, lo uint16
+	if len(regs) == 2 {
+		hi, lo = regs[0], regs[1]
+		if f == RegisterUint32Swapped || f == RegisterInt32Swapped || f == RegisterFloat32Swapped {
+			hi, lo = lo, hi
+		}
+	}
+	word := uint32(hi)<<16 | uint32(lo)
+
+	switch f {
+	case RegisterInt16:
+		return float64(int16(regs[0]))
+	case RegisterUint32, RegisterUint32Swapped:
+		return float64(word)
+	case RegisterInt32, RegisterInt32Swapped:
+		return float64(int32(word))
+	case RegisterFloat32, RegisterFloat32Swapped:
+		return float64(math.Float32frombits(word))
+	}
+	return float64(regs[0])
+}
+
+// ModbusPoint maps Modbus registers to a BACnet Analog Input, Analog Value or Binary Value.
+type ModbusPoint struct {
+	Object  BACnetObject   `json:"object"`
+	Name    string         `json:"name"`
+	Address uint16         `json:"address"`
+	Format  RegisterFormat `json:"format"`
+	// Scale and Offset convert the register value v of analog points to Scale*v + Offset.
+	// A zero Scale is treated as 1.
+	Scale  float64 `json:"scale,omitempty"`
+	Offset float64 `json:"offset,omitempty"`
+	// Units is the BACnet engineering unit of analog points.
+	Units uint32 `json:"units,omitempty"`
+	// Bit selects the bit of the register that drives a Binary Value, 0 being the least significant.
+	Bit uint8 `json:"bit,omitempty"`
+}
+
+// ModbusMapping exposes Modbus registers as objects of a VirtualDevice. Every read of a Present_Value
+// reads the registers through the RegisterReader.
+type ModbusMapping struct {
+	read   RegisterReader
+	points []ModbusPoint
+}
+
+// NewModbusMapping validates the points and returns a mapping reading registers through read.
+func NewModbusMapping(read RegisterReader, points []ModbusPoint) (*ModbusMapping, error) {
+	seen := make(map[BACnetObject]bool)
+	for _, p := range points {
+		switch p.Object.Type {
+		case OBJECT_ANALOG_INPUT, OBJECT_ANALOG_VALUE:
+		case OBJECT_BINARY_VALUE:
+			if p.Format != RegisterUint16 && p.Format != RegisterInt16 {
+				return nil, fmt.Errorf("%s: binary points must use a single register", p.Object)
+			}
+			if p.Bit > 15 {
+				return nil, fmt.Errorf("%s: bit %d is out of range", p.Object, p.Bit)
+			}
+		default:
+			return nil, fmt.Errorf("%s: only analog inputs, analog values and binary values can be mapped", p.Object)
+		}
+		if seen[p.Object] {
+			return nil, fmt.Errorf("%s is mapped twice", p.Object)
+		}
+		seen[p.Object] = true
+	}
+	return &ModbusMapping{read: read, points: points}, nil
+}
+
+// AddTo adds an object for every point to device.
+func (m *ModbusMapping) AddTo(device *VirtualDevice) error {
+	for _, p := range m.points {
+		object := &VirtualObject{
+			Object:       p.Object,
+			Name:         p.Name,
+			Properties:   make(map[uint32]interface{}),
+			PresentValue: m.presentValue(p),
+		}
+		if p.Object.Type == OBJECT_BINARY_VALUE {
+			object.Properties[PROP_POLARITY] = PolarityNormal
+		} else {
+			object.Properties[PROP_UNITS] = Enumerated(p.Units)
+		}
+		if err := device.AddObject(object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// presentValue returns the Present_Value function of a point.
+func (m *ModbusMapping) presentValue(p ModbusPoint) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		regs, err := m.read(p.Address, p.Format.registerCount())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read register %d for %s: %w", p.Address, p.Object, err)
+		}
+		if len(regs) < int(p.Format.registerCount()) {
+			return nil, fmt.Errorf("short register read at %d for %s", p.Address, p.Object)
+		}
+
+		if p.Object.Type == OBJECT_BINARY_VALUE {
+			if regs[0]&(1<<p.Bit) != 0 {
+				return BinaryActive, nil
+			}
+			return BinaryInactive, nil
+		}
+		scale := p.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		return float32(p.Format.decode(regs)*scale + p.Offset), nil
+	}
+}
@@ -0,0 +1,34 @@
+package bacnet
+
+import "fmt"
+
+// MSTPSettings are the MS/TP data link settings a device exposes through its Device object.
+type MSTPSettings struct {
+	// MaxMaster is the highest MAC address the device polls for other masters.
+	MaxMaster uint32 `json:"maxMaster"`
+	// MaxInfoFrames is the number of frames the device may send per token.
+	MaxInfoFrames uint32 `json:"maxInfoFrames"`
+}
+
+// ReadMSTPSettings reads Max_Master and Max_Info_Frames of a device. The client addresses devices
+// by IP address only, without a destination network, so the device must be reachable over
+// BACnet/IP, e.g. an MS/TP master that also has a BACnet/IP port. Devices that are not MS/TP
+// masters do not have these properties; an error is returned for them.
+//
+// The client does not speak MS/TP, so data link statistics such as token losses and retries are
+// not available in ClientStats.
+func (c *BACnetClient) ReadMSTPSettings(device DeviceInfo, opts ...CallOption) (MSTPSettings, error) {
+	deviceObject := BACnetObject{Type: OBJECT_DEVICE, Instance: device.DeviceID}
+	results, err := c.ReadSpecificPropertiesFromObject(device, deviceObject, []uint32{PROP_MAX_MASTER, PROP_MAX_INFO_FRAMES}, opts...)
+	if err != nil {
+		return MSTPSettings{}, err
+	}
+	values := results.Map()
+
+	maxMaster, ok1 := values[PROP_MAX_MASTER].(uint32)
+	maxInfoFrames, ok2 := values[PROP_MAX_INFO_FRAMES].(uint32)
+	if !ok1 || !ok2 {
+		return MSTPSettings{}, fmt.Errorf("device %d has no MS/TP settings", device.DeviceID)
+	}
+	return MSTPSettings{MaxMaster: maxMaster, MaxInfoFrames: maxInfoFrames}, nil
+}
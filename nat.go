@@ -0,0 +1,89 @@
+package bacnet
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// NATMapping is a device behind NAT and the public address that reaches it, e.g. a port
+// forwarded by the router of a remote site.
+type NATMapping struct {
+	DeviceID uint32       `json:"deviceId"`
+	Public   *net.UDPAddr `json:"public"`
+	// Private is the address of the device on its own network, which the device and the BBMDs of
+	// its site put into I-Ams and the originating address of Forwarded-NPDUs; nil if unknown.
+	Private *net.UDPAddr `json:"private,omitempty"`
+}
+
+// NATTable is a static table of devices behind NAT. Set it in ClientOptions.NAT: the client then
+// sends the requests for these devices to their public addresses, whatever address the DeviceInfo
+// passed holds, and devices discovered at a private address of the table get its public address.
+type NATTable struct {
+	byDevice  map[uint32]NATMapping
+	byPrivate map[string]NATMapping
+}
+
+// NewNATTable returns a table of the given mappings. It fails if a device is mapped twice or a
+// mapping has no public address.
+func NewNATTable(mappings ...NATMapping) (*NATTable, error) {
+	t := &NATTable{byDevice: make(map[uint32]NATMapping), byPrivate: make(map[string]NATMapping)}
+	for _, m := range mappings {
+		if m.Public == nil {
+			return nil, fmt.Errorf("NAT mapping of device %d has no public address", m.DeviceID)
+		}
+		if _, ok := t.byDevice[m.DeviceID]; ok {
+			return nil, fmt.Errorf("device %d is mapped twice", m.DeviceID)
+		}
+		t.byDevice[m.DeviceID] = m
+		if m.Private != nil {
+			t.byPrivate[m.Private.String()] = m
+		}
+	}
+	return t, nil
+}
+
+// Device returns the binding of a mapped device, to contact it without a Who-Is, which does not
+// cross NAT.
+func (t *NATTable) Device(deviceID uint32) (DeviceInfo, bool) {
+	m, ok := t.byDevice[deviceID]
+	if !ok {
+		return DeviceInfo{}, false
+	}
+	return DeviceInfo{DeviceID: deviceID, IPAddress: m.Public.IP, Port: m.Public.Port}, true
+}
+
+// Mappings returns the mappings of the table ordered by device instance.
+func (t *NATTable) Mappings() []NATMapping {
+	mappings := make([]NATMapping, 0, len(t.byDevice))
+	for _, m := range t.byDevice {
+		mappings = append(mappings, m)
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].DeviceID < mappings[j].DeviceID })
+	return mappings
+}
+
+// deviceAddr returns the address requests to device are sent to.
+func (c *BACnetClient) deviceAddr(device DeviceInfo) *net.UDPAddr {
+	addr := &net.UDPAddr{IP: device.IPAddress, Port: device.Port}
+	if t := c.options.NAT; t != nil {
+		if m, ok := t.byDevice[device.DeviceID]; ok {
+			return m.Public
+		}
+		if m, ok := t.byPrivate[addr.String()]; ok {
+			return m.Public
+		}
+	}
+	return addr
+}
+
+// translateDevice replaces the private address of a discovered device by its public address if
+// the device or its address is in the NAT table.
+func (c *BACnetClient) translateDevice(device DeviceInfo) DeviceInfo {
+	if c.options.NAT == nil {
+		return device
+	}
+	public := c.deviceAddr(device)
+	device.IPAddress, device.Port = public.IP, public.Port
+	return device
+}
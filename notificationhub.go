@@ -0,0 +1,175 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// NotificationHubOptions configures a NotificationHub.
+type NotificationHubOptions struct {
+	// SubscriberProcessIdentifier, IssueConfirmedNotifications and Lifetime are used for every
+	// subscription of the hub.
+	SubscriberProcessIdentifier uint32 `json:"subscriberProcessIdentifier"`
+	IssueConfirmedNotifications bool   `json:"issueConfirmedNotifications"`
+	Lifetime                    uint32 `json:"lifetime"`
+	// COVOptions configures the channel of every subscription; BufferSize is also the capacity of
+	// the hub's channel.
+	COVOptions
+}
+
+// HubNotification is a COV notification delivered by a NotificationHub, with the point it is about.
+type HubNotification struct {
+	Point PointRef
+	COVNotification
+}
+
+// NotificationHub delivers the COV notifications of subscriptions across many devices on one
+// channel, keyed by device and object. The subscriptions share the client's receive loop and
+// notification dispatcher, so none of them reads the connection itself; the hub only adds and
+// removes them. Unlike a COVManager, every subscription uses the same subscriber process
+// identifier, and notifications carry their PointRef.
+type NotificationHub struct {
+	client        *BACnetClient
+	options       NotificationHubOptions
+	ctx           context.Context
+	cancel        context.CancelFunc
+	notifications chan HubNotification
+	errors        chan error
+
+	mu            sync.Mutex
+	subscriptions map[PointRef]*hubSubscription
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+}
+
+type hubSubscription struct {
+	cancel context.CancelFunc
+	// done is closed once the subscription has ended and its notifications are forwarded.
+	done chan struct{}
+}
+
+// NewNotificationHub returns a hub subscribing through client. All subscriptions end when ctx is
+// cancelled or Close is called.
+func NewNotificationHub(ctx context.Context, client *BACnetClient, options NotificationHubOptions) *NotificationHub {
+	ctx, cancel := context.WithCancel(ctx)
+	return &NotificationHub{
+		client:        client,
+		options:       options,
+		ctx:           ctx,
+		cancel:        cancel,
+		notifications: make(chan HubNotification, options.BufferSize),
+		errors:        make(chan error, 16),
+		subscriptions: make(map[PointRef]*hubSubscription),
+	}
+}
+
+// Notifications returns the channel the notifications of all subscriptions are delivered on. It is
+// closed by Close.
+func (h *NotificationHub) Notifications() <-chan HubNotification {
+	return h.notifications
+}
+
+// Errors returns the channel subscription errors are delivered on, as SubscriptionErrors. Errors
+// are dropped when the channel is full. It is closed by Close.
+func (h *NotificationHub) Errors() <-chan error {
+	return h.errors
+}
+
+// Subscribe subscribes to object on device. It fails if the hub already holds a subscription to the
+// object or has been closed; errors of the subscription itself are delivered on Errors.
+func (h *NotificationHub) Subscribe(device DeviceInfo, object BACnetObject) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.ctx.Err() != nil {
+		return fmt.Errorf("notification hub is closed")
+	}
+	point := PointRef{DeviceID: device.DeviceID, Object: object}
+	if _, ok := h.subscriptions[point]; ok {
+		return fmt.Errorf("%s is already subscribed", point)
+	}
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	sub := &hubSubscription{cancel: cancel, done: make(chan struct{})}
+	h.subscriptions[point] = sub
+
+	o := h.options
+	covChan, errChan := h.client.SubscribeCOVWithOptions(ctx, device, object, o.SubscriberProcessIdentifier, o.IssueConfirmedNotifications, o.Lifetime, o.COVOptions)
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer close(sub.done)
+		h.forward(ctx, point, covChan, errChan)
+	}()
+	return nil
+}
+
+// forward copies the notifications and errors of one subscription to the hub's channels until the
+// subscription has ended.
+func (h *NotificationHub) forward(ctx context.Context, point PointRef, covChan <-chan COVNotification, errChan <-chan error) {
+	for covChan != nil || errChan != nil {
+		select {
+		case notification, ok := <-covChan:
+			if !ok {
+				covChan = nil
+				continue
+			}
+			select {
+			case h.notifications <- HubNotification{Point: point, COVNotification: notification}:
+			case <-ctx.Done():
+			}
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			select {
+			case h.errors <- &SubscriptionError{DeviceID: point.DeviceID, Object: point.Object, Err: err}:
+			default:
+			}
+		}
+	}
+}
+
+// Unsubscribe ends the subscription to object on the device and cancels it on the device. It waits
+// until the subscription has ended, so the object can be subscribed again right away, and reports
+// whether the hub held a subscription to the object.
+func (h *NotificationHub) Unsubscribe(deviceID uint32, object BACnetObject) bool {
+	point := PointRef{DeviceID: deviceID, Object: object}
+	h.mu.Lock()
+	sub, ok := h.subscriptions[point]
+	delete(h.subscriptions, point)
+	h.mu.Unlock()
+	if !ok {
+		return false
+	}
+	sub.cancel()
+	<-sub.done
+	return true
+}
+
+// Points returns the points the hub is subscribed to, ordered by device and object.
+func (h *NotificationHub) Points() []PointRef {
+	h.mu.Lock()
+	points := make([]PointRef, 0, len(h.subscriptions))
+	for point := range h.subscriptions {
+		points = append(points, point)
+	}
+	h.mu.Unlock()
+
+	sort.Slice(points, func(i, j int) bool { return pointRefLess(points[i], points[j]) })
+	return points
+}
+
+// Close ends all subscriptions, waits for them to stop and closes the hub's channels.
+func (h *NotificationHub) Close() {
+	h.mu.Lock()
+	h.cancel()
+	h.mu.Unlock()
+	h.wg.Wait()
+	h.closeOnce.Do(func() {
+		close(h.notifications)
+		close(h.errors)
+	})
+}
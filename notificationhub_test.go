@@ -0,0 +1,115 @@
+package bacnet
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNotificationHubFansInDevices(t *testing.T) {
+	sim1, device1 := startSimulator(t, SimulatorOptions{DeviceID: 2001, AnalogValues: 2})
+	sim2, device2 := startSimulator(t, SimulatorOptions{DeviceID: 2002, BinaryValues: 1})
+	client := newLoopbackClient(t, ClientOptions{})
+
+	hub := NewNotificationHub(context.Background(), client, NotificationHubOptions{SubscriberProcessIdentifier: 9, Lifetime: 60, COVOptions: COVOptions{BufferSize: 8}})
+	defer hub.Close()
+
+	av2 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 2}
+	bv1 := BACnetObject{Type: OBJECT_BINARY_VALUE, Instance: 1}
+	if err := hub.Subscribe(device1, av2); err != nil {
+		t.Fatal(err)
+	}
+	if err := hub.Subscribe(device2, bv1); err != nil {
+		t.Fatal(err)
+	}
+	if err := hub.Subscribe(device1, av2); err == nil {
+		t.Error("second Subscribe to the same point succeeded")
+	}
+
+	// Devices send the current value right after subscribing.
+	want := map[PointRef]bool{
+		{DeviceID: 2001, Object: av2}: true,
+		{DeviceID: 2002, Object: bv1}: true,
+	}
+	received := func() HubNotification {
+		t.Helper()
+		select {
+		case n := <-hub.Notifications():
+			if n.InitiatingDeviceIdentifier.Instance != n.Point.DeviceID || n.MonitoredObjectIdentifier != n.Point.Object {
+				t.Errorf("notification %+v delivered for %s", n.COVNotification, n.Point)
+			}
+			return n
+		case err := <-hub.Errors():
+			t.Fatalf("subscription error: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no notification")
+		}
+		return HubNotification{}
+	}
+	for len(want) > 0 {
+		delete(want, received().Point)
+	}
+
+	sim2.Set(bv1, BinaryActive)
+	if n := received(); n.Point != (PointRef{DeviceID: 2002, Object: bv1}) {
+		t.Errorf("change of %s delivered for %s", bv1, n.Point)
+	}
+
+	if got := hub.Points(); len(got) != 2 || got[0].DeviceID != 2001 || got[1].DeviceID != 2002 {
+		t.Errorf("Points() = %v", got)
+	}
+
+	if !hub.Unsubscribe(2001, av2) {
+		t.Fatal("Unsubscribe reported no subscription")
+	}
+	if hub.Unsubscribe(2001, av2) {
+		t.Error("second Unsubscribe reported a subscription")
+	}
+	sim1.Set(av2, float32(5))
+	select {
+	case n := <-hub.Notifications():
+		t.Errorf("notification %s after Unsubscribe", n.Point)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if n := sim1.Server.SubscriptionCount(); n != 0 {
+		t.Errorf("device still holds %d subscriptions after Unsubscribe", n)
+	}
+
+	// The point can be subscribed again right away.
+	if err := hub.Subscribe(device1, av2); err != nil {
+		t.Fatal(err)
+	}
+	if n := received(); n.Point.DeviceID != 2001 {
+		t.Errorf("notification for %s after subscribing again", n.Point)
+	}
+}
+
+func TestNotificationHubErrorsNamePoint(t *testing.T) {
+	_, device := startSimulator(t, SimulatorOptions{DeviceID: 2003, AnalogValues: 1})
+	client := newLoopbackClient(t, ClientOptions{})
+
+	hub := NewNotificationHub(context.Background(), client, NotificationHubOptions{SubscriberProcessIdentifier: 1, Lifetime: 60})
+	missing := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 99}
+	if err := hub.Subscribe(device, missing); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-hub.Errors():
+		var subErr *SubscriptionError
+		if !errors.As(err, &subErr) || subErr.DeviceID != 2003 || subErr.Object != missing {
+			t.Errorf("error %v does not name %s of device 2003", err, missing)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no error for an unknown object")
+	}
+
+	hub.Close()
+	if _, ok := <-hub.Notifications(); ok {
+		t.Error("notification channel open after Close")
+	}
+	if err := hub.Subscribe(device, missing); err == nil {
+		t.Error("Subscribe succeeded after Close")
+	}
+}
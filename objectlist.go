@@ -0,0 +1,181 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// DefaultObjectListPageSize is the number of objects read per request when paging through an
+// object list. The response to a page of this size fits the smallest APDU of 480 bytes.
+const DefaultObjectListPageSize = 32
+
+// ObjectListPage is a part of the object list of a device.
+type ObjectListPage struct {
+	Objects []BACnetObject `json:"objects"`
+	// Offset is the position of the first object of the page in the list, counting from 0.
+	Offset uint32 `json:"offset"`
+	// Total is the length of the whole object list.
+	Total uint32 `json:"total"`
+}
+
+// Next returns the offset of the page following p, and false if p ends the list.
+func (p ObjectListPage) Next() (uint32, bool) {
+	next := p.Offset + uint32(len(p.Objects))
+	return next, next < p.Total
+}
+
+// ObjectWalkOptions bounds what WalkObjectList and ReadAllObjects read and hold at a time.
+type ObjectWalkOptions struct {
+	// PageSize is the number of objects read per request and held at a time. Zero uses
+	// DefaultObjectListPageSize.
+	PageSize int `json:"pageSize,omitempty"`
+	// Offset skips the first objects of the list, e.g. to resume an interrupted walk.
+	Offset uint32 `json:"offset,omitempty"`
+	// Limit ends the walk after this many objects. Zero walks to the end of the list.
+	Limit int `json:"limit,omitempty"`
+}
+
+// ReadObjectListPage reads at most limit objects of the object list of a device, starting at
+// offset, with a ReadProperty of the list length and a ReadPropertyMultiple of the elements.
+// Unlike GetObjectList, which holds the whole list, it holds no more than limit objects, for
+// devices with tens of thousands of objects. A zero limit reads DefaultObjectListPageSize objects.
+func (c *BACnetClient) ReadObjectListPage(device DeviceInfo, offset uint32, limit int, opts ...CallOption) (ObjectListPage, error) {
+	ctx := callContext(opts...)
+	total, err := c.readObjectListLength(ctx, device)
+	if err != nil {
+		return ObjectListPage{}, err
+	}
+	if limit <= 0 {
+		limit = DefaultObjectListPageSize
+	}
+	page := ObjectListPage{Offset: offset, Total: total}
+	if offset >= total {
+		return page, nil
+	}
+	page.Objects, err = c.readObjectListElements(ctx, device, offset, uint32(min(uint64(limit), uint64(total-offset))))
+	if err != nil {
+		return ObjectListPage{}, err
+	}
+	return page, nil
+}
+
+// WalkObjectList hands the objects of the object list of a device to fn page by page, holding at
+// most options.PageSize objects at a time. Returning false from fn ends the walk.
+func (c *BACnetClient) WalkObjectList(device DeviceInfo, options ObjectWalkOptions, fn func(BACnetObject) bool, opts ...CallOption) error {
+	return c.walkObjectList(callContext(opts...), device, options, func(page []BACnetObject) (bool, error) {
+		for _, object := range page {
+			if !fn(object) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// ReadAllObjects reads the given properties of every object of a device, walking the object list
+// page by page and reading each page with one ReadPropertyMultiple request whose results are handed
+// to fn as they are decoded, like ReadPropertyMultipleStream. Memory use is bounded by one page of
+// options.PageSize objects and one response, whatever the size of the device. Returning false from
+// fn ends the read.
+func (c *BACnetClient) ReadAllObjects(device DeviceInfo, propertyIDs []uint32, options ObjectWalkOptions, fn func(RPMResult) bool, opts ...CallOption) error {
+	ctx := callContext(opts...)
+	return c.walkObjectList(ctx, device, options, func(page []BACnetObject) (bool, error) {
+		var params bytes.Buffer
+		for _, object := range page {
+			encodeReadAccessSpecification(&params, object, propertyIDs)
+		}
+		stopped := false
+		err := c.readPropertyMultipleStream(ctx, device, params.Bytes(), func(result RPMResult) bool {
+			stopped = !fn(result)
+			return !stopped
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to read objects %s to %s: %w", page[0], page[len(page)-1], err)
+		}
+		return !stopped, nil
+	})
+}
+
+// walkObjectList reads the object list page by page and hands every page to fn until fn returns
+// false or an error.
+func (c *BACnetClient) walkObjectList(ctx context.Context, device DeviceInfo, options ObjectWalkOptions, fn func([]BACnetObject) (bool, error)) error {
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultObjectListPageSize
+	}
+	total, err := c.readObjectListLength(ctx, device)
+	if err != nil {
+		return err
+	}
+	end := total
+	if options.Limit > 0 && uint64(options.Offset)+uint64(options.Limit) < uint64(total) {
+		end = options.Offset + uint32(options.Limit)
+	}
+
+	for offset := options.Offset; offset < end; {
+		count := uint32(min(uint64(pageSize), uint64(end-offset)))
+		page, err := c.readObjectListElements(ctx, device, offset, count)
+		if err != nil {
+			return err
+		}
+		if more, err := fn(page); err != nil || !more {
+			return err
+		}
+		offset += count
+	}
+	return nil
+}
+
+// readObjectListLength reads the length of the object list of a device.
+func (c *BACnetClient) readObjectListLength(ctx context.Context, device DeviceInfo) (uint32, error) {
+	deviceObject := BACnetObject{Type: OBJECT_DEVICE, Instance: device.DeviceID}
+	index := uint32(0)
+	value, err := c.readProperty(ctx, device, deviceObject, PROP_OBJECT_LIST, &index)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read object list length: %w", err)
+	}
+	length, ok := value.(uint32)
+	if !ok {
+		return 0, fmt.Errorf("unexpected object list length %v (%T)", value, value)
+	}
+	return length, nil
+}
+
+// readObjectListElements reads count elements of the object list of a device from offset on
+// with a single ReadPropertyMultiple request of one array element per reference.
+func (c *BACnetClient) readObjectListElements(ctx context.Context, device DeviceInfo, offset, count uint32) ([]BACnetObject, error) {
+	var params bytes.Buffer
+	encoding.EncodeContextObjectID(&params, 0, uint32(OBJECT_DEVICE), device.DeviceID)
+	encoding.EncodeOpeningTag(&params, 1)
+	for index := offset + 1; index <= offset+count; index++ {
+		encoding.EncodeContextUnsigned(&params, 0, PROP_OBJECT_LIST)
+		encoding.EncodeContextUnsigned(&params, 1, index)
+	}
+	encoding.EncodeClosingTag(&params, 1)
+
+	objects := make([]BACnetObject, 0, count)
+	var resultErr error
+	err := c.readPropertyMultipleStream(ctx, device, params.Bytes(), func(result RPMResult) bool {
+		if result.Err != nil {
+			resultErr = fmt.Errorf("failed to read object list index %d: %w", len(objects)+int(offset)+1, result.Err)
+			return false
+		}
+		object, ok := result.Value.(BACnetObject)
+		if !ok {
+			resultErr = fmt.Errorf("unexpected object list entry %v (%T)", result.Value, result.Value)
+			return false
+		}
+		objects = append(objects, object)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resultErr != nil {
+		return nil, resultErr
+	}
+	return objects, nil
+}
@@ -0,0 +1,60 @@
+package bacnet
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Objects returns an iterator over the object list of a device together with a function reporting
+// the error that ended the iteration early, if any.
+//
+// The object list is fetched lazily: the length is read first (array index 0), then one element is
+// read per step. Breaking out of the loop stops further requests, which makes it cheap to look for a
+// single object on slow MS/TP devices behind a router.
+//
+//	objects, errFn := client.Objects(device)
+//	for object := range objects {
+//		...
+//	}
+//	if err := errFn(); err != nil {
+//		...
+//	}
+func (c *BACnetClient) Objects(device DeviceInfo, opts ...CallOption) (iter.Seq[BACnetObject], func() error) {
+	var iterErr error
+
+	seq := func(yield func(BACnetObject) bool) {
+		iterErr = nil
+		ctx := callContext(opts...)
+		deviceObject := BACnetObject{Type: OBJECT_DEVICE, Instance: device.DeviceID}
+
+		length := uint32(0)
+		value, err := c.readProperty(ctx, device, deviceObject, uint32(PROP_OBJECT_LIST), &length)
+		if err != nil {
+			iterErr = fmt.Errorf("failed to read object list length: %w", err)
+			return
+		}
+		count, ok := value.(uint32)
+		if !ok {
+			iterErr = fmt.Errorf("unexpected object list length %v (%T)", value, value)
+			return
+		}
+
+		for index := uint32(1); index <= count; index++ {
+			value, err := c.readProperty(ctx, device, deviceObject, uint32(PROP_OBJECT_LIST), &index)
+			if err != nil {
+				iterErr = fmt.Errorf("failed to read object list index %d: %w", index, err)
+				return
+			}
+			object, ok := value.(BACnetObject)
+			if !ok {
+				iterErr = fmt.Errorf("unexpected object list entry %v (%T) at index %d", value, value, index)
+				return
+			}
+			if !yield(object) {
+				return
+			}
+		}
+	}
+
+	return seq, func() error { return iterErr }
+}
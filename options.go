@@ -0,0 +1,156 @@
+package bacnet
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Option configures a client in NewClient and NewClientWithConn. Options are applied in order on
+// top of the ClientOptions passed along, so knobs added to the client later become new Options and
+// ClientOptions fields without breaking existing callers.
+type Option func(*ClientOptions)
+
+// WithTimeout sets ClientOptions.Timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *ClientOptions) { o.Timeout = timeout }
+}
+
+// WithLogger sets ClientOptions.Logger.
+func WithLogger(logger *log.Logger) Option {
+	return func(o *ClientOptions) { o.Logger = logger }
+}
+
+// WithTransport sets ClientOptions.Transport.
+func WithTransport(conn PacketConn) Option {
+	return func(o *ClientOptions) { o.Transport = conn }
+}
+
+// WithReadOnly sets ClientOptions.ReadOnly.
+func WithReadOnly(mode ReadOnlyMode) Option {
+	return func(o *ClientOptions) { o.ReadOnly = mode }
+}
+
+// RetryPolicy controls how confirmed requests are retried.
+type RetryPolicy struct {
+	// Retries is how many times a request is resent when no response arrives in time.
+	Retries int
+	// Backoff is the wait added before the first resend, doubled before every further one; see
+	// ClientOptions.RetryBackoff.
+	Backoff time.Duration
+}
+
+// WithRetryPolicy sets the retry fields of ClientOptions.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *ClientOptions) {
+		o.Retries = policy.Retries
+		o.RetryBackoff = policy.Backoff
+	}
+}
+
+// CallOption overrides client options for a single request.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	ctx     context.Context
+	timeout *time.Duration
+	retries *int
+	backoff *time.Duration
+	frames  func(Frame)
+	user    *string
+	info    *CallInfo
+}
+
+// CallTimeout overrides ClientOptions.Timeout for one request.
+func CallTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) { o.timeout = &timeout }
+}
+
+// CallRetries overrides ClientOptions.Retries for one request.
+func CallRetries(retries int) CallOption {
+	return func(o *callOptions) { o.retries = &retries }
+}
+
+// CallRetryBackoff overrides ClientOptions.RetryBackoff for one request.
+func CallRetryBackoff(backoff time.Duration) CallOption {
+	return func(o *callOptions) { o.backoff = &backoff }
+}
+
+// CallContext makes a call part of ctx: cancelling ctx ends its requests, and the deadline of ctx
+// bounds them. Calls that go on for long, such as scans of a whole device, stop at the next request.
+// Methods taking a context do not need it, and every method taking CallOptions has a variant taking
+// the context first, e.g. ReadPropertyContext.
+func CallContext(ctx context.Context) CallOption {
+	return func(o *callOptions) { o.ctx = ctx }
+}
+
+// withContext returns opts followed by CallContext(ctx), for the ...Context variants of methods.
+func withContext(ctx context.Context, opts []CallOption) []CallOption {
+	return append(opts[:len(opts):len(opts)], CallContext(ctx))
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context that applies opts to every request made with it, for methods
+// that take a context but no CallOptions. CallContext is ignored.
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	call, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	for _, opt := range opts {
+		opt(&call)
+	}
+	call.ctx = nil
+	return context.WithValue(ctx, callOptionsKey{}, call)
+}
+
+// callContext returns the context of a call made with opts: the context of CallContext, or the
+// background context, applying opts.
+func callContext(opts ...CallOption) context.Context {
+	var call callOptions
+	for _, opt := range opts {
+		opt(&call)
+	}
+	ctx := call.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return WithCallOptions(ctx, opts...)
+}
+
+// timeout returns the response timeout of requests made with ctx.
+func (c *BACnetClient) timeout(ctx context.Context) time.Duration {
+	if call, ok := ctx.Value(callOptionsKey{}).(callOptions); ok && call.timeout != nil {
+		return *call.timeout
+	}
+	return c.options.Timeout
+}
+
+// retries returns how often requests made with ctx are resent.
+func (c *BACnetClient) retries(ctx context.Context) int {
+	if call, ok := ctx.Value(callOptionsKey{}).(callOptions); ok && call.retries != nil {
+		return *call.retries
+	}
+	return c.options.Retries
+}
+
+// retryBackoff returns the wait added before the given resend, counting from 1, of a request made
+// with ctx.
+func (c *BACnetClient) retryBackoff(ctx context.Context, retry int) time.Duration {
+	backoff := c.options.RetryBackoff
+	if call, ok := ctx.Value(callOptionsKey{}).(callOptions); ok && call.backoff != nil {
+		backoff = *call.backoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff << min(retry-1, 16)
+}
+
+// logf writes to ClientOptions.Logger, if set.
+func (c *BACnetClient) logf(format string, args ...interface{}) {
+	if c.options.Logger != nil {
+		c.options.Logger.Printf(format, args...)
+	}
+}
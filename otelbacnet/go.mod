@@ -0,0 +1,23 @@
+module github.com/maxzerker/bacnet/otelbacnet
+
+go 1.24.5
+
+require (
+	github.com/maxzerker/bacnet v0.0.0-20261016233922-08822b5a41ec
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/sdk v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
+
+// Builds in this repository use the bacnet package next to it. The replace is ignored where
+// otelbacnet is a dependency, which gets the version required above.
+replace github.com/maxzerker/bacnet => ../
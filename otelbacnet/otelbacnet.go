@@ -0,0 +1,74 @@
+// Package otelbacnet reports the confirmed transactions of a bacnet.BACnetClient as OpenTelemetry
+// spans. It is a separate module so the bacnet package itself has no dependencies.
+//
+//	client, err := bacnet.NewClient(iface, bacnet.ClientOptions{Tracer: otelbacnet.NewTracer(nil)})
+package otelbacnet
+
+import (
+	"context"
+
+	"github.com/maxzerker/bacnet"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans.
+const ScopeName = "github.com/maxzerker/bacnet/otelbacnet"
+
+// Span attributes.
+const (
+	AttributeDevice   = attribute.Key("bacnet.device")
+	AttributeService  = attribute.Key("bacnet.service")
+	AttributeInvokeID = attribute.Key("bacnet.invoke_id")
+	AttributeObject   = attribute.Key("bacnet.object")
+	AttributeProperty = attribute.Key("bacnet.property")
+	AttributeRetries  = attribute.Key("bacnet.retries")
+)
+
+// Tracer implements bacnet.Tracer with an OpenTelemetry tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer returns a Tracer creating spans with a tracer of provider. A nil provider uses the
+// global tracer provider.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: provider.Tracer(ScopeName)}
+}
+
+// StartTransaction starts a client span named after the service, e.g. "BACnet ReadProperty", as a
+// child of the span in ctx.
+func (t *Tracer) StartTransaction(ctx context.Context, info bacnet.TransactionInfo) bacnet.TransactionSpan {
+	attributes := []attribute.KeyValue{
+		AttributeDevice.Int64(int64(info.Device.DeviceID)),
+		AttributeService.String(info.ServiceName()),
+		AttributeInvokeID.Int(int(info.InvokeID)),
+	}
+	if info.Object != nil {
+		attributes = append(attributes, AttributeObject.String(info.Object.String()))
+	}
+	if info.PropertyID != nil {
+		attributes = append(attributes, AttributeProperty.Int64(int64(*info.PropertyID)))
+	}
+	_, span := t.tracer.Start(ctx, "BACnet "+info.ServiceName(),
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	return transactionSpan{span}
+}
+
+type transactionSpan struct {
+	span trace.Span
+}
+
+func (s transactionSpan) End(retries int, err error) {
+	s.span.SetAttributes(AttributeRetries.Int(retries))
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,119 @@
+package otelbacnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/maxzerker/bacnet"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecorder(t *testing.T) (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return recorder, provider
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestTransactionSpan(t *testing.T) {
+	recorder, provider := newRecorder(t)
+	tracer := NewTracer(provider)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	object := bacnet.BACnetObject{Type: bacnet.OBJECT_ANALOG_INPUT, Instance: 3}
+	propertyID := uint32(bacnet.PROP_PRESENT_VALUE)
+	span := tracer.StartTransaction(ctx, bacnet.TransactionInfo{
+		Device:     bacnet.DeviceInfo{DeviceID: 1234},
+		Service:    bacnet.SERVICE_CONFIRMED_READ_PROPERTY,
+		InvokeID:   7,
+		Object:     &object,
+		PropertyID: &propertyID,
+	})
+	span.End(2, errors.New("timeout"))
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans ended, want 2", len(spans))
+	}
+	got := spans[0]
+	if got.Name() != "BACnet ReadProperty" || got.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span %q of kind %v", got.Name(), got.SpanKind())
+	}
+	if got.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("span is not a child of the span in the context")
+	}
+	attrs := attributes(got)
+	if attrs[AttributeDevice].AsInt64() != 1234 || attrs[AttributeInvokeID].AsInt64() != 7 ||
+		attrs[AttributeService].AsString() != "ReadProperty" || attrs[AttributeObject].AsString() != object.String() ||
+		attrs[AttributeProperty].AsInt64() != int64(propertyID) || attrs[AttributeRetries].AsInt64() != 2 {
+		t.Errorf("attributes %v", got.Attributes())
+	}
+	if got.Status().Code != codes.Error || len(got.Events()) != 1 {
+		t.Errorf("status %+v with %d events, want the error recorded", got.Status(), len(got.Events()))
+	}
+}
+
+func TestClientSpans(t *testing.T) {
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	conn, err := net.ListenUDP("udp4", loopback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := bacnet.NewSimulator(conn, bacnet.SimulatorOptions{DeviceID: 4001, AnalogValues: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sim.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		conn.Close()
+		<-done
+	}()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	device := bacnet.DeviceInfo{DeviceID: 4001, IPAddress: addr.IP, Port: addr.Port, MaxAPDU: 1476}
+
+	recorder, provider := newRecorder(t)
+	client, err := bacnet.NewClient(bacnet.ClientOptions{LocalAddr: loopback, Timeout: time.Second, Tracer: NewTracer(provider)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	av1 := bacnet.BACnetObject{Type: bacnet.OBJECT_ANALOG_VALUE, Instance: 1}
+	if _, err := client.ReadProperty(device, av1, bacnet.PROP_PRESENT_VALUE, nil); err != nil {
+		t.Fatal(err)
+	}
+	missing := bacnet.BACnetObject{Type: bacnet.OBJECT_ANALOG_VALUE, Instance: 99}
+	if _, err := client.ReadProperty(device, missing, bacnet.PROP_PRESENT_VALUE, nil); err == nil {
+		t.Fatal("read of an unknown object succeeded")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans ended, want 2", len(spans))
+	}
+	if spans[0].Status().Code == codes.Error || attributes(spans[0])[AttributeObject].AsString() != av1.String() {
+		t.Errorf("first span %+v %v", spans[0].Status(), spans[0].Attributes())
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("failed read has status %+v", spans[1].Status())
+	}
+}
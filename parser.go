@@ -5,47 +5,40 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
 )
 
 func parseIAm(data []byte, addr net.UDPAddr) (DeviceInfo, error) {
-	r := bytes.NewReader(data)
-
-	// BVLC
-	bvlcHeader := BVLCHeader{}
-	if err := binary.Read(r, binary.BigEndian, &bvlcHeader); err != nil {
-		return DeviceInfo{}, fmt.Errorf("error reading BVLC header: %w", err)
+	frame, err := decodeBVLC(data)
+	if err != nil {
+		return DeviceInfo{}, err
 	}
-
-	if bvlcHeader.Type != BVLC_TYPE_BACNET_IP {
-		return DeviceInfo{}, fmt.Errorf("not a BACnet/IP packet")
+	if frame.forwardedFrom != nil {
+		addr = *frame.forwardedFrom // Forwarded by a BBMD or proxy: the device is at the originating address
 	}
-
-	// NPDU
-	npduHeader := NPDU{}
-	if err := binary.Read(r, binary.BigEndian, &npduHeader); err != nil {
+	header, apdu, err := decodeNPDU(frame.npdu)
+	if err != nil {
 		return DeviceInfo{}, fmt.Errorf("error reading NPDU header: %w", err)
 	}
+	if header.isNetworkMessage() || len(apdu) < 2 {
+		return DeviceInfo{}, fmt.Errorf("not an application layer message")
+	}
 
 	// APDU
-	apduHeader := APDUHeader{}
-	apduType, _ := r.ReadByte()
-	apduHeader.Type = apduType & 0xF0
-
-	if apduHeader.Type != APDU_UNCONFIRMED_REQUEST {
-		return DeviceInfo{}, fmt.Errorf("not an unconfirmed request, got %x", apduHeader.Type)
+	if apdu[0]&0xF0 != APDU_UNCONFIRMED_REQUEST {
+		return DeviceInfo{}, fmt.Errorf("not an unconfirmed request, got %x", apdu[0]&0xF0)
 	}
 
 	// Unconfirmed Service Choice
-	serviceChoice, _ := r.ReadByte()
-	if serviceChoice != SERVICE_UNCONFIRMED_I_AM {
-		return DeviceInfo{}, fmt.Errorf("not an I-Am service, got %x", serviceChoice)
+	if apdu[1] != SERVICE_UNCONFIRMED_I_AM {
+		return DeviceInfo{}, fmt.Errorf("not an I-Am service, got %x", apdu[1])
 	}
+	r := bytes.NewReader(apdu[2:])
 
 	// I-Am Data (Object Identifier, Max APDU, Segmentation, Vendor ID)
 	var objectIdentifier uint32
-	var maxAPDULen uint16
-	var segmentation uint8
-	var vendorID uint16
 
 	// Object Identifier
 	// Expected tag: Application Tag 12 (BACnetObjectIdentifier), Length 4
@@ -60,398 +53,385 @@ func parseIAm(data []byte, addr net.UDPAddr) (DeviceInfo, error) {
 		return DeviceInfo{}, fmt.Errorf("failed to read object identifier: %w", err)
 	}
 
-	// Max APDU
-	// Expected tag: Application Tag 2 (Unsigned), Length 2
-	tag, err = r.ReadByte()
-	if err != nil {
-		return DeviceInfo{}, fmt.Errorf("failed to read max APDU tag: %w", err)
-	}
-	if tag != 0x22 { // Application tag 2, length 2
-		return DeviceInfo{}, fmt.Errorf("unexpected tag for max APDU: got 0x%x, expected 0x22. Full packet: %x", tag, data)
-	}
-	if err := binary.Read(r, binary.BigEndian, &maxAPDULen); err != nil {
-		return DeviceInfo{}, fmt.Errorf("failed to read max APDU: %w", err)
-	}
-
-	// Segmentation Supported
-	// Expected tag: Application Tag 9 (Enumerated), Length 1
-	tag, err = r.ReadByte()
+	// Max APDU, Segmentation Supported and Vendor ID, whose encoded length depends on their value
+	maxAPDULen, err := decodeIAmField(r, encoding.TagUnsignedInt, "max APDU")
 	if err != nil {
-		return DeviceInfo{}, fmt.Errorf("failed to read segmentation tag: %w", err)
-	}
-	if tag != 0x91 { // Application tag 9, length 1
-		return DeviceInfo{}, fmt.Errorf("unexpected tag for segmentation: got 0x%x, expected 0x91. Full packet: %x", tag, data)
+		return DeviceInfo{}, err
 	}
-	if err := binary.Read(r, binary.BigEndian, &segmentation); err != nil {
-		return DeviceInfo{}, fmt.Errorf("failed to read segmentation: %w", err)
+	if _, err := decodeIAmField(r, encoding.TagEnumerated, "segmentation"); err != nil {
+		return DeviceInfo{}, err
 	}
-
-	// Vendor ID
-	// Expected tag: Application Tag 2 (Unsigned), Length 2
-	tag, err = r.ReadByte()
+	vendorID, err := decodeIAmField(r, encoding.TagUnsignedInt, "vendor ID")
 	if err != nil {
-		return DeviceInfo{}, fmt.Errorf("failed to read vendor ID tag: %w", err)
-	}
-	if tag != 0x22 { // Application tag 2, length 2
-		return DeviceInfo{}, fmt.Errorf("unexpected tag for vendor ID: got 0x%x, expected 0x22. Full packet: %x", tag, data)
-	}
-	if err := binary.Read(r, binary.BigEndian, &vendorID); err != nil {
-		return DeviceInfo{}, fmt.Errorf("failed to read vendor ID: %w", err)
+		return DeviceInfo{}, err
 	}
 
 	return DeviceInfo{
 		DeviceID:  objectIdentifier & 0x3FFFFF,
 		IPAddress: addr.IP,
 		Port:      addr.Port,
-		MaxAPDU:   maxAPDULen,
+		MaxAPDU:   uint16(maxAPDULen),
+		VendorID:  vendorID,
 	}, nil
 }
 
-func parseObjectList(data []byte, expectedInvokeID byte) ([]BACnetObject, error) {
-	r := bytes.NewReader(data)
-	var tag byte
-	var err error
-
-	// BVLC & NPDU - skip
-	var bvlcHeader BVLCHeader
-	if err := binary.Read(r, binary.BigEndian, &bvlcHeader); err != nil {
-		return nil, fmt.Errorf("error reading BVLC header: %w", err)
+// decodeIAmField decodes an unsigned or enumerated I-Am parameter with the expected application tag.
+func decodeIAmField(r *bytes.Reader, tagNumber uint8, name string) (uint32, error) {
+	tag, err := encoding.DecodeTagHeader(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s tag: %w", name, err)
 	}
-	var npduHeader NPDU
-	if err := binary.Read(r, binary.BigEndian, &npduHeader); err != nil {
-		return nil, fmt.Errorf("error reading NPDU header: %w", err)
+	if tag.Context || tag.Number != tagNumber {
+		return 0, fmt.Errorf("unexpected tag %d for %s", tag.Number, name)
 	}
+	value, err := encoding.DecodeUnsigned(r, tag.Length)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return value, nil
+}
 
-	// APDU
-	apduType, err := r.ReadByte()
+// notificationParams returns a reader of the service parameters of an unsegmented notification,
+// which is either the unconfirmed or the confirmed service given. The Simple-ACK of a confirmed
+// notification is sent by the caller.
+func notificationParams(data []byte, unconfirmed, confirmed byte, name string) (*bytes.Reader, error) {
+	apdu, err := apduFromPacket(data)
 	if err != nil {
-		return nil, fmt.Errorf("error reading APDU type: %w", err)
+		return nil, err
 	}
-	if apduType&0xF0 != APDU_COMPLEX_ACK {
-		return nil, fmt.Errorf("not a Complex-ACK, got %x", apduType)
+	switch {
+	case len(apdu) >= 2 && apdu[0]&0xF0 == APDU_UNCONFIRMED_REQUEST && apdu[1] == unconfirmed:
+		return bytes.NewReader(apdu[2:]), nil
+	case len(apdu) >= 4 && apdu[0]&0xF0 == APDU_CONFIRMED_REQUEST && apdu[0]&0x08 == 0 && apdu[3] == confirmed:
+		return bytes.NewReader(apdu[4:]), nil
 	}
-	invokeID, _ := r.ReadByte()
-	if invokeID != expectedInvokeID {
-		return nil, fmt.Errorf("invoke ID mismatch: expected %d, got %d", expectedInvokeID, invokeID)
+	return nil, fmt.Errorf("not an unsegmented %s, got % x", name, apdu[:min(len(apdu), 4)])
+}
+
+// parseCOVNotification decodes a confirmed or unconfirmed COV notification.
+func parseCOVNotification(data []byte) (COVNotification, error) {
+	r, err := notificationParams(data, SERVICE_UNCONFIRMED_COV_NOTIFICATION, SERVICE_CONFIRMED_COV_NOTIFICATION, "COV notification")
+	if err != nil {
+		return COVNotification{}, err
 	}
 
-	service, err := r.ReadByte()
+	var notification COVNotification
+	notification.SubscriberProcessIdentifier, err = encoding.DecodeContextUnsigned(r, 0)
+	if err != nil {
+		return COVNotification{}, fmt.Errorf("error reading subscriber process identifier: %w", err)
+	}
+	deviceType, deviceInstance, err := encoding.DecodeContextObjectID(r, 1)
 	if err != nil {
-		return nil, fmt.Errorf("error reading service choice: %w", err)
+		return COVNotification{}, fmt.Errorf("error reading initiating device identifier: %w", err)
 	}
-	if service != SERVICE_CONFIRMED_READ_PROPERTY {
-		return nil, fmt.Errorf("not a ReadProperty ACK, got %x", service)
+	notification.InitiatingDeviceIdentifier = BACnetObject{Type: ObjectType(deviceType), Instance: deviceInstance}
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 2)
+	if err != nil {
+		return COVNotification{}, fmt.Errorf("error reading monitored object identifier: %w", err)
+	}
+	notification.MonitoredObjectIdentifier = BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	// Time Remaining, as long as the lifetime subscribed with
+	notification.TimeRemaining, err = encoding.DecodeContextUnsigned(r, 3)
+	if err != nil {
+		return COVNotification{}, fmt.Errorf("error reading time remaining: %w", err)
 	}
 
-	// Context Tag: Object Identifier
-	tag, err = r.ReadByte()
+	if err := encoding.ExpectOpeningTag(r, 4); err != nil {
+		return COVNotification{}, fmt.Errorf("expected list of values: %w", err)
+	}
+	values, err := decodePropertyValueList(r, 4)
 	if err != nil {
-		return nil, fmt.Errorf("error reading object identifier tag: %w", err)
+		return COVNotification{}, err
 	}
-	if tag != 0x0C { // Context 0, Length 4
-		return nil, fmt.Errorf("expected object identifier tag 0x0C, got %x", tag)
+	for _, value := range values {
+		value.Value = typedPropertyValue(notification.MonitoredObjectIdentifier.Type, value.PropertyID, value.Value)
+		notification.ListOfValues = append(notification.ListOfValues, value)
 	}
-	var objID uint32
-	if err := binary.Read(r, binary.BigEndian, &objID); err != nil {
-		return nil, fmt.Errorf("error reading object identifier: %w", err)
+
+	return notification, nil
+}
+
+// parseEventNotification decodes a confirmed or unconfirmed event notification. Time is set from
+// a date-time time stamp and left zero for a time or sequence number one.
+func parseEventNotification(data []byte) (EventNotification, error) {
+	r, err := notificationParams(data, SERVICE_UNCONFIRMED_EVENT_NOTIFICATION, SERVICE_CONFIRMED_EVENT_NOTIFICATION, "event notification")
+	if err != nil {
+		return EventNotification{}, err
 	}
 
-	// Context Tag: Property Identifier
-	tag, err = r.ReadByte()
+	var n EventNotification
+	if n.ProcessID, err = encoding.DecodeContextUnsigned(r, 0); err != nil {
+		return EventNotification{}, fmt.Errorf("error reading process identifier: %w", err)
+	}
+	deviceType, deviceInstance, err := encoding.DecodeContextObjectID(r, 1)
+	if err != nil {
+		return EventNotification{}, fmt.Errorf("error reading initiating device identifier: %w", err)
+	}
+	n.InitiatingDevice = BACnetObject{Type: ObjectType(deviceType), Instance: deviceInstance}
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 2)
 	if err != nil {
-		return nil, fmt.Errorf("error reading property identifier tag: %w", err)
+		return EventNotification{}, fmt.Errorf("error reading event object identifier: %w", err)
 	}
-	if tag != 0x19 { // Context 1, Length 1
-		return nil, fmt.Errorf("expected property identifier tag 0x19, got %x", tag)
+	n.EventObject = BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	if n.Time, err = decodeTimeStamp(r, 3); err != nil {
+		return EventNotification{}, fmt.Errorf("error reading time stamp: %w", err)
 	}
-	var propID byte
-	if err := binary.Read(r, binary.BigEndian, &propID); err != nil {
-		return nil, fmt.Errorf("error reading property identifier: %w", err)
+	if n.NotificationClass, err = encoding.DecodeContextUnsigned(r, 4); err != nil {
+		return EventNotification{}, fmt.Errorf("error reading notification class: %w", err)
 	}
-
-	// Opening tag for the list of objects
-	tag, err = r.ReadByte()
+	priority, err := encoding.DecodeContextUnsigned(r, 5)
 	if err != nil {
-		return nil, fmt.Errorf("error reading opening tag for object list: %w", err)
+		return EventNotification{}, fmt.Errorf("error reading priority: %w", err)
 	}
-	if tag != 0x3E { // Context-specific tag 3, opening tag
-		return nil, fmt.Errorf("expected opening tag 0x3E for object list, got %x. Full packet: %x", tag, data)
+	n.Priority = uint8(priority)
+	eventType, err := encoding.DecodeContextUnsigned(r, 6)
+	if err != nil {
+		return EventNotification{}, fmt.Errorf("error reading event type: %w", err)
 	}
-
-	var objectList []BACnetObject
-	for {
-		tag, err = r.ReadByte()
-		if err != nil {
-			break // EOF
+	n.EventType = EventType(eventType)
+	if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsContext(7) {
+		if n.MessageText, err = encoding.DecodeContextCharacterString(r, 7); err != nil {
+			return EventNotification{}, fmt.Errorf("error reading message text: %w", err)
 		}
-
-		if tag == 0x3F { // Context-specific tag 3, closing tag
-			break
+	}
+	notifyType, err := encoding.DecodeContextUnsigned(r, 8)
+	if err != nil {
+		return EventNotification{}, fmt.Errorf("error reading notify type: %w", err)
+	}
+	n.NotifyType = NotifyType(notifyType)
+	// Acknowledgment notifications carry neither Ack_Required nor the from state
+	if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsContext(9) {
+		if n.AckRequired, err = encoding.DecodeContextBoolean(r, 9); err != nil {
+			return EventNotification{}, fmt.Errorf("error reading ack required: %w", err)
 		}
-
-		// It should be an object identifier, which is application-tagged.
-		if tag == 0xC4 { // Application tag 12 (BACnetObjectIdentifier), length 4
-			var rawObjId uint32
-			if err := binary.Read(r, binary.BigEndian, &rawObjId); err != nil {
-				return nil, fmt.Errorf("error reading object id from list: %w", err)
-			}
-			objectList = append(objectList, BACnetObject{
-				Type:     ObjectType(rawObjId >> 22),
-				Instance: rawObjId & 0x3FFFFF,
-			})
-		} else {
-			return nil, fmt.Errorf("unexpected tag 0x%x in object list. Full packet: %x", tag, data)
+	}
+	if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsContext(10) {
+		fromState, err := encoding.DecodeContextUnsigned(r, 10)
+		if err != nil {
+			return EventNotification{}, fmt.Errorf("error reading from state: %w", err)
 		}
+		n.FromState = EventState(fromState)
 	}
-
-	return objectList, nil
-}
-
-func parseObjectPropertyList(data []byte, expectedInvokeID byte) ([]BACnetPropertyValue, error) {
-	r := bytes.NewReader(data)
-
-	// BVLC & NPDU - skip
-	var bvlcHeader BVLCHeader
-	if err := binary.Read(r, binary.BigEndian, &bvlcHeader); err != nil {
-		return nil, fmt.Errorf("error reading BVLC header: %w", err)
+	toState, err := encoding.DecodeContextUnsigned(r, 11)
+	if err != nil {
+		return EventNotification{}, fmt.Errorf("error reading to state: %w", err)
 	}
-	var npduHeader NPDU
-	if err := binary.Read(r, binary.BigEndian, &npduHeader); err != nil {
-		return nil, fmt.Errorf("error reading NPDU header: %w", err)
+	n.ToState = EventState(toState)
+	if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsOpening(12) {
+		encoding.DecodeTagHeader(r)
+		if n.Values, err = decodeEventValues(r); err != nil {
+			return EventNotification{}, fmt.Errorf("error reading event values: %w", err)
+		}
 	}
+	return n, nil
+}
 
-	// APDU
-	apduType, err := r.ReadByte()
-	if err != nil {
-		return nil, fmt.Errorf("error reading APDU type: %w", err)
+// decodeTimeStamp decodes a BACnetTimeStamp enclosed in the given context tag. Only the date-time
+// choice yields a time; the time and sequence number choices return the zero time.
+func decodeTimeStamp(r *bytes.Reader, tagNumber uint8) (time.Time, error) {
+	if err := encoding.ExpectOpeningTag(r, tagNumber); err != nil {
+		return time.Time{}, err
 	}
-	if apduType&0xF0 == APDU_ERROR {
-		return nil, fmt.Errorf("received BACnet Error PDU") // Basic error handling
-	}
-	if apduType&0xF0 != APDU_COMPLEX_ACK {
-		return nil, fmt.Errorf("not a Complex-ACK, got 0x%x", apduType)
+	t, _, err := decodeTimeStampChoice(r)
+	if err != nil {
+		return time.Time{}, err
 	}
-	invokeID, _ := r.ReadByte()
-	if invokeID != expectedInvokeID {
-		return nil, fmt.Errorf("invoke ID mismatch: expected %d, got %d", expectedInvokeID, invokeID)
+	if err := encoding.ExpectClosingTag(r, tagNumber); err != nil {
+		return time.Time{}, err
 	}
+	return t, nil
+}
 
-	service, err := r.ReadByte()
+// decodeTimeStampChoice decodes a BACnetTimeStamp like decodeTimeStamp, without an enclosing tag.
+// It returns the encoded time stamp as well, so that it can be sent back unchanged.
+func decodeTimeStampChoice(r *bytes.Reader) (time.Time, []byte, error) {
+	start := r.Size() - int64(r.Len())
+	var t time.Time
+	tag, err := encoding.DecodeTagHeader(r)
 	if err != nil {
-		return nil, fmt.Errorf("error reading service choice: %w", err)
-	}
-	if service != SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE {
-		return nil, fmt.Errorf("not a ReadPropertyMultiple ACK, got 0x%x", service)
+		return time.Time{}, nil, err
 	}
-
-	var allProperties []BACnetPropertyValue
-
-	// The list of results continues until the APDU is fully read.
-	for r.Len() > 0 {
-		// It must be an object identifier.
-		tag, err := r.ReadByte()
+	if tag.IsOpening(2) {
+		date, err := decodeApplicationValue(r)
 		if err != nil {
-			break // Clean exit at end of data
+			return time.Time{}, nil, err
+		}
+		clock, err := decodeApplicationValue(r)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		d, dateOK := date.(Date)
+		c, timeOK := clock.(Time)
+		if !dateOK || !timeOK {
+			return time.Time{}, nil, fmt.Errorf("expected date and time, got %T and %T", date, clock)
 		}
-		if tag != 0x0C { // Context Tag 0, Length 4
-			return nil, fmt.Errorf("expected object identifier tag 0x0C, got 0x%x", tag)
+		if t, err = (DateTime{Date: d, Time: c}).In(time.Local); err != nil {
+			t = time.Time{} // Unspecified fields
 		}
-		var objectIdentifier uint32
-		if err := binary.Read(r, binary.BigEndian, &objectIdentifier); err != nil {
-			return nil, fmt.Errorf("failed to read object identifier: %w", err)
+		if err := encoding.ExpectClosingTag(r, 2); err != nil {
+			return time.Time{}, nil, err
 		}
+	} else if err := encoding.SkipValue(r, tag); err != nil {
+		return time.Time{}, nil, err
+	}
+	raw := make([]byte, r.Size()-int64(r.Len())-start)
+	r.ReadAt(raw, start)
+	return t, raw, nil
+}
 
-		// Now comes the list of properties for this object.
-		// Expect Context Tag 1, Opening Tag (0x1E)
-		tag, err = r.ReadByte()
+// decodePropertyValueList decodes a list of BACnetPropertyValue up to and including the closing
+// tag with the given number. Values are decoded with the general tag reader, so constructed values
+// and values of several elements decode like property values read with ReadProperty. Array
+// indexes and priorities are skipped.
+func decodePropertyValueList(r *bytes.Reader, closingTag uint8) ([]BACnetPropertyValue, error) {
+	var values []BACnetPropertyValue
+	for {
+		tag, err := encoding.PeekTagHeader(r)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read opening tag for property list: %w", err)
+			return nil, fmt.Errorf("failed to read tag inside property values: %w", err)
 		}
-		if tag != 0x1E {
-			return nil, fmt.Errorf("expected opening tag 0x1E for property list, got 0x%x", tag)
+		if tag.IsClosing(closingTag) {
+			encoding.DecodeTagHeader(r)
+			return values, nil
 		}
 
-		// Loop over each property result
-		for {
-			tag, err := r.ReadByte()
-			if err != nil {
-				return nil, fmt.Errorf("failed to read tag inside property list: %w", err)
-			}
-
-			if tag == 0x1F { // Context Tag 1, Closing Tag
-				break // End of properties for this object
-			}
-
-			if tag != 0x29 { // Context Tag 2, Length 1
-				return nil, fmt.Errorf("expected property identifier tag 0x29, got 0x%x", tag)
-			}
-			var propID byte
-			if err := binary.Read(r, binary.BigEndian, &propID); err != nil {
-				return nil, fmt.Errorf("failed to read property identifier: %w", err)
-			}
-
-			// Expect Context Tag 4, Opening Tag (0x4E)
-			tag, err = r.ReadByte()
-			if err != nil {
-				return nil, fmt.Errorf("failed to read opening tag for property value: %w", err)
-			}
-			if tag != 0x4E {
-				return nil, fmt.Errorf("expected opening tag 0x4E for property value, got 0x%x", tag)
-			}
-
-			var values []interface{}
-			for {
-				peek, err := r.ReadByte()
-				if err != nil {
-					return nil, err
-				}
-				r.UnreadByte()
-
-				if peek == 0x4F { // Context Tag 4, Closing Tag
-					r.ReadByte() // consume it
-					break
-				}
-
-				val, err := decodeApplicationValue(r)
-				if err != nil {
-					return nil, fmt.Errorf("failed to decode application value for prop %d: %w", propID, err)
-				}
-				values = append(values, val)
+		propertyID, err := encoding.DecodeContextUnsigned(r, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read property identifier: %w", err)
+		}
+		if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsContext(1) {
+			if _, err := encoding.DecodeContextUnsigned(r, 1); err != nil {
+				return nil, fmt.Errorf("failed to read array index of %s: %w", propertyLabel(propertyID), err)
 			}
-
-			var finalValue interface{}
-			if len(values) == 1 {
-				finalValue = values[0]
-			} else {
-				finalValue = values
+		}
+		if err := encoding.ExpectOpeningTag(r, 2); err != nil {
+			return nil, fmt.Errorf("expected value of %s: %w", propertyLabel(propertyID), err)
+		}
+		value, err := decodeValueList(r, 2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value of %s: %w", propertyLabel(propertyID), err)
+		}
+		if tag, err := encoding.PeekTagHeader(r); err == nil && tag.IsContext(3) {
+			if _, err := encoding.DecodeContextUnsigned(r, 3); err != nil {
+				return nil, fmt.Errorf("failed to read priority of %s: %w", propertyLabel(propertyID), err)
 			}
-
-			allProperties = append(allProperties, BACnetPropertyValue{
-				PropertyID: uint32(propID),
-				Value:      finalValue,
-			})
 		}
+		values = append(values, BACnetPropertyValue{PropertyID: propertyID, Value: value})
 	}
-
-	return allProperties, nil
 }
 
-func parseCOVNotification(data []byte) (COVNotification, error) {
-	r := bytes.NewReader(data)
-
-	// BVLC & NPDU - skip
-	r.Seek(6, 0)
-
-	// APDU
-	apduType, err := r.ReadByte()
-	if err != nil {
-		return COVNotification{}, fmt.Errorf("error reading APDU type: %w", err)
-	}
-	if apduType&0xF0 != APDU_UNCONFIRMED_REQUEST {
-		return COVNotification{}, fmt.Errorf("not an Unconfirmed-Request, got %x", apduType)
-	}
+// bvlcFrame is a BACnet/IP datagram with the BVLC header removed.
+type bvlcFrame struct {
+	function byte
+	// forwardedFrom is the original source address carried in a Forwarded-NPDU.
+	forwardedFrom *net.UDPAddr
+	npdu          []byte
+}
 
-	service, err := r.ReadByte()
-	if err != nil {
-		return COVNotification{}, fmt.Errorf("error reading service choice: %w", err)
+// decodeBVLC strips the BVLC header from a BACnet/IP datagram.
+func decodeBVLC(data []byte) (bvlcFrame, error) {
+	if len(data) < 4 {
+		return bvlcFrame{}, fmt.Errorf("datagram too short for BVLC header: %d bytes", len(data))
 	}
-	var notification COVNotification
-
-	if service != SERVICE_UNCONFIRMED_EVENT_NOTIFICATION {
-		return COVNotification{}, fmt.Errorf("not a COV Notification or Event Notification, got %x", service)
+	if data[0] != BVLC_TYPE_BACNET_IP {
+		return bvlcFrame{}, fmt.Errorf("not a BACnet/IP packet")
 	}
-
-	// Subscriber Process Identifier
-	tag, err := r.ReadByte()
-	if tag != 0x09 { // Context 0, Length 1
-		return COVNotification{}, fmt.Errorf("error reading subscriber process identifier tag: %w", err)
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if length < 4 || length > len(data) {
+		return bvlcFrame{}, fmt.Errorf("invalid BVLC length %d for %d byte datagram", length, len(data))
 	}
-	subId, _ := r.ReadByte()
-	notification.SubscriberProcessIdentifier = uint32(subId)
 
-	tag, err = r.ReadByte()
-	// Initiating Device Identifier
-	if tag != 0x1C { // Context tag 1, length 4
-		return COVNotification{}, fmt.Errorf("unexpected tag for device identifier: got 0x%x, expected 0x1C.", tag)
+	frame := bvlcFrame{function: data[1], npdu: data[4:length]}
+	if frame.function == BVLC_FORWARDED_NPDU {
+		if len(frame.npdu) < 6 {
+			return bvlcFrame{}, fmt.Errorf("Forwarded-NPDU too short for originating address")
+		}
+		frame.forwardedFrom = &net.UDPAddr{
+			IP:   net.IPv4(frame.npdu[0], frame.npdu[1], frame.npdu[2], frame.npdu[3]),
+			Port: int(binary.BigEndian.Uint16(frame.npdu[4:6])),
+		}
+		frame.npdu = frame.npdu[6:]
 	}
+	return frame, nil
+}
 
-	var devId uint32
-	binary.Read(r, binary.BigEndian, &devId)
-	notification.InitiatingDeviceIdentifier = BACnetObject{Type: ObjectType(devId >> 22), Instance: devId & 0x3FFFFF}
-
-	tag, err = r.ReadByte()
-	// Monitored Object Identifier
-	if tag != 0x2C { // Context tag 2, length 4
-		return COVNotification{}, fmt.Errorf("unexpected tag for object identifier: got 0x%x, expected 0x2C.", tag)
-	}
+// npduHeader holds the decoded fields of a network layer header.
+type npduHeader struct {
+	control     byte
+	dnet        uint16
+	dadr        []byte
+	snet        uint16
+	sadr        []byte
+	hopCount    byte
+	messageType byte // Only meaningful for network layer messages
+}
 
-	var objId uint32
-	binary.Read(r, binary.BigEndian, &objId)
-	notification.MonitoredObjectIdentifier = BACnetObject{Type: ObjectType(objId >> 22), Instance: objId & 0x3FFFFF}
+func (h npduHeader) isNetworkMessage() bool {
+	return h.control&NPDU_CONTROL_NETWORK_LAYER_MESSAGE != 0
+}
 
-	// Time Remaining
-	tag, err = r.ReadByte()
-	if tag != 0x39 { // Context tag 3, length 1
-		return COVNotification{}, fmt.Errorf("error reading time remaining tag: %w", err)
-	}
-	timeRem, _ := r.ReadByte()
-	notification.TimeRemaining = uint32(timeRem)
+func (h npduHeader) hasSource() bool {
+	return h.control&NPDU_CONTROL_SOURCE_PRESENT != 0
+}
 
-	// List of Values (Context Tag 4, Opening Tag 0x4E) - This is common for both COV and Event Notifications
-	tag, err = r.ReadByte()
-	if err != nil {
-		return COVNotification{}, fmt.Errorf("failed to read opening tag for property values: %w", err)
+// decodeNPDU decodes a network layer header and returns it together with the remaining bytes,
+// which are either an APDU or the body of a network layer message.
+func decodeNPDU(data []byte) (npduHeader, []byte, error) {
+	if len(data) < 2 {
+		return npduHeader{}, nil, fmt.Errorf("NPDU too short: %d bytes", len(data))
 	}
-	if tag != 0x4E { // Context Tag 4, Opening Tag
-		return COVNotification{}, fmt.Errorf("expected opening tag 0x4E for property values, got 0x%x", tag)
+	if data[0] != 1 {
+		return npduHeader{}, nil, fmt.Errorf("unsupported NPDU version %d", data[0])
 	}
 
-	for {
-		tag, err := r.ReadByte()
-		if err != nil {
-			return COVNotification{}, fmt.Errorf("failed to read tag inside property values: %w", err)
-		}
-
-		if tag == 0x4F { // Context Tag 4, Closing Tag
-			break
-		}
+	h := npduHeader{control: data[1]}
+	rest := data[2:]
 
-		if tag != 0x09 { // Context Tag 0, Length 1
-			return COVNotification{}, fmt.Errorf("expected property identifier tag 0x09, got 0x%x", tag)
+	readAddress := func() (uint16, []byte, error) {
+		if len(rest) < 3 {
+			return 0, nil, fmt.Errorf("NPDU truncated in address field")
 		}
-		var propID byte
-		if err := binary.Read(r, binary.BigEndian, &propID); err != nil {
-			return COVNotification{}, fmt.Errorf("failed to read property identifier: %w", err)
+		network := binary.BigEndian.Uint16(rest[0:2])
+		length := int(rest[2])
+		if len(rest) < 3+length {
+			return 0, nil, fmt.Errorf("NPDU truncated in MAC address")
 		}
+		mac := rest[3 : 3+length]
+		rest = rest[3+length:]
+		return network, mac, nil
+	}
 
-		// Expect Context Tag 2, Opening Tag (0x2E)
-		tag, err = r.ReadByte()
-		if err != nil {
-			return COVNotification{}, fmt.Errorf("failed to read opening tag for property value: %w", err)
+	var err error
+	if h.control&NPDU_CONTROL_DESTINATION_PRESENT != 0 {
+		if h.dnet, h.dadr, err = readAddress(); err != nil {
+			return npduHeader{}, nil, err
 		}
-		if tag != 0x2E {
-			return COVNotification{}, fmt.Errorf("expected opening tag 0x2E for property value, got 0x%x", tag)
+	}
+	if h.hasSource() {
+		if h.snet, h.sadr, err = readAddress(); err != nil {
+			return npduHeader{}, nil, err
 		}
-
-		val, err := decodeApplicationValue(r)
-		if err != nil {
-			return COVNotification{}, fmt.Errorf("failed to decode application value for prop %d: %w", propID, err)
+	}
+	if h.control&NPDU_CONTROL_DESTINATION_PRESENT != 0 {
+		if len(rest) < 1 {
+			return npduHeader{}, nil, fmt.Errorf("NPDU truncated before hop count")
 		}
-
-		// Expect Context Tag 2, Closing Tag (0x2F)
-		tag, err = r.ReadByte()
-		if err != nil {
-			return COVNotification{}, fmt.Errorf("failed to read closing tag for property value: %w", err)
+		h.hopCount = rest[0]
+		rest = rest[1:]
+	}
+	if h.isNetworkMessage() {
+		if len(rest) < 1 {
+			return npduHeader{}, nil, fmt.Errorf("NPDU truncated before message type")
 		}
-		if tag != 0x2F {
-			return COVNotification{}, fmt.Errorf("expected closing tag 0x2F for property value, got 0x%x", tag)
+		h.messageType = rest[0]
+		rest = rest[1:]
+		if h.messageType >= 0x80 { // Proprietary messages carry a vendor ID
+			if len(rest) < 2 {
+				return npduHeader{}, nil, fmt.Errorf("NPDU truncated before vendor ID")
+			}
+			rest = rest[2:]
 		}
-
-		notification.ListOfValues = append(notification.ListOfValues, BACnetPropertyValue{
-			PropertyID: uint32(propID),
-			Value:      val,
-		})
 	}
 
-	return notification, nil
+	return h, rest, nil
 }
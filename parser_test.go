@@ -0,0 +1,64 @@
+package bacnet
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// iAmPacket builds a broadcast I-Am with minimally encoded parameters.
+func iAmPacket(instance, maxAPDU, segmentation, vendorID uint32) []byte {
+	var apdu bytes.Buffer
+	apdu.WriteByte(APDU_UNCONFIRMED_REQUEST)
+	apdu.WriteByte(SERVICE_UNCONFIRMED_I_AM)
+	encoding.EncodeApplicationObjectID(&apdu, uint32(OBJECT_DEVICE), instance)
+	encoding.EncodeApplicationUnsigned(&apdu, maxAPDU)
+	encoding.EncodeApplicationEnumerated(&apdu, segmentation)
+	encoding.EncodeApplicationUnsigned(&apdu, vendorID)
+
+	length := 4 + 2 + apdu.Len()
+	packet := []byte{BVLC_TYPE_BACNET_IP, BVLC_ORIGINAL_BROADCAST_NPDU, byte(length >> 8), byte(length), 0x01, NPDU_CONTROL_NORMAL_MESSAGE}
+	return append(packet, apdu.Bytes()...)
+}
+
+func TestParseIAmParameterLengths(t *testing.T) {
+	addr := net.UDPAddr{IP: net.IPv4(192, 168, 1, 20), Port: 47808}
+	tests := []struct {
+		name     string
+		maxAPDU  uint32
+		vendorID uint32
+	}{
+		{"one-byte vendor ID", 1476, 7},
+		{"two-byte vendor ID", 1476, 260},
+		{"one-byte max APDU", 206, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device, err := parseIAm(iAmPacket(1234, tt.maxAPDU, 3, tt.vendorID), addr)
+			if err != nil {
+				t.Fatalf("parseIAm: %v", err)
+			}
+			if device.DeviceID != 1234 {
+				t.Errorf("DeviceID = %d, want 1234", device.DeviceID)
+			}
+			if uint32(device.MaxAPDU) != tt.maxAPDU {
+				t.Errorf("MaxAPDU = %d, want %d", device.MaxAPDU, tt.maxAPDU)
+			}
+			if !device.IPAddress.Equal(addr.IP) || device.Port != addr.Port {
+				t.Errorf("address = %s:%d, want %s", device.IPAddress, device.Port, addr.String())
+			}
+		})
+	}
+}
+
+func TestParseIAmRejectsWrongTag(t *testing.T) {
+	packet := iAmPacket(1234, 1476, 3, 7)
+	// Replace the Segmentation Supported enumerated tag with an unsigned tag.
+	idx := bytes.LastIndexByte(packet, 0x91)
+	packet[idx] = 0x21
+	if _, err := parseIAm(packet, net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 47808}); err == nil {
+		t.Fatal("parseIAm accepted an unsigned segmentation parameter")
+	}
+}
@@ -0,0 +1,320 @@
+package bacnet
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Point list CSV format
+//
+// A point list starts with a header row naming its columns, in any order and case:
+//
+//	device,object_type,object_instance,property,name,units,poll_rate
+//
+// device, object_type and object_instance are required; the other columns are optional.
+// Spaces, dashes and underscores in column names are ignored, and the column names used by common
+// engineering tool exports ("Device Instance", "Object Type", "Instance", "Object Name",
+// "Engineering Units", "Poll Interval") are accepted too.
+//
+//   - object_type is a number, a name ("AnalogInput", "analog-input") or an abbreviation ("AI").
+//   - property is a number or a name ("PresentValue", "present-value") and defaults to Present_Value.
+//   - units is free text, e.g. "°C" or "degrees-celsius".
+//   - poll_rate is a number of seconds or a duration ("30s", "5m"). An empty value, 0 or "cov"
+//     selects a COV subscription instead of polling.
+//
+// Empty rows and rows whose first cell starts with "#" are skipped.
+
+// PointListEntry is one row of a point list.
+type PointListEntry struct {
+	DeviceID   uint32       `json:"deviceId"`
+	Object     BACnetObject `json:"object"`
+	PropertyID uint32       `json:"propertyId"`
+	Name       string       `json:"name,omitempty"`
+	Units      string       `json:"units,omitempty"`
+	// PollRate is the polling interval; zero means the point is monitored through COV.
+	PollRate time.Duration `json:"pollRate,omitempty"`
+}
+
+// Ref returns the point the entry refers to.
+func (e PointListEntry) Ref() PointRef {
+	return PointRef{DeviceID: e.DeviceID, Object: e.Object}
+}
+
+// Meta returns the registry metadata of the entry, with the units as the "unit" tag.
+func (e PointListEntry) Meta() PointMeta {
+	meta := PointMeta{Name: e.Name}
+	if e.Units != "" {
+		meta.Tags = map[string]string{"unit": e.Units}
+	}
+	return meta
+}
+
+// PollPoint returns the Poller configuration of the entry on device.
+func (e PointListEntry) PollPoint(device DeviceInfo) PollPoint {
+	return PollPoint{Device: device, Object: e.Object, PropertyID: e.PropertyID}
+}
+
+// COVSubscription returns the COVManager configuration of the entry on device.
+func (e PointListEntry) COVSubscription(device DeviceInfo, processID uint32) COVSubscriptionConfig {
+	return COVSubscriptionConfig{Device: device, Object: e.Object, SubscriberProcessIdentifier: processID}
+}
+
+// PollGroups returns the polled entries grouped by poll rate, for one Poller per rate, and the
+// entries monitored through COV.
+func PollGroups(entries []PointListEntry) (polled map[time.Duration][]PointListEntry, cov []PointListEntry) {
+	polled = make(map[time.Duration][]PointListEntry)
+	for _, e := range entries {
+		if e.PollRate == 0 {
+			cov = append(cov, e)
+			continue
+		}
+		polled[e.PollRate] = append(polled[e.PollRate], e)
+	}
+	return polled, cov
+}
+
+const (
+	pointColumnDevice   = "device"
+	pointColumnType     = "objecttype"
+	pointColumnInstance = "objectinstance"
+	pointColumnProperty = "property"
+	pointColumnName     = "name"
+	pointColumnUnits    = "units"
+	pointColumnPollRate = "pollrate"
+)
+
+// pointColumnAliases maps normalized header names to columns.
+var pointColumnAliases = map[string]string{
+	"device":           pointColumnDevice,
+	"deviceid":         pointColumnDevice,
+	"deviceinstance":   pointColumnDevice,
+	"objecttype":       pointColumnType,
+	"type":             pointColumnType,
+	"objectinstance":   pointColumnInstance,
+	"instance":         pointColumnInstance,
+	"property":         pointColumnProperty,
+	"propertyid":       pointColumnProperty,
+	"propertyname":     pointColumnProperty,
+	"name":             pointColumnName,
+	"objectname":       pointColumnName,
+	"pointname":        pointColumnName,
+	"units":            pointColumnUnits,
+	"unit":             pointColumnUnits,
+	"engineeringunits": pointColumnUnits,
+	"pollrate":         pointColumnPollRate,
+	"pollinterval":     pointColumnPollRate,
+	"interval":         pointColumnPollRate,
+}
+
+// objectTypeAbbreviations are the object type abbreviations used by engineering tools.
+var objectTypeAbbreviations = map[string]ObjectType{
+	"ai":  OBJECT_ANALOG_INPUT,
+	"ao":  OBJECT_ANALOG_OUTPUT,
+	"av":  OBJECT_ANALOG_VALUE,
+	"bi":  OBJECT_BINARY_INPUT,
+	"bo":  OBJECT_BINARY_OUTPUT,
+	"bv":  OBJECT_BINARY_VALUE,
+	"msi": OBJECT_MULTI_STATE_INPUT,
+	"mso": OBJECT_MULTI_STATE_OUTPUT,
+	"msv": OBJECT_MULTI_STATE_VALUE,
+	"dev": OBJECT_DEVICE,
+	"tl":  OBJECT_TREND_LOG,
+	"sch": OBJECT_SCHEDULE,
+	"nc":  OBJECT_NOTIFICATION_CLASS,
+}
+
+// normalizeName lowercases s and strips spaces, dashes and underscores, so that "Present_Value",
+// "present-value" and "PresentValue" compare equal.
+func normalizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(s)))
+}
+
+// ParseObjectType parses an object type number, name or abbreviation.
+func ParseObjectType(s string) (ObjectType, error) {
+	if n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 10); err == nil {
+		return ObjectType(n), nil
+	}
+	name := normalizeName(s)
+	if t, ok := objectTypeAbbreviations[name]; ok {
+		return t, nil
+	}
+	for t, typeName := range ObjectTypeNames {
+		if normalizeName(typeName) == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown object type %q", s)
+}
+
+// ParsePropertyID parses a property identifier number or name.
+func ParsePropertyID(s string) (uint32, error) {
+	if n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 22); err == nil {
+		return uint32(n), nil
+	}
+	name := normalizeName(s)
+	for id, propName := range PropertyNames {
+		if normalizeName(propName) == name {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown property %q", s)
+}
+
+// parsePollRate parses a poll rate in seconds or as a duration; empty, 0 and "cov" mean COV.
+func parsePollRate(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "cov") {
+		return 0, nil
+	}
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("negative poll rate %q", s)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid poll rate %q", s)
+	}
+	return d, nil
+}
+
+// ReadPointList reads a point list in the CSV format described above.
+func ReadPointList(r io.Reader) ([]PointListEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("point list is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read point list header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		// Excel exports often start with a byte order mark
+		if col, ok := pointColumnAliases[normalizeName(strings.TrimPrefix(h, "\ufeff"))]; ok {
+			if _, dup := columns[col]; !dup {
+				columns[col] = i
+			}
+		}
+	}
+	for _, col := range []string{pointColumnDevice, pointColumnType, pointColumnInstance} {
+		if _, ok := columns[col]; !ok {
+			return nil, fmt.Errorf("point list has no %s column", col)
+		}
+	}
+
+	var entries []PointListEntry
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read point list: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if isBlankRecord(record) {
+			continue
+		}
+		entry, err := parsePointListRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("point list line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePointListRecord(record []string, columns map[string]int) (PointListEntry, error) {
+	cell := func(col string) string {
+		i, ok := columns[col]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	entry := PointListEntry{
+		PropertyID: PROP_PRESENT_VALUE,
+		Name:       cell(pointColumnName),
+		Units:      cell(pointColumnUnits),
+	}
+	device, err := strconv.ParseUint(cell(pointColumnDevice), 10, 22)
+	if err != nil {
+		return entry, fmt.Errorf("invalid device instance %q", cell(pointColumnDevice))
+	}
+	entry.DeviceID = uint32(device)
+	if entry.Object.Type, err = ParseObjectType(cell(pointColumnType)); err != nil {
+		return entry, err
+	}
+	instance, err := strconv.ParseUint(cell(pointColumnInstance), 10, 22)
+	if err != nil {
+		return entry, fmt.Errorf("invalid object instance %q", cell(pointColumnInstance))
+	}
+	entry.Object.Instance = uint32(instance)
+	if s := cell(pointColumnProperty); s != "" {
+		if entry.PropertyID, err = ParsePropertyID(s); err != nil {
+			return entry, err
+		}
+	}
+	if entry.PollRate, err = parsePollRate(cell(pointColumnPollRate)); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// WritePointList writes entries in the CSV format described above, with object types and
+// properties by name.
+func WritePointList(w io.Writer, entries []PointListEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"device", "object_type", "object_instance", "property", "name", "units", "poll_rate"})
+	for _, e := range entries {
+		typeName, ok := ObjectTypeNames[e.Object.Type]
+		if !ok {
+			typeName = strconv.FormatUint(uint64(e.Object.Type), 10)
+		}
+		propName, ok := PropertyNames[e.PropertyID]
+		if !ok {
+			propName = strconv.FormatUint(uint64(e.PropertyID), 10)
+		}
+		pollRate := "cov"
+		if e.PollRate > 0 {
+			pollRate = e.PollRate.String()
+		}
+		cw.Write([]string{
+			strconv.FormatUint(uint64(e.DeviceID), 10),
+			typeName,
+			strconv.FormatUint(uint64(e.Object.Instance), 10),
+			propName,
+			e.Name,
+			e.Units,
+			pollRate,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,223 @@
+package bacnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PointRef identifies an object on a specific device.
+type PointRef struct {
+	DeviceID uint32       `json:"deviceId"`
+	Object   BACnetObject `json:"object"`
+}
+
+// String returns the point in the form "<device>:<ObjectType>:<instance>", e.g. "1001:AnalogInput:3".
+func (p PointRef) String() string {
+	return fmt.Sprintf("%d:%s", p.DeviceID, p.Object)
+}
+
+// PointMeta is the application-level metadata attached to a point.
+type PointMeta struct {
+	// Name is the friendly name of the point, unique within a registry.
+	Name string `json:"name"`
+	// Aliases are additional unique names the point can be looked up by.
+	Aliases []string `json:"aliases,omitempty"`
+	// Tags are Haystack-style tags. Marker tags (e.g. "sensor", "temp") have an empty value,
+	// value tags (e.g. "unit": "°C") carry their value.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// PointRegistry associates friendly names, aliases and tags with (device, object) pairs. Set it in
+// ClientOptions.Points to have COV notifications and Poller updates carry the names.
+// It is safe for concurrent use.
+type PointRegistry struct {
+	mu     sync.RWMutex
+	points map[PointRef]PointMeta
+	names  map[string]PointRef // names and aliases
+}
+
+// NewPointRegistry returns an empty registry.
+func NewPointRegistry() *PointRegistry {
+	return &PointRegistry{
+		points: make(map[PointRef]PointMeta),
+		names:  make(map[string]PointRef),
+	}
+}
+
+// Set attaches meta to ref, replacing any metadata it had before.
+// It fails if the name or one of the aliases already belongs to another point, or if meta uses a
+// name twice, e.g. as its name and as an alias.
+func (r *PointRegistry) Set(ref PointRef, meta PointMeta) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used := make(map[string]bool)
+	for _, name := range meta.names() {
+		if used[name] {
+			return fmt.Errorf("point name %q of %s is used twice", name, ref)
+		}
+		used[name] = true
+		if owner, ok := r.names[name]; ok && owner != ref {
+			return fmt.Errorf("point name %q is already used by %s", name, owner)
+		}
+	}
+
+	r.removeLocked(ref)
+	r.points[ref] = meta
+	for _, name := range meta.names() {
+		r.names[name] = ref
+	}
+	return nil
+}
+
+// Remove deletes the metadata of ref.
+func (r *PointRegistry) Remove(ref PointRef) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(ref)
+}
+
+func (r *PointRegistry) removeLocked(ref PointRef) {
+	old, ok := r.points[ref]
+	if !ok {
+		return
+	}
+	for _, name := range old.names() {
+		delete(r.names, name)
+	}
+	delete(r.points, ref)
+}
+
+// Get returns the metadata of ref.
+func (r *PointRegistry) Get(ref PointRef) (PointMeta, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	meta, ok := r.points[ref]
+	return meta, ok
+}
+
+// Resolve looks up a point by name or alias.
+func (r *PointRegistry) Resolve(name string) (PointRef, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ref, ok := r.names[name]
+	return ref, ok
+}
+
+// Name returns the friendly name of ref, or ref.String() if it has none.
+// Use it to key values coming from reads or COV notifications by application-level names.
+func (r *PointRegistry) Name(ref PointRef) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if meta, ok := r.points[ref]; ok && meta.Name != "" {
+		return meta.Name
+	}
+	return ref.String()
+}
+
+// pointName returns the name of ref in ClientOptions.Points, or "" if the client has no registry.
+func (c *BACnetClient) pointName(ref PointRef) string {
+	if c == nil || c.options.Points == nil {
+		return ""
+	}
+	return c.options.Points.Name(ref)
+}
+
+// NotificationName returns the friendly name of the object a COV notification reports on.
+func (r *PointRegistry) NotificationName(n COVNotification) string {
+	return r.Name(PointRef{DeviceID: n.InitiatingDeviceIdentifier.Instance, Object: n.MonitoredObjectIdentifier})
+}
+
+// FindByTags returns all points carrying every given tag, sorted by device and object.
+// A tag is either a bare name ("temp"), which matches regardless of value, or "name=value".
+func (r *PointRegistry) FindByTags(tags ...string) []PointRef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var refs []PointRef
+	for ref, meta := range r.points {
+		if meta.hasTags(tags) {
+			refs = append(refs, ref)
+		}
+	}
+	sortPointRefs(refs)
+	return refs
+}
+
+// Save writes the registry to w as JSON, ordered by device and object.
+func (r *PointRegistry) Save(w io.Writer) error {
+	r.mu.RLock()
+	entries := make([]registryEntry, 0, len(r.points))
+	for ref, meta := range r.points {
+		entries = append(entries, registryEntry{Point: ref, PointMeta: meta})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return pointRefLess(entries[i].Point, entries[j].Point) })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// Load replaces the contents of the registry with the JSON read from rd.
+func (r *PointRegistry) Load(rd io.Reader) error {
+	var entries []registryEntry
+	if err := json.NewDecoder(rd).Decode(&entries); err != nil {
+		return fmt.Errorf("failed to decode point registry: %w", err)
+	}
+
+	loaded := NewPointRegistry()
+	for _, entry := range entries {
+		if err := loaded.Set(entry.Point, entry.PointMeta); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.points, r.names = loaded.points, loaded.names
+	return nil
+}
+
+type registryEntry struct {
+	Point PointRef `json:"point"`
+	PointMeta
+}
+
+func (m PointMeta) names() []string {
+	var names []string
+	if m.Name != "" {
+		names = append(names, m.Name)
+	}
+	return append(names, m.Aliases...)
+}
+
+func (m PointMeta) hasTags(tags []string) bool {
+	for _, tag := range tags {
+		name, value, hasValue := strings.Cut(tag, "=")
+		got, ok := m.Tags[name]
+		if !ok || (hasValue && got != value) {
+			return false
+		}
+	}
+	return true
+}
+
+func pointRefLess(a, b PointRef) bool {
+	if a.DeviceID != b.DeviceID {
+		return a.DeviceID < b.DeviceID
+	}
+	if a.Object.Type != b.Object.Type {
+		return a.Object.Type < b.Object.Type
+	}
+	return a.Object.Instance < b.Object.Instance
+}
+
+func sortPointRefs(refs []PointRef) {
+	sort.Slice(refs, func(i, j int) bool { return pointRefLess(refs[i], refs[j]) })
+}
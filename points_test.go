@@ -0,0 +1,78 @@
+package bacnet
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPointRegistryRejectsReusedNames(t *testing.T) {
+	r := NewPointRegistry()
+	ai1 := PointRef{DeviceID: 1, Object: BACnetObject{Type: OBJECT_ANALOG_INPUT, Instance: 1}}
+	ai2 := PointRef{DeviceID: 1, Object: BACnetObject{Type: OBJECT_ANALOG_INPUT, Instance: 2}}
+
+	if err := r.Set(ai1, PointMeta{Name: "zone-temp", Aliases: []string{"zone-temp"}}); err == nil {
+		t.Error("name equal to an alias of the same point accepted")
+	}
+	if err := r.Set(ai1, PointMeta{Name: "zone-temp", Aliases: []string{"zt", "zt"}}); err == nil {
+		t.Error("alias given twice accepted")
+	}
+	if _, ok := r.Get(ai1); ok {
+		t.Error("rejected metadata was stored")
+	}
+
+	if err := r.Set(ai1, PointMeta{Name: "zone-temp", Aliases: []string{"zt"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(ai2, PointMeta{Name: "zt"}); err == nil {
+		t.Error("alias of another point accepted as a name")
+	}
+	// A point may keep its names when its metadata is replaced.
+	if err := r.Set(ai1, PointMeta{Name: "zt", Aliases: []string{"zone-temp"}}); err != nil {
+		t.Errorf("swapping name and alias: %v", err)
+	}
+	if ref, ok := r.Resolve("zone-temp"); !ok || ref != ai1 {
+		t.Errorf("Resolve(zone-temp) = %s, %t", ref, ok)
+	}
+}
+
+func TestPointNamesInOutput(t *testing.T) {
+	sim, device := startSimulator(t, SimulatorOptions{DeviceID: 3006, AnalogValues: 2})
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+	av2 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 2}
+
+	points := NewPointRegistry()
+	if err := points.Set(PointRef{DeviceID: 3006, Object: av1}, PointMeta{Name: "supply-temp"}); err != nil {
+		t.Fatal(err)
+	}
+	client := newLoopbackClient(t, ClientOptions{Points: points})
+
+	poller := NewPoller(client, 0)
+	poller.Add(PollPoint{Device: device, Object: av1, PropertyID: PROP_PRESENT_VALUE})
+	poller.Add(PollPoint{Device: device, Object: av2, PropertyID: PROP_PRESENT_VALUE})
+	names := make(map[string]bool)
+	poller.poll(context.Background(), func(u PollUpdate) {
+		if u.Err != nil {
+			t.Errorf("%s: %v", u.Point.Object, u.Err)
+		}
+		names[u.PointName] = true
+	})
+	if len(names) != 2 || !names["supply-temp"] || !names["3006:"+av2.String()] {
+		t.Errorf("poll updates named %v", names)
+	}
+
+	manager := NewCOVManager(context.Background(), client)
+	defer manager.Close()
+	manager.Subscribe(COVSubscriptionConfig{Device: device, Object: av1, SubscriberProcessIdentifier: 1, Lifetime: 60})
+	sim.Set(av1, float32(3))
+	select {
+	case n := <-manager.Notifications():
+		if n.PointName != "supply-temp" {
+			t.Errorf("notification named %q", n.PointName)
+		}
+	case err := <-manager.Errors():
+		t.Fatal(err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification")
+	}
+}
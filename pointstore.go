@@ -0,0 +1,166 @@
+package bacnet
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PointKey identifies a property of an object on a specific device.
+type PointKey struct {
+	PointRef
+	PropertyID uint32 `json:"propertyId"`
+}
+
+// PointValue is the latest known value of a point.
+type PointValue struct {
+	Key   PointKey    `json:"key"`
+	Value interface{} `json:"value,omitempty"`
+	// Err is set when the last attempt to read the point failed; Value then holds the last good value.
+	Err  error     `json:"-"`
+	Time time.Time `json:"time"`
+}
+
+// PointStore keeps the latest value of a set of configured points, fed from Poller updates and COV
+// notifications, and notifies watchers of changes. It is safe for concurrent use.
+type PointStore struct {
+	mu       sync.RWMutex
+	values   map[PointKey]*PointValue
+	watchers map[*pointWatcher]struct{}
+}
+
+type pointWatcher struct {
+	keys map[PointKey]bool // Nil watches all points
+	ch   chan PointValue
+}
+
+// NewPointStore returns an empty store.
+func NewPointStore() *PointStore {
+	return &PointStore{
+		values:   make(map[PointKey]*PointValue),
+		watchers: make(map[*pointWatcher]struct{}),
+	}
+}
+
+// Add configures a point. Updates for points that were not added are ignored.
+func (s *PointStore) Add(key PointKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; !ok {
+		s.values[key] = &PointValue{Key: key}
+	}
+}
+
+// Remove drops a point and its value.
+func (s *PointStore) Remove(key PointKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// Get returns the latest value of a point. It reports false for points that were not added; the
+// returned value has a zero Time until the first update arrives.
+func (s *PointStore) Get(key PointKey) (PointValue, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[key]
+	if !ok {
+		return PointValue{}, false
+	}
+	return *v, true
+}
+
+// Values returns the latest values of all points, sorted by device, object and property.
+func (s *PointStore) Values() []PointValue {
+	s.mu.RLock()
+	values := make([]PointValue, 0, len(s.values))
+	for _, v := range s.values {
+		values = append(values, *v)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(values, func(i, j int) bool {
+		a, b := values[i].Key, values[j].Key
+		if a.PointRef != b.PointRef {
+			return pointRefLess(a.PointRef, b.PointRef)
+		}
+		return a.PropertyID < b.PropertyID
+	})
+	return values
+}
+
+// Set records a value (or read error) of a point and notifies watchers if it changed.
+func (s *PointStore) Set(key PointKey, value interface{}, err error, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.values[key]
+	if !ok {
+		return
+	}
+	changed := current.Time.IsZero() || (err == nil) != (current.Err == nil)
+	if err == nil {
+		changed = changed || !ValuesEqual(current.Value, value)
+		current.Value = value
+	}
+	current.Err = err
+	current.Time = t
+	if !changed {
+		return
+	}
+
+	for w := range s.watchers {
+		if w.keys != nil && !w.keys[key] {
+			continue
+		}
+		select {
+		case w.ch <- *current:
+		default: // Slow watcher, drop the update
+		}
+	}
+}
+
+// UpdateFromPoll records a Poller update.
+func (s *PointStore) UpdateFromPoll(update PollUpdate) {
+	key := PointKey{
+		PointRef:   PointRef{DeviceID: update.Point.Device.DeviceID, Object: update.Point.Object},
+		PropertyID: update.Point.PropertyID,
+	}
+	s.Set(key, update.Value, update.Err, update.Time)
+}
+
+// UpdateFromCOV records all values of a COV notification.
+func (s *PointStore) UpdateFromCOV(n COVNotification) {
+	ref := PointRef{DeviceID: n.InitiatingDeviceIdentifier.Instance, Object: n.MonitoredObjectIdentifier}
+	now := time.Now()
+	for _, prop := range n.ListOfValues {
+		s.Set(PointKey{PointRef: ref, PropertyID: prop.PropertyID}, prop.Value, nil, now)
+	}
+}
+
+// Watch returns a channel receiving the new value whenever one of the given points changes, or any
+// point if none are given. Updates are dropped while the channel is full. The channel is closed
+// when ctx is cancelled.
+func (s *PointStore) Watch(ctx context.Context, keys ...PointKey) <-chan PointValue {
+	w := &pointWatcher{ch: make(chan PointValue, 64)}
+	if len(keys) > 0 {
+		w.keys = make(map[PointKey]bool, len(keys))
+		for _, key := range keys {
+			w.keys[key] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.watchers[w] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.watchers, w)
+		close(w.ch)
+		s.mu.Unlock()
+	}()
+	return w.ch
+}
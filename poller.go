@@ -0,0 +1,181 @@
+package bacnet
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PollPoint is a property read periodically by a Poller.
+type PollPoint struct {
+	Device     DeviceInfo   `json:"device"`
+	Object     BACnetObject `json:"object"`
+	PropertyID uint32       `json:"propertyId"`
+	// Deadband suppresses updates of analog values that moved by no more than this amount.
+	Deadband float64 `json:"deadband,omitempty"`
+}
+
+// PollUpdate is emitted by a Poller when a point's value changed or could not be read.
+type PollUpdate struct {
+	Point PollPoint
+	// PointName is the name of the point's object in ClientOptions.Points, or its PointRef string
+	// if it has no name there. It is empty if the client has no registry.
+	PointName string
+	Value     interface{}
+	Err       error
+	Time      time.Time
+}
+
+type pollKey struct {
+	deviceID   uint32
+	object     BACnetObject
+	propertyID uint32
+}
+
+func (p PollPoint) key() pollKey {
+	return pollKey{deviceID: p.Device.DeviceID, object: p.Object, propertyID: p.PropertyID}
+}
+
+// Poller reads a set of points at a fixed interval and emits only values that changed beyond the
+// point's deadband. Points of the same device and property are read with a single
+// ReadPropertyMultiple request per cycle.
+type Poller struct {
+	client   *BACnetClient
+	interval time.Duration
+
+	mu     sync.Mutex
+	points map[pollKey]PollPoint
+	last   map[pollKey]interface{}
+}
+
+// NewPoller returns a poller reading through client every interval.
+func NewPoller(client *BACnetClient, interval time.Duration) *Poller {
+	return &Poller{
+		client:   client,
+		interval: interval,
+		points:   make(map[pollKey]PollPoint),
+		last:     make(map[pollKey]interface{}),
+	}
+}
+
+// Add starts polling point. Adding a point that is already polled updates its deadband.
+func (p *Poller) Add(point PollPoint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.points[point.key()] = point
+}
+
+// Remove stops polling the given property.
+func (p *Poller) Remove(deviceID uint32, object BACnetObject, propertyID uint32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := pollKey{deviceID: deviceID, object: object, propertyID: propertyID}
+	delete(p.points, key)
+	delete(p.last, key)
+}
+
+// Points returns the points currently polled, ordered by device, object and property.
+func (p *Poller) Points() []PollPoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	points := make([]PollPoint, 0, len(p.points))
+	for _, point := range p.points {
+		points = append(points, point)
+	}
+	sortPollPoints(points)
+	return points
+}
+
+func sortPollPoints(points []PollPoint) {
+	sort.Slice(points, func(i, j int) bool {
+		a := PointRef{DeviceID: points[i].Device.DeviceID, Object: points[i].Object}
+		b := PointRef{DeviceID: points[j].Device.DeviceID, Object: points[j].Object}
+		if a != b {
+			return pointRefLess(a, b)
+		}
+		return points[i].PropertyID < points[j].PropertyID
+	})
+}
+
+// Run polls until ctx is cancelled, calling fn for every change. The first successful read of a
+// point is always reported.
+func (p *Poller) Run(ctx context.Context, fn func(PollUpdate)) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll(ctx, fn)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll runs a single polling cycle.
+func (p *Poller) poll(ctx context.Context, fn func(PollUpdate)) {
+	type group struct {
+		device     DeviceInfo
+		propertyID uint32
+		objects    []BACnetObject
+	}
+	type groupKey struct {
+		deviceID   uint32
+		propertyID uint32
+	}
+
+	// Groups and their objects are in the order of Points, so that every cycle sends the same
+	// requests.
+	var groups []*group
+	index := make(map[groupKey]*group)
+	for _, point := range p.Points() {
+		key := groupKey{deviceID: point.Device.DeviceID, propertyID: point.PropertyID}
+		g, ok := index[key]
+		if !ok {
+			g = &group{device: point.Device, propertyID: point.PropertyID}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.objects = append(g.objects, point.Object)
+	}
+
+	for _, g := range groups {
+		seen := make(map[BACnetObject]bool, len(g.objects))
+		err := p.client.ReadPropertyMultipleStream(g.device, g.objects, []uint32{g.propertyID}, func(result RPMResult) bool {
+			seen[result.Object] = true
+			p.report(pollKey{deviceID: g.device.DeviceID, object: result.Object, propertyID: result.PropertyID}, result.Value, result.Err, fn)
+			return true
+		}, CallContext(ctx))
+		if err != nil {
+			for _, object := range g.objects {
+				if !seen[object] {
+					p.report(pollKey{deviceID: g.device.DeviceID, object: object, propertyID: g.propertyID}, nil, err, fn)
+				}
+			}
+		}
+	}
+}
+
+// report emits an update for key if the read failed or the value changed beyond the deadband.
+func (p *Poller) report(key pollKey, value interface{}, err error, fn func(PollUpdate)) {
+	p.mu.Lock()
+	point, ok := p.points[key]
+	if !ok {
+		p.mu.Unlock()
+		return // Removed while polling
+	}
+	if err == nil {
+		prev, seen := p.last[key]
+		if seen && !ValueChanged(prev, value, point.Deadband) {
+			p.mu.Unlock()
+			return
+		}
+		p.last[key] = value
+	}
+	p.mu.Unlock()
+
+	name := p.client.pointName(PointRef{DeviceID: key.deviceID, Object: key.object})
+	fn(PollUpdate{Point: point, PointName: name, Value: value, Err: err, Time: time.Now()})
+}
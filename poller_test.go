@@ -0,0 +1,104 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestPollerRequestsStable checks that every polling cycle sends the same ReadPropertyMultiple
+// requests, whatever order the points were added in, and that they decode to the points in order.
+func TestPollerRequestsStable(t *testing.T) {
+	_, device := startSimulator(t, SimulatorOptions{DeviceID: 3001, AnalogValues: 4, BinaryValues: 2})
+
+	var mu sync.Mutex
+	var requests [][]byte
+	client := newLoopbackClient(t, ClientOptions{OnFrame: func(f Frame) {
+		if f.Direction == FrameSent && f.Packet.PDUType == APDU_CONFIRMED_REQUEST {
+			mu.Lock()
+			requests = append(requests, f.Packet.APDU[4:]) // Service parameters, without invoke ID
+			mu.Unlock()
+		}
+	}})
+
+	poller := NewPoller(client, 0)
+	objects := []BACnetObject{
+		{Type: OBJECT_BINARY_VALUE, Instance: 2},
+		{Type: OBJECT_ANALOG_VALUE, Instance: 3},
+		{Type: OBJECT_ANALOG_VALUE, Instance: 1},
+		{Type: OBJECT_BINARY_VALUE, Instance: 1},
+		{Type: OBJECT_ANALOG_VALUE, Instance: 4},
+	}
+	for _, object := range objects {
+		poller.Add(PollPoint{Device: device, Object: object, PropertyID: PROP_PRESENT_VALUE})
+		poller.Add(PollPoint{Device: device, Object: object, PropertyID: PROP_STATUS_FLAGS})
+	}
+
+	cycle := func() [][]byte {
+		mu.Lock()
+		requests = nil
+		mu.Unlock()
+		poller.poll(context.Background(), func(u PollUpdate) {
+			if u.Err != nil {
+				t.Errorf("%s of %s: %v", propertyLabel(u.Point.PropertyID), u.Point.Object, u.Err)
+			}
+		})
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+	first := cycle()
+	if len(first) != 2 { // One request per property
+		t.Fatalf("cycle sent %d requests, want 2", len(first))
+	}
+	for range 5 {
+		next := cycle()
+		if len(next) != len(first) {
+			t.Fatalf("cycle sent %d requests, then %d", len(first), len(next))
+		}
+		for i := range first {
+			if !bytes.Equal(next[i], first[i]) {
+				t.Fatalf("request %d changed between cycles: %x, then %x", i, first[i], next[i])
+			}
+		}
+	}
+
+	points := poller.Points()
+	for i, raw := range first {
+		specs, err := decodeReadAccessSpecifications(raw)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		var j int
+		for _, point := range points {
+			if point.PropertyID != specs[0].Properties[0].PropertyID {
+				continue
+			}
+			if j >= len(specs) || specs[j].Object != point.Object {
+				t.Errorf("request %d does not read %s in position %d: %+v", i, point.Object, j, specs)
+			}
+			j++
+		}
+	}
+}
+
+func TestPollerPointsOrdered(t *testing.T) {
+	poller := NewPoller(nil, 0)
+	devices := []DeviceInfo{{DeviceID: 20}, {DeviceID: 10}}
+	for _, device := range devices {
+		for _, instance := range []uint32{5, 1, 3} {
+			poller.Add(PollPoint{Device: device, Object: BACnetObject{Type: OBJECT_ANALOG_INPUT, Instance: instance}, PropertyID: PROP_PRESENT_VALUE})
+			poller.Add(PollPoint{Device: device, Object: BACnetObject{Type: OBJECT_ANALOG_INPUT, Instance: instance}, PropertyID: PROP_OBJECT_NAME})
+		}
+	}
+	points := poller.Points()
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		ra := PointRef{DeviceID: a.Device.DeviceID, Object: a.Object}
+		rb := PointRef{DeviceID: b.Device.DeviceID, Object: b.Object}
+		if pointRefLess(rb, ra) || (ra == rb && b.PropertyID < a.PropertyID) {
+			t.Fatalf("points out of order at %d: %+v before %+v", i, a, b)
+		}
+	}
+}
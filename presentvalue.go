@@ -0,0 +1,146 @@
+package bacnet
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// CoercePresentValue converts value to the Go type that is encoded with the datatype of the
+// Present_Value of the given object type, e.g. float64 to float32 for an Analog Value but to
+// float64 (Double) for a Large Analog Value, int to uint32 for a Positive Integer Value, or a
+// time.Duration to milliseconds for a Timer.
+// Values of object types without a known Present_Value datatype are returned unchanged.
+func CoercePresentValue(objectType ObjectType, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil // Relinquish
+	}
+
+	switch objectType {
+	case OBJECT_ANALOG_INPUT, OBJECT_ANALOG_OUTPUT, OBJECT_ANALOG_VALUE, OBJECT_STAGING:
+		f, ok := numericValue(value)
+		if !ok {
+			break
+		}
+		return float32(f), nil
+	case OBJECT_LARGE_ANALOG_VALUE:
+		f, ok := numericValue(value)
+		if !ok {
+			break
+		}
+		return f, nil
+	case OBJECT_INTEGER_VALUE:
+		f, ok := numericValue(value)
+		if !ok || f != math.Trunc(f) || f < math.MinInt32 || f > math.MaxInt32 {
+			break
+		}
+		return int32(f), nil
+	case OBJECT_POSITIVE_INTEGER_VALUE, OBJECT_MULTI_STATE_INPUT, OBJECT_MULTI_STATE_OUTPUT, OBJECT_MULTI_STATE_VALUE:
+		f, ok := numericValue(value)
+		if !ok || f != math.Trunc(f) || f < 0 || f > math.MaxUint32 {
+			break
+		}
+		return uint32(f), nil
+	case OBJECT_TIMER:
+		// Time remaining in milliseconds
+		if d, ok := value.(time.Duration); ok {
+			value = d.Milliseconds()
+		}
+		f, ok := numericValue(value)
+		if !ok || f != math.Trunc(f) || f < 0 || f > math.MaxUint32 {
+			break
+		}
+		return uint32(f), nil
+	case OBJECT_BINARY_INPUT, OBJECT_BINARY_OUTPUT, OBJECT_BINARY_VALUE:
+		switch v := value.(type) {
+		case bool:
+			if v {
+				return BinaryActive, nil
+			}
+			return BinaryInactive, nil
+		case BinaryPV:
+			if v <= BinaryActive {
+				return v, nil
+			}
+		case Enumerated:
+			if v <= 1 {
+				return BinaryPV(v), nil
+			}
+		default:
+			if f, ok := numericValue(value); ok && (f == 0 || f == 1) {
+				return BinaryPV(f), nil
+			}
+		}
+	case OBJECT_CHARACTERSTRING_VALUE:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case OBJECT_OCTETSTRING_VALUE:
+		if b, ok := value.([]byte); ok {
+			return b, nil
+		}
+	case OBJECT_DATE_VALUE:
+		if d, ok := value.(Date); ok {
+			return d, nil
+		}
+	case OBJECT_TIME_VALUE:
+		if t, ok := value.(Time); ok {
+			return t, nil
+		}
+	case OBJECT_DATETIME_VALUE:
+		if dt, ok := value.(DateTime); ok {
+			return dt, nil
+		}
+	default:
+		return value, nil
+	}
+	return nil, fmt.Errorf("cannot use %v (%T) as present value of %s", value, value, objectTypeLabel(objectType))
+}
+
+// typedPropertyValue turns a decoded property value into its natural Go type where that depends on
+// the object type: a DateTime Present_Value decodes as a Date followed by a Time and is combined
+// into a DateTime, and the Present_Value and Polarity of binary objects become BinaryPV and Polarity.
+func typedPropertyValue(objectType ObjectType, propertyID uint32, value interface{}) interface{} {
+	switch propertyID {
+	case PROP_PRESENT_VALUE:
+		switch objectType {
+		case OBJECT_DATETIME_VALUE:
+			if pair, ok := value.([]interface{}); ok && len(pair) == 2 {
+				d, dateOK := pair[0].(Date)
+				t, timeOK := pair[1].(Time)
+				if dateOK && timeOK {
+					return DateTime{Date: d, Time: t}
+				}
+			}
+		case OBJECT_BINARY_INPUT, OBJECT_BINARY_OUTPUT, OBJECT_BINARY_VALUE:
+			if v, ok := value.(uint32); ok {
+				return BinaryPV(v)
+			}
+		}
+	case PROP_POLARITY:
+		if v, ok := value.(uint32); ok && isBinaryObjectType(objectType) {
+			return Polarity(v)
+		}
+	}
+	return value
+}
+
+// ReadPresentValue reads the Present_Value of an object, decoded to the Go type matching the
+// object type (see CoercePresentValue).
+func (c *BACnetClient) ReadPresentValue(device DeviceInfo, object BACnetObject, opts ...CallOption) (interface{}, error) {
+	value, err := c.readProperty(callContext(opts...), device, object, uint32(PROP_PRESENT_VALUE), nil)
+	if err != nil {
+		return nil, err
+	}
+	return typedPropertyValue(object.Type, PROP_PRESENT_VALUE, value), nil
+}
+
+// WritePresentValue writes the Present_Value of an object at the given priority (0 for none),
+// converting value to the datatype of the object type first. A nil value relinquishes the priority.
+func (c *BACnetClient) WritePresentValue(device DeviceInfo, object BACnetObject, value interface{}, priority uint8, opts ...CallOption) error {
+	coerced, err := CoercePresentValue(object.Type, value)
+	if err != nil {
+		return err
+	}
+	return c.WriteProperty(device, object, uint32(PROP_PRESENT_VALUE), coerced, priority, opts...)
+}
@@ -0,0 +1,50 @@
+package bacnet
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// InterfaceAddrs returns the BACnet/IP address of the first IPv4 address of a network interface,
+// together with the directed broadcast address of its subnet.
+func InterfaceAddrs(ifaceName string) (local, broadcast *net.UDPAddr, err error) {
+	intf, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find interface %s: %w", ifaceName, err)
+	}
+	addrs, err := intf.Addrs()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get addresses for interface %s: %w", ifaceName, err)
+	}
+
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.To4() == nil {
+			continue
+		}
+		ip := ipnet.IP.To4()
+		mask := ipnet.Mask[len(ipnet.Mask)-4:]
+		broadcastIP := make(net.IP, len(ip))
+		for i := range ip {
+			broadcastIP[i] = ip[i] | ^mask[i]
+		}
+		return &net.UDPAddr{IP: ip, Port: BACNET_DEFAULT_PORT}, &net.UDPAddr{IP: broadcastIP, Port: BACNET_DEFAULT_PORT}, nil
+	}
+	return nil, nil, fmt.Errorf("could not find a suitable IPv4 address on interface %s", ifaceName)
+}
+
+// QuickClient creates a client bound to the BACnet/IP port on the first IPv4 address of a network
+// interface, with a 5 second request timeout. It also returns the broadcast address to use for
+// discovery on that interface.
+func QuickClient(ifaceName string) (*BACnetClient, *net.UDPAddr, error) {
+	local, broadcast, err := InterfaceAddrs(ifaceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := NewClient(ClientOptions{LocalAddr: local, Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, broadcast, nil
+}
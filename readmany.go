@@ -0,0 +1,68 @@
+package bacnet
+
+import (
+	"context"
+	"sync"
+)
+
+// ReadRequest is one property read of a ReadMany batch.
+type ReadRequest struct {
+	Device     DeviceInfo
+	Object     BACnetObject
+	PropertyID uint32
+	// ArrayIndex, if set, reads a single element of an array property.
+	ArrayIndex *uint32
+}
+
+// ReadResult is the outcome of a ReadRequest.
+type ReadResult struct {
+	Request ReadRequest
+	Value   interface{}
+	Err     error
+}
+
+// ReadMany performs the reads with up to concurrency requests in flight and returns their results
+// in the order of requests. Reads that had not started when ctx was cancelled fail with ctx.Err().
+// A concurrency below 1 reads one property at a time.
+func (c *BACnetClient) ReadMany(ctx context.Context, requests []ReadRequest, concurrency int) []ReadResult {
+	results := make([]ReadResult, len(requests))
+	for i, req := range requests {
+		results[i].Request = req
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	concurrency = min(concurrency, len(requests))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				req := requests[i]
+				value, err := c.readProperty(ctx, req.Device, req.Object, req.PropertyID, req.ArrayIndex)
+				if err == nil {
+					value = typedPropertyValue(req.Object.Type, req.PropertyID, value)
+				}
+				results[i].Value, results[i].Err = value, err
+			}
+		}()
+	}
+
+	for i := range requests {
+		select {
+		case next <- i:
+			continue
+		case <-ctx.Done():
+		}
+		for ; i < len(requests); i++ {
+			results[i].Err = ctx.Err()
+		}
+		break
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
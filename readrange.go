@@ -0,0 +1,353 @@
+package bacnet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// Range selects the items a ReadRange request returns. The zero Range selects all items.
+type Range struct {
+	kind      uint8
+	reference uint32
+	time      time.Time
+	count     int32
+}
+
+// RangeByPosition selects count items starting at the 1-based index, or the -count items up to
+// and including it for a negative count.
+func RangeByPosition(index uint32, count int32) Range {
+	return Range{kind: rangeByPosition, reference: index, count: count}
+}
+
+// RangeBySequence selects count log records starting at the given sequence number, or the -count
+// records up to and including it for a negative count.
+func RangeBySequence(sequence uint32, count int32) Range {
+	return Range{kind: rangeBySequence, reference: sequence, count: count}
+}
+
+// RangeByTime selects the count log records after t, or the -count records before it for a
+// negative count. t is sent in its own location, which should be the time zone of the device.
+func RangeByTime(t time.Time, count int32) Range {
+	return Range{kind: rangeByTime, time: t, count: count}
+}
+
+// encode writes the range of a ReadRange request.
+func (rng Range) encode(buf *bytes.Buffer) {
+	switch rng.kind {
+	case rangeByPosition, rangeBySequence:
+		encoding.EncodeOpeningTag(buf, rng.kind)
+		encoding.EncodeApplicationUnsigned(buf, rng.reference)
+		encoding.EncodeApplicationSigned(buf, rng.count)
+		encoding.EncodeClosingTag(buf, rng.kind)
+	case rangeByTime:
+		encoding.EncodeOpeningTag(buf, rangeByTime)
+		encodeApplicationValue(buf, NewDateTime(rng.time))
+		encoding.EncodeApplicationSigned(buf, rng.count)
+		encoding.EncodeClosingTag(buf, rangeByTime)
+	}
+}
+
+// RangeResult is the answer to a ReadRange request.
+type RangeResult struct {
+	// FirstItem and LastItem are set if the result includes the first or last item of the list;
+	// MoreItems if the device had more items in the range than fitted into its response.
+	FirstItem bool
+	LastItem  bool
+	MoreItems bool
+	// FirstSequence is the sequence number of the first record, returned for ranges by sequence
+	// number or time.
+	FirstSequence *uint32
+	// Records holds the items read from a Log_Buffer, Items those of other list and array
+	// properties.
+	Records []LogRecord
+	Items   []interface{}
+}
+
+// ReadRange reads a range of the items of a list or array property, most commonly the records of
+// the Log_Buffer of a Trend Log or Event Log. Log record timestamps are taken as local time.
+func (c *BACnetClient) ReadRange(device DeviceInfo, object BACnetObject, propertyID uint32, rng Range, opts ...CallOption) (RangeResult, error) {
+	var params bytes.Buffer
+	encoding.EncodeContextObjectID(&params, 0, uint32(object.Type), object.Instance)
+	encoding.EncodeContextUnsigned(&params, 1, propertyID)
+	rng.encode(&params)
+
+	invokeID, data, err := c.transact(callContext(opts...), device, SERVICE_CONFIRMED_READ_RANGE, params.Bytes())
+	if err != nil {
+		return RangeResult{}, err
+	}
+	apdu, err := apduFromPacket(data)
+	if err != nil {
+		return RangeResult{}, err
+	}
+	header, serviceData, err := decodeComplexAck(apdu)
+	if err != nil {
+		return RangeResult{}, fmt.Errorf("ReadRange %s of %s failed: %w", propertyLabel(propertyID), object, err)
+	}
+	if header.invokeID != invokeID || header.service != SERVICE_CONFIRMED_READ_RANGE {
+		return RangeResult{}, fmt.Errorf("unexpected response to ReadRange: invoke ID %d, service 0x%x", header.invokeID, header.service)
+	}
+	if header.segmented {
+		return RangeResult{}, fmt.Errorf("segmented ReadRange responses are not supported, read a smaller range")
+	}
+	result, err := decodeReadRangeAck(serviceData, object.Type, propertyID)
+	if err != nil {
+		return RangeResult{}, fmt.Errorf("failed to decode ReadRange-ACK: %w", err)
+	}
+	return result, nil
+}
+
+// ReadLogBuffer reads all records of the Log_Buffer of a Trend Log or Event Log, paging through
+// the buffer by sequence number.
+func (c *BACnetClient) ReadLogBuffer(device DeviceInfo, object BACnetObject, opts ...CallOption) ([]LogRecord, error) {
+	// Records after a time before any device clock yield the sequence number of the first record.
+	rng := RangeByTime(time.Date(1901, 1, 1, 0, 0, 0, 0, time.Local), math.MaxInt16)
+	var records []LogRecord
+	for {
+		result, err := c.ReadRange(device, object, PROP_LOG_BUFFER, rng, opts...)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, result.Records...)
+		if !result.MoreItems || len(result.Records) == 0 || result.FirstSequence == nil {
+			return records, nil
+		}
+		rng = RangeBySequence(*result.FirstSequence+uint32(len(result.Records)), math.MaxInt16)
+	}
+}
+
+// decodeReadRangeAck decodes the service data of a ReadRange-ACK.
+func decodeReadRangeAck(data []byte, objectType ObjectType, propertyID uint32) (RangeResult, error) {
+	var result RangeResult
+	r := bytes.NewReader(data)
+	if _, _, err := encoding.DecodeContextObjectID(r, 0); err != nil {
+		return result, err
+	}
+	if _, err := encoding.DecodeContextUnsigned(r, 1); err != nil {
+		return result, err
+	}
+	h, err := encoding.PeekTagHeader(r)
+	if err != nil {
+		return result, err
+	}
+	if h.IsContext(2) {
+		if _, err := encoding.DecodeContextUnsigned(r, 2); err != nil {
+			return result, err
+		}
+	}
+	flags, err := decodeContextBits(r, 3)
+	if err != nil {
+		return result, err
+	}
+	for len(flags) < 3 {
+		flags = append(flags, false)
+	}
+	result.FirstItem, result.LastItem, result.MoreItems = flags[0], flags[1], flags[2]
+	count, err := encoding.DecodeContextUnsigned(r, 4)
+	if err != nil {
+		return result, err
+	}
+	if err := encoding.ExpectOpeningTag(r, 5); err != nil {
+		return result, err
+	}
+
+	if propertyID == PROP_LOG_BUFFER {
+		for i := uint32(0); i < count; i++ {
+			record, err := decodeLogRecord(r, objectType == OBJECT_EVENT_LOG)
+			if err != nil {
+				return result, fmt.Errorf("log record %d: %w", i+1, err)
+			}
+			result.Records = append(result.Records, record)
+		}
+		if err := encoding.ExpectClosingTag(r, 5); err != nil {
+			return result, err
+		}
+	} else {
+		items, err := decodeValueList(r, 5)
+		if err != nil {
+			return result, err
+		}
+		if list, ok := items.([]interface{}); ok && count != 1 {
+			result.Items = list
+		} else {
+			result.Items = []interface{}{items}
+		}
+	}
+
+	if r.Len() > 0 {
+		sequence, err := encoding.DecodeContextUnsigned(r, 6)
+		if err != nil {
+			return result, err
+		}
+		result.FirstSequence = &sequence
+	}
+	return result, nil
+}
+
+// decodeLogRecord decodes a BACnetLogRecord, or a BACnetEventLogRecord if eventLog is set.
+func decodeLogRecord(r *bytes.Reader, eventLog bool) (LogRecord, error) {
+	var record LogRecord
+	if err := encoding.ExpectOpeningTag(r, 0); err != nil {
+		return record, err
+	}
+	date, err := decodeApplicationValue(r)
+	if err != nil {
+		return record, err
+	}
+	tod, err := decodeApplicationValue(r)
+	if err != nil {
+		return record, err
+	}
+	d, dateOK := date.(Date)
+	t, timeOK := tod.(Time)
+	if !dateOK || !timeOK {
+		return record, fmt.Errorf("invalid timestamp")
+	}
+	if record.Timestamp, err = (DateTime{Date: d, Time: t}).In(time.Local); err != nil {
+		return record, err
+	}
+	if err := encoding.ExpectClosingTag(r, 0); err != nil {
+		return record, err
+	}
+
+	if err := encoding.ExpectOpeningTag(r, 1); err != nil {
+		return record, err
+	}
+	if record.Value, err = decodeLogDatum(r, eventLog); err != nil {
+		return record, err
+	}
+	if err := encoding.ExpectClosingTag(r, 1); err != nil {
+		return record, err
+	}
+
+	if eventLog || r.Len() == 0 {
+		return record, nil
+	}
+	h, err := encoding.PeekTagHeader(r)
+	if err != nil || !h.IsContext(2) {
+		return record, err
+	}
+	bits, err := decodeContextBits(r, 2)
+	if err != nil {
+		return record, err
+	}
+	if len(bits) < 4 {
+		return record, fmt.Errorf("status flags too short: %d bits", len(bits))
+	}
+	record.StatusFlags = &StatusFlags{InAlarm: bits[0], Fault: bits[1], Overridden: bits[2], OutOfService: bits[3]}
+	return record, nil
+}
+
+// decodeLogDatum decodes the log-datum choice of a log record, see LogRecord.Value.
+func decodeLogDatum(r *bytes.Reader, eventLog bool) (interface{}, error) {
+	h, err := encoding.DecodeTagHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if !h.Context {
+		return nil, fmt.Errorf("expected context-tagged log datum, got application tag %d", h.Number)
+	}
+	if h.Number == 0 && !h.Opening {
+		bits, err := readBits(r, h.Length)
+		if err != nil {
+			return nil, err
+		}
+		bits = append(bits, false, false, false)
+		return LogStatus{LogDisabled: bits[0], BufferPurged: bits[1], LogInterrupted: bits[2]}, nil
+	}
+
+	if eventLog {
+		switch {
+		case h.IsOpening(1):
+			start := r.Size() - int64(r.Len())
+			if err := encoding.SkipValue(r, h); err != nil {
+				return nil, err
+			}
+			// The notification parameters, without the closing tag 1 SkipValue consumed
+			raw := make([]byte, r.Size()-int64(r.Len())-start-1)
+			r.ReadAt(raw, start)
+			return raw, nil
+		case h.Number == 2 && !h.Opening:
+			v, err := readReal(r, h.Length)
+			return LogTimeChange(v), err
+		}
+		return nil, fmt.Errorf("unknown event log datum %d", h.Number)
+	}
+
+	switch {
+	case h.Number == 1 && !h.Opening:
+		v, err := encoding.DecodeUnsigned(r, h.Length)
+		return v != 0, err
+	case h.Number == 2 && !h.Opening:
+		return readReal(r, h.Length)
+	case h.Number == 3 && !h.Opening:
+		v, err := encoding.DecodeUnsigned(r, h.Length)
+		return Enumerated(v), err
+	case h.Number == 4 && !h.Opening:
+		return encoding.DecodeUnsigned(r, h.Length)
+	case h.Number == 5 && !h.Opening:
+		return encoding.DecodeSigned(r, h.Length)
+	case h.Number == 6 && !h.Opening:
+		return readBits(r, h.Length)
+	case h.Number == 7 && !h.Opening:
+		return nil, nil
+	case h.IsOpening(8):
+		var codes [2]uint32
+		for i := range codes {
+			code, err := decodeApplicationValue(r)
+			if err != nil {
+				return nil, err
+			}
+			n, ok := code.(uint32)
+			if !ok {
+				return nil, fmt.Errorf("invalid error in log record")
+			}
+			codes[i] = n
+		}
+		return &PropertyAccessError{Class: codes[0], Code: codes[1]}, encoding.ExpectClosingTag(r, 8)
+	case h.Number == 9 && !h.Opening:
+		v, err := readReal(r, h.Length)
+		return LogTimeChange(v), err
+	case h.IsOpening(10):
+		// any-value: application-tagged values up to the closing tag
+		return decodeValueList(r, 10)
+	}
+	return nil, fmt.Errorf("unknown log datum %d", h.Number)
+}
+
+// decodeContextBits decodes a context-tagged bit string.
+func decodeContextBits(r *bytes.Reader, tagNumber uint8) ([]bool, error) {
+	h, err := encoding.DecodeTagHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if !h.IsContext(tagNumber) {
+		return nil, fmt.Errorf("expected context tag %d, got tag %d", tagNumber, h.Number)
+	}
+	return readBits(r, h.Length)
+}
+
+// readBits reads the content of a bit string of the given length.
+func readBits(r *bytes.Reader, length uint32) ([]bool, error) {
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return decodeBits(data)
+}
+
+// readReal reads the content of a REAL.
+func readReal(r *bytes.Reader, length uint32) (float32, error) {
+	if length != 4 {
+		return 0, fmt.Errorf("invalid REAL length %d", length)
+	}
+	var data [4]byte
+	if _, err := io.ReadFull(r, data[:]); err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(uint32(data[0])<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])), nil
+}
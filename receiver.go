@@ -0,0 +1,371 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// receivedDatagram is a datagram read by the receive loop, copied out of its buffer.
+type receivedDatagram struct {
+	data []byte
+	addr *net.UDPAddr
+}
+
+// transactionKey identifies the responses of a transaction: the invoke ID of its request and the
+// address the request was sent to. Responses to requests to different devices may carry the same
+// invoke ID.
+type transactionKey struct {
+	invokeID byte
+	peer     string
+}
+
+// pendingTransaction is a transaction waiting for its response.
+type pendingTransaction struct {
+	key      transactionKey
+	response chan receivedDatagram
+	// failed receives the error that ended the receive loop.
+	failed chan error
+}
+
+// datagramHandler is called with a datagram by the receive loop and reports whether it took it.
+// data is only valid during the call.
+type datagramHandler func(data []byte, addr *net.UDPAddr) bool
+
+// receiver is the receive loop of a client, the only reader of its connection. It hands responses
+// to the transactions waiting for them, notifications to the notification dispatcher, and every
+// other datagram to the registered handlers, so requests, subscriptions and discovery never
+// read the connection themselves and do not have to pass on what they read for others.
+//
+// The loop starts when the client first needs it and runs until the client is closed or reading
+// fails; the next use starts it again.
+type receiver struct {
+	mu      sync.Mutex
+	running bool
+	// done is closed when the current loop ended, after err was set.
+	done    chan struct{}
+	err     error
+	pending map[transactionKey]*pendingTransaction
+	// lastInvokeID is the invoke ID last used with each peer.
+	lastInvokeID map[string]byte
+	// released is closed, and replaced, whenever a transaction ends.
+	released chan struct{}
+	handlers map[int]datagramHandler
+	// watchers get a copy of every datagram, before anything else.
+	watchers map[int]*datagramWatcher
+	nextID   int
+}
+
+func newReceiver() *receiver {
+	return &receiver{
+		pending:      make(map[transactionKey]*pendingTransaction),
+		lastInvokeID: make(map[string]byte),
+		released:     make(chan struct{}),
+		handlers:     make(map[int]datagramHandler),
+		watchers:     make(map[int]*datagramWatcher),
+	}
+}
+
+// startReceiving starts the receive loop unless it runs, and returns a channel closed when it
+// ends.
+func (c *BACnetClient) startReceiving() <-chan struct{} {
+	c.receiver.mu.Lock()
+	defer c.receiver.mu.Unlock()
+	return c.startReceivingLocked()
+}
+
+// startReceivingLocked is startReceiving for callers holding c.receiver.mu.
+func (c *BACnetClient) startReceivingLocked() <-chan struct{} {
+	r := c.receiver
+	if !r.running {
+		r.running = true
+		r.done = make(chan struct{})
+		r.err = nil
+		go c.receive()
+	}
+	c.notifications.start.Do(func() { go c.dispatchNotifications() })
+	return r.done
+}
+
+// receiveErr returns the error that ended the last receive loop.
+func (c *BACnetClient) receiveErr() error {
+	c.receiver.mu.Lock()
+	defer c.receiver.mu.Unlock()
+	return c.receiver.err
+}
+
+// receive reads the connection until it is closed or fails.
+func (c *BACnetClient) receive() {
+	buffer := make([]byte, c.receiveBufferSize())
+	c.conn.SetReadDeadline(time.Time{})
+	for {
+		n, addr, err := c.conn.ReadFromUDP(buffer)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() && c.closed.Err() == nil {
+				c.conn.SetReadDeadline(time.Time{}) // Left by a reader of GetConn
+				continue
+			}
+			c.stopReceiving(fmt.Errorf("failed to read from UDP: %w", err))
+			return
+		}
+		c.route(buffer[:n], addr)
+	}
+}
+
+// stopReceiving ends the receive loop with err, failing the transactions waiting for a response
+// and, unless the client was closed, all subscriptions and event listeners.
+func (c *BACnetClient) stopReceiving(err error) {
+	r := c.receiver
+	r.mu.Lock()
+	r.running = false
+	r.err = err
+	close(r.done)
+	for _, t := range r.pending {
+		select {
+		case t.failed <- err:
+		default:
+		}
+	}
+	r.mu.Unlock()
+
+	if c.closed.Err() == nil {
+		c.failNotifications(err)
+	}
+}
+
+// route hands a datagram to whatever waits for it.
+func (c *BACnetClient) route(data []byte, addr *net.UDPAddr) {
+	r := c.receiver
+	r.mu.Lock()
+	if len(r.watchers) > 0 {
+		datagram := receivedDatagram{data: append([]byte(nil), data...), addr: addr}
+		for _, w := range r.watchers {
+			w.push(datagram)
+		}
+	}
+	r.mu.Unlock()
+
+	if !c.screenBVLC(data, addr) {
+		return
+	}
+	if apdu, err := apduFromPacket(data); err == nil && len(apdu) >= 2 {
+		switch {
+		case isResponsePDU(apdu[0]):
+			if c.deliverResponse(apdu[1], data, addr) {
+				return
+			}
+		case isNotificationPDU(apdu):
+			c.queueNotification(data, addr)
+			return
+		}
+	}
+	if c.receiveTextMessage(data, addr) {
+		return
+	}
+
+	r.mu.Lock()
+	handlers := make([]datagramHandler, 0, len(r.handlers))
+	for _, handle := range r.handlers {
+		handlers = append(handlers, handle)
+	}
+	r.mu.Unlock()
+	taken := false
+	for _, handle := range handlers {
+		if handle(data, addr) {
+			taken = true
+		}
+	}
+	if !taken {
+		c.stats.dropped()
+	}
+}
+
+// deliverResponse hands a response to the transaction waiting for it and reports whether there
+// is one. Duplicates of a response not taken yet are dropped.
+func (c *BACnetClient) deliverResponse(invokeID byte, data []byte, addr *net.UDPAddr) bool {
+	c.receiver.mu.Lock()
+	t := c.receiver.pending[transactionKey{invokeID: invokeID, peer: addr.String()}]
+	c.receiver.mu.Unlock()
+	if t == nil {
+		return false
+	}
+	select {
+	case t.response <- receivedDatagram{data: append([]byte(nil), data...), addr: addr}:
+	default:
+		c.stats.dropped()
+	}
+	return true
+}
+
+// newTransaction registers a transaction with a device. Invoke IDs are allocated per device
+// address, in turn, skipping those of open transactions; while all 256 are in use, it waits for a
+// transaction with the device to end, or fails once ctx is done. The caller must release the
+// transaction.
+func (c *BACnetClient) newTransaction(ctx context.Context, device DeviceInfo) (*pendingTransaction, error) {
+	peer := c.deviceAddr(device).String()
+	r := c.receiver
+	for {
+		r.mu.Lock()
+		for range 256 {
+			r.lastInvokeID[peer]++
+			key := transactionKey{invokeID: r.lastInvokeID[peer], peer: peer}
+			if r.pending[key] != nil {
+				continue
+			}
+			t := &pendingTransaction{key: key, response: make(chan receivedDatagram, 1), failed: make(chan error, 1)}
+			r.pending[key] = t
+			c.startReceivingLocked()
+			r.mu.Unlock()
+			return t, nil
+		}
+		released := r.released
+		r.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("all invoke IDs for requests to %s are in use: %w", peer, ctx.Err())
+		}
+	}
+}
+
+// releaseTransaction removes t, freeing its invoke ID; later responses are dropped.
+func (c *BACnetClient) releaseTransaction(t *pendingTransaction) {
+	r := c.receiver
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, t.key)
+	close(r.released)
+	r.released = make(chan struct{})
+}
+
+// awaitResponse waits up to wait, or until ctx is done if that is earlier, for the next response to
+// t.
+func (c *BACnetClient) awaitResponse(ctx context.Context, t *pendingTransaction, wait time.Duration) ([]byte, error) {
+	deadline, ctxDeadline := readDeadline(ctx, wait)
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case response := <-t.response:
+		c.tapFrame(ctx, FrameReceived, response.addr, response.data)
+		return response.data, nil
+	case err := <-t.failed:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		if ctxDeadline {
+			return nil, context.DeadlineExceeded
+		}
+		return nil, errResponseTimeout
+	}
+}
+
+// handleDatagrams calls handle with every datagram the receive loop has no other use for, until
+// the returned function is called.
+func (c *BACnetClient) handleDatagrams(handle datagramHandler) (remove func()) {
+	r := c.receiver
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.nextID
+	r.nextID++
+	r.handlers[id] = handle
+	c.startReceivingLocked()
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.handlers, id)
+	}
+}
+
+// watchDatagrams calls watch with a copy of every datagram received, until the returned function
+// is called. watch runs on a goroutine of its own: the receive loop queues the datagrams for it
+// and goes on, so a slow watcher does not hold up responses. Datagrams still queued when remove
+// is called are discarded.
+func (c *BACnetClient) watchDatagrams(watch func(data []byte, addr *net.UDPAddr)) (remove func()) {
+	w := &datagramWatcher{wake: make(chan struct{}, 1), stop: make(chan struct{})}
+	go w.run(watch)
+
+	r := c.receiver
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.nextID
+	r.nextID++
+	r.watchers[id] = w
+	c.startReceivingLocked()
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.watchers[id]; ok {
+			delete(r.watchers, id)
+			close(w.stop)
+		}
+	}
+}
+
+// datagramWatcher queues the datagrams of a watchDatagrams caller.
+type datagramWatcher struct {
+	mu    sync.Mutex
+	queue []receivedDatagram
+	wake  chan struct{}
+	stop  chan struct{}
+}
+
+// push queues a datagram without waiting for the watcher.
+func (w *datagramWatcher) push(datagram receivedDatagram) {
+	w.mu.Lock()
+	w.queue = append(w.queue, datagram)
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run calls watch with the queued datagrams, in the order they were received, until stop is closed.
+func (w *datagramWatcher) run(watch func(data []byte, addr *net.UDPAddr)) {
+	for {
+		select {
+		case <-w.wake:
+		case <-w.stop:
+			return
+		}
+		w.mu.Lock()
+		queue := w.queue
+		w.queue = nil
+		w.mu.Unlock()
+		for _, datagram := range queue {
+			select {
+			case <-w.stop:
+				return
+			default:
+			}
+			watch(datagram.data, datagram.addr)
+		}
+	}
+}
+
+// HandleUnmatched calls fn with every datagram the client receives that is not a response to one
+// of its requests, a notification or a text message, e.g. I-Ams, requests from devices or
+// responses that came too late. fn is called by the client's receive loop, which waits for it. The
+// returned function removes fn.
+func (c *BACnetClient) HandleUnmatched(fn func(Frame)) (remove func()) {
+	return c.handleDatagrams(func(data []byte, addr *net.UDPAddr) bool {
+		frame := Frame{Time: time.Now(), Direction: FrameReceived, Peer: addr, Data: append([]byte(nil), data...)}
+		frame.Packet, _ = DecodePacket(frame.Data)
+		fn(frame)
+		return true
+	})
+}
+
+// receiveBufferSize is the size of the buffer the receive loop reads into: large enough for any
+// UDP datagram unless ClientOptions.ReadBufferSize is set.
+func (c *BACnetClient) receiveBufferSize() int {
+	if c.options.ReadBufferSize > 0 {
+		return c.options.ReadBufferSize
+	}
+	return 1 << 16
+}
@@ -0,0 +1,62 @@
+package bacnet
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestWatcherDoesNotBlockReceiveLoop checks that a watcher that does not return keeps neither
+// responses from requests nor later datagrams from itself.
+func TestWatcherDoesNotBlockReceiveLoop(t *testing.T) {
+	_, device := startSimulator(t, SimulatorOptions{DeviceID: 3004, AnalogValues: 1})
+	client := newLoopbackClient(t, ClientOptions{})
+
+	release := make(chan struct{})
+	watched := make(chan []byte, 16)
+	remove := client.watchDatagrams(func(data []byte, addr *net.UDPAddr) {
+		<-release
+		watched <- data
+	})
+
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+	for range 3 {
+		if _, err := client.ReadProperty(device, av1, PROP_PRESENT_VALUE, nil); err != nil {
+			t.Fatalf("read while the watcher blocks: %v", err)
+		}
+	}
+
+	close(release)
+	for i := range 3 {
+		select {
+		case data := <-watched:
+			if apdu, err := apduFromPacket(data); err != nil || apdu[0]&0xF0 != APDU_COMPLEX_ACK {
+				t.Errorf("watched datagram %d: %x", i, data)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("watcher saw %d of 3 responses", i)
+		}
+	}
+	remove()
+}
+
+func TestMonitorNetwork(t *testing.T) {
+	_, device := startSimulator(t, SimulatorOptions{DeviceID: 3005})
+	client := newLoopbackClient(t, ClientOptions{})
+
+	report, err := client.MonitorNetwork(context.Background(), NetworkHealthOptions{
+		Window:         200 * time.Millisecond,
+		BroadcastAddr:  &net.UDPAddr{IP: device.IPAddress, Port: device.Port},
+		FloodThreshold: 100, // One I-Am in a short window is a high rate
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.IAmCount != 1 || len(report.Devices) != 1 || report.Devices[0].DeviceID != 3005 {
+		t.Errorf("report has %d I-Ams from %+v", report.IAmCount, report.Devices)
+	}
+	if !report.Healthy() {
+		t.Errorf("report of one device is not healthy: %+v", report)
+	}
+}
@@ -0,0 +1,234 @@
+package bacnet
+
+import (
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReplayOptions configures a ReplayConn.
+type ReplayOptions struct {
+	// Speed scales the recorded timing: 2 replays twice as fast. Zero means the original timing.
+	Speed float64
+}
+
+// ReplayConn is a PacketConn that replays a recorded trace to a client, so that traffic captured in
+// the field can be reproduced without the original devices.
+//
+// Packets of the trace sent by the local address are the client's requests; packets sent to it or
+// broadcast are what the client receives. Each packet the client writes is matched, in order, with
+// the next recorded request, and the packets received after that request in the trace are delivered
+// with their recorded delay after the write. Packets received before the first request are
+// delivered with their recorded delay after the ReplayConn was created. Invoke IDs of responses are
+// rewritten to the invoke IDs the client actually used.
+type ReplayConn struct {
+	local *net.UDPAddr
+	speed float64
+
+	mu        sync.Mutex
+	steps     []replayStep
+	next      int
+	pending   []replayDelivery // Sorted by due time
+	invokeIDs map[byte]byte    // Recorded invoke ID to the one the client used
+	deadline  time.Time
+	closed    bool
+	wake      chan struct{}
+}
+
+type replayStep struct {
+	request TracePacket
+	replies []TracePacket
+}
+
+type replayDelivery struct {
+	due    time.Time
+	packet TracePacket
+}
+
+var _ PacketConn = (*ReplayConn)(nil)
+
+// NewReplayConn returns a connection replaying packets, e.g. from ReadPcap, to a client that had
+// the address local when the trace was recorded.
+func NewReplayConn(packets []TracePacket, local *net.UDPAddr, options ReplayOptions) *ReplayConn {
+	c := &ReplayConn{
+		local:     local,
+		speed:     options.Speed,
+		invokeIDs: make(map[byte]byte),
+		wake:      make(chan struct{}, 1),
+	}
+	if c.speed <= 0 {
+		c.speed = 1
+	}
+
+	now := time.Now()
+	for _, p := range packets {
+		switch {
+		case sameUDPAddr(p.Src, local):
+			c.steps = append(c.steps, replayStep{request: p})
+		case sameUDPAddr(p.Dst, local) || isBroadcastIP(p.Dst.IP):
+			if len(c.steps) == 0 {
+				c.pending = append(c.pending, replayDelivery{due: now.Add(c.scale(p.Time.Sub(packets[0].Time))), packet: p})
+				continue
+			}
+			last := &c.steps[len(c.steps)-1]
+			last.replies = append(last.replies, p)
+		}
+	}
+	return c
+}
+
+// Remaining returns the number of recorded requests the client has not sent yet and of recorded
+// packets not delivered yet. It is zero once the client replayed the whole trace.
+func (c *ReplayConn) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.pending)
+	for _, step := range c.steps[c.next:] {
+		n += 1 + len(step.replies)
+	}
+	return n
+}
+
+// WriteTo matches b with the next recorded request and schedules the packets received after it.
+// Writes beyond the end of the trace are discarded.
+func (c *ReplayConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if c.next == len(c.steps) {
+		return len(b), nil
+	}
+	step := c.steps[c.next]
+	c.next++
+
+	if recorded, ok := requestInvokeID(step.request.Data); ok {
+		if actual, ok := requestInvokeID(b); ok {
+			c.invokeIDs[recorded] = actual
+		}
+	}
+	now := time.Now()
+	for _, p := range step.replies {
+		c.pending = append(c.pending, replayDelivery{due: now.Add(c.scale(p.Time.Sub(step.request.Time))), packet: p})
+	}
+	sort.SliceStable(c.pending, func(i, j int) bool { return c.pending[i].due.Before(c.pending[j].due) })
+	c.signal()
+	return len(b), nil
+}
+
+// ReadFromUDP returns the next recorded packet once it is due.
+func (c *ReplayConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return 0, nil, net.ErrClosed
+		}
+		now := time.Now()
+		if len(c.pending) > 0 && !c.pending[0].due.After(now) {
+			p := c.pending[0].packet
+			c.pending = c.pending[1:]
+			n := copy(b, p.Data)
+			c.rewriteInvokeID(b[:n])
+			c.mu.Unlock()
+			return n, p.Src, nil
+		}
+		if !c.deadline.IsZero() && !now.Before(c.deadline) {
+			c.mu.Unlock()
+			return 0, nil, os.ErrDeadlineExceeded
+		}
+
+		var timer *time.Timer
+		var wait <-chan time.Time
+		until := c.deadline
+		if len(c.pending) > 0 && (until.IsZero() || c.pending[0].due.Before(until)) {
+			until = c.pending[0].due
+		}
+		if !until.IsZero() {
+			timer = time.NewTimer(until.Sub(now))
+			wait = timer.C
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-c.wake:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// SetReadDeadline sets the time after which ReadFromUDP fails with a timeout.
+func (c *ReplayConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	c.signal()
+	return nil
+}
+
+// LocalAddr returns the address of the client in the trace.
+func (c *ReplayConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// Close stops the replay; blocked reads return net.ErrClosed.
+func (c *ReplayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.signal()
+	return nil
+}
+
+func (c *ReplayConn) scale(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.speed)
+}
+
+// signal wakes a blocked ReadFromUDP. The caller must hold c.mu.
+func (c *ReplayConn) signal() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// rewriteInvokeID replaces the recorded invoke ID of a response with the one the client used.
+// The caller must hold c.mu.
+func (c *ReplayConn) rewriteInvokeID(packet []byte) {
+	apdu, err := apduFromPacket(packet)
+	if err != nil || len(apdu) < 2 {
+		return
+	}
+	if !isResponsePDU(apdu[0]) && apdu[0]&0xF0 != APDU_SEGMENT_ACK {
+		return
+	}
+	if actual, ok := c.invokeIDs[apdu[1]]; ok {
+		apdu[1] = actual
+	}
+}
+
+// requestInvokeID returns the invoke ID of a confirmed request packet.
+func requestInvokeID(packet []byte) (byte, bool) {
+	apdu, err := apduFromPacket(packet)
+	if err != nil || len(apdu) < 3 || apdu[0]&0xF0 != APDU_CONFIRMED_REQUEST {
+		return 0, false
+	}
+	return apdu[2], true
+}
+
+func sameUDPAddr(a, b *net.UDPAddr) bool {
+	return a != nil && b != nil && a.Port == b.Port && a.IP.Equal(b.IP)
+}
+
+// isBroadcastIP reports whether ip is the limited broadcast address or, assuming the usual /24
+// BACnet subnets, a directed broadcast.
+func isBroadcastIP(ip net.IP) bool {
+	ip4 := ip.To4()
+	return ip4 != nil && ip4[3] == 255
+}
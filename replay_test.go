@@ -0,0 +1,108 @@
+package bacnet
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// testdata/readproperty-retry.pcap is a capture of a client at 192.168.1.10:47808 reading device
+// 1001 at 192.168.1.20:47808 with a 300 ms timeout and one retry:
+//
+//	0.000  ReadProperty analog-value 1 present-value, invoke ID 42 (no answer)
+//	0.300  the same request resent
+//	0.312  ComplexACK 21.5
+//	0.400  ReadProperty analog-value 1 object-name, invoke ID 43
+//	0.405  ComplexACK "Zone Temp"
+//	0.500  ReadProperty analog-value 2 present-value, invoke ID 44 (no answer)
+//	0.800  the same request resent (no answer)
+func readReplayTrace(t *testing.T) []TracePacket {
+	t.Helper()
+	f, err := os.Open("testdata/readproperty-retry.pcap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	packets, err := ReadPcap(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return packets
+}
+
+func TestReadPcap(t *testing.T) {
+	packets := readReplayTrace(t)
+	if len(packets) != 7 {
+		t.Fatalf("read %d packets, want 7", len(packets))
+	}
+	client := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 47808}
+	if !sameUDPAddr(packets[0].Src, client) || packets[2].Dst.String() != client.String() {
+		t.Errorf("addresses %s -> %s, %s -> %s", packets[0].Src, packets[0].Dst, packets[2].Src, packets[2].Dst)
+	}
+	if d := packets[2].Time.Sub(packets[0].Time); d != 312*time.Millisecond {
+		t.Errorf("acknowledgement %v after the request, want 312ms", d)
+	}
+	if id, ok := requestInvokeID(packets[0].Data); !ok || id != 42 {
+		t.Errorf("invoke ID %d (%t), want 42", id, ok)
+	}
+}
+
+// TestReplayRetryAndInvokeIDs replays the trace to a client whose invoke IDs differ from the
+// recorded ones: the resent requests must be answered by the recorded acknowledgement, and a
+// request left unanswered twice must time out.
+func TestReplayRetryAndInvokeIDs(t *testing.T) {
+	packets := readReplayTrace(t)
+	local := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 47808}
+	conn := NewReplayConn(packets, local, ReplayOptions{Speed: 4})
+
+	var sent []byte
+	client := NewClientWithConn(conn, ClientOptions{Timeout: 50 * time.Millisecond, Retries: 1, OnFrame: func(f Frame) {
+		if f.Direction == FrameSent && f.Packet.PDUType == APDU_CONFIRMED_REQUEST {
+			sent = append(sent, f.Packet.InvokeID)
+		}
+	}})
+	defer client.Close()
+
+	device := DeviceInfo{DeviceID: 1001, IPAddress: net.IPv4(192, 168, 1, 20), Port: 47808, MaxAPDU: 1476}
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+	value, err := client.ReadProperty(device, av1, PROP_PRESENT_VALUE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != float32(21.5) {
+		t.Errorf("present-value = %v, want 21.5", value)
+	}
+	name, err := client.ReadProperty(device, av1, PROP_OBJECT_NAME, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Zone Temp" {
+		t.Errorf("object-name = %v, want Zone Temp", name)
+	}
+	av2 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 2}
+	if _, err := client.ReadProperty(device, av2, PROP_PRESENT_VALUE, nil); !errors.Is(err, errResponseTimeout) {
+		t.Errorf("read of %s: %v, want a timeout", av2, err)
+	}
+
+	if len(sent) != 5 {
+		t.Fatalf("client sent %d requests, want 5", len(sent))
+	}
+	for _, id := range sent {
+		if id >= 42 && id <= 44 {
+			t.Fatalf("client used recorded invoke ID %d, the rewrite is not exercised", id)
+		}
+	}
+	if sent[0] != sent[1] || sent[3] != sent[4] {
+		t.Errorf("retries changed the invoke ID: %v", sent)
+	}
+
+	stats := client.Stats()
+	if stats.Retries != 2 || stats.Timeouts != 1 {
+		t.Errorf("%d retries and %d timeouts, want 2 and 1", stats.Retries, stats.Timeouts)
+	}
+	if n := conn.Remaining(); n != 0 {
+		t.Errorf("%d packets of the trace not replayed", n)
+	}
+}
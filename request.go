@@ -2,40 +2,25 @@ package bacnet
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
 )
 
-// WhoIs sends a WhoIs request and returns a list of discovered devices.
+// WhoIs sends a WhoIs request on conn and returns a list of discovered devices.
+//
+// Deprecated: WhoIs reads conn itself, taking datagrams from the receive loop of a client using
+// it, and cannot be cancelled. Use BACnetClient.WhoIsContext.
 func WhoIs(conn *net.UDPConn, broadcastAddr *net.UDPAddr, timeout time.Duration) ([]DeviceInfo, error) {
-
-	// Construct WhoIs packet
-	var buffer bytes.Buffer
-
-	// BVLC Header
-	bvlc := BVLCHeader{
-		Type:     BVLC_TYPE_BACNET_IP,
-		Function: BVLC_ORIGINAL_BROADCAST_NPDU,
-		Length:   8, // BVLC(4) + NPDU(2) + APDU(2)
-	}
-	binary.Write(&buffer, binary.BigEndian, &bvlc)
-
-	// NPDU
-	npdu := NPDU{
-		Version: 1,
-		Control: NPDU_CONTROL_NORMAL_MESSAGE,
-	}
-	binary.Write(&buffer, binary.BigEndian, &npdu)
-
-	// APDU (Unconfirmed-Request, Who-Is)
-	buffer.WriteByte(APDU_UNCONFIRMED_REQUEST)
-	buffer.WriteByte(SERVICE_UNCONFIRMED_WHO_IS)
-	// No parameters for Who-Is
-
 	// Send WhoIs packet
-	_, err := conn.WriteTo(buffer.Bytes(), broadcastAddr)
+	_, err := conn.WriteTo(whoIsPacket(), broadcastAddr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send WhoIs packet: %w", err)
 	}
@@ -43,7 +28,7 @@ func WhoIs(conn *net.UDPConn, broadcastAddr *net.UDPAddr, timeout time.Duration)
 	// Listen for I-Am responses
 	var devices []DeviceInfo
 	conn.SetReadDeadline(time.Now().Add(timeout))
-	readBuffer := make([]byte, 1500)
+	readBuffer := make([]byte, clientMaxAPDU+encapsulationOverhead)
 
 	for {
 		n, addr, err := conn.ReadFromUDP(readBuffer)
@@ -63,243 +48,281 @@ func WhoIs(conn *net.UDPConn, broadcastAddr *net.UDPAddr, timeout time.Duration)
 	return devices, nil
 }
 
-// GetObjectList retrieves the object list from a device.
-func (c *BACnetClient) GetObjectList(device DeviceInfo) ([]BACnetObject, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Construct ReadProperty request for object-list
-	var apduBuffer bytes.Buffer
-
-	// APDU (Confirmed-Request)
-	apduBuffer.WriteByte(APDU_CONFIRMED_REQUEST | 0x02) // APDU Type (0x00) | PDU Flags (0x02)
-	apduBuffer.WriteByte(0x75)                          // Max segments (7) | Max APDU (5)
-	invokeID := GInvokeIDManager.Next()
-	apduBuffer.WriteByte(invokeID) // Invoke ID
-	apduBuffer.WriteByte(SERVICE_CONFIRMED_READ_PROPERTY)
-
-	// Context-specific tags for ReadProperty
-	// Object Identifier (Device Object)
-	apduBuffer.WriteByte(0x0C) // Tag 0, context-specific, length 4
-	objectIdentifier := (uint32(OBJECT_DEVICE) << 22) | device.DeviceID
-	binary.Write(&apduBuffer, binary.BigEndian, objectIdentifier)
-
-	// Property Identifier (Object List)
-	apduBuffer.WriteByte(0x19) // Tag 1, context-specific, length 1
-	apduBuffer.WriteByte(byte(PROP_OBJECT_LIST))
-
+// whoIsPacket builds a broadcast Who-Is request without device range limits.
+func whoIsPacket() []byte {
 	var buffer bytes.Buffer
+
 	// BVLC Header
 	bvlc := BVLCHeader{
 		Type:     BVLC_TYPE_BACNET_IP,
-		Function: BVLC_ORIGINAL_UNICAST_NPDU,
-		Length:   uint16(4 + 2 + apduBuffer.Len()),
+		Function: BVLC_ORIGINAL_BROADCAST_NPDU,
+		Length:   8, // BVLC(4) + NPDU(2) + APDU(2)
 	}
 	binary.Write(&buffer, binary.BigEndian, &bvlc)
 
 	// NPDU
 	npdu := NPDU{
 		Version: 1,
-		Control: 0x04, // Expecting Reply
+		Control: NPDU_CONTROL_NORMAL_MESSAGE,
 	}
 	binary.Write(&buffer, binary.BigEndian, &npdu)
 
-	// APDU
-	buffer.Write(apduBuffer.Bytes())
+	// APDU (Unconfirmed-Request, Who-Is)
+	buffer.WriteByte(APDU_UNCONFIRMED_REQUEST)
+	buffer.WriteByte(SERVICE_UNCONFIRMED_WHO_IS)
+	// No parameters for Who-Is
+
+	return buffer.Bytes()
+}
 
-	// Send ReadProperty packet
-	_, err := c.conn.WriteTo(buffer.Bytes(), &net.UDPAddr{IP: device.IPAddress, Port: device.Port})
+// ReadProperty reads a property of any object with a single ReadProperty request. A non-nil
+// arrayIndex reads one element of an array property, or its length with index 0. Properties with
+// more than one element decode to []interface{}.
+func (c *BACnetClient) ReadProperty(device DeviceInfo, object BACnetObject, propertyID uint32, arrayIndex *uint32, opts ...CallOption) (interface{}, error) {
+	return c.readProperty(callContext(opts...), device, object, propertyID, arrayIndex)
+}
+
+// GetObjectList retrieves the object list from a device. The whole list is read with one request
+// and held in memory; use ReadObjectListPage or WalkObjectList for devices with very large lists.
+func (c *BACnetClient) GetObjectList(device DeviceInfo, opts ...CallOption) ([]BACnetObject, error) {
+	deviceObject := BACnetObject{Type: OBJECT_DEVICE, Instance: device.DeviceID}
+	value, err := c.readProperty(callContext(opts...), device, deviceObject, uint32(PROP_OBJECT_LIST), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send ReadProperty packet: %w", err)
+		return nil, err
 	}
 
-	// Listen for Complex-ACK response
-	c.conn.SetReadDeadline(time.Now().Add(c.options.Timeout))
-	readBuffer := make([]byte, 2048)
+	// A single element decodes to the bare object identifier
+	elements, ok := value.([]interface{})
+	if !ok {
+		elements = []interface{}{value}
+	}
 
-	n, _, err := c.conn.ReadFromUDP(readBuffer)
-	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return nil, fmt.Errorf("timeout waiting for ReadProperty response")
+	objectList := make([]BACnetObject, 0, len(elements))
+	for _, element := range elements {
+		object, ok := element.(BACnetObject)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v (%T) in object list", element, element)
 		}
-		return nil, fmt.Errorf("failed to read from UDP: %w", err)
+		objectList = append(objectList, object)
 	}
-
-	return parseObjectList(readBuffer[:n], invokeID)
+	return objectList, nil
 }
 
-func (c *BACnetClient) GetObjectAllPropertyList(device DeviceInfo, object BACnetObject) ([]BACnetPropertyValue, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Construct ReadPropertyMultiple request
-	var apduBuffer bytes.Buffer
-
-	// APDU (Confirmed-Request)
-	apduBuffer.WriteByte(APDU_CONFIRMED_REQUEST | 0x02) // APDU Type (0x00) | PDU Flags (0x02)
-	apduBuffer.WriteByte(0x75)                          // Max segments (7) | Max APDU (5)
-	invokeID := GInvokeIDManager.Next()
-	apduBuffer.WriteByte(invokeID) // Invoke ID
-	apduBuffer.WriteByte(SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE)
-
+// GetObjectAllPropertyList reads all properties of an object with ReadPropertyMultiple and
+// PROP_ALL. Segmented responses are supported.
+func (c *BACnetClient) GetObjectAllPropertyList(device DeviceInfo, object BACnetObject, opts ...CallOption) ([]BACnetPropertyValue, error) {
 	// Read Access Specification
-	// Object Identifier
-	apduBuffer.WriteByte(0x0C) // Tag 0, context-specific, length 4
-	objectIdentifier := (uint32(object.Type) << 22) | object.Instance
-	binary.Write(&apduBuffer, binary.BigEndian, objectIdentifier)
-
-	// Opening tag for List of Property References
-	apduBuffer.WriteByte(0x1E)
-
-	// Property Reference
-	apduBuffer.WriteByte(0x09) // Tag 0, context-specific, length 1
-	apduBuffer.WriteByte(PROP_ALL)
-
-	// Closing tag for List of Property References
-	apduBuffer.WriteByte(0x1F)
-
-	var buffer bytes.Buffer
-	// BVLC Header
-	bvlc := BVLCHeader{
-		Type:     BVLC_TYPE_BACNET_IP,
-		Function: BVLC_ORIGINAL_UNICAST_NPDU,
-		Length:   uint16(4 + 2 + apduBuffer.Len()),
-	}
-	binary.Write(&buffer, binary.BigEndian, &bvlc)
-
-	// NPDU
-	npdu := NPDU{
-		Version: 1,
-		Control: 0x04, // Expecting Reply
+	var params bytes.Buffer
+	encodeReadAccessSpecification(&params, object, []uint32{uint32(PROP_ALL)})
+
+	var properties []BACnetPropertyValue
+	var resultErr error
+	err := c.readPropertyMultipleStream(callContext(opts...), device, params.Bytes(), func(result RPMResult) bool {
+		if result.Err != nil {
+			resultErr = fmt.Errorf("failed to read prop %d: %w", result.PropertyID, result.Err)
+			return false
+		}
+		properties = append(properties, BACnetPropertyValue{
+			PropertyID: result.PropertyID,
+			Value:      result.Value,
+		})
+		return true
+	})
+	if err == nil {
+		err = resultErr
 	}
-	binary.Write(&buffer, binary.BigEndian, &npdu)
-
-	// APDU
-	buffer.Write(apduBuffer.Bytes())
-
-	// Send ReadPropertyMultiple packet
-	_, err := c.conn.WriteTo(buffer.Bytes(), &net.UDPAddr{IP: device.IPAddress, Port: device.Port})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send ReadPropertyMultiple packet: %w", err)
+		return nil, err
 	}
+	return properties, nil
+}
 
-	// Listen for Complex-ACK response
-	c.conn.SetReadDeadline(time.Now().Add(c.options.Timeout))
-	readBuffer := make([]byte, 4096) // Increased buffer size for potentially large responses
+// PartialError is returned with the results of a ReadPropertyMultiple of which only a part could
+// be read: properties the device reported errors for, objects missing from the response, or a
+// response that ended early. The results returned with it hold everything that was read.
+type PartialError struct {
+	// Failed are the properties the device reported an error for, with the error in Err.
+	Failed []RPMResult
+	// Missing are the objects requested that the response has no results for.
+	Missing []BACnetObject
+	// Truncated is the decoding error of a response that ended early, or nil.
+	Truncated error
+}
 
-	n, _, err := c.conn.ReadFromUDP(readBuffer)
-	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return nil, fmt.Errorf("timeout waiting for ReadPropertyMultiple response")
-		}
-		return nil, fmt.Errorf("failed to read from UDP: %w", err)
+func (e *PartialError) Error() string {
+	var parts []string
+	for _, failed := range e.Failed {
+		parts = append(parts, fmt.Sprintf("%s of %s: %v", propertyLabel(failed.PropertyID), failed.Object, failed.Err))
 	}
+	if len(e.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("%d objects missing from response", len(e.Missing)))
+	}
+	if e.Truncated != nil {
+		parts = append(parts, fmt.Sprintf("response truncated: %v", e.Truncated))
+	}
+	return "ReadPropertyMultiple partially failed: " + strings.Join(parts, "; ")
+}
 
-	return parseObjectPropertyList(readBuffer[:n], invokeID)
+// Unwrap returns the errors of the failed properties and the truncation, for errors.Is and
+// errors.As.
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed)+1)
+	for _, failed := range e.Failed {
+		errs = append(errs, failed.Err)
+	}
+	if e.Truncated != nil {
+		errs = append(errs, e.Truncated)
+	}
+	return errs
 }
 
 // ReadPropertiesFromMultipleObjects retrieves a specific property from multiple objects on a device.
-func (c *BACnetClient) ReadPropertiesFromMultipleObjects(device DeviceInfo, objects []BACnetObject, propertyID uint32) (map[BACnetObject]interface{}, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	var apduBuffer bytes.Buffer
-
-	// APDU (Confirmed-Request)
-	apduBuffer.WriteByte(APDU_CONFIRMED_REQUEST | 0x02) // APDU Type (0x00) | PDU Flags (0x02)
-	apduBuffer.WriteByte(0x75)                          // Max segments (7) | Max APDU (5)
-	invokeID := GInvokeIDManager.Next()
-	apduBuffer.WriteByte(SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE)
-
+// If some objects could not be read, the values of the others are returned with a *PartialError
+// listing what failed. Segmented responses are supported.
+func (c *BACnetClient) ReadPropertiesFromMultipleObjects(device DeviceInfo, objects []BACnetObject, propertyID uint32, opts ...CallOption) (map[BACnetObject]interface{}, error) {
 	// List of Read Access Specifications
+	var params bytes.Buffer
 	for _, obj := range objects {
-		// Object Identifier
-		apduBuffer.WriteByte(0x0C) // Tag 0, context-specific, length 4
-		objectIdentifier := (uint32(obj.Type) << 22) | obj.Instance
-		binary.Write(&apduBuffer, binary.BigEndian, objectIdentifier)
-
-		// Opening tag for List of Property References
-		apduBuffer.WriteByte(0x1E)
-
-		// Property Reference
-		apduBuffer.WriteByte(0x09) // Tag 0, context-specific, length 1
-		binary.Write(&apduBuffer, binary.BigEndian, uint8(propertyID))
+		encodeReadAccessSpecification(&params, obj, []uint32{propertyID})
+	}
 
-		// Closing tag for List of Property References
-		apduBuffer.WriteByte(0x1F)
+	results := make(map[BACnetObject]interface{})
+	var failed []RPMResult
+	err := c.readPropertyMultipleStream(callContext(opts...), device, params.Bytes(), func(result RPMResult) bool {
+		if result.Err != nil {
+			failed = append(failed, result)
+			return true
+		}
+		objectProperties, ok := results[result.Object].(map[uint32]interface{})
+		if !ok {
+			objectProperties = make(map[uint32]interface{})
+			results[result.Object] = objectProperties
+		}
+		objectProperties[result.PropertyID] = result.Value
+		return true
+	})
+	if err != nil && len(results) == 0 && len(failed) == 0 {
+		return nil, err
 	}
 
-	var buffer bytes.Buffer
-	// BVLC Header
-	bvlc := BVLCHeader{
-		Type:     BVLC_TYPE_BACNET_IP,
-		Function: BVLC_ORIGINAL_UNICAST_NPDU,
-		Length:   uint16(4 + 2 + apduBuffer.Len()),
+	// The response ended early, or properties failed: keep what was read
+	var partial *PartialError
+	if err != nil || len(failed) > 0 {
+		partial = &PartialError{Failed: failed, Truncated: err}
 	}
-	binary.Write(&buffer, binary.BigEndian, &bvlc)
+	if partial = missingObjects(partial, objects, results); partial != nil {
+		return results, partial
+	}
+	return results, nil
+}
 
-	// NPDU
-	npdu := NPDU{
-		Version: 1,
-		Control: 0x04, // Expecting Reply
+// missingObjects adds the objects of a request that have neither results nor errors to partial,
+// which may be nil.
+func missingObjects(partial *PartialError, objects []BACnetObject, results map[BACnetObject]interface{}) *PartialError {
+	for _, object := range objects {
+		if _, ok := results[object]; ok {
+			continue
+		}
+		if partial != nil && slices.ContainsFunc(partial.Failed, func(r RPMResult) bool { return r.Object == object }) {
+			continue
+		}
+		if partial == nil {
+			partial = &PartialError{}
+		}
+		partial.Missing = append(partial.Missing, object)
 	}
-	binary.Write(&buffer, binary.BigEndian, &npdu)
+	return partial
+}
 
-	// APDU
-	buffer.Write(apduBuffer.Bytes())
+// PropertyResult is the outcome of reading one property: its value, or the error the device
+// reported for it.
+type PropertyResult struct {
+	Property uint32
+	Value    interface{}
+	Err      error
+}
 
-	// Send ReadPropertyMultiple packet
-	_, err := c.conn.WriteTo(buffer.Bytes(), &net.UDPAddr{IP: device.IPAddress, Port: device.Port})
-	if err != nil {
-		return nil, fmt.Errorf("failed to send ReadPropertyMultiple packet: %w", err)
+// PropertyResults are the results of reading several properties of an object.
+type PropertyResults []PropertyResult
+
+// Map returns the values of the properties that were read successfully, keyed by property.
+func (r PropertyResults) Map() map[uint32]interface{} {
+	values := make(map[uint32]interface{}, len(r))
+	for _, result := range r {
+		if result.Err == nil {
+			values[result.Property] = result.Value
+		}
 	}
+	return values
+}
 
-	// Listen for Complex-ACK response
-	c.conn.SetReadDeadline(time.Now().Add(c.options.Timeout))
-	readBuffer := make([]byte, 4096) // Increased buffer size for potentially large responses
+// Get returns the value of a property, or the error reported for it.
+func (r PropertyResults) Get(propertyID uint32) (interface{}, error) {
+	for _, result := range r {
+		if result.Property == propertyID {
+			return result.Value, result.Err
+		}
+	}
+	return nil, fmt.Errorf("%s was not read", propertyLabel(propertyID))
+}
 
-	n, _, err := c.conn.ReadFromUDP(readBuffer)
-	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return nil, fmt.Errorf("timeout waiting for ReadPropertyMultiple response")
+// ReadSpecificPropertiesFromObject retrieves specific properties from a single object on a device.
+// Results are in request order and carry the error the device reported for individual properties;
+// properties returned for PROP_ALL, PROP_REQUIRED or PROP_OPTIONAL follow in the order received.
+// If the response ends early, the properties read are returned with a *PartialError.
+func (c *BACnetClient) ReadSpecificPropertiesFromObject(device DeviceInfo, object BACnetObject, propertyIDs []uint32, opts ...CallOption) (PropertyResults, error) {
+	received := make(map[uint32]PropertyResult)
+	var extra PropertyResults
+	err := c.ReadPropertyMultipleStream(device, []BACnetObject{object}, propertyIDs, func(result RPMResult) bool {
+		if result.Object != object {
+			return true
+		}
+		r := PropertyResult{Property: result.PropertyID, Value: result.Value, Err: result.Err}
+		if _, ok := received[r.Property]; ok || !slices.Contains(propertyIDs, r.Property) {
+			extra = append(extra, r)
+		} else {
+			received[r.Property] = r
 		}
-		return nil, fmt.Errorf("failed to read from UDP: %w", err)
+		return true
+	}, opts...)
+	if err != nil && len(received) == 0 && len(extra) == 0 {
+		return nil, err
 	}
 
-	return parseReadPropertyMultipleResponse(readBuffer[:n], invokeID)
+	results := make(PropertyResults, 0, len(propertyIDs)+len(extra))
+	for _, id := range propertyIDs {
+		if r, ok := received[id]; ok {
+			results = append(results, r)
+		}
+	}
+	results = append(results, extra...)
+	if err != nil {
+		// The response ended early: keep what was read
+		return results, &PartialError{Truncated: err}
+	}
+	return results, nil
 }
 
-// ReadSpecificPropertiesFromObject retrieves specific properties from a single object on a device.
-func (c *BACnetClient) ReadSpecificPropertiesFromObject(device DeviceInfo, object BACnetObject, propertyIDs []uint32) (map[uint32]interface{}, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// encodeReadAccessSpecification writes a ReadAccessSpecification for the given properties of an object.
+func encodeReadAccessSpecification(buf *bytes.Buffer, object BACnetObject, propertyIDs []uint32) {
+	encoding.EncodeContextObjectID(buf, 0, uint32(object.Type), object.Instance)
+	encoding.EncodeOpeningTag(buf, 1)
+	for _, propID := range propertyIDs {
+		encoding.EncodeContextUnsigned(buf, 0, propID)
+	}
+	encoding.EncodeClosingTag(buf, 1)
+}
 
+// confirmedRequestPacket wraps the service parameters of a confirmed request in APDU, NPDU and BVLC headers.
+func confirmedRequestPacket(invokeID byte, service byte, params []byte) []byte {
 	var apduBuffer bytes.Buffer
 
 	// APDU (Confirmed-Request)
 	apduBuffer.WriteByte(APDU_CONFIRMED_REQUEST | 0x02) // APDU Type (0x00) | PDU Flags (0x02)
-	apduBuffer.WriteByte(0x75)                          // Max segments (7) | Max APDU (5)
-	invokeID := GInvokeIDManager.Next()
-	apduBuffer.WriteByte(invokeID) // Invoke ID
-	apduBuffer.WriteByte(SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE)
-
-	// Read Access Specification for the single object
-	// Object Identifier
-	apduBuffer.WriteByte(0x0C) // Tag 0, context-specific, length 4
-	objectIdentifier := (uint32(object.Type) << 22) | object.Instance
-	binary.Write(&apduBuffer, binary.BigEndian, objectIdentifier)
-
-	// Opening tag for List of Property References
-	apduBuffer.WriteByte(0x1E)
-
-	// Property References
-	for _, propID := range propertyIDs {
-		apduBuffer.WriteByte(0x09) // Tag 0, context-specific, length 1
-		binary.Write(&apduBuffer, binary.BigEndian, uint8(propID))
-	}
-
-	// Closing tag for List of Property References
-	apduBuffer.WriteByte(0x1F)
+	apduBuffer.WriteByte(0x75)                          // Max segments (7) | Max APDU (5: clientMaxAPDU)
+	apduBuffer.WriteByte(invokeID)                      // Invoke ID
+	apduBuffer.WriteByte(service)
+	apduBuffer.Write(params)
 
 	var buffer bytes.Buffer
 	// BVLC Header
@@ -313,163 +336,262 @@ func (c *BACnetClient) ReadSpecificPropertiesFromObject(device DeviceInfo, objec
 	// NPDU
 	npdu := NPDU{
 		Version: 1,
-		Control: 0x04, // Expecting Reply
+		Control: NPDU_CONTROL_EXPECTING_REPLY,
 	}
 	binary.Write(&buffer, binary.BigEndian, &npdu)
 
 	// APDU
 	buffer.Write(apduBuffer.Bytes())
 
-	// Send ReadPropertyMultiple packet
-	_, err := c.conn.WriteTo(buffer.Bytes(), &net.UDPAddr{IP: device.IPAddress, Port: device.Port})
+	return buffer.Bytes()
+}
+
+// clientMaxAPDU is the Max_APDU_Length_Accepted the client announces in its requests.
+const clientMaxAPDU = 1476
+
+// encapsulationOverhead bounds the BVLC and NPDU headers around an APDU: a Forwarded-NPDU header,
+// and an NPDU with network number, length and 18-octet (B/IPv6) address for both destination and
+// source, a hop count and a network message type with vendor ID.
+const encapsulationOverhead = 10 + 2 + 2*(2+1+18) + 1 + 3
+
+// errResponseTimeout is returned by awaitResponse when no matching response arrived in time.
+var errResponseTimeout = errors.New("timeout waiting for response")
+
+// transact sends a confirmed request and waits for the response carrying its invoke ID. If no
+// response arrives within the client timeout, adapted to the device under
+// ClientOptions.AdaptiveTimeout, the request is resent with the same invoke ID up to
+// ClientOptions.Retries times, after waiting ClientOptions.RetryBackoff longer, doubled for every
+// further resend; CallOptions carried by ctx override all three. The deadline of ctx cuts every
+// wait short and cancelling ctx ends the transaction at once, without further retries. It returns
+// the invoke ID and the response datagram. Any number of transactions may be open at a time; the
+// receive loop hands each its responses.
+func (c *BACnetClient) transact(ctx context.Context, device DeviceInfo, service byte, params []byte) (byte, []byte, error) {
+	t, err := c.newTransaction(ctx, device)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send ReadPropertyMultiple packet: %w", err)
+		return 0, nil, err
 	}
+	defer c.releaseTransaction(t)
+	data, err := c.request(ctx, t, device, service, params)
+	return t.key.invokeID, data, err
+}
 
-	// Listen for Complex-ACK response
-	c.conn.SetReadDeadline(time.Now().Add(c.options.Timeout))
-	readBuffer := make([]byte, 4096) // Increased buffer size for potentially large responses
+// request sends the confirmed request of t and waits for the response, as described for transact.
+func (c *BACnetClient) request(ctx context.Context, t *pendingTransaction, device DeviceInfo, service byte, params []byte) (_ []byte, err error) {
+	c.stats.beginTransaction()
+	defer c.stats.endTransaction()
+
+	invokeID := t.key.invokeID
+	packet := confirmedRequestPacket(invokeID, service, params)
+	deviceAddr := c.deviceAddr(device)
+
+	span := c.startTransactionSpan(ctx, device, service, invokeID, params)
+	attempt := 0
+	var pduErr error // Error, Reject or Abort answer, reported to the span only
+	info, start := callInfo(ctx), time.Now()
+	defer func() {
+		spanErr := err
+		if spanErr == nil {
+			spanErr = pduErr
+		}
+		span.End(attempt, spanErr)
+		if info != nil {
+			info.Transactions++
+			info.Retries += attempt
+			info.Elapsed += time.Since(start)
+		}
+	}()
 
-	n, _, err := c.conn.ReadFromUDP(readBuffer)
-	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return nil, fmt.Errorf("timeout waiting for ReadPropertyMultiple response")
+	for ; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("failed to read from UDP: %w", err)
+		if _, err := c.conn.WriteTo(packet, deviceAddr); err != nil {
+			err = fmt.Errorf("failed to send confirmed request (service 0x%x): %w", service, err)
+			c.stats.recordError(device.DeviceID, err)
+			return nil, err
+		}
+		c.tapFrame(ctx, FrameSent, deviceAddr, packet)
+		sent := time.Now()
+
+		data, err := c.awaitResponse(ctx, t, c.requestTimeout(ctx, device))
+		if errors.Is(err, errResponseTimeout) && attempt < c.retries(ctx) {
+			if backoff := c.retryBackoff(ctx, attempt+1); backoff > 0 {
+				// A slow device may still answer the request sent
+				data, err = c.awaitResponse(ctx, t, backoff)
+			}
+		}
+		if errors.Is(err, errResponseTimeout) && attempt < c.retries(ctx) {
+			c.stats.retry()
+			c.logf("bacnet: no response from device %d (service 0x%x, invoke ID %d), retrying", device.DeviceID, service, invokeID)
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, errResponseTimeout) {
+				c.stats.timeout()
+			}
+			c.stats.recordError(device.DeviceID, err)
+			c.logf("bacnet: request to device %d (service 0x%x) failed: %v", device.DeviceID, service, err)
+			return nil, err
+		}
+
+		if attempt == 0 {
+			// Responses to resent requests may answer any of the sends and are not measured
+			c.stats.roundTrip(device.DeviceID, time.Since(sent))
+		}
+		if info != nil {
+			info.RoundTrip += time.Since(sent)
+		}
+		if apdu, err := apduFromPacket(data); err == nil {
+			pduErr = pduError(apdu)
+			c.stats.recordError(device.DeviceID, pduErr)
+		}
+		return data, nil
 	}
+}
 
-	// Parse the response, expecting results for a single object
-	parsedResults, err := parseReadPropertyMultipleResponse(readBuffer[:n], invokeID)
+// readDeadline returns the deadline of a read waiting up to timeout, or the deadline of ctx if that
+// is earlier, in which case fromContext is true.
+func readDeadline(ctx context.Context, timeout time.Duration) (deadline time.Time, fromContext bool) {
+	deadline = time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d, true
+	}
+	return deadline, false
+}
+
+// isResponsePDU reports whether an APDU of the given first byte answers a confirmed request.
+func isResponsePDU(pduType byte) bool {
+	switch pduType & 0xF0 {
+	case APDU_SIMPLE_ACK, APDU_COMPLEX_ACK, APDU_ERROR, APDU_REJECT, APDU_ABORT:
+		return true
+	}
+	return false
+}
+
+// apduFromPacket strips the BVLC and NPDU headers from a datagram and returns the APDU.
+func apduFromPacket(data []byte) ([]byte, error) {
+	frame, err := decodeBVLC(data)
+	if err != nil {
+		return nil, err
+	}
+	npdu, apdu, err := decodeNPDU(frame.npdu)
 	if err != nil {
 		return nil, err
 	}
+	if npdu.isNetworkMessage() {
+		return nil, fmt.Errorf("network layer message 0x%x carries no APDU", npdu.messageType)
+	}
+	if len(apdu) == 0 {
+		return nil, fmt.Errorf("empty APDU")
+	}
+	return apdu, nil
+}
 
-	// Extract properties for the requested object
-	if singleObjectResults, ok := parsedResults[object]; ok {
-		if propsMap, isMap := singleObjectResults.(map[uint32]interface{}); isMap {
-			return propsMap, nil
+// readPropertyRaw reads a single property with ReadProperty and returns the encoded property value,
+// i.e. the bytes between the opening and closing tag 3 of the ReadProperty-ACK. Concurrent reads of
+// the same property with the same timeout and retries are coalesced into one request, made with the
+// context of the first of them; the returned bytes must not be modified. CallStats and CallFrames
+// of the other reads see nothing, and should that context end first, the next read still waiting
+// makes the request again with its own.
+func (c *BACnetClient) readPropertyRaw(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, arrayIndex *uint32) ([]byte, error) {
+	return c.reads.do(ctx, c.readKey(ctx, device, object, propertyID, arrayIndex), func(ctx context.Context) ([]byte, error) {
+		var params bytes.Buffer
+		encoding.EncodeContextObjectID(&params, 0, uint32(object.Type), object.Instance)
+		encoding.EncodeContextUnsigned(&params, 1, propertyID)
+		if arrayIndex != nil {
+			encoding.EncodeContextUnsigned(&params, 2, *arrayIndex)
 		}
-		return nil, fmt.Errorf("unexpected format for single object results")
-	}
 
-	return nil, fmt.Errorf("object %v not found in ReadPropertyMultiple response", object)
-}
+		invokeID, data, err := c.transact(ctx, device, SERVICE_CONFIRMED_READ_PROPERTY, params.Bytes())
+		if err != nil {
+			return nil, err
+		}
 
-// parseReadPropertyMultipleResponse parses the response to a ReadPropertyMultiple request.
-func parseReadPropertyMultipleResponse(data []byte, expectedInvokeID byte) (map[BACnetObject]interface{}, error) {
-	r := bytes.NewReader(data)
+		return parseReadPropertyAck(data, invokeID)
+	})
+}
 
-	// BVLC & NPDU - skip
-	var bvlcHeader BVLCHeader
-	if err := binary.Read(r, binary.BigEndian, &bvlcHeader); err != nil {
-		return nil, fmt.Errorf("error reading BVLC header: %w", err)
+// readKey returns the key under which a read made with ctx is coalesced.
+func (c *BACnetClient) readKey(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, arrayIndex *uint32) readKey {
+	key := readKey{
+		deviceID:   device.DeviceID,
+		object:     object,
+		propertyID: propertyID,
+		timeout:    c.timeout(ctx),
+		retries:    c.retries(ctx),
+		backoff:    c.retryBackoff(ctx, 1),
 	}
-	var npduHeader NPDU
-	if err := binary.Read(r, binary.BigEndian, &npduHeader); err != nil {
-		return nil, fmt.Errorf("error reading NPDU header: %w", err)
+	if arrayIndex != nil {
+		key.arrayIndex, key.hasIndex = *arrayIndex, true
 	}
+	return key
+}
 
-	// APDU
-	apduType, err := r.ReadByte()
+// readProperty reads a single property with ReadProperty and decodes its value.
+// Properties with more than one element decode to []interface{}.
+func (c *BACnetClient) readProperty(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, arrayIndex *uint32) (interface{}, error) {
+	raw, err := c.readPropertyRaw(ctx, device, object, propertyID, arrayIndex)
 	if err != nil {
-		return nil, fmt.Errorf("error reading APDU type: %w", err)
-	}
-	if apduType&0xF0 == APDU_ERROR {
-		return nil, fmt.Errorf("received BACnet Error PDU") // Basic error handling
-	}
-	if apduType&0xF0 != APDU_COMPLEX_ACK {
-		return nil, fmt.Errorf("not a Complex-ACK, got 0x%x", apduType)
+		return nil, err
 	}
-	invokeID, _ := r.ReadByte()
-	if invokeID != expectedInvokeID {
-		return nil, fmt.Errorf("invoke ID mismatch: expected %d, got %d", expectedInvokeID, invokeID)
+	value, err := decodePropertyValue(raw)
+	if err != nil {
+		return nil, err
 	}
+	return c.normalizeText(value), nil
+}
+
+// decodePropertyValue decodes an encoded property value as returned by readPropertyRaw.
+func decodePropertyValue(raw []byte) (interface{}, error) {
+	// Wrap the value in tag 3 again so decodeValueList finds its closing tag.
+	framed := bytes.NewBuffer(make([]byte, 0, len(raw)+1))
+	framed.Write(raw)
+	encoding.EncodeClosingTag(framed, 3)
+	return decodeValueList(bytes.NewReader(framed.Bytes()), 3)
+}
 
-	service, err := r.ReadByte()
+// parseReadPropertyAck validates a ReadProperty-ACK and returns the encoded property value.
+func parseReadPropertyAck(data []byte, expectedInvokeID byte) ([]byte, error) {
+	apdu, err := apduFromPacket(data)
+	if err != nil {
+		return nil, err
+	}
+	header, serviceData, err := decodeComplexAck(apdu)
 	if err != nil {
-		return nil, fmt.Errorf("error reading service choice: %w", err)
+		return nil, err
 	}
-	if service != SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE {
-		return nil, fmt.Errorf("not a ReadPropertyMultiple ACK, got 0x%x", service)
+	if header.invokeID != expectedInvokeID {
+		return nil, fmt.Errorf("invoke ID mismatch: expected %d, got %d", expectedInvokeID, header.invokeID)
+	}
+	if header.service != SERVICE_CONFIRMED_READ_PROPERTY {
+		return nil, fmt.Errorf("not a ReadProperty ACK, got 0x%x", header.service)
+	}
+	if header.segmented {
+		return nil, fmt.Errorf("segmented ReadProperty responses are not supported")
 	}
 
-	results := make(map[BACnetObject]interface{})
-
-	// The list of results continues until the APDU is fully read.
-	for r.Len() > 0 {
-		// It must be an object identifier.
-		tag, err := r.ReadByte()
+	r := bytes.NewReader(serviceData)
+	// Object Identifier, Property Identifier and optional Property Array Index
+	for {
+		tag, err := encoding.DecodeTagHeader(r)
 		if err != nil {
-			break // Clean exit at end of data
-		}
-		if tag != 0x0C { // Context Tag 0, Length 4
-			return nil, fmt.Errorf("expected object identifier tag 0x0C, got 0x%x", tag)
+			return nil, fmt.Errorf("error reading ReadProperty-ACK: %w", err)
 		}
-		var objectIdentifier uint32
-		if err := binary.Read(r, binary.BigEndian, &objectIdentifier); err != nil {
-			return nil, fmt.Errorf("failed to read object identifier: %w", err)
+		if tag.IsOpening(3) {
+			break
 		}
-		currentObject := BACnetObject{
-			Type:     ObjectType(objectIdentifier >> 22),
-			Instance: objectIdentifier & 0x3FFFFF,
+		if !tag.Context || tag.Number > 2 {
+			return nil, fmt.Errorf("unexpected tag %d in ReadProperty-ACK", tag.Number)
 		}
-
-		// Now comes the list of properties for this object.
-		// Expect Context Tag 1, Opening Tag (0x1E)
-		tag, err = r.ReadByte()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read opening tag for property list: %w", err)
-		}
-		if tag != 0x1E {
-			return nil, fmt.Errorf("expected opening tag 0x1E for property list, got 0x%x", tag)
-		}
-
-		// Properties for the current object
-		objectProperties := make(map[uint32]interface{})
-		for {
-			tag, err := r.ReadByte()
-			if err != nil {
-				return nil, fmt.Errorf("failed to read tag inside property list: %w", err)
-			}
-
-			if tag == 0x1F { // Context Tag 1, Closing Tag
-				break // End of properties for this object
-			}
-
-			if tag != 0x29 { // Context Tag 2, Length 1
-				return nil, fmt.Errorf("expected property identifier tag 0x29, got 0x%x", tag)
-			}
-			var propID byte
-			if err := binary.Read(r, binary.BigEndian, &propID); err != nil {
-				return nil, fmt.Errorf("failed to read property identifier: %w", err)
-			}
-
-			// Expect Context Tag 4, Opening Tag (0x4E)
-			tag, err = r.ReadByte()
-			if err != nil {
-				return nil, fmt.Errorf("failed to read opening tag for property value: %w", err)
-			}
-			if tag != 0x4E {
-				return nil, fmt.Errorf("expected opening tag 0x4E for property value, got 0x%x", tag)
-			}
-
-			val, err := decodeApplicationValue(r)
-			if err != nil {
-				return nil, fmt.Errorf("failed to decode application value for prop %d: %w", propID, err)
-			}
-
-			// Expect Context Tag 4, Closing Tag (0x4F)
-			tag, err = r.ReadByte()
-			if err != nil {
-				return nil, fmt.Errorf("failed to read closing tag for property value: %w", err)
-			}
-			if tag != 0x4F {
-				return nil, fmt.Errorf("expected closing tag 0x4F for property value, got 0x%x", tag)
-			}
-			objectProperties[uint32(propID)] = val
+		if err := encoding.SkipValue(r, tag); err != nil {
+			return nil, err
 		}
-		results[currentObject] = objectProperties
 	}
 
-	return results, nil
+	// Property Value, up to the closing tag 3 at the end of the service data
+	value := serviceData[len(serviceData)-r.Len():]
+	if len(value) == 0 || value[len(value)-1] != 0x3F {
+		return nil, fmt.Errorf("missing closing tag for property value")
+	}
+	return value[:len(value)-1], nil
 }
@@ -0,0 +1,236 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+func TestReadAccessSpecificationRoundTrip(t *testing.T) {
+	f := func(types []uint16, instances []uint32, propertyIDs []uint32) bool {
+		var buf bytes.Buffer
+		var want []ReadAccessSpecification
+		for i := range min(len(types), len(instances)) {
+			object := BACnetObject{Type: ObjectType(types[i] & 0x3FF), Instance: instances[i] & 0x3FFFFF}
+			encodeReadAccessSpecification(&buf, object, propertyIDs)
+			spec := ReadAccessSpecification{Object: object}
+			for _, id := range propertyIDs {
+				spec.Properties = append(spec.Properties, PropertyReference{PropertyID: id})
+			}
+			want = append(want, spec)
+		}
+		got, err := decodeReadAccessSpecifications(buf.Bytes())
+		return err == nil && reflect.DeepEqual(got, want)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestRequestEncodingDeterministic checks that the multi-object encoders produce the same bytes
+// every time and keep the order of objects and properties they are given.
+func TestRequestEncodingDeterministic(t *testing.T) {
+	objects := []BACnetObject{
+		{Type: OBJECT_BINARY_VALUE, Instance: 3},
+		{Type: OBJECT_ANALOG_INPUT, Instance: 10},
+		{Type: OBJECT_ANALOG_INPUT, Instance: 2},
+	}
+	properties := []uint32{PROP_STATUS_FLAGS, PROP_PRESENT_VALUE, PROP_OBJECT_NAME}
+
+	encodeRPM := func() []byte {
+		var buf bytes.Buffer
+		for _, object := range objects {
+			encodeReadAccessSpecification(&buf, object, properties)
+		}
+		return buf.Bytes()
+	}
+	first := encodeRPM()
+	for range 10 {
+		if got := encodeRPM(); !bytes.Equal(got, first) {
+			t.Fatalf("ReadPropertyMultiple encoding changed: %x, then %x", first, got)
+		}
+	}
+	specs, err := decodeReadAccessSpecifications(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, spec := range specs {
+		if spec.Object != objects[i] {
+			t.Errorf("specification %d is for %s, want %s", i, spec.Object, objects[i])
+		}
+		for j, ref := range spec.Properties {
+			if ref.PropertyID != properties[j] {
+				t.Errorf("property %d of %s is %d, want %d", j, spec.Object, ref.PropertyID, properties[j])
+			}
+		}
+	}
+
+	increment := float32(0.5)
+	specsCOV := []COVSubscriptionSpec{
+		{Object: objects[0], References: []COVReference{{PropertyID: PROP_PRESENT_VALUE}}},
+		{Object: objects[1], References: []COVReference{{PropertyID: PROP_PRESENT_VALUE, Increment: &increment}, {PropertyID: PROP_STATUS_FLAGS}}},
+	}
+	covFirst := encodeSubscribeCOVPropertyMultiple(specsCOV, 1, false, 300, 0)
+	for range 10 {
+		if got := encodeSubscribeCOVPropertyMultiple(specsCOV, 1, false, 300, 0); !bytes.Equal(got, covFirst) {
+			t.Fatalf("SubscribeCOVPropertyMultiple encoding changed: %x, then %x", covFirst, got)
+		}
+	}
+}
+
+func TestContextVariants(t *testing.T) {
+	sim, device := startSimulator(t, SimulatorOptions{DeviceID: 3003, AnalogValues: 1})
+	client := newLoopbackClient(t, ClientOptions{})
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+
+	if _, err := client.ReadPropertyContext(context.Background(), device, av1, PROP_PRESENT_VALUE, nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.ReadPropertyContext(ctx, device, av1, PROP_PRESENT_VALUE, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("read with a cancelled context: %v", err)
+	}
+	// The context of the variant wins over one passed with CallContext.
+	if err := client.WritePropertyContext(ctx, device, av1, PROP_PRESENT_VALUE, float32(1), 0, CallContext(context.Background())); !errors.Is(err, context.Canceled) {
+		t.Errorf("write with a cancelled context: %v", err)
+	}
+	if v := sim.Value(av1); v == float32(1) {
+		t.Error("write with a cancelled context reached the device")
+	}
+
+	// Ending the context ends the search with the devices that answered.
+	search, stop := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer stop()
+	devices, err := client.WhoIsContext(search, &net.UDPAddr{IP: device.IPAddress, Port: device.Port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].DeviceID != 3003 {
+		t.Errorf("Who-Is found %+v", devices)
+	}
+}
+
+// rpmResults encodes the service data of a ReadPropertyMultiple-ACK with one result per property
+// of each object.
+func rpmResults(objects []BACnetObject, properties []uint32, value func(BACnetObject, uint32) interface{}) []byte {
+	var buf bytes.Buffer
+	for _, object := range objects {
+		encoding.EncodeContextObjectID(&buf, 0, uint32(object.Type), object.Instance)
+		encoding.EncodeOpeningTag(&buf, 1)
+		for _, id := range properties {
+			encoding.EncodeContextUnsigned(&buf, 2, id)
+			encoding.EncodeOpeningTag(&buf, 4)
+			encodeApplicationValue(&buf, value(object, id))
+			encoding.EncodeClosingTag(&buf, 4)
+		}
+		encoding.EncodeClosingTag(&buf, 1)
+	}
+	return buf.Bytes()
+}
+
+// sendSegmented answers a ReadPropertyMultiple request of a fakeDevice with the given service data
+// in segments of size bytes, expecting a Segment-ACK for each.
+func sendSegmented(t *testing.T, dev *net.UDPConn, client *BACnetClient, requests <-chan []byte, serviceData []byte, size int) {
+	t.Helper()
+	request := receiveAPDU(t, requests)
+	invokeID := request[2]
+	for seq := byte(0); len(serviceData) > 0; seq++ {
+		n := min(size, len(serviceData))
+		pduType := APDU_COMPLEX_ACK | 0x08
+		if n < len(serviceData) {
+			pduType |= 0x04 // More follows
+		}
+		segment := append([]byte{pduType, invokeID, seq, 1, SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE}, serviceData[:n]...)
+		serviceData = serviceData[n:]
+		dev.WriteTo(serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, npduHeader{}, segment), client.conn.LocalAddr())
+		if ack := receiveAPDU(t, requests); ack[0] != APDU_SEGMENT_ACK || ack[1] != invokeID || ack[2] != seq {
+			t.Fatalf("segment %d answered with % x", seq, ack)
+		}
+	}
+}
+
+func TestMultipleObjectReadsSegmented(t *testing.T) {
+	dev, device, requests := fakeDevice(t, 3009)
+	client := newLoopbackClient(t, ClientOptions{})
+	objects := []BACnetObject{{Type: OBJECT_ANALOG_VALUE, Instance: 1}, {Type: OBJECT_ANALOG_VALUE, Instance: 2}}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		values, err := client.ReadPropertiesFromMultipleObjects(device, objects, PROP_PRESENT_VALUE)
+		done <- result{values, err}
+	}()
+	sendSegmented(t, dev, client, requests, rpmResults(objects, []uint32{PROP_PRESENT_VALUE}, func(object BACnetObject, _ uint32) interface{} {
+		return float32(object.Instance)
+	}), 7)
+	r := <-done
+	want := map[BACnetObject]interface{}{
+		objects[0]: map[uint32]interface{}{PROP_PRESENT_VALUE: float32(1)},
+		objects[1]: map[uint32]interface{}{PROP_PRESENT_VALUE: float32(2)},
+	}
+	if r.err != nil || !reflect.DeepEqual(r.value, want) {
+		t.Errorf("ReadPropertiesFromMultipleObjects = %v, %v", r.value, r.err)
+	}
+
+	go func() {
+		properties, err := client.GetObjectAllPropertyList(device, objects[0])
+		done <- result{properties, err}
+	}()
+	sendSegmented(t, dev, client, requests, rpmResults(objects[:1], []uint32{PROP_OBJECT_NAME, PROP_PRESENT_VALUE}, func(_ BACnetObject, id uint32) interface{} {
+		if id == PROP_OBJECT_NAME {
+			return "supply temperature"
+		}
+		return float32(21.5)
+	}), 7)
+	r = <-done
+	wantAll := []BACnetPropertyValue{{PropertyID: PROP_OBJECT_NAME, Value: "supply temperature"}, {PropertyID: PROP_PRESENT_VALUE, Value: float32(21.5)}}
+	if r.err != nil || !reflect.DeepEqual(r.value, wantAll) {
+		t.Errorf("GetObjectAllPropertyList = %v, %v", r.value, r.err)
+	}
+}
+
+// TestStoppedStreamAborted checks that a segmented response is aborted, not acknowledged, when
+// reading stops before its last segment.
+func TestStoppedStreamAborted(t *testing.T) {
+	dev, device, requests := fakeDevice(t, 3010)
+	client := newLoopbackClient(t, ClientOptions{})
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.GetObjectAllPropertyList(device, av1)
+		done <- err
+	}()
+	invokeID := receiveAPDU(t, requests)[2]
+
+	// The first segment holds an error, on which GetObjectAllPropertyList stops.
+	segment := []byte{APDU_COMPLEX_ACK | 0x08 | 0x04, invokeID, 0, 1, SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE}
+	buf := bytes.NewBuffer(segment)
+	encoding.EncodeContextObjectID(buf, 0, uint32(av1.Type), av1.Instance)
+	encoding.EncodeOpeningTag(buf, 1)
+	encoding.EncodeContextUnsigned(buf, 2, PROP_PRESENT_VALUE)
+	encoding.EncodeOpeningTag(buf, 5)
+	encoding.EncodeApplicationEnumerated(buf, 2)  // Property
+	encoding.EncodeApplicationEnumerated(buf, 32) // Unknown property
+	encoding.EncodeClosingTag(buf, 5)
+	dev.WriteTo(serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, npduHeader{}, buf.Bytes()), client.conn.LocalAddr())
+
+	if reply := receiveAPDU(t, requests); !bytes.Equal(reply, []byte{APDU_ABORT, invokeID, byte(AbortReasonOther)}) {
+		t.Errorf("stopped stream answered with % x, want an Abort", reply)
+	}
+	var accessErr *PropertyAccessError
+	if err := <-done; !errors.As(err, &accessErr) {
+		t.Errorf("GetObjectAllPropertyList: %v, want the property access error", err)
+	}
+}
@@ -0,0 +1,125 @@
+package bacnet
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// roundTripWindow is how many of the latest round trips to a device the client keeps.
+const roundTripWindow = 64
+
+// AdaptiveTimeout derives the response timeout of requests to a device from the round trips
+// measured to it, instead of using ClientOptions.Timeout for every device: fast devices are retried
+// sooner and slow ones are given longer. Until enough round trips were measured, and for calls
+// setting CallTimeout, the timeout is not adapted.
+type AdaptiveTimeout struct {
+	// Factor multiplies the 95th percentile of the measured round trips; zero means 3.
+	Factor float64 `json:"factor,omitempty"`
+	// Min and Max bound the derived timeout; zero means a tenth and four times
+	// ClientOptions.Timeout.
+	Min time.Duration `json:"min,omitempty"`
+	Max time.Duration `json:"max,omitempty"`
+	// MinSamples is how many round trips to a device must have been measured before its timeout is
+	// adapted; zero means 10.
+	MinSamples int `json:"minSamples,omitempty"`
+}
+
+// RoundTripStats summarizes the latest round trips measured to a device: the time from sending a
+// request to its response, for requests answered without being resent.
+type RoundTripStats struct {
+	Samples int           `json:"samples"`
+	Mean    time.Duration `json:"mean"`
+	P50     time.Duration `json:"p50"`
+	P95     time.Duration `json:"p95"`
+	Max     time.Duration `json:"max"`
+}
+
+// roundTrips holds the latest round trips to a device, oldest overwritten first.
+type roundTrips struct {
+	samples []time.Duration
+	next    int
+}
+
+func (r *roundTrips) add(d time.Duration) {
+	if len(r.samples) < roundTripWindow {
+		r.samples = append(r.samples, d)
+		return
+	}
+	r.samples[r.next] = d
+	r.next = (r.next + 1) % roundTripWindow
+}
+
+func (r *roundTrips) stats() RoundTripStats {
+	sorted := slices.Clone(r.samples)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	return RoundTripStats{
+		Samples: len(sorted),
+		Mean:    sum / time.Duration(len(sorted)),
+		P50:     percentile(sorted, 50),
+		P95:     percentile(sorted, 95),
+		Max:     sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the p-th percentile of sorted, which must not be empty, by the nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+func (s *clientStats) roundTrip(deviceID uint32, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.roundTrips[deviceID]
+	if r == nil {
+		r = &roundTrips{}
+		s.roundTrips[deviceID] = r
+	}
+	r.add(d)
+}
+
+// roundTripStats returns the round trip statistics of a device, with Samples zero if none were
+// measured.
+func (s *clientStats) roundTripStats(deviceID uint32) RoundTripStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.roundTrips[deviceID]
+	if r == nil {
+		return RoundTripStats{}
+	}
+	return r.stats()
+}
+
+// requestTimeout returns the response timeout of a request to device made with ctx: the timeout of
+// the call, or the one derived from the round trips to device under ClientOptions.AdaptiveTimeout.
+func (c *BACnetClient) requestTimeout(ctx context.Context, device DeviceInfo) time.Duration {
+	adaptive := c.options.AdaptiveTimeout
+	if call, ok := ctx.Value(callOptionsKey{}).(callOptions); adaptive == nil || ok && call.timeout != nil {
+		return c.timeout(ctx)
+	}
+	minSamples := adaptive.MinSamples
+	if minSamples <= 0 {
+		minSamples = 10
+	}
+	rtt := c.stats.roundTripStats(device.DeviceID)
+	if rtt.Samples < minSamples {
+		return c.options.Timeout
+	}
+	factor := adaptive.Factor
+	if factor <= 0 {
+		factor = 3
+	}
+	lower, upper := adaptive.Min, adaptive.Max
+	if lower <= 0 {
+		lower = c.options.Timeout / 10
+	}
+	if upper <= 0 {
+		upper = 4 * c.options.Timeout
+	}
+	return min(max(time.Duration(float64(rtt.P95)*factor), lower), upper)
+}
@@ -0,0 +1,429 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// RPMResult is a single (object, property, value) tuple of a ReadPropertyMultiple response.
+type RPMResult struct {
+	Object     BACnetObject
+	PropertyID uint32
+	// ArrayIndex is set when the result is for a single element of an array property.
+	ArrayIndex *uint32
+	// Value is the decoded property value. Properties with more than one element decode to []interface{}.
+	Value interface{}
+	// Err is set instead of Value when the device reported an error for this property.
+	Err error
+}
+
+// PropertyAccessError is the error a device reports for a single property of a ReadPropertyMultiple
+// request, e.g. when the property does not exist on the object.
+type PropertyAccessError struct {
+	Class uint32
+	Code  uint32
+}
+
+func (e *PropertyAccessError) Error() string {
+	return "property access error: " + errorLabel(e.Class, e.Code)
+}
+
+// RPMStreamDecoder decodes the service data of ReadPropertyMultiple-ACKs incrementally.
+// Data can be written in arbitrary pieces (e.g. one segment at a time); every complete
+// result is handed to the callback as soon as it has been decoded, and only the bytes of the
+// result currently being received are buffered.
+type RPMStreamDecoder struct {
+	fn       func(RPMResult) bool
+	buf      []byte
+	inObject bool
+	object   BACnetObject
+	stopped  bool
+}
+
+// NewRPMStreamDecoder returns a decoder that calls fn for every result. Decoding stops when fn
+// returns false; further writes are then discarded.
+func NewRPMStreamDecoder(fn func(RPMResult) bool) *RPMStreamDecoder {
+	return &RPMStreamDecoder{fn: fn}
+}
+
+// Write feeds service data (the bytes following the service choice of the Complex-ACK) to the decoder.
+func (d *RPMStreamDecoder) Write(p []byte) (int, error) {
+	if d.stopped {
+		return len(p), nil
+	}
+	d.buf = append(d.buf, p...)
+
+	for len(d.buf) > 0 && !d.stopped {
+		r := bytes.NewReader(d.buf)
+		err := d.decodeNext(r)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break // Incomplete element, wait for more data
+		}
+		if err != nil {
+			return 0, err
+		}
+		d.buf = d.buf[len(d.buf)-r.Len():]
+	}
+
+	// Release the backing array once everything has been consumed.
+	if len(d.buf) == 0 {
+		d.buf = nil
+	}
+	return len(p), nil
+}
+
+// Stopped reports whether the callback asked to stop decoding.
+func (d *RPMStreamDecoder) Stopped() bool {
+	return d.stopped
+}
+
+// Close reports an error if the data written so far ended in the middle of a result.
+func (d *RPMStreamDecoder) Close() error {
+	if d.stopped {
+		return nil
+	}
+	if len(d.buf) > 0 || d.inObject {
+		return fmt.Errorf("truncated ReadPropertyMultiple response")
+	}
+	return nil
+}
+
+// decodeNext decodes one element: the start of an object's results, the end of an object's
+// results, or a single property result.
+func (d *RPMStreamDecoder) decodeNext(r *bytes.Reader) error {
+	tag, err := encoding.DecodeTagHeader(r)
+	if err != nil {
+		return err
+	}
+
+	if !d.inObject {
+		if !tag.IsContext(0) || tag.Length != 4 {
+			return fmt.Errorf("expected object identifier context tag 0, got tag %d", tag.Number)
+		}
+		var objectIdentifier uint32
+		if err := binary.Read(r, binary.BigEndian, &objectIdentifier); err != nil {
+			return err
+		}
+		if err := encoding.ExpectOpeningTag(r, 1); err != nil {
+			return err
+		}
+		objectType, instance := encoding.SplitObjectID(objectIdentifier)
+		d.object = BACnetObject{Type: ObjectType(objectType), Instance: instance}
+		d.inObject = true
+		return nil
+	}
+
+	if tag.IsClosing(1) {
+		d.inObject = false
+		return nil
+	}
+
+	if !tag.IsContext(2) {
+		return fmt.Errorf("expected property identifier context tag 2, got tag %d", tag.Number)
+	}
+	result := RPMResult{Object: d.object}
+	if result.PropertyID, err = encoding.DecodeUnsigned(r, tag.Length); err != nil {
+		return err
+	}
+
+	tag, err = encoding.DecodeTagHeader(r)
+	if err != nil {
+		return err
+	}
+	if tag.IsContext(3) {
+		index, err := encoding.DecodeUnsigned(r, tag.Length)
+		if err != nil {
+			return err
+		}
+		result.ArrayIndex = &index
+		if tag, err = encoding.DecodeTagHeader(r); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case tag.IsOpening(4):
+		value, err := decodeValueList(r, 4)
+		if err != nil {
+			return err
+		}
+		result.Value = typedPropertyValue(d.object.Type, result.PropertyID, value)
+	case tag.IsOpening(5):
+		if result.Err, err = decodePropertyAccessError(r); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("expected property value or error for prop %d, got tag %d", result.PropertyID, tag.Number)
+	}
+
+	if !d.fn(result) {
+		d.stopped = true
+	}
+	return nil
+}
+
+// decodeValueList decodes application values up to the closing tag with the given number.
+// A single value is returned as is, several values as []interface{}. Context-tagged values,
+// which cannot be interpreted without knowing the property, are returned as raw bytes and
+// nested constructed values as []interface{}.
+func decodeValueList(r *bytes.Reader, closingTag uint8) (interface{}, error) {
+	var values []interface{}
+	for {
+		start := r.Len()
+		tag, err := encoding.DecodeTagHeader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case tag.IsClosing(closingTag):
+			if len(values) == 1 {
+				return values[0], nil
+			}
+			return values, nil
+		case tag.Opening:
+			nested, err := decodeValueList(r, tag.Number)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, nested)
+		case tag.Context:
+			raw := make([]byte, tag.Length)
+			if _, err := io.ReadFull(r, raw); err != nil {
+				return nil, err
+			}
+			values = append(values, raw)
+		default:
+			// Rewind so decodeApplicationValue sees the tag.
+			r.Seek(int64(r.Size())-int64(start), io.SeekStart)
+			val, err := decodeApplicationValue(r)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, val)
+		}
+	}
+}
+
+// decodePropertyAccessError decodes the error class and code of a property access error up to
+// and including closing tag 5.
+func decodePropertyAccessError(r *bytes.Reader) (error, error) {
+	var codes [2]uint32
+	for i := range codes {
+		tag, err := encoding.DecodeTagHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if tag.Context || tag.Number != encoding.TagEnumerated {
+			return nil, fmt.Errorf("expected enumerated error class/code, got tag %d", tag.Number)
+		}
+		if codes[i], err = encoding.DecodeUnsigned(r, tag.Length); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoding.ExpectClosingTag(r, 5); err != nil {
+		return nil, err
+	}
+	return &PropertyAccessError{Class: codes[0], Code: codes[1]}, nil
+}
+
+// complexAckHeader is the decoded header of a Complex-ACK APDU.
+type complexAckHeader struct {
+	invokeID       byte
+	segmented      bool
+	moreFollows    bool
+	sequenceNumber byte
+	windowSize     byte
+	service        byte
+}
+
+// decodeComplexAck decodes the header of a Complex-ACK and returns it with the service data.
+func decodeComplexAck(apdu []byte) (complexAckHeader, []byte, error) {
+	if len(apdu) < 1 {
+		return complexAckHeader{}, nil, fmt.Errorf("empty APDU")
+	}
+	if err := pduError(apdu); err != nil {
+		return complexAckHeader{}, nil, err
+	}
+	if apdu[0]&0xF0 != APDU_COMPLEX_ACK {
+		return complexAckHeader{}, nil, fmt.Errorf("not a Complex-ACK, got 0x%x", apdu[0])
+	}
+
+	h := complexAckHeader{
+		segmented:   apdu[0]&0x08 != 0,
+		moreFollows: apdu[0]&0x04 != 0,
+	}
+	headerLen := 3
+	if h.segmented {
+		headerLen = 5
+	}
+	if len(apdu) < headerLen {
+		return complexAckHeader{}, nil, fmt.Errorf("truncated Complex-ACK header")
+	}
+	h.invokeID = apdu[1]
+	if h.segmented {
+		h.sequenceNumber = apdu[2]
+		h.windowSize = apdu[3]
+	}
+	h.service = apdu[headerLen-1]
+	return h, apdu[headerLen:], nil
+}
+
+// segmentAckPacket builds a Segment-ACK acknowledging all segments up to sequenceNumber.
+func segmentAckPacket(invokeID, sequenceNumber, windowSize byte) []byte {
+	var buffer bytes.Buffer
+
+	// BVLC Header
+	bvlc := BVLCHeader{
+		Type:     BVLC_TYPE_BACNET_IP,
+		Function: BVLC_ORIGINAL_UNICAST_NPDU,
+		Length:   4 + 2 + 4,
+	}
+	binary.Write(&buffer, binary.BigEndian, &bvlc)
+
+	// NPDU
+	npdu := NPDU{
+		Version: 1,
+		Control: NPDU_CONTROL_NORMAL_MESSAGE,
+	}
+	binary.Write(&buffer, binary.BigEndian, &npdu)
+
+	// APDU (Segment-ACK, sent by the client)
+	buffer.WriteByte(APDU_SEGMENT_ACK)
+	buffer.WriteByte(invokeID)
+	buffer.WriteByte(sequenceNumber)
+	buffer.WriteByte(windowSize)
+
+	return buffer.Bytes()
+}
+
+// abortPacket builds an Abort of the transaction with the given invoke ID, sent by the client.
+func abortPacket(invokeID byte, reason AbortReason) []byte {
+	var buffer bytes.Buffer
+
+	// BVLC Header
+	bvlc := BVLCHeader{
+		Type:     BVLC_TYPE_BACNET_IP,
+		Function: BVLC_ORIGINAL_UNICAST_NPDU,
+		Length:   4 + 2 + 3,
+	}
+	binary.Write(&buffer, binary.BigEndian, &bvlc)
+
+	// NPDU
+	npdu := NPDU{
+		Version: 1,
+		Control: NPDU_CONTROL_NORMAL_MESSAGE,
+	}
+	binary.Write(&buffer, binary.BigEndian, &npdu)
+
+	// APDU (Abort, sent by the client)
+	buffer.WriteByte(APDU_ABORT)
+	buffer.WriteByte(invokeID)
+	buffer.WriteByte(byte(reason))
+
+	return buffer.Bytes()
+}
+
+// ReadPropertyMultipleStream reads the given properties of several objects with a single
+// ReadPropertyMultiple request and hands every (object, property, value) result to fn as soon as
+// it has been decoded, without building maps of the whole response. Segmented responses are
+// acknowledged and decoded segment by segment. Returning false from fn stops decoding; segments
+// not yet received are then aborted.
+func (c *BACnetClient) ReadPropertyMultipleStream(device DeviceInfo, objects []BACnetObject, propertyIDs []uint32, fn func(RPMResult) bool, opts ...CallOption) error {
+	var params bytes.Buffer
+	for _, obj := range objects {
+		encodeReadAccessSpecification(&params, obj, propertyIDs)
+	}
+	return c.readPropertyMultipleStream(callContext(opts...), device, params.Bytes(), fn)
+}
+
+// readPropertyMultipleStream sends a ReadPropertyMultiple request with the encoded read access
+// specifications params and hands the results to fn as they are decoded.
+func (c *BACnetClient) readPropertyMultipleStream(ctx context.Context, device DeviceInfo, params []byte, fn func(RPMResult) bool) error {
+	// The transaction stays open for the segments following the first.
+	t, err := c.newTransaction(ctx, device)
+	if err != nil {
+		return err
+	}
+	defer c.releaseTransaction(t)
+	invokeID := t.key.invokeID
+	data, err := c.request(ctx, t, device, SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE, params)
+	if err != nil {
+		return err
+	}
+
+	decoder := NewRPMStreamDecoder(func(result RPMResult) bool {
+		result.Value = c.normalizeText(result.Value)
+		return fn(result)
+	})
+	deviceAddr := c.deviceAddr(device)
+	var expectedSequence byte
+	info := callInfo(ctx)
+
+	for {
+		apdu, err := apduFromPacket(data)
+		if err != nil {
+			return err
+		}
+		header, serviceData, err := decodeComplexAck(apdu)
+		if err != nil {
+			return err
+		}
+		if header.invokeID != invokeID {
+			return fmt.Errorf("invoke ID mismatch: expected %d, got %d", invokeID, header.invokeID)
+		}
+		if header.service != SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE {
+			return fmt.Errorf("not a ReadPropertyMultiple ACK, got 0x%x", header.service)
+		}
+
+		if header.segmented {
+			if header.sequenceNumber != expectedSequence {
+				return fmt.Errorf("segment out of order: expected %d, got %d", expectedSequence, header.sequenceNumber)
+			}
+			expectedSequence++
+			if info != nil {
+				info.Segments++
+			}
+		}
+
+		if _, err := decoder.Write(serviceData); err != nil {
+			return err
+		}
+		if decoder.Stopped() && header.moreFollows {
+			// Abort rather than acknowledge, so the device does not go on sending the rest
+			abort := abortPacket(invokeID, AbortReasonOther)
+			if _, err := c.conn.WriteTo(abort, deviceAddr); err != nil {
+				return fmt.Errorf("failed to send Abort: %w", err)
+			}
+			c.tapFrame(ctx, FrameSent, deviceAddr, abort)
+			return nil
+		}
+		if header.segmented {
+			ack := segmentAckPacket(invokeID, header.sequenceNumber, 1)
+			if _, err := c.conn.WriteTo(ack, deviceAddr); err != nil {
+				return fmt.Errorf("failed to send Segment-ACK: %w", err)
+			}
+			c.tapFrame(ctx, FrameSent, deviceAddr, ack)
+		}
+		if decoder.Stopped() || !header.moreFollows {
+			return decoder.Close()
+		}
+
+		waited := time.Now()
+		data, err = c.awaitResponse(ctx, t, c.requestTimeout(ctx, device))
+		if info != nil {
+			info.Elapsed += time.Since(waited)
+		}
+		if err != nil {
+			c.stats.recordError(device.DeviceID, err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package sc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// LoadIssuerCertificates reads PEM encoded issuer (CA) certificates from files into a pool.
+func LoadIssuerCertificates(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read issuer certificate: %w", err)
+		}
+		found := false
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse issuer certificate in %s: %w", file, err)
+			}
+			pool.AddCert(cert)
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("no certificate found in %s", file)
+		}
+	}
+	return pool, nil
+}
+
+// LoadOperationalCertificate reads the PEM encoded operational certificate of the node and its
+// private key.
+func LoadOperationalCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load operational certificate: %w", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to parse operational certificate: %w", err)
+		}
+	}
+	return cert, nil
+}
+
+// VerifyPeerCertificate checks that the certificate chain presented by a peer leads to one of the
+// issuers. BACnet/SC peers are authenticated by their certificate alone, so host names are not
+// checked. Intermediate certificates sent by the peer are used to build the chain.
+func VerifyPeerCertificate(rawCerts [][]byte, issuers *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("peer presented no certificate")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse peer certificate: %w", err)
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         issuers,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("peer certificate not trusted: %w", err)
+	}
+	return nil
+}
+
+// CertificateStore holds the operational certificate and issuer certificates of a node and reloads
+// them from disk when the files change. TLS configurations from TLSConfig always use the current
+// certificates for new handshakes; established connections are not affected by a reload.
+type CertificateStore struct {
+	certFile    string
+	keyFile     string
+	issuerFiles []string
+	errors      chan error
+
+	mu       sync.RWMutex
+	cert     tls.Certificate
+	issuers  *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewCertificateStore loads the operational certificate and key and the issuer certificates.
+func NewCertificateStore(certFile, keyFile string, issuerFiles ...string) (*CertificateStore, error) {
+	s := &CertificateStore{
+		certFile:    certFile,
+		keyFile:     keyFile,
+		issuerFiles: issuerFiles,
+		errors:      make(chan error, 16),
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Errors returns the channel reload errors of Watch are delivered on. Errors are dropped when the
+// channel is full.
+func (s *CertificateStore) Errors() <-chan error {
+	return s.errors
+}
+
+// Reload reads all certificate files again. The current certificates are kept if any file fails
+// to load.
+func (s *CertificateStore) Reload() error {
+	modTimes, err := s.fileModTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := LoadOperationalCertificate(s.certFile, s.keyFile)
+	if err != nil {
+		return err
+	}
+	issuers, err := LoadIssuerCertificates(s.issuerFiles...)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cert, s.issuers, s.modTimes = cert, issuers, modTimes
+	return nil
+}
+
+// Certificate returns the current operational certificate.
+func (s *CertificateStore) Certificate() tls.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert
+}
+
+// Watch checks the certificate files every interval and reloads them when one has changed, until
+// ctx is cancelled.
+func (s *CertificateStore) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTimes, err := s.fileModTimes()
+		if err != nil {
+			s.reportError(err)
+			continue
+		}
+		s.mu.RLock()
+		changed := false
+		for file, modTime := range modTimes {
+			if !modTime.Equal(s.modTimes[file]) {
+				changed = true
+			}
+		}
+		s.mu.RUnlock()
+		if changed {
+			if err := s.Reload(); err != nil {
+				s.reportError(err)
+			}
+		}
+	}
+}
+
+// TLSConfig returns a TLS configuration for hub and direct connections, both as client and as
+// server. Peers must present a certificate issued by one of the issuers.
+func (s *CertificateStore) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert := s.Certificate()
+			return &cert, nil
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert := s.Certificate()
+			return &cert, nil
+		},
+		ClientAuth: tls.RequireAnyClientCert,
+		// Chains are verified against the current issuers in VerifyPeerCertificate instead.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			s.mu.RLock()
+			issuers := s.issuers
+			s.mu.RUnlock()
+			return VerifyPeerCertificate(rawCerts, issuers)
+		},
+	}
+}
+
+func (s *CertificateStore) fileModTimes() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+	for _, file := range append([]string{s.certFile, s.keyFile}, s.issuerFiles...) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+func (s *CertificateStore) reportError(err error) {
+	select {
+	case s.errors <- err:
+	default:
+	}
+}
@@ -0,0 +1,268 @@
+// Package sc implements the BACnet Secure Connect (BACnet/SC) data link: BVLC-SC messages, hub
+// connections with failover and direct connections between nodes.
+//
+// The package does not depend on a WebSocket implementation. Connections are opened through a
+// Dialer supplied by the application, which makes it possible to use any WebSocket library and to
+// test nodes without a network.
+package sc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Function is the BVLC function of a BVLC-SC message.
+type Function byte
+
+const (
+	FunctionResult                    Function = 0x00
+	FunctionEncapsulatedNPDU          Function = 0x01
+	FunctionAddressResolution         Function = 0x02
+	FunctionAddressResolutionACK      Function = 0x03
+	FunctionAdvertisement             Function = 0x04
+	FunctionAdvertisementSolicitation Function = 0x05
+	FunctionConnectRequest            Function = 0x06
+	FunctionConnectAccept             Function = 0x07
+	FunctionDisconnectRequest         Function = 0x08
+	FunctionDisconnectACK             Function = 0x09
+	FunctionHeartbeatRequest          Function = 0x0A
+	FunctionHeartbeatACK              Function = 0x0B
+	FunctionProprietaryMessage        Function = 0x0C
+)
+
+// Control flags of the BVLC-SC header.
+const (
+	controlOriginatingVMAC    = 0x08
+	controlDestinationVMAC    = 0x04
+	controlDestinationOptions = 0x02
+	controlDataOptions        = 0x01
+)
+
+// Header option marker bits.
+const (
+	optionMoreFollows    = 0x80
+	optionMustUnderstand = 0x40
+	optionHasData        = 0x20
+	optionTypeMask       = 0x1F
+)
+
+// ErrTruncated is returned when a message ends before its header or payload is complete.
+var ErrTruncated = errors.New("truncated BVLC-SC message")
+
+// VMAC is the six byte virtual MAC address of a BACnet/SC node.
+type VMAC [6]byte
+
+// BroadcastVMAC is the destination of broadcast messages.
+var BroadcastVMAC = VMAC{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+func (v VMAC) String() string {
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", v[0], v[1], v[2], v[3], v[4], v[5])
+}
+
+// HeaderOption is a destination or data option of a BVLC-SC message.
+type HeaderOption struct {
+	Type           byte
+	MustUnderstand bool
+	Data           []byte
+}
+
+// Message is a BVLC-SC message.
+type Message struct {
+	Function  Function
+	MessageID uint16
+	// Origin and Destination are nil when the message does not carry the address.
+	Origin             *VMAC
+	Destination        *VMAC
+	DestinationOptions []HeaderOption
+	DataOptions        []HeaderOption
+	Payload            []byte
+}
+
+// Encode returns the wire encoding of m.
+func (m Message) Encode() []byte {
+	buf := make([]byte, 4, 4+12+len(m.Payload))
+	buf[0] = byte(m.Function)
+	binary.BigEndian.PutUint16(buf[2:], m.MessageID)
+
+	var control byte
+	if m.Origin != nil {
+		control |= controlOriginatingVMAC
+		buf = append(buf, m.Origin[:]...)
+	}
+	if m.Destination != nil {
+		control |= controlDestinationVMAC
+		buf = append(buf, m.Destination[:]...)
+	}
+	if len(m.DestinationOptions) > 0 {
+		control |= controlDestinationOptions
+		buf = appendOptions(buf, m.DestinationOptions)
+	}
+	if len(m.DataOptions) > 0 {
+		control |= controlDataOptions
+		buf = appendOptions(buf, m.DataOptions)
+	}
+	buf[1] = control
+	return append(buf, m.Payload...)
+}
+
+func appendOptions(buf []byte, options []HeaderOption) []byte {
+	for i, option := range options {
+		marker := option.Type & optionTypeMask
+		if i < len(options)-1 {
+			marker |= optionMoreFollows
+		}
+		if option.MustUnderstand {
+			marker |= optionMustUnderstand
+		}
+		if option.Data != nil {
+			marker |= optionHasData
+		}
+		buf = append(buf, marker)
+		if option.Data != nil {
+			buf = binary.BigEndian.AppendUint16(buf, uint16(len(option.Data)))
+			buf = append(buf, option.Data...)
+		}
+	}
+	return buf
+}
+
+// DecodeMessage decodes a BVLC-SC message. The payload aliases data.
+func DecodeMessage(data []byte) (Message, error) {
+	if len(data) < 4 {
+		return Message{}, ErrTruncated
+	}
+	m := Message{
+		Function:  Function(data[0]),
+		MessageID: binary.BigEndian.Uint16(data[2:]),
+	}
+	control := data[1]
+	rest := data[4:]
+
+	readVMAC := func() (*VMAC, error) {
+		if len(rest) < 6 {
+			return nil, ErrTruncated
+		}
+		var v VMAC
+		copy(v[:], rest)
+		rest = rest[6:]
+		return &v, nil
+	}
+
+	var err error
+	if control&controlOriginatingVMAC != 0 {
+		if m.Origin, err = readVMAC(); err != nil {
+			return Message{}, err
+		}
+	}
+	if control&controlDestinationVMAC != 0 {
+		if m.Destination, err = readVMAC(); err != nil {
+			return Message{}, err
+		}
+	}
+	if control&controlDestinationOptions != 0 {
+		if m.DestinationOptions, rest, err = decodeOptions(rest); err != nil {
+			return Message{}, err
+		}
+	}
+	if control&controlDataOptions != 0 {
+		if m.DataOptions, rest, err = decodeOptions(rest); err != nil {
+			return Message{}, err
+		}
+	}
+	m.Payload = rest
+	return m, nil
+}
+
+func decodeOptions(data []byte) ([]HeaderOption, []byte, error) {
+	var options []HeaderOption
+	for {
+		if len(data) < 1 {
+			return nil, nil, ErrTruncated
+		}
+		marker := data[0]
+		data = data[1:]
+		option := HeaderOption{Type: marker & optionTypeMask, MustUnderstand: marker&optionMustUnderstand != 0}
+		if marker&optionHasData != 0 {
+			if len(data) < 2 {
+				return nil, nil, ErrTruncated
+			}
+			length := int(binary.BigEndian.Uint16(data))
+			if len(data) < 2+length {
+				return nil, nil, ErrTruncated
+			}
+			option.Data = data[2 : 2+length]
+			data = data[2+length:]
+		}
+		options = append(options, option)
+		if marker&optionMoreFollows == 0 {
+			return options, data, nil
+		}
+	}
+}
+
+// ConnectInfo is the payload of Connect-Request and Connect-Accept messages.
+type ConnectInfo struct {
+	VMAC VMAC
+	// UUID is the device UUID of the node, which stays the same when the VMAC changes.
+	UUID          [16]byte
+	MaxBVLCLength uint16
+	MaxNPDULength uint16
+}
+
+func (c ConnectInfo) encode() []byte {
+	buf := make([]byte, 0, 26)
+	buf = append(buf, c.VMAC[:]...)
+	buf = append(buf, c.UUID[:]...)
+	buf = binary.BigEndian.AppendUint16(buf, c.MaxBVLCLength)
+	return binary.BigEndian.AppendUint16(buf, c.MaxNPDULength)
+}
+
+func decodeConnectInfo(payload []byte) (ConnectInfo, error) {
+	if len(payload) < 26 {
+		return ConnectInfo{}, ErrTruncated
+	}
+	var c ConnectInfo
+	copy(c.VMAC[:], payload)
+	copy(c.UUID[:], payload[6:])
+	c.MaxBVLCLength = binary.BigEndian.Uint16(payload[22:])
+	c.MaxNPDULength = binary.BigEndian.Uint16(payload[24:])
+	return c, nil
+}
+
+// ResultError is a NAK reported in a BVLC-Result message.
+type ResultError struct {
+	Function Function
+	Class    uint16
+	Code     uint16
+	Details  string
+}
+
+func (e *ResultError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("BVLC-SC function 0x%02x rejected: class %d, code %d: %s", byte(e.Function), e.Class, e.Code, e.Details)
+	}
+	return fmt.Sprintf("BVLC-SC function 0x%02x rejected: class %d, code %d", byte(e.Function), e.Class, e.Code)
+}
+
+// decodeResult decodes the payload of a BVLC-Result. It returns nil for an ACK and a *ResultError
+// for a NAK.
+func decodeResult(payload []byte) (Function, error) {
+	if len(payload) < 2 {
+		return 0, ErrTruncated
+	}
+	function := Function(payload[0])
+	if payload[1] == 0 {
+		return function, nil
+	}
+	if len(payload) < 7 {
+		return function, ErrTruncated
+	}
+	// payload[2] is the marker of the header option that caused the error, if any.
+	return function, &ResultError{
+		Function: function,
+		Class:    binary.BigEndian.Uint16(payload[3:]),
+		Code:     binary.BigEndian.Uint16(payload[5:]),
+		Details:  string(payload[7:]),
+	}
+}
@@ -0,0 +1,442 @@
+package sc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WebSocket subprotocols of hub and direct connections.
+const (
+	HubSubprotocol    = "hub.bsc.bacnet.org"
+	DirectSubprotocol = "dc.bsc.bacnet.org"
+)
+
+// ErrNotConnected is returned by Send when there is neither a hub nor a direct connection.
+var ErrNotConnected = errors.New("not connected to a hub")
+
+// MessageConn is a WebSocket connection carrying one BVLC-SC message per binary frame.
+type MessageConn interface {
+	ReadMessage(ctx context.Context) ([]byte, error)
+	WriteMessage(ctx context.Context, data []byte) error
+	Close() error
+}
+
+// Dialer opens a secured WebSocket connection to uri using the given subprotocol. The TLS
+// configuration would normally come from CertificateStore.TLSConfig.
+type Dialer func(ctx context.Context, uri, subprotocol string) (MessageConn, error)
+
+// NodeConfig configures a Node.
+type NodeConfig struct {
+	VMAC VMAC
+	UUID [16]byte
+	// PrimaryHub and FailoverHub are the WebSocket URIs of the hub function. FailoverHub is optional.
+	PrimaryHub  string
+	FailoverHub string
+	Dial        Dialer
+	// ReconnectDelay is the wait between connection attempts, and how often the primary hub is
+	// retried while connected to the failover hub. Defaults to 10 seconds.
+	ReconnectDelay time.Duration
+	// ConnectTimeout bounds the connect handshake. Defaults to 10 seconds.
+	ConnectTimeout time.Duration
+	// HeartbeatInterval is how often a Heartbeat-Request is sent on every connection. Defaults to
+	// 5 minutes.
+	HeartbeatInterval time.Duration
+	// MaxBVLCLength and MaxNPDULength are announced to peers. Default to 1600 and 1497.
+	MaxBVLCLength uint16
+	MaxNPDULength uint16
+	// DirectConnectURIs are the WebSocket URIs this node accepts direct connections on, returned
+	// to Address-Resolution requests. Empty if the node does not accept direct connections.
+	DirectConnectURIs []string
+}
+
+// NPDU is a network layer message received from another node.
+type NPDU struct {
+	Source VMAC
+	Data   []byte
+}
+
+// Node is a BACnet/SC node. It keeps a connection to the primary hub, switches to the failover hub
+// when the primary cannot be reached and back as soon as the primary is available again. Direct
+// connections to other nodes are used for unicast messages to those nodes.
+type Node struct {
+	config NodeConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	npdus  chan NPDU
+	errors chan error
+	nextID atomic.Uint32
+
+	mu      sync.Mutex
+	hub     *peerConn
+	hubURI  string
+	direct  map[VMAC]*peerConn
+	pending map[uint16]chan Message
+	wg      sync.WaitGroup
+}
+
+// peerConn is an established connection to a hub or another node.
+type peerConn struct {
+	conn    MessageConn
+	peer    ConnectInfo
+	writeMu sync.Mutex
+}
+
+func (p *peerConn) write(ctx context.Context, m Message) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	return p.conn.WriteMessage(ctx, m.Encode())
+}
+
+// NewNode starts a node. It connects to the hub in the background; all connections are closed when
+// ctx is cancelled or Close is called.
+func NewNode(ctx context.Context, config NodeConfig) (*Node, error) {
+	if config.Dial == nil {
+		return nil, fmt.Errorf("no dialer configured")
+	}
+	if config.PrimaryHub == "" {
+		return nil, fmt.Errorf("no primary hub configured")
+	}
+	if config.ReconnectDelay <= 0 {
+		config.ReconnectDelay = 10 * time.Second
+	}
+	if config.ConnectTimeout <= 0 {
+		config.ConnectTimeout = 10 * time.Second
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 5 * time.Minute
+	}
+	if config.MaxBVLCLength == 0 {
+		config.MaxBVLCLength = 1600
+	}
+	if config.MaxNPDULength == 0 {
+		config.MaxNPDULength = 1497
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	n := &Node{
+		config:  config,
+		ctx:     ctx,
+		cancel:  cancel,
+		npdus:   make(chan NPDU, 64),
+		errors:  make(chan error, 16),
+		direct:  make(map[VMAC]*peerConn),
+		pending: make(map[uint16]chan Message),
+	}
+	n.wg.Add(1)
+	go n.maintainHub()
+	return n, nil
+}
+
+// NPDUs returns the channel received network layer messages are delivered on.
+func (n *Node) NPDUs() <-chan NPDU {
+	return n.npdus
+}
+
+// Errors returns the channel connection errors are delivered on. Errors are dropped when the
+// channel is full.
+func (n *Node) Errors() <-chan error {
+	return n.errors
+}
+
+// ActiveHub returns the URI of the hub the node is connected to, or "" while it is not connected.
+func (n *Node) ActiveHub() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.hubURI
+}
+
+// Send sends an NPDU to another node, over a direct connection to it if there is one and through
+// the hub otherwise. Use BroadcastVMAC to broadcast.
+func (n *Node) Send(ctx context.Context, destination VMAC, npdu []byte) error {
+	n.mu.Lock()
+	pc, direct := n.direct[destination]
+	if !direct {
+		pc = n.hub
+	}
+	n.mu.Unlock()
+	if pc == nil {
+		return ErrNotConnected
+	}
+
+	m := Message{Function: FunctionEncapsulatedNPDU, MessageID: n.messageID(), Payload: npdu}
+	if !direct {
+		m.Destination = &destination
+	}
+	return pc.write(ctx, m)
+}
+
+// ConnectDirect opens a direct connection to the node at uri and returns its VMAC. The connection
+// stays open until the peer disconnects, DisconnectDirect is called or the node is closed.
+func (n *Node) ConnectDirect(ctx context.Context, uri string) (VMAC, error) {
+	pc, err := n.connect(ctx, uri, DirectSubprotocol)
+	if err != nil {
+		return VMAC{}, err
+	}
+
+	n.mu.Lock()
+	if old, ok := n.direct[pc.peer.VMAC]; ok {
+		old.conn.Close()
+	}
+	n.direct[pc.peer.VMAC] = pc
+	n.mu.Unlock()
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		err := n.serve(n.ctx, pc)
+		n.mu.Lock()
+		if n.direct[pc.peer.VMAC] == pc {
+			delete(n.direct, pc.peer.VMAC)
+		}
+		n.mu.Unlock()
+		pc.conn.Close()
+		if n.ctx.Err() == nil && !errors.Is(err, errDisconnected) {
+			n.reportError(fmt.Errorf("direct connection to %s lost: %w", uri, err))
+		}
+	}()
+	return pc.peer.VMAC, nil
+}
+
+// DisconnectDirect closes the direct connection to a node, if there is one.
+func (n *Node) DisconnectDirect(ctx context.Context, peer VMAC) error {
+	n.mu.Lock()
+	pc, ok := n.direct[peer]
+	delete(n.direct, peer)
+	n.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	err := pc.write(ctx, Message{Function: FunctionDisconnectRequest, MessageID: n.messageID()})
+	pc.conn.Close()
+	return err
+}
+
+// Close disconnects from the hub and all peers.
+func (n *Node) Close() error {
+	n.cancel()
+	n.mu.Lock()
+	for _, pc := range n.direct {
+		pc.conn.Close()
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+	return nil
+}
+
+// maintainHub keeps a hub connection until the node is closed.
+func (n *Node) maintainHub() {
+	defer n.wg.Done()
+	var pc *peerConn
+	var uri string
+	for {
+		if pc == nil {
+			var err error
+			if pc, uri, err = n.connectHub(); err != nil {
+				n.reportError(err)
+				select {
+				case <-n.ctx.Done():
+					return
+				case <-time.After(n.config.ReconnectDelay):
+				}
+				continue
+			}
+		}
+		pc, uri = n.serveHub(pc, uri)
+		if n.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// connectHub connects to the primary hub, or to the failover hub if the primary is unreachable.
+func (n *Node) connectHub() (*peerConn, string, error) {
+	pc, err := n.connect(n.ctx, n.config.PrimaryHub, HubSubprotocol)
+	if err == nil {
+		return pc, n.config.PrimaryHub, nil
+	}
+	if n.config.FailoverHub == "" {
+		return nil, "", fmt.Errorf("failed to connect to primary hub: %w", err)
+	}
+	pc, failoverErr := n.connect(n.ctx, n.config.FailoverHub, HubSubprotocol)
+	if failoverErr != nil {
+		return nil, "", fmt.Errorf("failed to connect to primary hub (%v) and failover hub: %w", err, failoverErr)
+	}
+	return pc, n.config.FailoverHub, nil
+}
+
+// serveHub serves a hub connection until it is lost, or until the primary hub becomes reachable
+// while connected to the failover hub. It returns the primary hub connection in the latter case.
+func (n *Node) serveHub(pc *peerConn, uri string) (*peerConn, string) {
+	n.setHub(pc, uri)
+	ctx, cancel := context.WithCancel(n.ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- n.serve(ctx, pc) }()
+
+	var retry <-chan time.Time
+	if uri != n.config.PrimaryHub {
+		ticker := time.NewTicker(n.config.ReconnectDelay)
+		defer ticker.Stop()
+		retry = ticker.C
+	}
+
+	for {
+		select {
+		case err := <-done:
+			n.setHub(nil, "")
+			pc.conn.Close()
+			if n.ctx.Err() == nil {
+				n.reportError(fmt.Errorf("hub connection to %s lost: %w", uri, err))
+			}
+			return nil, ""
+		case <-retry:
+			primary, err := n.connect(n.ctx, n.config.PrimaryHub, HubSubprotocol)
+			if err != nil {
+				continue
+			}
+			// Switch back to the primary hub before dropping the failover connection.
+			n.setHub(primary, n.config.PrimaryHub)
+			pc.write(ctx, Message{Function: FunctionDisconnectRequest, MessageID: n.messageID()})
+			cancel()
+			pc.conn.Close()
+			<-done
+			return primary, n.config.PrimaryHub
+		}
+	}
+}
+
+func (n *Node) setHub(pc *peerConn, uri string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.hub, n.hubURI = pc, uri
+}
+
+// connect opens a connection and performs the Connect-Request/Connect-Accept handshake.
+func (n *Node) connect(ctx context.Context, uri, subprotocol string) (*peerConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.config.ConnectTimeout)
+	defer cancel()
+
+	conn, err := n.config.Dial(ctx, uri, subprotocol)
+	if err != nil {
+		return nil, err
+	}
+	pc := &peerConn{conn: conn}
+
+	request := ConnectInfo{
+		VMAC:          n.config.VMAC,
+		UUID:          n.config.UUID,
+		MaxBVLCLength: n.config.MaxBVLCLength,
+		MaxNPDULength: n.config.MaxNPDULength,
+	}
+	id := n.messageID()
+	if err := pc.write(ctx, Message{Function: FunctionConnectRequest, MessageID: id, Payload: request.encode()}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send Connect-Request: %w", err)
+	}
+
+	for {
+		data, err := conn.ReadMessage(ctx)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("no Connect-Accept from %s: %w", uri, err)
+		}
+		m, err := DecodeMessage(data)
+		if err != nil || m.MessageID != id {
+			continue
+		}
+		switch m.Function {
+		case FunctionConnectAccept:
+			if pc.peer, err = decodeConnectInfo(m.Payload); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("invalid Connect-Accept from %s: %w", uri, err)
+			}
+			return pc, nil
+		case FunctionResult:
+			_, err := decodeResult(m.Payload)
+			if err == nil {
+				err = fmt.Errorf("unexpected BVLC-Result ACK")
+			}
+			conn.Close()
+			return nil, fmt.Errorf("connect to %s failed: %w", uri, err)
+		}
+	}
+}
+
+// errDisconnected ends serve when the peer asks to disconnect.
+var errDisconnected = errors.New("disconnected by peer")
+
+// serve reads messages from a connection until it fails or ctx is cancelled, and sends heartbeats.
+func (n *Node) serve(ctx context.Context, pc *peerConn) error {
+	go n.heartbeat(ctx, pc)
+	for {
+		data, err := pc.conn.ReadMessage(ctx)
+		if err != nil {
+			return err
+		}
+		m, err := DecodeMessage(data)
+		if err != nil {
+			continue // Malformed message
+		}
+		if err := n.handle(ctx, pc, m); err != nil {
+			return err
+		}
+	}
+}
+
+// handle processes a message received on an established connection.
+func (n *Node) handle(ctx context.Context, pc *peerConn, m Message) error {
+	switch m.Function {
+	case FunctionEncapsulatedNPDU:
+		npdu := NPDU{Source: pc.peer.VMAC, Data: m.Payload}
+		if m.Origin != nil {
+			npdu.Source = *m.Origin
+		}
+		select {
+		case n.npdus <- npdu:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case FunctionHeartbeatRequest:
+		return pc.write(ctx, Message{Function: FunctionHeartbeatACK, MessageID: m.MessageID})
+	case FunctionDisconnectRequest:
+		pc.write(ctx, Message{Function: FunctionDisconnectACK, MessageID: m.MessageID})
+		return errDisconnected
+	case FunctionResult, FunctionAddressResolution, FunctionAddressResolutionACK,
+		FunctionAdvertisement, FunctionAdvertisementSolicitation:
+		return n.handleResolution(ctx, pc, m)
+	}
+	return nil
+}
+
+// heartbeat sends a Heartbeat-Request every HeartbeatInterval and closes the connection when that
+// fails.
+func (n *Node) heartbeat(ctx context.Context, pc *peerConn) {
+	ticker := time.NewTicker(n.config.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := pc.write(ctx, Message{Function: FunctionHeartbeatRequest, MessageID: n.messageID()}); err != nil {
+				pc.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (n *Node) messageID() uint16 {
+	return uint16(n.nextID.Add(1))
+}
+
+func (n *Node) reportError(err error) {
+	select {
+	case n.errors <- err:
+	default:
+	}
+}
@@ -0,0 +1,222 @@
+package sc
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// HubConnectionStatus is the hub connection state a node reports in its Advertisement.
+type HubConnectionStatus byte
+
+const (
+	HubNotConnected      HubConnectionStatus = 0
+	HubConnectedPrimary  HubConnectionStatus = 1
+	HubConnectedFailover HubConnectionStatus = 2
+)
+
+// Advertisement is the payload of an Advertisement message: what a node supports and how it is
+// connected.
+type Advertisement struct {
+	HubStatus                HubConnectionStatus
+	AcceptsDirectConnections bool
+	MaxBVLCLength            uint16
+	MaxNPDULength            uint16
+}
+
+func (a Advertisement) encode() []byte {
+	buf := []byte{byte(a.HubStatus), 0}
+	if a.AcceptsDirectConnections {
+		buf[1] = 1
+	}
+	buf = binary.BigEndian.AppendUint16(buf, a.MaxBVLCLength)
+	return binary.BigEndian.AppendUint16(buf, a.MaxNPDULength)
+}
+
+func decodeAdvertisement(payload []byte) (Advertisement, error) {
+	if len(payload) < 6 {
+		return Advertisement{}, ErrTruncated
+	}
+	return Advertisement{
+		HubStatus:                HubConnectionStatus(payload[0]),
+		AcceptsDirectConnections: payload[1] == 1,
+		MaxBVLCLength:            binary.BigEndian.Uint16(payload[2:]),
+		MaxNPDULength:            binary.BigEndian.Uint16(payload[4:]),
+	}, nil
+}
+
+// Error class and code of the NAK sent for Address-Resolution when the node does not accept
+// direct connections.
+const (
+	errorClassCommunication                    = 7
+	errorCodeOptionalFunctionalityNotSupported = 45
+)
+
+// resultNAK returns the payload of a BVLC-Result NAK for function.
+func resultNAK(function Function, class, code uint16) []byte {
+	buf := []byte{byte(function), 1, 0}
+	buf = binary.BigEndian.AppendUint16(buf, class)
+	return binary.BigEndian.AppendUint16(buf, code)
+}
+
+// Resolve asks the node with the given VMAC, through the hub, for the WebSocket URIs it accepts
+// direct connections on.
+func (n *Node) Resolve(ctx context.Context, peer VMAC) ([]string, error) {
+	reply, err := n.request(ctx, peer, FunctionAddressResolution, nil)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Function != FunctionAddressResolutionACK {
+		return nil, fmt.Errorf("unexpected reply 0x%02x to Address-Resolution", byte(reply.Function))
+	}
+	return strings.Fields(string(reply.Payload)), nil
+}
+
+// Solicit asks the node with the given VMAC for its Advertisement.
+func (n *Node) Solicit(ctx context.Context, peer VMAC) (Advertisement, error) {
+	reply, err := n.request(ctx, peer, FunctionAdvertisementSolicitation, nil)
+	if err != nil {
+		return Advertisement{}, err
+	}
+	if reply.Function != FunctionAdvertisement {
+		return Advertisement{}, fmt.Errorf("unexpected reply 0x%02x to Advertisement-Solicitation", byte(reply.Function))
+	}
+	return decodeAdvertisement(reply.Payload)
+}
+
+// Advertise sends the node's Advertisement to another node, or to all nodes with BroadcastVMAC.
+func (n *Node) Advertise(ctx context.Context, destination VMAC) error {
+	n.mu.Lock()
+	hub := n.hub
+	n.mu.Unlock()
+	if hub == nil {
+		return ErrNotConnected
+	}
+	return hub.write(ctx, Message{
+		Function:    FunctionAdvertisement,
+		MessageID:   n.messageID(),
+		Destination: &destination,
+		Payload:     n.advertisement().encode(),
+	})
+}
+
+// ConnectDirectTo resolves the URIs of a node and opens a direct connection to the first one that
+// accepts it.
+func (n *Node) ConnectDirectTo(ctx context.Context, peer VMAC) error {
+	uris, err := n.Resolve(ctx, peer)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", peer, err)
+	}
+	if len(uris) == 0 {
+		return fmt.Errorf("%s does not accept direct connections", peer)
+	}
+
+	var lastErr error
+	for _, uri := range uris {
+		vmac, err := n.ConnectDirect(ctx, uri)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if vmac != peer {
+			n.DisconnectDirect(ctx, vmac)
+			lastErr = fmt.Errorf("%s is %s, not %s", uri, vmac, peer)
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// advertisement returns the node's current Advertisement.
+func (n *Node) advertisement() Advertisement {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a := Advertisement{
+		AcceptsDirectConnections: len(n.config.DirectConnectURIs) > 0,
+		MaxBVLCLength:            n.config.MaxBVLCLength,
+		MaxNPDULength:            n.config.MaxNPDULength,
+	}
+	switch {
+	case n.hub == nil:
+		a.HubStatus = HubNotConnected
+	case n.hubURI == n.config.PrimaryHub:
+		a.HubStatus = HubConnectedPrimary
+	default:
+		a.HubStatus = HubConnectedFailover
+	}
+	return a
+}
+
+// request sends a message to another node through the hub and waits for the reply with the same
+// message ID.
+func (n *Node) request(ctx context.Context, peer VMAC, function Function, payload []byte) (Message, error) {
+	n.mu.Lock()
+	hub := n.hub
+	n.mu.Unlock()
+	if hub == nil {
+		return Message{}, ErrNotConnected
+	}
+
+	id := n.messageID()
+	reply := make(chan Message, 1)
+	n.mu.Lock()
+	n.pending[id] = reply
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.pending, id)
+		n.mu.Unlock()
+	}()
+
+	if err := hub.write(ctx, Message{Function: function, MessageID: id, Destination: &peer, Payload: payload}); err != nil {
+		return Message{}, err
+	}
+	select {
+	case m := <-reply:
+		if m.Function == FunctionResult {
+			if _, err := decodeResult(m.Payload); err != nil {
+				return Message{}, err
+			}
+			return Message{}, fmt.Errorf("unexpected BVLC-Result ACK")
+		}
+		return m, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+// handleResolution answers Address-Resolution and Advertisement-Solicitation messages and hands
+// replies to waiting requests.
+func (n *Node) handleResolution(ctx context.Context, pc *peerConn, m Message) error {
+	switch m.Function {
+	case FunctionAddressResolution:
+		reply := Message{Function: FunctionAddressResolutionACK, MessageID: m.MessageID, Destination: m.Origin}
+		if len(n.config.DirectConnectURIs) > 0 {
+			reply.Payload = []byte(strings.Join(n.config.DirectConnectURIs, " "))
+		} else {
+			reply.Function = FunctionResult
+			reply.Payload = resultNAK(FunctionAddressResolution, errorClassCommunication, errorCodeOptionalFunctionalityNotSupported)
+		}
+		return pc.write(ctx, reply)
+	case FunctionAdvertisementSolicitation:
+		return pc.write(ctx, Message{
+			Function:    FunctionAdvertisement,
+			MessageID:   m.MessageID,
+			Destination: m.Origin,
+			Payload:     n.advertisement().encode(),
+		})
+	}
+
+	n.mu.Lock()
+	reply, ok := n.pending[m.MessageID]
+	n.mu.Unlock()
+	if ok {
+		select {
+		case reply <- m:
+		default:
+		}
+	}
+	return nil
+}
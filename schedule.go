@@ -0,0 +1,333 @@
+package bacnet
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// TimeValue is one entry of a daily schedule: from Time on, the schedule commands Value. A nil
+// Value (NULL) relinquishes the command.
+type TimeValue struct {
+	Time  Time        `json:"time"`
+	Value interface{} `json:"value"`
+}
+
+// WeekNDay matches days by month, week of month and weekday; any field may be Unspecified.
+// Month 13 and 14 match odd and even months. WeekOfMonth 1 to 5 match days 1-7, 8-14, 15-21,
+// 22-28 and 29-31; 6 to 9 match the last, second to last, third to last and fourth to last seven
+// days of the month. DayOfWeek runs from 1 (Monday) to 7 (Sunday).
+type WeekNDay struct {
+	Month       uint8 `json:"month"`
+	WeekOfMonth uint8 `json:"week_of_month"`
+	DayOfWeek   uint8 `json:"day_of_week"`
+}
+
+// DateRange is an inclusive range of dates. A Start or End with an unspecified year, month or day
+// leaves the range open on that side.
+type DateRange struct {
+	Start Date `json:"start"`
+	End   Date `json:"end"`
+}
+
+// CalendarEntry is one entry of a Calendar's Date_List or of a special event period; exactly one
+// of Date, Range and WeekNDay is set.
+type CalendarEntry struct {
+	Date     *Date      `json:"date,omitempty"`
+	Range    *DateRange `json:"range,omitempty"`
+	WeekNDay *WeekNDay  `json:"week_n_day,omitempty"`
+}
+
+// SpecialEvent is one entry of an Exception_Schedule. Its period is either an inline calendar
+// entry or a reference to a Calendar object; Priority runs from 1 (highest) to 16.
+type SpecialEvent struct {
+	Entry      *CalendarEntry `json:"entry,omitempty"`
+	Calendar   *BACnetObject  `json:"calendar,omitempty"`
+	TimeValues []TimeValue    `json:"time_values"`
+	Priority   uint8          `json:"priority"`
+}
+
+// Schedule holds the decoded properties of a Schedule object needed to evaluate it.
+type Schedule struct {
+	// EffectivePeriod limits the dates the schedule is in effect; nil means always.
+	EffectivePeriod *DateRange `json:"effective_period,omitempty"`
+	// Weekly is the Weekly_Schedule, starting with Monday.
+	Weekly     [7][]TimeValue `json:"weekly"`
+	Exceptions []SpecialEvent `json:"exceptions,omitempty"`
+	Default    interface{}    `json:"default"`
+}
+
+// ValueAt returns the value the schedule commands at t, following the evaluation of the standard:
+// among the special events in effect on the day of t, the one with the highest priority whose
+// latest time value at or before t is not NULL wins, earlier events in the list winning ties.
+// Without such an event the latest time value of the weekly schedule applies, and if that is NULL
+// or there is none, the schedule default. Outside the effective period the schedule default is
+// returned as well. calendars holds the Date_List of every Calendar referenced by a special event.
+func (s Schedule) ValueAt(t time.Time, calendars map[BACnetObject][]CalendarEntry) (interface{}, error) {
+	if s.EffectivePeriod != nil && !s.EffectivePeriod.Contains(t) {
+		return s.Default, nil
+	}
+
+	var (
+		value    interface{}
+		priority uint8
+		found    bool
+	)
+	for i, event := range s.Exceptions {
+		active, err := event.activeOn(t, calendars)
+		if err != nil {
+			return nil, fmt.Errorf("exception schedule entry %d: %w", i, err)
+		}
+		if !active || (found && event.Priority >= priority) {
+			continue
+		}
+		if v, ok := timeValueAt(event.TimeValues, t); ok && v != nil {
+			value, priority, found = v, event.Priority, true
+		}
+	}
+	if found {
+		return value, nil
+	}
+
+	if v, ok := timeValueAt(s.Weekly[weekdayIndex(t)], t); ok && v != nil {
+		return v, nil
+	}
+	return s.Default, nil
+}
+
+// activeOn reports whether the period of the event includes the day of t.
+func (e SpecialEvent) activeOn(t time.Time, calendars map[BACnetObject][]CalendarEntry) (bool, error) {
+	switch {
+	case e.Entry != nil:
+		return e.Entry.Matches(t), nil
+	case e.Calendar != nil:
+		entries, ok := calendars[*e.Calendar]
+		if !ok {
+			return false, fmt.Errorf("calendar %s not provided", *e.Calendar)
+		}
+		return CalendarActive(entries, t), nil
+	default:
+		return false, fmt.Errorf("special event has no period")
+	}
+}
+
+// timeValueAt returns the value of the latest entry at or before the time of day of t; ok is false
+// if t is before every entry. Entries need not be sorted.
+func timeValueAt(values []TimeValue, t time.Time) (value interface{}, ok bool) {
+	now := timeOfDay(NewTime(t))
+	latest := -1
+	for _, tv := range values {
+		at := timeOfDay(tv.Time)
+		if at <= now && at >= latest {
+			value, latest, ok = tv.Value, at, true
+		}
+	}
+	return value, ok
+}
+
+// timeOfDay returns a Time in hundredths of a second since midnight, counting unspecified fields
+// as zero.
+func timeOfDay(t Time) int {
+	field := func(v uint8) int {
+		if v == Unspecified {
+			return 0
+		}
+		return int(v)
+	}
+	return ((field(t.Hour)*60+field(t.Minute))*60+field(t.Second))*100 + field(t.Hundredths)
+}
+
+// weekdayIndex returns the index of the day of t in a weekly schedule, 0 being Monday.
+func weekdayIndex(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
+
+// CalendarActive reports whether any of the entries matches the day of t, i.e. whether a Calendar
+// with this Date_List is active.
+func CalendarActive(entries []CalendarEntry, t time.Time) bool {
+	for _, entry := range entries {
+		if entry.Matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the entry includes the day of t.
+func (e CalendarEntry) Matches(t time.Time) bool {
+	switch {
+	case e.Date != nil:
+		return e.Date.Matches(t)
+	case e.Range != nil:
+		return e.Range.Contains(t)
+	case e.WeekNDay != nil:
+		return e.WeekNDay.Matches(t)
+	}
+	return false
+}
+
+// Matches reports whether the day of t matches the date, which may contain wildcards. Besides
+// Unspecified, Month accepts 13 and 14 for odd and even months and Day accepts 32 for the last day
+// of the month and 33 and 34 for odd and even days.
+func (d Date) Matches(t time.Time) bool {
+	today := NewDate(t)
+	if d.Year != Unspecified && d.Year != today.Year {
+		return false
+	}
+	if !matchMonth(d.Month, today.Month) {
+		return false
+	}
+	switch d.Day {
+	case Unspecified:
+	case 32:
+		if int(today.Day) != daysIn(t) {
+			return false
+		}
+	case 33, 34:
+		if today.Day%2 != d.Day%2 {
+			return false
+		}
+	default:
+		if d.Day != today.Day {
+			return false
+		}
+	}
+	return d.Weekday == Unspecified || d.Weekday == today.Weekday
+}
+
+// Contains reports whether the day of t lies within the range.
+func (r DateRange) Contains(t time.Time) bool {
+	day := dateOrdinal(NewDate(t))
+	if start, ok := specificDate(r.Start); ok && day < start {
+		return false
+	}
+	if end, ok := specificDate(r.End); ok && day > end {
+		return false
+	}
+	return true
+}
+
+// Matches reports whether the day of t matches the pattern.
+func (w WeekNDay) Matches(t time.Time) bool {
+	today := NewDate(t)
+	if !matchMonth(w.Month, today.Month) {
+		return false
+	}
+	if w.DayOfWeek != Unspecified && w.DayOfWeek != today.Weekday {
+		return false
+	}
+	day := int(today.Day)
+	switch week := int(w.WeekOfMonth); {
+	case w.WeekOfMonth == Unspecified:
+		return true
+	case week >= 1 && week <= 5:
+		return (day-1)/7+1 == week
+	case week >= 6 && week <= 9:
+		// Counted from the end of the month: 6 is the last seven days, 7 the seven before, ...
+		fromEnd := daysIn(t) - day
+		return fromEnd/7 == week-6
+	}
+	return false
+}
+
+func matchMonth(pattern, month uint8) bool {
+	switch pattern {
+	case Unspecified:
+		return true
+	case 13:
+		return month%2 == 1
+	case 14:
+		return month%2 == 0
+	}
+	return pattern == month
+}
+
+// daysIn returns the number of days in the month of t.
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// dateOrdinal orders dates by year, month and day.
+func dateOrdinal(d Date) int {
+	return (int(d.Year)*12+int(d.Month))*32 + int(d.Day)
+}
+
+// specificDate returns the ordinal of a date without wildcards in year, month and day.
+func specificDate(d Date) (int, bool) {
+	if d.Year == Unspecified || d.Month == Unspecified || d.Day == Unspecified {
+		return 0, false
+	}
+	return dateOrdinal(d), true
+}
+
+// encode writes the time value as an application-tagged Time and value.
+func (tv TimeValue) encode(buf *bytes.Buffer) error {
+	encoding.EncodeApplicationTime(buf, tv.Time.Hour, tv.Time.Minute, tv.Time.Second, tv.Time.Hundredths)
+	return encodeApplicationValue(buf, tv.Value)
+}
+
+// dailySchedule is an element of a Weekly_Schedule array.
+type dailySchedule []TimeValue
+
+// encode writes a BACnetDailySchedule.
+func (d dailySchedule) encode(buf *bytes.Buffer) error {
+	encoding.EncodeOpeningTag(buf, 0)
+	for _, tv := range d {
+		if err := tv.encode(buf); err != nil {
+			return err
+		}
+	}
+	encoding.EncodeClosingTag(buf, 0)
+	return nil
+}
+
+// encode writes the range as two application-tagged Dates.
+func (r DateRange) encode(buf *bytes.Buffer) {
+	encoding.EncodeApplicationDate(buf, r.Start.Year, r.Start.Month, r.Start.Day, r.Start.Weekday)
+	encoding.EncodeApplicationDate(buf, r.End.Year, r.End.Month, r.End.Day, r.End.Weekday)
+}
+
+// encode writes a BACnetCalendarEntry.
+func (e CalendarEntry) encode(buf *bytes.Buffer) error {
+	switch {
+	case e.Date != nil:
+		encoding.EncodeTag(buf, 0, true, 4)
+		buf.Write([]byte{e.Date.Year, e.Date.Month, e.Date.Day, e.Date.Weekday})
+	case e.Range != nil:
+		encoding.EncodeOpeningTag(buf, 1)
+		e.Range.encode(buf)
+		encoding.EncodeClosingTag(buf, 1)
+	case e.WeekNDay != nil:
+		encoding.EncodeContextOctetString(buf, 2, []byte{e.WeekNDay.Month, e.WeekNDay.WeekOfMonth, e.WeekNDay.DayOfWeek})
+	default:
+		return fmt.Errorf("calendar entry has no date, range or week-n-day")
+	}
+	return nil
+}
+
+// encode writes a BACnetSpecialEvent.
+func (e SpecialEvent) encode(buf *bytes.Buffer) error {
+	switch {
+	case e.Entry != nil:
+		encoding.EncodeOpeningTag(buf, 0)
+		if err := e.Entry.encode(buf); err != nil {
+			return err
+		}
+		encoding.EncodeClosingTag(buf, 0)
+	case e.Calendar != nil:
+		encoding.EncodeContextObjectID(buf, 1, uint32(e.Calendar.Type), e.Calendar.Instance)
+	default:
+		return fmt.Errorf("special event has no period")
+	}
+	encoding.EncodeOpeningTag(buf, 2)
+	for _, tv := range e.TimeValues {
+		if err := tv.encode(buf); err != nil {
+			return err
+		}
+	}
+	encoding.EncodeClosingTag(buf, 2)
+	encoding.EncodeContextUnsigned(buf, 3, uint32(e.Priority))
+	return nil
+}
@@ -0,0 +1,802 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// Error classes and codes returned by the server.
+const (
+	errorClassObject   = 1
+	errorClassProperty = 2
+
+	errorCodeOther             = 0
+	errorCodeInvalidDataType   = 9
+	errorCodeUnknownObject     = 31
+	errorCodeUnknownProperty   = 32
+	errorCodeValueOutOfRange   = 37
+	errorCodeWriteAccessDenied = 40
+	errorCodeInvalidArrayIndex = 42
+)
+
+// serverMaxAPDU is the Max_APDU_Length_Accepted of virtual devices.
+const serverMaxAPDU = 1476
+
+// VirtualObject is an object served by a VirtualDevice.
+type VirtualObject struct {
+	Object BACnetObject
+	Name   string
+	// Properties holds the values of further properties, e.g. Units or Description.
+	Properties map[uint32]interface{}
+	// PresentValue, if set, is called on every read of Present_Value.
+	PresentValue func() (interface{}, error)
+	// Dynamic, if set, returns properties whose values change, e.g. the Record_Count of a trend
+	// log. It is called on every read and overrides Properties.
+	Dynamic func() map[uint32]interface{}
+	// Write, if set, is called for WriteProperty requests the device does not handle itself (see
+	// Commandable); priority is 0 when the request has none. It may return a *PropertyAccessError
+	// to choose the error returned to the client. Objects without Write reject these writes.
+	Write func(propertyID uint32, value interface{}, priority uint8) error
+
+	// Commandable gives an Analog, Binary or Multi-state Value a 16-level priority array; output
+	// objects always have one. The device then resolves Present_Value from Priority_Array and
+	// RelinquishDefault, which defaults to 0.0, inactive or state 1, and PresentValue is not used.
+	// Out_Of_Service is writable for every object; while it is set, Present_Value writes to
+	// objects without a priority array, including inputs, override the value read.
+	Commandable       bool
+	RelinquishDefault interface{}
+	// OnCommand, if set, is called when a write changed the value commanding the object. It is
+	// not called while the object is out of service; taking it back into service reports the
+	// current command.
+	OnCommand func(CommandChange)
+
+	state commandState
+}
+
+// VirtualDevice is a BACnet device made of VirtualObjects, served on the network by a Server. It
+// is safe for concurrent use.
+type VirtualDevice struct {
+	DeviceID uint32
+	Name     string
+	VendorID uint32
+
+	// Password, if set, must accompany DeviceCommunicationControl and ReinitializeDevice requests.
+	Password string
+	// OnCommunicationControl and OnReinitialize, if set, are called for DeviceCommunicationControl
+	// and ReinitializeDevice requests with a valid password before they are acknowledged, and may
+	// return a *PropertyAccessError to refuse them. ReinitializeDevice is refused without
+	// OnReinitialize; an application restarting itself should do so after the call returned.
+	OnCommunicationControl func(CommunicationControl) error
+	OnReinitialize         func(ReinitializeState) error
+	// OnTextMessage, if set, receives the text messages sent to the device. For a
+	// ConfirmedTextMessage it may return a *PropertyAccessError to refuse the message.
+	OnTextMessage func(TextMessage) error
+
+	mu      sync.RWMutex
+	objects map[BACnetObject]*VirtualObject
+	// watchers are called after successful writes, outside of mu
+	watchers []func(BACnetObject)
+}
+
+// NewVirtualDevice returns a device without objects.
+func NewVirtualDevice(deviceID uint32, name string) *VirtualDevice {
+	return &VirtualDevice{DeviceID: deviceID, Name: name, objects: make(map[BACnetObject]*VirtualObject)}
+}
+
+// AddObject adds an object, replacing an existing object with the same identifier.
+func (d *VirtualDevice) AddObject(object *VirtualObject) error {
+	if object.Object.Type == OBJECT_DEVICE {
+		return fmt.Errorf("cannot add a device object to a virtual device")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	object.initState()
+	d.objects[object.Object] = object
+	return nil
+}
+
+// RemoveObject removes an object.
+func (d *VirtualDevice) RemoveObject(object BACnetObject) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.objects, object)
+}
+
+// watch registers fn to be called with the object after every successful write to the device.
+func (d *VirtualDevice) watch(fn func(BACnetObject)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.watchers = append(d.watchers, fn)
+}
+
+// changed calls the watchers of the device for object.
+func (d *VirtualDevice) changed(object BACnetObject) {
+	d.mu.RLock()
+	watchers := d.watchers
+	d.mu.RUnlock()
+	for _, fn := range watchers {
+		fn(object)
+	}
+}
+
+// deviceObject returns the identifier of the Device object.
+func (d *VirtualDevice) deviceObject() BACnetObject {
+	return BACnetObject{Type: OBJECT_DEVICE, Instance: d.DeviceID}
+}
+
+// objectName returns the Object_Name of an object of the device.
+func (d *VirtualDevice) objectName(object BACnetObject) (string, bool) {
+	if object == d.deviceObject() {
+		return d.Name, true
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	vo, ok := d.objects[object]
+	if !ok {
+		return "", false
+	}
+	return vo.Name, true
+}
+
+// findObject returns the object of the device with the given Object_Name.
+func (d *VirtualDevice) findObject(name string) (BACnetObject, bool) {
+	if name == d.Name {
+		return d.deviceObject(), true
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for object, vo := range d.objects {
+		if vo.Name == name {
+			return object, true
+		}
+	}
+	return BACnetObject{}, false
+}
+
+// objectList returns the identifiers of all objects, the Device object first.
+func (d *VirtualDevice) objectList() []interface{} {
+	d.mu.RLock()
+	objects := make([]BACnetObject, 0, len(d.objects))
+	for object := range d.objects {
+		objects = append(objects, object)
+	}
+	d.mu.RUnlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Type != objects[j].Type {
+			return objects[i].Type < objects[j].Type
+		}
+		return objects[i].Instance < objects[j].Instance
+	})
+	list := make([]interface{}, 0, len(objects)+1)
+	list = append(list, d.deviceObject())
+	for _, object := range objects {
+		list = append(list, object)
+	}
+	return list
+}
+
+// properties returns the values of all properties of an object, or nil if it does not exist.
+// Present_Value is only included if withPresentValue is set, since reading it may be expensive.
+func (d *VirtualDevice) properties(object BACnetObject, withPresentValue bool) (map[uint32]interface{}, error) {
+	if object == d.deviceObject() {
+		return map[uint32]interface{}{
+			PROP_OBJECT_IDENTIFIER:        object,
+			PROP_OBJECT_NAME:              d.Name,
+			PROP_OBJECT_TYPE:              Enumerated(OBJECT_DEVICE),
+			PROP_OBJECT_LIST:              d.objectList(),
+			PROP_SYSTEM_STATUS:            Enumerated(0), // Operational
+			PROP_VENDOR_IDENTIFIER:        d.VendorID,
+			PROP_MAX_APDU_LENGTH_ACCEPTED: uint32(serverMaxAPDU),
+			PROP_SEGMENTATION_SUPPORTED:   Enumerated(3), // No segmentation
+		}, nil
+	}
+
+	d.mu.RLock()
+	vo, ok := d.objects[object]
+	var state commandState
+	if ok {
+		state = vo.state
+	}
+	d.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+
+	props := map[uint32]interface{}{
+		PROP_OBJECT_IDENTIFIER: object,
+		PROP_OBJECT_NAME:       vo.Name,
+		PROP_OBJECT_TYPE:       Enumerated(object.Type),
+		PROP_EVENT_STATE:       Enumerated(EventStateNormal),
+	}
+	for id, value := range vo.Properties {
+		props[id] = value
+	}
+	if vo.Dynamic != nil {
+		for id, value := range vo.Dynamic() {
+			props[id] = value
+		}
+	}
+	props[PROP_OUT_OF_SERVICE] = state.outOfService
+	flags, _ := props[PROP_STATUS_FLAGS].(StatusFlags)
+	flags.OutOfService = state.outOfService
+	props[PROP_STATUS_FLAGS] = flags
+	switch {
+	case vo.commandable():
+		priorityArray := make([]interface{}, len(state.priorityArray))
+		copy(priorityArray, state.priorityArray[:])
+		props[PROP_PRIORITY_ARRAY] = priorityArray
+		props[PROP_RELINQUISH_DEFAULT] = state.relinquishDefault
+		_, props[PROP_PRESENT_VALUE] = state.commanded()
+	case state.override != nil:
+		props[PROP_PRESENT_VALUE] = state.override
+	case vo.PresentValue != nil:
+		if !withPresentValue {
+			props[PROP_PRESENT_VALUE] = nil
+		} else {
+			value, err := vo.PresentValue()
+			if err != nil {
+				return nil, err
+			}
+			props[PROP_PRESENT_VALUE] = value
+		}
+	}
+	return props, nil
+}
+
+// readProperty returns the value of a property, or a *PropertyAccessError.
+func (d *VirtualDevice) readProperty(object BACnetObject, propertyID uint32, arrayIndex *uint32) (interface{}, error) {
+	props, err := d.properties(object, propertyID == PROP_PRESENT_VALUE)
+	if err != nil {
+		return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeOther}
+	}
+	if props == nil {
+		return nil, &PropertyAccessError{Class: errorClassObject, Code: errorCodeUnknownObject}
+	}
+	value, ok := props[propertyID]
+	if !ok {
+		return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeUnknownProperty}
+	}
+	if _, ok := value.(logBuffer); ok {
+		return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeReadAccessDenied}
+	}
+	if arrayIndex == nil {
+		return value, nil
+	}
+
+	elements, ok := value.([]interface{})
+	switch {
+	case !ok:
+		return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeInvalidArrayIndex}
+	case *arrayIndex == 0:
+		return uint32(len(elements)), nil
+	case int(*arrayIndex) > len(elements):
+		return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeInvalidArrayIndex}
+	}
+	return elements[*arrayIndex-1], nil
+}
+
+// logBuffer returns the value of a Log_Buffer property, which only ReadRange can read.
+func (d *VirtualDevice) logBuffer(object BACnetObject, propertyID uint32) (logBuffer, bool) {
+	props, err := d.properties(object, false)
+	if err != nil {
+		return logBuffer{}, false
+	}
+	buffer, ok := props[propertyID].(logBuffer)
+	return buffer, ok
+}
+
+// writeProperty performs a write the device handles itself (see VirtualObject.Commandable) or
+// hands it to the object's Write function.
+func (d *VirtualDevice) writeProperty(object BACnetObject, propertyID uint32, value interface{}, priority uint8) error {
+	d.mu.Lock()
+	vo, ok := d.objects[object]
+	if !ok {
+		d.mu.Unlock()
+		return &PropertyAccessError{Class: errorClassObject, Code: errorCodeUnknownObject}
+	}
+	change, handled, err := vo.writeLocal(propertyID, value, priority)
+	d.mu.Unlock()
+
+	switch {
+	case handled:
+		if err != nil {
+			return err
+		}
+		if change != nil && vo.OnCommand != nil {
+			vo.OnCommand(*change)
+		}
+	case vo.Write == nil:
+		return &PropertyAccessError{Class: errorClassProperty, Code: errorCodeWriteAccessDenied}
+	default:
+		if err := vo.Write(propertyID, value, priority); err != nil {
+			return err
+		}
+	}
+	d.changed(object)
+	return nil
+}
+
+// Server answers Who-Is, Who-Has, ReadProperty, ReadPropertyMultiple, ReadRange, WriteProperty,
+// SubscribeCOV, DeviceCommunicationControl and ReinitializeDevice requests for a VirtualDevice,
+// and sends the events the application reports to the recipients of its notification classes.
+type Server struct {
+	// OnUnexpectedBVLC, if set, is called for every received datagram whose BVLC function the
+	// server does not accept. The server is not a BBMD: it answers BBMD requests with a NAK.
+	OnUnexpectedBVLC func(UnexpectedBVLC)
+
+	conn   *net.UDPConn
+	device *VirtualDevice
+
+	mu             sync.Mutex
+	subscriptions  map[serverSubscriptionKey]*serverSubscription
+	pendingInitial []serverSubscription
+	// communication is the DeviceCommunicationControl state, in effect until communicationUntil
+	// unless that is zero
+	communication      CommunicationState
+	communicationUntil time.Time
+	// bindings are the addresses of devices learned from their I-Am, for event recipients
+	bindings map[uint32]serverBinding
+	invokeID byte
+}
+
+// NewServer returns a server for device on conn, which should be bound to the BACnet/IP port.
+func NewServer(conn *net.UDPConn, device *VirtualDevice) *Server {
+	s := &Server{
+		conn:          conn,
+		device:        device,
+		subscriptions: make(map[serverSubscriptionKey]*serverSubscription),
+		bindings:      make(map[uint32]serverBinding),
+	}
+	device.watch(s.NotifyChange)
+	return s
+}
+
+// Announce sends an I-Am for the device to addr, typically the broadcast address. It fails while
+// DeviceCommunicationControl disabled initiation.
+func (s *Server) Announce(addr *net.UDPAddr) error {
+	if s.initiationDisabled() {
+		return errCommunicationDisabled
+	}
+	_, err := s.conn.WriteTo(serverPacket(BVLC_ORIGINAL_BROADCAST_NPDU, npduHeader{}, s.iAmAPDU()), addr)
+	return err
+}
+
+// Serve answers requests until ctx is cancelled or the connection fails.
+func (s *Server) Serve(ctx context.Context) error {
+	buf := make([]byte, serverMaxAPDU+encapsulationOverhead)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		if reply := s.handle(buf[:n], addr); reply != nil {
+			s.conn.WriteTo(reply, addr)
+		}
+		s.sendInitialNotifications()
+	}
+}
+
+// handle processes a datagram and returns the reply to send to its source, if any.
+func (s *Server) handle(data []byte, addr *net.UDPAddr) []byte {
+	frame, err := decodeBVLC(data)
+	if err != nil {
+		return nil
+	}
+	if !expectedBVLC(frame.function) {
+		return s.rejectBVLC(frame.function, addr)
+	}
+	if frame.function == BVLC_RESULT {
+		return nil
+	}
+	header, apdu, err := decodeNPDU(frame.npdu)
+	if err != nil || header.isNetworkMessage() || len(apdu) < 2 {
+		return nil
+	}
+
+	disabled := s.communicationState() == CommunicationDisable
+	switch apdu[0] & 0xF0 {
+	case APDU_UNCONFIRMED_REQUEST:
+		if disabled {
+			return nil
+		}
+		if apdu[1] == SERVICE_UNCONFIRMED_I_AM {
+			source := addr
+			if frame.forwardedFrom != nil {
+				source = frame.forwardedFrom
+			}
+			s.learnBinding(apdu[2:], source, header)
+		}
+		if apdu[1] == SERVICE_UNCONFIRMED_WHO_IS && s.matchesWhoIs(apdu[2:]) {
+			return serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, header, s.iAmAPDU())
+		}
+		if apdu[1] == SERVICE_UNCONFIRMED_TEXT_MESSAGE {
+			s.unconfirmedTextMessage(apdu[2:], addr)
+		}
+		if apdu[1] == SERVICE_UNCONFIRMED_WHO_HAS {
+			if iHave := s.iHaveAPDU(apdu[2:]); iHave != nil {
+				return serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, header, iHave)
+			}
+		}
+	case APDU_CONFIRMED_REQUEST:
+		if apdu[0]&0x08 != 0 || len(apdu) < 4 {
+			return nil // Segmented requests are not supported
+		}
+		invokeID, service, params := apdu[2], apdu[3], apdu[4:]
+		if disabled && service != SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL && service != SERVICE_CONFIRMED_REINITIALIZE_DEVICE {
+			return nil
+		}
+		reply := []byte{APDU_REJECT, invokeID, byte(RejectReasonUnrecognizedService)}
+		if handler, ok := confirmedHandlers[service]; ok {
+			reply = handler(s, invokeID, params, addr, header)
+		}
+		return serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, header, reply)
+	}
+	return nil
+}
+
+// confirmedHandler answers a confirmed request with the APDU of the reply.
+type confirmedHandler func(s *Server, invokeID byte, params []byte, addr *net.UDPAddr, header npduHeader) []byte
+
+// confirmedHandlers are the confirmed services the server executes.
+var confirmedHandlers = map[byte]confirmedHandler{
+	SERVICE_CONFIRMED_READ_PROPERTY: func(s *Server, invokeID byte, params []byte, _ *net.UDPAddr, _ npduHeader) []byte {
+		return s.readProperty(invokeID, params)
+	},
+	SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE: func(s *Server, invokeID byte, params []byte, _ *net.UDPAddr, _ npduHeader) []byte {
+		return s.readPropertyMultiple(invokeID, params)
+	},
+	SERVICE_CONFIRMED_WRITE_PROPERTY: func(s *Server, invokeID byte, params []byte, _ *net.UDPAddr, _ npduHeader) []byte {
+		return s.writeProperty(invokeID, params)
+	},
+	SERVICE_CONFIRMED_SUBSCRIBE_COV: (*Server).subscribeCOV,
+	SERVICE_CONFIRMED_READ_RANGE: func(s *Server, invokeID byte, params []byte, _ *net.UDPAddr, _ npduHeader) []byte {
+		return s.readRange(invokeID, params)
+	},
+	SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL: func(s *Server, invokeID byte, params []byte, _ *net.UDPAddr, _ npduHeader) []byte {
+		return s.deviceCommunicationControl(invokeID, params)
+	},
+	SERVICE_CONFIRMED_REINITIALIZE_DEVICE: func(s *Server, invokeID byte, params []byte, _ *net.UDPAddr, _ npduHeader) []byte {
+		return s.reinitializeDevice(invokeID, params)
+	},
+	SERVICE_CONFIRMED_TEXT_MESSAGE: func(s *Server, invokeID byte, params []byte, addr *net.UDPAddr, _ npduHeader) []byte {
+		return s.textMessage(invokeID, params, addr)
+	},
+}
+
+// matchesWhoIs reports whether the device is within the range of a Who-Is.
+func (s *Server) matchesWhoIs(params []byte) bool {
+	low, high, ok := decodeWhoIsRange(params)
+	return ok && s.device.DeviceID >= low && s.device.DeviceID <= high
+}
+
+// decodeWhoIsRange returns the device instance range of a Who-Is, which is all instances if the
+// request has none; ok is false if the parameters are malformed.
+func decodeWhoIsRange(params []byte) (low, high uint32, ok bool) {
+	if len(params) == 0 {
+		return 0, 0x3FFFFF, true
+	}
+	r := bytes.NewReader(params)
+	low, err := encoding.DecodeContextUnsigned(r, 0)
+	if err != nil {
+		return 0, 0, false
+	}
+	high, err = encoding.DecodeContextUnsigned(r, 1)
+	if err != nil {
+		return 0, 0, false
+	}
+	return low, high, true
+}
+
+func (s *Server) iAmAPDU() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(APDU_UNCONFIRMED_REQUEST)
+	buf.WriteByte(SERVICE_UNCONFIRMED_I_AM)
+	encoding.EncodeApplicationObjectID(&buf, uint32(OBJECT_DEVICE), s.device.DeviceID)
+	encoding.EncodeApplicationUnsigned(&buf, serverMaxAPDU)
+	encoding.EncodeApplicationEnumerated(&buf, 3) // No segmentation
+	encoding.EncodeApplicationUnsigned(&buf, s.device.VendorID)
+	return buf.Bytes()
+}
+
+// iHaveAPDU answers a Who-Has for an object of the device, or returns nil if the device is outside
+// the requested range or has no such object.
+func (s *Server) iHaveAPDU(params []byte) []byte {
+	r := bytes.NewReader(params)
+	h, err := encoding.PeekTagHeader(r)
+	if err != nil {
+		return nil
+	}
+	if h.IsContext(0) {
+		low, err := encoding.DecodeContextUnsigned(r, 0)
+		if err != nil {
+			return nil
+		}
+		high, err := encoding.DecodeContextUnsigned(r, 1)
+		if err != nil || s.device.DeviceID < low || s.device.DeviceID > high {
+			return nil
+		}
+		if h, err = encoding.PeekTagHeader(r); err != nil {
+			return nil
+		}
+	}
+
+	var (
+		object BACnetObject
+		name   string
+		found  bool
+	)
+	switch {
+	case h.IsContext(2):
+		objectType, instance, err := encoding.DecodeContextObjectID(r, 2)
+		if err != nil {
+			return nil
+		}
+		object = BACnetObject{Type: ObjectType(objectType), Instance: instance}
+		name, found = s.device.objectName(object)
+	case h.IsContext(3):
+		name, err = encoding.DecodeContextCharacterString(r, 3)
+		if err != nil {
+			return nil
+		}
+		object, found = s.device.findObject(name)
+	}
+	if !found {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(APDU_UNCONFIRMED_REQUEST)
+	buf.WriteByte(SERVICE_UNCONFIRMED_I_HAVE)
+	encoding.EncodeApplicationObjectID(&buf, uint32(OBJECT_DEVICE), s.device.DeviceID)
+	encoding.EncodeApplicationObjectID(&buf, uint32(object.Type), object.Instance)
+	encoding.EncodeApplicationCharacterString(&buf, name)
+	return buf.Bytes()
+}
+
+func (s *Server) readProperty(invokeID byte, params []byte) []byte {
+	r := bytes.NewReader(params)
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0} // Other
+	}
+	object := BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	propertyID, err := encoding.DecodeContextUnsigned(r, 1)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	var arrayIndex *uint32
+	if r.Len() > 0 {
+		index, err := encoding.DecodeContextUnsigned(r, 2)
+		if err != nil {
+			return []byte{APDU_REJECT, invokeID, 0}
+		}
+		arrayIndex = &index
+	}
+
+	value, err := s.device.readProperty(object, propertyID, arrayIndex)
+	if err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_READ_PROPERTY, err)
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{APDU_COMPLEX_ACK, invokeID, SERVICE_CONFIRMED_READ_PROPERTY})
+	encoding.EncodeContextObjectID(&buf, 0, objectType, instance)
+	encoding.EncodeContextUnsigned(&buf, 1, propertyID)
+	if arrayIndex != nil {
+		encoding.EncodeContextUnsigned(&buf, 2, *arrayIndex)
+	}
+	encoding.EncodeOpeningTag(&buf, 3)
+	if err := encodeApplicationValue(&buf, value); err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_READ_PROPERTY, err)
+	}
+	encoding.EncodeClosingTag(&buf, 3)
+	return buf.Bytes()
+}
+
+func (s *Server) writeProperty(invokeID byte, params []byte) []byte {
+	r := bytes.NewReader(params)
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	object := BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	propertyID, err := encoding.DecodeContextUnsigned(r, 1)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	if h, err := encoding.PeekTagHeader(r); err == nil && h.IsContext(2) {
+		// Writing array elements is not supported
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_WRITE_PROPERTY, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeWriteAccessDenied})
+	}
+	if err := encoding.ExpectOpeningTag(r, 3); err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	value, err := decodeWrittenValue(r, propertyID)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	var priority uint8
+	if r.Len() > 0 {
+		p, err := encoding.DecodeContextUnsigned(r, 4)
+		if err != nil || p < 1 || p > 16 {
+			return errorAPDU(invokeID, SERVICE_CONFIRMED_WRITE_PROPERTY, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeValueOutOfRange})
+		}
+		priority = uint8(p)
+	}
+
+	if err := s.device.writeProperty(object, propertyID, value, priority); err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_WRITE_PROPERTY, err)
+	}
+	return []byte{APDU_SIMPLE_ACK, invokeID, SERVICE_CONFIRMED_WRITE_PROPERTY}
+}
+
+func (s *Server) readPropertyMultiple(invokeID byte, params []byte) []byte {
+	specs, err := decodeReadAccessSpecifications(params)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{APDU_COMPLEX_ACK, invokeID, SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE})
+	for _, spec := range specs {
+		encoding.EncodeContextObjectID(&buf, 0, uint32(spec.Object.Type), spec.Object.Instance)
+		encoding.EncodeOpeningTag(&buf, 1)
+		for _, ref := range s.expandReferences(spec) {
+			encoding.EncodeContextUnsigned(&buf, 2, ref.PropertyID)
+			if ref.ArrayIndex != nil {
+				encoding.EncodeContextUnsigned(&buf, 3, *ref.ArrayIndex)
+			}
+			value, err := s.device.readProperty(spec.Object, ref.PropertyID, ref.ArrayIndex)
+			var valueBuf bytes.Buffer
+			if err == nil {
+				err = encodeApplicationValue(&valueBuf, value)
+			}
+			if err != nil {
+				class, code := errorClassCode(err)
+				encoding.EncodeOpeningTag(&buf, 5)
+				encoding.EncodeApplicationEnumerated(&buf, class)
+				encoding.EncodeApplicationEnumerated(&buf, code)
+				encoding.EncodeClosingTag(&buf, 5)
+				continue
+			}
+			encoding.EncodeOpeningTag(&buf, 4)
+			buf.Write(valueBuf.Bytes())
+			encoding.EncodeClosingTag(&buf, 4)
+		}
+		encoding.EncodeClosingTag(&buf, 1)
+	}
+	if buf.Len() > serverMaxAPDU {
+		return []byte{APDU_ABORT | 0x01, invokeID, byte(AbortReasonSegmentationNotSupported)} // Sent by server
+	}
+	return buf.Bytes()
+}
+
+// expandReferences replaces PROP_ALL, PROP_REQUIRED and PROP_OPTIONAL with the matching properties
+// of the object. A special property of an unknown object is kept, so that reading it reports the
+// unknown object.
+func (s *Server) expandReferences(spec ReadAccessSpecification) []PropertyReference {
+	var refs []PropertyReference
+	for _, ref := range spec.Properties {
+		if ref.PropertyID != PROP_ALL && ref.PropertyID != PROP_REQUIRED && ref.PropertyID != PROP_OPTIONAL {
+			refs = append(refs, ref)
+			continue
+		}
+		props, _ := s.device.properties(spec.Object, false)
+		if props == nil {
+			refs = append(refs, ref)
+			continue
+		}
+		required := requiredProperties(spec.Object.Type)
+		ids := make([]uint32, 0, len(props))
+		for id := range props {
+			if ref.PropertyID == PROP_ALL || (ref.PropertyID == PROP_REQUIRED) == required[id] {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			refs = append(refs, PropertyReference{PropertyID: id})
+		}
+	}
+	return refs
+}
+
+// requiredProperties returns the properties the standard requires for objects of a type, as far as
+// virtual devices serve them. Priority_Array and Relinquish_Default count as required for value
+// objects too, since they are required whenever the object is commandable.
+func requiredProperties(objectType ObjectType) map[uint32]bool {
+	ids := []uint32{PROP_OBJECT_IDENTIFIER, PROP_OBJECT_NAME, PROP_OBJECT_TYPE}
+	status := []uint32{PROP_PRESENT_VALUE, PROP_STATUS_FLAGS, PROP_EVENT_STATE, PROP_OUT_OF_SERVICE}
+	command := []uint32{PROP_PRIORITY_ARRAY, PROP_RELINQUISH_DEFAULT}
+	switch objectType {
+	case OBJECT_DEVICE:
+		ids = append(ids, PROP_SYSTEM_STATUS, PROP_VENDOR_NAME, PROP_VENDOR_IDENTIFIER, PROP_FIRMWARE_REVISION,
+			PROP_APPLICATION_SOFTWARE_VERSION, PROP_PROTOCOL_VERSION, PROP_PROTOCOL_SERVICES_SUPPORTED,
+			PROP_PROTOCOL_OBJECT_TYPES_SUPPORTED, PROP_OBJECT_LIST, PROP_MAX_APDU_LENGTH_ACCEPTED,
+			PROP_SEGMENTATION_SUPPORTED, PROP_APDU_TIMEOUT, PROP_DEVICE_ADDRESS_BINDING)
+	case OBJECT_ANALOG_INPUT:
+		ids = append(append(ids, status...), PROP_UNITS)
+	case OBJECT_ANALOG_OUTPUT, OBJECT_ANALOG_VALUE:
+		ids = append(append(append(ids, status...), command...), PROP_UNITS)
+	case OBJECT_BINARY_INPUT:
+		ids = append(append(ids, status...), PROP_POLARITY)
+	case OBJECT_BINARY_OUTPUT:
+		ids = append(append(append(ids, status...), command...), PROP_POLARITY)
+	case OBJECT_BINARY_VALUE:
+		ids = append(append(ids, status...), command...)
+	case OBJECT_MULTI_STATE_INPUT:
+		ids = append(append(ids, status...), PROP_NUMBER_OF_STATES)
+	case OBJECT_MULTI_STATE_OUTPUT, OBJECT_MULTI_STATE_VALUE:
+		ids = append(append(append(ids, status...), command...), PROP_NUMBER_OF_STATES)
+	case OBJECT_SCHEDULE:
+		ids = append(ids, PROP_PRESENT_VALUE, PROP_EFFECTIVE_PERIOD, PROP_SCHEDULE_DEFAULT,
+			PROP_LIST_OF_OBJECT_PROPERTY_REFERENCES, PROP_PRIORITY_FOR_WRITING, PROP_STATUS_FLAGS, PROP_RELIABILITY,
+			PROP_OUT_OF_SERVICE)
+	case OBJECT_NOTIFICATION_CLASS:
+		ids = append(ids, PROP_NOTIFICATION_CLASS, PROP_PRIORITY, PROP_ACK_REQUIRED, PROP_RECIPIENT_LIST)
+	case OBJECT_CALENDAR:
+		ids = append(ids, PROP_PRESENT_VALUE, PROP_DATE_LIST)
+	case OBJECT_TREND_LOG:
+		ids = append(ids, PROP_ENABLE, PROP_STOP_WHEN_FULL, PROP_BUFFER_SIZE, PROP_LOG_BUFFER, PROP_RECORD_COUNT,
+			PROP_TOTAL_RECORD_COUNT, PROP_EVENT_STATE, PROP_STATUS_FLAGS, PROP_LOGGING_TYPE)
+	}
+	required := make(map[uint32]bool, len(ids))
+	for _, id := range ids {
+		required[id] = true
+	}
+	return required
+}
+
+func errorClassCode(err error) (uint32, uint32) {
+	var accessErr *PropertyAccessError
+	if errors.As(err, &accessErr) {
+		return accessErr.Class, accessErr.Code
+	}
+	return errorClassProperty, errorCodeOther
+}
+
+func errorAPDU(invokeID, service byte, err error) []byte {
+	class, code := errorClassCode(err)
+	var buf bytes.Buffer
+	buf.Write([]byte{APDU_ERROR, invokeID, service})
+	encoding.EncodeApplicationEnumerated(&buf, class)
+	encoding.EncodeApplicationEnumerated(&buf, code)
+	return buf.Bytes()
+}
+
+// serverPacket wraps an APDU in an NPDU and BVLC header. Replies to requests that came through a
+// router are addressed to the original source.
+func serverPacket(function byte, request npduHeader, apdu []byte) []byte {
+	npdu := []byte{1, NPDU_CONTROL_NORMAL_MESSAGE}
+	if request.hasSource() {
+		npdu[1] |= NPDU_CONTROL_DESTINATION_PRESENT
+		npdu = binary.BigEndian.AppendUint16(npdu, request.snet)
+		npdu = append(npdu, byte(len(request.sadr)))
+		npdu = append(npdu, request.sadr...)
+		npdu = append(npdu, 255) // Hop count
+	}
+
+	packet := []byte{BVLC_TYPE_BACNET_IP, function, 0, 0}
+	packet = append(packet, npdu...)
+	packet = append(packet, apdu...)
+	binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+	return packet
+}
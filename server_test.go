@@ -0,0 +1,37 @@
+package bacnet
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestServerReadAllPropertiesStable checks that a virtual device answers a ReadPropertyMultiple for
+// all properties with the same bytes every time, in ascending property order, although it keeps
+// the properties in maps.
+func TestServerReadAllPropertiesStable(t *testing.T) {
+	sim, device := startSimulator(t, SimulatorOptions{DeviceID: 3002, AnalogValues: 1})
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+
+	var params bytes.Buffer
+	encodeReadAccessSpecification(&params, av1, []uint32{PROP_ALL})
+	first := sim.Server.readPropertyMultiple(1, params.Bytes())
+	for range 10 {
+		if again := sim.Server.readPropertyMultiple(1, params.Bytes()); !bytes.Equal(again, first) {
+			t.Fatalf("response changed: %x, then %x", first, again)
+		}
+	}
+
+	client := newLoopbackClient(t, ClientOptions{})
+	properties, err := client.GetObjectAllPropertyList(device, av1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(properties) == 0 {
+		t.Fatal("no properties")
+	}
+	for i := 1; i < len(properties); i++ {
+		if properties[i].PropertyID <= properties[i-1].PropertyID {
+			t.Errorf("%s after %s", propertyLabel(properties[i].PropertyID), propertyLabel(properties[i-1].PropertyID))
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package bacnet
+
+// CommandChange reports a change of the value commanding a local object to the host application.
+type CommandChange struct {
+	Object BACnetObject
+	// Value is the new Present_Value; Priority is the priority in control, or 0 if the object
+	// fell back to its Relinquish_Default.
+	Value    interface{}
+	Priority uint8
+}
+
+// commandState is the state the device keeps for an object, guarded by the device's mutex.
+type commandState struct {
+	priorityArray     [16]interface{}
+	relinquishDefault interface{}
+	outOfService      bool
+	// override is the Present_Value written while out of service to an object without a
+	// priority array.
+	override interface{}
+}
+
+// commandable reports whether the object has a priority array: output objects always do, value
+// objects if Commandable is set.
+func (vo *VirtualObject) commandable() bool {
+	switch vo.Object.Type {
+	case OBJECT_ANALOG_OUTPUT, OBJECT_BINARY_OUTPUT, OBJECT_MULTI_STATE_OUTPUT:
+		return true
+	case OBJECT_ANALOG_VALUE, OBJECT_BINARY_VALUE, OBJECT_MULTI_STATE_VALUE:
+		return vo.Commandable
+	}
+	return false
+}
+
+// initState sets up the state of a newly added object.
+func (vo *VirtualObject) initState() {
+	vo.state = commandState{relinquishDefault: vo.RelinquishDefault}
+	if oos, ok := vo.Properties[PROP_OUT_OF_SERVICE].(bool); ok {
+		vo.state.outOfService = oos
+	}
+	if vo.state.relinquishDefault != nil {
+		return
+	}
+	switch vo.Object.Type {
+	case OBJECT_ANALOG_OUTPUT, OBJECT_ANALOG_VALUE:
+		vo.state.relinquishDefault = float32(0)
+	case OBJECT_BINARY_OUTPUT, OBJECT_BINARY_VALUE:
+		vo.state.relinquishDefault = BinaryInactive
+	case OBJECT_MULTI_STATE_OUTPUT, OBJECT_MULTI_STATE_VALUE:
+		vo.state.relinquishDefault = uint32(1)
+	}
+}
+
+// commanded returns the priority in control and the value the priority array resolves to.
+func (s *commandState) commanded() (uint8, interface{}) {
+	return resolvePriorityArray(s.priorityArray, s.relinquishDefault, 0)
+}
+
+// writeLocal performs the writes the device implements itself: Out_Of_Service, the Present_Value,
+// Priority_Array and Relinquish_Default of commandable objects and the Present_Value of objects
+// out of service. Input objects only accept Present_Value writes while out of service. handled is
+// false for writes left to the object's Write function; change is set if the command of the
+// object changed while in service. The device's mutex must be held.
+func (vo *VirtualObject) writeLocal(propertyID uint32, value interface{}, priority uint8) (change *CommandChange, handled bool, err error) {
+	state := &vo.state
+	commandable := vo.commandable()
+	switch {
+	case propertyID == PROP_OUT_OF_SERVICE:
+		oos, ok := value.(bool)
+		if !ok {
+			return nil, true, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeInvalidDataType}
+		}
+		wasOOS := state.outOfService
+		state.outOfService = oos
+		if !oos {
+			state.override = nil
+		}
+		if wasOOS && !oos && commandable {
+			// Back in service, the output follows the priority array again
+			return vo.commandChange(), true, nil
+		}
+		return nil, true, nil
+
+	case propertyID == PROP_PRESENT_VALUE && commandable:
+		if priority == 0 {
+			priority = 16
+		}
+		var converted interface{}
+		if value != nil {
+			if converted, err = vo.checkPresentValue(value); err != nil {
+				return nil, true, err
+			}
+		}
+		beforePriority, before := state.commanded()
+		state.priorityArray[priority-1] = converted
+		afterPriority, after := state.commanded()
+		if state.outOfService || (afterPriority == beforePriority && ValuesEqual(before, after)) {
+			return nil, true, nil
+		}
+		return vo.commandChange(), true, nil
+
+	case propertyID == PROP_RELINQUISH_DEFAULT && commandable:
+		converted, err := vo.checkPresentValue(value)
+		if err != nil {
+			return nil, true, err
+		}
+		state.relinquishDefault = converted
+		if priority, _ := state.commanded(); priority != 0 || state.outOfService {
+			return nil, true, nil
+		}
+		return vo.commandChange(), true, nil
+
+	case propertyID == PROP_PRIORITY_ARRAY && commandable:
+		return nil, true, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeWriteAccessDenied}
+
+	case propertyID == PROP_PRESENT_VALUE && state.outOfService:
+		converted, err := vo.checkPresentValue(value)
+		if err != nil {
+			return nil, true, err
+		}
+		state.override = converted
+		return nil, true, nil
+
+	case propertyID == PROP_PRESENT_VALUE && isInputObjectType(vo.Object.Type):
+		return nil, true, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeWriteAccessDenied}
+	}
+	return nil, false, nil
+}
+
+// commandChange describes the current command of the object.
+func (vo *VirtualObject) commandChange() *CommandChange {
+	priority, value := vo.state.commanded()
+	return &CommandChange{Object: vo.Object, Value: value, Priority: priority}
+}
+
+// checkPresentValue converts a written value to the Present_Value type of the object, returning
+// the error for the client if it does not fit.
+func (vo *VirtualObject) checkPresentValue(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeInvalidDataType}
+	}
+	converted, err := CoercePresentValue(vo.Object.Type, value)
+	if err != nil {
+		if _, numeric := numericValue(value); numeric && isBinaryObjectType(vo.Object.Type) {
+			return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeValueOutOfRange}
+		}
+		return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeInvalidDataType}
+	}
+	switch vo.Object.Type {
+	case OBJECT_MULTI_STATE_INPUT, OBJECT_MULTI_STATE_OUTPUT, OBJECT_MULTI_STATE_VALUE:
+		states, limited := vo.Properties[PROP_NUMBER_OF_STATES].(uint32)
+		if state := converted.(uint32); state == 0 || (limited && state > states) {
+			return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeValueOutOfRange}
+		}
+	}
+	return converted, nil
+}
+
+func isInputObjectType(t ObjectType) bool {
+	return t == OBJECT_ANALOG_INPUT || t == OBJECT_BINARY_INPUT || t == OBJECT_MULTI_STATE_INPUT
+}
@@ -0,0 +1,177 @@
+package bacnet
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// Error class and code returned for requests with a wrong password.
+const (
+	errorClassSecurity       = 4
+	errorCodePasswordFailure = 26
+)
+
+// CommunicationState is the enable-disable parameter of a DeviceCommunicationControl request.
+type CommunicationState uint32
+
+const (
+	CommunicationEnable CommunicationState = iota
+	// CommunicationDisable stops the device from answering anything but DeviceCommunicationControl
+	// and ReinitializeDevice, and from initiating requests.
+	CommunicationDisable
+	// CommunicationDisableInitiation stops the device from initiating requests such as COV
+	// notifications; it still answers requests, Who-Is included.
+	CommunicationDisableInitiation
+)
+
+var communicationStateNames = map[CommunicationState]string{
+	CommunicationEnable:            "enable",
+	CommunicationDisable:           "disable",
+	CommunicationDisableInitiation: "disable-initiation",
+}
+
+func (s CommunicationState) String() string { return enumName(communicationStateNames, s) }
+
+// CommunicationControl is a DeviceCommunicationControl request sent by a client or received by a
+// Server.
+type CommunicationControl struct {
+	State CommunicationState
+	// Duration after which communication is enabled again; zero means until enabled by request.
+	Duration time.Duration
+}
+
+// ReinitializeState is the state a ReinitializeDevice request asks the device to enter.
+type ReinitializeState uint32
+
+const (
+	ReinitializeColdstart ReinitializeState = iota
+	ReinitializeWarmstart
+	ReinitializeStartBackup
+	ReinitializeEndBackup
+	ReinitializeStartRestore
+	ReinitializeEndRestore
+	ReinitializeAbortRestore
+	ReinitializeActivateChanges
+)
+
+var reinitializeStateNames = map[ReinitializeState]string{
+	ReinitializeColdstart:       "coldstart",
+	ReinitializeWarmstart:       "warmstart",
+	ReinitializeStartBackup:     "start-backup",
+	ReinitializeEndBackup:       "end-backup",
+	ReinitializeStartRestore:    "start-restore",
+	ReinitializeEndRestore:      "end-restore",
+	ReinitializeAbortRestore:    "abort-restore",
+	ReinitializeActivateChanges: "activate-changes",
+}
+
+func (s ReinitializeState) String() string { return enumName(reinitializeStateNames, s) }
+
+// communicationState returns the DeviceCommunicationControl state in effect, re-enabling
+// communication once the duration of a disable request has passed.
+func (s *Server) communicationState() CommunicationState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.communication != CommunicationEnable && !s.communicationUntil.IsZero() && time.Now().After(s.communicationUntil) {
+		s.communication = CommunicationEnable
+	}
+	return s.communication
+}
+
+// initiationDisabled reports whether the server must not initiate requests.
+func (s *Server) initiationDisabled() bool {
+	return s.communicationState() != CommunicationEnable
+}
+
+// checkPassword compares the password of a request with the device's; requests to devices
+// without a password are accepted regardless.
+func (s *Server) checkPassword(password string) error {
+	if s.device.Password != "" && password != s.device.Password {
+		return &PropertyAccessError{Class: errorClassSecurity, Code: errorCodePasswordFailure}
+	}
+	return nil
+}
+
+func (s *Server) deviceCommunicationControl(invokeID byte, params []byte) []byte {
+	r := bytes.NewReader(params)
+	var control CommunicationControl
+	h, err := encoding.PeekTagHeader(r)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	if h.IsContext(0) {
+		minutes, err := encoding.DecodeContextUnsigned(r, 0)
+		if err != nil {
+			return []byte{APDU_REJECT, invokeID, 0}
+		}
+		control.Duration = time.Duration(minutes) * time.Minute
+	}
+	state, err := encoding.DecodeContextUnsigned(r, 1)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	if state > uint32(CommunicationDisableInitiation) {
+		return []byte{APDU_REJECT, invokeID, byte(RejectReasonUndefinedEnumeration)}
+	}
+	control.State = CommunicationState(state)
+	var password string
+	if r.Len() > 0 {
+		if password, err = encoding.DecodeContextCharacterString(r, 2); err != nil {
+			return []byte{APDU_REJECT, invokeID, 0}
+		}
+	}
+
+	if err := s.checkPassword(password); err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL, err)
+	}
+	if s.device.OnCommunicationControl != nil {
+		if err := s.device.OnCommunicationControl(control); err != nil {
+			return errorAPDU(invokeID, SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.communication = control.State
+	s.communicationUntil = time.Time{}
+	if control.State != CommunicationEnable && control.Duration > 0 {
+		s.communicationUntil = time.Now().Add(control.Duration)
+	}
+	s.mu.Unlock()
+	return []byte{APDU_SIMPLE_ACK, invokeID, SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL}
+}
+
+func (s *Server) reinitializeDevice(invokeID byte, params []byte) []byte {
+	r := bytes.NewReader(params)
+	state, err := encoding.DecodeContextUnsigned(r, 0)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	if state > uint32(ReinitializeActivateChanges) {
+		return []byte{APDU_REJECT, invokeID, byte(RejectReasonUndefinedEnumeration)}
+	}
+	var password string
+	if r.Len() > 0 {
+		if password, err = encoding.DecodeContextCharacterString(r, 1); err != nil {
+			return []byte{APDU_REJECT, invokeID, 0}
+		}
+	}
+
+	if err := s.checkPassword(password); err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_REINITIALIZE_DEVICE, err)
+	}
+	if s.device.OnReinitialize == nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_REINITIALIZE_DEVICE,
+			&PropertyAccessError{Class: errorClassServices, Code: errorCodeOptionalFunctionalityNotSupported})
+	}
+	if err := s.device.OnReinitialize(ReinitializeState(state)); err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_REINITIALIZE_DEVICE, err)
+	}
+	return []byte{APDU_SIMPLE_ACK, invokeID, SERVICE_CONFIRMED_REINITIALIZE_DEVICE}
+}
+
+// errCommunicationDisabled is returned by Announce while DeviceCommunicationControl disabled
+// initiation.
+var errCommunicationDisabled = errors.New("communication disabled by DeviceCommunicationControl")
@@ -0,0 +1,166 @@
+package bacnet
+
+import (
+	"bytes"
+	"net"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// Error class and code returned for SubscribeCOV requests asking for confirmed notifications,
+// which the server does not send.
+const (
+	errorClassServices                         = 5
+	errorCodeOptionalFunctionalityNotSupported = 45
+)
+
+type serverSubscriptionKey struct {
+	addr      string
+	processID uint32
+	object    BACnetObject
+}
+
+// serverSubscription is a COV subscription held by a Server.
+type serverSubscription struct {
+	addr      *net.UDPAddr
+	request   npduHeader // For routing notifications back to the subscriber
+	processID uint32
+	object    BACnetObject
+	expires   time.Time // Zero for indefinite subscriptions
+}
+
+// SubscriptionCount returns the number of COV subscriptions that have not expired.
+func (s *Server) SubscriptionCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireSubscriptions(time.Now())
+	return len(s.subscriptions)
+}
+
+// NotifyChange sends a COV notification with the Present_Value and Status_Flags of object to all
+// its subscribers. Writes to the device, by clients or local schedules, notify automatically;
+// applications call it when they change a value themselves.
+func (s *Server) NotifyChange(object BACnetObject) {
+	now := time.Now()
+	s.mu.Lock()
+	s.expireSubscriptions(now)
+	var subs []serverSubscription
+	for _, sub := range s.subscriptions {
+		if sub.object == object {
+			subs = append(subs, *sub)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		s.sendNotification(sub, now)
+	}
+}
+
+func (s *Server) subscribeCOV(invokeID byte, params []byte, addr *net.UDPAddr, request npduHeader) []byte {
+	r := bytes.NewReader(params)
+	processID, err := encoding.DecodeContextUnsigned(r, 0)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 1)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	object := BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	key := serverSubscriptionKey{addr: addr.String(), processID: processID, object: object}
+
+	if r.Len() == 0 { // Cancellation
+		s.mu.Lock()
+		delete(s.subscriptions, key)
+		s.mu.Unlock()
+		return []byte{APDU_SIMPLE_ACK, invokeID, SERVICE_CONFIRMED_SUBSCRIBE_COV}
+	}
+
+	confirmed, err := encoding.DecodeContextBoolean(r, 2)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	var lifetime uint32
+	if r.Len() > 0 {
+		if lifetime, err = encoding.DecodeContextUnsigned(r, 3); err != nil {
+			return []byte{APDU_REJECT, invokeID, 0}
+		}
+	}
+	if confirmed {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_SUBSCRIBE_COV,
+			&PropertyAccessError{Class: errorClassServices, Code: errorCodeOptionalFunctionalityNotSupported})
+	}
+	if props, _ := s.device.properties(object, false); props == nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_SUBSCRIBE_COV, &PropertyAccessError{Class: errorClassObject, Code: errorCodeUnknownObject})
+	}
+
+	sub := &serverSubscription{addr: addr, request: request, processID: processID, object: object}
+	if lifetime > 0 {
+		sub.expires = time.Now().Add(time.Duration(lifetime) * time.Second)
+	}
+	s.mu.Lock()
+	s.subscriptions[key] = sub
+	s.pendingInitial = append(s.pendingInitial, *sub)
+	s.mu.Unlock()
+	return []byte{APDU_SIMPLE_ACK, invokeID, SERVICE_CONFIRMED_SUBSCRIBE_COV}
+}
+
+// sendInitialNotifications sends the notification every new subscriber receives right after the
+// Simple-ACK of its SubscribeCOV request.
+func (s *Server) sendInitialNotifications() {
+	s.mu.Lock()
+	subs := s.pendingInitial
+	s.pendingInitial = nil
+	s.mu.Unlock()
+
+	now := time.Now()
+	for _, sub := range subs {
+		s.sendNotification(sub, now)
+	}
+}
+
+func (s *Server) sendNotification(sub serverSubscription, now time.Time) {
+	if s.initiationDisabled() {
+		return
+	}
+	var buf bytes.Buffer
+	buf.Write([]byte{APDU_UNCONFIRMED_REQUEST, SERVICE_UNCONFIRMED_COV_NOTIFICATION})
+	encoding.EncodeContextUnsigned(&buf, 0, sub.processID)
+	encoding.EncodeContextObjectID(&buf, 1, uint32(OBJECT_DEVICE), s.device.DeviceID)
+	encoding.EncodeContextObjectID(&buf, 2, uint32(sub.object.Type), sub.object.Instance)
+	var remaining uint32
+	if !sub.expires.IsZero() {
+		remaining = uint32(sub.expires.Sub(now).Round(time.Second) / time.Second)
+	}
+	encoding.EncodeContextUnsigned(&buf, 3, remaining)
+
+	encoding.EncodeOpeningTag(&buf, 4)
+	for _, propertyID := range []uint32{PROP_PRESENT_VALUE, PROP_STATUS_FLAGS} {
+		value, err := s.device.readProperty(sub.object, propertyID, nil)
+		if err != nil {
+			continue
+		}
+		var valueBuf bytes.Buffer
+		if err := encodeApplicationValue(&valueBuf, value); err != nil {
+			continue
+		}
+		encoding.EncodeContextUnsigned(&buf, 0, propertyID)
+		encoding.EncodeOpeningTag(&buf, 2)
+		buf.Write(valueBuf.Bytes())
+		encoding.EncodeClosingTag(&buf, 2)
+	}
+	encoding.EncodeClosingTag(&buf, 4)
+
+	s.conn.WriteTo(serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, sub.request, buf.Bytes()), sub.addr)
+}
+
+// expireSubscriptions drops subscriptions whose lifetime ended. The caller must hold s.mu.
+func (s *Server) expireSubscriptions(now time.Time) {
+	for key, sub := range s.subscriptions {
+		if !sub.expires.IsZero() && now.After(sub.expires) {
+			delete(s.subscriptions, key)
+		}
+	}
+}
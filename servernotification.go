@@ -0,0 +1,501 @@
+package bacnet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// Event transitions, indexing the Priority, Ack_Required and recipient transitions of a
+// notification class.
+const (
+	transitionToOffnormal = 0
+	transitionToFault     = 1
+	transitionToNormal    = 2
+)
+
+// transitionOf returns the transition that leads to an event state.
+func transitionOf(state EventState) int {
+	switch state {
+	case EventStateNormal:
+		return transitionToNormal
+	case EventStateFault:
+		return transitionToFault
+	}
+	return transitionToOffnormal
+}
+
+// Destination is an entry of the Recipient_List of a Notification Class object.
+type Destination struct {
+	// ValidDays are the days, starting with Monday, on which the recipient is notified.
+	ValidDays [7]bool `json:"validDays"`
+	// FromTime and ToTime bound the time of day at which the recipient is notified, inclusive.
+	FromTime Time `json:"fromTime"`
+	ToTime   Time `json:"toTime"`
+	// Recipient is the device or address and the process notified.
+	Recipient COVRecipient `json:"recipient"`
+	Confirmed bool         `json:"confirmed"`
+	// Transitions selects the to-offnormal, to-fault and to-normal notifications.
+	Transitions [3]bool `json:"transitions"`
+}
+
+// AlwaysDestination returns a destination notifying recipient of all transitions at all times.
+func AlwaysDestination(recipient COVRecipient, confirmed bool) Destination {
+	return Destination{
+		ValidDays:   [7]bool{true, true, true, true, true, true, true},
+		FromTime:    Time{},
+		ToTime:      Time{Hour: 23, Minute: 59, Second: 59, Hundredths: 99},
+		Recipient:   recipient,
+		Confirmed:   confirmed,
+		Transitions: [3]bool{true, true, true},
+	}
+}
+
+// accepts reports whether the destination is notified of a transition at t.
+func (d Destination) accepts(t time.Time, transition int) bool {
+	now := timeOfDay(NewTime(t))
+	return d.ValidDays[weekdayIndex(t)] && d.Transitions[transition] &&
+		timeOfDay(d.FromTime) <= now && now <= timeOfDay(d.ToTime)
+}
+
+// encode writes a BACnetDestination.
+func (d Destination) encode(buf *bytes.Buffer) {
+	encoding.EncodeApplicationBitString(buf, d.ValidDays[:])
+	encoding.EncodeApplicationTime(buf, d.FromTime.Hour, d.FromTime.Minute, d.FromTime.Second, d.FromTime.Hundredths)
+	encoding.EncodeApplicationTime(buf, d.ToTime.Hour, d.ToTime.Minute, d.ToTime.Second, d.ToTime.Hundredths)
+	d.Recipient.encodeRecipient(buf)
+	encoding.EncodeApplicationUnsigned(buf, d.Recipient.ProcessID)
+	encoding.EncodeApplicationBoolean(buf, d.Confirmed)
+	encoding.EncodeApplicationBitString(buf, d.Transitions[:])
+}
+
+// decodeDestinations decodes a list of BACnetDestination up to the closing tag with the given
+// number, which it consumes.
+func decodeDestinations(r *bytes.Reader, closingTag uint8) ([]Destination, error) {
+	var destinations []Destination
+	for {
+		tag, err := encoding.PeekTagHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if tag.IsClosing(closingTag) {
+			encoding.DecodeTagHeader(r)
+			return destinations, nil
+		}
+
+		var d Destination
+		days, err := decodeApplicationBits(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(days) < len(d.ValidDays) {
+			return nil, fmt.Errorf("valid days has %d bits", len(days))
+		}
+		copy(d.ValidDays[:], days)
+		from, err := decodeApplicationValue(r)
+		if err != nil {
+			return nil, err
+		}
+		to, err := decodeApplicationValue(r)
+		if err != nil {
+			return nil, err
+		}
+		var fromOK, toOK bool
+		if d.FromTime, fromOK = from.(Time); !fromOK {
+			return nil, fmt.Errorf("expected from time, got %T", from)
+		}
+		if d.ToTime, toOK = to.(Time); !toOK {
+			return nil, fmt.Errorf("expected to time, got %T", to)
+		}
+		if d.Recipient, err = decodeRecipient(r); err != nil {
+			return nil, err
+		}
+		processID, err := decodeApplicationValue(r)
+		if err != nil {
+			return nil, err
+		}
+		confirmed, err := decodeApplicationValue(r)
+		if err != nil {
+			return nil, err
+		}
+		var processOK, confirmedOK bool
+		if d.Recipient.ProcessID, processOK = processID.(uint32); !processOK {
+			return nil, fmt.Errorf("expected process identifier, got %T", processID)
+		}
+		if d.Confirmed, confirmedOK = confirmed.(bool); !confirmedOK {
+			return nil, fmt.Errorf("expected confirmed flag, got %T", confirmed)
+		}
+		transitions, err := decodeApplicationBits(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(transitions) < len(d.Transitions) {
+			return nil, fmt.Errorf("transitions has %d bits", len(transitions))
+		}
+		copy(d.Transitions[:], transitions)
+		destinations = append(destinations, d)
+	}
+}
+
+// encodeContextBits writes a context-tagged bit string.
+func encodeContextBits(buf *bytes.Buffer, tagNumber uint8, bits []bool) {
+	data := make([]byte, 1+(len(bits)+7)/8)
+	data[0] = byte(len(data)*8 - 8 - len(bits)) // Unused bits
+	for i, set := range bits {
+		if set {
+			data[1+i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	encoding.EncodeTag(buf, tagNumber, true, uint32(len(data)))
+	buf.Write(data)
+}
+
+// decodeApplicationBits decodes an application-tagged bit string of any length.
+func decodeApplicationBits(r *bytes.Reader) ([]bool, error) {
+	tag, err := encoding.DecodeTagHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if tag.Context || tag.Number != encoding.TagBitString || tag.Length == 0 {
+		return nil, fmt.Errorf("expected bit string, got tag %d", tag.Number)
+	}
+	data := make([]byte, tag.Length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return decodeBits(data)
+}
+
+// decodeBits decodes the content of a bit string: the number of unused bits and the bits.
+func decodeBits(data []byte) ([]bool, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("invalid bit string")
+	}
+	unused := int(data[0])
+	n := (len(data)-1)*8 - unused
+	if unused > 7 || n < 0 {
+		return nil, fmt.Errorf("invalid bit string")
+	}
+	bits := make([]bool, n)
+	for i := range bits {
+		bits[i] = data[1+i/8]&(0x80>>(i%8)) != 0
+	}
+	return bits, nil
+}
+
+// decodeWrittenValue decodes the value of a WriteProperty request up to and including the
+// closing tag 3. Recipient_List takes a list of BACnetDestination; other properties a single
+// application-tagged value.
+func decodeWrittenValue(r *bytes.Reader, propertyID uint32) (interface{}, error) {
+	if propertyID == PROP_RECIPIENT_LIST {
+		return decodeDestinations(r, 3)
+	}
+	value, err := decodeApplicationValue(r)
+	if err != nil {
+		return nil, err
+	}
+	return value, encoding.ExpectClosingTag(r, 3)
+}
+
+// LocalNotificationClass is a Notification Class object of a VirtualDevice. Clients can rewrite
+// its Recipient_List; Server.ReportEvent sends the events of the class to its recipients.
+type LocalNotificationClass struct {
+	object BACnetObject
+	name   string
+
+	mu          sync.Mutex
+	priority    [3]uint8
+	ackRequired [3]bool
+	recipients  []Destination
+}
+
+// NewLocalNotificationClass returns a notification class with the given number. priority and
+// ackRequired hold the event priority and whether an acknowledgment is required for the
+// to-offnormal, to-fault and to-normal transitions.
+func NewLocalNotificationClass(number uint32, name string, priority [3]uint8, ackRequired [3]bool, recipients ...Destination) *LocalNotificationClass {
+	return &LocalNotificationClass{
+		object:      BACnetObject{Type: OBJECT_NOTIFICATION_CLASS, Instance: number},
+		name:        name,
+		priority:    priority,
+		ackRequired: ackRequired,
+		recipients:  recipients,
+	}
+}
+
+// AddTo adds the Notification Class object to device.
+func (c *LocalNotificationClass) AddTo(device *VirtualDevice) error {
+	return device.AddObject(&VirtualObject{
+		Object:     c.object,
+		Name:       c.name,
+		Properties: map[uint32]interface{}{PROP_NOTIFICATION_CLASS: c.object.Instance},
+		Dynamic:    c.properties,
+		Write:      c.write,
+	})
+}
+
+// SetRecipients replaces the Recipient_List.
+func (c *LocalNotificationClass) SetRecipients(recipients []Destination) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recipients = append([]Destination(nil), recipients...)
+}
+
+// Recipients returns the Recipient_List.
+func (c *LocalNotificationClass) Recipients() []Destination {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Destination(nil), c.recipients...)
+}
+
+func (c *LocalNotificationClass) properties() map[uint32]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	priority := make([]interface{}, len(c.priority))
+	for i, p := range c.priority {
+		priority[i] = uint32(p)
+	}
+	return map[uint32]interface{}{
+		PROP_PRIORITY:       priority,
+		PROP_ACK_REQUIRED:   c.ackRequired[:],
+		PROP_RECIPIENT_LIST: append([]Destination(nil), c.recipients...),
+	}
+}
+
+func (c *LocalNotificationClass) write(propertyID uint32, value interface{}, _ uint8) error {
+	if propertyID != PROP_RECIPIENT_LIST {
+		return &PropertyAccessError{Class: errorClassProperty, Code: errorCodeWriteAccessDenied}
+	}
+	recipients, ok := value.([]Destination)
+	if !ok {
+		return &PropertyAccessError{Class: errorClassProperty, Code: errorCodeInvalidDataType}
+	}
+	c.SetRecipients(recipients)
+	return nil
+}
+
+// EventValues are the event values of an event notification, specific to its event type.
+type EventValues interface {
+	encodeEventValues(buf *bytes.Buffer)
+}
+
+// ChangeOfStateValues are the event values of a change-of-state event. NewState is a BinaryPV,
+// or a uint32 state of a multi-state object.
+type ChangeOfStateValues struct {
+	NewState    interface{}
+	StatusFlags StatusFlags
+}
+
+func (v ChangeOfStateValues) encodeEventValues(buf *bytes.Buffer) {
+	encoding.EncodeOpeningTag(buf, uint8(EventChangeOfState))
+	encoding.EncodeOpeningTag(buf, 0)
+	switch state := v.NewState.(type) {
+	case BinaryPV:
+		encoding.EncodeContextEnumerated(buf, 1, uint32(state))
+	case bool:
+		encoding.EncodeContextBoolean(buf, 0, state)
+	default:
+		n, _ := numericValue(state)
+		encoding.EncodeContextUnsigned(buf, 11, uint32(n))
+	}
+	encoding.EncodeClosingTag(buf, 0)
+	encodeContextStatusFlags(buf, 1, v.StatusFlags)
+	encoding.EncodeClosingTag(buf, uint8(EventChangeOfState))
+}
+
+// OutOfRangeValues are the event values of an out-of-range event.
+type OutOfRangeValues struct {
+	ExceedingValue float32
+	StatusFlags    StatusFlags
+	Deadband       float32
+	ExceededLimit  float32
+}
+
+func (v OutOfRangeValues) encodeEventValues(buf *bytes.Buffer) {
+	encoding.EncodeOpeningTag(buf, uint8(EventOutOfRange))
+	encoding.EncodeContextReal(buf, 0, v.ExceedingValue)
+	encodeContextStatusFlags(buf, 1, v.StatusFlags)
+	encoding.EncodeContextReal(buf, 2, v.Deadband)
+	encoding.EncodeContextReal(buf, 3, v.ExceededLimit)
+	encoding.EncodeClosingTag(buf, uint8(EventOutOfRange))
+}
+
+// encodeContextStatusFlags writes Status_Flags as a context-tagged bit string.
+func encodeContextStatusFlags(buf *bytes.Buffer, tagNumber uint8, f StatusFlags) {
+	var bits byte
+	for i, set := range []bool{f.InAlarm, f.Fault, f.Overridden, f.OutOfService} {
+		if set {
+			bits |= 0x80 >> i
+		}
+	}
+	encoding.EncodeTag(buf, tagNumber, true, 2)
+	buf.WriteByte(4) // Unused bits
+	buf.WriteByte(bits)
+}
+
+// LocalEvent is an event of an object of the device, detected by the application and reported
+// with Server.ReportEvent.
+type LocalEvent struct {
+	Object            BACnetObject
+	NotificationClass uint32
+	EventType         EventType
+	NotifyType        NotifyType
+	FromState         EventState
+	ToState           EventState
+	MessageText       string
+	// Values are the event values; they may be nil for ack notifications.
+	Values EventValues
+	// Time is the time of the transition; zero means now.
+	Time time.Time
+}
+
+// serverBinding is the address of a device learned from its I-Am.
+type serverBinding struct {
+	addr   *net.UDPAddr
+	header npduHeader
+}
+
+// errUnknownRecipient is returned by ReportEvent for recipients whose address is unknown.
+var errUnknownRecipient = errors.New("recipient address unknown")
+
+// ReportEvent sends an event notification for event to the recipients of its notification class
+// that accept the transition at the time of the event, taking priority and acknowledgment from
+// the class, and returns the notification. Recipients given by device are addressed through their
+// I-Am, which the server learns from the network; others must be on the local network. Confirmed
+// notifications are sent once, without waiting for the acknowledgment.
+func (s *Server) ReportEvent(event LocalEvent) (EventNotification, error) {
+	if s.initiationDisabled() {
+		return EventNotification{}, errCommunicationDisabled
+	}
+	class := BACnetObject{Type: OBJECT_NOTIFICATION_CLASS, Instance: event.NotificationClass}
+	props, err := s.device.properties(class, false)
+	if err != nil {
+		return EventNotification{}, err
+	}
+	if props == nil {
+		return EventNotification{}, fmt.Errorf("notification class %d not found", event.NotificationClass)
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	transition := transitionOf(event.ToState)
+	n := EventNotification{
+		InitiatingDevice:  s.device.deviceObject(),
+		EventObject:       event.Object,
+		Time:              event.Time,
+		NotificationClass: event.NotificationClass,
+		EventType:         event.EventType,
+		MessageText:       event.MessageText,
+		NotifyType:        event.NotifyType,
+		FromState:         event.FromState,
+		ToState:           event.ToState,
+		Values:            event.Values,
+	}
+	if priority, ok := props[PROP_PRIORITY].([]interface{}); ok && len(priority) == 3 {
+		if p, ok := priority[transition].(uint32); ok {
+			n.Priority = uint8(p)
+		}
+	}
+	if ack, ok := props[PROP_ACK_REQUIRED].([]bool); ok && len(ack) == 3 {
+		n.AckRequired = ack[transition]
+	}
+
+	recipients, _ := props[PROP_RECIPIENT_LIST].([]Destination)
+	var errs []error
+	for _, d := range recipients {
+		if !d.accepts(event.Time, transition) {
+			continue
+		}
+		addr, header, err := s.recipientAddress(d.Recipient)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recipient process %d: %w", d.Recipient.ProcessID, err))
+			continue
+		}
+		n.ProcessID = d.Recipient.ProcessID
+		apdu := s.eventNotificationAPDU(n, d.Confirmed)
+		if _, err := s.conn.WriteTo(serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, header, apdu), addr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	n.ProcessID = 0
+	return n, errors.Join(errs...)
+}
+
+// recipientAddress returns the address of a recipient and the header addressing it through a
+// router, if any.
+func (s *Server) recipientAddress(recipient COVRecipient) (*net.UDPAddr, npduHeader, error) {
+	if recipient.Device != nil {
+		s.mu.Lock()
+		binding, ok := s.bindings[recipient.Device.Instance]
+		s.mu.Unlock()
+		if !ok {
+			return nil, npduHeader{}, errUnknownRecipient
+		}
+		return binding.addr, binding.header, nil
+	}
+	if recipient.Network != 0 || len(recipient.MAC) != 6 {
+		return nil, npduHeader{}, errUnknownRecipient
+	}
+	ip := net.IPv4(recipient.MAC[0], recipient.MAC[1], recipient.MAC[2], recipient.MAC[3])
+	return &net.UDPAddr{IP: ip, Port: int(recipient.MAC[4])<<8 | int(recipient.MAC[5])}, npduHeader{}, nil
+}
+
+// learnBinding records the address of the device sending an I-Am.
+func (s *Server) learnBinding(params []byte, addr *net.UDPAddr, header npduHeader) {
+	id, err := decodeApplicationValue(bytes.NewReader(params))
+	if err != nil {
+		return
+	}
+	device, ok := id.(BACnetObject)
+	if !ok || device.Type != OBJECT_DEVICE {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bindings[device.Instance] = serverBinding{addr: addr, header: header}
+}
+
+// eventNotificationAPDU builds a ConfirmedEventNotification or UnconfirmedEventNotification
+// request.
+func (s *Server) eventNotificationAPDU(n EventNotification, confirmed bool) []byte {
+	var buf bytes.Buffer
+	if confirmed {
+		s.mu.Lock()
+		s.invokeID++
+		invokeID := s.invokeID
+		s.mu.Unlock()
+		buf.Write([]byte{APDU_CONFIRMED_REQUEST, 0x05, invokeID, SERVICE_CONFIRMED_EVENT_NOTIFICATION})
+	} else {
+		buf.Write([]byte{APDU_UNCONFIRMED_REQUEST, SERVICE_UNCONFIRMED_EVENT_NOTIFICATION})
+	}
+	encoding.EncodeContextUnsigned(&buf, 0, n.ProcessID)
+	encoding.EncodeContextObjectID(&buf, 1, uint32(n.InitiatingDevice.Type), n.InitiatingDevice.Instance)
+	encoding.EncodeContextObjectID(&buf, 2, uint32(n.EventObject.Type), n.EventObject.Instance)
+	encoding.EncodeOpeningTag(&buf, 3)
+	encodeDateTimeStamp(&buf, n.Time)
+	encoding.EncodeClosingTag(&buf, 3)
+	encoding.EncodeContextUnsigned(&buf, 4, n.NotificationClass)
+	encoding.EncodeContextUnsigned(&buf, 5, uint32(n.Priority))
+	encoding.EncodeContextEnumerated(&buf, 6, uint32(n.EventType))
+	if n.MessageText != "" {
+		encoding.EncodeContextCharacterString(&buf, 7, n.MessageText)
+	}
+	encoding.EncodeContextEnumerated(&buf, 8, uint32(n.NotifyType))
+	if n.NotifyType != NotifyAckNotification {
+		encoding.EncodeContextBoolean(&buf, 9, n.AckRequired)
+		encoding.EncodeContextEnumerated(&buf, 10, uint32(n.FromState))
+	}
+	encoding.EncodeContextEnumerated(&buf, 11, uint32(n.ToState))
+	if n.Values != nil && n.NotifyType != NotifyAckNotification {
+		encoding.EncodeOpeningTag(&buf, 12)
+		n.Values.encodeEventValues(&buf)
+		encoding.EncodeClosingTag(&buf, 12)
+	}
+	return buf.Bytes()
+}
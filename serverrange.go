@@ -0,0 +1,291 @@
+package bacnet
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// errorCodePropertyIsNotAList is returned for ReadRange requests on properties that are not lists
+// or arrays.
+const errorCodePropertyIsNotAList = 22
+
+// Range kinds of a ReadRange request, named after their context tags.
+const (
+	rangeAll        = 0
+	rangeByPosition = 3
+	rangeBySequence = 6
+	rangeByTime     = 7
+)
+
+// readRangeHeaderSize bounds the size of a ReadRange-ACK without its items.
+const readRangeHeaderSize = 32
+
+// rangeRequest holds the parameters of a ReadRange request.
+type rangeRequest struct {
+	object     BACnetObject
+	propertyID uint32
+	arrayIndex *uint32
+	kind       uint8
+	reference  uint32
+	// referenceTime is the reference of by-time ranges
+	referenceTime time.Time
+	count         int32
+}
+
+// decodeRangeRequest decodes the parameters of a ReadRange request. Reference times are taken as
+// local time.
+func decodeRangeRequest(params []byte) (rangeRequest, error) {
+	var req rangeRequest
+	r := bytes.NewReader(params)
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+	if err != nil {
+		return req, err
+	}
+	req.object = BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	if req.propertyID, err = encoding.DecodeContextUnsigned(r, 1); err != nil {
+		return req, err
+	}
+	if r.Len() == 0 {
+		return req, nil
+	}
+	h, err := encoding.PeekTagHeader(r)
+	if err != nil {
+		return req, err
+	}
+	if h.IsContext(2) {
+		index, err := encoding.DecodeContextUnsigned(r, 2)
+		if err != nil {
+			return req, err
+		}
+		req.arrayIndex = &index
+		if r.Len() == 0 {
+			return req, nil
+		}
+		if h, err = encoding.PeekTagHeader(r); err != nil {
+			return req, err
+		}
+	}
+
+	switch {
+	case h.IsOpening(rangeByPosition), h.IsOpening(rangeBySequence):
+		req.kind = h.Number
+		encoding.DecodeTagHeader(r)
+		ref, err := decodeApplicationValue(r)
+		if err != nil {
+			return req, err
+		}
+		count, err := decodeApplicationValue(r)
+		if err != nil {
+			return req, err
+		}
+		reference, refOK := ref.(uint32)
+		n, countOK := count.(int32)
+		if !refOK || !countOK {
+			return req, errInvalidRange
+		}
+		req.reference, req.count = reference, n
+		return req, encoding.ExpectClosingTag(r, h.Number)
+	case h.IsOpening(rangeByTime):
+		req.kind = rangeByTime
+		encoding.DecodeTagHeader(r)
+		date, err := decodeApplicationValue(r)
+		if err != nil {
+			return req, err
+		}
+		tod, err := decodeApplicationValue(r)
+		if err != nil {
+			return req, err
+		}
+		count, err := decodeApplicationValue(r)
+		if err != nil {
+			return req, err
+		}
+		d, dateOK := date.(Date)
+		t, timeOK := tod.(Time)
+		n, countOK := count.(int32)
+		if !dateOK || !timeOK || !countOK {
+			return req, errInvalidRange
+		}
+		if req.referenceTime, err = (DateTime{Date: d, Time: t}).In(time.Local); err != nil {
+			return req, err
+		}
+		req.count = n
+		return req, encoding.ExpectClosingTag(r, rangeByTime)
+	}
+	return req, errInvalidRange
+}
+
+// errInvalidRange reports a malformed range of a ReadRange request.
+var errInvalidRange = errors.New("invalid range")
+
+func (s *Server) readRange(invokeID byte, params []byte) []byte {
+	req, err := decodeRangeRequest(params)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	if req.kind != rangeAll && req.count == 0 {
+		return []byte{APDU_REJECT, invokeID, byte(RejectReasonParameterOutOfRange)}
+	}
+	if buffer, ok := s.device.logBuffer(req.object, req.propertyID); ok && req.arrayIndex == nil {
+		return s.readLogRange(invokeID, req, buffer)
+	}
+
+	value, err := s.device.readProperty(req.object, req.propertyID, req.arrayIndex)
+	if err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_READ_RANGE, err)
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_READ_RANGE, &PropertyAccessError{Class: errorClassProperty, Code: errorCodePropertyIsNotAList})
+	}
+	if req.kind == rangeBySequence || req.kind == rangeByTime {
+		// Only log buffers have sequence numbers and timestamps
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_READ_RANGE, &PropertyAccessError{Class: errorClassServices, Code: errorCodeOptionalFunctionalityNotSupported})
+	}
+
+	first, last := 1, len(items)
+	if req.kind == rangeByPosition {
+		first, last = positionRange(len(items), req.reference, req.count)
+	}
+	encoded, first, err := encodeRange(first, last, req.count < 0, func(i int, buf *bytes.Buffer) error {
+		return encodeApplicationValue(buf, items[i-1])
+	})
+	if err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_READ_RANGE, err)
+	}
+	return readRangeACK(invokeID, req, encoded, first, len(items), max(last-first+1, 0) > len(encoded), nil)
+}
+
+// readLogRange answers a ReadRange of a trend log buffer.
+func (s *Server) readLogRange(invokeID byte, req rangeRequest, buffer logBuffer) []byte {
+	n := len(buffer.records)
+	first, last := 1, n
+	switch req.kind {
+	case rangeByPosition:
+		first, last = positionRange(n, req.reference, req.count)
+	case rangeBySequence:
+		index := int64(req.reference) - int64(buffer.firstSequence) + 1
+		first, last = 1, 0
+		if index >= 1 && index <= int64(n) {
+			first, last = positionRange(n, uint32(index), req.count)
+		}
+	case rangeByTime:
+		first, last = timeRange(buffer.records, req.referenceTime, req.count)
+	}
+
+	selected := max(last-first+1, 0)
+	encoded, firstEncoded, err := encodeRange(first, last, req.count < 0, func(i int, buf *bytes.Buffer) error {
+		return buffer.records[i-1].encode(buf)
+	})
+	if err != nil {
+		return errorAPDU(invokeID, SERVICE_CONFIRMED_READ_RANGE, err)
+	}
+	var firstSequence *uint32
+	if (req.kind == rangeBySequence || req.kind == rangeByTime) && len(encoded) > 0 {
+		sequence := buffer.firstSequence + uint32(firstEncoded-1)
+		firstSequence = &sequence
+	}
+	return readRangeACK(invokeID, req, encoded, firstEncoded, n, selected > len(encoded), firstSequence)
+}
+
+// encodeRange encodes the items first to last (1-based) of a range, as many as fit into an APDU,
+// starting from the reference item, which is the last one if backwards. It returns the encoded
+// items in order and the index of the first of them.
+func encodeRange(first, last int, backwards bool, encode func(i int, buf *bytes.Buffer) error) ([][]byte, int, error) {
+	selected := max(last-first+1, 0)
+	encoded := make([][]byte, 0, selected)
+	size := 0
+	for n := 0; n < selected; n++ {
+		i := first + n
+		if backwards {
+			i = last - n
+		}
+		var item bytes.Buffer
+		if err := encode(i, &item); err != nil {
+			return nil, 0, err
+		}
+		if size+item.Len() > serverMaxAPDU-readRangeHeaderSize {
+			break
+		}
+		size += item.Len()
+		encoded = append(encoded, item.Bytes())
+	}
+	if backwards {
+		slices.Reverse(encoded)
+		return encoded, last - len(encoded) + 1, nil
+	}
+	return encoded, first, nil
+}
+
+// readRangeACK builds a ReadRange-ACK carrying the encoded items, the first of which has index
+// first in a list of total items.
+func readRangeACK(invokeID byte, req rangeRequest, items [][]byte, first, total int, more bool, firstSequence *uint32) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{APDU_COMPLEX_ACK, invokeID, SERVICE_CONFIRMED_READ_RANGE})
+	encoding.EncodeContextObjectID(&buf, 0, uint32(req.object.Type), req.object.Instance)
+	encoding.EncodeContextUnsigned(&buf, 1, req.propertyID)
+	if req.arrayIndex != nil {
+		encoding.EncodeContextUnsigned(&buf, 2, *req.arrayIndex)
+	}
+	found := len(items) > 0
+	encodeResultFlags(&buf, found && first == 1, found && first+len(items)-1 == total, more)
+	encoding.EncodeContextUnsigned(&buf, 4, uint32(len(items)))
+	encoding.EncodeOpeningTag(&buf, 5)
+	for _, item := range items {
+		buf.Write(item)
+	}
+	encoding.EncodeClosingTag(&buf, 5)
+	if firstSequence != nil {
+		encoding.EncodeContextUnsigned(&buf, 6, *firstSequence)
+	}
+	return buf.Bytes()
+}
+
+// positionRange returns the 1-based indexes of the first and last item of a by-position range
+// over n items; last < first if the range is empty.
+func positionRange(n int, reference uint32, count int32) (first, last int) {
+	index := int(reference)
+	if index < 1 || index > n {
+		return 1, 0
+	}
+	if count > 0 {
+		return index, min(index+int(count)-1, n)
+	}
+	return max(index+int(count)+1, 1), index
+}
+
+// timeRange returns the 1-based indexes of the first and last record of a by-time range: the
+// count records after the reference time, or before it for a negative count.
+func timeRange(records []LogRecord, reference time.Time, count int32) (first, last int) {
+	if count > 0 {
+		for i, record := range records {
+			if record.Timestamp.After(reference) {
+				return i + 1, min(i+int(count), len(records))
+			}
+		}
+		return 1, 0
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Timestamp.Before(reference) {
+			return max(i+int(count)+2, 1), i + 1
+		}
+	}
+	return 1, 0
+}
+
+// encodeResultFlags writes the BACnetResultFlags of a ReadRange-ACK with context tag 3.
+func encodeResultFlags(buf *bytes.Buffer, firstItem, lastItem, moreItems bool) {
+	var flags byte
+	for i, set := range []bool{firstItem, lastItem, moreItems} {
+		if set {
+			flags |= 0x80 >> i
+		}
+	}
+	encoding.EncodeTag(buf, 3, true, 2)
+	buf.WriteByte(5) // Unused bits
+	buf.WriteByte(flags)
+}
@@ -0,0 +1,287 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// scheduleInterval is how often a LocalSchedule evaluates its schedule.
+const scheduleInterval = time.Second
+
+// LocalCalendar is a Calendar object of a VirtualDevice. Its Present_Value is true on the days its
+// Date_List matches; LocalSchedules of the same device use it to resolve their special events.
+type LocalCalendar struct {
+	object BACnetObject
+	name   string
+
+	mu      sync.Mutex
+	entries []CalendarEntry
+}
+
+// NewLocalCalendar returns a calendar with the given instance number and Date_List.
+func NewLocalCalendar(instance uint32, name string, entries []CalendarEntry) *LocalCalendar {
+	c := &LocalCalendar{object: BACnetObject{Type: OBJECT_CALENDAR, Instance: instance}, name: name}
+	c.SetDateList(entries)
+	return c
+}
+
+// AddTo adds the Calendar object to device.
+func (c *LocalCalendar) AddTo(device *VirtualDevice) error {
+	return device.AddObject(&VirtualObject{Object: c.object, Name: c.name, Dynamic: c.properties})
+}
+
+// SetDateList replaces the Date_List of the calendar.
+func (c *LocalCalendar) SetDateList(entries []CalendarEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append([]CalendarEntry(nil), entries...)
+}
+
+// DateList returns the Date_List of the calendar.
+func (c *LocalCalendar) DateList() []CalendarEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CalendarEntry(nil), c.entries...)
+}
+
+func (c *LocalCalendar) properties() map[uint32]interface{} {
+	entries := c.DateList()
+	return map[uint32]interface{}{
+		PROP_PRESENT_VALUE: CalendarActive(entries, time.Now()),
+		PROP_DATE_LIST:     entries,
+	}
+}
+
+// ScheduleTarget is a property a LocalSchedule writes its value to.
+type ScheduleTarget struct {
+	Object     BACnetObject
+	PropertyID uint32
+	// Device is the remote device holding the object, or nil for an object of the local device.
+	Device *DeviceInfo
+}
+
+// ScheduleWrite reports a write of a LocalSchedule to one of its targets. Evaluation errors, such
+// as a special event referring to a calendar the device does not hold, are reported with a zero
+// Target.
+type ScheduleWrite struct {
+	Target ScheduleTarget
+	Value  interface{}
+	Time   time.Time
+	Err    error
+}
+
+// LocalScheduleOptions configures a LocalSchedule.
+type LocalScheduleOptions struct {
+	Schedule Schedule
+	Targets  []ScheduleTarget
+	// Priority is the Priority_For_Writing, 1 to 16; zero means 16.
+	Priority uint8
+	// Client writes to targets on remote devices; it is required if any target has a Device.
+	Client *BACnetClient
+}
+
+// LocalSchedule is a Schedule object of a VirtualDevice that drives its targets: Run evaluates the
+// schedule and writes the value to every target whenever it changes. Special events may refer to
+// LocalCalendars of the same device. While the schedule is out of service it writes nothing.
+type LocalSchedule struct {
+	object   BACnetObject
+	name     string
+	targets  []ScheduleTarget
+	priority uint8
+	client   *BACnetClient
+
+	mu        sync.Mutex
+	device    *VirtualDevice
+	schedule  Schedule
+	value     interface{}
+	evaluated bool
+	// pending marks targets whose write of value is outstanding
+	pending []bool
+}
+
+// NewLocalSchedule returns a schedule with the given instance number.
+func NewLocalSchedule(instance uint32, name string, options LocalScheduleOptions) (*LocalSchedule, error) {
+	priority := options.Priority
+	if priority == 0 {
+		priority = 16
+	}
+	if priority > 16 {
+		return nil, fmt.Errorf("invalid priority for writing %d", options.Priority)
+	}
+	for _, target := range options.Targets {
+		if target.Device != nil && options.Client == nil {
+			return nil, fmt.Errorf("schedule target %s on device %d needs a client", target.Object, target.Device.DeviceID)
+		}
+	}
+	return &LocalSchedule{
+		object:   BACnetObject{Type: OBJECT_SCHEDULE, Instance: instance},
+		name:     name,
+		targets:  append([]ScheduleTarget(nil), options.Targets...),
+		priority: priority,
+		client:   options.Client,
+		schedule: options.Schedule,
+		pending:  make([]bool, len(options.Targets)),
+	}, nil
+}
+
+// AddTo adds the Schedule object to device, which must also hold its local targets and calendars.
+func (s *LocalSchedule) AddTo(device *VirtualDevice) error {
+	s.mu.Lock()
+	s.device = device
+	s.mu.Unlock()
+	refs := make([]DeviceObjectPropertyReference, len(s.targets))
+	for i, target := range s.targets {
+		refs[i] = DeviceObjectPropertyReference{Object: target.Object, PropertyID: target.PropertyID}
+		if target.Device != nil {
+			refs[i].Device = &BACnetObject{Type: OBJECT_DEVICE, Instance: target.Device.DeviceID}
+		}
+	}
+	return device.AddObject(&VirtualObject{
+		Object: s.object,
+		Name:   s.name,
+		Properties: map[uint32]interface{}{
+			PROP_LIST_OF_OBJECT_PROPERTY_REFERENCES: refs,
+			PROP_PRIORITY_FOR_WRITING:               uint32(s.priority),
+			PROP_RELIABILITY:                        Enumerated(0), // No fault detected
+		},
+		Dynamic: s.properties,
+	})
+}
+
+// SetSchedule replaces the schedule; Run applies it at its next evaluation.
+func (s *LocalSchedule) SetSchedule(schedule Schedule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedule = schedule
+}
+
+// Value returns the current value of the schedule, nil before its first evaluation.
+func (s *LocalSchedule) Value() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value
+}
+
+// Run evaluates the schedule every second until ctx is cancelled, writing the value to all targets
+// when it changes and retrying failed writes at the next evaluation. onWrite, if not nil, is
+// called for every write and evaluation error. The schedule must have been added to a device.
+func (s *LocalSchedule) Run(ctx context.Context, onWrite func(ScheduleWrite)) {
+	if onWrite == nil {
+		onWrite = func(ScheduleWrite) {}
+	}
+	s.evaluate(ctx, time.Now(), onWrite)
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.evaluate(ctx, now, onWrite)
+		}
+	}
+}
+
+// evaluate computes the value of the schedule at now and performs the outstanding writes.
+func (s *LocalSchedule) evaluate(ctx context.Context, now time.Time, onWrite func(ScheduleWrite)) {
+	s.mu.Lock()
+	device, schedule := s.device, s.schedule
+	s.mu.Unlock()
+	if device == nil {
+		return
+	}
+	if oos, _ := device.readProperty(s.object, PROP_OUT_OF_SERVICE, nil); oos == true {
+		return
+	}
+
+	value, err := schedule.ValueAt(now, s.calendars(device, schedule))
+	if err != nil {
+		onWrite(ScheduleWrite{Time: now, Err: err})
+		return
+	}
+	s.mu.Lock()
+	changed := !s.evaluated || !ValuesEqual(s.value, value)
+	if changed {
+		s.value, s.evaluated = value, true
+		for i := range s.pending {
+			s.pending[i] = true
+		}
+	}
+	pending := append([]bool(nil), s.pending...)
+	s.mu.Unlock()
+	if changed {
+		device.changed(s.object)
+	}
+
+	for i, target := range s.targets {
+		if !pending[i] || ctx.Err() != nil {
+			continue
+		}
+		err := s.write(device, target, value)
+		if err == nil {
+			s.mu.Lock()
+			if ValuesEqual(s.value, value) {
+				s.pending[i] = false
+			}
+			s.mu.Unlock()
+		}
+		onWrite(ScheduleWrite{Target: target, Value: value, Time: now, Err: err})
+	}
+}
+
+// calendars returns the Date_List of the calendars of the device the special events refer to.
+// Calendars the device does not hold are left out, failing the evaluation.
+func (s *LocalSchedule) calendars(device *VirtualDevice, schedule Schedule) map[BACnetObject][]CalendarEntry {
+	calendars := make(map[BACnetObject][]CalendarEntry)
+	for _, event := range schedule.Exceptions {
+		if event.Calendar == nil {
+			continue
+		}
+		value, err := device.readProperty(*event.Calendar, PROP_DATE_LIST, nil)
+		if entries, ok := value.([]CalendarEntry); err == nil && ok {
+			calendars[*event.Calendar] = entries
+		}
+	}
+	return calendars
+}
+
+// write commands a target. A nil value relinquishes the command at the schedule's priority.
+func (s *LocalSchedule) write(device *VirtualDevice, target ScheduleTarget, value interface{}) error {
+	if target.Device != nil {
+		return s.client.WriteProperty(*target.Device, target.Object, target.PropertyID, value, s.priority)
+	}
+	return device.writeProperty(target.Object, target.PropertyID, value, s.priority)
+}
+
+func (s *LocalSchedule) properties() map[uint32]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	period := DateRange{
+		Start: Date{Year: Unspecified, Month: Unspecified, Day: Unspecified, Weekday: Unspecified},
+		End:   Date{Year: Unspecified, Month: Unspecified, Day: Unspecified, Weekday: Unspecified},
+	}
+	if s.schedule.EffectivePeriod != nil {
+		period = *s.schedule.EffectivePeriod
+	}
+	weekly := make([]interface{}, len(s.schedule.Weekly))
+	for i, day := range s.schedule.Weekly {
+		weekly[i] = dailySchedule(day)
+	}
+	exceptions := make([]interface{}, len(s.schedule.Exceptions))
+	for i, event := range s.schedule.Exceptions {
+		exceptions[i] = event
+	}
+	value := s.value
+	if !s.evaluated {
+		value = s.schedule.Default
+	}
+	return map[uint32]interface{}{
+		PROP_PRESENT_VALUE:      value,
+		PROP_EFFECTIVE_PERIOD:   period,
+		PROP_WEEKLY_SCHEDULE:    weekly,
+		PROP_EXCEPTION_SCHEDULE: exceptions,
+		PROP_SCHEDULE_DEFAULT:   s.schedule.Default,
+	}
+}
@@ -0,0 +1,256 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// errorCodeReadAccessDenied is returned for ReadProperty requests of a Log_Buffer, which can only
+// be read with ReadRange.
+const errorCodeReadAccessDenied = 27
+
+// LogRecord is a record of a trend log or event log buffer.
+type LogRecord struct {
+	Timestamp time.Time
+	// Value is the logged value: nil, bool, float32, Enumerated, BinaryPV, uint32, int32, a bit
+	// string as []bool, or the *PropertyAccessError of a failed read. Records of changes of the log
+	// itself hold a LogStatus or LogTimeChange. Records of event logs hold the encoded parameters
+	// of the logged ConfirmedEventNotification as []byte.
+	Value interface{}
+	// StatusFlags are the Status_Flags of the logged object, if known.
+	StatusFlags *StatusFlags
+}
+
+// LogStatus is the value of a log record reporting a change of the log's own state.
+type LogStatus struct {
+	LogDisabled    bool
+	BufferPurged   bool
+	LogInterrupted bool
+}
+
+// LogTimeChange is the value of a log record reporting a change of the clock, in seconds.
+type LogTimeChange float32
+
+// encode writes the record as a BACnetLogRecord.
+func (r LogRecord) encode(buf *bytes.Buffer) error {
+	dt := NewDateTime(r.Timestamp)
+	encoding.EncodeOpeningTag(buf, 0)
+	encodeApplicationValue(buf, dt)
+	encoding.EncodeClosingTag(buf, 0)
+
+	encoding.EncodeOpeningTag(buf, 1)
+	switch v := r.Value.(type) {
+	case nil:
+		encoding.EncodeTag(buf, 7, true, 0)
+	case bool:
+		encoding.EncodeContextBoolean(buf, 1, v)
+	case float32:
+		encoding.EncodeContextReal(buf, 2, v)
+	case Enumerated:
+		encoding.EncodeContextEnumerated(buf, 3, uint32(v))
+	case BinaryPV:
+		encoding.EncodeContextEnumerated(buf, 3, uint32(v))
+	case uint32:
+		encoding.EncodeContextUnsigned(buf, 4, v)
+	case int32:
+		encoding.EncodeContextSigned(buf, 5, v)
+	case LogStatus:
+		encodeContextBits(buf, 0, []bool{v.LogDisabled, v.BufferPurged, v.LogInterrupted})
+	case []bool:
+		encodeContextBits(buf, 6, v)
+	case *PropertyAccessError:
+		encoding.EncodeOpeningTag(buf, 8)
+		encoding.EncodeApplicationEnumerated(buf, v.Class)
+		encoding.EncodeApplicationEnumerated(buf, v.Code)
+		encoding.EncodeClosingTag(buf, 8)
+	case LogTimeChange:
+		encoding.EncodeContextReal(buf, 9, float32(v))
+	default:
+		return fmt.Errorf("cannot log value %v of type %T", r.Value, r.Value)
+	}
+	encoding.EncodeClosingTag(buf, 1)
+
+	if r.StatusFlags != nil {
+		encodeContextStatusFlags(buf, 2, *r.StatusFlags)
+	}
+	return nil
+}
+
+// logBuffer is the value of a Log_Buffer property: the records, oldest first, and the sequence
+// number of the first.
+type logBuffer struct {
+	records       []LogRecord
+	firstSequence uint32
+}
+
+// TrendLog is a Trend Log object of a VirtualDevice. It samples a property of another object of
+// the device every interval into a ring buffer, which clients read with ReadRange. Clients can
+// write Enable, and write Record_Count to zero to clear the buffer.
+type TrendLog struct {
+	object     BACnetObject
+	name       string
+	source     BACnetObject
+	propertyID uint32
+	interval   time.Duration
+	size       int
+
+	mu      sync.Mutex
+	device  *VirtualDevice
+	enabled bool
+	records []LogRecord // Ring buffer, oldest record at start once full
+	start   int
+	total   uint32 // Total_Record_Count
+}
+
+// NewTrendLog returns an enabled trend log with the given instance number that logs propertyID of
+// source every interval, keeping the last bufferSize records.
+func NewTrendLog(instance uint32, name string, source BACnetObject, propertyID uint32, interval time.Duration, bufferSize int) (*TrendLog, error) {
+	if interval <= 0 || bufferSize <= 0 {
+		return nil, fmt.Errorf("trend log needs a positive interval and buffer size")
+	}
+	return &TrendLog{
+		object:     BACnetObject{Type: OBJECT_TREND_LOG, Instance: instance},
+		name:       name,
+		source:     source,
+		propertyID: propertyID,
+		interval:   interval,
+		size:       bufferSize,
+		enabled:    true,
+		records:    make([]LogRecord, 0, bufferSize),
+	}, nil
+}
+
+// AddTo adds the Trend Log object to device, which must also hold the logged object.
+func (t *TrendLog) AddTo(device *VirtualDevice) error {
+	t.mu.Lock()
+	t.device = device
+	t.mu.Unlock()
+	return device.AddObject(&VirtualObject{
+		Object: t.object,
+		Name:   t.name,
+		Properties: map[uint32]interface{}{
+			PROP_LOG_DEVICE_OBJECT_PROPERTY: DeviceObjectPropertyReference{Object: t.source, PropertyID: t.propertyID},
+			PROP_LOG_INTERVAL:               uint32(t.interval / (10 * time.Millisecond)), // Hundredths of a second
+			PROP_BUFFER_SIZE:                uint32(t.size),
+			PROP_STOP_WHEN_FULL:             false,
+			PROP_LOGGING_TYPE:               Enumerated(0), // Polled
+		},
+		Dynamic: t.properties,
+		Write:   t.write,
+	})
+}
+
+// Run samples the logged property every interval until ctx is cancelled. The trend log must have
+// been added to a device.
+func (t *TrendLog) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			t.sample(now)
+		}
+	}
+}
+
+// sample records the current value of the logged property; failed reads are skipped.
+func (t *TrendLog) sample(now time.Time) {
+	t.mu.Lock()
+	device := t.device
+	t.mu.Unlock()
+	if device == nil {
+		return
+	}
+	value, err := device.readProperty(t.source, t.propertyID, nil)
+	if err != nil {
+		return
+	}
+	var flags *StatusFlags
+	if f, err := device.readProperty(t.source, PROP_STATUS_FLAGS, nil); err == nil {
+		if f, ok := f.(StatusFlags); ok {
+			flags = &f
+		}
+	}
+	t.Record(LogRecord{Timestamp: now, Value: value, StatusFlags: flags})
+}
+
+// Record adds a record to the buffer unless the log is disabled, overwriting the oldest record
+// once the buffer is full. Values of type float64 are stored as float32.
+func (t *TrendLog) Record(record LogRecord) {
+	if f, ok := record.Value.(float64); ok {
+		record.Value = float32(f)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.enabled {
+		return
+	}
+	if len(t.records) < t.size {
+		t.records = append(t.records, record)
+	} else {
+		t.records[t.start] = record
+		t.start = (t.start + 1) % t.size
+	}
+	t.total++
+	if t.total == 0 {
+		t.total = 1 // Total_Record_Count wraps to 1
+	}
+}
+
+// Records returns the records in the buffer, oldest first.
+func (t *TrendLog) Records() []LogRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.orderedRecords()
+}
+
+// orderedRecords returns a copy of the buffer, oldest first. The caller must hold t.mu.
+func (t *TrendLog) orderedRecords() []LogRecord {
+	records := make([]LogRecord, 0, len(t.records))
+	records = append(records, t.records[t.start:]...)
+	return append(records, t.records[:t.start]...)
+}
+
+// properties returns the properties of the Trend Log object that change.
+func (t *TrendLog) properties() map[uint32]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	records := t.orderedRecords()
+	return map[uint32]interface{}{
+		PROP_ENABLE:             t.enabled,
+		PROP_RECORD_COUNT:       uint32(len(records)),
+		PROP_TOTAL_RECORD_COUNT: t.total,
+		PROP_LOG_BUFFER: logBuffer{
+			records:       records,
+			firstSequence: t.total - uint32(len(records)) + 1,
+		},
+	}
+}
+
+func (t *TrendLog) write(propertyID uint32, value interface{}, _ uint8) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch propertyID {
+	case PROP_ENABLE:
+		enabled, ok := value.(bool)
+		if !ok {
+			return &PropertyAccessError{Class: errorClassProperty, Code: errorCodeInvalidDataType}
+		}
+		t.enabled = enabled
+	case PROP_RECORD_COUNT:
+		if count, ok := value.(uint32); !ok || count != 0 {
+			return &PropertyAccessError{Class: errorClassProperty, Code: errorCodeValueOutOfRange}
+		}
+		t.records, t.start = t.records[:0], 0
+	default:
+		return &PropertyAccessError{Class: errorClassProperty, Code: errorCodeWriteAccessDenied}
+	}
+	return nil
+}
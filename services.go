@@ -0,0 +1,137 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+//go:generate go run ./cmd/servicegen -in services.json -out services_gen.go
+//go:generate go run ./cmd/contextgen -out context_gen.go
+
+// Services are the confirmed services BACnetClient initiates, one method per service. Code that
+// only talks to devices can depend on Services instead of *BACnetClient, e.g. to run against a fake
+// in tests or to wrap every call.
+//
+// Services described in services.json are generated by cmd/servicegen: a request struct, an ack
+// struct unless the service is answered with a Simple-ACK, and the client method. Adding a
+// standard service whose parameters the generator can describe takes an entry in the table and
+// go generate.
+type Services interface {
+	ReadProperty(device DeviceInfo, object BACnetObject, propertyID uint32, arrayIndex *uint32, opts ...CallOption) (interface{}, error)
+	ReadPropertyMultipleStream(device DeviceInfo, objects []BACnetObject, propertyIDs []uint32, fn func(RPMResult) bool, opts ...CallOption) error
+	WriteProperty(device DeviceInfo, object BACnetObject, propertyID uint32, value interface{}, priority uint8, opts ...CallOption) error
+	WritePropertyMultiple(device DeviceInfo, writes []PropertyWrite, opts ...CallOption) error
+	ReadRange(device DeviceInfo, object BACnetObject, propertyID uint32, rng Range, opts ...CallOption) (RangeResult, error)
+	CreateObject(device DeviceInfo, objectType ObjectType, instance *uint32, initialValues []BACnetPropertyValue, opts ...CallOption) (BACnetObject, error)
+	DeleteObject(device DeviceInfo, object BACnetObject, opts ...CallOption) error
+	UnsubscribeCOV(device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, opts ...CallOption) error
+	DeviceCommunicationControl(device DeviceInfo, control CommunicationControl, password string, opts ...CallOption) error
+	ReinitializeDevice(device DeviceInfo, state ReinitializeState, password string, opts ...CallOption) error
+	SendTextMessage(device DeviceInfo, message TextMessage, opts ...CallOption) error
+	GetEventInformation(device DeviceInfo, opts ...CallOption) ([]EventSummary, error)
+	AcknowledgeAlarm(device DeviceInfo, ack AlarmAcknowledgment, opts ...CallOption) error
+	GetAlarmSummary(device DeviceInfo, request GetAlarmSummaryRequest, opts ...CallOption) (GetAlarmSummaryAck, error)
+	LifeSafetyOperation(device DeviceInfo, request LifeSafetyOperationRequest, opts ...CallOption) error
+}
+
+var _ Services = (*BACnetClient)(nil)
+
+// serviceMessage is a generated request or ack.
+type serviceMessage interface {
+	encode(buf *bytes.Buffer)
+	decode(r *bytes.Reader) error
+}
+
+// confirmedService sends the request of a generated service and decodes the response into ack,
+// or expects a Simple-ACK if ack is nil. Mutations are authorized first.
+func (c *BACnetClient) confirmedService(ctx context.Context, device DeviceInfo, service byte, mutation bool, request, ack serviceMessage) error {
+	name := TransactionInfo{Service: service}.ServiceName()
+	if mutation {
+		if send, err := c.authorize(ctx, Mutation{Service: service, Device: device, Value: request}); !send {
+			return err
+		}
+	}
+	var params bytes.Buffer
+	request.encode(&params)
+
+	invokeID, data, err := c.transact(ctx, device, service, params.Bytes())
+	if err != nil {
+		return err
+	}
+	if ack == nil {
+		if err := expectSimpleAck(data); err != nil {
+			return fmt.Errorf("%s of device %d failed: %w", name, device.DeviceID, err)
+		}
+		return nil
+	}
+	apdu, err := apduFromPacket(data)
+	if err != nil {
+		return err
+	}
+	header, serviceData, err := decodeComplexAck(apdu)
+	if err != nil {
+		return fmt.Errorf("%s of device %d failed: %w", name, device.DeviceID, err)
+	}
+	if header.invokeID != invokeID || header.service != service {
+		return fmt.Errorf("unexpected response to %s: invoke ID %d, service 0x%x", name, header.invokeID, header.service)
+	}
+	if header.segmented {
+		return fmt.Errorf("segmented %s responses are not supported", name)
+	}
+	if err := ack.decode(bytes.NewReader(serviceData)); err != nil {
+		return fmt.Errorf("failed to decode %s-ACK: %w", name, err)
+	}
+	return nil
+}
+
+// Helpers of the generated encoders and decoders.
+
+// hasContextTag reports whether the next tag is the context tag tagNumber, primitive or opening.
+func hasContextTag(r *bytes.Reader, tagNumber uint8) bool {
+	h, err := encoding.PeekTagHeader(r)
+	return err == nil && h.Context && h.Number == tagNumber && !h.Closing
+}
+
+// atClosingTag reports whether the next tag is the closing tag tagNumber, or the data ended.
+func atClosingTag(r *bytes.Reader, tagNumber uint8) bool {
+	h, err := encoding.PeekTagHeader(r)
+	return err != nil || h.IsClosing(tagNumber)
+}
+
+// decodeApplicationAs decodes an application-tagged value that must have the given tag and Go
+// type.
+func decodeApplicationAs[T any](r *bytes.Reader, tagNumber uint8) (T, error) {
+	var zero T
+	h, err := encoding.PeekTagHeader(r)
+	if err != nil {
+		return zero, err
+	}
+	if h.Context || h.Number != tagNumber {
+		return zero, fmt.Errorf("expected application tag %d, got tag %d", tagNumber, h.Number)
+	}
+	value, err := decodeApplicationValue(r)
+	if err != nil {
+		return zero, err
+	}
+	v, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("expected %T, got %T", zero, value)
+	}
+	return v, nil
+}
+
+func decodeContextObject(r *bytes.Reader, tagNumber uint8) (BACnetObject, error) {
+	objectType, instance, err := encoding.DecodeContextObjectID(r, tagNumber)
+	return BACnetObject{Type: ObjectType(objectType), Instance: instance}, err
+}
+
+// encodeContextTimeStamp encodes t as a date-time BACnetTimeStamp enclosed in a context tag.
+func encodeContextTimeStamp(buf *bytes.Buffer, tagNumber uint8, t time.Time) {
+	encoding.EncodeOpeningTag(buf, tagNumber)
+	encodeDateTimeStamp(buf, t)
+	encoding.EncodeClosingTag(buf, tagNumber)
+}
@@ -0,0 +1,148 @@
+// Code generated by servicegen from services.json; DO NOT EDIT.
+
+package bacnet
+
+import (
+	"bytes"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// AlarmSummary is an object in alarm as reported by GetAlarmSummary.
+type AlarmSummary struct {
+	Object BACnetObject `json:"object"`
+	State  EventState   `json:"state"`
+	// AckedTransitions are indexed by transition: to-offnormal, to-fault and to-normal.
+	AckedTransitions []bool `json:"ackedTransitions"`
+}
+
+func (m *AlarmSummary) encode(buf *bytes.Buffer) {
+	encoding.EncodeApplicationObjectID(buf, uint32(m.Object.Type), m.Object.Instance)
+	encoding.EncodeApplicationEnumerated(buf, uint32(m.State))
+	encoding.EncodeApplicationBitString(buf, m.AckedTransitions)
+}
+
+func (m *AlarmSummary) decode(r *bytes.Reader) error {
+	{
+		v, err := decodeApplicationAs[BACnetObject](r, encoding.TagObjectID)
+		if err != nil {
+			return err
+		}
+		m.Object = v
+	}
+	{
+		raw, err := decodeApplicationAs[uint32](r, encoding.TagEnumerated)
+		if err != nil {
+			return err
+		}
+		v := EventState(raw)
+		m.State = v
+	}
+	{
+		v, err := decodeApplicationBits(r)
+		if err != nil {
+			return err
+		}
+		m.AckedTransitions = v
+	}
+	return nil
+}
+
+// GetAlarmSummaryRequest is the request of the GetAlarmSummary service.
+type GetAlarmSummaryRequest struct {
+}
+
+func (m *GetAlarmSummaryRequest) encode(buf *bytes.Buffer) {
+}
+
+func (m *GetAlarmSummaryRequest) decode(r *bytes.Reader) error {
+	return nil
+}
+
+// GetAlarmSummaryAck is the acknowledgment of the GetAlarmSummary service.
+type GetAlarmSummaryAck struct {
+	Summaries []AlarmSummary `json:"summaries,omitempty"`
+}
+
+func (m *GetAlarmSummaryAck) encode(buf *bytes.Buffer) {
+	for _, e := range m.Summaries {
+		e.encode(buf)
+	}
+}
+
+func (m *GetAlarmSummaryAck) decode(r *bytes.Reader) error {
+	for r.Len() > 0 {
+		var e AlarmSummary
+		if err := e.decode(r); err != nil {
+			return err
+		}
+		m.Summaries = append(m.Summaries, e)
+	}
+	return nil
+}
+
+// GetAlarmSummary returns the objects of a device in an alarm state whose notification class uses
+// acknowledged alarms. Newer devices implement GetEventInformation instead.
+func (c *BACnetClient) GetAlarmSummary(device DeviceInfo, request GetAlarmSummaryRequest, opts ...CallOption) (GetAlarmSummaryAck, error) {
+	var ack GetAlarmSummaryAck
+	err := c.confirmedService(callContext(opts...), device, SERVICE_CONFIRMED_GET_ALARM_SUMMARY, false, &request, &ack)
+	return ack, err
+}
+
+// LifeSafetyOperationRequest is the request of the LifeSafetyOperation service.
+type LifeSafetyOperationRequest struct {
+	// ProcessID identifies the requesting process.
+	ProcessID uint32 `json:"processId"`
+	// Source identifies the requesting operator.
+	Source    string              `json:"source"`
+	Operation LifeSafetyOperation `json:"operation"`
+	Object    *BACnetObject       `json:"object,omitempty"`
+}
+
+func (m *LifeSafetyOperationRequest) encode(buf *bytes.Buffer) {
+	encoding.EncodeContextUnsigned(buf, 0, m.ProcessID)
+	encoding.EncodeContextCharacterString(buf, 1, m.Source)
+	encoding.EncodeContextEnumerated(buf, 2, uint32(m.Operation))
+	if m.Object != nil {
+		encoding.EncodeContextObjectID(buf, 3, uint32((*m.Object).Type), (*m.Object).Instance)
+	}
+}
+
+func (m *LifeSafetyOperationRequest) decode(r *bytes.Reader) error {
+	{
+		v, err := encoding.DecodeContextUnsigned(r, 0)
+		if err != nil {
+			return err
+		}
+		m.ProcessID = v
+	}
+	{
+		v, err := encoding.DecodeContextCharacterString(r, 1)
+		if err != nil {
+			return err
+		}
+		m.Source = v
+	}
+	{
+		raw, err := encoding.DecodeContextUnsigned(r, 2)
+		if err != nil {
+			return err
+		}
+		v := LifeSafetyOperation(raw)
+		m.Operation = v
+	}
+	if hasContextTag(r, 3) {
+		v, err := decodeContextObject(r, 3)
+		if err != nil {
+			return err
+		}
+		m.Object = &v
+	}
+	return nil
+}
+
+// LifeSafetyOperation requests an operation, e.g. silencing or resetting, of a life safety object,
+// or of all life safety objects of the device if no object is given.
+func (c *BACnetClient) LifeSafetyOperation(device DeviceInfo, request LifeSafetyOperationRequest, opts ...CallOption) error {
+	return c.confirmedService(callContext(opts...), device, SERVICE_CONFIRMED_LIFE_SAFETY_OPERATION, true, &request, nil)
+}
@@ -0,0 +1,219 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// unitsDegreesCelsius is the engineering unit of the simulated analog values.
+const unitsDegreesCelsius = 62
+
+// SimulatorOptions configures a Simulator.
+type SimulatorOptions struct {
+	DeviceID uint32
+	Name     string
+	// AnalogValues and BinaryValues are the number of writable Analog Value and Binary Value objects,
+	// numbered from 1.
+	AnalogValues int
+	BinaryValues int
+	// ChangeInterval is how often Run changes the value of a random object and notifies COV
+	// subscribers. Zero leaves values alone unless they are written.
+	ChangeInterval time.Duration
+	// Seed seeds the random value changes.
+	Seed int64
+	// Generators drive the Present_Value of objects while Run is running; see also SetGenerator.
+	// They are evaluated every GeneratorInterval, by default every second.
+	Generators        map[BACnetObject]ValueGenerator
+	GeneratorInterval time.Duration
+}
+
+// Simulator is a virtual BACnet device with changing values, for exercising clients without
+// real hardware. Values change at random, follow ValueGenerators or are set explicitly, and
+// InjectFault simulates failed sensors and alarms.
+type Simulator struct {
+	Device *VirtualDevice
+	Server *Server
+
+	options    SimulatorOptions
+	mu         sync.Mutex
+	values     map[BACnetObject]interface{}
+	generators map[BACnetObject]ValueGenerator
+	faults     map[BACnetObject]Fault
+	rand       *rand.Rand
+}
+
+// NewSimulator returns a simulator serving on conn.
+func NewSimulator(conn *net.UDPConn, options SimulatorOptions) *Simulator {
+	name := options.Name
+	if name == "" {
+		name = fmt.Sprintf("Simulator %d", options.DeviceID)
+	}
+	s := &Simulator{
+		Device:     NewVirtualDevice(options.DeviceID, name),
+		options:    options,
+		values:     make(map[BACnetObject]interface{}),
+		generators: make(map[BACnetObject]ValueGenerator),
+		faults:     make(map[BACnetObject]Fault),
+		rand:       rand.New(rand.NewSource(options.Seed)),
+	}
+	for object, generator := range options.Generators {
+		s.generators[object] = generator
+	}
+	s.Server = NewServer(conn, s.Device)
+
+	for i := 1; i <= options.AnalogValues; i++ {
+		object := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: uint32(i)}
+		s.values[object] = float32(20 + i%10)
+		s.addObject(object, fmt.Sprintf("AV-%d", i), map[uint32]interface{}{PROP_UNITS: Enumerated(unitsDegreesCelsius)})
+	}
+	for i := 1; i <= options.BinaryValues; i++ {
+		object := BACnetObject{Type: OBJECT_BINARY_VALUE, Instance: uint32(i)}
+		s.values[object] = BinaryInactive
+		s.addObject(object, fmt.Sprintf("BV-%d", i), map[uint32]interface{}{PROP_POLARITY: PolarityNormal})
+	}
+	return s
+}
+
+func (s *Simulator) addObject(object BACnetObject, name string, properties map[uint32]interface{}) {
+	s.Device.AddObject(&VirtualObject{
+		Object:     object,
+		Name:       name,
+		Properties: properties,
+		PresentValue: func() (interface{}, error) {
+			return s.Value(object), nil
+		},
+		Dynamic: func() map[uint32]interface{} {
+			return s.faultProperties(object)
+		},
+		Write: func(propertyID uint32, value interface{}, _ uint8) error {
+			if propertyID != PROP_PRESENT_VALUE {
+				return &PropertyAccessError{Class: errorClassProperty, Code: errorCodeWriteAccessDenied}
+			}
+			return s.set(object, value)
+		},
+	})
+}
+
+// Value returns the Present_Value of an object, or nil if the simulator has no such object.
+func (s *Simulator) Value(object BACnetObject) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[object]
+}
+
+// Set changes the Present_Value of an object and notifies its COV subscribers.
+func (s *Simulator) Set(object BACnetObject, value interface{}) error {
+	if err := s.set(object, value); err != nil {
+		return err
+	}
+	s.Server.NotifyChange(object)
+	return nil
+}
+
+// set stores a value converted to the type of the object's Present_Value, unless the object is
+// stuck.
+func (s *Simulator) set(object BACnetObject, value interface{}) error {
+	converted, err := s.convert(object, value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[object]; !ok {
+		return &PropertyAccessError{Class: errorClassObject, Code: errorCodeUnknownObject}
+	}
+	if !s.faults[object].Stuck {
+		s.values[object] = converted
+	}
+	return nil
+}
+
+// convert converts a value to the type of the object's Present_Value.
+func (s *Simulator) convert(object BACnetObject, value interface{}) (interface{}, error) {
+	var converted interface{}
+	switch object.Type {
+	case OBJECT_ANALOG_VALUE:
+		switch v := value.(type) {
+		case float32:
+			converted = v
+		case float64:
+			converted = float32(v)
+		case uint32:
+			converted = float32(v)
+		case int32:
+			converted = float32(v)
+		}
+	case OBJECT_BINARY_VALUE:
+		switch v := value.(type) {
+		case BinaryPV:
+			converted = v
+		case Enumerated:
+			converted = BinaryPV(v)
+		case uint32:
+			converted = BinaryPV(v)
+		case bool:
+			converted = BinaryInactive
+			if v {
+				converted = BinaryActive
+			}
+		}
+		if converted != nil && converted.(BinaryPV) > BinaryActive {
+			return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeValueOutOfRange}
+		}
+	}
+	if converted == nil {
+		return nil, &PropertyAccessError{Class: errorClassProperty, Code: errorCodeInvalidDataType}
+	}
+	return converted, nil
+}
+
+// Run serves requests, changes values every ChangeInterval and evaluates the generators until ctx
+// is cancelled.
+func (s *Simulator) Run(ctx context.Context) error {
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.Server.Serve(ctx) }()
+
+	var changes <-chan time.Time
+	if s.options.ChangeInterval > 0 && s.options.AnalogValues+s.options.BinaryValues > 0 {
+		ticker := time.NewTicker(s.options.ChangeInterval)
+		defer ticker.Stop()
+		changes = ticker.C
+	}
+	interval := s.options.GeneratorInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	generators := time.NewTicker(interval)
+	defer generators.Stop()
+
+	start := time.Now()
+	s.generate(0)
+	for {
+		select {
+		case err := <-serveErr:
+			return err
+		case <-changes:
+			s.Set(s.randomChange())
+		case now := <-generators.C:
+			s.generate(now.Sub(start))
+		}
+	}
+}
+
+// randomChange picks an object and a new value for it: analog values drift, binary values toggle.
+func (s *Simulator) randomChange() (BACnetObject, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.rand.Intn(s.options.AnalogValues + s.options.BinaryValues)
+	if n < s.options.AnalogValues {
+		object := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: uint32(n + 1)}
+		return object, s.values[object].(float32) + float32(s.rand.Float64()-0.5)
+	}
+	object := BACnetObject{Type: OBJECT_BINARY_VALUE, Instance: uint32(n - s.options.AnalogValues + 1)}
+	return object, 1 - s.values[object].(BinaryPV)
+}
@@ -0,0 +1,173 @@
+package bacnet
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var loopback = &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+
+// startSimulator runs a simulator on a loopback socket until the test ends and returns the device
+// to address it with.
+func startSimulator(t *testing.T, options SimulatorOptions) (*Simulator, DeviceInfo) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", loopback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := NewSimulator(conn, options)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sim.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		conn.Close()
+		<-done
+	})
+
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	return sim, DeviceInfo{DeviceID: options.DeviceID, IPAddress: addr.IP, Port: addr.Port, MaxAPDU: 1476}
+}
+
+// newLoopbackClient returns a client on a loopback socket that is closed when the test ends.
+func newLoopbackClient(t *testing.T, options ClientOptions) *BACnetClient {
+	t.Helper()
+	options.LocalAddr = loopback
+	if options.Timeout == 0 {
+		options.Timeout = time.Second
+	}
+	client, err := NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// fakeDevice opens a loopback socket for a device the test answers itself. It returns the socket,
+// the device to address it with and the datagrams sent to it.
+func fakeDevice(t *testing.T, deviceID uint32) (*net.UDPConn, DeviceInfo, <-chan []byte) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", loopback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	received := make(chan []byte, 16)
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			received <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	return conn, DeviceInfo{DeviceID: deviceID, IPAddress: addr.IP, Port: addr.Port, MaxAPDU: 1476}, received
+}
+
+// receiveAPDU returns the APDU of the next datagram sent to a fakeDevice.
+func receiveAPDU(t *testing.T, received <-chan []byte) []byte {
+	t.Helper()
+	select {
+	case data := <-received:
+		apdu, err := apduFromPacket(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return apdu
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing sent to the device")
+		return nil
+	}
+}
+
+// waitGoroutines waits until at most n goroutines run, and fails the test if that does not happen
+// within a few seconds.
+func waitGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines still running, want at most %d:\n%s", runtime.NumGoroutine(), n, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSimulatorReadWrite(t *testing.T) {
+	_, device := startSimulator(t, SimulatorOptions{DeviceID: 1001, AnalogValues: 2, BinaryValues: 1})
+	client := newLoopbackClient(t, ClientOptions{})
+
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+	if err := client.WritePresentValue(device, av1, float32(42.5), 8); err != nil {
+		t.Fatalf("WritePresentValue: %v", err)
+	}
+	value, err := client.ReadPresentValue(device, av1)
+	if err != nil {
+		t.Fatalf("ReadPresentValue: %v", err)
+	}
+	if value != float32(42.5) {
+		t.Errorf("Present_Value = %v, want 42.5", value)
+	}
+
+	objects, err := client.GetObjectList(device)
+	if err != nil {
+		t.Fatalf("GetObjectList: %v", err)
+	}
+	if len(objects) != 4 { // Device, two analog and one binary value
+		t.Errorf("object list has %d objects, want 4: %v", len(objects), objects)
+	}
+}
+
+// TestSoakNoLeaks is a short run of what cmd/soak does for hours: polling, COV churn and writes
+// against a simulator must leave no goroutines behind once the client is closed.
+func TestSoakNoLeaks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak test")
+	}
+	before := runtime.NumGoroutine()
+	func() {
+		sim, device := startSimulator(t, SimulatorOptions{DeviceID: 1002, AnalogValues: 3, BinaryValues: 3, ChangeInterval: 5 * time.Millisecond})
+		client, err := NewClient(ClientOptions{LocalAddr: loopback, Timeout: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for round := 0; round < 5; round++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			object := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: uint32(round%3 + 1)}
+			notifications, errs := client.SubscribeCOV(ctx, device, object, 7, false, 60)
+			sim.Set(object, float32(round))
+			select {
+			case <-notifications:
+			case err := <-errs:
+				t.Fatalf("SubscribeCOV: %v", err)
+			case <-time.After(2 * time.Second):
+				t.Fatal("no COV notification")
+			}
+			if _, err := client.ReadPresentValue(device, BACnetObject{Type: OBJECT_BINARY_VALUE, Instance: 1}); err != nil {
+				t.Errorf("ReadPresentValue: %v", err)
+			}
+			if err := client.WritePresentValue(device, object, float32(round*2), 16); err != nil {
+				t.Errorf("WritePresentValue: %v", err)
+			}
+			cancel()
+			for range notifications {
+			}
+		}
+		client.Close()
+	}()
+	// The simulator stops in a cleanup, after this test; allow for its goroutines.
+	waitGoroutines(t, before+2)
+}
@@ -0,0 +1,152 @@
+package bacnet
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ValueGenerator computes the Present_Value of a simulated object from the time elapsed since the
+// simulator started running. Returning nil leaves the value unchanged.
+type ValueGenerator func(elapsed time.Duration) interface{}
+
+// SineWave oscillates around mean with the given amplitude and period.
+func SineWave(mean, amplitude float64, period time.Duration) ValueGenerator {
+	return func(elapsed time.Duration) interface{} {
+		if period <= 0 {
+			return mean
+		}
+		return mean + amplitude*math.Sin(2*math.Pi*elapsed.Seconds()/period.Seconds())
+	}
+}
+
+// Ramp rises from start by perSecond, or falls if it is negative. If max is above min the value
+// wraps around within [min, max), like a sawtooth.
+func Ramp(start, perSecond, min, max float64) ValueGenerator {
+	return func(elapsed time.Duration) interface{} {
+		value := start + perSecond*elapsed.Seconds()
+		if max > min {
+			value = min + math.Mod(value-min, max-min)
+			if value < min {
+				value += max - min
+			}
+		}
+		return value
+	}
+}
+
+// RandomWalk starts at start and moves by up to maxStep in either direction on every tick,
+// staying within [min, max].
+func RandomWalk(start, maxStep, min, max float64, seed int64) ValueGenerator {
+	rng := rand.New(rand.NewSource(seed))
+	value := start
+	return func(time.Duration) interface{} {
+		value = math.Max(min, math.Min(max, value+(rng.Float64()*2-1)*maxStep))
+		return value
+	}
+}
+
+// Sequence steps through values, holding each for step and starting over after the last, e.g. a
+// scripted occupancy pattern or BinaryActive and BinaryInactive for a toggling binary value.
+func Sequence(step time.Duration, values ...interface{}) ValueGenerator {
+	return func(elapsed time.Duration) interface{} {
+		if len(values) == 0 {
+			return nil
+		}
+		if step <= 0 {
+			return values[0]
+		}
+		return values[int(elapsed/step)%len(values)]
+	}
+}
+
+// Fault is a failure injected into a simulated object.
+type Fault struct {
+	// Stuck freezes Present_Value: generators, random changes and writes no longer change it.
+	Stuck bool
+	// StatusFlags are reported in Status_Flags in addition to Out_Of_Service. InAlarm makes the
+	// Event_State offnormal.
+	StatusFlags StatusFlags
+	// Reliability is the Reliability reported, e.g. 1 for no-sensor or 2 for over-range. A
+	// non-zero Reliability sets the Fault flag and makes the Event_State fault.
+	Reliability Enumerated
+}
+
+// InjectFault applies fault to an object until ClearFault, replacing any earlier fault, and
+// notifies COV subscribers of the changed Status_Flags.
+func (s *Simulator) InjectFault(object BACnetObject, fault Fault) error {
+	s.mu.Lock()
+	if _, ok := s.values[object]; !ok {
+		s.mu.Unlock()
+		return &PropertyAccessError{Class: errorClassObject, Code: errorCodeUnknownObject}
+	}
+	s.faults[object] = fault
+	s.mu.Unlock()
+	s.Server.NotifyChange(object)
+	return nil
+}
+
+// ClearFault removes the fault of an object.
+func (s *Simulator) ClearFault(object BACnetObject) {
+	s.mu.Lock()
+	_, ok := s.faults[object]
+	delete(s.faults, object)
+	s.mu.Unlock()
+	if ok {
+		s.Server.NotifyChange(object)
+	}
+}
+
+// SetGenerator makes generator drive the Present_Value of an object while the simulator runs; nil
+// removes the generator.
+func (s *Simulator) SetGenerator(object BACnetObject, generator ValueGenerator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if generator == nil {
+		delete(s.generators, object)
+		return
+	}
+	s.generators[object] = generator
+}
+
+// generate sets every generated value that changed.
+func (s *Simulator) generate(elapsed time.Duration) {
+	s.mu.Lock()
+	generators := make(map[BACnetObject]ValueGenerator, len(s.generators))
+	for object, generator := range s.generators {
+		generators[object] = generator
+	}
+	s.mu.Unlock()
+
+	for object, generator := range generators {
+		value := generator(elapsed)
+		if value == nil {
+			continue
+		}
+		if converted, err := s.convert(object, value); err == nil && !ValuesEqual(converted, s.Value(object)) {
+			s.Set(object, converted)
+		}
+	}
+}
+
+// faultProperties returns the Status_Flags, Reliability and Event_State of an object.
+func (s *Simulator) faultProperties(object BACnetObject) map[uint32]interface{} {
+	s.mu.Lock()
+	fault := s.faults[object]
+	s.mu.Unlock()
+
+	flags := fault.StatusFlags
+	state := EventStateNormal
+	if fault.StatusFlags.InAlarm {
+		state = EventStateOffnormal
+	}
+	if fault.Reliability != 0 {
+		flags.Fault = true
+		state = EventStateFault
+	}
+	return map[uint32]interface{}{
+		PROP_STATUS_FLAGS: flags,
+		PROP_RELIABILITY:  fault.Reliability,
+		PROP_EVENT_STATE:  Enumerated(state),
+	}
+}
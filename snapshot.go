@@ -0,0 +1,206 @@
+package bacnet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DeviceSnapshot is a serializable record of every object on a device together with all of its
+// property values, as read at a given moment. Snapshots are typically taken before and after
+// commissioning work and compared with DiffSnapshots.
+type DeviceSnapshot struct {
+	DeviceID uint32           `json:"deviceId"`
+	Address  string           `json:"address"`
+	TakenAt  time.Time        `json:"takenAt"`
+	Objects  []ObjectSnapshot `json:"objects"`
+}
+
+// ObjectSnapshot holds the property values of a single object. If the properties could not be read,
+// Error describes why and Properties is empty.
+type ObjectSnapshot struct {
+	Object     BACnetObject       `json:"object"`
+	ReadAt     time.Time          `json:"readAt"`
+	Properties []PropertySnapshot `json:"properties,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// PropertySnapshot is a single property value within an ObjectSnapshot.
+type PropertySnapshot struct {
+	PropertyID uint32      `json:"propertyId"`
+	Name       string      `json:"name,omitempty"`
+	Value      interface{} `json:"value"`
+}
+
+// Snapshot reads the object list of a device and all properties of every object on it.
+// Objects whose properties cannot be read are recorded with an error rather than aborting the snapshot.
+func (c *BACnetClient) Snapshot(device DeviceInfo, opts ...CallOption) (*DeviceSnapshot, error) {
+	snapshot := &DeviceSnapshot{
+		DeviceID: device.DeviceID,
+		Address:  net.JoinHostPort(device.IPAddress.String(), strconv.Itoa(device.Port)),
+		TakenAt:  time.Now(),
+	}
+
+	ctx := callContext(opts...)
+	objects, err := c.GetObjectList(device, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object list: %w", err)
+	}
+
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		objectSnapshot := ObjectSnapshot{Object: object}
+
+		properties, err := c.GetObjectAllPropertyList(device, object, opts...)
+		objectSnapshot.ReadAt = time.Now()
+		if err != nil {
+			objectSnapshot.Error = err.Error()
+		}
+		for _, prop := range properties {
+			objectSnapshot.Properties = append(objectSnapshot.Properties, PropertySnapshot{
+				PropertyID: prop.PropertyID,
+				Name:       PropertyNames[prop.PropertyID],
+				Value:      prop.Value,
+			})
+		}
+
+		snapshot.Objects = append(snapshot.Objects, objectSnapshot)
+	}
+
+	return snapshot, nil
+}
+
+// WriteJSON writes the snapshot to w as an indented JSON document.
+func (s *DeviceSnapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// ReadSnapshot reads a snapshot previously written with WriteJSON.
+func ReadSnapshot(r io.Reader) (*DeviceSnapshot, error) {
+	var snapshot DeviceSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// SnapshotDiff lists the differences between two snapshots of the same device.
+type SnapshotDiff struct {
+	AddedObjects   []BACnetObject   `json:"addedObjects,omitempty"`
+	RemovedObjects []BACnetObject   `json:"removedObjects,omitempty"`
+	Changes        []PropertyChange `json:"changes,omitempty"`
+}
+
+// PropertyChange describes a property whose value differs between two snapshots.
+// Before is nil for properties that only exist in the later snapshot and After is nil for
+// properties that disappeared.
+type PropertyChange struct {
+	Object     BACnetObject `json:"object"`
+	PropertyID uint32       `json:"propertyId"`
+	Before     interface{}  `json:"before"`
+	After      interface{}  `json:"after"`
+}
+
+// Empty reports whether the two snapshots were identical.
+func (d SnapshotDiff) Empty() bool {
+	return len(d.AddedObjects) == 0 && len(d.RemovedObjects) == 0 && len(d.Changes) == 0
+}
+
+// DiffSnapshots compares two snapshots of a device. Values are compared by their JSON
+// representation, so a snapshot loaded from disk compares equal to the live snapshot it was saved from.
+func DiffSnapshots(before, after *DeviceSnapshot) SnapshotDiff {
+	var diff SnapshotDiff
+
+	beforeObjects := snapshotObjectMap(before)
+	afterObjects := snapshotObjectMap(after)
+
+	for _, obj := range after.Objects {
+		if _, ok := beforeObjects[obj.Object]; !ok {
+			diff.AddedObjects = append(diff.AddedObjects, obj.Object)
+		}
+	}
+
+	for _, obj := range before.Objects {
+		afterObj, ok := afterObjects[obj.Object]
+		if !ok {
+			diff.RemovedObjects = append(diff.RemovedObjects, obj.Object)
+			continue
+		}
+
+		beforeProps := snapshotPropertyMap(obj)
+		afterProps := snapshotPropertyMap(afterObj)
+
+		for _, propID := range sortedPropertyKeys(beforeProps, afterProps) {
+			beforeVal, inBefore := beforeProps[propID]
+			afterVal, inAfter := afterProps[propID]
+			if inBefore && inAfter && canonicalJSON(beforeVal) == canonicalJSON(afterVal) {
+				continue
+			}
+			diff.Changes = append(diff.Changes, PropertyChange{
+				Object:     obj.Object,
+				PropertyID: propID,
+				Before:     beforeVal,
+				After:      afterVal,
+			})
+		}
+	}
+
+	return diff
+}
+
+func snapshotObjectMap(s *DeviceSnapshot) map[BACnetObject]ObjectSnapshot {
+	objects := make(map[BACnetObject]ObjectSnapshot, len(s.Objects))
+	for _, obj := range s.Objects {
+		objects[obj.Object] = obj
+	}
+	return objects
+}
+
+func snapshotPropertyMap(obj ObjectSnapshot) map[uint32]interface{} {
+	props := make(map[uint32]interface{}, len(obj.Properties))
+	for _, prop := range obj.Properties {
+		props[prop.PropertyID] = prop.Value
+	}
+	return props
+}
+
+func sortedPropertyKeys(a, b map[uint32]interface{}) []uint32 {
+	seen := make(map[uint32]bool, len(a)+len(b))
+	var keys []uint32
+	for _, m := range []map[uint32]interface{}{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// canonicalJSON renders v as JSON with sorted object keys, so that a value and its
+// JSON round-tripped counterpart produce the same string.
+func canonicalJSON(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return string(raw)
+	}
+	canonical, _ := json.Marshal(generic)
+	return string(canonical)
+}
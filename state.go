@@ -0,0 +1,120 @@
+package bacnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PollerConfig is the serializable configuration of a Poller.
+type PollerConfig struct {
+	Interval time.Duration `json:"interval"`
+	Points   []PollPoint   `json:"points"`
+}
+
+// Config returns the poller's current configuration.
+func (p *Poller) Config() PollerConfig {
+	return PollerConfig{Interval: p.interval, Points: p.Points()}
+}
+
+// NewPollerFromConfig returns a poller polling the points of config.
+func NewPollerFromConfig(client *BACnetClient, config PollerConfig) *Poller {
+	p := NewPoller(client, config.Interval)
+	for _, point := range config.Points {
+		p.Add(point)
+	}
+	return p
+}
+
+// GatewayState is everything a long-running gateway needs to resume data collection after a
+// restart without rediscovering the network: the device address cache, the COV subscriptions and
+// the poller configuration.
+type GatewayState struct {
+	SavedAt       time.Time               `json:"savedAt"`
+	Devices       []CachedDevice          `json:"devices,omitempty"`
+	Subscriptions []COVSubscriptionConfig `json:"subscriptions,omitempty"`
+	Poller        *PollerConfig           `json:"poller,omitempty"`
+}
+
+// CaptureState collects the state of the given components. Any of them may be nil.
+func CaptureState(cache *DeviceCache, manager *COVManager, poller *Poller) *GatewayState {
+	state := &GatewayState{SavedAt: time.Now()}
+	if cache != nil {
+		state.Devices = cache.Devices()
+	}
+	if manager != nil {
+		state.Subscriptions = manager.Subscriptions()
+	}
+	if poller != nil {
+		config := poller.Config()
+		state.Poller = &config
+	}
+	return state
+}
+
+// Restore loads the device cache and re-establishes the subscriptions of the state. Any of the
+// targets may be nil. Use NewPollerFromConfig with s.Poller to restore polling.
+func (s *GatewayState) Restore(cache *DeviceCache, manager *COVManager) {
+	if cache != nil {
+		cache.restore(s.Devices)
+	}
+	if manager != nil {
+		for _, config := range s.Subscriptions {
+			// Prefer the most recently cached address of the device.
+			if cache != nil {
+				if device, ok := cache.Get(config.Device.DeviceID); ok {
+					config.Device = device
+				}
+			}
+			manager.Subscribe(config)
+		}
+	}
+}
+
+// WriteJSON writes the state to w as an indented JSON document.
+func (s *GatewayState) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// ReadGatewayState reads a state previously written with WriteJSON.
+func ReadGatewayState(r io.Reader) (*GatewayState, error) {
+	var state GatewayState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode gateway state: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveFile writes the state to path. The file is replaced atomically, so a crash while saving
+// leaves the previous state intact.
+func (s *GatewayState) SaveFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.WriteJSON(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadGatewayStateFile reads a state saved with SaveFile.
+func LoadGatewayStateFile(path string) (*GatewayState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadGatewayState(f)
+}
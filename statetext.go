@@ -0,0 +1,103 @@
+package bacnet
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MultiStateValue is the Present_Value of a multi-state object together with its State_Text.
+type MultiStateValue struct {
+	// Value is the raw state number, starting at 1.
+	Value uint32
+	// Text is the State_Text entry for Value, or the number if the object has no text for it.
+	Text string
+}
+
+// StateTextCache reads the State_Text of multi-state objects once and maps present values to
+// their text. It is safe for concurrent use.
+type StateTextCache struct {
+	client *BACnetClient
+
+	mu    sync.Mutex
+	texts map[PointRef][]string
+}
+
+// NewStateTextCache returns an empty cache reading through client.
+func NewStateTextCache(client *BACnetClient) *StateTextCache {
+	return &StateTextCache{client: client, texts: make(map[PointRef][]string)}
+}
+
+// StateTexts returns the State_Text of a multi-state object, reading it from the device on first use.
+func (c *StateTextCache) StateTexts(device DeviceInfo, object BACnetObject, opts ...CallOption) ([]string, error) {
+	if !isMultiStateObjectType(object.Type) {
+		return nil, fmt.Errorf("%s is not a multi-state object", object)
+	}
+
+	ref := PointRef{DeviceID: device.DeviceID, Object: object}
+	c.mu.Lock()
+	texts, ok := c.texts[ref]
+	c.mu.Unlock()
+	if ok {
+		return texts, nil
+	}
+
+	elements, err := c.client.ReadArray(device, object, PROP_STATE_TEXT, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state text of %s: %w", object, err)
+	}
+	texts = make([]string, len(elements))
+	for i, element := range elements {
+		texts[i], _ = element.(string)
+	}
+
+	c.mu.Lock()
+	c.texts[ref] = texts
+	c.mu.Unlock()
+	return texts, nil
+}
+
+// Text maps a state number of a multi-state object to its text.
+func (c *StateTextCache) Text(device DeviceInfo, object BACnetObject, state uint32, opts ...CallOption) (string, error) {
+	texts, err := c.StateTexts(device, object, opts...)
+	if err != nil {
+		return "", err
+	}
+	return stateText(texts, state), nil
+}
+
+// ReadPresentValue reads the Present_Value of a multi-state object and maps it to its text.
+func (c *StateTextCache) ReadPresentValue(device DeviceInfo, object BACnetObject, opts ...CallOption) (MultiStateValue, error) {
+	texts, err := c.StateTexts(device, object, opts...)
+	if err != nil {
+		return MultiStateValue{}, err
+	}
+
+	value, err := c.client.readProperty(callContext(opts...), device, object, PROP_PRESENT_VALUE, nil)
+	if err != nil {
+		return MultiStateValue{}, err
+	}
+	state, ok := value.(uint32)
+	if !ok {
+		return MultiStateValue{}, fmt.Errorf("unexpected present value %v (%T) of %s", value, value, object)
+	}
+	return MultiStateValue{Value: state, Text: stateText(texts, state)}, nil
+}
+
+// Invalidate drops the cached text of an object, e.g. after it was reconfigured.
+func (c *StateTextCache) Invalidate(deviceID uint32, object BACnetObject) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.texts, PointRef{DeviceID: deviceID, Object: object})
+}
+
+// stateText returns the text of a state; states are numbered from 1.
+func stateText(texts []string, state uint32) string {
+	if state >= 1 && int(state) <= len(texts) && texts[state-1] != "" {
+		return texts[state-1]
+	}
+	return fmt.Sprintf("%d", state)
+}
+
+func isMultiStateObjectType(t ObjectType) bool {
+	return t == OBJECT_MULTI_STATE_INPUT || t == OBJECT_MULTI_STATE_OUTPUT || t == OBJECT_MULTI_STATE_VALUE
+}
@@ -0,0 +1,185 @@
+package bacnet
+
+import (
+	"sync"
+	"time"
+)
+
+// ClientStats is a point-in-time view of the client's health, suitable for status pages and logs.
+type ClientStats struct {
+	// OpenTransactions is the number of confirmed requests waiting for a response.
+	OpenTransactions int `json:"openTransactions"`
+	// Transactions is the number of confirmed requests sent, not counting retries.
+	Transactions uint64 `json:"transactions"`
+	// Retries is the number of times a request was resent after a timeout.
+	Retries uint64 `json:"retries"`
+	// Timeouts is the number of requests that failed because no response arrived.
+	Timeouts uint64 `json:"timeouts"`
+	// DroppedPackets is the number of received datagrams that were discarded because no open
+	// transaction, subscription or handler took them, or they could not be parsed.
+	DroppedPackets uint64 `json:"droppedPackets"`
+	// DroppedNotifications is the number of COV notifications discarded because the consumer of a
+	// subscription, or the client's notification queue, did not keep up; see BackpressurePolicy.
+	DroppedNotifications uint64 `json:"droppedNotifications"`
+	// CoalescedNotifications is the number of COV notifications merged into a pending one under
+	// BackpressureCoalesce.
+	CoalescedNotifications uint64 `json:"coalescedNotifications"`
+	// FilteredNotifications is the number of COV notifications discarded by the filters of
+	// COVOptions.
+	FilteredNotifications uint64 `json:"filteredNotifications"`
+	// FilteredDevices is the number of I-Ams discarded by ClientOptions.DeviceFilter.
+	FilteredDevices uint64 `json:"filteredDevices"`
+	// UnexpectedBVLC is the number of received datagrams discarded because their BVLC function is
+	// not one a client accepts; see UnexpectedBVLC.
+	UnexpectedBVLC uint64 `json:"unexpectedBVLC"`
+	// ActiveSubscriptions is the number of COV subscriptions currently held.
+	ActiveSubscriptions int `json:"activeSubscriptions"`
+	// LastErrors holds the most recent error of every device a request to has failed.
+	LastErrors map[uint32]DeviceError `json:"lastErrors,omitempty"`
+	// RoundTrips holds the round trip statistics of every device a request was answered by.
+	RoundTrips map[uint32]RoundTripStats `json:"roundTrips,omitempty"`
+}
+
+// DeviceError is the last error seen while talking to a device.
+type DeviceError struct {
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// clientStats collects the counters behind ClientStats.
+type clientStats struct {
+	mu                     sync.Mutex
+	openTransactions       int
+	transactions           uint64
+	retries                uint64
+	timeouts               uint64
+	droppedPackets         uint64
+	droppedNotifications   uint64
+	coalescedNotifications uint64
+	filteredNotifications  uint64
+	filteredDevices        uint64
+	unexpectedBVLCs        uint64
+	activeSubscriptions    int
+	lastErrors             map[uint32]DeviceError
+	roundTrips             map[uint32]*roundTrips
+}
+
+func newClientStats() *clientStats {
+	return &clientStats{lastErrors: make(map[uint32]DeviceError), roundTrips: make(map[uint32]*roundTrips)}
+}
+
+// Stats returns the client's current statistics.
+func (c *BACnetClient) Stats() ClientStats {
+	s := c.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := ClientStats{
+		OpenTransactions:       s.openTransactions,
+		Transactions:           s.transactions,
+		Retries:                s.retries,
+		Timeouts:               s.timeouts,
+		DroppedPackets:         s.droppedPackets,
+		DroppedNotifications:   s.droppedNotifications,
+		CoalescedNotifications: s.coalescedNotifications,
+		FilteredNotifications:  s.filteredNotifications,
+		FilteredDevices:        s.filteredDevices,
+		UnexpectedBVLC:         s.unexpectedBVLCs,
+		ActiveSubscriptions:    s.activeSubscriptions,
+	}
+	if len(s.lastErrors) > 0 {
+		stats.LastErrors = make(map[uint32]DeviceError, len(s.lastErrors))
+		for deviceID, deviceErr := range s.lastErrors {
+			stats.LastErrors[deviceID] = deviceErr
+		}
+	}
+	if len(s.roundTrips) > 0 {
+		stats.RoundTrips = make(map[uint32]RoundTripStats, len(s.roundTrips))
+		for deviceID, r := range s.roundTrips {
+			stats.RoundTrips[deviceID] = r.stats()
+		}
+	}
+	return stats
+}
+
+func (s *clientStats) beginTransaction() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.openTransactions++
+	s.transactions++
+}
+
+func (s *clientStats) endTransaction() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.openTransactions--
+}
+
+func (s *clientStats) retry() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries++
+}
+
+func (s *clientStats) timeout() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeouts++
+}
+
+func (s *clientStats) dropped() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.droppedPackets++
+}
+
+func (s *clientStats) notificationDropped() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.droppedNotifications++
+}
+
+func (s *clientStats) notificationCoalesced() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coalescedNotifications++
+}
+
+func (s *clientStats) notificationFiltered() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filteredNotifications++
+}
+
+func (s *clientStats) deviceFiltered() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filteredDevices++
+}
+
+func (s *clientStats) unexpectedBVLC() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unexpectedBVLCs++
+}
+
+func (s *clientStats) subscriptionStarted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.activeSubscriptions++
+}
+
+func (s *clientStats) subscriptionEnded() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.activeSubscriptions--
+}
+
+func (s *clientStats) recordError(deviceID uint32, err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErrors[deviceID] = DeviceError{Error: err.Error(), Time: time.Now()}
+}
@@ -3,171 +3,492 @@ package bacnet
 import (
 	"bytes"
 	"context"
-	"encoding/binary"
 	"fmt"
-	"net"
+	"math/rand/v2"
 	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// BackpressurePolicy decides what a subscription does with a notification while the consumer has
+// not taken the previous ones off the notification channel.
+type BackpressurePolicy uint32
+
+const (
+	// BackpressureBlock waits for the consumer, holding up the subscription and, once its queue of
+	// received notifications is full, the notifications of all subscriptions of the client. Responses
+	// to requests are not held up; once the client's queue of notifications is full as well, further
+	// notifications are dropped.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropNewest discards the new notification.
+	BackpressureDropNewest
+	// BackpressureDropOldest discards the oldest queued notification to make room for the new one.
+	// On an unbuffered channel it behaves like BackpressureDropNewest.
+	BackpressureDropOldest
+	// BackpressureCoalesce merges the new notification into the queued one, so the consumer gets the
+	// latest value of every property. The channel holds at most one notification.
+	BackpressureCoalesce
 )
 
+var backpressurePolicyNames = map[BackpressurePolicy]string{
+	BackpressureBlock:      "block",
+	BackpressureDropNewest: "drop-newest",
+	BackpressureDropOldest: "drop-oldest",
+	BackpressureCoalesce:   "coalesce",
+}
+
+func (p BackpressurePolicy) String() string { return enumName(backpressurePolicyNames, p) }
+
+// COVOptions configures the notification channel of a subscription.
+type COVOptions struct {
+	// BufferSize is the capacity of the notification channel.
+	BufferSize int `json:"bufferSize,omitempty"`
+	// Backpressure applies while the channel is full.
+	Backpressure BackpressurePolicy `json:"backpressure,omitempty"`
+
+	// Properties, if set, are the only properties whose values are delivered, e.g.
+	// PROP_PRESENT_VALUE; notifications left without values are discarded.
+	Properties []uint32 `json:"properties,omitempty"`
+	// IgnoreStatusFlagsOnly discards notifications in which nothing but Status_Flags changed.
+	IgnoreStatusFlagsOnly bool `json:"ignoreStatusFlagsOnly,omitempty"`
+	// MinInterval is the minimum time between two notifications delivered. Notifications
+	// arriving sooner are held back and merged, so the latest values are delivered once the
+	// interval has passed.
+	MinInterval time.Duration `json:"minInterval,omitempty"`
+	// MaxNotificationDelay is how long the device may hold back the notifications of a
+	// SubscribeCOVPropertyMultiple subscription to send them together. Zero leaves it to the device.
+	MaxNotificationDelay time.Duration `json:"maxNotificationDelay,omitempty"`
+
+	// ResubscribeAttempts is how many renewals in a row may fail before the subscription ends;
+	// zero means 5. Failures before the last are reported on the error channel as a
+	// ResubscribeError that is not final, and the renewal is retried.
+	ResubscribeAttempts int `json:"resubscribeAttempts,omitempty"`
+	// ResubscribeBackoff is the wait before retrying a failed renewal, doubled after every further
+	// failure up to the renewal interval and randomized by up to half; zero means one second.
+	ResubscribeBackoff time.Duration `json:"resubscribeBackoff,omitempty"`
+
+	// OnState, if set, is called with every step in the lifecycle of the subscription, from the
+	// goroutine running it; it must not block.
+	OnState func(COVStateChange) `json:"-"`
+}
+
+// ResubscribeError is reported on the error channel of a COV subscription when renewing it failed.
+// Unless it is Final, the subscription continues and retries the renewal after Retry.
+type ResubscribeError struct {
+	// Attempt counts the failed renewals in a row, from 1.
+	Attempt int
+	// Retry is the wait before the next attempt; zero if Final.
+	Retry time.Duration
+	// Final is set on the failure that ends the subscription.
+	Final bool
+	Err   error
+}
+
+func (e *ResubscribeError) Error() string {
+	if e.Final {
+		return fmt.Sprintf("re-subscription failed after %d attempts: %v", e.Attempt, e.Err)
+	}
+	return fmt.Sprintf("re-subscription attempt %d failed, retrying in %s: %v", e.Attempt, e.Retry.Round(time.Millisecond), e.Err)
+}
+
+func (e *ResubscribeError) Unwrap() error { return e.Err }
+
+// Temporary reports whether the subscription goes on despite the error.
+func (e *ResubscribeError) Temporary() bool { return !e.Final }
+
 // SubscribeCOV establishes a Change of Value (COV) subscription with a BACnet device.
 // It returns a channel for COV notifications and a channel for errors during the subscription lifecycle.
-// The subscription will automatically re-subscribe before the lifetime expires.
-// The context can be used to cancel the subscription.
-func (c *BACnetClient) SubscribeCOV(ctx context.Context, device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime uint8) (<-chan COVNotification, <-chan error) {
-	covChan := make(chan COVNotification)
-	errChan := make(chan error, 1) // Buffered to prevent goroutine leak if no one reads the error
+// The subscription will automatically re-subscribe before the lifetime expires, retrying failed
+// renewals as configured by COVOptions.ResubscribeAttempts.
+// The subscription ends, and both channels are closed, when ctx is cancelled, the client is closed
+// or an error ends it. Cancelling ctx also cancels the subscription on the device, see
+// UnsubscribeCOV; the channels are closed once the device answered. The notification channel uses
+// ClientOptions.COVBufferSize and ClientOptions.COVBackpressure. Errors are dropped while the error
+// channel is full.
+// When the client is part of a redundant pair (see StartFailover), the subscription is only held
+// while the client is active and is re-established automatically after a takeover.
+func (c *BACnetClient) SubscribeCOV(ctx context.Context, device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime uint32) (<-chan COVNotification, <-chan error) {
+	options := COVOptions{BufferSize: c.options.COVBufferSize, Backpressure: c.options.COVBackpressure}
+	return c.SubscribeCOVWithOptions(ctx, device, object, subscriberProcessIdentifier, issueConfirmedNotifications, lifetime, options)
+}
+
+// SubscribeCOVWithOptions is SubscribeCOV with a notification channel configured by options
+// instead of the client defaults.
+func (c *BACnetClient) SubscribeCOVWithOptions(ctx context.Context, device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime uint32, options COVOptions) (<-chan COVNotification, <-chan error) {
+	return c.runCOVSubscription(ctx, covSubscription{
+		name:      "SubscribeCOV",
+		device:    device,
+		processID: subscriberProcessIdentifier,
+		objects:   []BACnetObject{object},
+		lifetime:  lifetime,
+		subscribe: func(ctx context.Context) error {
+			return c.sendSubscribeCOVRequest(ctx, device, object, subscriberProcessIdentifier, issueConfirmedNotifications, lifetime)
+		},
+		unsubscribe: func(ctx context.Context) error {
+			return c.unsubscribeCOV(ctx, device, object, subscriberProcessIdentifier)
+		},
+	}, options)
+}
+
+// covSubscription is a subscription request as the subscription loop sees it.
+type covSubscription struct {
+	// name is the service, for errors
+	name      string
+	device    DeviceInfo
+	processID uint32
+	// objects are the monitored objects, whose notifications the subscription receives.
+	objects  []BACnetObject
+	lifetime uint32
+	// subscribe sends the request and waits for its acknowledgement; it also renews the subscription.
+	subscribe func(ctx context.Context) error
+	// unsubscribe cancels the subscription on the device.
+	unsubscribe func(ctx context.Context) error
+}
+
+// routes returns the routes under which the notifications of the subscription are dispatched.
+func (sub covSubscription) routes() []covRoute {
+	routes := make([]covRoute, len(sub.objects))
+	for i, object := range sub.objects {
+		routes[i] = covRoute{device: sub.device.DeviceID, processID: sub.processID, object: object}
+	}
+	return routes
+}
+
+// runCOVSubscription subscribes and delivers the notifications of sub until ctx is cancelled, the
+// client is closed or an error ends the subscription.
+func (c *BACnetClient) runCOVSubscription(ctx context.Context, sub covSubscription, options COVOptions) (<-chan COVNotification, <-chan error) {
+	if options.Backpressure == BackpressureCoalesce {
+		options.BufferSize = 1
+	}
+	covChan := make(chan COVNotification, options.BufferSize)
+	errChan := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.closed, cancel)
+
+	// The inbox is registered before subscribing, as devices notify right after the subscription.
+	subscriber := newCOVSubscriber()
+	routes := sub.routes()
+	err := c.registerCOV(routes, subscriber)
 
 	go func() {
 		defer close(covChan)
 		defer close(errChan)
+		defer stop()
+		defer cancel()
 
-		// Initial subscription
-		err := c.sendSubscribeCOVRequest(device, object, subscriberProcessIdentifier, issueConfirmedNotifications, lifetime)
 		if err != nil {
-			errChan <- fmt.Errorf("initial SubscribeCOV failed: %w", err)
+			reportError(errChan, err)
+			sub.reportState(options, COVFailed, time.Time{}, err)
 			return
 		}
+		// The routes are registered only while the client is active: in standby nobody takes the
+		// inbox, and notifications the device still sends would hold up the dispatcher.
+		registered := true
+		defer func() {
+			if registered {
+				c.unregisterCOV(routes)
+			}
+		}()
+		defer close(subscriber.done)
+
+		filters := newCOVFilters(options)
+		for {
+			if registered && c.failover.current() != RoleActive {
+				c.unregisterCOV(routes)
+				registered = false
+			}
+			// Only the active member of a redundant pair owns subscriptions.
+			activeCtx, cancelActive, err := c.failover.whileActive(ctx)
+			if err != nil {
+				sub.reportState(options, COVCancelled, time.Time{}, nil)
+				return // Context cancelled while in standby
+			}
+			if !registered {
+				subscriber.drain() // Notifications of the previous active period
+				if err := c.registerCOV(routes, subscriber); err != nil {
+					cancelActive()
+					reportError(errChan, err)
+					sub.reportState(options, COVFailed, time.Time{}, err)
+					return
+				}
+				registered = true
+			}
+
+			// Initial subscription
+			err = sub.subscribe(activeCtx)
+			if err != nil {
+				cancelActive()
+				if ctx.Err() != nil {
+					sub.reportState(options, COVCancelled, time.Time{}, nil)
+					return
+				}
+				err = fmt.Errorf("initial %s failed: %w", sub.name, err)
+				reportError(errChan, err)
+				sub.reportState(options, COVFailed, time.Time{}, err)
+				return
+			}
+			sub.reportState(options, COVSubscribed, sub.expiry(time.Now()), nil)
 
-		// Start listening for COV notifications and handle re-subscriptions
-		c.handleCOVSubscription(ctx, device, object, subscriberProcessIdentifier, issueConfirmedNotifications, lifetime, covChan, errChan)
+			// Start listening for COV notifications and handle re-subscriptions
+			c.stats.subscriptionStarted()
+			err = c.handleCOVSubscription(activeCtx, sub, subscriber, options, filters, covChan, errChan)
+			c.stats.subscriptionEnded()
+			demoted := activeCtx.Err() != nil
+			cancelActive()
+			if demoted && ctx.Err() == nil {
+				c.unregisterCOV(routes)
+				registered = false
+			}
+			if demoted && c.closed.Err() == nil {
+				// Cancelled by the caller, or demoted: the device must not keep notifying a
+				// standby, whose peer subscribes on its own.
+				c.cancelCOVSubscription(ctx, sub)
+			}
+
+			switch {
+			case ctx.Err() != nil:
+				sub.reportState(options, COVCancelled, time.Time{}, nil)
+				return
+			case !demoted:
+				sub.reportState(options, COVFailed, time.Time{}, err)
+				return // Terminated by an error
+			}
+			// Demoted to standby: wait for the next promotion and subscribe again.
+			sub.reportState(options, COVStandby, time.Time{}, nil)
+		}
 	}()
 
 	return covChan, errChan
 }
 
-// sendSubscribeCOVRequest sends a single SubscribeCOV request and waits for the Simple-ACK.
-func (c *BACnetClient) sendSubscribeCOVRequest(device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime uint8) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Construct SubscribeCOV request
-	var apduBuffer bytes.Buffer
-
-	// APDU (Confirmed-Request)
-	apduBuffer.WriteByte(APDU_CONFIRMED_REQUEST | 0x02) // APDU Type (0x00) | PDU Flags (0x02)
-	apduBuffer.WriteByte(0x75)                          // Max segments (7) | Max APDU (5)
-	invokeID := GInvokeIDManager.Next()
-	apduBuffer.WriteByte(invokeID) // Invoke ID
-	apduBuffer.WriteByte(SERVICE_CONFIRMED_SUBSCRIBE_COV)
-
-	// Subscriber Process Identifier
-	apduBuffer.WriteByte(0x09) // Tag 0, context-specific, length 1
-	apduBuffer.WriteByte(byte(subscriberProcessIdentifier))
-
-	// Monitored Object Identifier
-	apduBuffer.WriteByte(0x1C) // Tag 1, context-specific, length 4
-	monitoredObjectIdentifier := (uint32(object.Type) << 22) | object.Instance
-	binary.Write(&apduBuffer, binary.BigEndian, monitoredObjectIdentifier)
-
-	// Issue Confirmed Notifications
-	apduBuffer.WriteByte(0x29) // Tag 2, context-specific, length 1
-	if issueConfirmedNotifications {
-		apduBuffer.WriteByte(1)
-	} else {
-		apduBuffer.WriteByte(0)
+// cancelCOVSubscription cancels sub on the device once the caller has cancelled ctx or the client
+// was demoted to standby, so the device stops sending notifications nobody reads. Nobody waits for
+// the cancellation, so failures are only logged.
+func (c *BACnetClient) cancelCOVSubscription(ctx context.Context, sub covSubscription) {
+	if err := sub.unsubscribe(context.WithoutCancel(ctx)); err != nil {
+		c.logf("cancelling %s subscription on device %d failed: %v", sub.name, sub.device.DeviceID, err)
 	}
+}
 
-	// Lifetime
-	apduBuffer.WriteByte(0x39) // Tag 3, context-specific, length 1
-	apduBuffer.WriteByte(byte(lifetime))
+// covInboxSize is the number of notifications the dispatcher queues for a subscription before it
+// waits for the subscription to take them.
+const covInboxSize = 16
 
-	var buffer bytes.Buffer
-	// BVLC Header
-	bvlc := BVLCHeader{
-		Type:     BVLC_TYPE_BACNET_IP,
-		Function: BVLC_ORIGINAL_UNICAST_NPDU,
-		Length:   uint16(4 + 2 + apduBuffer.Len()),
+// reportError sends err on errChan unless the channel is full.
+func reportError(errChan chan<- error, err error) {
+	select {
+	case errChan <- err:
+	default:
 	}
-	binary.Write(&buffer, binary.BigEndian, &bvlc)
+}
 
-	// NPDU
-	npdu := NPDU{
-		Version: 1,
-		Control: 0x04, // Expecting Reply
+// reportFinalError sends the error that ends a subscription on errChan, replacing a pending one so
+// that it is not lost behind an error the subscription went on after.
+func reportFinalError(errChan chan error, err error) {
+	for {
+		select {
+		case errChan <- err:
+			return
+		default:
+		}
+		select {
+		case <-errChan:
+		default:
+		}
 	}
-	binary.Write(&buffer, binary.BigEndian, &npdu)
-
-	// APDU
-	buffer.Write(apduBuffer.Bytes())
+}
 
-	// Send SubscribeCOV packet
-	_, err := c.conn.WriteTo(buffer.Bytes(), &net.UDPAddr{IP: device.IPAddress, Port: device.Port})
-	if err != nil {
-		return fmt.Errorf("failed to send SubscribeCOV packet: %w", err)
+// resubscribeDelay returns the wait before retrying a renewal after the given number of failures in
+// a row: backoff doubled for every earlier failure, at most limit, of which the upper half is
+// random so that subscriptions failing together do not retry in lockstep.
+func resubscribeDelay(backoff, limit time.Duration, failures int) time.Duration {
+	d := backoff
+	for i := 1; i < failures && d < limit; i++ {
+		d *= 2
 	}
+	d = max(min(d, limit), time.Millisecond)
+	return d/2 + rand.N(d/2+1)
+}
 
-	// Listen for Simple-ACK response
-	c.conn.SetReadDeadline(time.Now().Add(c.options.Timeout))
-	readBuffer := make([]byte, 2048)
+// deliver hands a notification to the consumer according to policy. It reports false if ctx ended
+// while waiting for the consumer.
+func (c *BACnetClient) deliver(ctx context.Context, covChan chan COVNotification, policy BackpressurePolicy, notification COVNotification) bool {
+	select {
+	case covChan <- notification:
+		return true
+	default:
+	}
 
-	n, _, err := c.conn.ReadFromUDP(readBuffer)
-	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return fmt.Errorf("timeout waiting for SubscribeCOV response")
+	switch policy {
+	case BackpressureDropNewest:
+		c.stats.notificationDropped()
+	case BackpressureDropOldest, BackpressureCoalesce:
+		// Only this goroutine sends, so after taking a queued notification there is room for one
+		select {
+		case queued := <-covChan:
+			if policy == BackpressureCoalesce {
+				notification = coalesceNotifications(queued, notification)
+				c.stats.notificationCoalesced()
+			} else {
+				c.stats.notificationDropped()
+			}
+		default:
+			if cap(covChan) == 0 {
+				c.stats.notificationDropped()
+				return true
+			}
+			// The consumer took the queued notification meanwhile
+		}
+		covChan <- notification
+	default:
+		select {
+		case covChan <- notification:
+		case <-ctx.Done():
+			return false
 		}
-		return fmt.Errorf("failed to read from UDP: %w", err)
 	}
+	return true
+}
 
-	// Parse Simple-ACK
-	r := bytes.NewReader(readBuffer[:n])
-	// BVLC & NPDU - skip
-	r.Seek(6, 0)
-	apduType, _ := r.ReadByte()
-	if apduType&0xF0 != APDU_SIMPLE_ACK {
-		return fmt.Errorf("not a Simple-ACK, got %x", apduType)
-	}
-	respInvokeID, _ := r.ReadByte()
-	if respInvokeID != invokeID {
-		return fmt.Errorf("invoke ID mismatch: expected %d, got %d", invokeID, respInvokeID)
+// coalesceNotifications returns next with the values of earlier properties it does not carry.
+func coalesceNotifications(earlier, next COVNotification) COVNotification {
+	values := make([]BACnetPropertyValue, 0, len(earlier.ListOfValues)+len(next.ListOfValues))
+	values = append(values, next.ListOfValues...)
+	for _, v := range earlier.ListOfValues {
+		found := false
+		for _, n := range next.ListOfValues {
+			if n.PropertyID == v.PropertyID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, v)
+		}
 	}
+	next.ListOfValues = values
+	return next
+}
 
-	return nil
+// sendSubscribeCOVRequest sends a single SubscribeCOV request and waits for the Simple-ACK.
+func (c *BACnetClient) sendSubscribeCOVRequest(ctx context.Context, device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime uint32) error {
+	// Construct SubscribeCOV request
+	var params bytes.Buffer
+	encoding.EncodeContextUnsigned(&params, 0, subscriberProcessIdentifier)
+	encoding.EncodeContextObjectID(&params, 1, uint32(object.Type), object.Instance)
+	encoding.EncodeContextBoolean(&params, 2, issueConfirmedNotifications)
+	encoding.EncodeContextUnsigned(&params, 3, lifetime)
+
+	return c.sendSubscriptionRequest(ctx, device, SERVICE_CONFIRMED_SUBSCRIBE_COV, params.Bytes())
+}
+
+// UnsubscribeCOV cancels the COV subscription of subscriberProcessIdentifier to object on the
+// device, with a SubscribeCOV request that omits the notification type and lifetime. Subscriptions
+// made with SubscribeCOV are cancelled automatically when their context is cancelled.
+func (c *BACnetClient) UnsubscribeCOV(device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, opts ...CallOption) error {
+	return c.unsubscribeCOV(callContext(opts...), device, object, subscriberProcessIdentifier)
+}
+
+func (c *BACnetClient) unsubscribeCOV(ctx context.Context, device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32) error {
+	var params bytes.Buffer
+	encoding.EncodeContextUnsigned(&params, 0, subscriberProcessIdentifier)
+	encoding.EncodeContextObjectID(&params, 1, uint32(object.Type), object.Instance)
+
+	return c.sendSubscriptionRequest(ctx, device, SERVICE_CONFIRMED_SUBSCRIBE_COV, params.Bytes())
 }
 
-// handleCOVSubscription manages the COV subscription lifecycle, including re-subscriptions and notification listening.
-func (c *BACnetClient) handleCOVSubscription(ctx context.Context, device DeviceInfo, object BACnetObject, subscriberProcessIdentifier uint32, issueConfirmedNotifications bool, lifetime uint8, covChan chan<- COVNotification, errChan chan<- error) {
+// sendSubscriptionRequest sends a subscription request and waits for the Simple-ACK.
+func (c *BACnetClient) sendSubscriptionRequest(ctx context.Context, device DeviceInfo, service byte, params []byte) error {
+	_, data, err := c.transact(ctx, device, service, params)
+	if err != nil {
+		return err
+	}
+	return expectSimpleAck(data)
+}
+
+// handleCOVSubscription manages the COV subscription lifecycle: it renews the subscription and
+// delivers the notifications the dispatcher hands to subscriber. It returns the error that ended
+// the subscription, or nil if ctx ended.
+func (c *BACnetClient) handleCOVSubscription(ctx context.Context, sub covSubscription, subscriber *covSubscriber, options COVOptions, filters *covFilters, covChan chan COVNotification, errChan chan error) error {
 	// Calculate re-subscription interval (e.g., 80% of lifetime)
-	reSubscribeInterval := time.Duration(float64(lifetime)*0.8) * time.Second
+	reSubscribeInterval := time.Duration(float64(sub.lifetime)*0.8) * time.Second
 	if reSubscribeInterval <= 0 { // Ensure a minimum interval if lifetime is very small or zero
 		reSubscribeInterval = 1 * time.Second
 	}
 
-	ticker := time.NewTicker(reSubscribeInterval)
-	defer ticker.Stop()
+	attempts := options.ResubscribeAttempts
+	if attempts <= 0 {
+		attempts = 5
+	}
+	backoff := options.ResubscribeBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	policy := options.Backpressure
 
-	readBuffer := make([]byte, 4096)
+	renewal := time.Now().Add(reSubscribeInterval)
+	expires := sub.expiry(time.Now())
+	failures := 0
+	timer := time.NewTimer(reSubscribeInterval)
+	defer timer.Stop()
 	for {
+		// Wake up for the renewal or the notifications held back by the filters, whichever is first
+		wake := renewal
+		if due, ok := filters.due(); ok && due.Before(wake) {
+			wake = due
+		}
+		timer.Reset(time.Until(wake))
+
 		select {
 		case <-ctx.Done():
-			return // Context cancelled, terminate goroutine
-		case <-ticker.C:
-			// Time to re-subscribe
-			err := c.sendSubscribeCOVRequest(device, object, subscriberProcessIdentifier, issueConfirmedNotifications, lifetime)
-			if err != nil {
-				errChan <- fmt.Errorf("re-subscription failed: %w", err)
-				return // Terminate on re-subscription failure
+			return nil
+		case err := <-subscriber.failed:
+			reportError(errChan, err)
+			return err // Terminate on read error
+		case notification := <-subscriber.inbox:
+			notification, deliver, filtered := filters.accept(notification, time.Now())
+			if filtered {
+				c.stats.notificationFiltered()
 			}
-		case <-time.After(100 * time.Millisecond): // Small timeout to allow reading from UDP
-			// Attempt to read COV notifications
-			c.mu.Lock()
-			c.conn.SetReadDeadline(time.Now().Add(c.options.Timeout))
-			n, _, err := c.conn.ReadFromUDP(readBuffer)
-			c.mu.Unlock()
-
-			if err != nil {
-				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-					continue // Timeout, no data, try again
+			if deliver && !c.deliver(ctx, covChan, policy, notification) {
+				return nil
+			}
+		case <-timer.C:
+			for _, notification := range filters.flush(time.Now()) {
+				if !c.deliver(ctx, covChan, policy, notification) {
+					return nil
 				}
-				errChan <- fmt.Errorf("error reading COV notification: %w", err)
-				return // Terminate on read error
 			}
-
-			notification, err := parseCOVNotification(readBuffer[:n])
-			if err == nil {
-				covChan <- notification
-			} else {
-				errChan <- fmt.Errorf("error parsing COV notification: %w", err)
+			if time.Now().Before(renewal) {
+				continue
+			}
+			if err := sub.subscribe(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				failures++
+				if failures >= attempts {
+					finalErr := &ResubscribeError{Attempt: failures, Final: true, Err: err}
+					reportFinalError(errChan, finalErr)
+					sub.reportState(options, COVRenewalFailed, expires, finalErr)
+					return finalErr
+				}
+				delay := resubscribeDelay(backoff, reSubscribeInterval, failures)
+				resubErr := &ResubscribeError{Attempt: failures, Retry: delay, Err: err}
+				reportError(errChan, resubErr)
+				sub.reportState(options, COVRenewalFailed, expires, resubErr)
+				if !expires.IsZero() && time.Now().After(expires) {
+					sub.reportState(options, COVExpired, expires, nil)
+					expires = time.Time{} // Reported once
+				}
+				renewal = time.Now().Add(delay)
+				continue
 			}
+			failures = 0
+			expires = sub.expiry(time.Now())
+			renewal = time.Now().Add(reSubscribeInterval)
+			sub.reportState(options, COVRenewed, expires, nil)
 		}
 	}
 }
@@ -0,0 +1,158 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ObjectTemplate describes how to provision an object: the values passed with CreateObject and the
+// properties written afterwards, in order. Writes are used for properties that must only be set
+// once the object is fully configured, such as Enable on a trend log.
+type ObjectTemplate struct {
+	ObjectType    ObjectType
+	InitialValues []BACnetPropertyValue
+	Writes        []BACnetPropertyValue
+
+	// required lists the properties the template must set, checked by Validate.
+	required []uint32
+	// validate performs template specific checks.
+	validate func(values map[uint32]interface{}) error
+}
+
+// TrendLogTemplate returns a template for a trend log recording the Present_Value of an analog input
+// every interval into a circular buffer of bufferSize records.
+func TrendLogTemplate(name string, source BACnetObject, interval time.Duration, bufferSize uint32) ObjectTemplate {
+	return ObjectTemplate{
+		ObjectType: OBJECT_TREND_LOG,
+		InitialValues: []BACnetPropertyValue{
+			{PropertyID: uint32(PROP_OBJECT_NAME), Value: name},
+			{PropertyID: uint32(PROP_LOG_DEVICE_OBJECT_PROPERTY), Value: DeviceObjectPropertyReference{
+				Object:     source,
+				PropertyID: uint32(PROP_PRESENT_VALUE),
+			}},
+			{PropertyID: uint32(PROP_LOG_INTERVAL), Value: uint32(interval / (10 * time.Millisecond))}, // Hundredths of a second
+			{PropertyID: uint32(PROP_BUFFER_SIZE), Value: bufferSize},
+			{PropertyID: uint32(PROP_STOP_WHEN_FULL), Value: false},
+		},
+		Writes: []BACnetPropertyValue{
+			{PropertyID: uint32(PROP_ENABLE), Value: true},
+		},
+		required: []uint32{
+			uint32(PROP_OBJECT_NAME),
+			uint32(PROP_LOG_DEVICE_OBJECT_PROPERTY),
+			uint32(PROP_LOG_INTERVAL),
+			uint32(PROP_BUFFER_SIZE),
+			uint32(PROP_ENABLE),
+		},
+		validate: func(values map[uint32]interface{}) error {
+			ref, ok := values[uint32(PROP_LOG_DEVICE_OBJECT_PROPERTY)].(DeviceObjectPropertyReference)
+			if !ok || ref.Object.Type != OBJECT_ANALOG_INPUT {
+				return fmt.Errorf("trend log source must be a property of an analog input")
+			}
+			if interval, ok := values[uint32(PROP_LOG_INTERVAL)].(uint32); !ok || interval == 0 {
+				return fmt.Errorf("log interval must be an unsigned number of hundredths of a second, at least 1")
+			}
+			if size, ok := values[uint32(PROP_BUFFER_SIZE)].(uint32); !ok || size == 0 {
+				return fmt.Errorf("buffer size must be a non-zero unsigned")
+			}
+			return nil
+		},
+	}
+}
+
+// ScheduleTemplate returns a template for a schedule commanding the Present_Value of a binary value
+// at the given priority. The schedule falls back to defaultActive when no weekly or exception
+// schedule applies; the weekly schedule itself is left to the caller.
+func ScheduleTemplate(name string, target BACnetObject, defaultActive bool, priority uint8) ObjectTemplate {
+	defaultValue := Enumerated(0) // Inactive
+	if defaultActive {
+		defaultValue = 1 // Active
+	}
+	return ObjectTemplate{
+		ObjectType: OBJECT_SCHEDULE,
+		InitialValues: []BACnetPropertyValue{
+			{PropertyID: uint32(PROP_OBJECT_NAME), Value: name},
+			{PropertyID: uint32(PROP_SCHEDULE_DEFAULT), Value: defaultValue},
+			{PropertyID: uint32(PROP_PRIORITY_FOR_WRITING), Value: uint32(priority)},
+			{PropertyID: uint32(PROP_LIST_OF_OBJECT_PROPERTY_REFERENCES), Value: []DeviceObjectPropertyReference{{
+				Object:     target,
+				PropertyID: uint32(PROP_PRESENT_VALUE),
+			}}},
+		},
+		required: []uint32{
+			uint32(PROP_OBJECT_NAME),
+			uint32(PROP_SCHEDULE_DEFAULT),
+			uint32(PROP_PRIORITY_FOR_WRITING),
+			uint32(PROP_LIST_OF_OBJECT_PROPERTY_REFERENCES),
+		},
+		validate: func(values map[uint32]interface{}) error {
+			refs, ok := values[uint32(PROP_LIST_OF_OBJECT_PROPERTY_REFERENCES)].([]DeviceObjectPropertyReference)
+			if !ok {
+				return fmt.Errorf("schedule targets must be a list of property references")
+			}
+			for _, ref := range refs {
+				if ref.Object.Type != OBJECT_BINARY_VALUE {
+					return fmt.Errorf("schedule target must be a binary value, got %s", ref.Object)
+				}
+			}
+			if p, ok := values[uint32(PROP_PRIORITY_FOR_WRITING)].(uint32); !ok || p < 1 || p > 16 {
+				return fmt.Errorf("priority for writing must be an unsigned between 1 and 16")
+			}
+			return nil
+		},
+	}
+}
+
+// Validate checks that the template sets every required property exactly once, that all values
+// can be encoded and that the template specific constraints hold.
+func (t ObjectTemplate) Validate() error {
+	values := make(map[uint32]interface{})
+	var scratch bytes.Buffer
+	for _, pv := range append(append([]BACnetPropertyValue{}, t.InitialValues...), t.Writes...) {
+		if _, dup := values[pv.PropertyID]; dup {
+			return fmt.Errorf("%s is set more than once", propertyLabel(pv.PropertyID))
+		}
+		if err := encodeApplicationValue(&scratch, pv.Value); err != nil {
+			return fmt.Errorf("%s: %w", propertyLabel(pv.PropertyID), err)
+		}
+		values[pv.PropertyID] = pv.Value
+	}
+	for _, propID := range t.required {
+		if _, ok := values[propID]; !ok {
+			return fmt.Errorf("template for %s is missing %s", objectTypeLabel(t.ObjectType), propertyLabel(propID))
+		}
+	}
+	if t.validate != nil {
+		return t.validate(values)
+	}
+	return nil
+}
+
+// Provision validates a template and creates the object on a device: one CreateObject carrying the
+// initial values, followed by a WriteProperty for each of the template's writes. If a write fails
+// the object is deleted again. If instance is nil the device picks the instance number.
+func (c *BACnetClient) Provision(device DeviceInfo, template ObjectTemplate, instance *uint32, opts ...CallOption) (BACnetObject, error) {
+	if err := template.Validate(); err != nil {
+		return BACnetObject{}, fmt.Errorf("invalid template: %w", err)
+	}
+
+	object, err := c.CreateObject(device, template.ObjectType, instance, template.InitialValues, opts...)
+	if err != nil {
+		return BACnetObject{}, err
+	}
+
+	for _, pv := range template.Writes {
+		if err := c.WriteProperty(device, object, pv.PropertyID, pv.Value, 0, opts...); err != nil {
+			// The object is removed even if the call was cancelled.
+			cleanup := append(slices.Clip(opts), CallContext(context.WithoutCancel(callContext(opts...))))
+			if deleteErr := c.DeleteObject(device, object, cleanup...); deleteErr != nil {
+				return BACnetObject{}, fmt.Errorf("%w (removing %s also failed: %v)", err, object, deleteErr)
+			}
+			return BACnetObject{}, err
+		}
+	}
+	return object, nil
+}
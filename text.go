@@ -0,0 +1,106 @@
+package bacnet
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// CharacterString is a Character String in a character set other than UTF-8. Strings in the
+// character sets the package can convert (UTF-8, ISO 8859-1, UCS-2 and UCS-4) decode to plain Go
+// strings; those in DBCS and JIS X 0208 decode to a CharacterString, so that applications can
+// convert them with code tables of their own. Writing a CharacterString sends it unchanged.
+type CharacterString struct {
+	Charset encoding.CharacterSet
+	// Data is the encoded text following the character set octet, including the code page of DBCS.
+	Data []byte
+}
+
+// NewCharacterString encodes s in the given character set, for writing text to devices that
+// expect e.g. ISO 8859-1 or UCS-2.
+func NewCharacterString(s string, charset encoding.CharacterSet) (CharacterString, error) {
+	data, err := encoding.EncodeText(charset, s)
+	if err != nil {
+		return CharacterString{}, err
+	}
+	return CharacterString{Charset: charset, Data: data}, nil
+}
+
+// Text returns the string as UTF-8, or false if its character set is not supported.
+func (s CharacterString) Text() (string, bool) {
+	return encoding.DecodeText(s.Charset, s.Data)
+}
+
+func (s CharacterString) String() string {
+	if text, ok := s.Text(); ok {
+		return text
+	}
+	return fmt.Sprintf("(character set %d) % x", s.Charset, s.Data)
+}
+
+// TextNormalization selects how a client cleans up the strings it reads; see
+// ClientOptions.TextNormalization.
+type TextNormalization uint32
+
+const (
+	// TextTrimSpace removes leading and trailing white space and NUL characters, e.g. the padding
+	// of fixed-width fields.
+	TextTrimSpace TextNormalization = 1 << iota
+	// TextStripControl removes control characters such as NUL, CR and LF within strings.
+	TextStripControl
+)
+
+// NormalizeText applies the normalizations of n to s.
+func NormalizeText(s string, n TextNormalization) string {
+	if n&TextStripControl != 0 {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	if n&TextTrimSpace != 0 {
+		s = strings.TrimFunc(s, func(r rune) bool { return r == 0 || unicode.IsSpace(r) })
+	}
+	return s
+}
+
+// normalizeText applies ClientOptions.TextNormalization to the strings of a value read.
+func (c *BACnetClient) normalizeText(value interface{}) interface{} {
+	if c.options.TextNormalization == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		return NormalizeText(v, c.options.TextNormalization)
+	case []interface{}:
+		for i := range v {
+			v[i] = c.normalizeText(v[i])
+		}
+	}
+	return value
+}
+
+// encodeText converts the strings of a value to write to ClientOptions.Charset.
+func (c *BACnetClient) encodeText(value interface{}) (interface{}, error) {
+	if c.options.Charset == encoding.CharsetUTF8 {
+		return value, nil
+	}
+	switch v := value.(type) {
+	case string:
+		return NewCharacterString(v, c.options.Charset)
+	case []interface{}:
+		encoded := make([]interface{}, len(v))
+		for i := range v {
+			var err error
+			if encoded[i], err = c.encodeText(v[i]); err != nil {
+				return nil, err
+			}
+		}
+		return encoded, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,204 @@
+package bacnet
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// MessagePriority is the priority of a text message.
+type MessagePriority uint32
+
+const (
+	MessageNormal MessagePriority = iota
+	MessageUrgent
+)
+
+var messagePriorityNames = map[MessagePriority]string{
+	MessageNormal: "normal",
+	MessageUrgent: "urgent",
+}
+
+func (p MessagePriority) String() string { return enumName(messagePriorityNames, p) }
+
+// TextMessage is a ConfirmedTextMessage or UnconfirmedTextMessage, sent by a client or received by
+// a client or a Server.
+type TextMessage struct {
+	// Source is the instance of the device sending the message.
+	Source uint32
+	// Class optionally classifies the message: a uint32 or a string, nil for none.
+	Class    interface{}
+	Priority MessagePriority
+	Message  string
+
+	// Confirmed reports whether a received message was a ConfirmedTextMessage, and Sender is
+	// the address it came from.
+	Confirmed bool
+	Sender    *net.UDPAddr
+}
+
+// SendTextMessage sends a ConfirmedTextMessage to a device and waits for it to be acknowledged.
+func (c *BACnetClient) SendTextMessage(device DeviceInfo, message TextMessage, opts ...CallOption) error {
+	ctx := callContext(opts...)
+	params, err := encodeTextMessage(message)
+	if err != nil {
+		return err
+	}
+
+	_, data, err := c.transact(ctx, device, SERVICE_CONFIRMED_TEXT_MESSAGE, params)
+	if err != nil {
+		return err
+	}
+	if err := expectSimpleAck(data); err != nil {
+		return fmt.Errorf("text message to device %d failed: %w", device.DeviceID, err)
+	}
+	return nil
+}
+
+// SendUnconfirmedTextMessage sends an UnconfirmedTextMessage to addr, typically the broadcast
+// address.
+func (c *BACnetClient) SendUnconfirmedTextMessage(addr *net.UDPAddr, message TextMessage) error {
+	params, err := encodeTextMessage(message)
+	if err != nil {
+		return err
+	}
+	apdu := append([]byte{APDU_UNCONFIRMED_REQUEST, SERVICE_UNCONFIRMED_TEXT_MESSAGE}, params...)
+
+	if _, err := c.conn.WriteTo(serverPacket(BVLC_ORIGINAL_BROADCAST_NPDU, npduHeader{}, apdu), addr); err != nil {
+		return fmt.Errorf("failed to send text message: %w", err)
+	}
+	return nil
+}
+
+// encodeTextMessage encodes the service parameters of a text message.
+func encodeTextMessage(message TextMessage) ([]byte, error) {
+	var params bytes.Buffer
+	encoding.EncodeContextObjectID(&params, 0, uint32(OBJECT_DEVICE), message.Source)
+	switch class := message.Class.(type) {
+	case nil:
+	case uint32:
+		encoding.EncodeOpeningTag(&params, 1)
+		encoding.EncodeContextUnsigned(&params, 0, class)
+		encoding.EncodeClosingTag(&params, 1)
+	case string:
+		encoding.EncodeOpeningTag(&params, 1)
+		encoding.EncodeContextCharacterString(&params, 1, class)
+		encoding.EncodeClosingTag(&params, 1)
+	default:
+		return nil, fmt.Errorf("message class must be a uint32 or a string, not %T", message.Class)
+	}
+	encoding.EncodeContextEnumerated(&params, 2, uint32(message.Priority))
+	encoding.EncodeContextCharacterString(&params, 3, message.Message)
+	return params.Bytes(), nil
+}
+
+// decodeTextMessage decodes the service parameters of a text message.
+func decodeTextMessage(params []byte) (TextMessage, error) {
+	r := bytes.NewReader(params)
+	var message TextMessage
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+	if err != nil {
+		return message, fmt.Errorf("text message source: %w", err)
+	}
+	if objectType != uint32(OBJECT_DEVICE) {
+		return message, fmt.Errorf("text message source is not a device but object type %d", objectType)
+	}
+	message.Source = instance
+
+	h, err := encoding.PeekTagHeader(r)
+	if err != nil {
+		return message, err
+	}
+	if h.IsOpening(1) {
+		encoding.ExpectOpeningTag(r, 1)
+		if h, err = encoding.PeekTagHeader(r); err != nil {
+			return message, err
+		}
+		if h.IsContext(0) {
+			message.Class, err = encoding.DecodeContextUnsigned(r, 0)
+		} else {
+			message.Class, err = encoding.DecodeContextCharacterString(r, 1)
+		}
+		if err != nil {
+			return message, fmt.Errorf("text message class: %w", err)
+		}
+		if err := encoding.ExpectClosingTag(r, 1); err != nil {
+			return message, err
+		}
+	}
+
+	priority, err := encoding.DecodeContextUnsigned(r, 2)
+	if err != nil {
+		return message, fmt.Errorf("text message priority: %w", err)
+	}
+	message.Priority = MessagePriority(priority)
+	if message.Message, err = encoding.DecodeContextCharacterString(r, 3); err != nil {
+		return message, fmt.Errorf("text message: %w", err)
+	}
+	return message, nil
+}
+
+// receiveTextMessage passes a datagram carrying a text message to ClientOptions.OnTextMessage,
+// acknowledging confirmed ones, and reports whether it was one.
+func (c *BACnetClient) receiveTextMessage(data []byte, addr *net.UDPAddr) bool {
+	frame, err := decodeBVLC(data)
+	if err != nil {
+		return false
+	}
+	header, apdu, err := decodeNPDU(frame.npdu)
+	if err != nil || header.isNetworkMessage() || len(apdu) < 2 {
+		return false
+	}
+
+	var message TextMessage
+	switch {
+	case apdu[0]&0xF0 == APDU_UNCONFIRMED_REQUEST && apdu[1] == SERVICE_UNCONFIRMED_TEXT_MESSAGE:
+		message, err = decodeTextMessage(apdu[2:])
+	case apdu[0]&0xF0 == APDU_CONFIRMED_REQUEST && apdu[0]&0x08 == 0 && len(apdu) >= 4 && apdu[3] == SERVICE_CONFIRMED_TEXT_MESSAGE:
+		message, err = decodeTextMessage(apdu[4:])
+		message.Confirmed = true
+		reply := []byte{APDU_SIMPLE_ACK, apdu[2], SERVICE_CONFIRMED_TEXT_MESSAGE}
+		if err != nil {
+			reply = []byte{APDU_REJECT, apdu[2], 0}
+		}
+		c.conn.WriteTo(serverPacket(BVLC_ORIGINAL_UNICAST_NPDU, header, reply), addr)
+	default:
+		return false
+	}
+	if err != nil {
+		c.logf("bacnet: discarded text message from %s: %v", addr, err)
+		return true
+	}
+	message.Sender = addr
+	if c.options.OnTextMessage != nil {
+		c.options.OnTextMessage(message)
+	}
+	return true
+}
+
+// textMessage executes a ConfirmedTextMessage received by the server.
+func (s *Server) textMessage(invokeID byte, params []byte, addr *net.UDPAddr) []byte {
+	message, err := decodeTextMessage(params)
+	if err != nil {
+		return []byte{APDU_REJECT, invokeID, 0}
+	}
+	message.Confirmed, message.Sender = true, addr
+	if s.device.OnTextMessage != nil {
+		if err := s.device.OnTextMessage(message); err != nil {
+			return errorAPDU(invokeID, SERVICE_CONFIRMED_TEXT_MESSAGE, err)
+		}
+	}
+	return []byte{APDU_SIMPLE_ACK, invokeID, SERVICE_CONFIRMED_TEXT_MESSAGE}
+}
+
+// unconfirmedTextMessage passes an UnconfirmedTextMessage received by the server to the device.
+func (s *Server) unconfirmedTextMessage(params []byte, addr *net.UDPAddr) {
+	message, err := decodeTextMessage(params)
+	if err != nil || s.device.OnTextMessage == nil {
+		return
+	}
+	message.Sender = addr
+	s.device.OnTextMessage(message)
+}
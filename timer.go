@@ -0,0 +1,133 @@
+package bacnet
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimerState is the value of Timer_State of a Timer object.
+type TimerState uint32
+
+const (
+	TimerStateIdle    TimerState = 0
+	TimerStateRunning TimerState = 1
+	TimerStateExpired TimerState = 2
+)
+
+var timerStateNames = map[TimerState]string{
+	TimerStateIdle:    "idle",
+	TimerStateRunning: "running",
+	TimerStateExpired: "expired",
+}
+
+func (s TimerState) String() string { return enumName(timerStateNames, s) }
+
+// TimerTransition is the value of Last_State_Change of a Timer object.
+type TimerTransition uint32
+
+const (
+	TimerTransitionNone             TimerTransition = 0
+	TimerTransitionIdleToRunning    TimerTransition = 1
+	TimerTransitionRunningToIdle    TimerTransition = 2
+	TimerTransitionRunningToRunning TimerTransition = 3
+	TimerTransitionRunningToExpired TimerTransition = 4
+	TimerTransitionForcedToExpired  TimerTransition = 5
+	TimerTransitionExpiredToIdle    TimerTransition = 6
+	TimerTransitionExpiredToRunning TimerTransition = 7
+)
+
+var timerTransitionNames = map[TimerTransition]string{
+	TimerTransitionNone:             "none",
+	TimerTransitionIdleToRunning:    "idle-to-running",
+	TimerTransitionRunningToIdle:    "running-to-idle",
+	TimerTransitionRunningToRunning: "running-to-running",
+	TimerTransitionRunningToExpired: "running-to-expired",
+	TimerTransitionForcedToExpired:  "forced-to-expired",
+	TimerTransitionExpiredToIdle:    "expired-to-idle",
+	TimerTransitionExpiredToRunning: "expired-to-running",
+}
+
+func (t TimerTransition) String() string { return enumName(timerTransitionNames, t) }
+
+// TimerStatus is the state of a Timer object. The Present_Value of a timer is the time remaining
+// until expiration, in milliseconds; writing a non-zero value starts the timer.
+type TimerStatus struct {
+	State           TimerState
+	Running         bool
+	Remaining       time.Duration
+	LastStateChange TimerTransition
+}
+
+// ReadTimer reads the state of a Timer object with a single ReadPropertyMultiple request.
+func (c *BACnetClient) ReadTimer(device DeviceInfo, object BACnetObject, opts ...CallOption) (TimerStatus, error) {
+	if object.Type != OBJECT_TIMER {
+		return TimerStatus{}, fmt.Errorf("%s is not a timer", object)
+	}
+	results, err := c.ReadSpecificPropertiesFromObject(device, object, []uint32{
+		PROP_PRESENT_VALUE, PROP_TIMER_STATE, PROP_TIMER_RUNNING, PROP_LAST_STATE_CHANGE,
+	}, opts...)
+	if err != nil {
+		return TimerStatus{}, err
+	}
+	values := results.Map()
+
+	var status TimerStatus
+	if ms, ok := values[PROP_PRESENT_VALUE].(uint32); ok {
+		status.Remaining = time.Duration(ms) * time.Millisecond
+	}
+	if state, ok := values[PROP_TIMER_STATE].(uint32); ok {
+		status.State = TimerState(state)
+	}
+	if running, ok := values[PROP_TIMER_RUNNING].(bool); ok {
+		status.Running = running
+	}
+	if transition, ok := values[PROP_LAST_STATE_CHANGE].(uint32); ok {
+		status.LastStateChange = TimerTransition(transition)
+	}
+	return status, nil
+}
+
+// StartTimer starts (or restarts) a Timer object so that it expires after d.
+func (c *BACnetClient) StartTimer(device DeviceInfo, object BACnetObject, d time.Duration, opts ...CallOption) error {
+	return c.WritePresentValue(device, object, d, 0, opts...)
+}
+
+// StagingStatus is the state of a Staging object: the Present_Value it was commanded to and the
+// stage that value falls into.
+type StagingStatus struct {
+	PresentValue float32
+	// PresentStage is the index of the active stage into Stages and Stage_Names, starting at 1.
+	PresentStage uint32
+	// StageName is the name of the active stage, if the object has Stage_Names.
+	StageName string
+}
+
+// ReadStaging reads the state of a Staging object.
+func (c *BACnetClient) ReadStaging(device DeviceInfo, object BACnetObject, opts ...CallOption) (StagingStatus, error) {
+	if object.Type != OBJECT_STAGING {
+		return StagingStatus{}, fmt.Errorf("%s is not a staging object", object)
+	}
+	results, err := c.ReadSpecificPropertiesFromObject(device, object, []uint32{
+		PROP_PRESENT_VALUE, PROP_PRESENT_STAGE, PROP_STAGE_NAMES,
+	}, opts...)
+	if err != nil {
+		return StagingStatus{}, err
+	}
+	values := results.Map()
+
+	var status StagingStatus
+	if pv, ok := values[PROP_PRESENT_VALUE].(float32); ok {
+		status.PresentValue = pv
+	}
+	if stage, ok := values[PROP_PRESENT_STAGE].(uint32); ok {
+		status.PresentStage = stage
+	}
+	names, ok := values[PROP_STAGE_NAMES].([]interface{})
+	if !ok {
+		names = []interface{}{values[PROP_STAGE_NAMES]} // A single stage decodes to a bare value
+	}
+	if status.PresentStage >= 1 && int(status.PresentStage) <= len(names) {
+		status.StageName, _ = names[status.PresentStage-1].(string)
+	}
+	return status, nil
+}
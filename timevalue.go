@@ -0,0 +1,180 @@
+package bacnet
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParseTime parses a time of day written as HH:MM, HH:MM:SS or HH:MM:SS.hh.
+func ParseTime(s string) (Time, error) {
+	s = strings.TrimSpace(s)
+	clock, hundredths, hasHundredths := strings.Cut(s, ".")
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 || (hasHundredths && len(parts) != 3) {
+		return Time{}, fmt.Errorf("invalid time %q, expected HH:MM, HH:MM:SS or HH:MM:SS.hh", s)
+	}
+	fields := []string{parts[0], parts[1], "0", "0"}
+	if len(parts) == 3 {
+		fields[2] = parts[2]
+	}
+	if hasHundredths {
+		fields[3] = hundredths
+	}
+	limits := []int{23, 59, 59, 99}
+	var values [4]uint8
+	for i, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil || v < 0 || v > limits[i] || len(field) > 2 {
+			return Time{}, fmt.Errorf("invalid time %q", s)
+		}
+		values[i] = uint8(v)
+	}
+	return Time{Hour: values[0], Minute: values[1], Second: values[2], Hundredths: values[3]}, nil
+}
+
+// ParseTimeValue parses a time value written as "time = value", e.g. "08:00 = 21.5". The value is
+// null (relinquish), active or inactive, true or false, a number with a fraction or exponent,
+// which is a Real, or an integer, which is an Unsigned, e.g. a multi-state value's state.
+func ParseTimeValue(s string) (TimeValue, error) {
+	at, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return TimeValue{}, fmt.Errorf("invalid time value %q, expected \"time = value\"", s)
+	}
+	t, err := ParseTime(at)
+	if err != nil {
+		return TimeValue{}, err
+	}
+	v, err := parseScheduleValue(strings.TrimSpace(value))
+	if err != nil {
+		return TimeValue{}, fmt.Errorf("time value %q: %w", s, err)
+	}
+	return TimeValue{Time: t, Value: v}, nil
+}
+
+func parseScheduleValue(s string) (interface{}, error) {
+	switch strings.ToLower(s) {
+	case "null":
+		return nil, nil
+	case "active":
+		return BinaryActive, nil
+	case "inactive":
+		return BinaryInactive, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if !strings.ContainsAny(s, ".eE") {
+		if v, err := strconv.ParseUint(s, 10, 32); err == nil {
+			return uint32(v), nil
+		}
+	}
+	v, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	return float32(v), nil
+}
+
+// ParseDailySchedule parses the time values of a day separated by commas or semicolons, e.g.
+// "07:00 = 21.5, 18:00 = null". Integers are taken as Reals when other values of the day are.
+// The result is validated with ValidateDailySchedule.
+func ParseDailySchedule(s string) ([]TimeValue, error) {
+	var values []TimeValue
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		tv, err := ParseTimeValue(entry)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, tv)
+	}
+
+	hasReal := false
+	for _, tv := range values {
+		if _, ok := tv.Value.(float32); ok {
+			hasReal = true
+		}
+	}
+	if hasReal {
+		for i, tv := range values {
+			if v, ok := tv.Value.(uint32); ok {
+				values[i].Value = float32(v)
+			}
+		}
+	}
+	if err := ValidateDailySchedule(values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ValidateDailySchedule checks the time values of a day before they are written: every time is
+// fully specified and valid, the times are in ascending order without two entries for the same
+// time, and all values but NULL have the same type.
+func ValidateDailySchedule(values []TimeValue) error {
+	var valueType reflect.Type
+	for i, tv := range values {
+		t := tv.Time
+		if t.Hour > 23 || t.Minute > 59 || t.Second > 59 || t.Hundredths > 99 {
+			return fmt.Errorf("entry %d: invalid or unspecified time %s", i, t)
+		}
+		if i > 0 {
+			switch prev := values[i-1].Time; {
+			case timeOfDay(t) == timeOfDay(prev):
+				return fmt.Errorf("entry %d: time %s overlaps the entry before it", i, t)
+			case timeOfDay(t) < timeOfDay(prev):
+				return fmt.Errorf("entry %d: time %s is before %s of the entry before it", i, t, prev)
+			}
+		}
+		if tv.Value == nil {
+			continue
+		}
+		if valueType == nil {
+			valueType = reflect.TypeOf(tv.Value)
+		} else if reflect.TypeOf(tv.Value) != valueType {
+			return fmt.Errorf("entry %d: value %v is a %T, other values are %s", i, tv.Value, tv.Value, valueType)
+		}
+	}
+	return nil
+}
+
+// String returns the time value as "HH:MM:SS.hh = value", the form ParseTimeValue reads.
+func (tv TimeValue) String() string {
+	if tv.Value == nil {
+		return tv.Time.String() + " = null"
+	}
+	return fmt.Sprintf("%s = %v", tv.Time, tv.Value)
+}
+
+// WriteWeeklySchedule validates and writes the Weekly_Schedule of a Schedule object; weekly starts
+// with Monday.
+func (c *BACnetClient) WriteWeeklySchedule(device DeviceInfo, schedule BACnetObject, weekly [7][]TimeValue, opts ...CallOption) error {
+	days := make([]interface{}, len(weekly))
+	for i, day := range weekly {
+		if err := ValidateDailySchedule(day); err != nil {
+			return fmt.Errorf("weekly schedule day %d: %w", i+1, err)
+		}
+		days[i] = dailySchedule(day)
+	}
+	ctx := callContext(opts...)
+	return c.writeProperty(ctx, device, schedule, PROP_WEEKLY_SCHEDULE, nil, days, 0)
+}
+
+// WriteDailySchedule validates and writes one day of the Weekly_Schedule of a Schedule object;
+// weekday runs from 1 (Monday) to 7 (Sunday).
+func (c *BACnetClient) WriteDailySchedule(device DeviceInfo, schedule BACnetObject, weekday uint8, values []TimeValue, opts ...CallOption) error {
+	if weekday < 1 || weekday > 7 {
+		return fmt.Errorf("invalid weekday %d", weekday)
+	}
+	if err := ValidateDailySchedule(values); err != nil {
+		return err
+	}
+	index := uint32(weekday)
+	ctx := callContext(opts...)
+	return c.writeProperty(ctx, device, schedule, PROP_WEEKLY_SCHEDULE, &index, dailySchedule(values), 0)
+}
@@ -0,0 +1,218 @@
+package bacnet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocalNetwork is the network number under which a Topology lists the network the client is on,
+// whose number the client does not learn. 0 is not a valid BACnet network number.
+const LocalNetwork uint16 = 0
+
+// DataLink is the data link layer of a network.
+type DataLink string
+
+const (
+	DataLinkBACnetIP DataLink = "BACnet/IP"
+	DataLinkMSTP     DataLink = "MS/TP"
+	DataLinkEthernet DataLink = "Ethernet"
+	DataLinkSC       DataLink = "BACnet/SC"
+)
+
+// Topology is the graph of a site: its networks, the routers between them and the devices on
+// them. Every node has an ID, and Links connect routers and devices to their networks.
+type Topology struct {
+	Networks []TopologyNetwork `json:"networks"`
+	Routers  []TopologyRouter  `json:"routers"`
+	Devices  []TopologyDevice  `json:"devices"`
+	Links    []TopologyLink    `json:"links"`
+}
+
+// TopologyNetwork is a BACnet network of a site.
+type TopologyNetwork struct {
+	ID     string `json:"id"`
+	Number uint16 `json:"number"`
+	// DataLink is empty if unknown.
+	DataLink DataLink `json:"dataLink,omitempty"`
+	// Reachable is false for remote networks devices were seen on but no router announced.
+	Reachable bool `json:"reachable"`
+}
+
+// TopologyRouter is a router on the local network and the remote networks it reaches.
+type TopologyRouter struct {
+	ID       string   `json:"id"`
+	Address  string   `json:"address"`
+	Networks []uint16 `json:"networks"`
+}
+
+// TopologyDevice is a device and where it is: the IP address and port of a device on the local
+// network, or the network number and MAC address of a remote one.
+type TopologyDevice struct {
+	ID       string `json:"id"`
+	DeviceID uint32 `json:"deviceId"`
+	Name     string `json:"name,omitempty"`
+	Network  uint16 `json:"network"`
+	Address  string `json:"address"`
+	VendorID uint32 `json:"vendorId,omitempty"`
+}
+
+// TopologyLink connects a router or device to a network, by node ID.
+type TopologyLink struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TopologyBuilder assembles a Topology from discovery results, router announcements and what the
+// caller knows about the data links of the site.
+type TopologyBuilder struct {
+	dataLinks map[uint16]DataLink
+	routers   map[string]map[uint16]bool
+	devices   map[uint32]TopologyDevice
+}
+
+// NewTopologyBuilder returns an empty builder.
+func NewTopologyBuilder() *TopologyBuilder {
+	return &TopologyBuilder{
+		dataLinks: map[uint16]DataLink{LocalNetwork: DataLinkBACnetIP},
+		routers:   make(map[string]map[uint16]bool),
+		devices:   make(map[uint32]TopologyDevice),
+	}
+}
+
+// AddDevice adds a device discovered on the local network, e.g. by WhoIs.
+func (b *TopologyBuilder) AddDevice(device DeviceInfo) {
+	address := (&net.UDPAddr{IP: device.IPAddress, Port: device.Port}).String()
+	b.addDevice(TopologyDevice{DeviceID: device.DeviceID, Network: LocalNetwork, Address: address, VendorID: device.VendorID})
+}
+
+// AddRemoteDevice adds a device on a remote network, with its MAC address on that network.
+func (b *TopologyBuilder) AddRemoteDevice(deviceID uint32, network uint16, mac []byte) {
+	b.addDevice(TopologyDevice{DeviceID: deviceID, Network: network, Address: hex.EncodeToString(mac)})
+}
+
+func (b *TopologyBuilder) addDevice(device TopologyDevice) {
+	if known, ok := b.devices[device.DeviceID]; ok {
+		device.Name = known.Name
+		if device.VendorID == 0 {
+			device.VendorID = known.VendorID
+		}
+	}
+	b.devices[device.DeviceID] = device
+}
+
+// NameDevice sets the name of a device added before, e.g. its Object_Name.
+func (b *TopologyBuilder) NameDevice(deviceID uint32, name string) {
+	if device, ok := b.devices[deviceID]; ok {
+		device.Name = name
+		b.devices[deviceID] = device
+	}
+}
+
+// AddRouter adds a router and the networks it announced.
+func (b *TopologyBuilder) AddRouter(router RouterInfo) {
+	networks := b.routers[router.Address]
+	if networks == nil {
+		networks = make(map[uint16]bool)
+		b.routers[router.Address] = networks
+	}
+	for _, network := range router.Networks {
+		networks[network] = true
+	}
+}
+
+// AddHealthReport adds the devices and routers seen by MonitorNetwork.
+func (b *TopologyBuilder) AddHealthReport(report *NetworkHealthReport) {
+	for _, router := range report.Routers {
+		b.AddRouter(router)
+	}
+	for _, announcement := range report.Devices {
+		if addr, err := netip.ParseAddrPort(announcement.Address); err == nil {
+			b.AddDevice(DeviceInfo{DeviceID: announcement.DeviceID, IPAddress: addr.Addr().AsSlice(), Port: int(addr.Port())})
+			continue
+		}
+		// Devices behind routers are reported as network:mac
+		network, mac, ok := strings.Cut(announcement.Address, ":")
+		number, err := strconv.ParseUint(network, 10, 16)
+		if !ok || err != nil {
+			continue
+		}
+		address, err := hex.DecodeString(mac)
+		if err != nil {
+			continue
+		}
+		b.AddRemoteDevice(announcement.DeviceID, uint16(number), address)
+	}
+}
+
+// SetDataLink records the data link layer of a network. The local network is BACnet/IP.
+func (b *TopologyBuilder) SetDataLink(network uint16, link DataLink) {
+	b.dataLinks[network] = link
+}
+
+// Build returns the topology, with networks, routers and devices in a stable order.
+func (b *TopologyBuilder) Build() *Topology {
+	topology := &Topology{}
+	reachable := map[uint16]bool{LocalNetwork: true}
+	networks := map[uint16]bool{LocalNetwork: true}
+
+	addresses := make([]string, 0, len(b.routers))
+	for address := range b.routers {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		router := TopologyRouter{ID: "router:" + address, Address: address}
+		for network := range b.routers[address] {
+			router.Networks = append(router.Networks, network)
+			reachable[network], networks[network] = true, true
+		}
+		sort.Slice(router.Networks, func(i, j int) bool { return router.Networks[i] < router.Networks[j] })
+		topology.Routers = append(topology.Routers, router)
+		topology.Links = append(topology.Links, TopologyLink{From: router.ID, To: networkNodeID(LocalNetwork)})
+		for _, network := range router.Networks {
+			topology.Links = append(topology.Links, TopologyLink{From: router.ID, To: networkNodeID(network)})
+		}
+	}
+
+	for _, device := range b.devices {
+		device.ID = fmt.Sprintf("device:%d", device.DeviceID)
+		topology.Devices = append(topology.Devices, device)
+		networks[device.Network] = true
+	}
+	sort.Slice(topology.Devices, func(i, j int) bool { return topology.Devices[i].DeviceID < topology.Devices[j].DeviceID })
+	for _, device := range topology.Devices {
+		topology.Links = append(topology.Links, TopologyLink{From: device.ID, To: networkNodeID(device.Network)})
+	}
+
+	for network := range b.dataLinks {
+		networks[network] = true
+	}
+	for network := range networks {
+		topology.Networks = append(topology.Networks, TopologyNetwork{
+			ID:        networkNodeID(network),
+			Number:    network,
+			DataLink:  b.dataLinks[network],
+			Reachable: reachable[network],
+		})
+	}
+	sort.Slice(topology.Networks, func(i, j int) bool { return topology.Networks[i].Number < topology.Networks[j].Number })
+	return topology
+}
+
+func networkNodeID(network uint16) string {
+	return fmt.Sprintf("network:%d", network)
+}
+
+// WriteJSON writes the topology to w as indented JSON.
+func (t *Topology) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
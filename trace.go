@@ -0,0 +1,139 @@
+package bacnet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// TracePacket is a UDP datagram of a recorded trace.
+type TracePacket struct {
+	Time time.Time
+	Src  *net.UDPAddr
+	Dst  *net.UDPAddr
+	Data []byte
+}
+
+// pcap link types ReadPcap understands.
+const (
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRaw      = 101
+	linkTypeLinuxSLL = 113
+	linkTypeIPv4     = 228
+)
+
+// ReadPcap reads the IPv4 UDP datagrams of a classic libpcap capture, as written by tcpdump or
+// Wireshark's "pcap" format. pcapng files must be converted first (editcap -F pcap). Other traffic
+// and IP fragments are skipped.
+func ReadPcap(r io.Reader) ([]TracePacket, error) {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read pcap header: %w", err)
+	}
+
+	var order binary.ByteOrder
+	var nanos bool
+	switch magic := binary.LittleEndian.Uint32(header); magic {
+	case 0xa1b2c3d4:
+		order = binary.LittleEndian
+	case 0xd4c3b2a1:
+		order = binary.BigEndian
+	case 0xa1b23c4d:
+		order, nanos = binary.LittleEndian, true
+	case 0x4d3cb2a1:
+		order, nanos = binary.BigEndian, true
+	case 0x0a0d0d0a:
+		return nil, errors.New("pcapng captures are not supported, convert with editcap -F pcap")
+	default:
+		return nil, fmt.Errorf("not a pcap file: magic 0x%08x", magic)
+	}
+	linkType := order.Uint32(header[20:]) & 0xFFFF
+
+	var packets []TracePacket
+	record := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, record); err != nil {
+			if err == io.EOF {
+				return packets, nil
+			}
+			return nil, fmt.Errorf("failed to read pcap record: %w", err)
+		}
+		sec, frac := int64(order.Uint32(record)), int64(order.Uint32(record[4:]))
+		if !nanos {
+			frac *= 1000
+		}
+		frame := make([]byte, order.Uint32(record[8:]))
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return nil, fmt.Errorf("failed to read pcap record: %w", err)
+		}
+
+		ip, ok := ipv4Payload(linkType, frame, order)
+		if !ok {
+			continue
+		}
+		if p, ok := decodeUDPv4(ip); ok {
+			p.Time = time.Unix(sec, frac)
+			packets = append(packets, p)
+		}
+	}
+}
+
+// ipv4Payload strips the link layer header of a captured frame and returns its IPv4 packet.
+func ipv4Payload(linkType uint32, frame []byte, order binary.ByteOrder) ([]byte, bool) {
+	switch linkType {
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return nil, false
+		}
+		etherType, offset := binary.BigEndian.Uint16(frame[12:]), 14
+		for etherType == 0x8100 && len(frame) >= offset+4 { // VLAN tags
+			etherType = binary.BigEndian.Uint16(frame[offset+2:])
+			offset += 4
+		}
+		return frame[offset:], etherType == 0x0800
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 {
+			return nil, false
+		}
+		return frame[16:], binary.BigEndian.Uint16(frame[14:]) == 0x0800
+	case linkTypeNull:
+		// The address family is in the byte order of the capturing host
+		if len(frame) < 4 {
+			return nil, false
+		}
+		return frame[4:], order.Uint32(frame) == 2
+	case linkTypeRaw, linkTypeIPv4:
+		return frame, len(frame) > 0 && frame[0]>>4 == 4
+	}
+	return nil, false
+}
+
+// decodeUDPv4 decodes an unfragmented IPv4 UDP datagram.
+func decodeUDPv4(ip []byte) (TracePacket, bool) {
+	if len(ip) < 20 || ip[0]>>4 != 4 || ip[9] != 17 {
+		return TracePacket{}, false
+	}
+	headerLen := int(ip[0]&0x0F) * 4
+	fragment := binary.BigEndian.Uint16(ip[6:])
+	if fragment&0x3FFF != 0 { // More fragments or a fragment offset
+		return TracePacket{}, false
+	}
+	total := int(binary.BigEndian.Uint16(ip[2:]))
+	if total > len(ip) || headerLen+8 > total {
+		return TracePacket{}, false
+	}
+	udp := ip[headerLen:total]
+	length := int(binary.BigEndian.Uint16(udp[4:]))
+	if length < 8 || length > len(udp) {
+		return TracePacket{}, false
+	}
+	return TracePacket{
+		Src:  &net.UDPAddr{IP: net.IP(append([]byte(nil), ip[12:16]...)), Port: int(binary.BigEndian.Uint16(udp))},
+		Dst:  &net.UDPAddr{IP: net.IP(append([]byte(nil), ip[16:20]...)), Port: int(binary.BigEndian.Uint16(udp[2:]))},
+		Data: append([]byte(nil), udp[8:length]...),
+	}, true
+}
@@ -0,0 +1,129 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// Tracer creates a span for every confirmed transaction of a client, so BACnet calls show up in
+// the distributed traces of the application. Set it in ClientOptions.Tracer.
+//
+// The package does not depend on OpenTelemetry; the otelbacnet module implements Tracer with an
+// OpenTelemetry tracer.
+type Tracer interface {
+	// StartTransaction starts the span of a transaction. ctx is the context of the call, set with
+	// CallContext, so spans join the caller's trace.
+	StartTransaction(ctx context.Context, info TransactionInfo) TransactionSpan
+}
+
+// TransactionSpan is the span of one confirmed transaction.
+type TransactionSpan interface {
+	// End is called once the transaction finished. retries is the number of times the request was
+	// resent; err is set if the request failed or the device answered with an Error, Reject or Abort.
+	End(retries int, err error)
+}
+
+// TransactionInfo describes a confirmed transaction.
+type TransactionInfo struct {
+	Device   DeviceInfo
+	Service  byte
+	InvokeID byte
+	// Object and PropertyID are set for services addressing an object or property; for
+	// ReadPropertyMultiple and SubscribeCOVPropertyMultiple Object is the first object of the request.
+	Object     *BACnetObject
+	PropertyID *uint32
+}
+
+var confirmedServiceNames = map[byte]string{
+	SERVICE_CONFIRMED_COV_NOTIFICATION:                "ConfirmedCOVNotification",
+	SERVICE_CONFIRMED_EVENT_NOTIFICATION:              "ConfirmedEventNotification",
+	SERVICE_CONFIRMED_SUBSCRIBE_COV:                   "SubscribeCOV",
+	SERVICE_CONFIRMED_CREATE_OBJECT:                   "CreateObject",
+	SERVICE_CONFIRMED_DELETE_OBJECT:                   "DeleteObject",
+	SERVICE_CONFIRMED_READ_PROPERTY:                   "ReadProperty",
+	SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE:          "ReadPropertyMultiple",
+	SERVICE_CONFIRMED_WRITE_PROPERTY:                  "WriteProperty",
+	SERVICE_CONFIRMED_WRITE_PROPERTY_MULTIPLE:         "WritePropertyMultiple",
+	SERVICE_CONFIRMED_READ_RANGE:                      "ReadRange",
+	SERVICE_CONFIRMED_DEVICE_COMMUNICATION_CONTROL:    "DeviceCommunicationControl",
+	SERVICE_CONFIRMED_REINITIALIZE_DEVICE:             "ReinitializeDevice",
+	SERVICE_CONFIRMED_TEXT_MESSAGE:                    "ConfirmedTextMessage",
+	SERVICE_CONFIRMED_SUBSCRIBE_COV_PROPERTY_MULTIPLE: "SubscribeCOVPropertyMultiple",
+	SERVICE_CONFIRMED_COV_NOTIFICATION_MULTIPLE:       "ConfirmedCOVNotificationMultiple",
+	SERVICE_CONFIRMED_ACKNOWLEDGE_ALARM:               "AcknowledgeAlarm",
+	SERVICE_CONFIRMED_GET_EVENT_INFORMATION:           "GetEventInformation",
+	SERVICE_CONFIRMED_GET_ALARM_SUMMARY:               "GetAlarmSummary",
+	SERVICE_CONFIRMED_LIFE_SAFETY_OPERATION:           "LifeSafetyOperation",
+}
+
+// ServiceName returns the name of the confirmed service, e.g. "ReadProperty".
+func (t TransactionInfo) ServiceName() string {
+	if name, ok := confirmedServiceNames[t.Service]; ok {
+		return name
+	}
+	return fmt.Sprintf("Service%d", t.Service)
+}
+
+// transactionInfo describes a request for the tracer, decoding the object and property from the
+// service parameters where the service has them.
+func transactionInfo(device DeviceInfo, service, invokeID byte, params []byte) TransactionInfo {
+	info := TransactionInfo{Device: device, Service: service, InvokeID: invokeID}
+	r := bytes.NewReader(params)
+
+	var objectTag uint8
+	switch service {
+	case SERVICE_CONFIRMED_READ_PROPERTY, SERVICE_CONFIRMED_WRITE_PROPERTY, SERVICE_CONFIRMED_READ_PROPERTY_MULTIPLE,
+		SERVICE_CONFIRMED_WRITE_PROPERTY_MULTIPLE, SERVICE_CONFIRMED_DELETE_OBJECT, SERVICE_CONFIRMED_READ_RANGE:
+		objectTag = 0
+	case SERVICE_CONFIRMED_SUBSCRIBE_COV:
+		if _, err := encoding.DecodeContextUnsigned(r, 0); err != nil {
+			return info
+		}
+		objectTag = 1
+	case SERVICE_CONFIRMED_SUBSCRIBE_COV_PROPERTY_MULTIPLE:
+		// Skip to the first subscription specification
+		for {
+			h, err := encoding.DecodeTagHeader(r)
+			if err != nil {
+				return info
+			}
+			if h.IsOpening(4) {
+				break
+			}
+			if err := encoding.SkipValue(r, h); err != nil {
+				return info
+			}
+		}
+		objectTag = 0
+	default:
+		return info
+	}
+
+	objectType, instance, err := encoding.DecodeContextObjectID(r, objectTag)
+	if err != nil {
+		return info
+	}
+	info.Object = &BACnetObject{Type: ObjectType(objectType), Instance: instance}
+	if service == SERVICE_CONFIRMED_READ_PROPERTY || service == SERVICE_CONFIRMED_WRITE_PROPERTY || service == SERVICE_CONFIRMED_READ_RANGE {
+		if propertyID, err := encoding.DecodeContextUnsigned(r, 1); err == nil {
+			info.PropertyID = &propertyID
+		}
+	}
+	return info
+}
+
+// noopSpan is used when the client has no tracer.
+type noopSpan struct{}
+
+func (noopSpan) End(int, error) {}
+
+// startTransactionSpan starts the span of a transaction, or returns a no-op span without a tracer.
+func (c *BACnetClient) startTransactionSpan(ctx context.Context, device DeviceInfo, service, invokeID byte, params []byte) TransactionSpan {
+	if c.options.Tracer == nil {
+		return noopSpan{}
+	}
+	return c.options.Tracer.StartTransaction(ctx, transactionInfo(device, service, invokeID, params))
+}
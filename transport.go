@@ -0,0 +1,18 @@
+package bacnet
+
+import (
+	"net"
+	"time"
+)
+
+// PacketConn is the datagram connection a BACnetClient sends and receives BACnet/IP packets on.
+// *net.UDPConn implements it; ReplayConn implements it for replaying recorded traffic.
+type PacketConn interface {
+	ReadFromUDP(b []byte) (int, *net.UDPAddr, error)
+	WriteTo(b []byte, addr net.Addr) (int, error)
+	SetReadDeadline(t time.Time) error
+	LocalAddr() net.Addr
+	Close() error
+}
+
+var _ PacketConn = (*net.UDPConn)(nil)
@@ -0,0 +1,276 @@
+package bacnet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// ErrDatatypeMismatch is returned by WriteTyped when the value does not have the datatype of the
+// property written.
+var ErrDatatypeMismatch = errors.New("value does not match the datatype of the property")
+
+// Datatype is the application datatype of a BACnet value, numbered like its application tag.
+type Datatype uint8
+
+const (
+	DatatypeNull             = Datatype(encoding.TagNull)
+	DatatypeBoolean          = Datatype(encoding.TagBoolean)
+	DatatypeUnsigned         = Datatype(encoding.TagUnsignedInt)
+	DatatypeSigned           = Datatype(encoding.TagSignedInt)
+	DatatypeReal             = Datatype(encoding.TagReal)
+	DatatypeDouble           = Datatype(encoding.TagDouble)
+	DatatypeOctetString      = Datatype(encoding.TagOctetString)
+	DatatypeCharacterString  = Datatype(encoding.TagCharacterString)
+	DatatypeBitString        = Datatype(encoding.TagBitString)
+	DatatypeEnumerated       = Datatype(encoding.TagEnumerated)
+	DatatypeDate             = Datatype(encoding.TagDate)
+	DatatypeTime             = Datatype(encoding.TagTime)
+	DatatypeObjectIdentifier = Datatype(encoding.TagObjectID)
+)
+
+var datatypeNames = map[Datatype]string{
+	DatatypeNull:             "Null",
+	DatatypeBoolean:          "Boolean",
+	DatatypeUnsigned:         "Unsigned",
+	DatatypeSigned:           "Signed",
+	DatatypeReal:             "Real",
+	DatatypeDouble:           "Double",
+	DatatypeOctetString:      "OctetString",
+	DatatypeCharacterString:  "CharacterString",
+	DatatypeBitString:        "BitString",
+	DatatypeEnumerated:       "Enumerated",
+	DatatypeDate:             "Date",
+	DatatypeTime:             "Time",
+	DatatypeObjectIdentifier: "ObjectIdentifier",
+}
+
+func (d Datatype) String() string {
+	if name, ok := datatypeNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("Datatype(%d)", uint8(d))
+}
+
+// TypedValue is a value whose datatype is explicit, written with WriteTyped. The wrapper types
+// below, Enumerated, BinaryPV, Polarity, CharacterString, Date, Time and BACnetObject are
+// TypedValues.
+type TypedValue interface {
+	Datatype() Datatype
+	// untyped returns the value as encodeApplicationValue takes it.
+	untyped() interface{}
+}
+
+type (
+	// Null relinquishes a commandable property.
+	Null struct{}
+	// Boolean is a BACnet Boolean value.
+	Boolean bool
+	// Unsigned is a BACnet Unsigned value.
+	Unsigned uint32
+	// Signed is a BACnet Signed value.
+	Signed int32
+	// Real is a BACnet Real value.
+	Real float32
+	// Double is a BACnet Double value.
+	Double float64
+	// OctetString is a BACnet OctetString value.
+	OctetString []byte
+	// Text is a BACnet CharacterString value, encoded in ClientOptions.Charset.
+	Text string
+	// BitString is a BACnet BitString value.
+	BitString []bool
+)
+
+func (Null) Datatype() Datatype        { return DatatypeNull }
+func (Boolean) Datatype() Datatype     { return DatatypeBoolean }
+func (Unsigned) Datatype() Datatype    { return DatatypeUnsigned }
+func (Signed) Datatype() Datatype      { return DatatypeSigned }
+func (Real) Datatype() Datatype        { return DatatypeReal }
+func (Double) Datatype() Datatype      { return DatatypeDouble }
+func (OctetString) Datatype() Datatype { return DatatypeOctetString }
+func (Text) Datatype() Datatype        { return DatatypeCharacterString }
+func (BitString) Datatype() Datatype   { return DatatypeBitString }
+
+func (Enumerated) Datatype() Datatype      { return DatatypeEnumerated }
+func (BinaryPV) Datatype() Datatype        { return DatatypeEnumerated }
+func (Polarity) Datatype() Datatype        { return DatatypeEnumerated }
+func (CharacterString) Datatype() Datatype { return DatatypeCharacterString }
+func (Date) Datatype() Datatype            { return DatatypeDate }
+func (Time) Datatype() Datatype            { return DatatypeTime }
+func (BACnetObject) Datatype() Datatype    { return DatatypeObjectIdentifier }
+
+func (Null) untyped() interface{}          { return nil }
+func (v Boolean) untyped() interface{}     { return bool(v) }
+func (v Unsigned) untyped() interface{}    { return uint32(v) }
+func (v Signed) untyped() interface{}      { return int32(v) }
+func (v Real) untyped() interface{}        { return float32(v) }
+func (v Double) untyped() interface{}      { return float64(v) }
+func (v OctetString) untyped() interface{} { return []byte(v) }
+func (v Text) untyped() interface{}        { return string(v) }
+func (v BitString) untyped() interface{}   { return []bool(v) }
+
+func (v Enumerated) untyped() interface{}      { return v }
+func (v BinaryPV) untyped() interface{}        { return v }
+func (v Polarity) untyped() interface{}        { return v }
+func (v CharacterString) untyped() interface{} { return v }
+func (v Date) untyped() interface{}            { return v }
+func (v Time) untyped() interface{}            { return v }
+func (v BACnetObject) untyped() interface{}    { return v }
+
+// propertyDatatypes holds the datatype of properties that have the same one in every object type.
+var propertyDatatypes = map[uint32]Datatype{
+	PROP_OBJECT_NAME:              DatatypeCharacterString,
+	PROP_DESCRIPTION:              DatatypeCharacterString,
+	PROP_ACTIVE_TEXT:              DatatypeCharacterString,
+	PROP_INACTIVE_TEXT:            DatatypeCharacterString,
+	PROP_PROFILE_NAME:             DatatypeCharacterString,
+	PROP_OUT_OF_SERVICE:           DatatypeBoolean,
+	PROP_ENABLE:                   DatatypeBoolean,
+	PROP_STOP_WHEN_FULL:           DatatypeBoolean,
+	PROP_NOTIFICATION_CLASS:       DatatypeUnsigned,
+	PROP_UPDATE_INTERVAL:          DatatypeUnsigned,
+	PROP_APDU_TIMEOUT:             DatatypeUnsigned,
+	PROP_APDU_SEGMENT_TIMEOUT:     DatatypeUnsigned,
+	PROP_LOG_INTERVAL:             DatatypeUnsigned,
+	PROP_BUFFER_SIZE:              DatatypeUnsigned,
+	PROP_RECORD_COUNT:             DatatypeUnsigned,
+	PROP_NUMBER_OF_STATES:         DatatypeUnsigned,
+	PROP_PRIORITY_FOR_WRITING:     DatatypeUnsigned,
+	PROP_MAX_MASTER:               DatatypeUnsigned,
+	PROP_MAX_INFO_FRAMES:          DatatypeUnsigned,
+	PROP_DEFAULT_TIMEOUT:          DatatypeUnsigned,
+	PROP_INITIAL_TIMEOUT:          DatatypeUnsigned,
+	PROP_VENDOR_IDENTIFIER:        DatatypeUnsigned,
+	PROP_MAX_APDU_LENGTH_ACCEPTED: DatatypeUnsigned,
+	PROP_EVENT_ENABLE:             DatatypeBitString,
+	PROP_LIMIT_ENABLE:             DatatypeBitString,
+	PROP_STATUS_FLAGS:             DatatypeBitString,
+	PROP_OBJECT_IDENTIFIER:        DatatypeObjectIdentifier,
+	PROP_BIAS:                     DatatypeReal,
+	PROP_ERROR_LIMIT:              DatatypeReal,
+}
+
+// presentValueDatatype returns the datatype of the Present_Value of an object type, like
+// CoercePresentValue converts to.
+func presentValueDatatype(objectType ObjectType) (Datatype, bool) {
+	switch objectType {
+	case OBJECT_ANALOG_INPUT, OBJECT_ANALOG_OUTPUT, OBJECT_ANALOG_VALUE, OBJECT_STAGING:
+		return DatatypeReal, true
+	case OBJECT_LARGE_ANALOG_VALUE:
+		return DatatypeDouble, true
+	case OBJECT_INTEGER_VALUE:
+		return DatatypeSigned, true
+	case OBJECT_POSITIVE_INTEGER_VALUE, OBJECT_MULTI_STATE_INPUT, OBJECT_MULTI_STATE_OUTPUT, OBJECT_MULTI_STATE_VALUE, OBJECT_TIMER:
+		return DatatypeUnsigned, true
+	case OBJECT_BINARY_INPUT, OBJECT_BINARY_OUTPUT, OBJECT_BINARY_VALUE:
+		return DatatypeEnumerated, true
+	case OBJECT_CHARACTERSTRING_VALUE:
+		return DatatypeCharacterString, true
+	case OBJECT_OCTETSTRING_VALUE:
+		return DatatypeOctetString, true
+	case OBJECT_DATE_VALUE:
+		return DatatypeDate, true
+	case OBJECT_TIME_VALUE:
+		return DatatypeTime, true
+	}
+	return 0, false
+}
+
+// PropertyDatatype returns the datatype of a property of objects of a type, and false for
+// properties whose datatype is not known, e.g. constructed or proprietary ones.
+func PropertyDatatype(objectType ObjectType, propertyID uint32) (Datatype, bool) {
+	switch propertyID {
+	case PROP_PRESENT_VALUE, PROP_RELINQUISH_DEFAULT:
+		return presentValueDatatype(objectType)
+	case PROP_COV_INCREMENT, PROP_HIGH_LIMIT, PROP_LOW_LIMIT, PROP_DEADBAND:
+		switch objectType {
+		case OBJECT_ANALOG_INPUT, OBJECT_ANALOG_OUTPUT, OBJECT_ANALOG_VALUE:
+			return DatatypeReal, true
+		case OBJECT_LARGE_ANALOG_VALUE:
+			return DatatypeDouble, true
+		}
+		return 0, false
+	}
+	if datatype, ok := propertyDatatypes[propertyID]; ok {
+		return datatype, true
+	}
+	if enumeratedProperty(objectType, propertyID) {
+		return DatatypeEnumerated, true
+	}
+	return 0, false
+}
+
+// WriteOption configures WriteTyped. CallOptions are WriteOptions too.
+type WriteOption interface {
+	applyWrite(*writeOptions)
+}
+
+type writeOptions struct {
+	priority   uint8
+	arrayIndex *uint32
+	property   uint32
+	call       []CallOption
+}
+
+type writeOptionFunc func(*writeOptions)
+
+func (f writeOptionFunc) applyWrite(o *writeOptions) { f(o) }
+
+func (o CallOption) applyWrite(w *writeOptions) { w.call = append(w.call, o) }
+
+// WithPriority writes at a command priority (1-16).
+func WithPriority(priority uint8) WriteOption {
+	return writeOptionFunc(func(o *writeOptions) { o.priority = priority })
+}
+
+// WithArrayIndex writes a single element of an array property.
+func WithArrayIndex(index uint32) WriteOption {
+	return writeOptionFunc(func(o *writeOptions) { o.arrayIndex = &index })
+}
+
+// WithProperty writes a property other than Present_Value.
+func WithProperty(propertyID uint32) WriteOption {
+	return writeOptionFunc(func(o *writeOptions) { o.property = propertyID })
+}
+
+// WriteTyped writes a value of an explicit datatype to the Present_Value of an object, or to the
+// property chosen with WithProperty, e.g.
+//
+//	client.WriteTyped(device, object, bacnet.Real(21.5), bacnet.WithPriority(8))
+//
+// The datatype is checked against PropertyDatatype before anything is sent, and a mismatch, such
+// as an Unsigned written to a Real property, fails with ErrDatatypeMismatch. Null is accepted for
+// the Present_Value to relinquish a priority. Properties of unknown datatype are written unchecked.
+func (c *BACnetClient) WriteTyped(device DeviceInfo, object BACnetObject, value TypedValue, opts ...WriteOption) error {
+	options := writeOptions{property: PROP_PRESENT_VALUE}
+	for _, opt := range opts {
+		opt.applyWrite(&options)
+	}
+	if value == nil {
+		value = Null{}
+	}
+	if err := checkDatatype(object, options.property, options.arrayIndex, value); err != nil {
+		return err
+	}
+	ctx := callContext(options.call...)
+	return c.writeProperty(ctx, device, object, options.property, options.arrayIndex, value.untyped(), options.priority)
+}
+
+// checkDatatype reports a value that does not have the datatype of the property it is written to.
+// Elements of arrays are not checked, as their datatype differs from that of the property.
+func checkDatatype(object BACnetObject, propertyID uint32, arrayIndex *uint32, value TypedValue) error {
+	if arrayIndex != nil {
+		return nil
+	}
+	want, ok := PropertyDatatype(object.Type, propertyID)
+	if !ok {
+		return nil
+	}
+	got := value.Datatype()
+	if got == want || (got == DatatypeNull && propertyID == PROP_PRESENT_VALUE) {
+		return nil
+	}
+	return fmt.Errorf("cannot write %s to %s of %s, which is %s: %w", got, propertyLabel(propertyID), object, want, ErrDatatypeMismatch)
+}
@@ -0,0 +1,123 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// WhoIsProxyOptions configures a WhoIsProxy.
+type WhoIsProxyOptions struct {
+	// MaxAge, if set, stops the proxy from answering for devices not seen for longer.
+	MaxAge time.Duration
+	// OnAnswer, if set, is called for every I-Am sent on behalf of a device.
+	OnAnswer func(device DeviceInfo, to *net.UDPAddr)
+}
+
+// WhoIsProxy answers Who-Is requests on behalf of the devices in a DeviceCache, for networks
+// whose broadcasts do not reach the devices, e.g. lab setups split across VLANs. Every answer is
+// a Forwarded-NPDU carrying the I-Am of a device with its own address as the originating address,
+// so the requester talks to the device directly. Keep the cache up to date with a
+// DiscoveryRefresher on a network that reaches the devices.
+type WhoIsProxy struct {
+	conn    *net.UDPConn
+	cache   *DeviceCache
+	options WhoIsProxyOptions
+}
+
+// NewWhoIsProxy returns a proxy answering from cache on conn, which should be bound to the
+// BACnet/IP port of the network the requests come from.
+func NewWhoIsProxy(conn *net.UDPConn, cache *DeviceCache, options WhoIsProxyOptions) *WhoIsProxy {
+	return &WhoIsProxy{conn: conn, cache: cache, options: options}
+}
+
+// Run answers Who-Is requests until ctx is cancelled or the connection fails.
+func (p *WhoIsProxy) Run(ctx context.Context) error {
+	buf := make([]byte, serverMaxAPDU+encapsulationOverhead)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		p.conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, addr, err := p.conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		for _, device := range p.answers(buf[:n]) {
+			if _, err := p.conn.WriteTo(device.packet, addr); err != nil {
+				return err
+			}
+			if p.options.OnAnswer != nil {
+				p.options.OnAnswer(device.DeviceInfo, addr)
+			}
+		}
+	}
+}
+
+// proxyAnswer is an I-Am sent on behalf of a device.
+type proxyAnswer struct {
+	DeviceInfo
+	packet []byte
+}
+
+// answers returns the I-Ams answering a datagram if it is a Who-Is.
+func (p *WhoIsProxy) answers(data []byte) []proxyAnswer {
+	frame, err := decodeBVLC(data)
+	if err != nil || (frame.function != BVLC_ORIGINAL_BROADCAST_NPDU && frame.function != BVLC_ORIGINAL_UNICAST_NPDU) {
+		return nil
+	}
+	header, apdu, err := decodeNPDU(frame.npdu)
+	if err != nil || header.isNetworkMessage() || len(apdu) < 2 ||
+		apdu[0]&0xF0 != APDU_UNCONFIRMED_REQUEST || apdu[1] != SERVICE_UNCONFIRMED_WHO_IS {
+		return nil
+	}
+	low, high, ok := decodeWhoIsRange(apdu[2:])
+	if !ok {
+		return nil
+	}
+
+	var answers []proxyAnswer
+	for _, cached := range p.cache.Devices() {
+		if cached.DeviceID < low || cached.DeviceID > high || cached.IPAddress.To4() == nil {
+			continue
+		}
+		if p.options.MaxAge > 0 && time.Since(cached.LastSeen) > p.options.MaxAge {
+			continue
+		}
+		answers = append(answers, proxyAnswer{cached.DeviceInfo, proxiedIAmPacket(cached.DeviceInfo, header)})
+	}
+	return answers
+}
+
+// proxiedIAmPacket builds the Forwarded-NPDU with the I-Am of device, originating from the
+// device's address.
+func proxiedIAmPacket(device DeviceInfo, request npduHeader) []byte {
+	var apdu bytes.Buffer
+	apdu.WriteByte(APDU_UNCONFIRMED_REQUEST)
+	apdu.WriteByte(SERVICE_UNCONFIRMED_I_AM)
+	encoding.EncodeApplicationObjectID(&apdu, uint32(OBJECT_DEVICE), device.DeviceID)
+	maxAPDU := uint32(device.MaxAPDU)
+	if maxAPDU == 0 {
+		maxAPDU = 480
+	}
+	encoding.EncodeApplicationUnsigned(&apdu, maxAPDU)
+	encoding.EncodeApplicationEnumerated(&apdu, 3) // No segmentation
+	encoding.EncodeApplicationUnsigned(&apdu, device.VendorID)
+
+	packet := serverPacket(BVLC_FORWARDED_NPDU, request, apdu.Bytes())
+	origin := make([]byte, 6)
+	copy(origin, device.IPAddress.To4())
+	binary.BigEndian.PutUint16(origin[4:], uint16(device.Port))
+	packet = append(packet[:4], append(origin, packet[4:]...)...)
+	binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+	return packet
+}
@@ -0,0 +1,199 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// PropertyWrite is one write of a WritePropertyMultiple request.
+type PropertyWrite struct {
+	Object     BACnetObject
+	PropertyID uint32
+	// ArrayIndex, if set, writes a single element of an array property.
+	ArrayIndex *uint32
+	Value      interface{}
+	// Priority is the command priority (1-16) for commandable properties, or 0.
+	Priority uint8
+}
+
+// WritePropertyMultipleError is returned by WritePropertyMultiple when the device failed one of
+// the writes. The writes before it were made; the device did not attempt those after it.
+type WritePropertyMultipleError struct {
+	// Index is the index of the failed write in the request.
+	Index int
+	Write PropertyWrite
+	Err   error
+}
+
+func (e *WritePropertyMultipleError) Error() string {
+	return fmt.Sprintf("WritePropertyMultiple failed at %s of %s: %v", propertyLabel(e.Write.PropertyID), e.Write.Object, e.Err)
+}
+
+func (e *WritePropertyMultipleError) Unwrap() error { return e.Err }
+
+// WritePropertyMultiple writes several properties with a single request. The device makes the
+// writes in order and stops at the first that fails, which is reported as a
+// *WritePropertyMultipleError. Every write is authorized and audited like a WriteProperty.
+func (c *BACnetClient) WritePropertyMultiple(device DeviceInfo, writes []PropertyWrite, opts ...CallOption) error {
+	return c.writePropertyMultiple(callContext(opts...), device, writes)
+}
+
+func (c *BACnetClient) writePropertyMultiple(ctx context.Context, device DeviceInfo, writes []PropertyWrite) (err error) {
+	if len(writes) == 0 {
+		return nil
+	}
+	send := false
+	for i, w := range writes {
+		if w.Priority > 16 {
+			return fmt.Errorf("invalid write priority %d", w.Priority)
+		}
+		mutation := Mutation{
+			Service:    SERVICE_CONFIRMED_WRITE_PROPERTY_MULTIPLE,
+			Device:     device,
+			Object:     &writes[i].Object,
+			PropertyID: &writes[i].PropertyID,
+			ArrayIndex: w.ArrayIndex,
+			Value:      w.Value,
+			Priority:   w.Priority,
+		}
+		ok, err := c.authorize(ctx, mutation)
+		if err != nil {
+			return err
+		}
+		send = send || ok
+	}
+	if !send {
+		return nil // Dry run
+	}
+
+	params, err := c.encodeWritePropertyMultiple(writes)
+	if err != nil {
+		return err
+	}
+
+	if c.options.AuditStore != nil {
+		audits := make([]WriteAudit, len(writes))
+		for i, w := range writes {
+			audits[i] = c.newWriteAudit(ctx, device, w.Object, w.PropertyID, w.ArrayIndex, w.Value, w.Priority)
+		}
+		defer func() { c.auditWrites(audits, err) }()
+	}
+
+	_, data, err := c.transact(ctx, device, SERVICE_CONFIRMED_WRITE_PROPERTY_MULTIPLE, params)
+	if err != nil {
+		return err
+	}
+	apdu, err := apduFromPacket(data)
+	if err != nil {
+		return err
+	}
+	if apdu[0]&0xF0 == APDU_ERROR && len(apdu) >= 3 {
+		if failed, err := decodeWritePropertyMultipleError(apdu[3:], writes); err == nil {
+			return failed
+		}
+	}
+	if err := expectSimpleAck(data); err != nil {
+		return fmt.Errorf("WritePropertyMultiple failed: %w", err)
+	}
+	return nil
+}
+
+// encodeWritePropertyMultiple encodes the parameters of a WritePropertyMultiple request. Writes
+// are encoded in the order given; consecutive writes to the same object share one
+// WriteAccessSpecification.
+func (c *BACnetClient) encodeWritePropertyMultiple(writes []PropertyWrite) ([]byte, error) {
+	var params bytes.Buffer
+	for i, w := range writes {
+		if i == 0 || writes[i-1].Object != w.Object {
+			if i > 0 {
+				encoding.EncodeClosingTag(&params, 1)
+			}
+			encoding.EncodeContextObjectID(&params, 0, uint32(w.Object.Type), w.Object.Instance)
+			encoding.EncodeOpeningTag(&params, 1)
+		}
+		encoding.EncodeContextEnumerated(&params, 0, w.PropertyID)
+		if w.ArrayIndex != nil {
+			encoding.EncodeContextUnsigned(&params, 1, *w.ArrayIndex)
+		}
+		value, err := c.encodeText(w.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", propertyLabel(w.PropertyID), w.Object, err)
+		}
+		encoding.EncodeOpeningTag(&params, 2)
+		if err := encodeApplicationValue(&params, value); err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", propertyLabel(w.PropertyID), w.Object, err)
+		}
+		encoding.EncodeClosingTag(&params, 2)
+		if w.Priority != 0 {
+			encoding.EncodeContextUnsigned(&params, 3, uint32(w.Priority))
+		}
+	}
+	encoding.EncodeClosingTag(&params, 1)
+	return params.Bytes(), nil
+}
+
+// decodeWritePropertyMultipleError decodes the service data of a WritePropertyMultiple-Error and
+// finds the failed write among writes.
+func decodeWritePropertyMultipleError(data []byte, writes []PropertyWrite) (*WritePropertyMultipleError, error) {
+	r := bytes.NewReader(data)
+	if err := encoding.ExpectOpeningTag(r, 0); err != nil {
+		return nil, err
+	}
+	var codes [2]uint32
+	for i := range codes {
+		tag, err := encoding.DecodeTagHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if tag.Context || tag.Number != encoding.TagEnumerated {
+			return nil, fmt.Errorf("expected enumerated error class/code, got tag %d", tag.Number)
+		}
+		if codes[i], err = encoding.DecodeUnsigned(r, tag.Length); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoding.ExpectClosingTag(r, 0); err != nil {
+		return nil, err
+	}
+
+	// First failed write attempt: BACnetObjectPropertyReference
+	if err := encoding.ExpectOpeningTag(r, 1); err != nil {
+		return nil, err
+	}
+	objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	propertyID, err := encoding.DecodeContextUnsigned(r, 1)
+	if err != nil {
+		return nil, err
+	}
+	var arrayIndex *uint32
+	if h, err := encoding.PeekTagHeader(r); err == nil && h.IsContext(2) {
+		index, err := encoding.DecodeContextUnsigned(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		arrayIndex = &index
+	}
+
+	failed := &WritePropertyMultipleError{
+		Index: -1,
+		Write: PropertyWrite{Object: BACnetObject{Type: ObjectType(objectType), Instance: instance}, PropertyID: propertyID, ArrayIndex: arrayIndex},
+		Err:   &PropertyAccessError{Class: codes[0], Code: codes[1]},
+	}
+	for i, w := range writes {
+		if w.Object == failed.Write.Object && w.PropertyID == propertyID && sameArrayIndex(w.ArrayIndex, arrayIndex) {
+			failed.Index, failed.Write = i, w
+			break
+		}
+	}
+	return failed, nil
+}
+
+func sameArrayIndex(a, b *uint32) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
@@ -0,0 +1,104 @@
+package bacnet
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// decodeWritePropertyMultiple decodes the parameters of a WritePropertyMultiple request, the way a
+// device does.
+func decodeWritePropertyMultiple(params []byte) ([]PropertyWrite, error) {
+	r := bytes.NewReader(params)
+	var writes []PropertyWrite
+	for r.Len() > 0 {
+		objectType, instance, err := encoding.DecodeContextObjectID(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		object := BACnetObject{Type: ObjectType(objectType), Instance: instance}
+		if err := encoding.ExpectOpeningTag(r, 1); err != nil {
+			return nil, err
+		}
+		for {
+			tag, err := encoding.PeekTagHeader(r)
+			if err != nil {
+				return nil, err
+			}
+			if tag.IsClosing(1) {
+				encoding.DecodeTagHeader(r)
+				break
+			}
+			w := PropertyWrite{Object: object}
+			if w.PropertyID, err = encoding.DecodeContextUnsigned(r, 0); err != nil {
+				return nil, err
+			}
+			if tag, _ := encoding.PeekTagHeader(r); tag.IsContext(1) {
+				index, err := encoding.DecodeContextUnsigned(r, 1)
+				if err != nil {
+					return nil, err
+				}
+				w.ArrayIndex = &index
+			}
+			if err := encoding.ExpectOpeningTag(r, 2); err != nil {
+				return nil, err
+			}
+			if w.Value, err = decodeApplicationValue(r); err != nil {
+				return nil, err
+			}
+			if err := encoding.ExpectClosingTag(r, 2); err != nil {
+				return nil, fmt.Errorf("more than one value: %w", err)
+			}
+			if tag, _ := encoding.PeekTagHeader(r); tag.IsContext(3) {
+				priority, err := encoding.DecodeContextUnsigned(r, 3)
+				if err != nil {
+					return nil, err
+				}
+				w.Priority = uint8(priority)
+			}
+			writes = append(writes, w)
+		}
+	}
+	return writes, nil
+}
+
+func TestWritePropertyMultipleRoundTrip(t *testing.T) {
+	index := uint32(3)
+	av1 := BACnetObject{Type: OBJECT_ANALOG_VALUE, Instance: 1}
+	bv7 := BACnetObject{Type: OBJECT_BINARY_VALUE, Instance: 7}
+	writes := []PropertyWrite{
+		{Object: av1, PropertyID: PROP_PRESENT_VALUE, Value: float32(21.5), Priority: 8},
+		{Object: av1, PropertyID: PROP_OBJECT_NAME, Value: "Zone 1"},
+		{Object: bv7, PropertyID: PROP_PRESENT_VALUE, Value: uint32(1), Priority: 16},
+		// Writes to an object that was written before stay where they are.
+		{Object: av1, PropertyID: PROP_PRIORITY_ARRAY, ArrayIndex: &index, Value: nil},
+	}
+
+	client := NewClientWithConn(nil, ClientOptions{})
+	params, err := client.encodeWritePropertyMultiple(writes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		again, err := client.encodeWritePropertyMultiple(writes)
+		if err != nil || !bytes.Equal(again, params) {
+			t.Fatalf("encoding changed: %x, then %x (%v)", params, again, err)
+		}
+	}
+
+	got, err := decodeWritePropertyMultiple(params)
+	if err != nil {
+		t.Fatalf("decode %x: %v", params, err)
+	}
+	if !reflect.DeepEqual(got, writes) {
+		t.Errorf("decoded %+v, want %+v", got, writes)
+	}
+
+	// Three WriteAccessSpecifications, as av1 is not written consecutively.
+	if n := bytes.Count(params, []byte{0x1E}); n != 3 {
+		t.Errorf("%d list-of-properties opening tags, want 3", n)
+	}
+}
@@ -0,0 +1,299 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// ErrStandby is returned by operations that change devices while the client is the standby member
+// of a redundant pair (see StartFailover).
+var ErrStandby = errors.New("client is in standby")
+
+// Enumerated is a BACnet Enumerated value. Unsigned integers are written as Unsigned, so use
+// Enumerated for properties such as Units or the Present_Value of binary objects.
+type Enumerated uint32
+
+// DeviceObjectPropertyReference refers to a property of an object, optionally on another device.
+type DeviceObjectPropertyReference struct {
+	Object     BACnetObject `json:"object"`
+	PropertyID uint32       `json:"propertyId"`
+	ArrayIndex *uint32      `json:"arrayIndex,omitempty"`
+	// Device is the device holding the object. Nil means the device the reference is stored in.
+	Device *BACnetObject `json:"device,omitempty"`
+}
+
+func (r DeviceObjectPropertyReference) encode(buf *bytes.Buffer) {
+	encoding.EncodeContextObjectID(buf, 0, uint32(r.Object.Type), r.Object.Instance)
+	encoding.EncodeContextEnumerated(buf, 1, r.PropertyID)
+	if r.ArrayIndex != nil {
+		encoding.EncodeContextUnsigned(buf, 2, *r.ArrayIndex)
+	}
+	if r.Device != nil {
+		encoding.EncodeContextObjectID(buf, 3, uint32(r.Device.Type), r.Device.Instance)
+	}
+}
+
+// encodeApplicationValue writes value as one or more application-tagged values. Slices other than
+// []byte and []bool are written element by element.
+func encodeApplicationValue(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		encoding.EncodeApplicationNull(buf)
+	case bool:
+		encoding.EncodeApplicationBoolean(buf, v)
+	case uint32:
+		encoding.EncodeApplicationUnsigned(buf, v)
+	case uint:
+		encoding.EncodeApplicationUnsigned(buf, uint32(v))
+	case int32:
+		encoding.EncodeApplicationSigned(buf, v)
+	case int:
+		encoding.EncodeApplicationSigned(buf, int32(v))
+	case float32:
+		encoding.EncodeApplicationReal(buf, v)
+	case float64:
+		encoding.EncodeApplicationDouble(buf, v)
+	case string:
+		encoding.EncodeApplicationCharacterString(buf, v)
+	case CharacterString:
+		encoding.EncodeApplicationCharacterStringData(buf, v.Charset, v.Data)
+	case []byte:
+		encoding.EncodeApplicationOctetString(buf, v)
+	case []bool:
+		encoding.EncodeApplicationBitString(buf, v)
+	case Enumerated:
+		encoding.EncodeApplicationEnumerated(buf, uint32(v))
+	case BinaryPV:
+		encoding.EncodeApplicationEnumerated(buf, uint32(v))
+	case Polarity:
+		encoding.EncodeApplicationEnumerated(buf, uint32(v))
+	case Date:
+		encoding.EncodeApplicationDate(buf, v.Year, v.Month, v.Day, v.Weekday)
+	case Time:
+		encoding.EncodeApplicationTime(buf, v.Hour, v.Minute, v.Second, v.Hundredths)
+	case DateTime:
+		encoding.EncodeApplicationDate(buf, v.Date.Year, v.Date.Month, v.Date.Day, v.Date.Weekday)
+		encoding.EncodeApplicationTime(buf, v.Time.Hour, v.Time.Minute, v.Time.Second, v.Time.Hundredths)
+	case BACnetObject:
+		encoding.EncodeApplicationObjectID(buf, uint32(v.Type), v.Instance)
+	case StatusFlags:
+		encoding.EncodeApplicationBitString(buf, []bool{v.InAlarm, v.Fault, v.Overridden, v.OutOfService})
+	case DeviceObjectPropertyReference:
+		v.encode(buf)
+	case []DeviceObjectPropertyReference:
+		for _, ref := range v {
+			ref.encode(buf)
+		}
+	case []AddressBinding:
+		for _, binding := range v {
+			binding.encode(buf)
+		}
+	case []Destination:
+		for _, d := range v {
+			d.encode(buf)
+		}
+	case DateRange:
+		v.encode(buf)
+	case []CalendarEntry:
+		for _, entry := range v {
+			if err := entry.encode(buf); err != nil {
+				return err
+			}
+		}
+	case dailySchedule:
+		return v.encode(buf)
+	case SpecialEvent:
+		return v.encode(buf)
+	case []interface{}:
+		for _, element := range v {
+			if err := encodeApplicationValue(buf, element); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode value %v of type %T", value, value)
+	}
+	return nil
+}
+
+// WriteProperty writes value to a property of an object. priority is the command priority (1-16)
+// for commandable properties; pass 0 to write without a priority.
+func (c *BACnetClient) WriteProperty(device DeviceInfo, object BACnetObject, propertyID uint32, value interface{}, priority uint8, opts ...CallOption) error {
+	return c.writeProperty(callContext(opts...), device, object, propertyID, nil, value, priority)
+}
+
+func (c *BACnetClient) writeProperty(ctx context.Context, device DeviceInfo, object BACnetObject, propertyID uint32, arrayIndex *uint32, value interface{}, priority uint8) (err error) {
+	if priority > 16 {
+		return fmt.Errorf("invalid write priority %d", priority)
+	}
+	mutation := Mutation{
+		Service:    SERVICE_CONFIRMED_WRITE_PROPERTY,
+		Device:     device,
+		Object:     &object,
+		PropertyID: &propertyID,
+		ArrayIndex: arrayIndex,
+		Value:      value,
+		Priority:   priority,
+	}
+	if send, err := c.authorize(ctx, mutation); !send {
+		return err
+	}
+
+	var params bytes.Buffer
+	encoding.EncodeContextObjectID(&params, 0, uint32(object.Type), object.Instance)
+	encoding.EncodeContextEnumerated(&params, 1, propertyID)
+	if arrayIndex != nil {
+		encoding.EncodeContextUnsigned(&params, 2, *arrayIndex)
+	}
+	encoded, err := c.encodeText(value)
+	if err != nil {
+		return err
+	}
+	encoding.EncodeOpeningTag(&params, 3)
+	if err := encodeApplicationValue(&params, encoded); err != nil {
+		return err
+	}
+	encoding.EncodeClosingTag(&params, 3)
+	if priority != 0 {
+		encoding.EncodeContextUnsigned(&params, 4, uint32(priority))
+	}
+
+	if c.options.AuditStore != nil {
+		audit := c.newWriteAudit(ctx, device, object, propertyID, arrayIndex, value, priority)
+		defer func() { c.auditWrite(audit, err) }()
+	}
+
+	_, data, err := c.transact(ctx, device, SERVICE_CONFIRMED_WRITE_PROPERTY, params.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := expectSimpleAck(data); err != nil {
+		return fmt.Errorf("WriteProperty %s of %s failed: %w", propertyLabel(propertyID), object, err)
+	}
+	return nil
+}
+
+// CreateObject creates an object of the given type on a device and returns its identifier.
+// If instance is nil the device picks the instance number. initialValues are set atomically with
+// the creation; the device rejects the request if any of them cannot be written.
+func (c *BACnetClient) CreateObject(device DeviceInfo, objectType ObjectType, instance *uint32, initialValues []BACnetPropertyValue, opts ...CallOption) (BACnetObject, error) {
+	ctx := callContext(opts...)
+	requested := BACnetObject{Type: objectType, Instance: 0x3FFFFF}
+	if instance != nil {
+		requested.Instance = *instance
+	}
+	if send, err := c.authorize(ctx, Mutation{Service: SERVICE_CONFIRMED_CREATE_OBJECT, Device: device, Object: &requested}); err != nil {
+		return BACnetObject{}, err
+	} else if !send {
+		return requested, nil
+	}
+
+	var params bytes.Buffer
+	// Object Specifier
+	encoding.EncodeOpeningTag(&params, 0)
+	if instance != nil {
+		encoding.EncodeContextObjectID(&params, 1, uint32(objectType), *instance)
+	} else {
+		encoding.EncodeContextEnumerated(&params, 0, uint32(objectType))
+	}
+	encoding.EncodeClosingTag(&params, 0)
+
+	// List of Initial Values
+	if len(initialValues) > 0 {
+		encoding.EncodeOpeningTag(&params, 1)
+		for _, pv := range initialValues {
+			encoding.EncodeContextEnumerated(&params, 0, pv.PropertyID)
+			value, err := c.encodeText(pv.Value)
+			if err != nil {
+				return BACnetObject{}, fmt.Errorf("initial value of %s: %w", propertyLabel(pv.PropertyID), err)
+			}
+			encoding.EncodeOpeningTag(&params, 2)
+			if err := encodeApplicationValue(&params, value); err != nil {
+				return BACnetObject{}, fmt.Errorf("initial value of %s: %w", propertyLabel(pv.PropertyID), err)
+			}
+			encoding.EncodeClosingTag(&params, 2)
+		}
+		encoding.EncodeClosingTag(&params, 1)
+	}
+
+	_, data, err := c.transact(ctx, device, SERVICE_CONFIRMED_CREATE_OBJECT, params.Bytes())
+	if err != nil {
+		return BACnetObject{}, err
+	}
+	apdu, err := apduFromPacket(data)
+	if err != nil {
+		return BACnetObject{}, err
+	}
+	header, serviceData, err := decodeComplexAck(apdu)
+	if err != nil {
+		return BACnetObject{}, fmt.Errorf("CreateObject failed: %w", err)
+	}
+	if header.service != SERVICE_CONFIRMED_CREATE_OBJECT {
+		return BACnetObject{}, fmt.Errorf("not a CreateObject ACK, got 0x%x", header.service)
+	}
+
+	value, err := decodeApplicationValue(bytes.NewReader(serviceData))
+	if err != nil {
+		return BACnetObject{}, fmt.Errorf("error decoding CreateObject-ACK: %w", err)
+	}
+	created, ok := value.(BACnetObject)
+	if !ok {
+		return BACnetObject{}, fmt.Errorf("unexpected value %v in CreateObject-ACK", value)
+	}
+	return created, nil
+}
+
+// DeleteObject deletes an object from a device.
+func (c *BACnetClient) DeleteObject(device DeviceInfo, object BACnetObject, opts ...CallOption) error {
+	ctx := callContext(opts...)
+	if send, err := c.authorize(ctx, Mutation{Service: SERVICE_CONFIRMED_DELETE_OBJECT, Device: device, Object: &object}); !send {
+		return err
+	}
+
+	var params bytes.Buffer
+	encoding.EncodeApplicationObjectID(&params, uint32(object.Type), object.Instance)
+
+	_, data, err := c.transact(ctx, device, SERVICE_CONFIRMED_DELETE_OBJECT, params.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := expectSimpleAck(data); err != nil {
+		return fmt.Errorf("DeleteObject %s failed: %w", object, err)
+	}
+	return nil
+}
+
+// expectSimpleAck checks that a response datagram carries a Simple-ACK.
+func expectSimpleAck(data []byte) error {
+	apdu, err := apduFromPacket(data)
+	if err != nil {
+		return err
+	}
+	if apdu[0]&0xF0 == APDU_SIMPLE_ACK {
+		return nil
+	}
+	if err := pduError(apdu); err != nil {
+		return err
+	}
+	return fmt.Errorf("not a Simple-ACK, got %x", apdu[0])
+}
+
+// propertyLabel returns the name of a property for messages, or its number if it has none.
+func propertyLabel(propertyID uint32) string {
+	if name, ok := PropertyName(propertyID); ok {
+		return name
+	}
+	return fmt.Sprintf("property %d", propertyID)
+}
+
+// objectTypeLabel returns the name of an object type for messages, or its number if it has none.
+func objectTypeLabel(objectType ObjectType) string {
+	if name, ok := ObjectTypeName(objectType); ok {
+		return name
+	}
+	return fmt.Sprintf("object type %d", objectType)
+}
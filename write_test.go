@@ -0,0 +1,98 @@
+package bacnet
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/maxzerker/bacnet/encoding"
+)
+
+// roundTrip encodes value with encodeApplicationValue and decodes it again with
+// decodeApplicationValue.
+func roundTrip(t *testing.T, value interface{}) interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encodeApplicationValue(&buf, value); err != nil {
+		t.Fatalf("encode %#v: %v", value, err)
+	}
+	r := bytes.NewReader(buf.Bytes())
+	got, err := decodeApplicationValue(r)
+	if err != nil {
+		t.Fatalf("decode %x: %v", buf.Bytes(), err)
+	}
+	if r.Len() != 0 {
+		t.Fatalf("decode %x left %d octets", buf.Bytes(), r.Len())
+	}
+	return got
+}
+
+func TestApplicationValueSymmetry(t *testing.T) {
+	checks := map[string]interface{}{
+		"bool":     func(v bool) bool { return roundTrip(t, v) == v },
+		"unsigned": func(v uint32) bool { return roundTrip(t, v) == v },
+		"signed":   func(v int32) bool { return roundTrip(t, v) == v },
+		"real": func(v float32) bool {
+			got, ok := roundTrip(t, v).(float32)
+			return ok && math.Float32bits(got) == math.Float32bits(v)
+		},
+		"double": func(v float64) bool {
+			got, ok := roundTrip(t, v).(float64)
+			return ok && math.Float64bits(got) == math.Float64bits(v)
+		},
+		"string": func(v string) bool { return roundTrip(t, v) == v },
+		"octets": func(v []byte) bool {
+			got, ok := roundTrip(t, v).([]byte)
+			return ok && bytes.Equal(got, v)
+		},
+		// Enumerated values decode as plain uint32, the datatype is not carried in the tag.
+		"enumerated": func(v uint32) bool { return roundTrip(t, Enumerated(v)) == v },
+		"date": func(year, month, day, weekday uint8) bool {
+			v := Date{Year: year, Month: month, Day: day, Weekday: weekday}
+			return roundTrip(t, v) == v
+		},
+		"time": func(hour, minute, second, hundredths uint8) bool {
+			v := Time{Hour: hour, Minute: minute, Second: second, Hundredths: hundredths}
+			return roundTrip(t, v) == v
+		},
+		"object": func(objectType uint16, instance uint32) bool {
+			v := BACnetObject{Type: ObjectType(objectType & 0x3FF), Instance: instance & 0x3FFFFF}
+			return roundTrip(t, v) == v
+		},
+		"status flags": func(inAlarm, fault, overridden, outOfService bool) bool {
+			v := StatusFlags{InAlarm: inAlarm, Fault: fault, Overridden: overridden, OutOfService: outOfService}
+			return roundTrip(t, v) == v
+		},
+		"charset": func(v string) bool {
+			s, err := NewCharacterString(v, encoding.CharsetUCS2)
+			if err != nil {
+				return true
+			}
+			return roundTrip(t, s) == v
+		},
+	}
+	for name, f := range checks {
+		if err := quick.Check(f, nil); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if got := roundTrip(t, nil); got != nil {
+		t.Errorf("null decoded as %#v", got)
+	}
+}
+
+func TestApplicationValueBitStringSymmetry(t *testing.T) {
+	f := func(bits []bool) bool {
+		if len(bits) == 4 {
+			return true // Four-bit strings decode as StatusFlags
+		}
+		got, ok := roundTrip(t, bits).([]bool)
+		return ok && reflect.DeepEqual(got, append([]bool{}, bits...))
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}